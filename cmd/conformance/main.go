@@ -0,0 +1,53 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/carbynestack/ephemeral/pkg/conformance"
+)
+
+// Runs the conformance suite against ephemeral deployments and writes a JSON report. Exits with 1 if a case fails.
+func main() {
+	targets := flag.String("targets", "", "comma-separated base URLs of the ephemeral services to verify, e.g., http://ephemeral.default.example.com")
+	token := flag.String("token", "", "the bearer token sent with the requests, an unsigned token is used if empty")
+	timeout := flag.Duration("timeout", conformance.DefaultTimeout, "the time to wait for a response")
+	output := flag.String("output", "", "the file the report is written to, stdout if empty")
+	version := flag.Bool("version", false, "prints the version of the conformance suite")
+	flag.Parse()
+	if *version {
+		fmt.Println(conformance.Version)
+		return
+	}
+	if *targets == "" {
+		fmt.Fprintln(os.Stderr, "at least one target must be given")
+		os.Exit(2)
+	}
+	runner := &conformance.Runner{
+		Token:   *token,
+		Timeout: *timeout,
+	}
+	report := runner.Run(strings.Split(*targets, ","), conformance.Cases())
+	body, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error encoding the report: %s\n", err)
+		os.Exit(2)
+	}
+	if *output == "" {
+		fmt.Println(string(body))
+	} else if err := ioutil.WriteFile(*output, body, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing the report: %s\n", err)
+		os.Exit(2)
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -6,21 +6,40 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"github.com/carbynestack/ephemeral/pkg/busmetrics"
+	"github.com/carbynestack/ephemeral/pkg/depcheck"
 	"github.com/carbynestack/ephemeral/pkg/discovery"
 	c "github.com/carbynestack/ephemeral/pkg/discovery/transport/client"
 	cl "github.com/carbynestack/ephemeral/pkg/discovery/transport/client"
 	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
 	proto "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
+	"github.com/carbynestack/ephemeral/pkg/discovery/transport/security"
 	"github.com/carbynestack/ephemeral/pkg/discovery/transport/server"
 	l "github.com/carbynestack/ephemeral/pkg/logger"
+	"github.com/carbynestack/ephemeral/pkg/portplan"
 	"github.com/carbynestack/ephemeral/pkg/types"
 	. "github.com/carbynestack/ephemeral/pkg/types"
 	"github.com/carbynestack/ephemeral/pkg/utils"
 	mb "github.com/vardius/message-bus"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"syscall"
 	"time"
 )
 
@@ -32,26 +51,58 @@ const (
 	// DefaultPortRange is the range of ports used for MCP communication between the players.
 	DefaultPortRange      = "30000:30100"
 	defaultConfigLocation = "/etc/config/config.json"
+	// busMetricsInterval is the interval the metrics of the message bus are logged in.
+	busMetricsInterval = time.Minute
+	// configReloadInterval is the interval the configuration file is checked for changes in.
+	configReloadInterval = 10 * time.Second
 )
 
 func main() {
+	validate := flag.Bool("validate-config", false, "validates the configuration and reports all problems found")
+	flag.Parse()
+	if *validate {
+		os.Exit(RunConfigValidation(defaultConfigLocation))
+	}
 	config, err := ParseConfig(defaultConfigLocation)
 	if err != nil {
 		panic(err)
 	}
-	logger, err := l.NewDevelopmentLogger()
+	level := zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	logger, err := l.NewDevelopmentLoggerAt(level)
+	if err != nil {
+		panic(err)
+	}
+	logLevel, err := l.ParseLevel(config.LogLevel)
 	if err != nil {
 		panic(err)
 	}
+	level.SetLevel(logLevel)
 	SetDefaults(config)
-	logger.Infof("Starting with the config %v", config)
-	bus := mb.New(config.BusSize)
-	tr := NewTransportServer(logger, config.Port)
+	plan, err := NewPortPlan(config)
+	logger.Infof("Port allocation:\n%s", plan)
+	if err != nil {
+		panic(err)
+	}
+	dependencies := depcheck.NewChecker("discovery", config, DependencyChecks(config))
+	dependencies.Run().Log(logger)
+	busMetrics := busmetrics.New(config.BusStallThreshold)
+	bus := busMetrics.Instrument(mb.New(config.BusSize), logger)
+	go busMetrics.Report(logger, busMetricsInterval, nil)
+	// Slaves forget the games which got stuck once the janitor evicts them.
+	membershipTTL := config.StateTimeout + config.ComputationTimeout + config.GameRetention
+	tr, err := NewTransportServer(logger, config.Port, config.TLS, config.Auth, config.EventNamespaces, membershipTTL)
+	if err != nil {
+		panic(err)
+	}
 	pb := discovery.NewPublisher(bus)
 	doneCh := make(chan string)
 	errCh := make(chan error, 1)
 
-	n, err := discovery.NewIstioNetworker(logger, config.PortRange, doneCh)
+	ports, err := NewPortPools(config)
+	if err != nil {
+		panic(err)
+	}
+	n, err := NewNetworker(config, logger, ports, doneCh)
 	if err != nil {
 		panic(err)
 	}
@@ -61,14 +112,22 @@ func main() {
 			Host:           config.MasterHost,
 			Port:           config.MasterPort,
 			ConnectTimeout: config.ConnectTimeout,
+			TLS:            config.MasterTLS,
+			Token:          config.MasterToken,
+			EventNamespace: config.EventNamespace,
+			Fallbacks:      config.BackupMasters,
 		}
 	}
 	client, mode, err := NewClient(upstreamConfig, logger, errCh)
 	if err != nil {
 		panic(err)
 	}
+	store, err := NewStateStore(config.StateStore)
+	if err != nil {
+		panic(err)
+	}
 	// TODO: extract this Istio address dynamically.
-	s := discovery.NewServiceNG(bus, pb, config.StateTimeout, config.ComputationTimeout, tr, n, config.FrontendURL, logger, mode, client, config.PlayerCount)
+	s := discovery.NewServiceNG(bus, pb, config.StateTimeout, config.ComputationTimeout, tr, n, config.FrontendURL, logger, mode, client, config.PlayerCount, store)
 	if err != nil {
 		panic(err)
 	}
@@ -77,7 +136,38 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	if err = s.SetPortReuse(config.PortReuse); err != nil {
+		panic(err)
+	}
+	if err = RestoreCheckpoint(s, config.CheckpointPath, logger); err != nil {
+		panic(err)
+	}
+	if config.AdminPort != "" {
+		go ServeAdmin(config.AdminPort, dependencies, s, n, logger)
+	}
+	go s.RunJanitor(config.GameRetention, nil)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		sig := <-signals
+		logger.Infow("Shutting down", "Signal", sig)
+		if err := Shutdown(s, config.CheckpointPath); err != nil {
+			logger.Errorw("Failed to checkpoint the state of the service", "Error", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}()
 	go RunDeletion(doneCh, errCh, logger, s)
+	reloader := &ConfigReloader{Current: config, Service: s, Networker: n, Level: level, Logger: logger}
+	watcher := &utils.FileWatcher{
+		Path:     defaultConfigLocation,
+		Interval: configReloadInterval,
+		OnChange: reloader.Reload,
+		OnError: func(err error) {
+			logger.Warnw("Failed to read the configuration", "Error", err)
+		},
+	}
+	go watcher.Run(nil)
 	if err = s.Start(); err != nil {
 		errCh <- err
 	}
@@ -85,13 +175,19 @@ func main() {
 
 // NewClient returns a new client with parameters specific to the server mode. If upstreamClient is defined, the client
 // will be configured to forward incoming events to an upstream master server. With upstreamClient set to nil, the
-// service is considered to be the master service.
+// service is considered to be the master service. Slaves with backup masters fail over to the next reachable master
+// once the connection to the active one breaks.
 func NewClient(upstreamConfig *types.DiscoveryClientTypedConfig, logger *zap.SugaredLogger, errCh chan error) (*cl.Client, string, error) {
 	logger.Debug("Creating new discovery client")
 	mode := ModeMaster
 	client := &cl.Client{}
 	var err error
 	if upstreamConfig != nil { // If Follower/Slave -> Open GRPc Connection to Master
+		var connID string
+		connID, err = slaveConnID()
+		if err != nil {
+			return nil, "", err
+		}
 		inCh := make(chan *proto.Event)
 		outCh := make(chan *proto.Event)
 		grpcClientConf := &c.TransportClientConfig{
@@ -101,11 +197,20 @@ func NewClient(upstreamConfig *types.DiscoveryClientTypedConfig, logger *zap.Sug
 			Host:           upstreamConfig.Host,
 			Port:           upstreamConfig.Port,
 			EventScope:     EventScopeAll,
-			ConnID:         "slave",
+			Namespace:      upstreamConfig.EventNamespace,
+			Fallbacks:      upstreamConfig.Fallbacks,
+			ConnID:         connID,
 			ConnectTimeout: upstreamConfig.ConnectTimeout,
+			TLS:            upstreamConfig.TLS,
+			Token:          upstreamConfig.Token,
+			Backoff:        c.NewBackoff(upstreamConfig.ReconnectTimeout),
 			Logger:         logger,
 			Context:        context.Background(),
 		}
+		if len(upstreamConfig.Fallbacks) > 0 {
+			grpcClientConf.Health = c.NewEndpointHealth(masterCoolDown)
+			grpcClientConf.Failover = true
+		}
 		client, err = c.NewClient(grpcClientConf)
 		if err != nil {
 			return nil, "", err
@@ -115,8 +220,22 @@ func NewClient(upstreamConfig *types.DiscoveryClientTypedConfig, logger *zap.Sug
 	return client, mode, nil
 }
 
-// NewTransportServer returns a gRPC transport server.
-func NewTransportServer(logger *zap.SugaredLogger, port string) *server.TransportServer {
+// podNameEnv is the environment variable the downward API exposes the name of the pod in, if configured.
+const podNameEnv = "POD_NAME"
+
+// slaveConnID returns the connection ID the slave connects to the master with, i.e., the name of its pod. The master
+// keeps track of the games of each slave by it.
+func slaveConnID() (string, error) {
+	if name := os.Getenv(podNameEnv); name != "" {
+		return name, nil
+	}
+	return os.Hostname()
+}
+
+// NewTransportServer returns a gRPC transport server. The connections of the clients are secured with TLS if tls is
+// not nil and the clients are authenticated if auth is not nil. Clients are restricted to the given event namespaces,
+// if any. Slaves stop receiving the events of a game no event was seen of for longer than the given TTL.
+func NewTransportServer(logger *zap.SugaredLogger, port string, tls *TLSConfig, auth *AuthConfig, namespaces []string, membershipTTL time.Duration) (*server.TransportServer, error) {
 	serverIn := make(chan *pb.Event)
 	serverOut := make(chan *pb.Event)
 	serverErr := make(chan error)
@@ -126,6 +245,12 @@ func NewTransportServer(logger *zap.SugaredLogger, port string) *server.Transpor
 		ErrCh:  serverErr,
 		Logger: logger,
 		Port:   port,
+		TLS:    tls,
+		Auth:   auth,
+		// Events not acknowledged by slow clients are re-delivered.
+		RedeliveryInterval: server.DefaultRedeliveryInterval,
+		Namespaces:         namespaces,
+		MembershipTTL:      membershipTTL,
 	}
 	return server.NewTransportServer(grpcServerConf)
 }
@@ -143,17 +268,287 @@ func RunDeletion(doneCh chan string, errCh chan error, logger *zap.SugaredLogger
 	}
 }
 
+// RestoreCheckpoint resumes the service from the checkpoint at the given path, if any. The checkpoint is removed
+// afterwards, so that an outdated state is not restored once the service restarts after a crash.
+func RestoreCheckpoint(s *discovery.ServiceNG, path string, logger *zap.SugaredLogger) error {
+	if path == "" {
+		return nil
+	}
+	cp, err := discovery.ReadCheckpoint(path)
+	if err != nil {
+		return fmt.Errorf("error reading the checkpoint: %v", err)
+	}
+	if cp == nil {
+		return nil
+	}
+	if err := s.Restore(cp); err != nil {
+		return fmt.Errorf("error restoring the checkpoint: %v", err)
+	}
+	logger.Infow("Restored the state of the service", "Games", len(cp.Games))
+	return os.Remove(path)
+}
+
+// Shutdown stops the transport server and persists the state of the service to the given path, if any.
+func Shutdown(s *discovery.ServiceNG, path string) error {
+	s.Stop()
+	if path == "" {
+		return nil
+	}
+	cp, err := s.Checkpoint()
+	if err != nil {
+		return err
+	}
+	return discovery.WriteCheckpoint(path, cp)
+}
+
+// Types of the state stores the discovery service can keep its bookkeeping in.
+const (
+	StateStoreMemory = "memory"
+	StateStoreEtcd   = "etcd"
+	StateStoreRedis  = "redis"
+)
+
+// defaultStateStoreTimeout is the maximum duration of a single request to the state store.
+const defaultStateStoreTimeout = 5 * time.Second
+
+// masterCoolDown is the duration a master is tried last for once the connection to it broke.
+const masterCoolDown = time.Minute
+
+const (
+	// readinessPath is the path the readiness of the service is served on.
+	readinessPath = "/ready"
+	// dependenciesPath is the path the report of the dependency checks is served on.
+	dependenciesPath = "/admin/dependencies"
+	// metricsPath is the path the counters of the evicted games are served on.
+	metricsPath = "/admin/metrics"
+	// releasePath is the path the network controller releases the ports of deleted networks on.
+	releasePath = "/admin/networks/release"
+	// dependencyDialTimeout is the maximum duration of connecting to a dependency if no connect timeout is configured.
+	dependencyDialTimeout = 5 * time.Second
+)
+
+// DependencyChecks returns the checks of the dependencies of the discovery service defined by the configuration, i.e.,
+// the master for slaves, the state store and the directory of the checkpoint.
+func DependencyChecks(conf *DiscoveryTypedConfig) []depcheck.Check {
+	timeout := conf.ConnectTimeout
+	if timeout <= 0 {
+		timeout = dependencyDialTimeout
+	}
+	var checks []depcheck.Check
+	if conf.Slave {
+		checks = append(checks, depcheck.Reachable("master", net.JoinHostPort(conf.MasterHost, conf.MasterPort), timeout))
+	}
+	switch conf.StateStore.Type {
+	case StateStoreEtcd:
+		checks = append(checks, depcheck.Reachable("stateStore", etcdAddress(conf.StateStore.Endpoint), timeout))
+	case StateStoreRedis:
+		checks = append(checks, depcheck.Reachable("stateStore", conf.StateStore.Endpoint, timeout))
+	}
+	if conf.CheckpointPath != "" {
+		checks = append(checks, depcheck.Writable("checkpoint", filepath.Dir(conf.CheckpointPath)))
+	}
+	return checks
+}
+
+// JanitorStatsProvider provides the counters of the games evicted by the service.
+type JanitorStatsProvider interface {
+	JanitorStats() discovery.JanitorStats
+}
+
+// PortReleaser returns the ports of the networks deleted by others to their pools.
+type PortReleaser interface {
+	ReleasePort(pod string)
+}
+
+// ServeAdmin serves the readiness of the service, the report of the dependency checks and the counters of the evicted
+// games on the given port, and releases the ports of the networks deleted by the network controller. The service is
+// ready once all dependencies are available.
+func ServeAdmin(port string, dependencies *depcheck.Checker, janitor JanitorStatsProvider, networks PortReleaser, logger *zap.SugaredLogger) {
+	mux := http.NewServeMux()
+	mux.Handle(dependenciesPath, dependencies)
+	mux.Handle(releasePath, ReleaseHandler(networks, logger))
+	mux.HandleFunc(metricsPath, func(writer http.ResponseWriter, req *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(writer).Encode(janitor.JanitorStats()); err != nil {
+			logger.Errorw("Failed to write the metrics", "Error", err)
+		}
+	})
+	mux.HandleFunc(readinessPath, func(writer http.ResponseWriter, req *http.Request) {
+		if len(dependencies.Last().Failed()) > 0 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+	})
+	if err := http.ListenAndServe(":"+port, mux); err != nil {
+		logger.Errorw("Failed to serve the admin endpoints", "Error", err)
+	}
+}
+
+// ReleaseHandler releases the port of the network named by the "name" query parameter of POST requests. The networks
+// are named after the pods they were created for.
+func ReleaseHandler(networks PortReleaser, logger *zap.SugaredLogger) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			writer.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		name := req.URL.Query().Get("name")
+		if name == "" {
+			http.Error(writer, "missing name of the network", http.StatusBadRequest)
+			return
+		}
+		logger.Debugw("Releasing the port of the deleted network", "Network", name)
+		networks.ReleasePort(name)
+		writer.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// NewStateStore returns the state store defined by the configuration. The state is kept in memory if no type is set.
+func NewStateStore(conf StateStoreConfig) (discovery.StateStore, error) {
+	timeout := defaultStateStoreTimeout
+	if conf.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(conf.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid state store timeout format: %v", err)
+		}
+	}
+	if conf.Type == "" || conf.Type == StateStoreMemory {
+		return discovery.NewMemoryStateStore(), nil
+	}
+	password := conf.Password
+	if conf.PasswordFile != "" {
+		data, err := ioutil.ReadFile(conf.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the password of the state store: %v", err)
+		}
+		password = strings.TrimSpace(string(data))
+	}
+	var tlsConf *tls.Config
+	if conf.TLS != nil {
+		var err error
+		tlsConf, err = security.ClientTLSConfig(conf.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS configuration of the state store: %v", err)
+		}
+	}
+	switch conf.Type {
+	case StateStoreEtcd:
+		return discovery.NewEtcdStateStore(conf.Endpoint, conf.Prefix, conf.Username, password, tlsConf, timeout)
+	case StateStoreRedis:
+		if conf.Username != "" {
+			return nil, errors.New("the redis state store does not support a username")
+		}
+		return discovery.NewRedisStateStore(conf.Endpoint, conf.Prefix, password, tlsConf, timeout)
+	}
+	return nil, fmt.Errorf("unsupported state store type %q", conf.Type)
+}
+
+// etcdAddress returns the address of the given etcd endpoint, which may be given as a URL, e.g.,
+// "https://etcd:2379".
+func etcdAddress(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return endpoint
+}
+
 // ParseConfig parses the configuration file of the discovery service.
 func ParseConfig(path string) (*DiscoveryTypedConfig, error) {
 	bytes, err := utils.ReadFile(path)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	return parseConfig(bytes)
+}
+
+// defaultValidationDialTimeout is the maximum duration of connecting to the master when validating the configuration
+// if no connect timeout is configured.
+const defaultValidationDialTimeout = 5 * time.Second
+
+// RunConfigValidation validates the configuration file at the given path and returns the exit code of the process,
+// i.e., 0 if the configuration is valid and 1 otherwise. All problems found are written to stderr.
+func RunConfigValidation(path string) int {
+	errs := ValidateConfig(path)
+	if len(errs) == 0 {
+		fmt.Println("The configuration is valid")
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "The configuration is invalid, %d problem(s) found:\n", len(errs))
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "  - %v\n", err)
+	}
+	return 1
+}
+
+// ValidateConfig parses the configuration file at the given path like the service does on startup. In addition, the
+// port ranges and the state store are verified and, in slave mode, the reachability of the master. All problems found
+// are returned.
+func ValidateConfig(path string) []error {
+	conf, err := ParseConfig(path)
+	if err != nil {
+		return []error{fmt.Errorf("error reading the configuration: %v", err)}
+	}
+	SetDefaults(conf)
+	var errs []error
+	if _, err := l.ParseLevel(conf.LogLevel); err != nil {
+		errs = append(errs, err)
+	}
+	// The port pools are only created for a consistent port plan, as they reject overlapping ranges as well.
+	if _, err := NewPortPlan(conf); err != nil {
+		errs = append(errs, err)
+	} else if _, err := NewPortPools(conf); err != nil {
+		errs = append(errs, err)
+	}
+	switch conf.StateStore.Type {
+	case "", StateStoreMemory:
+	case StateStoreEtcd, StateStoreRedis:
+		if conf.StateStore.Endpoint == "" {
+			errs = append(errs, fmt.Errorf("the %s state store requires an endpoint", conf.StateStore.Type))
+		}
+		if conf.StateStore.Type == StateStoreRedis && conf.StateStore.Username != "" {
+			errs = append(errs, errors.New("the redis state store does not support a username"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unsupported state store type %q", conf.StateStore.Type))
+	}
+	if conf.StateStore.Timeout != "" {
+		if _, err := time.ParseDuration(conf.StateStore.Timeout); err != nil {
+			errs = append(errs, fmt.Errorf("invalid state store timeout format: %v", err))
+		}
+	}
+	if conf.Slave {
+		addr := net.JoinHostPort(conf.MasterHost, conf.MasterPort)
+		timeout := conf.ConnectTimeout
+		if timeout <= 0 {
+			timeout = defaultValidationDialTimeout
+		}
+		conn, err := net.DialTimeout("tcp", addr, timeout)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("the master discovery service at %s is not reachable: %v", addr, err))
+		} else {
+			conn.Close()
+		}
+	}
+	return errs
+}
+
+// envPrefix is the prefix of the environment variables overriding the settings of the configuration file, e.g.,
+// EPHEMERAL_PLAYER_COUNT.
+const envPrefix = "EPHEMERAL"
+
+// parseConfig parses the content of the configuration file of the discovery service. Settings are overridden by the
+// environment variables named after them, see utils.ApplyEnvOverrides.
+func parseConfig(bytes []byte) (*DiscoveryTypedConfig, error) {
 	var conf DiscoveryConfig
-	err = json.Unmarshal(bytes, &conf)
+	err := json.Unmarshal(bytes, &conf)
 	if err != nil {
 		return nil, err
 	}
+	if err := utils.ApplyEnvOverrides(&conf, envPrefix, os.LookupEnv); err != nil {
+		return nil, err
+	}
 	if conf.FrontendURL == "" {
 		return nil, errors.New("missing config error, FrontendURL must be defined")
 	}
@@ -163,6 +558,21 @@ func ParseConfig(path string) (*DiscoveryTypedConfig, error) {
 	if conf.MasterPort == "" {
 		return nil, errors.New("missing config error, MasterPort must be defined")
 	}
+	switch conf.PortReuse {
+	case "", discovery.PortReusePod, discovery.PortReuseRefCount:
+	default:
+		return nil, fmt.Errorf("invalid config error, unsupported port reuse policy %q", conf.PortReuse)
+	}
+	switch conf.Networker {
+	case "", discovery.NetworkerIstio, discovery.NetworkerService:
+	default:
+		return nil, fmt.Errorf("invalid config error, unsupported networker %q", conf.Networker)
+	}
+	for _, m := range conf.BackupMasters {
+		if m.Host == "" || m.Port == "" {
+			return nil, errors.New("missing config error, BackupMasters must define host and port")
+		}
+	}
 	if conf.PlayerCount == 0 {
 		return nil, errors.New("missing config error, PlayerCount must be defined")
 	}
@@ -181,21 +591,147 @@ func ParseConfig(path string) (*DiscoveryTypedConfig, error) {
 	if err != nil {
 		return nil, errors.New(fmt.Sprintf("invalid connection timeout format: %v", err))
 	}
+	if err := normalizePortRanges(&conf); err != nil {
+		return nil, err
+	}
+	var busStallThreshold time.Duration
+	if conf.BusStallThreshold != "" {
+		busStallThreshold, err = time.ParseDuration(conf.BusStallThreshold)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid bus stall threshold format: %v", err))
+		}
+	}
+	var gameRetention time.Duration
+	if conf.GameRetention != "" {
+		gameRetention, err = time.ParseDuration(conf.GameRetention)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid game retention format: %v", err))
+		}
+	}
 	return &DiscoveryTypedConfig{
-		FrontendURL:        conf.FrontendURL,
-		MasterHost:         conf.MasterHost,
-		MasterPort:         conf.MasterPort,
-		Slave:              conf.Slave,
-		StateTimeout:       stateTimeout,
-		ComputationTimeout: computationTimeout,
-		ConnectTimeout:     connectTimeout,
-		Port:               conf.Port,
-		BusSize:            conf.BusSize,
-		PortRange:          conf.PortRange,
-		PlayerCount:        conf.PlayerCount,
+		FrontendURL:         conf.FrontendURL,
+		MasterHost:          conf.MasterHost,
+		MasterPort:          conf.MasterPort,
+		Slave:               conf.Slave,
+		StateTimeout:        stateTimeout,
+		ComputationTimeout:  computationTimeout,
+		ConnectTimeout:      connectTimeout,
+		Port:                conf.Port,
+		BusSize:             conf.BusSize,
+		PortRange:           conf.PortRange,
+		PlayerCount:         conf.PlayerCount,
+		PortRanges:          conf.PortRanges,
+		NamespacePortRanges: conf.NamespacePortRanges,
+		CheckpointPath:      conf.CheckpointPath,
+		StateStore:          conf.StateStore,
+		TLS:                 conf.TLS,
+		MasterTLS:           conf.MasterTLS,
+		Auth:                conf.Auth,
+		MasterToken:         conf.MasterToken,
+		BusStallThreshold:   busStallThreshold,
+		LogLevel:            conf.LogLevel,
+		AdminPort:           conf.AdminPort,
+		GameRetention:       gameRetention,
+		EventNamespace:      conf.EventNamespace,
+		EventNamespaces:     conf.EventNamespaces,
+		BackupMasters:       conf.BackupMasters,
+		PortAllocation:      conf.PortAllocation,
+		PortReuse:           conf.PortReuse,
+		Networker:           conf.Networker,
 	}, nil
 }
 
+// TimeoutSetter changes the timeouts of the games started afterwards.
+type TimeoutSetter interface {
+	SetTimeouts(stateTimeout time.Duration, computationTimeout time.Duration)
+}
+
+// PortPoolSetter replaces the pools the ports of new networks are assigned from.
+type PortPoolSetter interface {
+	SetPortPools(ports *discovery.PortPools) error
+}
+
+// RunnableNetworker is a networker which is started once the service is set up and whose port pools are replaced on
+// configuration changes.
+type RunnableNetworker interface {
+	discovery.Networker
+	PortPoolSetter
+	PortReleaser
+	Run() error
+}
+
+// NewNetworker creates the networker configured for exposing the players, see DiscoveryConfig.Networker.
+func NewNetworker(conf *DiscoveryTypedConfig, logger *zap.SugaredLogger, ports *discovery.PortPools, delCh chan string) (RunnableNetworker, error) {
+	switch conf.Networker {
+	case "", discovery.NetworkerIstio:
+		return discovery.NewIstioNetworker(logger, ports, delCh)
+	case discovery.NetworkerService:
+		return discovery.NewServiceNetworker(logger, ports, delCh)
+	default:
+		return nil, fmt.Errorf("unsupported networker %q", conf.Networker)
+	}
+}
+
+// ConfigReloader applies changes of the configuration file to the running service. The timeouts of the games, the port
+// ranges and the log level are changed, other changes require a restart and are ignored.
+type ConfigReloader struct {
+	Current   *DiscoveryTypedConfig
+	Service   TimeoutSetter
+	Networker PortPoolSetter
+	Level     zap.AtomicLevel
+	Logger    *zap.SugaredLogger
+}
+
+// Reload applies the given content of the configuration file. The configuration is left as is if the content is
+// invalid.
+func (r *ConfigReloader) Reload(content []byte) {
+	conf, err := parseConfig(content)
+	if err != nil {
+		r.Logger.Errorw("Ignoring the invalid configuration", "Error", err)
+		return
+	}
+	SetDefaults(conf)
+	level, err := l.ParseLevel(conf.LogLevel)
+	if err != nil {
+		r.Logger.Errorw("Ignoring the invalid configuration", "Error", err)
+		return
+	}
+	next := *r.Current
+	next.StateTimeout = conf.StateTimeout
+	next.ComputationTimeout = conf.ComputationTimeout
+	next.PortRange = conf.PortRange
+	next.PortRanges = conf.PortRanges
+	next.NamespacePortRanges = conf.NamespacePortRanges
+	next.LogLevel = conf.LogLevel
+	if rejected := utils.ChangedFields(&next, conf); len(rejected) > 0 {
+		r.Logger.Warnw("Ignoring configuration changes which require a restart", "Settings", rejected)
+	}
+	applied := utils.ChangedFields(r.Current, &next)
+	if len(applied) == 0 {
+		return
+	}
+	if next.PortRange != r.Current.PortRange || !reflect.DeepEqual(next.PortRanges, r.Current.PortRanges) ||
+		!reflect.DeepEqual(next.NamespacePortRanges, r.Current.NamespacePortRanges) {
+		if _, err := NewPortPlan(&next); err != nil {
+			r.Logger.Errorw("Ignoring the invalid configuration", "Error", err)
+			return
+		}
+		ports, err := NewPortPools(&next)
+		if err != nil {
+			r.Logger.Errorw("Ignoring the invalid configuration", "Error", err)
+			return
+		}
+		if err := r.Networker.SetPortPools(ports); err != nil {
+			r.Logger.Errorw("Failed to apply the port ranges", "Error", err)
+			return
+		}
+	}
+	r.Service.SetTimeouts(next.StateTimeout, next.ComputationTimeout)
+	r.Level.SetLevel(level)
+	r.Current = &next
+	r.Logger.Infow("Reloaded the configuration", "Settings", applied)
+}
+
 // SetDefaults sets the default values for config properties if they are not set.
 func SetDefaults(conf *DiscoveryTypedConfig) {
 	if conf.Port == "" {
@@ -204,7 +740,92 @@ func SetDefaults(conf *DiscoveryTypedConfig) {
 	if conf.BusSize == 0 {
 		conf.BusSize = DefaultBusSize
 	}
-	if conf.PortRange == "" {
+	if conf.BusStallThreshold <= 0 {
+		conf.BusStallThreshold = busmetrics.DefaultStallThreshold
+	}
+	if conf.PortRange == "" && len(conf.PortRanges) == 0 {
 		conf.PortRange = DefaultPortRange
 	}
+	if conf.GameRetention <= 0 {
+		conf.GameRetention = discovery.DefaultGameRetention
+	}
+}
+
+// normalizePortRanges brings the port ranges of the player networks into the form start_port:end_port.
+func normalizePortRanges(conf *DiscoveryConfig) error {
+	normalize := func(rng *string) error {
+		normalized, err := portplan.NormalizeRange(*rng)
+		if err != nil {
+			return fmt.Errorf("invalid port range %q: %v", *rng, err)
+		}
+		*rng = normalized
+		return nil
+	}
+	if conf.PortRange != "" {
+		if err := normalize(&conf.PortRange); err != nil {
+			return err
+		}
+	}
+	for i := range conf.PortRanges {
+		if err := normalize(&conf.PortRanges[i]); err != nil {
+			return err
+		}
+	}
+	for _, rngs := range conf.NamespacePortRanges {
+		for i := range rngs {
+			if err := normalize(&rngs[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// NewPortPlan returns the ports used by the discovery service, i.e., the ports of its APIs and the ranges the ports of
+// the player networks are assigned from. The plan is returned along with the error if the ports are in conflict, so
+// that the allocation can be reported.
+func NewPortPlan(conf *DiscoveryTypedConfig) (*portplan.Plan, error) {
+	plan := &portplan.Plan{}
+	if err := plan.AddRange("gRPC API", conf.Port); err != nil {
+		return plan, err
+	}
+	if err := plan.AddRange("admin API", conf.AdminPort); err != nil {
+		return plan, err
+	}
+	for _, rng := range append([]string{conf.PortRange}, conf.PortRanges...) {
+		if err := plan.AddRange("player networks", rng); err != nil {
+			return plan, err
+		}
+	}
+	namespaces := make([]string, 0, len(conf.NamespacePortRanges))
+	for ns := range conf.NamespacePortRanges {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	for _, ns := range namespaces {
+		for _, rng := range conf.NamespacePortRanges[ns] {
+			if err := plan.AddRange(fmt.Sprintf("player networks of namespace %s", ns), rng); err != nil {
+				return plan, err
+			}
+		}
+	}
+	return plan, plan.Validate()
+}
+
+// NewPortPools returns the pools of the port ranges defined by the config, allocating the ports by the configured
+// policy.
+func NewPortPools(conf *DiscoveryTypedConfig) (*discovery.PortPools, error) {
+	var ranges []string
+	if conf.PortRange != "" {
+		ranges = append(ranges, conf.PortRange)
+	}
+	ranges = append(ranges, conf.PortRanges...)
+	pools, err := discovery.NewPortPools(ranges, conf.NamespacePortRanges)
+	if err != nil {
+		return nil, err
+	}
+	if err := pools.SetAllocation(conf.PortAllocation, rand.NewSource(time.Now().UnixNano())); err != nil {
+		return nil, err
+	}
+	return pools, nil
 }
@@ -10,12 +10,20 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	mb "github.com/vardius/message-bus"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 
+	"github.com/carbynestack/ephemeral/pkg/busmetrics"
 	"github.com/carbynestack/ephemeral/pkg/discovery"
 	. "github.com/carbynestack/ephemeral/pkg/types"
 	"github.com/carbynestack/ephemeral/pkg/utils"
@@ -127,6 +135,29 @@ var _ = Describe("Main", func() {
 						Expect(err.Error()).To(Equal("invalid computation timeout format: time: missing unit in duration 3"))
 					})
 				})
+				Context("busStallThreshold is invalid", func() {
+					It("returns an error on invalid format", func() {
+						data := []byte(`{"frontendURL": "apollo.test.specs.cloud","masterHost": "apollo.test.specs.cloud",
+		"masterPort": "31400","slave": false, "playerCount": 2, "stateTimeout": "1s", "connectTimeout": "2s", "computationTimeout": "3s", "busStallThreshold": "5"}`)
+						err := ioutil.WriteFile(path, data, 0644)
+						Expect(err).NotTo(HaveOccurred())
+						conf, err := ParseConfig(path)
+						Expect(conf).To(BeNil())
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(Equal("invalid bus stall threshold format: time: missing unit in duration 5"))
+					})
+				})
+				Context("networker is unsupported", func() {
+					It("returns an error", func() {
+						data := []byte(`{"frontendURL": "apollo.test.specs.cloud","masterHost": "apollo.test.specs.cloud",
+		"masterPort": "31400","slave": false, "playerCount": 2, "stateTimeout": "1s", "connectTimeout": "2s", "computationTimeout": "3s", "networker": "headless"}`)
+						err := ioutil.WriteFile(path, data, 0644)
+						Expect(err).NotTo(HaveOccurred())
+						conf, err := ParseConfig(path)
+						Expect(conf).To(BeNil())
+						Expect(err).To(MatchError("invalid config error, unsupported networker \"headless\""))
+					})
+				})
 			})
 
 		})
@@ -191,23 +222,260 @@ var _ = Describe("Main", func() {
 				Expect(conf.Port).To(Equal(DefaultPort))
 				Expect(conf.BusSize).To(Equal(DefaultBusSize))
 				Expect(conf.PortRange).To(Equal(DefaultPortRange))
+				Expect(conf.BusStallThreshold).To(Equal(busmetrics.DefaultStallThreshold))
+				Expect(conf.GameRetention).To(Equal(discovery.DefaultGameRetention))
+			})
+			It("does not set the default port range if further ranges are defined", func() {
+				conf := &DiscoveryTypedConfig{PortRanges: []string{"31000:31100"}}
+				SetDefaults(conf)
+				Expect(conf.PortRange).To(BeEmpty())
+			})
+		})
+		Context("when creating the port pools", func() {
+			It("shares the port range and further ranges among the namespaces without ranges of their own", func() {
+				pools, err := NewPortPools(&DiscoveryTypedConfig{
+					PortRange:           "30000:30000",
+					PortRanges:          []string{"31000:31000"},
+					NamespacePortRanges: map[string][]string{"tenant-a": {"32000:32000"}},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pools.GetFreePort("tenant-b")).To(Equal(int32(30000)))
+				Expect(pools.GetFreePort("tenant-b")).To(Equal(int32(31000)))
+				Expect(pools.GetFreePort("tenant-a")).To(Equal(int32(32000)))
+			})
+			It("fails for overlapping ranges", func() {
+				_, err := NewPortPools(&DiscoveryTypedConfig{
+					PortRange:  "30000:30100",
+					PortRanges: []string{"30050:30150"},
+				})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+		Context("when planning the ports", func() {
+			It("lists the ports of the APIs and the player networks", func() {
+				plan, err := NewPortPlan(&DiscoveryTypedConfig{
+					Port:                "8080",
+					AdminPort:           "8081",
+					PortRange:           "30000:30100",
+					NamespacePortRanges: map[string][]string{"tenant-a": {"32000:32000"}},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(plan.String()).To(Equal("8080         gRPC API\n8081         admin API\n" +
+					"30000:30100  player networks\n32000        player networks of namespace tenant-a\n"))
+			})
+			It("fails for player networks overlapping with the APIs", func() {
+				plan, err := NewPortPlan(&DiscoveryTypedConfig{Port: "8080", PortRange: "8000:8999"})
+				Expect(err).To(MatchError("the ports 8000:8999 of the player networks overlap with the ports 8080 of the gRPC API"))
+				Expect(plan.Allocations()).To(HaveLen(2))
+			})
+			It("normalizes the port ranges", func() {
+				conf, err := parseConfig([]byte(`{"frontendURL": "apollo.test.specs.cloud", "masterPort": "31400",
+					"playerCount": 2, "stateTimeout": "1s", "connectTimeout": "2s", "computationTimeout": "3s",
+					"portRange": " 30000 : 30100", "portRanges": ["31000"], "namespacePortRanges": {"tenant-a": ["32000 :32001"]}}`))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(conf.PortRange).To(Equal("30000:30100"))
+				Expect(conf.PortRanges).To(Equal([]string{"31000:31000"}))
+				Expect(conf.NamespacePortRanges).To(Equal(map[string][]string{"tenant-a": {"32000:32001"}}))
+			})
+			It("rejects invalid port ranges", func() {
+				_, err := parseConfig([]byte(`{"frontendURL": "apollo.test.specs.cloud", "masterPort": "31400",
+					"playerCount": 2, "stateTimeout": "1s", "connectTimeout": "2s", "computationTimeout": "3s",
+					"portRange": "30100:30000"}`))
+				Expect(err).To(MatchError(`invalid port range "30100:30000": the port range 30100:30000 must not end before it starts`))
+			})
+		})
+		Context("when validating the config", func() {
+			write := func(content string) {
+				Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+			}
+			AfterEach(func() {
+				os.Remove(path)
+			})
+			It("accepts a valid configuration", func() {
+				write(`{"frontendURL": "apollo.test.specs.cloud", "masterPort": "31400", "playerCount": 2,
+					"stateTimeout": "1s", "connectTimeout": "2s", "computationTimeout": "3s"}`)
+				Expect(ValidateConfig(path)).To(BeEmpty())
+				Expect(RunConfigValidation(path)).To(Equal(0))
+			})
+			It("aggregates the problems found", func() {
+				write(`{"frontendURL": "apollo.test.specs.cloud", "masterHost": "localhost", "masterPort": "1",
+					"slave": true, "playerCount": 2, "stateTimeout": "1s", "connectTimeout": "1s", "computationTimeout": "3s",
+					"logLevel": "verbose", "portRanges": ["30000:30100", "30050:30150"], "stateStore": {"type": "disk"}}`)
+				Expect(ValidateConfig(path)).To(HaveLen(4))
+				Expect(RunConfigValidation(path)).To(Equal(1))
+			})
+			It("fails if the configuration file cannot be read", func() {
+				Expect(ValidateConfig(path)).To(HaveLen(1))
 			})
 		})
 		Context("when initializing the gRPC server", func() {
 			It("sets its parameters", func() {
 				logger := zap.NewNop().Sugar()
 				port := "8080"
-				tr := NewTransportServer(logger, port)
+				tr, err := NewTransportServer(logger, port, nil, nil, nil, 0)
+				Expect(err).NotTo(HaveOccurred())
 				Expect(tr.GetIn()).NotTo(BeNil())
 				Expect(tr.GetOut()).NotTo(BeNil())
 			})
 		})
+		Context("when creating the state store", func() {
+			It("keeps the state in memory by default", func() {
+				store, err := NewStateStore(StateStoreConfig{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(store).To(BeAssignableToTypeOf(&discovery.MemoryStateStore{}))
+			})
+			It("creates the configured store", func() {
+				store, err := NewStateStore(StateStoreConfig{Type: StateStoreEtcd, Endpoint: "http://etcd:2379"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(store).To(BeAssignableToTypeOf(&discovery.EtcdStateStore{}))
+				store, err = NewStateStore(StateStoreConfig{Type: StateStoreRedis, Endpoint: "redis:6379", Timeout: "1s"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(store).To(BeAssignableToTypeOf(&discovery.RedisStateStore{}))
+			})
+			It("rejects unsupported types", func() {
+				_, err := NewStateStore(StateStoreConfig{Type: "zookeeper"})
+				Expect(err).To(MatchError("unsupported state store type \"zookeeper\""))
+			})
+			It("rejects invalid timeouts", func() {
+				_, err := NewStateStore(StateStoreConfig{Type: StateStoreRedis, Endpoint: "redis:6379", Timeout: "soon"})
+				Expect(err).To(HaveOccurred())
+			})
+			It("rejects a username for redis", func() {
+				_, err := NewStateStore(StateStoreConfig{Type: StateStoreRedis, Endpoint: "redis:6379", Username: "discovery"})
+				Expect(err).To(MatchError("the redis state store does not support a username"))
+			})
+			It("fails if the password file cannot be read", func() {
+				_, err := NewStateStore(StateStoreConfig{Type: StateStoreEtcd, Endpoint: "etcd:2379", PasswordFile: "/does/not/exist"})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+		Context("when checking the dependencies", func() {
+			It("checks the master, the state store and the checkpoint directory", func() {
+				checks := DependencyChecks(&DiscoveryTypedConfig{
+					Slave:          true,
+					MasterHost:     "localhost",
+					MasterPort:     "31400",
+					StateStore:     StateStoreConfig{Type: StateStoreRedis, Endpoint: "redis:6379"},
+					CheckpointPath: "/var/lib/discovery/checkpoint.json",
+				})
+				var names []string
+				for _, c := range checks {
+					names = append(names, c.Name)
+				}
+				Expect(names).To(Equal([]string{"master", "stateStore", "checkpoint"}))
+			})
+			It("has nothing to check for a master keeping its state in memory", func() {
+				Expect(DependencyChecks(&DiscoveryTypedConfig{})).To(BeEmpty())
+			})
+		})
+		Context("when shutting down", func() {
+			It("persists the state which is restored on start", func() {
+				logger := zap.NewNop().Sugar()
+				dir, _ := ioutil.TempDir("", "discovery_checkpoint_")
+				defer os.RemoveAll(dir)
+				path := filepath.Join(dir, "checkpoint.json")
+				newService := func() *discovery.ServiceNG {
+					return newTestService(logger)
+				}
+				Expect(Shutdown(newService(), path)).To(Succeed())
+				Expect(path).To(BeAnExistingFile())
+				Expect(RestoreCheckpoint(newService(), path, logger)).To(Succeed())
+				Expect(path).NotTo(BeAnExistingFile())
+			})
+			It("does not persist the state if no checkpoint path is configured", func() {
+				logger := zap.NewNop().Sugar()
+				s := newTestService(logger)
+				Expect(Shutdown(s, "")).To(Succeed())
+				Expect(RestoreCheckpoint(s, "", logger)).To(Succeed())
+			})
+		})
+		Context("when reloading the config", func() {
+			const config = `{"frontendURL": "apollo.test.specs.cloud", "masterPort": "31400", "playerCount": 2,
+				"stateTimeout": "1s", "connectTimeout": "2s", "computationTimeout": "3s"%s}`
+			var (
+				target   *fakeReloadTarget
+				logs     *observer.ObservedLogs
+				reloader *ConfigReloader
+			)
+			BeforeEach(func() {
+				current, err := parseConfig([]byte(fmt.Sprintf(config, "")))
+				Expect(err).NotTo(HaveOccurred())
+				SetDefaults(current)
+				target = &fakeReloadTarget{}
+				var core zapcore.Core
+				core, logs = observer.New(zapcore.InfoLevel)
+				reloader = &ConfigReloader{
+					Current:   current,
+					Service:   target,
+					Networker: target,
+					Level:     zap.NewAtomicLevelAt(zapcore.DebugLevel),
+					Logger:    zap.New(core).Sugar(),
+				}
+			})
+			It("applies the timeouts, port ranges and log level", func() {
+				reloader.Reload([]byte(fmt.Sprintf(config, `, "stateTimeout": "5s", "portRange": "31000:31100", "logLevel": "info"`)))
+				Expect(target.stateTimeout).To(Equal(5 * time.Second))
+				Expect(target.computationTimeout).To(Equal(3 * time.Second))
+				Expect(target.ports).NotTo(BeNil())
+				Expect(reloader.Level.Level()).To(Equal(zapcore.InfoLevel))
+				Expect(reloader.Current.PortRange).To(Equal("31000:31100"))
+				Expect(logs.FilterMessage("Reloaded the configuration").Len()).To(Equal(1))
+			})
+			It("keeps the port pools if the port ranges are unchanged", func() {
+				reloader.Reload([]byte(fmt.Sprintf(config, `, "stateTimeout": "5s"`)))
+				Expect(target.stateTimeout).To(Equal(5 * time.Second))
+				Expect(target.ports).To(BeNil())
+			})
+			It("ignores changes which require a restart", func() {
+				reloader.Reload([]byte(fmt.Sprintf(config, `, "masterPort": "31401"`)))
+				Expect(target.stateTimeout).To(BeZero())
+				Expect(reloader.Current.MasterPort).To(Equal("31400"))
+				rejected := logs.FilterMessage("Ignoring configuration changes which require a restart").All()
+				Expect(rejected).To(HaveLen(1))
+				Expect(rejected[0].ContextMap()["Settings"]).To(Equal([]interface{}{"MasterPort"}))
+			})
+			It("ignores invalid configurations", func() {
+				reloader.Reload([]byte(fmt.Sprintf(config, `, "stateTimeout": "soon"`)))
+				reloader.Reload([]byte(fmt.Sprintf(config, `, "portRange": "31000:31100", "portRanges": ["31050:31200"]`)))
+				Expect(target.stateTimeout).To(BeZero())
+				Expect(target.ports).To(BeNil())
+				Expect(logs.FilterMessage("Ignoring the invalid configuration").Len()).To(Equal(2))
+			})
+		})
+		Context("when the network controller releases a port", func() {
+			var (
+				networks *fakePortReleaser
+				handler  http.HandlerFunc
+			)
+			BeforeEach(func() {
+				networks = &fakePortReleaser{}
+				handler = ReleaseHandler(networks, zap.NewNop().Sugar())
+			})
+			It("releases the port of the named network", func() {
+				rec := httptest.NewRecorder()
+				handler(rec, httptest.NewRequest(http.MethodPost, releasePath+"?name=pod1", nil))
+				Expect(rec.Code).To(Equal(http.StatusNoContent))
+				Expect(networks.released).To(Equal([]string{"pod1"}))
+			})
+			It("rejects requests without a name", func() {
+				rec := httptest.NewRecorder()
+				handler(rec, httptest.NewRequest(http.MethodPost, releasePath, nil))
+				Expect(rec.Code).To(Equal(http.StatusBadRequest))
+				Expect(networks.released).To(BeEmpty())
+			})
+			It("rejects other methods than POST", func() {
+				rec := httptest.NewRecorder()
+				handler(rec, httptest.NewRequest(http.MethodGet, releasePath+"?name=pod1", nil))
+				Expect(rec.Code).To(Equal(http.StatusMethodNotAllowed))
+				Expect(networks.released).To(BeEmpty())
+			})
+		})
 		Context("when starting the network deletion", func() {
 			It("deletes the network with the given name", func() {
 				doneCh := make(chan string, 1)
 				errCh := make(chan error, 1)
 				logger := zap.NewNop().Sugar()
-				s := &discovery.ServiceNG{}
+				s := newTestService(logger)
 				doneCh <- "network"
 				errCh <- errors.New("some error")
 				runDeletion := func() {
@@ -223,3 +491,37 @@ var _ = Describe("Main", func() {
 		})
 	})
 })
+
+// newTestService returns a discovery service in master mode that is not started.
+func newTestService(logger *zap.SugaredLogger) *discovery.ServiceNG {
+	bus := mb.New(DefaultBusSize)
+	tr, err := NewTransportServer(logger, DefaultPort, nil, nil, nil, 0)
+	Expect(err).NotTo(HaveOccurred())
+	return discovery.NewServiceNG(bus, discovery.NewPublisher(bus), time.Second, time.Second, tr, nil, "", logger, ModeMaster, nil, 2, discovery.NewMemoryStateStore())
+}
+
+// fakeReloadTarget records the settings applied by the ConfigReloader.
+type fakeReloadTarget struct {
+	stateTimeout       time.Duration
+	computationTimeout time.Duration
+	ports              *discovery.PortPools
+}
+
+func (f *fakeReloadTarget) SetTimeouts(stateTimeout time.Duration, computationTimeout time.Duration) {
+	f.stateTimeout = stateTimeout
+	f.computationTimeout = computationTimeout
+}
+
+func (f *fakeReloadTarget) SetPortPools(ports *discovery.PortPools) error {
+	f.ports = ports
+	return nil
+}
+
+// fakePortReleaser records the pods whose ports are released.
+type fakePortReleaser struct {
+	released []string
+}
+
+func (f *fakePortReleaser) ReleasePort(pod string) {
+	f.released = append(f.released, pod)
+}
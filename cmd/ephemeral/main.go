@@ -5,87 +5,466 @@
 package main
 
 import (
+	"compress/flate"
+	"context"
 	"encoding/json"
 	"errors"
+	"flag"
+	"fmt"
+	"github.com/carbynestack/ephemeral/pkg/allowlist"
 	"github.com/carbynestack/ephemeral/pkg/amphora"
+	"github.com/carbynestack/ephemeral/pkg/archive"
+	"github.com/carbynestack/ephemeral/pkg/audit"
 	"github.com/carbynestack/ephemeral/pkg/castor"
+	"github.com/carbynestack/ephemeral/pkg/certs"
+	"github.com/carbynestack/ephemeral/pkg/depcheck"
 	. "github.com/carbynestack/ephemeral/pkg/ephemeral"
+	"github.com/carbynestack/ephemeral/pkg/ephemeral/api"
 	l "github.com/carbynestack/ephemeral/pkg/logger"
+	"github.com/carbynestack/ephemeral/pkg/notify"
+	"github.com/carbynestack/ephemeral/pkg/objectstore"
 	"github.com/carbynestack/ephemeral/pkg/opa"
+	"github.com/carbynestack/ephemeral/pkg/portplan"
+	"github.com/carbynestack/ephemeral/pkg/quota"
+	"github.com/carbynestack/ephemeral/pkg/results"
+	"github.com/carbynestack/ephemeral/pkg/retry"
+	"github.com/carbynestack/ephemeral/pkg/tracing"
 	"github.com/carbynestack/ephemeral/pkg/utils"
 	"os"
 
 	. "github.com/carbynestack/ephemeral/pkg/types"
 	"math/big"
+	"net"
 	"net/http"
 	"net/url"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
 )
 
 const (
 	defaultConfig = "/etc/config/config.json"
 	defaultPort   = "8080"
+	// defaultDrainTimeout is the maximum duration running games are given to finish on termination if not configured.
+	// It is shorter than the default termination grace period of Kubernetes pods.
+	defaultDrainTimeout = 20 * time.Second
+	// readinessPath is the path the readiness probe of the pod is served on.
+	readinessPath = "/ready"
+	// busMetricsInterval is the interval the metrics of the message buses of the players are logged in.
+	busMetricsInterval = time.Minute
+	// configReloadInterval is the interval the configuration file is checked for changes in.
+	configReloadInterval = 10 * time.Second
 )
 
 func main() {
-	logger, err := l.NewDevelopmentLogger()
+	selfTest := flag.Bool("self-test", false, "runs a computation between two local players and reports whether it succeeded")
+	validate := flag.Bool("validate-config", false, "validates the configuration and reports all problems found")
+	flag.Parse()
+	level := zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	logger, err := l.NewDevelopmentLoggerAt(level)
 	if err != nil {
 		panic(err)
 	}
+	if *validate {
+		os.Exit(runConfigValidation(defaultConfig, logger))
+	}
 	config, err := ParseConfig(defaultConfig)
 	if err != nil {
 		panic(err)
 	}
-	logger.Debugf("Starting with the config:\n%+v", config)
+	logLevel, err := l.ParseLevel(config.LogLevel)
+	if err != nil {
+		panic(err)
+	}
+	level.SetLevel(logLevel)
+	if *selfTest {
+		os.Exit(runSelfTest(config, logger))
+	}
+	svc, err := newService(config, logger)
 	if err != nil {
 		panic(err)
 	}
-	handler, err := GetHandlerChain(config, logger)
+	// The dependencies are checked once on startup, the service is started regardless so that the report can be
+	// retrieved.
+	svc.dependencies.Run().Log(logger)
+	go PlayerBusMetrics.Report(logger, busMetricsInterval, nil)
+	if svc.config.ArchiveExporter != nil {
+		go svc.config.ArchiveExporter.Run(nil)
+	}
+	if svc.config.PlayerCerts != nil {
+		if _, err := svc.config.PlayerCerts.Sync(); err != nil {
+			panic(err)
+		}
+		go svc.config.PlayerCerts.Run(logger, nil)
+	}
+	reloader := &configReloader{current: config, typed: svc.config, level: level, logger: logger}
+	watcher := &utils.FileWatcher{
+		Path:     defaultConfig,
+		Interval: configReloadInterval,
+		OnChange: reloader.reload,
+		OnError: func(err error) {
+			logger.Warnw("Failed to read the configuration", "Error", err)
+		},
+	}
+	go watcher.Run(nil)
+	lis, err := net.Listen("tcp", "localhost:"+defaultPort)
 	if err != nil {
 		panic(err)
 	}
-	http.Handle("/", handler)
+	// The games of the gRPC API are drained along with the ones of the HTTP API. Like the HTTP API, the gRPC API is
+	// bound to localhost, as the tokens of the callers are verified by the sidecars in front of the service only.
+	var grpcServer *grpc.Server
+	if config.GRPCPort != "" {
+		grpcServer, err = serveGRPC(svc.handler, "localhost:"+config.GRPCPort, logger)
+		if err != nil {
+			panic(err)
+		}
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
 	logger.Info("Starting http server")
-	err = http.ListenAndServe("localhost:"+defaultPort, nil)
+	err = serve(&http.Server{Handler: svc.handler}, lis, grpcServer, svc.drainTimeout, svc.preStop, svc.abortGames, svc.engine.Wait, signals, logger)
 	if err != nil {
 		panic(err)
 	}
 }
 
+// serveGRPC serves the gRPC API on the given address in the background. The calls are handled by the given HTTP handler
+// chain.
+func serveGRPC(handler http.Handler, addr string, logger *zap.SugaredLogger) (*grpc.Server, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	srv := grpc.NewServer()
+	api.RegisterEphemeralServer(srv, api.NewServer(handler))
+	go func() {
+		logger.Infow("Starting gRPC server", "Address", addr)
+		if err := srv.Serve(lis); err != nil {
+			logger.Errorw("gRPC server failed", "Error", err)
+		}
+	}()
+	return srv, nil
+}
+
+// runSelfTest runs the self-test with the public SPDZ parameters of the configuration and returns the exit code of the
+// process, i.e., 0 if the self-test passed and 1 otherwise.
+func runSelfTest(conf *SPDZEngineConfig, logger *zap.SugaredLogger) int {
+	typedConfig, err := InitTypedConfig(conf, logger)
+	if err != nil {
+		logger.Errorw("Self-test failed", "Error", err)
+		return 1
+	}
+	if err := RunSelfTest(typedConfig, logger); err != nil {
+		logger.Errorw("Self-test failed", "Error", err)
+		return 1
+	}
+	logger.Info("Self-test passed")
+	return 0
+}
+
+// defaultValidationDialTimeout is the maximum duration of connecting to the discovery service when validating the
+// configuration if no connect timeout is configured.
+const defaultValidationDialTimeout = 5 * time.Second
+
+// runConfigValidation validates the configuration file at the given path and returns the exit code of the process,
+// i.e., 0 if the configuration is valid and 1 otherwise. All problems found are written to stderr.
+func runConfigValidation(path string, logger *zap.SugaredLogger) int {
+	errs := validateConfig(path, logger)
+	if len(errs) == 0 {
+		fmt.Println("The configuration is valid")
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "The configuration is invalid, %d problem(s) found:\n", len(errs))
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "  - %v\n", err)
+	}
+	return 1
+}
+
+// validateConfig parses the configuration file at the given path like the service does on startup. In addition, the
+// consistency of the SPDZ parameters and the reachability of the discovery service are verified. All problems found
+// are returned.
+func validateConfig(path string, logger *zap.SugaredLogger) []error {
+	conf, err := ParseConfig(path)
+	if err != nil {
+		return []error{fmt.Errorf("error reading the configuration: %v", err)}
+	}
+	var errs []error
+	if _, err := l.ParseLevel(conf.LogLevel); err != nil {
+		errs = append(errs, err)
+	}
+	if typedConfig, err := InitTypedConfig(conf, logger); err != nil {
+		errs = append(errs, err)
+	} else if _, err := newPortPlan(conf, typedConfig); err != nil {
+		errs = append(errs, err)
+	}
+	errs = append(errs, checkSPDZParameters(conf)...)
+	if err := checkDiscoveryReachable(conf.DiscoveryConfig); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// checkSPDZParameters verifies that the prime is a prime, that rInv is the inverse of the Montgomery radix used by
+// MP-SPDZ for the prime and that the gfp MAC key is an element of the field. Parameters which cannot be parsed are
+// skipped, as they are reported by InitTypedConfig already.
+func checkSPDZParameters(conf *SPDZEngineConfig) []error {
+	var p, rInv, macKey big.Int
+	if _, ok := p.SetString(conf.Prime, 10); !ok {
+		return nil
+	}
+	if p.Sign() <= 0 || !p.ProbablyPrime(20) {
+		return []error{fmt.Errorf("the prime %s is not a prime number", conf.Prime)}
+	}
+	var errs []error
+	if _, ok := rInv.SetString(conf.RInv, 10); ok {
+		// MP-SPDZ represents field elements in Montgomery form using a radix of 2^(64*n) with n being the number of
+		// 64-bit limbs required to store the prime.
+		limbs := (p.BitLen() + 63) / 64
+		r := new(big.Int).Lsh(big.NewInt(1), uint(64*limbs))
+		product := new(big.Int).Mul(r, &rInv)
+		if rInv.Sign() < 0 || rInv.Cmp(&p) >= 0 || product.Mod(product, &p).Cmp(big.NewInt(1)) != 0 {
+			errs = append(errs, fmt.Errorf("rInv %s is not the inverse of 2^%d modulo the prime", conf.RInv, 64*limbs))
+		}
+	}
+	if _, ok := macKey.SetString(conf.GfpMacKey, 10); ok {
+		if macKey.Sign() < 0 || macKey.Cmp(&p) >= 0 {
+			errs = append(errs, fmt.Errorf("the gfpMacKey %s is not an element of the field defined by the prime", conf.GfpMacKey))
+		}
+	}
+	return errs
+}
+
+// checkDiscoveryReachable returns an error if no TCP connection can be established to the discovery service.
+func checkDiscoveryReachable(conf DiscoveryClientConfig) error {
+	timeout := defaultValidationDialTimeout
+	if conf.ConnectTimeout != "" {
+		if d, err := time.ParseDuration(conf.ConnectTimeout); err == nil && d > 0 {
+			timeout = d
+		}
+	}
+	addr := net.JoinHostPort(conf.Host, conf.Port)
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return fmt.Errorf("the discovery service at %s is not reachable: %v", addr, err)
+	}
+	return conn.Close()
+}
+
+// service bundles the HTTP handler with the engine executing the games.
+type service struct {
+	handler      http.Handler
+	engine       *SPDZEngine
+	config       *SPDZEngineTypedConfig
+	drainTimeout time.Duration
+	// preStop postpones the shutdown while games are in flight.
+	preStop func()
+	// abortGames cancels the running games.
+	abortGames func()
+	// dependencies checks the dependencies of the service.
+	dependencies *depcheck.Checker
+}
+
+// configReloader applies changes of the configuration file to the running service. The timeouts of the games, the
+// tuple stock and the log level are changed, other changes require a restart and are ignored.
+type configReloader struct {
+	current *SPDZEngineConfig
+	typed   *SPDZEngineTypedConfig
+	level   zap.AtomicLevel
+	logger  *zap.SugaredLogger
+}
+
+// reload applies the given content of the configuration file. The configuration is left as is if the content is
+// invalid.
+func (r *configReloader) reload(content []byte) {
+	conf, err := parseConfig(content)
+	if err != nil {
+		r.logger.Errorw("Ignoring the invalid configuration", "Error", err)
+		return
+	}
+	settings, err := parseReloadableSettings(conf)
+	if err != nil {
+		r.logger.Errorw("Ignoring the invalid configuration", "Error", err)
+		return
+	}
+	level, err := l.ParseLevel(conf.LogLevel)
+	if err != nil {
+		r.logger.Errorw("Ignoring the invalid configuration", "Error", err)
+		return
+	}
+	next := *r.current
+	next.StateTimeout = conf.StateTimeout
+	next.ComputationTimeout = conf.ComputationTimeout
+	next.StateTimeouts = conf.StateTimeouts
+	next.CastorConfig.TupleStock = conf.CastorConfig.TupleStock
+	next.PreStopTimeout = conf.PreStopTimeout
+	next.ResultDeliveryTimeout = conf.ResultDeliveryTimeout
+	next.LogLevel = conf.LogLevel
+	if rejected := utils.ChangedFields(&next, conf); len(rejected) > 0 {
+		r.logger.Warnw("Ignoring configuration changes which require a restart", "Settings", rejected)
+	}
+	applied := utils.ChangedFields(r.current, &next)
+	if len(applied) == 0 {
+		return
+	}
+	r.typed.Reload(settings)
+	r.level.SetLevel(level)
+	r.current = &next
+	r.logger.Infow("Reloaded the configuration", "Settings", applied)
+}
+
+// serve runs the HTTP server until a termination signal is received. The server then stops accepting new activations
+// and waits for the games in flight as done by preStop, while still serving the other requests, e.g., the ones
+// fetching the results. Afterwards, the running games are given up to the drain timeout to respond, before the
+// remaining ones are aborted by abortGames. The calls of the gRPC server, if given, are drained alike. It returns once all
+// MPC executions, including their tuple streamers, have terminated.
+func serve(srv *http.Server, lis net.Listener, grpcSrv *grpc.Server, drainTimeout time.Duration, preStop func(), abortGames func(), waitForGames func(), signals <-chan os.Signal, logger *zap.SugaredLogger) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(lis)
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case sig := <-signals:
+		logger.Infow("Draining running games", "Signal", sig, "DrainTimeout", drainTimeout)
+	}
+	preStop()
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	grpcStopped := make(chan struct{})
+	if grpcSrv != nil {
+		go func() {
+			defer close(grpcStopped)
+			grpcSrv.GracefulStop()
+		}()
+	}
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Warnw("Aborting the games that did not finish in time", "Error", err)
+		abortGames()
+		if err := srv.Close(); err != nil {
+			return err
+		}
+	}
+	if grpcSrv != nil {
+		select {
+		case <-grpcStopped:
+		case <-ctx.Done():
+			logger.Warn("Aborting the gRPC calls that did not finish in time")
+			abortGames()
+			grpcSrv.Stop()
+		}
+	}
+	waitForGames()
+	logger.Info("Stopped http server")
+	return nil
+}
+
 // GetHandlerChain returns a chain of handlers that are used to process HTTP requests.
 func GetHandlerChain(conf *SPDZEngineConfig, logger *zap.SugaredLogger) (http.Handler, error) {
+	svc, err := newService(conf, logger)
+	if err != nil {
+		return nil, err
+	}
+	return svc.handler, nil
+}
+
+// newService creates the engine and the chain of handlers that are used to process HTTP requests.
+func newService(conf *SPDZEngineConfig, logger *zap.SugaredLogger) (*service, error) {
 	typedConfig, err := InitTypedConfig(conf, logger)
 	if err != nil {
 		return nil, err
 	}
+	plan, err := newPortPlan(conf, typedConfig)
+	logger.Infof("Port allocation:\n%s", plan)
+	if err != nil {
+		return nil, err
+	}
 	spdzClient, err := NewSPDZEngine(logger, utils.NewCommander(), typedConfig)
 	if err != nil {
 		return nil, err
 	}
-	server := NewServer(conf.AuthUserIdField, spdzClient.Compile, spdzClient.Activate, logger, typedConfig)
+	backends := NewBackends(spdzClient)
+	if typedConfig.Simulator != nil {
+		logger.Warn("INSECURE: Games can be executed in cleartext by the plaintext simulator, do not use in production")
+		backends.Register(SimulatorBackend, NewPlaintextSimulator(spdzClient, typedConfig.Simulator))
+	}
+	server := NewServer(conf.AuthUserIdField, spdzClient.Compile, spdzClient.CompileWithReport, backends.Activate, logger, typedConfig)
+	server.OnSessionClosed(spdzClient.CloseSession)
+	server.OnGameFinished(spdzClient.RemoveWorkDir)
+	dependencies := depcheck.NewChecker("ephemeral", conf, DependencyChecks(typedConfig))
+	server.CheckDependencies(dependencies)
 	activationHandler := http.HandlerFunc(server.ActivationHandler)
 	// Apply in Order:
+	// 0) DrainFilter: Reject new games once the pod is terminating or busy and keep track of the games in flight
 	// 1) MethodFilter: Check that only POST Requests can go through
 	// 2) RequestFilter: Check that Request Body is set properly and Sets the CtxConfig to the request
-	// 3) CompilationHandler: Compiles the script if ?compile=true
-	// 4) ActivationHandler: Runs the script
-	filterChain := server.MethodFilter(server.RequestFilter(server.CompilationHandler(activationHandler)))
-	return filterChain, nil
+	// 3) PolicyFilter: Check that the admission policy allows the activation, if configured
+	// 4) QuotaFilter: Check that the user has not exceeded the configured quotas
+	// 5) CompilationHandler: Compiles the script if ?compile=true
+	// 6) ActivationHandler: Runs the script
+	filterChain := server.DrainFilter(server.MethodFilter(server.RequestFilter(server.PolicyFilter(server.QuotaFilter(server.CompilationHandler(activationHandler))))))
+	// Programs are compiled without activating a game on /compile, the capabilities of the deployment are served on
+	// /capabilities and the outcome of executions whose result is delivered in the background on /executions/.
+	// Computation sessions are managed on /sessions and the output of the MPC runtime of a game is tailed on
+	// /games/{id}/logs. The statistics of the tuple streamers are served on /metrics. The scope filters check the authorization scopes of the callers, if
+	// configured. The readiness probe of the pod reports on /ready whether new activations are accepted, along with the
+	// report of the dependency checks if asked for, which is also served on /admin/dependencies. The history of the
+	// executions is exported on /admin/executions, if archived.
+	mux := http.NewServeMux()
+	mux.Handle("/compile", server.ScopeFilter(ScopeCompile, server.MethodFilter(http.HandlerFunc(server.CompileOnlyHandler))))
+	mux.HandleFunc("/capabilities", server.CapabilitiesHandler)
+	mux.HandleFunc(MetricsPath, server.MetricsHandler)
+	mux.Handle(ExecutionsPath, server.ScopeFilter(ScopeExecute, http.HandlerFunc(server.ExecutionsHandler)))
+	mux.HandleFunc(readinessPath, server.ReadinessHandler)
+	mux.Handle(DependenciesPath, server.ScopeFilter(ScopeAdmin, dependencies))
+	if typedConfig.ExecutionsArchive != nil {
+		mux.Handle(archive.Path, server.ScopeFilter(ScopeAdmin, typedConfig.ExecutionsArchive))
+	}
+	mux.Handle("/sessions", server.ScopeFilter(ScopeExecute, http.HandlerFunc(server.SessionsHandler)))
+	mux.Handle(SessionsPath, server.ScopeFilter(ScopeExecute, http.HandlerFunc(server.SessionsHandler)))
+	mux.Handle(GamesPath, server.ScopeFilter(ScopeExecute, http.HandlerFunc(server.GamesHandler)))
+	mux.Handle("/", server.ScopeFilter(ScopeExecute, filterChain))
+	return &service{
+		handler:      mux,
+		engine:       spdzClient,
+		config:       typedConfig,
+		drainTimeout: typedConfig.DrainTimeout,
+		preStop:      server.PreStop,
+		abortGames:   server.AbortGames,
+		dependencies: dependencies,
+	}, nil
 }
 
-// ParseConfig reads the configuration file content.
+// envPrefix is the prefix of the environment variables overriding the settings of the configuration file, e.g.,
+// EPHEMERAL_PLAYER_ID.
+const envPrefix = "EPHEMERAL"
+
+// ParseConfig reads the configuration file content. Settings are overridden by the environment variables named after
+// them, see utils.ApplyEnvOverrides.
 func ParseConfig(path string) (*SPDZEngineConfig, error) {
 	bytes, err := utils.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
+	return parseConfig(bytes)
+}
+
+// parseConfig parses the content of the configuration file and applies the overrides of the environment.
+func parseConfig(bytes []byte) (*SPDZEngineConfig, error) {
 	var conf SPDZEngineConfig
-	err = json.Unmarshal(bytes, &conf)
+	err := json.Unmarshal(bytes, &conf)
 	if err != nil {
 		return nil, err
 	}
+	if err := utils.ApplyEnvOverrides(&conf, envPrefix, os.LookupEnv); err != nil {
+		return nil, err
+	}
 	return &conf, nil
 }
 
@@ -109,11 +488,11 @@ func InitTypedConfig(conf *SPDZEngineConfig, logger *zap.SugaredLogger) (*SPDZEn
 	if !ok {
 		return nil, errors.New("wrong gfpMacKey format")
 	}
-	stateTimeout, err := time.ParseDuration(conf.StateTimeout)
+	gf2nDisabled, err := validateGf2n(conf, logger)
 	if err != nil {
 		return nil, err
 	}
-	computationTimeout, err := time.ParseDuration(conf.ComputationTimeout)
+	settings, err := parseReloadableSettings(conf)
 	if err != nil {
 		return nil, err
 	}
@@ -121,10 +500,44 @@ func InitTypedConfig(conf *SPDZEngineConfig, logger *zap.SugaredLogger) (*SPDZEn
 	if err != nil {
 		return nil, err
 	}
+	var reconnectTimeout time.Duration
+	if conf.DiscoveryConfig.ReconnectTimeout != "" {
+		reconnectTimeout, err = time.ParseDuration(conf.DiscoveryConfig.ReconnectTimeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, fallback := range conf.DiscoveryConfig.Fallbacks {
+		if fallback.Host == "" || fallback.Port == "" {
+			return nil, errors.New("discovery fallback endpoints must define a host and a port")
+		}
+	}
+	for _, contentType := range conf.AcceptedContentTypes {
+		if !isSupportedContentType(contentType) {
+			return nil, fmt.Errorf("unsupported content type %s", contentType)
+		}
+	}
 	networkEstablishTimeout, err := time.ParseDuration(conf.NetworkEstablishTimeout)
 	if err != nil {
 		return nil, err
 	}
+	drainTimeout := defaultDrainTimeout
+	if conf.DrainTimeout != "" {
+		drainTimeout, err = time.ParseDuration(conf.DrainTimeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sessionIdleTimeout := defaultSessionIdleTimeout
+	if conf.SessionIdleTimeout != "" {
+		sessionIdleTimeout, err = time.ParseDuration(conf.SessionIdleTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if sessionIdleTimeout <= 0 {
+			return nil, errors.New("the session idle timeout must be positive")
+		}
+	}
 	programIdentifier, ok := os.LookupEnv("EPHEMERAL_PROGRAM_IDENTIFIER")
 	if !ok {
 		programIdentifier = conf.ProgramIdentifier
@@ -138,26 +551,154 @@ func InitTypedConfig(conf *SPDZEngineConfig, logger *zap.SugaredLogger) (*SPDZEn
 	if err != nil {
 		return nil, err
 	}
+	admissionPolicy, err := newAdmissionPolicy(conf.OpaConfig, opaClient)
+	if err != nil {
+		return nil, err
+	}
 
-	amphoraURL := url.URL{
-		Host:   conf.AmphoraConfig.Host,
-		Scheme: conf.AmphoraConfig.Scheme,
-		Path:   conf.AmphoraConfig.Path,
+	var quotaTracker *quota.Tracker
+	if conf.Quota.ExecutionsPerHour > 0 || conf.Quota.TupleBytesPerDay > 0 {
+		quotaTracker, err = quota.NewTracker(conf.Quota.ExecutionsPerHour, conf.Quota.TupleBytesPerDay, conf.Quota.StorePath)
+		if err != nil {
+			return nil, fmt.Errorf("error restoring the quota usage: %v", err)
+		}
 	}
-	amphoraClient, err := amphora.NewClient(amphoraURL)
+
+	notifications, err := newNotifications(conf.Notifiers, logger)
 	if err != nil {
 		return nil, err
 	}
 
-	castorURL := url.URL{
-		Host:   conf.CastorConfig.Host,
-		Scheme: conf.CastorConfig.Scheme,
-		Path:   conf.CastorConfig.Path,
+	tracer, err := newTracer(conf.Tracing, logger)
+	if err != nil {
+		return nil, err
 	}
-	castorClient, err := castor.NewClient(castorURL)
+
+	amphoraClient, err := newAmphoraClient(conf.AmphoraConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	insecurePreprocessing, err := parseInsecurePreprocessing(conf.InsecurePreprocessing)
+	if err != nil {
+		return nil, err
+	}
+	// Castor may be left out if the preprocessing data is generated locally anyway, e.g., in development clusters.
+	var castorClient castor.AbstractClient
+	if insecurePreprocessing == nil || conf.CastorConfig.Host != "" {
+		castorClient, err = newCastorClient(conf.CastorConfig, logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+	protocols, defaultProtocol, err := parseProtocols(conf.Protocols, conf.DefaultProtocol)
+	if err != nil {
+		return nil, err
+	}
+	if conf.MaxThreads < 0 {
+		return nil, errors.New("the maximum number of threads must not be negative")
+	}
+	if conf.ProcessLimits != nil && conf.ProcessLimits.MaxOpenFiles < 0 {
+		return nil, errors.New("the maximum number of open files must not be negative")
+	}
+	objectStoreClient, err := newObjectStoreClient(conf.ObjectStore)
+	if err != nil {
+		return nil, err
+	}
+	adaptiveStock, err := parseAdaptiveStock(conf.CastorConfig.AdaptiveStock)
+	if err != nil {
+		return nil, err
+	}
+	tupleCache, err := newTupleCache(conf.CastorConfig.TupleCache)
+	if err != nil {
+		return nil, err
+	}
+	tupleTypes, err := parseTupleTypes(conf.CastorConfig.TupleTypes)
+	if err != nil {
+		return nil, err
+	}
+	edaBitLength := conf.CastorConfig.EdaBitLength
+	if edaBitLength < 0 {
+		return nil, errors.New("the bit length of edaBits must not be negative")
+	}
+	if edaBitLength == 0 {
+		edaBitLength = defaultEdaBitLength
+	}
+	warmPool, err := parseWarmPool(conf.CastorConfig.WarmPool)
+	if err != nil {
+		return nil, err
+	}
+	egressLimit, err := parseEgressLimit(conf.EgressLimit)
+	if err != nil {
+		return nil, err
+	}
+	proxyPortRange := conf.ProxyPortRange
+	if proxyPortRange != "" {
+		if proxyPortRange, err = portplan.NormalizeRange(proxyPortRange); err != nil {
+			return nil, fmt.Errorf("invalid proxy port range: %v", err)
+		}
+	}
+	proxyCompression, err := parseProxyCompression(conf.ProxyCompression)
+	if err != nil {
+		return nil, err
+	}
+	colocation, err := parseColocation(conf.Colocation)
+	if err != nil {
+		return nil, err
+	}
+	partyNumbers, err := parsePartyNumbers(conf.PartyNumbers, conf.PlayerCount)
+	if err != nil {
+		return nil, err
+	}
+	readiness, err := parseReadiness(conf.Readiness)
+	if err != nil {
+		return nil, err
+	}
+	executionsArchive, archiveExporter, err := newExecutionsArchive(conf.ExecutionsArchive, objectStoreClient, logger)
 	if err != nil {
 		return nil, err
 	}
+	// The executions are archived once their audit trails are finished.
+	var archiveSinks []audit.Sink
+	if executionsArchive != nil {
+		archiveSinks = append(archiveSinks, executionsArchive)
+	}
+	auditor, err := newAuditor(conf.AuditSinks, logger, archiveSinks...)
+	if err != nil {
+		return nil, err
+	}
+	programAllowList, err := newProgramAllowList(conf.ProgramAllowList)
+	if err != nil {
+		return nil, err
+	}
+	// Warm streamers are bound to the preprocessing data directory of the game they were created for.
+	if conf.GameWorkDirs != nil && warmPool != nil {
+		return nil, errors.New("the warm pool cannot be used with per-game work directories")
+	}
+	// Each game gets its own proxy ports, so that the proxies of overlapping games do not conflict.
+	if proxyPortRange == "" && conf.PlayerCount > 0 {
+		proxyPortRange = DefaultProxyPortRange(conf.PlayerCount)
+	}
+	simulator := parseSimulator(conf.Simulator)
+	resultCache, err := newResultCache(conf.ResultCache, amphoraClient, programIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	party := conf.PlayerID
+	if partyNumbers != nil {
+		party = partyNumbers[conf.PlayerID]
+	}
+	playerCerts, err := newPlayerCerts(conf.PlayerCerts, conf.PrepFolder, party)
+	if err != nil {
+		return nil, err
+	}
+	if conf.TlsEnabled && playerCerts == nil {
+		return nil, errors.New("the player certificates must be configured if TLS is enabled")
+	}
+	if conf.TlsEnabled && proxyCompression != nil {
+		// Compressing the secret shares before encrypting them leaks information via the length of the records.
+		return nil, errors.New("the proxy compression cannot be used with TLS")
+	}
 
 	return &SPDZEngineTypedConfig{
 		ProgramIdentifier:       programIdentifier,
@@ -173,17 +714,902 @@ func InitTypedConfig(conf *SPDZEngineConfig, logger *zap.SugaredLogger) (*SPDZEn
 		OpaClient:               opaClient,
 		AmphoraClient:           amphoraClient,
 		CastorClient:            castorClient,
-		TupleStock:              conf.CastorConfig.TupleStock,
+		TupleStock:              settings.TupleStock,
 		PlayerID:                conf.PlayerID,
 		PlayerCount:             conf.PlayerCount,
 		FrontendURL:             conf.FrontendURL,
 		MaxBulkSize:             conf.MaxBulkSize,
 		DiscoveryConfig: DiscoveryClientTypedConfig{
-			Host:           conf.DiscoveryConfig.Host,
-			Port:           conf.DiscoveryConfig.Port,
-			ConnectTimeout: connectTimeout,
+			Host:             conf.DiscoveryConfig.Host,
+			Port:             conf.DiscoveryConfig.Port,
+			ConnectTimeout:   connectTimeout,
+			Fallbacks:        conf.DiscoveryConfig.Fallbacks,
+			TLS:              conf.DiscoveryConfig.TLS,
+			Namespace:        conf.DiscoveryConfig.Namespace,
+			Token:            conf.DiscoveryConfig.Token,
+			ReconnectTimeout: reconnectTimeout,
+			EventNamespace:   conf.DiscoveryConfig.EventNamespace,
 		},
-		StateTimeout:       stateTimeout,
-		ComputationTimeout: computationTimeout,
+		StateTimeout:           settings.StateTimeout,
+		ComputationTimeout:     settings.ComputationTimeout,
+		StateTimeouts:          settings.StateTimeouts,
+		AllowPartialResults:    conf.AllowPartialResults,
+		ProxyPortRange:         proxyPortRange,
+		ProxyReusePort:         conf.ProxyReusePort,
+		EgressLimit:            egressLimit,
+		ProxyCompression:       proxyCompression,
+		TlsEnabled:             conf.TlsEnabled,
+		Colocation:             colocation,
+		CompileCacheSize:       conf.CompileCacheSize,
+		AcceptedContentTypes:   conf.AcceptedContentTypes,
+		Quota:                  quotaTracker,
+		Notifications:          notifications,
+		OutputStreamBufferSize: conf.OutputStreamBufferSize,
+		Tracer:                 tracer,
+		DrainTimeout:           drainTimeout,
+		PreStopTimeout:         settings.PreStopTimeout,
+		ResultDeliveryTimeout:  settings.ResultDeliveryTimeout,
+		InsecurePreprocessing:  insecurePreprocessing,
+		Auditor:                auditor,
+		SessionIdleTimeout:     sessionIdleTimeout,
+		Protocols:              protocols,
+		DefaultProtocol:        defaultProtocol,
+		MaxThreads:             conf.MaxThreads,
+		Gf2nDisabled:           gf2nDisabled,
+		MPSPDZ:                 conf.MPSPDZ,
+		ObjectStoreClient:      objectStoreClient,
+		PartyNumbers:           partyNumbers,
+		InputStreamBufferSize:  conf.InputStreamBufferSize,
+		ProcessLimits:          conf.ProcessLimits,
+		AuthScopes:             parseAuthScopes(conf.AuthScopes),
+		AdaptiveStock:          adaptiveStock,
+		TupleCache:             tupleCache,
+		TupleTypes:             tupleTypes,
+		EdaBitLength:           edaBitLength,
+		WarmPool:               warmPool,
+		Readiness:              readiness,
+		AdmissionPolicy:        admissionPolicy,
+		ExecutionsArchive:      executionsArchive,
+		ArchiveExporter:        archiveExporter,
+		ProgramAllowList:       programAllowList,
+		GameWorkDirs:           conf.GameWorkDirs,
+		Simulator:              simulator,
+		MaxRequestSize:         parseMaxRequestSize(conf.MaxRequestSize),
+		ResultCache:            resultCache,
+		MaxResultSize:          parseMaxResultSize(conf.ResultCache),
+		PlayerCerts:            playerCerts,
+	}, nil
+}
+
+// parseReloadableSettings parses the settings which can be changed while the service is running.
+func parseReloadableSettings(conf *SPDZEngineConfig) (*ReloadableSettings, error) {
+	stateTimeout, err := time.ParseDuration(conf.StateTimeout)
+	if err != nil {
+		return nil, err
+	}
+	computationTimeout, err := time.ParseDuration(conf.ComputationTimeout)
+	if err != nil {
+		return nil, err
+	}
+	stateTimeouts, err := parseStateTimeouts(conf.StateTimeouts)
+	if err != nil {
+		return nil, err
+	}
+	var preStopTimeout time.Duration
+	if conf.PreStopTimeout != "" {
+		preStopTimeout, err = time.ParseDuration(conf.PreStopTimeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var resultDeliveryTimeout time.Duration
+	if conf.ResultDeliveryTimeout != "" {
+		resultDeliveryTimeout, err = time.ParseDuration(conf.ResultDeliveryTimeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &ReloadableSettings{
+		StateTimeout:          stateTimeout,
+		ComputationTimeout:    computationTimeout,
+		StateTimeouts:         stateTimeouts,
+		TupleStock:            conf.CastorConfig.TupleStock,
+		PreStopTimeout:        preStopTimeout,
+		ResultDeliveryTimeout: resultDeliveryTimeout,
 	}, nil
 }
+
+// validateGf2n returns true if gf2n is disabled because its MAC key or bit length is not configured. Otherwise, the
+// storage size of gf2n elements is verified.
+func validateGf2n(conf *SPDZEngineConfig, logger *zap.SugaredLogger) (bool, error) {
+	if conf.Gf2nMacKey == "" || conf.Gf2nBitLength == 0 {
+		logger.Warnw("The gf2n MAC key or bit length is not configured, programs requiring gf2n tuples are rejected",
+			"Gf2nBitLength", conf.Gf2nBitLength)
+		return true, nil
+	}
+	if conf.Gf2nBitLength < 0 {
+		return false, fmt.Errorf("invalid gf2n bit length %d, must be positive", conf.Gf2nBitLength)
+	}
+	if conf.Gf2nStorageSize != 8 && conf.Gf2nStorageSize != 16 {
+		return false, fmt.Errorf("invalid gf2n storage size %d, must be 8 or 16", conf.Gf2nStorageSize)
+	}
+	return false, nil
+}
+
+// parseStateTimeouts parses the timeouts of individual player states. Returns nil if none are configured.
+func parseStateTimeouts(timeouts map[string]string) (map[string]time.Duration, error) {
+	if len(timeouts) == 0 {
+		return nil, nil
+	}
+	parsed := make(map[string]time.Duration, len(timeouts))
+	for state, timeout := range timeouts {
+		if state != Registering && state != Playing {
+			return nil, fmt.Errorf("unsupported state %s in state timeouts, supported are %s and %s", state, Registering, Playing)
+		}
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout for state %s: %v", state, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("the timeout for state %s must be positive", state)
+		}
+		parsed[state] = d
+	}
+	return parsed, nil
+}
+
+// defaultNotifierTimeout is the maximum duration of sending an event if the notifier does not define a timeout.
+const defaultNotifierTimeout = 5 * time.Second
+
+// newNotifications creates the notifiers described by the given configurations. Returns nil if none are configured.
+func newNotifications(confs []NotifierConfig, logger *zap.SugaredLogger) (*notify.Dispatcher, error) {
+	if len(confs) == 0 {
+		return nil, nil
+	}
+	var notifiers []notify.Notifier
+	for _, c := range confs {
+		if c.Type != "webhook" {
+			return nil, fmt.Errorf("unsupported notifier type %s", c.Type)
+		}
+		for _, e := range c.Events {
+			if e != notify.GameSucceeded && e != notify.GameFailed {
+				return nil, fmt.Errorf("unsupported notification event %s", e)
+			}
+		}
+		timeout := defaultNotifierTimeout
+		if c.Timeout != "" {
+			var err error
+			timeout, err = time.ParseDuration(c.Timeout)
+			if err != nil {
+				return nil, err
+			}
+		}
+		webhook, err := notify.NewWebhookNotifier(c.URL, timeout)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, notify.NewFilter(webhook, c.Events))
+	}
+	return notify.NewDispatcher(logger, notifiers...), nil
+}
+
+// defaultSessionIdleTimeout is the duration after which idle computation sessions are closed if not configured.
+const defaultSessionIdleTimeout = 10 * time.Minute
+
+// defaultAuditTimeout is the maximum duration of posting an audit record if the webhook sink does not define a timeout.
+const defaultAuditTimeout = 5 * time.Second
+
+// newAuditor creates the audit sinks described by the given configurations in addition to the given sinks. Returns nil
+// if there are no sinks.
+func newAuditor(confs []AuditSinkConfig, logger *zap.SugaredLogger, sinks ...audit.Sink) (*audit.Auditor, error) {
+	if len(confs) == 0 && len(sinks) == 0 {
+		return nil, nil
+	}
+	for _, c := range confs {
+		switch c.Type {
+		case "file":
+			file, err := audit.NewFileSink(c.Path)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, file)
+		case "webhook":
+			timeout := defaultAuditTimeout
+			if c.Timeout != "" {
+				var err error
+				timeout, err = time.ParseDuration(c.Timeout)
+				if err != nil {
+					return nil, err
+				}
+			}
+			webhook, err := audit.NewWebhookSink(c.URL, timeout)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, webhook)
+		default:
+			return nil, fmt.Errorf("unsupported audit sink type %s", c.Type)
+		}
+	}
+	return audit.NewAuditor(logger, sinks...), nil
+}
+
+// newExecutionsArchive creates the archive of the executions and, if the executions are exported periodically, its
+// exporter. Returns nil if no archive is configured. The exports are uploaded to the given object store if configured
+// so.
+func newExecutionsArchive(conf *ExecutionsArchiveConfig, objectStore objectstore.AbstractClient,
+	logger *zap.SugaredLogger) (*archive.Archive, *archive.Exporter, error) {
+	if conf == nil {
+		return nil, nil, nil
+	}
+	if conf.MaxEntries < 0 {
+		return nil, nil, errors.New("the maximum number of archived executions must not be negative")
+	}
+	executions := archive.NewArchive(conf.MaxEntries)
+	if conf.ExportInterval == "" {
+		return executions, nil, nil
+	}
+	interval, err := time.ParseDuration(conf.ExportInterval)
+	if err != nil || interval <= 0 {
+		return nil, nil, fmt.Errorf("invalid archive export interval %q, must be a positive duration", conf.ExportInterval)
+	}
+	format := conf.ExportFormat
+	if format == "" {
+		format = archive.FormatCSV
+	}
+	var sink archive.Sink
+	switch {
+	case conf.ExportToObjectStore && objectStore == nil:
+		return nil, nil, errors.New("the executions cannot be exported to the object store as none is configured")
+	case conf.ExportToObjectStore:
+		sink = objectStore
+	case conf.ExportDir != "":
+		sink = archive.DirSink{Dir: conf.ExportDir}
+	default:
+		return nil, nil, errors.New("either the export directory or the object store must be set to export the executions")
+	}
+	exporter, err := archive.NewExporter(executions, sink, format, interval, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	return executions, exporter, nil
+}
+
+// newProgramAllowList creates the allow list of the programs that may be compiled. Returns nil if not configured.
+func newProgramAllowList(conf *ProgramAllowListConfig) (*allowlist.AllowList, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	return allowlist.New(conf.Hashes, conf.PublicKeys)
+}
+
+// Defaults of the tracing configuration.
+const (
+	defaultTracingServiceName = "ephemeral"
+	defaultTracingTimeout     = 5 * time.Second
+)
+
+// newTracer creates a tracer exporting spans to the configured collector. Returns nil if no collector is configured.
+func newTracer(conf TracingConfig, logger *zap.SugaredLogger) (*tracing.Tracer, error) {
+	if conf.Endpoint == "" {
+		return nil, nil
+	}
+	serviceName := conf.ServiceName
+	if serviceName == "" {
+		serviceName = defaultTracingServiceName
+	}
+	timeout := defaultTracingTimeout
+	if conf.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(conf.Timeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	exporter, err := tracing.NewOTLPExporter(conf.Endpoint, serviceName, timeout, logger)
+	if err != nil {
+		return nil, err
+	}
+	return tracing.NewTracer(exporter), nil
+}
+
+// isSupportedContentType returns true if activations can be encoded with the given media type.
+func isSupportedContentType(contentType string) bool {
+	for _, t := range SupportedContentTypes {
+		if t == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// newAmphoraClient returns a client of the configured Amphora service.
+func newAmphoraClient(conf AmphoraConfig, logger *zap.SugaredLogger) (*amphora.Client, error) {
+	amphoraURL := url.URL{
+		Host:   conf.Host,
+		Scheme: conf.Scheme,
+		Path:   conf.Path,
+	}
+	amphoraClient, err := amphora.NewClient(amphoraURL)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Retry != nil {
+		amphoraClient.Retry, err = newRetryConfig(conf.Retry, "Amphora", logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if conf.RequestTimeout != "" {
+		amphoraClient.HTTPClient.Timeout, err = time.ParseDuration(conf.RequestTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Amphora request timeout: %v", err)
+		}
+	}
+	if conf.MaxIdleConns < 0 {
+		return nil, fmt.Errorf("invalid number of idle Amphora connections %d, must not be negative", conf.MaxIdleConns)
+	}
+	if conf.MaxIdleConns > 0 {
+		amphoraClient.HTTPClient.Transport = amphora.NewPooledTransport(conf.MaxIdleConns)
+	}
+	amphoraClient.Bulk = conf.Bulk
+	return amphoraClient, nil
+}
+
+// newCastorClient returns a client of the configured Castor service.
+func newCastorClient(conf CastorConfig, logger *zap.SugaredLogger) (*castor.Client, error) {
+	castorURL := url.URL{
+		Host:   conf.Host,
+		Scheme: conf.Scheme,
+		Path:   conf.Path,
+	}
+	castorClient, err := castor.NewClient(castorURL)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Retry != nil {
+		castorClient.Retry, err = newRetryConfig(conf.Retry, "Castor", logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if conf.RequestTimeout != "" {
+		castorClient.HTTPClient.Timeout, err = time.ParseDuration(conf.RequestTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Castor request timeout: %v", err)
+		}
+	}
+	if conf.CircuitBreaker != nil {
+		castorClient.Breaker, err = retry.NewBreaker(conf.CircuitBreaker)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Castor circuit breaker config: %v", err)
+		}
+	}
+	return castorClient, nil
+}
+
+// defaultInsecureTupleCount is the number of fake tuples generated per type if not configured.
+const defaultInsecureTupleCount = 10000
+
+// parseInsecurePreprocessing returns the insecure preprocessing config if enabled, nil otherwise.
+func parseInsecurePreprocessing(conf *InsecurePreprocessingConfig) (*InsecurePreprocessingConfig, error) {
+	if conf == nil || !conf.Enabled {
+		return nil, nil
+	}
+	if conf.TupleCount < 0 {
+		return nil, fmt.Errorf("invalid tuple count %d for insecure preprocessing, must not be negative", conf.TupleCount)
+	}
+	parsed := *conf
+	if parsed.TupleCount == 0 {
+		parsed.TupleCount = defaultInsecureTupleCount
+	}
+	return &parsed, nil
+}
+
+// parseProtocols validates the declared protocols and determines the default protocol. Only mascot is supported if no
+// protocols are declared.
+func parseProtocols(protocols map[string]ProtocolConfig, defaultProtocol string) (map[string]ProtocolConfig, string, error) {
+	if len(protocols) == 0 {
+		protocols = DefaultProtocols()
+	}
+	for name, p := range protocols {
+		if err := ValidateProtocol(name, p); err != nil {
+			return nil, "", err
+		}
+	}
+	if defaultProtocol == "" {
+		if _, ok := protocols[MascotProtocol]; ok {
+			defaultProtocol = MascotProtocol
+		} else if len(protocols) == 1 {
+			for name := range protocols {
+				defaultProtocol = name
+			}
+		} else {
+			return nil, "", errors.New("the default protocol must be set if mascot is not declared")
+		}
+	}
+	if _, ok := protocols[defaultProtocol]; !ok {
+		return nil, "", fmt.Errorf("the default protocol %s is not declared", defaultProtocol)
+	}
+	return protocols, defaultProtocol, nil
+}
+
+// newRetryConfig returns the retry config for requests to the given service, logging each retry.
+func newRetryConfig(conf *RetryConfig, service string, logger *zap.SugaredLogger) (retry.Config, error) {
+	retryConf, err := retry.NewConfig(conf)
+	if err != nil {
+		return retry.Config{}, fmt.Errorf("invalid %s retry config: %v", service, err)
+	}
+	retryConf.Hooks.OnRetry = func(attempt int, delay time.Duration, err error) {
+		logger.Warnw("Retrying the request to "+service, "Attempt", attempt, "Delay", delay, "Error", err)
+	}
+	return retryConf, nil
+}
+
+// parsePartyNumbers validates that the party numbers assign a distinct MP-SPDZ party to each player. Returns nil if
+// no party numbers are configured or they equal the player IDs.
+func parsePartyNumbers(partyNumbers []int32, playerCount int32) ([]int32, error) {
+	if len(partyNumbers) == 0 {
+		return nil, nil
+	}
+	if len(partyNumbers) != int(playerCount) {
+		return nil, fmt.Errorf("the party numbers must assign a party to each of the %d players, got %d", playerCount, len(partyNumbers))
+	}
+	assigned := make([]bool, playerCount)
+	identity := true
+	for id, n := range partyNumbers {
+		if n < 0 || n >= playerCount {
+			return nil, fmt.Errorf("invalid party number %d of player %d, must be between 0 and %d", n, id, playerCount-1)
+		}
+		if assigned[n] {
+			return nil, fmt.Errorf("party number %d is assigned to more than one player", n)
+		}
+		assigned[n] = true
+		identity = identity && n == int32(id)
+	}
+	if identity {
+		return nil, nil
+	}
+	return partyNumbers, nil
+}
+
+// defaultScopesClaim is the claim holding the scopes of the callers if not configured.
+const defaultScopesClaim = "scope"
+
+// parseAuthScopes returns the authorization scopes config with the claim defaulted. Returns nil if scopes are not
+// checked.
+func parseAuthScopes(conf *AuthScopesConfig) *AuthScopesConfig {
+	if conf == nil {
+		return nil
+	}
+	parsed := *conf
+	if parsed.Claim == "" {
+		parsed.Claim = defaultScopesClaim
+	}
+	return &parsed
+}
+
+// Defaults of the adaptive tuple stock.
+const (
+	defaultMaxTupleChunks       = 16
+	defaultMaxConcurrentFetches = 4
+)
+
+// parseAdaptiveStock returns the adaptive tuple stock config with the defaults applied. The chunk size is left unset if
+// it defaults to the tuple stock, as the stock may be changed by reloads. Returns nil if the stock is not adapted.
+func parseAdaptiveStock(conf *AdaptiveStockConfig) (*AdaptiveStockConfig, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	if conf.ChunkSize < 0 || conf.MaxChunks < 0 || conf.MaxConcurrentFetches < 0 {
+		return nil, errors.New("the chunk size, the maximum number of chunks and of concurrent fetches of the adaptive tuple stock must not be negative")
+	}
+	parsed := *conf
+	if parsed.MaxChunks == 0 {
+		parsed.MaxChunks = defaultMaxTupleChunks
+	}
+	if parsed.MaxConcurrentFetches == 0 {
+		parsed.MaxConcurrentFetches = defaultMaxConcurrentFetches
+	}
+	return &parsed, nil
+}
+
+// defaultEdaBitLength is the bit length of the edaBits requested from castor if not configured.
+const defaultEdaBitLength = 64
+
+// parseTupleTypes returns the tuple types streamed from castor, i.e., the default ones with the given ones enabled or
+// disabled. Returns nil if the default tuple types are streamed.
+func parseTupleTypes(enabled map[string]bool) ([]castor.TupleType, error) {
+	if len(enabled) == 0 {
+		return nil, nil
+	}
+	for name := range enabled {
+		if _, ok := castor.TupleTypeByName(name); !ok {
+			return nil, fmt.Errorf("unknown tuple type %s", name)
+		}
+	}
+	types := []castor.TupleType{}
+	for _, tt := range castor.SupportedTupleTypes {
+		on, ok := enabled[tt.Name]
+		if !ok {
+			on = !castor.IsMixedCircuitTupleType(tt)
+		}
+		if on {
+			types = append(types, tt)
+		}
+	}
+	return types, nil
+}
+
+const (
+	// defaultWarmStreamers is the maximum number of tuple streamers kept between games if not configured.
+	defaultWarmStreamers = 64
+	// defaultWarmStreamerIdle is the maximum time tuple streamers are kept between games if not configured.
+	defaultWarmStreamerIdle = time.Minute
+)
+
+// parseWarmPool returns the warm streamer pool config with the defaults applied. Returns nil if no pool is configured.
+func parseWarmPool(conf *WarmPoolConfig) (*WarmPoolTypedConfig, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	if conf.MaxStreamers < 0 {
+		return nil, errors.New("the maximum number of warm streamers must not be negative")
+	}
+	pool := &WarmPoolTypedConfig{MaxStreamers: conf.MaxStreamers, MaxIdle: defaultWarmStreamerIdle}
+	if pool.MaxStreamers == 0 {
+		pool.MaxStreamers = defaultWarmStreamers
+	}
+	if conf.MaxIdle != "" {
+		maxIdle, err := time.ParseDuration(conf.MaxIdle)
+		if err != nil {
+			return nil, err
+		}
+		pool.MaxIdle = maxIdle
+	}
+	return pool, nil
+}
+
+// parseSimulator returns the plaintext simulator config with the default executable applied. Returns nil if the
+// simulator is not enabled.
+func parseSimulator(conf *SimulatorConfig) *SimulatorConfig {
+	if conf == nil {
+		return nil
+	}
+	simulator := &SimulatorConfig{Executable: conf.Executable, Args: conf.Args}
+	if simulator.Executable == "" {
+		simulator.Executable = DefaultSimulatorExecutable
+	}
+	return simulator
+}
+
+// newResultCache returns the store keeping the results of the finished games in the configured backend. Returns nil if
+// the results are not kept.
+func newResultCache(conf *ResultCacheConfig, amphoraClient amphora.AbstractClient, programIdentifier string) (results.Store, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	ttl := results.DefaultTTL
+	if conf.TTL != "" {
+		var err error
+		ttl, err = time.ParseDuration(conf.TTL)
+		if err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("invalid result cache ttl %q, must be a positive duration", conf.TTL)
+		}
+	}
+	switch conf.Backend {
+	case "", results.BackendMemory:
+		if conf.MaxResults < 0 {
+			return nil, errors.New("the maximum number of cached results must not be negative")
+		}
+		maxResults := conf.MaxResults
+		if maxResults == 0 {
+			maxResults = results.DefaultMaxResults
+		}
+		return results.NewMemoryStore(maxResults, ttl), nil
+	case results.BackendDisk:
+		if conf.Dir == "" {
+			return nil, errors.New("the directory of the result cache must be set for the disk backend")
+		}
+		return results.NewDiskStore(conf.Dir, ttl)
+	case results.BackendAmphora:
+		return results.NewAmphoraStore(amphoraClient, programIdentifier), nil
+	default:
+		return nil, fmt.Errorf("unsupported result cache backend %s, must be one of %s, %s or %s", conf.Backend,
+			results.BackendMemory, results.BackendDisk, results.BackendAmphora)
+	}
+}
+
+// Defaults of the provisioning of the player certificates.
+const (
+	defaultPlayerCertValidity         = 24 * time.Hour
+	defaultPlayerCertRotationInterval = time.Minute
+)
+
+// newPlayerCerts returns the provisioner keeping the certificates of the given MP-SPDZ party in the given preprocessing
+// folder. Returns nil if the certificates are not provisioned.
+func newPlayerCerts(conf *PlayerCertsConfig, prepFolder string, party int32) (*certs.Provisioner, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	interval := defaultPlayerCertRotationInterval
+	if conf.RotationInterval != "" {
+		var err error
+		interval, err = time.ParseDuration(conf.RotationInterval)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid player certificate rotation interval %q, must be a positive duration", conf.RotationInterval)
+		}
+	}
+	var source certs.Source
+	switch conf.Source {
+	case certs.SourceSecret:
+		if conf.CertFile == "" || conf.KeyFile == "" || conf.CAFile == "" {
+			return nil, errors.New("the certificate, key and CA files of the player certificates must be set for the secret source")
+		}
+		source = &certs.SecretSource{CertFile: conf.CertFile, KeyFile: conf.KeyFile, CAFile: conf.CAFile}
+	case certs.SourceCA:
+		if conf.CAFile == "" || conf.CAKeyFile == "" {
+			return nil, errors.New("the CA and CA key files of the player certificates must be set for the ca source")
+		}
+		validity := defaultPlayerCertValidity
+		if conf.Validity != "" {
+			var err error
+			validity, err = time.ParseDuration(conf.Validity)
+			if err != nil {
+				return nil, fmt.Errorf("invalid player certificate validity %q: %v", conf.Validity, err)
+			}
+		}
+		renewBefore := validity / 3
+		if conf.RenewBefore != "" {
+			var err error
+			renewBefore, err = time.ParseDuration(conf.RenewBefore)
+			if err != nil {
+				return nil, fmt.Errorf("invalid player certificate renewal %q: %v", conf.RenewBefore, err)
+			}
+		}
+		ca, err := certs.NewCASource(conf.CAFile, conf.CAKeyFile, certs.PlayerName(party), validity, renewBefore)
+		if err != nil {
+			return nil, err
+		}
+		source = ca
+	default:
+		return nil, fmt.Errorf("unsupported player certificate source %q, must be one of %s or %s", conf.Source,
+			certs.SourceSecret, certs.SourceCA)
+	}
+	cmder := utils.NewCommander()
+	rehash := func(dir string) error {
+		if _, stderr, err := cmder.CallCMD(context.TODO(), []string{"openssl rehash ."}, dir); err != nil {
+			return fmt.Errorf("error rehashing the certificates in %s: %v: %s", dir, err, stderr)
+		}
+		return nil
+	}
+	return certs.NewProvisioner(source, party, prepFolder, interval, rehash), nil
+}
+
+// defaultMaxRequestSize is the maximum size of the body of the requests if not configured.
+const defaultMaxRequestSize = 64 << 20
+
+// parseMaxRequestSize returns the maximum size of the body of the requests with the default applied. Returns zero if
+// the size is not limited.
+func parseMaxRequestSize(size int64) int64 {
+	switch {
+	case size == 0:
+		return defaultMaxRequestSize
+	case size < 0:
+		return 0
+	default:
+		return size
+	}
+}
+
+// parseMaxResultSize returns the maximum size of the kept results with the default applied. Returns zero if the size is
+// not limited or the results are not kept.
+func parseMaxResultSize(conf *ResultCacheConfig) int64 {
+	switch {
+	case conf == nil || conf.MaxResultSize < 0:
+		return 0
+	case conf.MaxResultSize == 0:
+		return results.DefaultMaxResultSize
+	default:
+		return conf.MaxResultSize
+	}
+}
+
+// parseEgressLimit validates the egress rate limit and applies the default burst. Returns nil if no limit is
+// configured.
+func parseEgressLimit(conf *EgressLimitConfig) (*EgressLimitConfig, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	if conf.BytesPerSecond <= 0 {
+		return nil, errors.New("the egress rate limit must be positive")
+	}
+	if conf.Burst < 0 {
+		return nil, errors.New("the egress burst must not be negative")
+	}
+	limit := *conf
+	if limit.Burst == 0 {
+		limit.Burst = limit.BytesPerSecond
+	}
+	return &limit, nil
+}
+
+// newPortPlan returns the ports used by the service, i.e., the ports of its APIs and the ones of the engine. The plan is
+// returned along with the error if the ports are in conflict, so that the allocation can be reported.
+func newPortPlan(conf *SPDZEngineConfig, typedConfig *SPDZEngineTypedConfig) (*portplan.Plan, error) {
+	plan, err := NewPortPlan(typedConfig)
+	if err != nil {
+		return plan, err
+	}
+	if err := plan.AddRange("HTTP API", defaultPort); err != nil {
+		return plan, err
+	}
+	if err := plan.AddRange("gRPC API", conf.GRPCPort); err != nil {
+		return plan, err
+	}
+	return plan, plan.Validate()
+}
+
+// Defaults of the proxy compression.
+const (
+	defaultCompressionLevel   = flate.BestSpeed
+	defaultInternalPortOffset = 20000
+)
+
+// parseProxyCompression validates the proxy compression config and applies the defaults. Returns nil if compression
+// is not configured.
+func parseProxyCompression(conf *ProxyCompressionConfig) (*ProxyCompressionConfig, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	c := *conf
+	if c.Level == 0 {
+		c.Level = defaultCompressionLevel
+	}
+	if c.Level < flate.BestSpeed || c.Level > flate.BestCompression {
+		return nil, fmt.Errorf("the compression level must be between %d and %d", flate.BestSpeed, flate.BestCompression)
+	}
+	if c.InternalPortOffset < 0 {
+		return nil, errors.New("the internal port offset must not be negative")
+	}
+	if c.InternalPortOffset == 0 {
+		c.InternalPortOffset = defaultInternalPortOffset
+	}
+	return &c, nil
+}
+
+// defaultDecisionCacheSize is the number of cached admission decisions if not configured.
+const defaultDecisionCacheSize = 1000
+
+// newAdmissionPolicy returns the policy deciding on the admission of activations, which caches the decisions of the
+// given client if configured. Returns nil if activations are not checked on admission.
+func newAdmissionPolicy(conf OpaConfig, client opa.Decider) (opa.Decider, error) {
+	if !conf.Admission {
+		return nil, nil
+	}
+	if conf.DecisionCacheTTL == "" {
+		return client, nil
+	}
+	ttl, err := time.ParseDuration(conf.DecisionCacheTTL)
+	if err != nil || ttl <= 0 {
+		return nil, fmt.Errorf("invalid decision cache TTL %q, must be a positive duration", conf.DecisionCacheTTL)
+	}
+	size := conf.DecisionCacheSize
+	if size < 0 {
+		return nil, fmt.Errorf("invalid decision cache size %d, must not be negative", size)
+	}
+	if size == 0 {
+		size = defaultDecisionCacheSize
+	}
+	return opa.NewDecisionCache(client, ttl, size), nil
+}
+
+// parseColocation validates the colocation config. Returns nil if not configured.
+func parseColocation(conf *ColocationConfig) (*ColocationConfig, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	if conf.Cluster == "" {
+		return nil, errors.New("the cluster of the colocation config must be set")
+	}
+	if net.ParseIP(conf.PodIP) == nil {
+		return nil, fmt.Errorf("invalid pod IP %q in the colocation config", conf.PodIP)
+	}
+	return conf, nil
+}
+
+// defaultTupleCacheTTL is the time tuples are kept in the tuple cache if not configured.
+const defaultTupleCacheTTL = time.Hour
+
+// newTupleCache creates the cache of the tuples fetched, but not consumed. Returns nil if no tuple cache is configured.
+func newTupleCache(conf *TupleCacheConfig) (*castor.TupleCache, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	if conf.Directory == "" {
+		return nil, errors.New("the directory of the tuple cache must be set")
+	}
+	ttl := defaultTupleCacheTTL
+	if conf.TTL != "" {
+		var err error
+		ttl, err = time.ParseDuration(conf.TTL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return castor.NewTupleCache(conf.Directory, ttl)
+}
+
+// defaultRetryAfter is the duration clients are asked to wait before retrying requests rejected with 503 if not
+// configured.
+const defaultRetryAfter = 5 * time.Second
+
+// parseReadiness returns the readiness config with the defaults applied. The service is ready unless draining if no
+// readiness config is given.
+func parseReadiness(conf *ReadinessConfig) (ReadinessTypedConfig, error) {
+	readiness := ReadinessTypedConfig{RetryAfter: defaultRetryAfter}
+	if conf == nil {
+		return readiness, nil
+	}
+	if conf.MaxGames < 0 {
+		return readiness, errors.New("the maximum number of games must not be negative")
+	}
+	readiness.NotReadyWhileCompiling = conf.NotReadyWhileCompiling
+	readiness.MaxGames = conf.MaxGames
+	if conf.RetryAfter != "" {
+		retryAfter, err := time.ParseDuration(conf.RetryAfter)
+		if err != nil {
+			return readiness, err
+		}
+		if retryAfter <= 0 {
+			return readiness, errors.New("the retry after duration must be positive")
+		}
+		readiness.RetryAfter = retryAfter
+	}
+	return readiness, nil
+}
+
+// newObjectStoreClient creates a client of the configured object store. Returns nil if no object store is configured.
+// The credentials are taken from the environment if set.
+func newObjectStoreClient(conf *ObjectStoreConfig) (objectstore.AbstractClient, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	accessKeyID, ok := os.LookupEnv("EPHEMERAL_OBJECT_STORE_ACCESS_KEY_ID")
+	if !ok {
+		accessKeyID = conf.AccessKeyID
+	}
+	secretAccessKey, ok := os.LookupEnv("EPHEMERAL_OBJECT_STORE_SECRET_ACCESS_KEY")
+	if !ok {
+		secretAccessKey = conf.SecretAccessKey
+	}
+	var urlExpiry, timeout time.Duration
+	var err error
+	if conf.URLExpiry != "" {
+		urlExpiry, err = time.ParseDuration(conf.URLExpiry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid object store URL expiry: %v", err)
+		}
+	}
+	if conf.Timeout != "" {
+		timeout, err = time.ParseDuration(conf.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid object store timeout: %v", err)
+		}
+	}
+	client, err := objectstore.NewClient(objectstore.Config{
+		Endpoint:           conf.Endpoint,
+		Region:             conf.Region,
+		Bucket:             conf.Bucket,
+		Prefix:             conf.Prefix,
+		VirtualHostedStyle: conf.VirtualHostedStyle,
+		AccessKeyID:        accessKeyID,
+		SecretAccessKey:    secretAccessKey,
+		URLExpiry:          urlExpiry,
+		Timeout:            timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
@@ -6,19 +6,29 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"net"
+	"net/http"
 	"os"
+	"syscall"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"github.com/carbynestack/ephemeral/pkg/castor"
+	"github.com/carbynestack/ephemeral/pkg/certs"
+	. "github.com/carbynestack/ephemeral/pkg/ephemeral"
+	"github.com/carbynestack/ephemeral/pkg/opa"
+	"github.com/carbynestack/ephemeral/pkg/results"
 	. "github.com/carbynestack/ephemeral/pkg/types"
 	"github.com/carbynestack/ephemeral/pkg/utils"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 var _ = Describe("Main", func() {
@@ -89,6 +99,19 @@ var _ = Describe("Main", func() {
 						Expect(err).NotTo(HaveOccurred())
 						Expect(conf.RetrySleep).To(Equal("50ms"))
 					})
+					It("applies the overrides of the environment", func() {
+						os.Setenv("EPHEMERAL_PLAYER_ID", "1")
+						os.Setenv("EPHEMERAL_CASTOR_CONFIG_TUPLE_STOCK", "500")
+						defer os.Unsetenv("EPHEMERAL_PLAYER_ID")
+						defer os.Unsetenv("EPHEMERAL_CASTOR_CONFIG_TUPLE_STOCK")
+						data := []byte(`{"playerID": 0, "castorConfig": {"host": "castor", "tupleStock": 1000}}`)
+						Expect(ioutil.WriteFile(path, data, 0644)).To(Succeed())
+						conf, err := ParseConfig(path)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(conf.PlayerID).To(Equal(int32(1)))
+						Expect(conf.CastorConfig.Host).To(Equal("castor"))
+						Expect(conf.CastorConfig.TupleStock).To(Equal(int32(500)))
+					})
 				})
 				Context("when JSON format is corrupt", func() {
 					It("returns an error", func() {
@@ -141,6 +164,7 @@ var _ = Describe("Main", func() {
 					},
 					StateTimeout:       "5s",
 					ComputationTimeout: "10s",
+					PlayerCount:        2,
 				}
 				typedConf, err := InitTypedConfig(conf, logger)
 				Expect(err).NotTo(HaveOccurred())
@@ -148,6 +172,612 @@ var _ = Describe("Main", func() {
 				Expect(typedConf.RetrySleep).To(Equal(1 * time.Second))
 				Expect(typedConf.StateTimeout).To(Equal(5 * time.Second))
 				Expect(typedConf.ComputationTimeout).To(Equal(10 * time.Second))
+				Expect(typedConf.StateTimeouts).To(BeNil())
+				Expect(typedConf.ProxyPortRange).To(Equal("5002:5009"))
+			})
+			It("rejects the warm pool combined with per-game work directories", func() {
+				conf := &SPDZEngineConfig{
+					ProgramIdentifier:       "ephemeral-generic",
+					NetworkEstablishTimeout: "2s",
+					RetrySleep:              "1s",
+					Prime:                   "198766463529478683931867765928436695041",
+					RInv:                    "133854242216446749056083838363708373830",
+					GfpMacKey:               "1113507028231509545156335486838233835",
+					Gf2nBitLength:           40,
+					Gf2nStorageSize:         8,
+					OpaConfig: OpaConfig{
+						Endpoint:      "http://opa.carbynestack.io",
+						PolicyPackage: "carbynestack.def",
+					},
+					AmphoraConfig: AmphoraConfig{
+						Host:   "localhost",
+						Scheme: "http",
+						Path:   "amphoraPath",
+					},
+					CastorConfig: CastorConfig{
+						Host:   "localhost",
+						Scheme: "http",
+						Path:   "castorPath",
+					},
+					DiscoveryConfig: DiscoveryClientConfig{
+						Host:           "localhost",
+						Port:           "8080",
+						ConnectTimeout: "0s",
+					},
+					StateTimeout:       "5s",
+					ComputationTimeout: "10s",
+					GameWorkDirs:       &GameWorkDirsConfig{},
+				}
+				conf.CastorConfig.WarmPool = &WarmPoolConfig{}
+				_, err := InitTypedConfig(conf, logger)
+				Expect(err).To(MatchError("the warm pool cannot be used with per-game work directories"))
+			})
+			It("rejects TLS without player certificates", func() {
+				conf := &SPDZEngineConfig{
+					ProgramIdentifier:       "ephemeral-generic",
+					NetworkEstablishTimeout: "2s",
+					RetrySleep:              "1s",
+					Prime:                   "198766463529478683931867765928436695041",
+					RInv:                    "133854242216446749056083838363708373830",
+					GfpMacKey:               "1113507028231509545156335486838233835",
+					Gf2nBitLength:           40,
+					Gf2nStorageSize:         8,
+					OpaConfig: OpaConfig{
+						Endpoint:      "http://opa.carbynestack.io",
+						PolicyPackage: "carbynestack.def",
+					},
+					AmphoraConfig: AmphoraConfig{
+						Host:   "localhost",
+						Scheme: "http",
+						Path:   "amphoraPath",
+					},
+					CastorConfig: CastorConfig{
+						Host:   "localhost",
+						Scheme: "http",
+						Path:   "castorPath",
+					},
+					DiscoveryConfig: DiscoveryClientConfig{
+						Host:           "localhost",
+						Port:           "8080",
+						ConnectTimeout: "0s",
+					},
+					StateTimeout:       "5s",
+					ComputationTimeout: "10s",
+					TlsEnabled:         true,
+				}
+				_, err := InitTypedConfig(conf, logger)
+				Expect(err).To(MatchError("the player certificates must be configured if TLS is enabled"))
+			})
+			It("rejects TLS with proxy compression", func() {
+				conf := &SPDZEngineConfig{
+					ProgramIdentifier:       "ephemeral-generic",
+					NetworkEstablishTimeout: "2s",
+					RetrySleep:              "1s",
+					Prime:                   "198766463529478683931867765928436695041",
+					RInv:                    "133854242216446749056083838363708373830",
+					GfpMacKey:               "1113507028231509545156335486838233835",
+					Gf2nBitLength:           40,
+					Gf2nStorageSize:         8,
+					OpaConfig: OpaConfig{
+						Endpoint:      "http://opa.carbynestack.io",
+						PolicyPackage: "carbynestack.def",
+					},
+					AmphoraConfig: AmphoraConfig{
+						Host:   "localhost",
+						Scheme: "http",
+						Path:   "amphoraPath",
+					},
+					CastorConfig: CastorConfig{
+						Host:   "localhost",
+						Scheme: "http",
+						Path:   "castorPath",
+					},
+					DiscoveryConfig: DiscoveryClientConfig{
+						Host:           "localhost",
+						Port:           "8080",
+						ConnectTimeout: "0s",
+					},
+					StateTimeout:       "5s",
+					ComputationTimeout: "10s",
+					TlsEnabled:         true,
+					ProxyCompression:   &ProxyCompressionConfig{},
+					PlayerCerts: &PlayerCertsConfig{
+						Source:   certs.SourceSecret,
+						CertFile: "tls.crt",
+						KeyFile:  "tls.key",
+						CAFile:   "ca.crt",
+					},
+				}
+				_, err := InitTypedConfig(conf, logger)
+				Expect(err).To(MatchError("the proxy compression cannot be used with TLS"))
+			})
+			Context("when per-state timeouts are specified", func() {
+				It("parses them", func() {
+					timeouts, err := parseStateTimeouts(map[string]string{Registering: "1m", Playing: "2h"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(timeouts).To(Equal(map[string]time.Duration{Registering: time.Minute, Playing: 2 * time.Hour}))
+				})
+				It("rejects unsupported states", func() {
+					_, err := parseStateTimeouts(map[string]string{"TCPCheck": "1m"})
+					Expect(err).To(MatchError("unsupported state TCPCheck in state timeouts, supported are Registering and Playing"))
+				})
+				It("rejects invalid timeouts", func() {
+					_, err := parseStateTimeouts(map[string]string{Registering: "0s"})
+					Expect(err).To(MatchError("the timeout for state Registering must be positive"))
+				})
+			})
+			Context("when creating the amphora client", func() {
+				conf := AmphoraConfig{Host: "localhost", Scheme: "http", Path: "amphoraPath"}
+				It("applies the request timeout, the connection pool and the bulk mode", func() {
+					conf := conf
+					conf.RequestTimeout = "10s"
+					conf.MaxIdleConns = 16
+					conf.Bulk = true
+					client, err := newAmphoraClient(conf, logger)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(client.HTTPClient.Timeout).To(Equal(10 * time.Second))
+					Expect(client.HTTPClient.Transport.(*http.Transport).MaxIdleConnsPerHost).To(Equal(16))
+					Expect(client.Bulk).To(BeTrue())
+				})
+				It("rejects invalid settings", func() {
+					conf := conf
+					conf.RequestTimeout = "soon"
+					_, err := newAmphoraClient(conf, logger)
+					Expect(err.Error()).To(HavePrefix("invalid Amphora request timeout"))
+					conf.RequestTimeout = ""
+					conf.MaxIdleConns = -1
+					_, err = newAmphoraClient(conf, logger)
+					Expect(err).To(MatchError("invalid number of idle Amphora connections -1, must not be negative"))
+				})
+			})
+			Context("when creating the castor client", func() {
+				conf := CastorConfig{Host: "localhost", Scheme: "http", Path: "castorPath"}
+				It("applies the request timeout and the circuit breaker", func() {
+					conf := conf
+					conf.RequestTimeout = "10s"
+					conf.CircuitBreaker = &CircuitBreakerConfig{FailureThreshold: 3}
+					client, err := newCastorClient(conf, logger)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(client.HTTPClient.Timeout).To(Equal(10 * time.Second))
+					Expect(client.Breaker).NotTo(BeNil())
+				})
+				It("sends all requests without a circuit breaker", func() {
+					client, err := newCastorClient(conf, logger)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(client.Breaker).To(BeNil())
+				})
+				It("rejects invalid settings", func() {
+					conf := conf
+					conf.RequestTimeout = "soon"
+					_, err := newCastorClient(conf, logger)
+					Expect(err.Error()).To(HavePrefix("invalid Castor request timeout"))
+					conf.RequestTimeout = ""
+					conf.CircuitBreaker = &CircuitBreakerConfig{FailureThreshold: -1}
+					_, err = newCastorClient(conf, logger)
+					Expect(err.Error()).To(HavePrefix("invalid Castor circuit breaker config"))
+				})
+			})
+			Context("when insecure preprocessing is configured", func() {
+				It("is disabled if not enabled explicitly", func() {
+					conf, err := parseInsecurePreprocessing(&InsecurePreprocessingConfig{TupleCount: 10})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(conf).To(BeNil())
+				})
+				It("defaults the tuple count", func() {
+					conf, err := parseInsecurePreprocessing(&InsecurePreprocessingConfig{Enabled: true})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(conf.TupleCount).To(Equal(defaultInsecureTupleCount))
+				})
+				It("rejects a negative tuple count", func() {
+					_, err := parseInsecurePreprocessing(&InsecurePreprocessingConfig{Enabled: true, TupleCount: -1})
+					Expect(err).To(MatchError("invalid tuple count -1 for insecure preprocessing, must not be negative"))
+				})
+			})
+			Context("when validating the gf2n parameters", func() {
+				It("disables gf2n if the MAC key or bit length is missing", func() {
+					disabled, err := validateGf2n(&SPDZEngineConfig{Gf2nBitLength: 40, Gf2nStorageSize: 8}, logger)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(disabled).To(BeTrue())
+					disabled, err = validateGf2n(&SPDZEngineConfig{Gf2nMacKey: "key", Gf2nStorageSize: 8}, logger)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(disabled).To(BeTrue())
+				})
+				It("enables gf2n if it is configured", func() {
+					disabled, err := validateGf2n(&SPDZEngineConfig{Gf2nMacKey: "key", Gf2nBitLength: 40, Gf2nStorageSize: 16}, logger)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(disabled).To(BeFalse())
+				})
+				It("rejects an invalid storage size", func() {
+					_, err := validateGf2n(&SPDZEngineConfig{Gf2nMacKey: "key", Gf2nBitLength: 40}, logger)
+					Expect(err).To(MatchError("invalid gf2n storage size 0, must be 8 or 16"))
+				})
+			})
+			Context("when protocols are declared", func() {
+				It("supports mascot only if none are declared", func() {
+					protocols, defaultProtocol, err := parseProtocols(nil, "")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(protocols).To(Equal(DefaultProtocols()))
+					Expect(defaultProtocol).To(Equal(MascotProtocol))
+				})
+				It("defaults to the only declared protocol", func() {
+					semi := map[string]ProtocolConfig{"semi": {Executable: "./Semi-Party.x"}}
+					_, defaultProtocol, err := parseProtocols(semi, "")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(defaultProtocol).To(Equal("semi"))
+				})
+				It("requires the default protocol if it is ambiguous", func() {
+					protocols := map[string]ProtocolConfig{"semi": {Executable: "./Semi-Party.x"}, "hemi": {Executable: "./Hemi-Party.x"}}
+					_, _, err := parseProtocols(protocols, "")
+					Expect(err).To(MatchError("the default protocol must be set if mascot is not declared"))
+					_, _, err = parseProtocols(protocols, "shamir")
+					Expect(err).To(MatchError("the default protocol shamir is not declared"))
+				})
+				It("rejects protocols requiring unsupported tuple families", func() {
+					protocols := map[string]ProtocolConfig{"cowgear": {Executable: "./Cowgear-Party.x", TupleFamilies: []string{"Dabits"}}}
+					_, _, err := parseProtocols(protocols, "")
+					Expect(err).To(MatchError("protocol cowgear requires the unsupported tuple family Dabits"))
+				})
+			})
+			Context("when audit sinks are configured", func() {
+				It("is disabled if none are configured", func() {
+					auditor, err := newAuditor(nil, logger)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(auditor).To(BeNil())
+				})
+				It("creates the file and webhook sinks", func() {
+					dir, err := ioutil.TempDir("", "audit")
+					Expect(err).NotTo(HaveOccurred())
+					defer os.RemoveAll(dir)
+					auditor, err := newAuditor([]AuditSinkConfig{
+						{Type: "file", Path: dir + "/audit.log"},
+						{Type: "webhook", URL: "http://siem", Timeout: "1s"},
+					}, logger)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(auditor).NotTo(BeNil())
+				})
+				It("rejects unsupported sinks", func() {
+					_, err := newAuditor([]AuditSinkConfig{{Type: "s3", URL: "s3://bucket"}}, logger)
+					Expect(err).To(MatchError("unsupported audit sink type s3"))
+				})
+			})
+			Context("when party numbers are configured", func() {
+				It("maps the players to the parties", func() {
+					partyNumbers, err := parsePartyNumbers([]int32{1, 0}, 2)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(partyNumbers).To(Equal([]int32{1, 0}))
+				})
+				It("omits an identity mapping", func() {
+					partyNumbers, err := parsePartyNumbers([]int32{0, 1}, 2)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(partyNumbers).To(BeNil())
+				})
+				It("requires a party for each player", func() {
+					_, err := parsePartyNumbers([]int32{0}, 2)
+					Expect(err).To(MatchError("the party numbers must assign a party to each of the 2 players, got 1"))
+				})
+				It("rejects party numbers out of range", func() {
+					_, err := parsePartyNumbers([]int32{0, 2}, 2)
+					Expect(err).To(MatchError("invalid party number 2 of player 1, must be between 0 and 1"))
+				})
+				It("rejects parties assigned to several players", func() {
+					_, err := parsePartyNumbers([]int32{1, 1}, 2)
+					Expect(err).To(MatchError("party number 1 is assigned to more than one player"))
+				})
+			})
+			Context("when the tuple stock is adapted", func() {
+				It("applies the defaults", func() {
+					conf, err := parseAdaptiveStock(&AdaptiveStockConfig{DoubleBuffering: true})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(conf).To(Equal(&AdaptiveStockConfig{
+						MaxChunks:            defaultMaxTupleChunks,
+						MaxConcurrentFetches: defaultMaxConcurrentFetches,
+						DoubleBuffering:      true,
+					}))
+				})
+				It("rejects negative values", func() {
+					_, err := parseAdaptiveStock(&AdaptiveStockConfig{MaxChunks: -1})
+					Expect(err).To(HaveOccurred())
+				})
+			})
+			Context("when tuple types are enabled or disabled", func() {
+				It("streams the default tuple types if none are configured", func() {
+					types, err := parseTupleTypes(nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(types).To(BeNil())
+				})
+				It("enables mixed-circuit and disables other tuple types", func() {
+					types, err := parseTupleTypes(map[string]bool{"DABIT_GFP": true, "BIT_GF2N": false})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(types).To(ContainElement(castor.DaBitGfp))
+					Expect(types).NotTo(ContainElement(castor.EdaBitGfp))
+					Expect(types).NotTo(ContainElement(castor.BitGf2n))
+					Expect(types).To(HaveLen(len(castor.DefaultTupleTypes())))
+				})
+				It("rejects unknown tuple types", func() {
+					_, err := parseTupleTypes(map[string]bool{"TRIPLE": true})
+					Expect(err).To(MatchError("unknown tuple type TRIPLE"))
+				})
+			})
+			Context("when a warm streamer pool is configured", func() {
+				It("is disabled if none is configured", func() {
+					pool, err := parseWarmPool(nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(pool).To(BeNil())
+				})
+				It("applies the defaults", func() {
+					pool, err := parseWarmPool(&WarmPoolConfig{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(pool).To(Equal(&WarmPoolTypedConfig{MaxStreamers: defaultWarmStreamers, MaxIdle: defaultWarmStreamerIdle}))
+				})
+				It("parses the settings", func() {
+					pool, err := parseWarmPool(&WarmPoolConfig{MaxStreamers: 8, MaxIdle: "30s"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(pool).To(Equal(&WarmPoolTypedConfig{MaxStreamers: 8, MaxIdle: 30 * time.Second}))
+				})
+				It("rejects invalid settings", func() {
+					_, err := parseWarmPool(&WarmPoolConfig{MaxStreamers: -1})
+					Expect(err).To(HaveOccurred())
+					_, err = parseWarmPool(&WarmPoolConfig{MaxIdle: "a while"})
+					Expect(err).To(HaveOccurred())
+				})
+			})
+			Context("when the result cache is configured", func() {
+				It("does not cache the results if not configured", func() {
+					store, err := newResultCache(nil, nil, "")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(store).To(BeNil())
+				})
+				It("keeps the results in memory by default", func() {
+					store, err := newResultCache(&ResultCacheConfig{}, nil, "")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(store).To(BeAssignableToTypeOf(&results.MemoryStore{}))
+				})
+				It("rejects invalid settings", func() {
+					_, err := newResultCache(&ResultCacheConfig{Backend: "tape"}, nil, "")
+					Expect(err).To(HaveOccurred())
+					_, err = newResultCache(&ResultCacheConfig{TTL: "a while"}, nil, "")
+					Expect(err).To(HaveOccurred())
+					_, err = newResultCache(&ResultCacheConfig{MaxResults: -1}, nil, "")
+					Expect(err).To(HaveOccurred())
+					_, err = newResultCache(&ResultCacheConfig{Backend: results.BackendDisk}, nil, "")
+					Expect(err).To(HaveOccurred())
+				})
+				It("limits the size of the results", func() {
+					Expect(parseMaxResultSize(nil)).To(BeZero())
+					Expect(parseMaxResultSize(&ResultCacheConfig{})).To(Equal(int64(results.DefaultMaxResultSize)))
+					Expect(parseMaxResultSize(&ResultCacheConfig{MaxResultSize: -1})).To(BeZero())
+					Expect(parseMaxResultSize(&ResultCacheConfig{MaxResultSize: 1024})).To(Equal(int64(1024)))
+				})
+			})
+			Context("when the player certificates are configured", func() {
+				It("does not provision them if not configured", func() {
+					p, err := newPlayerCerts(nil, "Player-Data", 0)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(p).To(BeNil())
+				})
+				It("provisions them from a secret", func() {
+					p, err := newPlayerCerts(&PlayerCertsConfig{Source: certs.SourceSecret, CertFile: "tls.crt", KeyFile: "tls.key", CAFile: "ca.crt"}, "Player-Data", 0)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(p).NotTo(BeNil())
+				})
+				It("rejects invalid settings", func() {
+					_, err := newPlayerCerts(&PlayerCertsConfig{Source: "vault"}, "Player-Data", 0)
+					Expect(err).To(HaveOccurred())
+					_, err = newPlayerCerts(&PlayerCertsConfig{Source: certs.SourceSecret, CertFile: "tls.crt"}, "Player-Data", 0)
+					Expect(err).To(HaveOccurred())
+					_, err = newPlayerCerts(&PlayerCertsConfig{Source: certs.SourceCA, CAFile: "ca.crt"}, "Player-Data", 0)
+					Expect(err).To(HaveOccurred())
+					_, err = newPlayerCerts(&PlayerCertsConfig{Source: certs.SourceCA, CAFile: "ca.crt", CAKeyFile: "ca.key", Validity: "1h", RenewBefore: "2h"}, "Player-Data", 0)
+					Expect(err).To(HaveOccurred())
+					_, err = newPlayerCerts(&PlayerCertsConfig{Source: certs.SourceCA, CAFile: "ca.crt", CAKeyFile: "ca.key", RotationInterval: "0s"}, "Player-Data", 0)
+					Expect(err).To(HaveOccurred())
+				})
+			})
+			Context("when the maximum request size is configured", func() {
+				It("applies the default if not set", func() {
+					Expect(parseMaxRequestSize(0)).To(Equal(int64(defaultMaxRequestSize)))
+				})
+				It("lifts the limit if negative", func() {
+					Expect(parseMaxRequestSize(-1)).To(BeZero())
+					Expect(parseMaxRequestSize(1024)).To(Equal(int64(1024)))
+				})
+			})
+			Context("when the plaintext simulator is configured", func() {
+				It("is disabled if not configured", func() {
+					Expect(parseSimulator(nil)).To(BeNil())
+				})
+				It("applies the default executable", func() {
+					Expect(parseSimulator(&SimulatorConfig{Args: []string{"-v"}})).To(Equal(&SimulatorConfig{Executable: DefaultSimulatorExecutable, Args: []string{"-v"}}))
+				})
+			})
+			Context("when an egress limit is configured", func() {
+				It("is disabled if none is configured", func() {
+					limit, err := parseEgressLimit(nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(limit).To(BeNil())
+				})
+				It("defaults the burst to the rate", func() {
+					limit, err := parseEgressLimit(&EgressLimitConfig{BytesPerSecond: 1024})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(limit).To(Equal(&EgressLimitConfig{BytesPerSecond: 1024, Burst: 1024}))
+				})
+				It("rejects invalid settings", func() {
+					_, err := parseEgressLimit(&EgressLimitConfig{})
+					Expect(err).To(MatchError("the egress rate limit must be positive"))
+					_, err = parseEgressLimit(&EgressLimitConfig{BytesPerSecond: 1024, Burst: -1})
+					Expect(err).To(MatchError("the egress burst must not be negative"))
+				})
+			})
+			Context("when the admission policy is configured", func() {
+				client := &opa.Client{}
+				It("is disabled if not configured", func() {
+					policy, err := newAdmissionPolicy(OpaConfig{}, client)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(policy).To(BeNil())
+				})
+				It("uses the client if no decision cache is configured", func() {
+					policy, err := newAdmissionPolicy(OpaConfig{Admission: true}, client)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(policy).To(Equal(client))
+				})
+				It("caches the decisions if configured", func() {
+					policy, err := newAdmissionPolicy(OpaConfig{Admission: true, DecisionCacheTTL: "1m"}, client)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(policy).To(BeAssignableToTypeOf(&opa.DecisionCache{}))
+				})
+				It("rejects invalid settings", func() {
+					_, err := newAdmissionPolicy(OpaConfig{Admission: true, DecisionCacheTTL: "-1m"}, client)
+					Expect(err).To(MatchError(`invalid decision cache TTL "-1m", must be a positive duration`))
+					_, err = newAdmissionPolicy(OpaConfig{Admission: true, DecisionCacheTTL: "1m", DecisionCacheSize: -1}, client)
+					Expect(err).To(MatchError("invalid decision cache size -1, must not be negative"))
+				})
+			})
+			Context("when the executions archive is configured", func() {
+				It("is disabled if not configured", func() {
+					executions, exporter, err := newExecutionsArchive(nil, nil, logger)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(executions).To(BeNil())
+					Expect(exporter).To(BeNil())
+				})
+				It("keeps the executions without exporting them if no interval is set", func() {
+					executions, exporter, err := newExecutionsArchive(&ExecutionsArchiveConfig{}, nil, logger)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(executions).NotTo(BeNil())
+					Expect(exporter).To(BeNil())
+				})
+				It("exports the executions if configured", func() {
+					_, exporter, err := newExecutionsArchive(&ExecutionsArchiveConfig{ExportInterval: "1h", ExportDir: "/tmp"}, nil, logger)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(exporter).NotTo(BeNil())
+				})
+				It("rejects invalid settings", func() {
+					_, _, err := newExecutionsArchive(&ExecutionsArchiveConfig{MaxEntries: -1}, nil, logger)
+					Expect(err).To(MatchError("the maximum number of archived executions must not be negative"))
+					_, _, err = newExecutionsArchive(&ExecutionsArchiveConfig{ExportInterval: "0s", ExportDir: "/tmp"}, nil, logger)
+					Expect(err).To(MatchError(`invalid archive export interval "0s", must be a positive duration`))
+					_, _, err = newExecutionsArchive(&ExecutionsArchiveConfig{ExportInterval: "1h", ExportFormat: "xml", ExportDir: "/tmp"}, nil, logger)
+					Expect(err).To(MatchError("unsupported export format xml, must be csv or json"))
+					_, _, err = newExecutionsArchive(&ExecutionsArchiveConfig{ExportInterval: "1h", ExportToObjectStore: true}, nil, logger)
+					Expect(err).To(MatchError("the executions cannot be exported to the object store as none is configured"))
+					_, _, err = newExecutionsArchive(&ExecutionsArchiveConfig{ExportInterval: "1h"}, nil, logger)
+					Expect(err).To(MatchError("either the export directory or the object store must be set to export the executions"))
+				})
+			})
+			Context("when the program allow list is configured", func() {
+				It("is disabled if not configured", func() {
+					allowList, err := newProgramAllowList(nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(allowList).To(BeNil())
+				})
+				It("rejects an empty allow list", func() {
+					_, err := newProgramAllowList(&ProgramAllowListConfig{})
+					Expect(err).To(MatchError("at least one program hash or public key must be allow-listed"))
+				})
+			})
+			Context("when planning the ports", func() {
+				It("includes the ports of the APIs", func() {
+					plan, err := newPortPlan(&SPDZEngineConfig{GRPCPort: "9090"}, &SPDZEngineTypedConfig{PlayerCount: 2})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(plan.String()).To(ContainSubstring("8080       HTTP API\n9090       gRPC API\n"))
+				})
+				It("fails for APIs using the ports of MP-SPDZ", func() {
+					_, err := newPortPlan(&SPDZEngineConfig{GRPCPort: "5001"}, &SPDZEngineTypedConfig{PlayerCount: 2})
+					Expect(err).To(MatchError("the ports 5000:5001 of the MP-SPDZ players and proxies overlap with the ports 5001 of the gRPC API"))
+				})
+			})
+			Context("when the colocation is configured", func() {
+				It("is disabled if not configured", func() {
+					colocation, err := parseColocation(nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(colocation).To(BeNil())
+				})
+				It("rejects invalid settings", func() {
+					_, err := parseColocation(&ColocationConfig{PodIP: "10.1.0.1"})
+					Expect(err).To(MatchError("the cluster of the colocation config must be set"))
+					_, err = parseColocation(&ColocationConfig{Cluster: "dev", PodIP: "pod"})
+					Expect(err).To(MatchError(`invalid pod IP "pod" in the colocation config`))
+				})
+			})
+			Context("when the proxy compression is configured", func() {
+				It("is disabled if not configured", func() {
+					compression, err := parseProxyCompression(nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(compression).To(BeNil())
+				})
+				It("applies the defaults", func() {
+					compression, err := parseProxyCompression(&ProxyCompressionConfig{ByDefault: true})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(compression).To(Equal(&ProxyCompressionConfig{Level: 1, ByDefault: true, InternalPortOffset: 20000}))
+				})
+				It("rejects invalid settings", func() {
+					_, err := parseProxyCompression(&ProxyCompressionConfig{Level: 10})
+					Expect(err).To(MatchError("the compression level must be between 1 and 9"))
+					_, err = parseProxyCompression(&ProxyCompressionConfig{InternalPortOffset: -1})
+					Expect(err).To(MatchError("the internal port offset must not be negative"))
+				})
+			})
+			Context("when a tuple cache is configured", func() {
+				It("is disabled if none is configured", func() {
+					cache, err := newTupleCache(nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(cache).To(BeNil())
+				})
+				It("creates the cache directory", func() {
+					dir, err := ioutil.TempDir("", "tuple-cache")
+					Expect(err).NotTo(HaveOccurred())
+					defer os.RemoveAll(dir)
+					cache, err := newTupleCache(&TupleCacheConfig{Directory: dir + "/tuples", TTL: "10m"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(cache).NotTo(BeNil())
+					Expect(dir + "/tuples").To(BeADirectory())
+				})
+				It("rejects invalid settings", func() {
+					_, err := newTupleCache(&TupleCacheConfig{})
+					Expect(err).To(HaveOccurred())
+					_, err = newTupleCache(&TupleCacheConfig{Directory: "/tmp", TTL: "forever"})
+					Expect(err).To(HaveOccurred())
+				})
+			})
+			Context("when the readiness is configured", func() {
+				It("asks clients to retry after 5 seconds by default", func() {
+					readiness, err := parseReadiness(nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(readiness).To(Equal(ReadinessTypedConfig{RetryAfter: defaultRetryAfter}))
+				})
+				It("parses the settings", func() {
+					readiness, err := parseReadiness(&ReadinessConfig{NotReadyWhileCompiling: true, MaxGames: 2, RetryAfter: "30s"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(readiness).To(Equal(ReadinessTypedConfig{NotReadyWhileCompiling: true, MaxGames: 2, RetryAfter: 30 * time.Second}))
+				})
+				It("rejects invalid settings", func() {
+					_, err := parseReadiness(&ReadinessConfig{MaxGames: -1})
+					Expect(err).To(HaveOccurred())
+					_, err = parseReadiness(&ReadinessConfig{RetryAfter: "0s"})
+					Expect(err).To(HaveOccurred())
+				})
+			})
+			Context("when an object store is configured", func() {
+				It("is disabled if none is configured", func() {
+					client, err := newObjectStoreClient(nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(client).To(BeNil())
+				})
+				It("creates the client", func() {
+					client, err := newObjectStoreClient(&ObjectStoreConfig{
+						Endpoint:        "http://minio:9000",
+						Bucket:          "results",
+						AccessKeyID:     "access",
+						SecretAccessKey: "secret",
+						URLExpiry:       "2h",
+					})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(client).NotTo(BeNil())
+				})
+				It("rejects an invalid URL expiry", func() {
+					_, err := newObjectStoreClient(&ObjectStoreConfig{Endpoint: "http://minio:9000", URLExpiry: "soon"})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(HavePrefix("invalid object store URL expiry: "))
+				})
 			})
 			Context("when non-valid parameters are specified", func() {
 				Context("retry timeout format is corrupt", func() {
@@ -218,6 +848,110 @@ var _ = Describe("Main", func() {
 						Expect(typedConf).To(BeNil())
 					})
 				})
+				Context("discovery fallback endpoint is incomplete", func() {
+					It("returns an error", func() {
+						conf := &SPDZEngineConfig{
+							ProgramIdentifier:       "ephemeral-generic",
+							NetworkEstablishTimeout: "2s",
+							RetrySleep:              "1s",
+							Prime:                   "123",
+							RInv:                    "123",
+							GfpMacKey:               "123",
+							DiscoveryConfig: DiscoveryClientConfig{
+								Host:           "localhost",
+								Port:           "8080",
+								ConnectTimeout: "0s",
+								Fallbacks:      []DiscoveryEndpoint{{Host: "fallback"}},
+							},
+							StateTimeout:       "0s",
+							ComputationTimeout: "0s",
+						}
+						typedConf, err := InitTypedConfig(conf, logger)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(Equal("discovery fallback endpoints must define a host and a port"))
+						Expect(typedConf).To(BeNil())
+					})
+				})
+				Context("an unsupported content type is accepted", func() {
+					It("returns an error", func() {
+						conf := &SPDZEngineConfig{
+							ProgramIdentifier:       "ephemeral-generic",
+							NetworkEstablishTimeout: "2s",
+							RetrySleep:              "1s",
+							Prime:                   "123",
+							RInv:                    "123",
+							GfpMacKey:               "123",
+							DiscoveryConfig: DiscoveryClientConfig{
+								Host:           "localhost",
+								Port:           "8080",
+								ConnectTimeout: "0s",
+							},
+							StateTimeout:         "0s",
+							ComputationTimeout:   "0s",
+							AcceptedContentTypes: []string{"application/json", "application/xml"},
+						}
+						typedConf, err := InitTypedConfig(conf, logger)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(Equal("unsupported content type application/xml"))
+						Expect(typedConf).To(BeNil())
+					})
+				})
+				Context("an unsupported notifier is configured", func() {
+					It("returns an error", func() {
+						conf := &SPDZEngineConfig{
+							ProgramIdentifier:       "ephemeral-generic",
+							NetworkEstablishTimeout: "2s",
+							RetrySleep:              "1s",
+							Prime:                   "123",
+							RInv:                    "123",
+							GfpMacKey:               "123",
+							OpaConfig: OpaConfig{
+								Endpoint:      "http://opa.carbynestack.io",
+								PolicyPackage: "carbynestack.def",
+							},
+							DiscoveryConfig: DiscoveryClientConfig{
+								Host:           "localhost",
+								Port:           "8080",
+								ConnectTimeout: "0s",
+							},
+							StateTimeout:       "0s",
+							ComputationTimeout: "0s",
+							Notifiers:          []NotifierConfig{{Type: "email", URL: "http://mail"}},
+						}
+						typedConf, err := InitTypedConfig(conf, logger)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(Equal("unsupported notifier type email"))
+						Expect(typedConf).To(BeNil())
+					})
+				})
+				Context("an invalid tracing endpoint is configured", func() {
+					It("returns an error", func() {
+						conf := &SPDZEngineConfig{
+							ProgramIdentifier:       "ephemeral-generic",
+							NetworkEstablishTimeout: "2s",
+							RetrySleep:              "1s",
+							Prime:                   "123",
+							RInv:                    "123",
+							GfpMacKey:               "123",
+							OpaConfig: OpaConfig{
+								Endpoint:      "http://opa.carbynestack.io",
+								PolicyPackage: "carbynestack.def",
+							},
+							DiscoveryConfig: DiscoveryClientConfig{
+								Host:           "localhost",
+								Port:           "8080",
+								ConnectTimeout: "0s",
+							},
+							StateTimeout:       "0s",
+							ComputationTimeout: "0s",
+							Tracing:            TracingConfig{Endpoint: "otel-collector:4318"},
+						}
+						typedConf, err := InitTypedConfig(conf, logger)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(Equal("invalid OTLP endpoint otel-collector:4318"))
+						Expect(typedConf).To(BeNil())
+					})
+				})
 				Context("amphora URL is not specified", func() {
 					It("returns an error", func() {
 						conf := &SPDZEngineConfig{
@@ -528,6 +1262,220 @@ var _ = Describe("Main", func() {
 			})
 		})
 	})
+	Context("when running the self-test", func() {
+		It("fails if the configuration is invalid", func() {
+			conf := &SPDZEngineConfig{
+				ProgramIdentifier: "ephemeral-generic",
+				RetrySleep:        "50ms",
+			}
+			Expect(runSelfTest(conf, logger)).To(Equal(1))
+		})
+	})
+	Context("when serving requests", func() {
+		var (
+			lis     net.Listener
+			signals chan os.Signal
+		)
+		BeforeEach(func() {
+			var err error
+			lis, err = net.Listen("tcp", "localhost:0")
+			Expect(err).NotTo(HaveOccurred())
+			signals = make(chan os.Signal, 1)
+		})
+		It("lets running games finish before terminating", func() {
+			started := make(chan struct{})
+			srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				time.Sleep(100 * time.Millisecond)
+				w.WriteHeader(http.StatusOK)
+			})}
+			waited := false
+			done := make(chan error, 1)
+			go func() {
+				done <- serve(srv, lis, nil, time.Second, func() {}, func() {}, func() { waited = true }, signals, logger)
+			}()
+			respCh := make(chan *http.Response, 1)
+			go func() {
+				resp, _ := http.Get("http://" + lis.Addr().String())
+				respCh <- resp
+			}()
+			<-started
+			signals <- syscall.SIGTERM
+			Eventually(done).Should(Receive(BeNil()))
+			Expect(waited).To(BeTrue())
+			var resp *http.Response
+			Eventually(respCh).Should(Receive(&resp))
+			Expect(resp).NotTo(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			_, err := net.Dial("tcp", lis.Addr().String())
+			Expect(err).To(HaveOccurred())
+		})
+		It("serves requests until the games in flight have finished before shutting down", func() {
+			srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})}
+			stopping := make(chan struct{})
+			finish := make(chan struct{})
+			preStop := func() {
+				close(stopping)
+				<-finish
+			}
+			done := make(chan error, 1)
+			go func() {
+				done <- serve(srv, lis, nil, time.Second, preStop, func() {}, func() {}, signals, logger)
+			}()
+			signals <- syscall.SIGTERM
+			<-stopping
+			resp, err := http.Get("http://" + lis.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			resp.Body.Close()
+			Consistently(done, 100*time.Millisecond).ShouldNot(Receive())
+			close(finish)
+			Eventually(done).Should(Receive(BeNil()))
+		})
+		It("aborts the games that do not finish within the drain timeout", func() {
+			// The games run in a context of their own which is not cancelled when the connections are closed.
+			games, abortGames := context.WithCancel(context.Background())
+			started := make(chan struct{})
+			aborted := make(chan struct{})
+			srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				<-games.Done()
+				close(aborted)
+			})}
+			done := make(chan error, 1)
+			go func() {
+				done <- serve(srv, lis, nil, 50*time.Millisecond, func() {}, abortGames, func() { <-aborted }, signals, logger)
+			}()
+			go http.Get("http://" + lis.Addr().String())
+			<-started
+			signals <- syscall.SIGTERM
+			Eventually(done, 5*time.Second).Should(Receive(BeNil()))
+		})
+	})
+	Context("when validating the config", func() {
+		var conf *SPDZEngineConfig
+		BeforeEach(func() {
+			conf = &SPDZEngineConfig{
+				Prime:     "198766463529478683931867765928436695041",
+				RInv:      "133854242216446749056083838363708373830",
+				GfpMacKey: "1113507028231509545156335486838233835",
+			}
+		})
+		It("accepts consistent SPDZ parameters", func() {
+			Expect(checkSPDZParameters(conf)).To(BeEmpty())
+		})
+		It("rejects a prime which is not a prime number", func() {
+			conf.Prime = "198766463529478683931867765928436695040"
+			Expect(checkSPDZParameters(conf)).To(ConsistOf(MatchError("the prime 198766463529478683931867765928436695040 is not a prime number")))
+		})
+		It("reports an inconsistent rInv and MAC key at once", func() {
+			conf.RInv = "42"
+			conf.GfpMacKey = "198766463529478683931867765928436695042"
+			Expect(checkSPDZParameters(conf)).To(HaveLen(2))
+		})
+		It("skips parameters which cannot be parsed", func() {
+			conf.RInv = "abc"
+			Expect(checkSPDZParameters(conf)).To(BeEmpty())
+		})
+		It("reports an unreachable discovery service", func() {
+			lis, err := net.Listen("tcp", "localhost:0")
+			Expect(err).NotTo(HaveOccurred())
+			host, port, _ := net.SplitHostPort(lis.Addr().String())
+			Expect(checkDiscoveryReachable(DiscoveryClientConfig{Host: host, Port: port, ConnectTimeout: "1s"})).To(Succeed())
+			Expect(lis.Close()).To(Succeed())
+			Expect(checkDiscoveryReachable(DiscoveryClientConfig{Host: host, Port: port, ConnectTimeout: "1s"})).NotTo(Succeed())
+		})
+		It("aggregates the problems of the configuration file", func() {
+			conf.RetrySleep = "soon"
+			conf.RInv = "42"
+			conf.LogLevel = "verbose"
+			conf.DiscoveryConfig = DiscoveryClientConfig{Host: "localhost", Port: "1", ConnectTimeout: "1s"}
+			content, err := json.Marshal(conf)
+			Expect(err).NotTo(HaveOccurred())
+			file, err := ioutil.TempFile("", "ephemeral-config-")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.Remove(file.Name())
+			_, err = file.Write(content)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(file.Close()).To(Succeed())
+			Expect(validateConfig(file.Name(), logger)).To(HaveLen(4))
+			Expect(runConfigValidation(file.Name(), logger)).To(Equal(1))
+		})
+		It("fails if the configuration file cannot be read", func() {
+			Expect(validateConfig("/non-existing-config.json", logger)).To(HaveLen(1))
+		})
+	})
+	Context("when reloading the config", func() {
+		var (
+			current  *SPDZEngineConfig
+			typed    *SPDZEngineTypedConfig
+			reloader *configReloader
+		)
+		BeforeEach(func() {
+			current = &SPDZEngineConfig{
+				StateTimeout:       "1s",
+				ComputationTimeout: "2s",
+				PlayerCount:        2,
+				CastorConfig:       CastorConfig{Host: "castor", TupleStock: 1000},
+			}
+			typed = &SPDZEngineTypedConfig{StateTimeout: time.Second, ComputationTimeout: 2 * time.Second, TupleStock: 1000, PlayerCount: 2}
+			reloader = &configReloader{current: current, typed: typed, level: zap.NewAtomicLevelAt(zapcore.DebugLevel), logger: logger}
+		})
+		reload := func(modify func(conf *SPDZEngineConfig)) {
+			conf := *current
+			modify(&conf)
+			content, err := json.Marshal(&conf)
+			Expect(err).NotTo(HaveOccurred())
+			reloader.reload(content)
+		}
+		It("applies the timeouts, the tuple stock and the log level", func() {
+			reload(func(conf *SPDZEngineConfig) {
+				conf.StateTimeout = "5s"
+				conf.ResultDeliveryTimeout = "30s"
+				conf.CastorConfig.TupleStock = 500
+				conf.LogLevel = "warn"
+			})
+			Expect(typed.StateTimeout).To(Equal(5 * time.Second))
+			Expect(typed.ComputationTimeout).To(Equal(2 * time.Second))
+			Expect(typed.ResultDeliveryTimeout).To(Equal(30 * time.Second))
+			Expect(typed.TupleStock).To(Equal(int32(500)))
+			Expect(reloader.level.Level()).To(Equal(zapcore.WarnLevel))
+			Expect(reloader.current.StateTimeout).To(Equal("5s"))
+		})
+		It("does not affect snapshots taken before", func() {
+			snapshot := typed.Snapshot()
+			reload(func(conf *SPDZEngineConfig) {
+				conf.ComputationTimeout = "1m"
+			})
+			Expect(snapshot.ComputationTimeout).To(Equal(2 * time.Second))
+			Expect(typed.ComputationTimeout).To(Equal(time.Minute))
+		})
+		It("ignores changes which require a restart", func() {
+			reload(func(conf *SPDZEngineConfig) {
+				conf.PlayerCount = 3
+				conf.CastorConfig.Host = "other-castor"
+				conf.StateTimeout = "5s"
+			})
+			Expect(typed.PlayerCount).To(Equal(int32(2)))
+			Expect(typed.StateTimeout).To(Equal(5 * time.Second))
+			Expect(reloader.current.PlayerCount).To(Equal(int32(2)))
+			Expect(reloader.current.CastorConfig.Host).To(Equal("castor"))
+		})
+		It("ignores invalid configurations", func() {
+			reload(func(conf *SPDZEngineConfig) {
+				conf.StateTimeout = "soon"
+				conf.CastorConfig.TupleStock = 1
+			})
+			reload(func(conf *SPDZEngineConfig) {
+				conf.LogLevel = "verbose"
+			})
+			Expect(typed.StateTimeout).To(Equal(time.Second))
+			Expect(reloader.level.Level()).To(Equal(zapcore.DebugLevel))
+			Expect(reloader.current).To(Equal(current))
+		})
+	})
 	Context("when retrieving the handler", func() {
 		Context("when no error happens", func() {
 			It("returns the handler chain and write mac keys", func() {
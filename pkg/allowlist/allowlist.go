@@ -0,0 +1,80 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package allowlist restricts the MPC programs that may be compiled and executed to the ones registered by the
+// operators, either by the SHA-256 hash of their source code or by an Ed25519 signature of a trusted key.
+package allowlist
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotAllowed is returned for programs which are neither registered nor signed by a trusted key.
+var ErrNotAllowed = errors.New("program is not allow-listed")
+
+// New returns an allow list accepting the programs with the given hex encoded SHA-256 hashes and the programs signed by
+// any of the given base64 encoded Ed25519 public keys.
+func New(hashes []string, publicKeys []string) (*AllowList, error) {
+	l := &AllowList{hashes: map[string]bool{}}
+	for _, h := range hashes {
+		h = strings.ToLower(h)
+		if b, err := hex.DecodeString(h); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("invalid program hash %q, must be a hex encoded SHA-256 hash", h)
+		}
+		l.hashes[h] = true
+	}
+	for _, k := range publicKeys {
+		key, err := base64.StdEncoding.DecodeString(k)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid public key %q, must be a base64 encoded Ed25519 public key", k)
+		}
+		l.keys = append(l.keys, ed25519.PublicKey(key))
+	}
+	if len(l.hashes) == 0 && len(l.keys) == 0 {
+		return nil, errors.New("at least one program hash or public key must be allow-listed")
+	}
+	return l, nil
+}
+
+// AllowList decides which programs may be compiled. It is safe for concurrent use.
+type AllowList struct {
+	hashes map[string]bool
+	keys   []ed25519.PublicKey
+}
+
+// Hash returns the hex encoded SHA-256 hash the given source code is registered by.
+func Hash(code string) string {
+	h := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(h[:])
+}
+
+// Check returns nil if the program with the given source code is registered or the given base64 encoded signature of
+// the source code is valid for one of the trusted keys. Otherwise, an error wrapping ErrNotAllowed is returned.
+func (l *AllowList) Check(code string, signature string) error {
+	hash := Hash(code)
+	if l.hashes[hash] {
+		return nil
+	}
+	if signature == "" {
+		return fmt.Errorf("%w: program with hash %s is not registered and not signed", ErrNotAllowed, hash)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: the signature is not base64 encoded", ErrNotAllowed)
+	}
+	for _, key := range l.keys {
+		if ed25519.Verify(key, []byte(code), sig) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: the signature of program with hash %s is not valid for any trusted key", ErrNotAllowed,
+		hash)
+}
@@ -0,0 +1,17 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package allowlist
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestAllowList(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Allow List Suite")
+}
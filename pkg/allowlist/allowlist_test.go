@@ -0,0 +1,59 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package allowlist
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AllowList", func() {
+	const code = "print_ln('hello')"
+	var (
+		public  ed25519.PublicKey
+		private ed25519.PrivateKey
+	)
+	BeforeEach(func() {
+		var err error
+		public, private, err = ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	It("accepts registered programs", func() {
+		l, err := New([]string{Hash(code)}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(l.Check(code, "")).To(Succeed())
+		err = l.Check("print_ln('bye')", "")
+		Expect(errors.Is(err, ErrNotAllowed)).To(BeTrue())
+	})
+	It("accepts programs signed by a trusted key", func() {
+		l, err := New(nil, []string{base64.StdEncoding.EncodeToString(public)})
+		Expect(err).NotTo(HaveOccurred())
+		signature := base64.StdEncoding.EncodeToString(ed25519.Sign(private, []byte(code)))
+		Expect(l.Check(code, signature)).To(Succeed())
+		Expect(errors.Is(l.Check("print_ln('bye')", signature), ErrNotAllowed)).To(BeTrue())
+		Expect(errors.Is(l.Check(code, "not base64"), ErrNotAllowed)).To(BeTrue())
+	})
+	It("rejects programs signed by an untrusted key", func() {
+		l, err := New(nil, []string{base64.StdEncoding.EncodeToString(public)})
+		Expect(err).NotTo(HaveOccurred())
+		_, other, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		signature := base64.StdEncoding.EncodeToString(ed25519.Sign(other, []byte(code)))
+		Expect(errors.Is(l.Check(code, signature), ErrNotAllowed)).To(BeTrue())
+	})
+	It("rejects invalid settings", func() {
+		_, err := New([]string{"abc"}, nil)
+		Expect(err).To(MatchError(`invalid program hash "abc", must be a hex encoded SHA-256 hash`))
+		_, err = New(nil, []string{"a2V5"})
+		Expect(err).To(MatchError(`invalid public key "a2V5", must be a base64 encoded Ed25519 public key`))
+		_, err = New(nil, nil)
+		Expect(err).To(MatchError("at least one program hash or public key must be allow-listed"))
+	})
+})
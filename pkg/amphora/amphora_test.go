@@ -5,10 +5,16 @@
 package amphora_test
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	. "github.com/carbynestack/ephemeral/pkg/utils"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -52,6 +58,68 @@ var _ = Describe("Amphora", func() {
 		})
 	})
 
+	Context("when retrieving several shared secrets", func() {
+		It("reads them with a single request in bulk mode", func() {
+			var requests int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				Expect(r.URL.Path).To(Equal("/intra-vcp/secret-shares/bulk"))
+				Expect(r.URL.Query()["secretId"]).To(Equal([]string{"a", "b"}))
+				Expect(r.URL.Query().Get("programId")).To(Equal("ephemeral-generic"))
+				json.NewEncoder(w).Encode([]SecretShare{{SecretID: "b"}, {SecretID: "a"}})
+			}))
+			defer server.Close()
+			u, _ := url.Parse(server.URL)
+			client := Client{HTTPClient: http.Client{}, URL: *u, Bulk: true}
+
+			secrets, err := client.GetSecretShares([]string{"a", "b"}, "ephemeral-generic")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).To(Equal(1))
+			Expect(secrets).To(Equal([]SecretShare{{SecretID: "a"}, {SecretID: "b"}}))
+		})
+		It("returns an error when a secret is missing", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode([]SecretShare{{SecretID: "a"}})
+			}))
+			defer server.Close()
+			u, _ := url.Parse(server.URL)
+			client := Client{HTTPClient: http.Client{}, URL: *u, Bulk: true}
+
+			_, err := client.GetSecretShares([]string{"a", "b"}, "ephemeral-generic")
+			Expect(err).To(MatchError("amphora did not return the secret share b"))
+		})
+		It("reads them one by one otherwise", func() {
+			rt := MockedRoundTripper{ExpectedPath: "/intra-vcp/secret-shares/xyz",
+				ExpectedRawQuery:     "programId=ephemeral-generic",
+				ReturnJSON:           js,
+				ExpectedResponseCode: http.StatusOK}
+			client := Client{HTTPClient: http.Client{Transport: &rt}, URL: url.URL{Host: "test", Scheme: "http"}}
+
+			secrets, err := client.GetSecretShares([]string{"xyz"}, "ephemeral-generic")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secrets).To(Equal([]SecretShare{share}))
+		})
+	})
+
+	Context("when creating several shared objects", func() {
+		It("creates them with a single request in bulk mode", func() {
+			var received []SecretShare
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).To(Equal(http.MethodPost))
+				Expect(r.URL.Path).To(Equal("/intra-vcp/secret-shares/bulk"))
+				Expect(json.NewDecoder(r.Body).Decode(&received)).To(Succeed())
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+			u, _ := url.Parse(server.URL)
+			client := Client{HTTPClient: http.Client{}, URL: *u, Bulk: true}
+
+			err := client.CreateSecretShares([]*SecretShare{{SecretID: "a"}, {SecretID: "b"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(received).To(Equal([]SecretShare{{SecretID: "a"}, {SecretID: "b"}}))
+		})
+	})
+
 	Context("when creating a shared object", func() {
 		It("returns no error when shared object is successfully created", func() {
 			rt := MockedRoundTripper{ExpectedPath: "/intra-vcp/secret-shares", ExpectedResponseCode: http.StatusCreated}
@@ -68,6 +136,84 @@ var _ = Describe("Amphora", func() {
 
 			err := client.CreateSecretShare(&share)
 			Expect(err).To(HaveOccurred())
+			Expect(StatusCode(err)).To(Equal(http.StatusNotFound))
+		})
+		It("reports no response code when amphora cannot be reached", func() {
+			HTTPClient := http.Client{Transport: &MockedBrokenRoundTripper{}}
+			client := Client{HTTPClient: HTTPClient, URL: url.URL{Host: "test", Scheme: "http"}}
+
+			err := client.CreateSecretShare(&share)
+			Expect(err).To(HaveOccurred())
+			Expect(StatusCode(err)).To(Equal(0))
+		})
+	})
+
+	Context("when creating a shared object from a reader", func() {
+		It("streams the base64 encoded data to amphora", func() {
+			var received SecretShare
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Equal("/intra-vcp/secret-shares"))
+				Expect(json.NewDecoder(r.Body).Decode(&received)).To(Succeed())
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+			u, _ := url.Parse(server.URL)
+			client := Client{HTTPClient: http.Client{}, URL: *u}
+
+			tags := []Tag{{ValueType: "STRING", Key: "gameID", Value: "xyz"}}
+			err := client.CreateSecretShareFromReader("xyz", tags, strings.NewReader("secret"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(received).To(Equal(SecretShare{
+				SecretID: "xyz",
+				Data:     base64.StdEncoding.EncodeToString([]byte("secret")),
+				Tags:     tags,
+			}))
+		})
+		It("returns an error when reading the data fails", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = ioutil.ReadAll(r.Body)
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+			u, _ := url.Parse(server.URL)
+			client := Client{HTTPClient: http.Client{}, URL: *u}
+
+			data, dataWriter := io.Pipe()
+			dataWriter.CloseWithError(errors.New("read error"))
+			err := client.CreateSecretShareFromReader("xyz", nil, data)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when deleting a shared object", func() {
+		It("sends a delete request to amphora", func() {
+			var method string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Equal("/intra-vcp/secret-shares/xyz"))
+				method = r.Method
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+			u, _ := url.Parse(server.URL)
+			client := Client{HTTPClient: http.Client{}, URL: *u}
+
+			Expect(client.DeleteSecretShare("xyz")).To(Succeed())
+			Expect(method).To(Equal(http.MethodDelete))
+		})
+		It("returns no error when the shared object does not exist", func() {
+			rt := MockedRoundTripper{ExpectedPath: "/intra-vcp/secret-shares/xxx", ExpectedResponseCode: http.StatusOK}
+			HTTPClient := http.Client{Transport: &rt}
+			client := Client{HTTPClient: HTTPClient, URL: url.URL{Host: "test", Scheme: "http"}}
+
+			Expect(client.DeleteSecretShare("xyz")).To(Succeed())
+		})
+		It("returns an error when amphora fails", func() {
+			rt := MockedRoundTripper{ExpectedPath: "/intra-vcp/secret-shares/xyz", ExpectedResponseCode: http.StatusInternalServerError}
+			HTTPClient := http.Client{Transport: &rt}
+			client := Client{HTTPClient: HTTPClient, URL: url.URL{Host: "test", Scheme: "http"}}
+
+			err := client.DeleteSecretShare("xyz")
+			Expect(StatusCode(err)).To(Equal(http.StatusInternalServerError))
 		})
 	})
 })
@@ -6,6 +6,8 @@ package amphora
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,6 +16,8 @@ import (
 	"net/http"
 	"net/url"
 
+	"github.com/carbynestack/ephemeral/pkg/retry"
+
 	"github.com/asaskevich/govalidator"
 )
 
@@ -35,6 +39,15 @@ type Tag struct {
 type AbstractClient interface {
 	GetSecretShare(string, string) (SecretShare, error)
 	CreateSecretShare(*SecretShare) error
+	CreateSecretShareFromReader(secretID string, tags []Tag, data io.Reader) error
+	DeleteSecretShare(secretID string) error
+}
+
+// BulkClient is implemented by clients reading and creating several secret shares at once.
+type BulkClient interface {
+	// GetSecretShares returns the secret shares with the given ids in the same order.
+	GetSecretShares(ids []string, programIdentifier string) ([]SecretShare, error)
+	CreateSecretShares(shares []*SecretShare) error
 }
 
 // NewClient returns a new Amphora client.
@@ -51,9 +64,27 @@ func NewClient(u url.URL) (*Client, error) {
 type Client struct {
 	URL        url.URL
 	HTTPClient http.Client
+	// Retry defines how failed reads of secret shares are retried. Requests are attempted once by default. Secret
+	// shares are never created more than once.
+	Retry retry.Config
+	// Bulk reads and creates several secret shares with a single request to Amphora's bulk endpoints. Otherwise, the
+	// secret shares are read and created one by one.
+	Bulk bool
+}
+
+// NewPooledTransport returns an HTTP transport keeping up to the given number of idle connections to Amphora, so that
+// the connections are reused by the concurrent requests of the games.
+func NewPooledTransport(maxIdleConns int) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = maxIdleConns
+	transport.MaxIdleConnsPerHost = maxIdleConns
+	return transport
 }
 
-const secretShareURI = "/intra-vcp/secret-shares"
+const (
+	secretShareURI     = "/intra-vcp/secret-shares"
+	bulkSecretShareURI = "/intra-vcp/secret-shares/bulk"
+)
 
 // GetSecretShare creates a new secret share by sending a POST request against Amphora.
 func (c *Client) GetSecretShare(id string, programIdentifier string) (SecretShare, error) {
@@ -65,15 +96,83 @@ func (c *Client) GetSecretShare(id string, programIdentifier string) (SecretShar
 	query := req.URL.Query()
 	query.Add("programId", programIdentifier)
 	req.URL.RawQuery = query.Encode()
-	body, err := c.doRequest(req, http.StatusOK)
+	err = retry.Do(context.Background(), c.Retry, func() error {
+		body, err := c.doRequest(req, http.StatusOK)
+		if err != nil {
+			if status, ok := err.(*statusError); ok && status.code < http.StatusInternalServerError {
+				return retry.Permanent(err)
+			}
+			return err
+		}
+		defer body.Close()
+		if err := json.NewDecoder(body).Decode(&os); err != nil {
+			return retry.Permanent(fmt.Errorf("amphora returned an invalid response body: %s", err))
+		}
+		return nil
+	})
+	return os, err
+}
+
+// GetSecretShares returns the secret shares with the given ids in the same order. They are read with a single GET
+// request against Amphora's bulk endpoint if Bulk is set.
+func (c *Client) GetSecretShares(ids []string, programIdentifier string) ([]SecretShare, error) {
+	if !c.Bulk {
+		shares := make([]SecretShare, 0, len(ids))
+		for _, id := range ids {
+			os, err := c.GetSecretShare(id, programIdentifier)
+			if err != nil {
+				return nil, err
+			}
+			shares = append(shares, os)
+		}
+		return shares, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, c.URL.String()+bulkSecretShareURI, nil)
 	if err != nil {
-		return os, err
+		return nil, err
 	}
-	err = json.NewDecoder(body).Decode(&os)
+	query := req.URL.Query()
+	for _, id := range ids {
+		query.Add("secretId", id)
+	}
+	query.Add("programId", programIdentifier)
+	req.URL.RawQuery = query.Encode()
+	var received []SecretShare
+	err = retry.Do(context.Background(), c.Retry, func() error {
+		body, err := c.doRequest(req, http.StatusOK)
+		if err != nil {
+			if status, ok := err.(*statusError); ok && status.code < http.StatusInternalServerError {
+				return retry.Permanent(err)
+			}
+			return err
+		}
+		defer body.Close()
+		if err := json.NewDecoder(body).Decode(&received); err != nil {
+			return retry.Permanent(fmt.Errorf("amphora returned an invalid response body: %s", err))
+		}
+		return nil
+	})
 	if err != nil {
-		return os, fmt.Errorf("amphora returned an invalid response body: %s", err)
+		return nil, err
+	}
+	return orderSecretShares(ids, received)
+}
+
+// orderSecretShares returns the given secret shares in the order of the given ids.
+func orderSecretShares(ids []string, shares []SecretShare) ([]SecretShare, error) {
+	byID := make(map[string]SecretShare, len(shares))
+	for _, os := range shares {
+		byID[os.SecretID] = os
 	}
-	return os, nil
+	ordered := make([]SecretShare, 0, len(ids))
+	for _, id := range ids {
+		os, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("amphora did not return the secret share %s", id)
+		}
+		ordered = append(ordered, os)
+	}
+	return ordered, nil
 }
 
 // CreateSecretShare creates a new secret share by sending a POST request against Amphora.
@@ -94,6 +193,96 @@ func (c *Client) CreateSecretShare(os *SecretShare) error {
 	return nil
 }
 
+// CreateSecretShares creates the given secret shares. They are created with a single POST request against Amphora's
+// bulk endpoint if Bulk is set.
+func (c *Client) CreateSecretShares(shares []*SecretShare) error {
+	if !c.Bulk {
+		for _, os := range shares {
+			if err := c.CreateSecretShare(os); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	jsonMarshalled, err := json.Marshal(shares)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.URL.String()+bulkSecretShareURI, bytes.NewBuffer(jsonMarshalled))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	body, err := c.doRequest(req, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+	return body.Close()
+}
+
+// CreateSecretShareFromReader creates a new secret share with the data read from the given reader. In contrast to
+// CreateSecretShare, the data is encoded and sent to Amphora while it is read, so it is never held in memory as a
+// whole.
+func (c *Client) CreateSecretShareFromReader(secretID string, tags []Tag, data io.Reader) error {
+	body, bodyWriter := io.Pipe()
+	defer body.Close()
+	go func() {
+		bodyWriter.CloseWithError(writeSecretShare(bodyWriter, secretID, tags, data))
+	}()
+	req, err := http.NewRequest(http.MethodPost, c.URL.String()+secretShareURI, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	_, err = c.doRequest(req, http.StatusCreated)
+	return err
+}
+
+// DeleteSecretShare deletes the secret share with the given id by sending a DELETE request against Amphora. Deleting
+// a secret share that does not exist succeeds.
+func (c *Client) DeleteSecretShare(secretID string) error {
+	req, err := http.NewRequest(http.MethodDelete, c.URL.String()+fmt.Sprintf("%s/%s", secretShareURI, secretID), nil)
+	if err != nil {
+		return err
+	}
+	body, err := c.doRequest(req, http.StatusOK)
+	if err != nil {
+		if StatusCode(err) == http.StatusNotFound {
+			return nil
+		}
+		return err
+	}
+	return body.Close()
+}
+
+// writeSecretShare writes the JSON representation of a secret share with the base64 encoded data read from the given
+// reader.
+func writeSecretShare(w io.Writer, secretID string, tags []Tag, data io.Reader) error {
+	id, err := json.Marshal(secretID)
+	if err != nil {
+		return err
+	}
+	if tags == nil {
+		tags = []Tag{}
+	}
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, `{"secretId":%s,"tags":%s,"data":"`, id, tagsJSON); err != nil {
+		return err
+	}
+	encoder := base64.NewEncoder(base64.StdEncoding, w)
+	if _, err := io.Copy(encoder, data); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, `"}`)
+	return err
+}
+
 // doRequest is a helper method that sends an HTTP request, compares the returned response code with expected and
 // does corresponding error handling.
 func (c *Client) doRequest(req *http.Request, expected int) (io.ReadCloser, error) {
@@ -106,7 +295,25 @@ func (c *Client) doRequest(req *http.Request, expected int) (io.ReadCloser, erro
 		if err != nil {
 			return nil, err
 		}
-		return nil, fmt.Errorf("server replied with an unexpected response code #%d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, &statusError{code: resp.StatusCode, body: string(bodyBytes)}
 	}
 	return resp.Body, nil
 }
+
+// statusError is returned if the server replied with an unexpected response code.
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("server replied with an unexpected response code #%d: %s", e.code, e.body)
+}
+
+// StatusCode returns the response code Amphora replied with to a failed request, or 0 if no response was received.
+func StatusCode(err error) int {
+	if status, ok := err.(*statusError); ok {
+		return status.code
+	}
+	return 0
+}
@@ -0,0 +1,247 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package archive keeps the history of the executions of the service and exports it as CSV or JSON, e.g., for the
+// offline analysis of the MPC usage.
+package archive
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/audit"
+)
+
+// Path is the path the archive is exported on.
+const Path = "/admin/executions"
+
+// Formats the archive can be exported in.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Statuses of an archived execution.
+const (
+	StatusSucceeded = "Succeeded"
+	StatusFailed    = "Failed"
+)
+
+// DefaultMaxEntries is the number of executions kept if not configured.
+const DefaultMaxEntries = 10000
+
+// csvHeader are the columns of the CSV export.
+var csvHeader = []string{"gameID", "programIdentifier", "playerID", "user", "protocol", "outputType", "status", "error",
+	"started", "finished", "durationMillis", "tupleBytes", "labels"}
+
+// Entry summarizes a single execution.
+type Entry struct {
+	GameID            string    `json:"gameID"`
+	ProgramIdentifier string    `json:"programIdentifier"`
+	PlayerID          int32     `json:"playerID"`
+	User              string    `json:"user"`
+	Protocol          string    `json:"protocol,omitempty"`
+	OutputType        string    `json:"outputType"`
+	Status            string    `json:"status"`
+	Error             string    `json:"error,omitempty"`
+	Started           time.Time `json:"started"`
+	Finished          time.Time `json:"finished"`
+	DurationMillis    int64     `json:"durationMillis"`
+	// TupleBytes is the amount of tuple data streamed to the MPC runtime per tuple type.
+	TupleBytes map[string]int64  `json:"tupleBytes,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// NewEntry summarizes the execution described by the given audit record.
+func NewEntry(r audit.Record) Entry {
+	e := Entry{
+		GameID:            r.GameID,
+		ProgramIdentifier: r.ProgramIdentifier,
+		PlayerID:          r.PlayerID,
+		User:              r.User,
+		Protocol:          r.Protocol,
+		OutputType:        r.OutputType,
+		Status:            StatusSucceeded,
+		Error:             r.Error,
+		Started:           r.Started,
+		Finished:          r.Finished,
+		DurationMillis:    r.Finished.Sub(r.Started).Milliseconds(),
+		Labels:            r.Labels,
+	}
+	if r.Error != "" {
+		e.Status = StatusFailed
+	}
+	for _, ev := range r.Events {
+		if ev.Name != audit.TuplesStreamed {
+			continue
+		}
+		for tt, v := range ev.Details {
+			if bytes, ok := v.(int64); ok {
+				if e.TupleBytes == nil {
+					e.TupleBytes = map[string]int64{}
+				}
+				e.TupleBytes[tt] += bytes
+			}
+		}
+	}
+	return e
+}
+
+// NewArchive returns an archive keeping the given number of executions. The oldest ones are dropped first.
+func NewArchive(maxEntries int) *Archive {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &Archive{maxEntries: maxEntries}
+}
+
+// Archive keeps the most recent executions in the order they finished in. It is used as an audit sink, so that each
+// audited game is archived once it has finished. It is safe for concurrent use.
+type Archive struct {
+	maxEntries int
+
+	mux     sync.Mutex
+	entries []Entry
+	// dropped is the number of executions dropped so far, i.e., the sequence number of the first entry.
+	dropped int
+}
+
+// Write archives the execution described by the audit record.
+func (a *Archive) Write(r audit.Record) error {
+	e := NewEntry(r)
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	a.entries = append(a.entries, e)
+	if excess := len(a.entries) - a.maxEntries; excess > 0 {
+		a.entries = append([]Entry(nil), a.entries[excess:]...)
+		a.dropped += excess
+	}
+	return nil
+}
+
+// after returns the executions archived after the one with the given sequence number, along with the sequence number
+// to continue from. Executions dropped in the meantime are skipped.
+func (a *Archive) after(seq int) ([]Entry, int) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	start := seq - a.dropped
+	if start < 0 {
+		start = 0
+	}
+	return append([]Entry(nil), a.entries[start:]...), a.dropped + len(a.entries)
+}
+
+// Entries returns the executions finished after the given time, the oldest first.
+func (a *Archive) Entries(since time.Time) []Entry {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	var entries []Entry
+	for _, e := range a.entries {
+		if e.Finished.After(since) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// ServeHTTP exports the archived executions in the format given by the format parameter, CSV by default. Only the
+// executions finished after the time given by the since parameter in RFC 3339 format are exported, if set.
+func (a *Archive) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write([]byte("GET requests must be used to export the executions"))
+		return
+	}
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format = FormatCSV
+	}
+	var since time.Time
+	if s := req.URL.Query().Get("since"); s != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write([]byte(fmt.Sprintf("invalid since parameter %q, must be in RFC 3339 format", s)))
+			return
+		}
+	}
+	contentType, err := ContentType(format)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write([]byte(err.Error()))
+		return
+	}
+	writer.Header().Set("Content-Type", contentType)
+	writer.WriteHeader(http.StatusOK)
+	Encode(writer, format, a.Entries(since))
+}
+
+// ContentType returns the content type of the given export format.
+func ContentType(format string) (string, error) {
+	switch format {
+	case FormatCSV:
+		return "text/csv", nil
+	case FormatJSON:
+		return "application/json", nil
+	default:
+		return "", fmt.Errorf("unsupported export format %s, must be %s or %s", format, FormatCSV, FormatJSON)
+	}
+}
+
+// Encode writes the executions in the given format. The executions are written as a JSON array or as CSV with a
+// header, the tuple usage and labels formatted as key=value pairs separated by semicolons.
+func Encode(w io.Writer, format string, entries []Entry) error {
+	if _, err := ContentType(format); err != nil {
+		return err
+	}
+	if format == FormatJSON {
+		if entries == nil {
+			entries = []Entry{}
+		}
+		return json.NewEncoder(w).Encode(entries)
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		tupleBytes := map[string]string{}
+		for tt, bytes := range e.TupleBytes {
+			tupleBytes[tt] = strconv.FormatInt(bytes, 10)
+		}
+		err := cw.Write([]string{e.GameID, e.ProgramIdentifier, strconv.Itoa(int(e.PlayerID)), e.User, e.Protocol,
+			e.OutputType, e.Status, e.Error, e.Started.UTC().Format(time.RFC3339Nano),
+			e.Finished.UTC().Format(time.RFC3339Nano), strconv.FormatInt(e.DurationMillis, 10), pairs(tupleBytes),
+			pairs(e.Labels)})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// pairs formats the map as key=value pairs sorted by key and separated by semicolons.
+func pairs(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	formatted := make([]string, len(keys))
+	for i, k := range keys {
+		formatted[i] = k + "=" + m[k]
+	}
+	return strings.Join(formatted, ";")
+}
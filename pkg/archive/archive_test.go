@@ -0,0 +1,173 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package archive
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/audit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+type failingSink struct {
+	err     error
+	objects map[string][]byte
+}
+
+func (f *failingSink) PutObject(name string, data []byte) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.objects[name] = data
+	return nil
+}
+
+var _ = Describe("Archive", func() {
+	started := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	record := func(gameID string, failure string) audit.Record {
+		return audit.Record{
+			GameID:            gameID,
+			ProgramIdentifier: "ephemeral-generic",
+			PlayerID:          1,
+			User:              "alice",
+			OutputType:        "PLAINTEXT",
+			Labels:            map[string]string{"useCase": "auction", "env": "dev"},
+			Started:           started,
+			Finished:          started.Add(1500 * time.Millisecond),
+			Error:             failure,
+			Events: []audit.Event{
+				{Name: audit.ActivationReceived, Time: started},
+				{Name: audit.TuplesStreamed, Details: map[string]interface{}{"MULTIPLICATION_TRIPLE_GFP": int64(4096)}},
+			},
+		}
+	}
+	Context("when archiving an audit record", func() {
+		It("summarizes the execution", func() {
+			Expect(NewEntry(record("71b2a100", ""))).To(Equal(Entry{
+				GameID:            "71b2a100",
+				ProgramIdentifier: "ephemeral-generic",
+				PlayerID:          1,
+				User:              "alice",
+				OutputType:        "PLAINTEXT",
+				Status:            StatusSucceeded,
+				Started:           started,
+				Finished:          started.Add(1500 * time.Millisecond),
+				DurationMillis:    1500,
+				TupleBytes:        map[string]int64{"MULTIPLICATION_TRIPLE_GFP": 4096},
+				Labels:            map[string]string{"useCase": "auction", "env": "dev"},
+			}))
+			Expect(NewEntry(record("71b2a100", "timeout")).Status).To(Equal(StatusFailed))
+		})
+		It("drops the oldest executions when full", func() {
+			a := NewArchive(2)
+			for _, id := range []string{"a", "b", "c"} {
+				Expect(a.Write(record(id, ""))).To(Succeed())
+			}
+			entries := a.Entries(time.Time{})
+			Expect(entries).To(HaveLen(2))
+			Expect(entries[0].GameID).To(Equal("b"))
+			Expect(entries[1].GameID).To(Equal("c"))
+		})
+	})
+	Context("when encoding the executions", func() {
+		It("writes CSV with a header", func() {
+			var buf bytes.Buffer
+			Expect(Encode(&buf, FormatCSV, []Entry{NewEntry(record("71b2a100", "oops, failed"))})).To(Succeed())
+			Expect(buf.String()).To(Equal("gameID,programIdentifier,playerID,user,protocol,outputType,status,error," +
+				"started,finished,durationMillis,tupleBytes,labels\n" +
+				"71b2a100,ephemeral-generic,1,alice,,PLAINTEXT,Failed,\"oops, failed\",2026-10-16T12:00:00Z," +
+				"2026-10-16T12:00:01.5Z,1500,MULTIPLICATION_TRIPLE_GFP=4096,env=dev;useCase=auction\n"))
+		})
+		It("writes a JSON array", func() {
+			var buf bytes.Buffer
+			Expect(Encode(&buf, FormatJSON, nil)).To(Succeed())
+			Expect(buf.String()).To(Equal("[]\n"))
+		})
+		It("rejects unknown formats", func() {
+			Expect(Encode(&bytes.Buffer{}, "xml", nil)).To(MatchError("unsupported export format xml, must be csv or json"))
+		})
+	})
+	Context("when serving the executions", func() {
+		var a *Archive
+		BeforeEach(func() {
+			a = NewArchive(0)
+			Expect(a.Write(record("71b2a100", ""))).To(Succeed())
+		})
+		It("exports the executions finished since the given time", func() {
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, Path+"?format=json&since=2026-10-16T11:00:00Z", nil)
+			a.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(rr.Header().Get("Content-Type")).To(Equal("application/json"))
+			var entries []Entry
+			Expect(json.Unmarshal(rr.Body.Bytes(), &entries)).To(Succeed())
+			Expect(entries).To(HaveLen(1))
+
+			rr = httptest.NewRecorder()
+			req, _ = http.NewRequest(http.MethodGet, Path+"?format=json&since=2026-10-16T13:00:00Z", nil)
+			a.ServeHTTP(rr, req)
+			Expect(rr.Body.String()).To(Equal("[]\n"))
+		})
+		It("rejects invalid parameters", func() {
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, Path+"?since=yesterday", nil)
+			a.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+	Context("when exporting the executions periodically", func() {
+		var (
+			a    *Archive
+			sink *failingSink
+			e    *Exporter
+		)
+		BeforeEach(func() {
+			a = NewArchive(0)
+			sink = &failingSink{objects: map[string][]byte{}}
+			var err error
+			e, err = NewExporter(a, sink, FormatCSV, time.Hour, zap.NewNop().Sugar())
+			Expect(err).NotTo(HaveOccurred())
+			e.now = func() time.Time { return started }
+		})
+		It("exports each execution once", func() {
+			Expect(e.Export()).To(Succeed())
+			Expect(sink.objects).To(BeEmpty())
+			Expect(a.Write(record("71b2a100", ""))).To(Succeed())
+			Expect(e.Export()).To(Succeed())
+			Expect(sink.objects).To(HaveKey("executions/20261016T120000Z.csv"))
+			sink.objects = map[string][]byte{}
+			Expect(e.Export()).To(Succeed())
+			Expect(sink.objects).To(BeEmpty())
+		})
+		It("exports the executions again if writing failed", func() {
+			Expect(a.Write(record("71b2a100", ""))).To(Succeed())
+			sink.err = errors.New("bucket not found")
+			Expect(e.Export()).NotTo(Succeed())
+			sink.err = nil
+			Expect(e.Export()).To(Succeed())
+			Expect(sink.objects).To(HaveLen(1))
+		})
+		It("writes the exports to a directory", func() {
+			dir, err := ioutil.TempDir("", "executions")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			Expect(DirSink{Dir: dir}.PutObject("executions/export.csv", []byte("data"))).To(Succeed())
+			data, err := ioutil.ReadFile(filepath.Join(dir, "executions", "export.csv"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("data"))
+		})
+	})
+})
@@ -0,0 +1,109 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Sink stores the exports of the archive. It is implemented by objectstore.AbstractClient, so that the exports can be
+// uploaded to an S3 compatible object store.
+type Sink interface {
+	// PutObject stores the data under the given name.
+	PutObject(name string, data []byte) error
+}
+
+// DirSink writes the exports to files in a directory, e.g., on a volume collected by a backup job.
+type DirSink struct {
+	Dir string
+}
+
+// PutObject writes the data to the file of the given name in the directory. The directory is created if it does not
+// exist.
+func (d DirSink) PutObject(name string, data []byte) error {
+	path := filepath.Join(d.Dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating the export directory: %w", err)
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// NewExporter returns an exporter writing the executions of the archive in the given format to the sink in the given
+// interval.
+func NewExporter(archive *Archive, sink Sink, format string, interval time.Duration, logger *zap.SugaredLogger) (*Exporter, error) {
+	if _, err := ContentType(format); err != nil {
+		return nil, err
+	}
+	return &Exporter{
+		archive:  archive,
+		sink:     sink,
+		format:   format,
+		interval: interval,
+		logger:   logger,
+		now:      time.Now,
+	}, nil
+}
+
+// Exporter periodically writes the executions archived since the last export to a sink. Each export is stored as a
+// separate object named after the time of the export, e.g., executions/20261016T120000Z.csv.
+type Exporter struct {
+	archive  *Archive
+	sink     Sink
+	format   string
+	interval time.Duration
+	logger   *zap.SugaredLogger
+	now      func() time.Time
+
+	mux sync.Mutex
+	// next is the sequence number of the first execution not exported yet.
+	next int
+}
+
+// Export writes the executions archived since the last successful export to the sink. Nothing is written if there are
+// none. The executions are exported again on the next call if writing fails.
+func (e *Exporter) Export() error {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	entries, next := e.archive.after(e.next)
+	if len(entries) == 0 {
+		return nil
+	}
+	now := e.now()
+	var buf bytes.Buffer
+	if err := Encode(&buf, e.format, entries); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("executions/%s.%s", now.UTC().Format("20060102T150405Z"), e.format)
+	if err := e.sink.PutObject(name, buf.Bytes()); err != nil {
+		return fmt.Errorf("error writing the executions export: %w", err)
+	}
+	e.next = next
+	e.logger.Infow("Exported the executions", "Name", name, "Executions", len(entries))
+	return nil
+}
+
+// Run exports the executions in the interval of the exporter until done is closed. Failures are logged only.
+func (e *Exporter) Run(done <-chan struct{}) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := e.Export(); err != nil {
+				e.logger.Errorw("Failed to export the executions", "Error", err)
+			}
+		case <-done:
+			return
+		}
+	}
+}
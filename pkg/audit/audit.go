@@ -0,0 +1,155 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package audit
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// The lifecycle events of a game recorded in the audit trail.
+const (
+	// ActivationReceived is recorded when the activation has been accepted.
+	ActivationReceived = "ActivationReceived"
+	// CompilationStarted is recorded before the program is compiled.
+	CompilationStarted = "CompilationStarted"
+	// CompilationFinished is recorded after the program has been compiled, successfully or not.
+	CompilationFinished = "CompilationFinished"
+	// StateChanged is recorded for each transition of the player, e.g., once all players joined the game.
+	StateChanged = "StateChanged"
+	// MPCFinished is recorded once the MPC runtime has exited.
+	MPCFinished = "MPCFinished"
+	// TuplesStreamed is recorded once the tuples have been streamed to the MPC runtime.
+	TuplesStreamed = "TuplesStreamed"
+	// ResultDelivered is recorded once the result has been passed to its destination.
+	ResultDelivered = "ResultDelivered"
+	// OutputsDiscarded is recorded once the outputs of a failed game have been deleted from Amphora.
+	OutputsDiscarded = "OutputsDiscarded"
+)
+
+// Event is a single lifecycle event of a game.
+type Event struct {
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+	// Details are the event specific attributes, e.g., the exit code of the MPC runtime.
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Record is the audit record of a single game as written to the sinks.
+type Record struct {
+	GameID            string `json:"gameID"`
+	ProgramIdentifier string `json:"programIdentifier"`
+	PlayerID          int32  `json:"playerID"`
+	// User is the authenticated user that triggered the activation.
+	User string `json:"user"`
+	// SecretIDs are the ids of the Amphora secrets used as input.
+	SecretIDs []string `json:"secretIDs,omitempty"`
+	// OutputType is the destination of the result, i.e., PLAINTEXT, SECRETSHARE or AMPHORASECRET.
+	OutputType string `json:"outputType"`
+	// Protocol is the name of the MP-SPDZ protocol the game is executed with.
+	Protocol string            `json:"protocol,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Started  time.Time         `json:"started"`
+	Finished time.Time         `json:"finished"`
+	// Error is the reason the game failed. Empty for successful games.
+	Error  string  `json:"error,omitempty"`
+	Events []Event `json:"events"`
+}
+
+// Sink persists audit records.
+type Sink interface {
+	Write(r Record) error
+}
+
+// NewAuditor returns an auditor writing the records to the given sinks.
+func NewAuditor(logger *zap.SugaredLogger, sinks ...Sink) *Auditor {
+	return &Auditor{
+		logger: logger,
+		sinks:  sinks,
+	}
+}
+
+// Auditor creates the audit trails of games and writes them to a set of sinks once the games have finished.
+type Auditor struct {
+	logger *zap.SugaredLogger
+	sinks  []Sink
+}
+
+// NewTrail starts the audit trail of a game described by the given record. Returns nil if the auditor is nil, i.e.,
+// auditing is disabled. All methods can be called on a nil trail, in which case nothing is recorded.
+func (a *Auditor) NewTrail(r Record) *Trail {
+	if a == nil {
+		return nil
+	}
+	if r.Started.IsZero() {
+		r.Started = time.Now()
+	}
+	r.Events = []Event{{Name: ActivationReceived, Time: r.Started}}
+	return &Trail{auditor: a, record: r}
+}
+
+// Trail collects the lifecycle events of a single game.
+type Trail struct {
+	auditor  *Auditor
+	mux      sync.Mutex
+	record   Record
+	finished bool
+}
+
+// Add records the event with the given details. Events added after the trail has been finished are dropped.
+func (t *Trail) Add(name string, details map[string]interface{}) {
+	if t == nil {
+		return
+	}
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if t.finished {
+		return
+	}
+	t.record.Events = append(t.record.Events, Event{Name: name, Time: time.Now(), Details: details})
+}
+
+// OnTransition records a transition of the player, so that the trail can be used as an observer of its state machine.
+func (t *Trail) OnTransition(src, event, dst, gameID string) {
+	t.Add(StateChanged, map[string]interface{}{"src": src, "event": event, "dst": dst})
+}
+
+// Finish completes the trail with the outcome of the game and writes the record to all sinks in the background. Only
+// the first call has an effect. Failures are logged only.
+func (t *Trail) Finish(failure error) {
+	if t == nil {
+		return
+	}
+	t.mux.Lock()
+	if t.finished {
+		t.mux.Unlock()
+		return
+	}
+	t.finished = true
+	t.record.Finished = time.Now()
+	if failure != nil {
+		t.record.Error = failure.Error()
+	}
+	r := t.record
+	t.mux.Unlock()
+	for _, s := range t.auditor.sinks {
+		go func(s Sink) {
+			if err := s.Write(r); err != nil {
+				t.auditor.logger.Errorw("Failed to write the audit record", "GameID", r.GameID, "Error", err)
+			}
+		}(s)
+	}
+}
+
+// Record returns a copy of the record collected so far.
+func (t *Trail) Record() Record {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	r := t.record
+	r.Events = append([]Event(nil), t.record.Events...)
+	return r
+}
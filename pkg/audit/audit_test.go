@@ -0,0 +1,129 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+type recordingSink struct {
+	records chan Record
+	err     error
+}
+
+func (r *recordingSink) Write(rec Record) error {
+	r.records <- rec
+	return r.err
+}
+
+var _ = Describe("Audit", func() {
+	var sink *recordingSink
+	BeforeEach(func() {
+		sink = &recordingSink{records: make(chan Record, 10)}
+	})
+	Context("when recording a trail", func() {
+		It("writes the events and the outcome to all sinks", func() {
+			failing := &recordingSink{records: make(chan Record, 1), err: errors.New("unreachable")}
+			trail := NewAuditor(zap.NewNop().Sugar(), failing, sink).NewTrail(Record{GameID: "g", SecretIDs: []string{"s"}})
+			trail.Add(CompilationStarted, nil)
+			trail.OnTransition("Registering", "PlayersReady", "Playing", "g")
+			trail.Finish(errors.New("timeout"))
+			Eventually(failing.records).Should(Receive())
+			var r Record
+			Eventually(sink.records).Should(Receive(&r))
+			Expect(r.GameID).To(Equal("g"))
+			Expect(r.SecretIDs).To(Equal([]string{"s"}))
+			Expect(r.Error).To(Equal("timeout"))
+			Expect(r.Finished).NotTo(BeZero())
+			Expect(r.Events).To(HaveLen(3))
+			Expect(r.Events[0].Name).To(Equal(ActivationReceived))
+			Expect(r.Events[1].Name).To(Equal(CompilationStarted))
+			Expect(r.Events[2].Name).To(Equal(StateChanged))
+			Expect(r.Events[2].Details).To(Equal(map[string]interface{}{"src": "Registering", "event": "PlayersReady", "dst": "Playing"}))
+		})
+		It("writes the record only once and drops later events", func() {
+			trail := NewAuditor(zap.NewNop().Sugar(), sink).NewTrail(Record{GameID: "g"})
+			trail.Finish(nil)
+			trail.Add(MPCFinished, nil)
+			trail.Finish(errors.New("late"))
+			var r Record
+			Eventually(sink.records).Should(Receive(&r))
+			Expect(r.Error).To(BeEmpty())
+			Expect(r.Events).To(HaveLen(1))
+			Consistently(sink.records).ShouldNot(Receive())
+		})
+		It("records nothing if auditing is disabled", func() {
+			var a *Auditor
+			trail := a.NewTrail(Record{GameID: "g"})
+			Expect(trail).To(BeNil())
+			trail.Add(MPCFinished, nil)
+			trail.Finish(nil)
+		})
+	})
+	Context("when writing to a file", func() {
+		It("appends the records as JSON lines", func() {
+			dir, err := ioutil.TempDir("", "audit")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "audit.log")
+			f, err := NewFileSink(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(f.Write(Record{GameID: "a"})).To(Succeed())
+			Expect(f.Write(Record{GameID: "b"})).To(Succeed())
+			Expect(f.Close()).To(Succeed())
+			file, err := os.Open(path)
+			Expect(err).NotTo(HaveOccurred())
+			defer file.Close()
+			var ids []string
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				var r Record
+				Expect(json.Unmarshal(scanner.Bytes(), &r)).To(Succeed())
+				ids = append(ids, r.GameID)
+			}
+			Expect(ids).To(Equal([]string{"a", "b"}))
+		})
+	})
+	Context("when posting to an endpoint", func() {
+		It("sends the record as JSON", func() {
+			received := make(chan Record, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var rec Record
+				Expect(json.NewDecoder(r.Body).Decode(&rec)).To(Succeed())
+				Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+				received <- rec
+			}))
+			defer server.Close()
+			w, err := NewWebhookSink(server.URL, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(w.Write(Record{GameID: "g", Error: "timeout"})).To(Succeed())
+			Expect((<-received).GameID).To(Equal("g"))
+		})
+		It("returns an error if the endpoint rejects the record", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer server.Close()
+			w, _ := NewWebhookSink(server.URL, time.Second)
+			Expect(w.Write(Record{GameID: "g"})).To(MatchError("audit endpoint responded with status code 500"))
+		})
+		It("rejects invalid URLs", func() {
+			_, err := NewWebhookSink("s3://bucket", time.Second)
+			Expect(err).To(MatchError("invalid audit URL s3://bucket"))
+		})
+	})
+})
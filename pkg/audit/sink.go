@@ -0,0 +1,85 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// NewFileSink returns a sink appending records as JSON lines to the file at the given path. The file is created if it
+// does not exist.
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening the audit file: %w", err)
+	}
+	return &FileSink{file: f}, nil
+}
+
+// FileSink appends records to a local file, e.g., on a volume collected by a log shipper.
+type FileSink struct {
+	mux  sync.Mutex
+	file *os.File
+}
+
+// Write appends the record as a single line to the file.
+func (f *FileSink) Write(r Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	_, err = f.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the file.
+func (f *FileSink) Close() error {
+	return f.file.Close()
+}
+
+// NewWebhookSink returns a sink posting records as JSON to the given URL.
+func NewWebhookSink(endpoint string, timeout time.Duration) (*WebhookSink, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid audit URL %s", endpoint)
+	}
+	return &WebhookSink{
+		URL:        *u,
+		HttpClient: http.Client{Timeout: timeout},
+	}, nil
+}
+
+// WebhookSink posts records to an HTTP endpoint, e.g., the ingestion API of a SIEM.
+type WebhookSink struct {
+	URL        url.URL
+	HttpClient http.Client
+}
+
+// Write posts the record to the endpoint. An error is returned if the endpoint does not respond with a 2xx status
+// code.
+func (w *WebhookSink) Write(r Record) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	resp, err := w.HttpClient.Post(w.URL.String(), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error posting the audit record: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("audit endpoint responded with status code %d", resp.StatusCode)
+	}
+	return nil
+}
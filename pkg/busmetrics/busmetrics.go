@@ -0,0 +1,248 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package busmetrics instruments the in-memory message buses the discovery service and the players communicate over. It
+// counts the events published per topic, measures the latency of the handlers and reports handlers and publishers which
+// do not complete in time along with the stack traces of all goroutines, so that stalls of the bus become visible.
+package busmetrics
+
+import (
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	mb "github.com/vardius/message-bus"
+	"go.uber.org/zap"
+)
+
+// DefaultStallThreshold is the duration after which a handler or publisher is considered stuck if none is configured.
+const DefaultStallThreshold = 10 * time.Second
+
+// maxStackSize bounds the size of the stack traces logged for a stall.
+const maxStackSize = 1 << 20
+
+// gameIDPattern matches the game ids topics are named after.
+var gameIDPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// TopicStats are the metrics of a topic.
+type TopicStats struct {
+	// Published is the number of events published to the topic.
+	Published int64
+	// Handled is the number of events processed by the handlers of the topic.
+	Handled int64
+	// Stalled is the number of handlers and publishers which did not complete within the stall threshold.
+	Stalled int64
+	// TotalLatency is the time spent in the handlers of the topic.
+	TotalLatency time.Duration
+	// MaxLatency is the longest time spent in a handler of the topic.
+	MaxLatency time.Duration
+}
+
+// MeanLatency returns the average time spent in a handler of the topic.
+func (s TopicStats) MeanLatency() time.Duration {
+	if s.Handled == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Handled)
+}
+
+// New returns empty metrics reporting handlers and publishers which take longer than the given threshold.
+func New(stallThreshold time.Duration) *Metrics {
+	if stallThreshold <= 0 {
+		stallThreshold = DefaultStallThreshold
+	}
+	return &Metrics{
+		stallThreshold: stallThreshold,
+		topics:         map[string]*TopicStats{},
+	}
+}
+
+// Metrics aggregates the metrics of the buses it instruments. Topics named after a game are aggregated, i.e. the game
+// id is replaced by a placeholder.
+type Metrics struct {
+	mux            sync.Mutex
+	stallThreshold time.Duration
+	topics         map[string]*TopicStats
+}
+
+// Instrument returns a bus recording the metrics of the given one. Stalls are logged to the given logger.
+func (m *Metrics) Instrument(bus mb.MessageBus, logger *zap.SugaredLogger) mb.MessageBus {
+	return &instrumentedBus{
+		bus:     bus,
+		metrics: m,
+		logger:  logger,
+		subs:    map[string][]subscription{},
+	}
+}
+
+// Snapshot returns the current metrics per topic.
+func (m *Metrics) Snapshot() map[string]TopicStats {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	snapshot := make(map[string]TopicStats, len(m.topics))
+	for topic, s := range m.topics {
+		snapshot[topic] = *s
+	}
+	return snapshot
+}
+
+// Report logs the metrics in the given interval until done is closed.
+func (m *Metrics) Report(logger *zap.SugaredLogger, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.Log(logger)
+		case <-done:
+			return
+		}
+	}
+}
+
+// Log logs the current metrics per topic.
+func (m *Metrics) Log(logger *zap.SugaredLogger) {
+	snapshot := m.Snapshot()
+	topics := make([]string, 0, len(snapshot))
+	for topic := range snapshot {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	for _, topic := range topics {
+		s := snapshot[topic]
+		logger.Infow("Message bus metrics", "Topic", topic, "Published", s.Published, "Handled", s.Handled,
+			"Stalled", s.Stalled, "MeanLatency", s.MeanLatency(), "MaxLatency", s.MaxLatency)
+	}
+}
+
+// update applies the change to the metrics of the given topic.
+func (m *Metrics) update(topic string, change func(*TopicStats)) {
+	name := gameIDPattern.ReplaceAllString(topic, "{gameID}")
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	s, ok := m.topics[name]
+	if !ok {
+		s = &TopicStats{}
+		m.topics[name] = s
+	}
+	change(s)
+}
+
+// subscription is a handler subscribed to the underlying bus in place of the handler of the caller.
+type subscription struct {
+	fn      reflect.Value
+	wrapped interface{}
+}
+
+// instrumentedBus is a message bus recording the metrics of the bus it wraps.
+type instrumentedBus struct {
+	bus     mb.MessageBus
+	metrics *Metrics
+	logger  *zap.SugaredLogger
+	mux     sync.Mutex
+	subs    map[string][]subscription
+}
+
+// Publish publishes the arguments to the handlers of the topic. Publishing blocks if a handler does not keep up, which
+// is reported once it takes longer than the stall threshold.
+func (b *instrumentedBus) Publish(topic string, args ...interface{}) {
+	b.metrics.update(topic, func(s *TopicStats) {
+		s.Published++
+	})
+	done := b.watch(topic, "Publishing to the message bus")
+	defer done()
+	b.bus.Publish(topic, args...)
+}
+
+// Subscribe subscribes the handler to the topic, recording the latency of the handler.
+func (b *instrumentedBus) Subscribe(topic string, fn interface{}) error {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		// The underlying bus rejects the handler.
+		return b.bus.Subscribe(topic, fn)
+	}
+	wrapped := reflect.MakeFunc(v.Type(), func(args []reflect.Value) []reflect.Value {
+		start := time.Now()
+		done := b.watch(topic, "Handling a message bus event")
+		defer func() {
+			done()
+			latency := time.Since(start)
+			b.metrics.update(topic, func(s *TopicStats) {
+				s.Handled++
+				s.TotalLatency += latency
+				if latency > s.MaxLatency {
+					s.MaxLatency = latency
+				}
+			})
+		}()
+		if v.Type().IsVariadic() {
+			return v.CallSlice(args)
+		}
+		return v.Call(args)
+	}).Interface()
+	if err := b.bus.Subscribe(topic, wrapped); err != nil {
+		return err
+	}
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.subs[topic] = append(b.subs[topic], subscription{fn: v, wrapped: wrapped})
+	return nil
+}
+
+// Unsubscribe removes the handler from the topic.
+func (b *instrumentedBus) Unsubscribe(topic string, fn interface{}) error {
+	v := reflect.ValueOf(fn)
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	for i, sub := range b.subs[topic] {
+		if sub.fn == v {
+			if err := b.bus.Unsubscribe(topic, sub.wrapped); err != nil {
+				return err
+			}
+			b.subs[topic] = append(b.subs[topic][:i], b.subs[topic][i+1:]...)
+			return nil
+		}
+	}
+	return b.bus.Unsubscribe(topic, fn)
+}
+
+// Close removes all handlers of the topic.
+func (b *instrumentedBus) Close(topic string) {
+	b.bus.Close(topic)
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	delete(b.subs, topic)
+}
+
+// watch reports the operation on the topic along with the stack traces of all goroutines if it does not complete
+// within the stall threshold. The returned function must be called once the operation has completed.
+func (b *instrumentedBus) watch(topic string, operation string) func() {
+	start := time.Now()
+	var mux sync.Mutex
+	stalled := false
+	timer := time.AfterFunc(b.metrics.stallThreshold, func() {
+		mux.Lock()
+		stalled = true
+		mux.Unlock()
+		b.metrics.update(topic, func(s *TopicStats) {
+			s.Stalled++
+		})
+		buf := make([]byte, maxStackSize)
+		buf = buf[:runtime.Stack(buf, true)]
+		b.logger.Warnw(operation+" did not complete in time", "Topic", topic, "Threshold", b.metrics.stallThreshold,
+			"Stacks", string(buf))
+	})
+	return func() {
+		timer.Stop()
+		mux.Lock()
+		defer mux.Unlock()
+		if stalled {
+			b.logger.Warnw(operation+" completed after a stall", "Topic", topic, "Duration", time.Since(start))
+		}
+	}
+}
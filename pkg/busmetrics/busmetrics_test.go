@@ -0,0 +1,98 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package busmetrics_test
+
+import (
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/busmetrics"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	mb "github.com/vardius/message-bus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var _ = Describe("Bus metrics", func() {
+	var (
+		metrics  *Metrics
+		bus      mb.MessageBus
+		recorded *observer.ObservedLogs
+	)
+	BeforeEach(func() {
+		var core zapcore.Core
+		core, recorded = observer.New(zapcore.DebugLevel)
+		metrics = New(50 * time.Millisecond)
+		bus = metrics.Instrument(mb.New(10), zap.New(core).Sugar())
+	})
+
+	It("counts the published and handled events per topic", func() {
+		handled := make(chan interface{}, 2)
+		Expect(bus.Subscribe("topic", func(e interface{}) {
+			handled <- e
+		})).To(Succeed())
+		bus.Publish("topic", "a")
+		bus.Publish("topic", "b")
+		Eventually(handled).Should(Receive(Equal("a")))
+		Eventually(handled).Should(Receive(Equal("b")))
+		Eventually(func() int64 {
+			return metrics.Snapshot()["topic"].Handled
+		}).Should(Equal(int64(2)))
+		Expect(metrics.Snapshot()["topic"].Published).To(Equal(int64(2)))
+	})
+	It("passes the arguments on to handlers returning values", func() {
+		handled := make(chan interface{}, 1)
+		Expect(bus.Subscribe("topic", func(e interface{}) error {
+			handled <- e
+			return nil
+		})).To(Succeed())
+		bus.Publish("topic", 42)
+		Eventually(handled).Should(Receive(Equal(42)))
+	})
+	It("aggregates topics named after games", func() {
+		bus.Publish("71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4", "a")
+		bus.Publish("81b2a100-f3f6-11e9-81b4-2a2ae2dbcce4", "a")
+		Expect(metrics.Snapshot()).To(HaveKey("{gameID}"))
+		Expect(metrics.Snapshot()["{gameID}"].Published).To(Equal(int64(2)))
+	})
+	It("reports handlers which do not complete in time", func() {
+		release := make(chan struct{})
+		Expect(bus.Subscribe("topic", func(e interface{}) {
+			<-release
+		})).To(Succeed())
+		bus.Publish("topic", "a")
+		Eventually(func() int64 {
+			return metrics.Snapshot()["topic"].Stalled
+		}).Should(Equal(int64(1)))
+		stalls := recorded.FilterMessage("Handling a message bus event did not complete in time").All()
+		Expect(stalls).To(HaveLen(1))
+		Expect(stalls[0].ContextMap()["Stacks"]).To(ContainSubstring("goroutine"))
+		close(release)
+		Eventually(func() int {
+			return recorded.FilterMessage("Handling a message bus event completed after a stall").Len()
+		}).Should(Equal(1))
+		Expect(metrics.Snapshot()["topic"].MaxLatency).To(BeNumerically(">=", 50*time.Millisecond))
+	})
+	It("unsubscribes the handlers of the caller", func() {
+		handled := make(chan interface{}, 1)
+		handler := func(e interface{}) {
+			handled <- e
+		}
+		Expect(bus.Subscribe("topic", handler)).To(Succeed())
+		Expect(bus.Unsubscribe("topic", handler)).To(Succeed())
+		bus.Publish("topic", "a")
+		Consistently(handled).ShouldNot(Receive())
+	})
+	It("rejects handlers which are not functions", func() {
+		Expect(bus.Subscribe("topic", "handler")).NotTo(Succeed())
+	})
+	It("computes the mean latency", func() {
+		s := TopicStats{Handled: 2, TotalLatency: 4 * time.Second}
+		Expect(s.MeanLatency()).To(Equal(2 * time.Second))
+		Expect(TopicStats{}.MeanLatency()).To(BeZero())
+	})
+})
@@ -0,0 +1,124 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package castor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// tupleCacheSuffix is the file name suffix of the cache entries.
+const tupleCacheSuffix = ".tuples"
+
+// NewTupleCache returns a cache keeping its entries in the given directory for the given time. The directory is created
+// if it does not exist. Entries left over from previous runs are kept until they expire.
+func NewTupleCache(dir string, ttl time.Duration) (*TupleCache, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("the time to live of cached tuples must be positive")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("error creating the tuple cache directory: %v", err)
+	}
+	return &TupleCache{dir: dir, ttl: ttl}, nil
+}
+
+// TupleCache keeps tuples fetched from castor, but never consumed, on disk so that they are used instead of fetching
+// them again if the same reservation is requested later on, e.g., when a game is retried with the same game ID.
+// Entries are keyed by the tuple type and the request ID of the reservation. They are used at most once and are
+// dropped once their time to live elapsed.
+//
+// **Note:** The entries are shares of secret tuples. The directory must not be shared with other parties.
+type TupleCache struct {
+	dir   string
+	ttl   time.Duration
+	mux   sync.Mutex
+	stats TupleCacheStats
+}
+
+// TupleCacheStats are the statistics of a tuple cache.
+type TupleCacheStats struct {
+	// CachedBytes is the amount of tuple data put into the cache.
+	CachedBytes int64
+	// ReusedBytes is the amount of tuple data taken from the cache.
+	ReusedBytes int64
+	// ExpiredBytes is the amount of tuple data dropped as its time to live elapsed.
+	ExpiredBytes int64
+}
+
+// Put stores the tuple data of the given reservation.
+func (c *TupleCache) Put(tt TupleType, requestID uuid.UUID, data []byte) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.evictExpired()
+	if err := ioutil.WriteFile(c.path(tt, requestID), data, 0600); err != nil {
+		return fmt.Errorf("error caching the tuples of request %s: %v", requestID, err)
+	}
+	c.stats.CachedBytes += int64(len(data))
+	return nil
+}
+
+// Take removes the tuple data of the given reservation from the cache and returns it. Returns false if the reservation
+// is not cached or its entry expired.
+func (c *TupleCache) Take(tt TupleType, requestID uuid.UUID) ([]byte, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	path := c.path(tt, requestID)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if c.expired(info) {
+		c.evict(path, info)
+		return nil, false
+	}
+	data, err := ioutil.ReadFile(path)
+	_ = os.Remove(path)
+	if err != nil {
+		return nil, false
+	}
+	c.stats.ReusedBytes += int64(len(data))
+	return data, true
+}
+
+// Stats returns the statistics of the cache.
+func (c *TupleCache) Stats() TupleCacheStats {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.stats
+}
+
+// evictExpired removes all expired entries. It must be called with the lock held.
+func (c *TupleCache) evictExpired() {
+	files, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	for _, info := range files {
+		if !info.IsDir() && strings.HasSuffix(info.Name(), tupleCacheSuffix) && c.expired(info) {
+			c.evict(filepath.Join(c.dir, info.Name()), info)
+		}
+	}
+}
+
+func (c *TupleCache) expired(info os.FileInfo) bool {
+	return time.Since(info.ModTime()) > c.ttl
+}
+
+func (c *TupleCache) evict(path string, info os.FileInfo) {
+	if os.Remove(path) == nil {
+		c.stats.ExpiredBytes += info.Size()
+	}
+}
+
+func (c *TupleCache) path(tt TupleType, requestID uuid.UUID) string {
+	return filepath.Join(c.dir, fmt.Sprintf("%s-%s%s", tt.Name, requestID, tupleCacheSuffix))
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package castor_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/carbynestack/ephemeral/pkg/castor"
+)
+
+var _ = Describe("TupleCache", func() {
+	var (
+		dir       string
+		cache     *TupleCache
+		requestID uuid.UUID
+	)
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "tuple-cache")
+		Expect(err).NotTo(HaveOccurred())
+		cache, err = NewTupleCache(dir, time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		requestID = uuid.New()
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("returns the cached tuples of a reservation once", func() {
+		Expect(cache.Put(MultiplicationTripleGfp, requestID, []byte{1, 2, 3})).To(Succeed())
+		data, ok := cache.Take(MultiplicationTripleGfp, requestID)
+		Expect(ok).To(BeTrue())
+		Expect(data).To(Equal([]byte{1, 2, 3}))
+		_, ok = cache.Take(MultiplicationTripleGfp, requestID)
+		Expect(ok).To(BeFalse())
+		Expect(cache.Stats()).To(Equal(TupleCacheStats{CachedBytes: 3, ReusedBytes: 3}))
+	})
+	It("keeps the tuples of different types apart", func() {
+		Expect(cache.Put(MultiplicationTripleGfp, requestID, []byte{1, 2, 3})).To(Succeed())
+		_, ok := cache.Take(BitGfp, requestID)
+		Expect(ok).To(BeFalse())
+	})
+	It("drops expired tuples", func() {
+		Expect(cache.Put(MultiplicationTripleGfp, requestID, []byte{1, 2, 3})).To(Succeed())
+		files, err := filepath.Glob(filepath.Join(dir, "*"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+		past := time.Now().Add(-2 * time.Hour)
+		Expect(os.Chtimes(files[0], past, past)).To(Succeed())
+		_, ok := cache.Take(MultiplicationTripleGfp, requestID)
+		Expect(ok).To(BeFalse())
+		Expect(cache.Stats().ExpiredBytes).To(Equal(int64(3)))
+		Expect(files[0]).NotTo(BeAnExistingFile())
+	})
+	It("rejects a non-positive time to live", func() {
+		_, err := NewTupleCache(dir, 0)
+		Expect(err).To(HaveOccurred())
+	})
+})
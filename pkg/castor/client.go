@@ -9,14 +9,17 @@
 package castor
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/carbynestack/ephemeral/pkg/retry"
 	"github.com/google/uuid"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/asaskevich/govalidator"
 )
@@ -40,6 +43,20 @@ func NewClient(u url.URL) (*Client, error) {
 type Client struct {
 	URL        url.URL
 	HTTPClient *http.Client
+	// Retry defines how failed requests are retried. Requests are attempted once by default.
+	Retry retry.Config
+	// Breaker rejects requests while castor is failing repeatedly. Requests are always sent if nil.
+	Breaker *retry.CircuitBreaker
+}
+
+// UnavailableError is returned without sending the request while the circuit breaker is open, i.e., castor failed
+// repeatedly. Callers may try again once RetryAfter has elapsed.
+type UnavailableError struct {
+	RetryAfter time.Duration
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("castor is unavailable, retry after %s", e.RetryAfter)
 }
 
 const tupleURI = "/intra-vcp/tuples"
@@ -62,21 +79,50 @@ func (c *Client) GetTuples(count int32, tt TupleType, requestID uuid.UUID) (*Tup
 	if err != nil {
 		return nil, err
 	}
+	var tuples *TupleList
+	err = retry.Do(context.Background(), c.Retry, func() error {
+		if c.Breaker != nil {
+			if ok, wait := c.Breaker.Allow(); !ok {
+				return retry.Permanent(&UnavailableError{RetryAfter: wait})
+			}
+		}
+		tuples, err = c.doRequest(req)
+		if c.Breaker != nil {
+			// Rejected requests do not indicate that castor is failing.
+			if retry.IsPermanent(err) {
+				c.Breaker.Record(nil)
+			} else {
+				c.Breaker.Record(err)
+			}
+		}
+		return err
+	})
+	return tuples, err
+}
+
+// doRequest sends the request for tuples. Communication failures and server errors may be retried, other errors are
+// permanent.
+func (c *Client) doRequest(req *http.Request) (*TupleList, error) {
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("communication with castor failed: %s", err)
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
 			return nil, err
 		}
-		return nil, fmt.Errorf("getting tuples failed for \"%s\" with response code #%d: %s", req.URL, resp.StatusCode, string(bodyBytes))
+		err = fmt.Errorf("getting tuples failed for \"%s\" with response code #%d: %s", req.URL, resp.StatusCode, string(bodyBytes))
+		if resp.StatusCode < http.StatusInternalServerError {
+			return nil, retry.Permanent(err)
+		}
+		return nil, err
 	}
 	tuples := &TupleList{}
 	err = json.NewDecoder(resp.Body).Decode(tuples)
 	if err != nil {
-		return nil, fmt.Errorf("castor has returned an invalid response body: %s", err)
+		return nil, retry.Permanent(fmt.Errorf("castor has returned an invalid response body: %s", err))
 	}
 	return tuples, nil
 }
@@ -9,11 +9,13 @@ package castor_test
 
 import (
 	"encoding/json"
+	"github.com/carbynestack/ephemeral/pkg/retry"
 	. "github.com/carbynestack/ephemeral/pkg/utils"
 	"github.com/google/uuid"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -90,6 +92,22 @@ var _ = Describe("Castor", func() {
 				Expect(checkHTTPError(err.Error(), "communication with castor failed")).To(BeTrue())
 			})
 		})
+		Context("when castor fails repeatedly", func() {
+			It("rejects requests while the circuit breaker is open", func() {
+				rt := MockedBrokenRoundTripper{}
+				httpClient := &http.Client{Transport: &rt}
+
+				client := Client{URL: myURL, HTTPClient: httpClient, Breaker: retry.NewCircuitBreaker(2, time.Minute)}
+				for i := 0; i < 2; i++ {
+					_, err := client.GetTuples(0, BitGfp, uuid.MustParse("acc23dc8-7855-4a2f-bc89-494ba30a74d2"))
+					Expect(checkHTTPError(err.Error(), "communication with castor failed")).To(BeTrue())
+				}
+				_, err := client.GetTuples(0, BitGfp, uuid.MustParse("acc23dc8-7855-4a2f-bc89-494ba30a74d2"))
+
+				Expect(err).To(BeAssignableToTypeOf(&UnavailableError{}))
+				Expect(err.(*UnavailableError).RetryAfter).To(BeNumerically(">", 0))
+			})
+		})
 		Context("when castor returns invalid json body", func() {
 			It("returns an error", func() {
 				jsn = []byte("invalid JSON String")
@@ -0,0 +1,306 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package castor
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// NewDealer returns a dealer for a game between players holding the given shares of the gfp MAC key. The prime and the
+// inverse of the Montgomery radix must match the ones of the SPDZ runtime.
+func NewDealer(prime big.Int, rInv big.Int, gfpMacKeys []big.Int, gf2nStorageSize int32) (*Dealer, error) {
+	if len(gfpMacKeys) < 2 {
+		return nil, errors.New("a dealer requires at least two players")
+	}
+	var r big.Int
+	if r.ModInverse(&rInv, &prime) == nil {
+		return nil, errors.New("rInv is not invertible modulo the prime")
+	}
+	var macKey big.Int
+	for i := range gfpMacKeys {
+		macKey.Add(&macKey, &gfpMacKeys[i])
+	}
+	macKey.Mod(&macKey, &prime)
+	return &Dealer{
+		prime:           prime,
+		r:               r,
+		rInv:            rInv,
+		macKey:          macKey,
+		players:         len(gfpMacKeys),
+		wordSize:        (prime.BitLen() + 63) / 64 * 8,
+		gf2nStorageSize: int(gf2nStorageSize),
+		deals:           map[uuid.UUID]*deal{},
+	}, nil
+}
+
+// Dealer generates tuples in place of castor, e.g., to run games without a Carbyne Stack deployment. The gfp tuples are
+// valid and authenticated. The gf2n tuples consist of sharings of zero, i.e., only bits, input masks, squares and
+// multiplication triples satisfy their relation.
+//
+// **Note:** The dealer knows the MAC key and all secrets, the tuples must only be used for testing purposes.
+type Dealer struct {
+	prime           big.Int
+	r               big.Int
+	rInv            big.Int
+	macKey          big.Int
+	players         int
+	wordSize        int
+	gf2nStorageSize int
+	deals           map[uuid.UUID]*deal
+	mux             sync.Mutex
+}
+
+// deal are the tuples handed out for a request, one list per player.
+type deal struct {
+	lists   []*TupleList
+	fetched int
+}
+
+// Client returns a castor client providing the dealer's tuples to the given player.
+func (d *Dealer) Client(playerID int32) AbstractClient {
+	return &dealerClient{
+		dealer:   d,
+		playerID: int(playerID),
+	}
+}
+
+type dealerClient struct {
+	dealer   *Dealer
+	playerID int
+}
+
+// GetTuples returns the player's shares of the tuples generated for the request. All players requesting tuples with
+// the same request ID receive shares of the same tuples.
+func (c *dealerClient) GetTuples(count int32, tt TupleType, requestID uuid.UUID) (*TupleList, error) {
+	if c.playerID < 0 || c.playerID >= c.dealer.players {
+		return nil, fmt.Errorf("player %d does not take part in the game", c.playerID)
+	}
+	return c.dealer.get(count, tt, requestID, c.playerID)
+}
+
+// ReleaseReservation forgets the tuples generated for the request, e.g., as the game was aborted before all players
+// fetched their shares.
+func (c *dealerClient) ReleaseReservation(requestID uuid.UUID) error {
+	c.dealer.mux.Lock()
+	defer c.dealer.mux.Unlock()
+	delete(c.dealer.deals, requestID)
+	return nil
+}
+
+func (d *Dealer) get(count int32, tt TupleType, requestID uuid.UUID, playerID int) (*TupleList, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	dl, ok := d.deals[requestID]
+	if !ok {
+		lists, err := d.generate(count, tt)
+		if err != nil {
+			return nil, err
+		}
+		dl = &deal{lists: lists}
+		d.deals[requestID] = dl
+	}
+	// The tuples are forgotten once all players fetched their shares.
+	dl.fetched++
+	if dl.fetched == d.players {
+		delete(d.deals, requestID)
+	}
+	return dl.lists[playerID], nil
+}
+
+// generate returns the shares of count tuples of the given type for each player.
+func (d *Dealer) generate(count int32, tt TupleType) ([]*TupleList, error) {
+	lists := make([]*TupleList, d.players)
+	for i := range lists {
+		lists[i] = &TupleList{Tuples: make([]Tuple, count)}
+	}
+	for t := 0; t < int(count); t++ {
+		var shares [][]Share
+		var err error
+		switch tt.SpdzProtocol {
+		case SPDZGfp:
+			shares, err = d.gfpTuple(tt)
+		case SPDZGf2n:
+			shares, err = d.gf2nTuple(tt)
+		default:
+			err = fmt.Errorf("unsupported spdz protocol %s", tt.SpdzProtocol.Descriptor)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for i := range lists {
+			lists[i].Tuples[t] = Tuple{Shares: shares[i]}
+		}
+	}
+	return lists, nil
+}
+
+// gfpTuple returns the shares of each player for a single tuple of the given type.
+func (d *Dealer) gfpTuple(tt TupleType) ([][]Share, error) {
+	a, err := d.random()
+	if err != nil {
+		return nil, err
+	}
+	var values []*big.Int
+	switch tt.PreprocessingName {
+	case "Bits":
+		values = []*big.Int{big.NewInt(int64(a.Bit(0)))}
+	case "Inputs":
+		values = []*big.Int{a}
+	case "Inverses":
+		if a.Sign() == 0 {
+			a.SetInt64(1)
+		}
+		values = []*big.Int{a, new(big.Int).ModInverse(a, &d.prime)}
+	case "Squares":
+		values = []*big.Int{a, d.mul(a, a)}
+	case "Triples":
+		b, err := d.random()
+		if err != nil {
+			return nil, err
+		}
+		values = []*big.Int{a, b, d.mul(a, b)}
+	default:
+		return nil, fmt.Errorf("unsupported tuple type %s", tt.Name)
+	}
+	shares := make([][]Share, d.players)
+	for _, v := range values {
+		vs, err := d.Share(v)
+		if err != nil {
+			return nil, err
+		}
+		for i := range shares {
+			shares[i] = append(shares[i], vs[i])
+		}
+	}
+	return shares, nil
+}
+
+// gf2nTuple returns sharings of zero for a single tuple of the given type.
+func (d *Dealer) gf2nTuple(tt TupleType) ([][]Share, error) {
+	var arity int
+	switch tt.PreprocessingName {
+	case "Bits", "Inputs":
+		arity = 1
+	case "Inverses", "Squares":
+		arity = 2
+	case "Triples":
+		arity = 3
+	default:
+		return nil, fmt.Errorf("unsupported tuple type %s", tt.Name)
+	}
+	zero := base64.StdEncoding.EncodeToString(make([]byte, d.gf2nStorageSize))
+	shares := make([][]Share, d.players)
+	for i := range shares {
+		for j := 0; j < arity; j++ {
+			shares[i] = append(shares[i], Share{Value: zero, Mac: zero})
+		}
+	}
+	return shares, nil
+}
+
+// Share splits the given gfp value into authenticated shares, one for each player. Values and MACs are encoded in
+// Montgomery representation as expected by the SPDZ runtime.
+func (d *Dealer) Share(value *big.Int) ([]Share, error) {
+	values, err := d.split(new(big.Int).Mod(value, &d.prime))
+	if err != nil {
+		return nil, err
+	}
+	macs, err := d.split(d.mul(value, &d.macKey))
+	if err != nil {
+		return nil, err
+	}
+	shares := make([]Share, d.players)
+	for i := range shares {
+		shares[i] = Share{
+			Value: base64.StdEncoding.EncodeToString(d.encode(values[i])),
+			Mac:   base64.StdEncoding.EncodeToString(d.encode(macs[i])),
+		}
+	}
+	return shares, nil
+}
+
+// Open reconstructs the gfp value from the shares of all players. An error is returned if the MAC does not match.
+func (d *Dealer) Open(shares []Share) (*big.Int, error) {
+	if len(shares) != d.players {
+		return nil, fmt.Errorf("expected %d shares, got %d", d.players, len(shares))
+	}
+	var value, mac big.Int
+	for _, s := range shares {
+		v, err := d.decode(s.Value)
+		if err != nil {
+			return nil, err
+		}
+		m, err := d.decode(s.Mac)
+		if err != nil {
+			return nil, err
+		}
+		value.Add(&value, v)
+		mac.Add(&mac, m)
+	}
+	value.Mod(&value, &d.prime)
+	mac.Mod(&mac, &d.prime)
+	if d.mul(&value, &d.macKey).Cmp(&mac) != 0 {
+		return nil, errors.New("MAC check failed")
+	}
+	return &value, nil
+}
+
+// split returns random additive shares of the value.
+func (d *Dealer) split(value *big.Int) ([]*big.Int, error) {
+	shares := make([]*big.Int, d.players)
+	last := new(big.Int).Set(value)
+	for i := 0; i < d.players-1; i++ {
+		s, err := d.random()
+		if err != nil {
+			return nil, err
+		}
+		shares[i] = s
+		last.Sub(last, s)
+	}
+	shares[d.players-1] = last.Mod(last, &d.prime)
+	return shares, nil
+}
+
+// encode returns the little endian Montgomery representation of the value.
+func (d *Dealer) encode(value *big.Int) []byte {
+	be := d.mul(value, &d.r).Bytes()
+	le := make([]byte, d.wordSize)
+	for i := range be {
+		le[i] = be[len(be)-1-i]
+	}
+	return le
+}
+
+// decode converts the base64 encoded little endian Montgomery representation back into the value.
+func (d *Dealer) decode(b64 string) (*big.Int, error) {
+	le, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return nil, err
+	}
+	if len(le) != d.wordSize {
+		return nil, fmt.Errorf("expected %d bytes, got %d", d.wordSize, len(le))
+	}
+	be := make([]byte, len(le))
+	for i := range le {
+		be[i] = le[len(le)-1-i]
+	}
+	return d.mul(new(big.Int).SetBytes(be), &d.rInv), nil
+}
+
+func (d *Dealer) mul(a, b *big.Int) *big.Int {
+	res := new(big.Int).Mul(a, b)
+	return res.Mod(res, &d.prime)
+}
+
+func (d *Dealer) random() (*big.Int, error) {
+	return rand.Int(rand.Reader, &d.prime)
+}
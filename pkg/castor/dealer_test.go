@@ -0,0 +1,96 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package castor_test
+
+import (
+	"math/big"
+
+	"github.com/google/uuid"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/carbynestack/ephemeral/pkg/castor"
+)
+
+var _ = Describe("Dealer", func() {
+	var (
+		prime  big.Int
+		dealer *Dealer
+	)
+	BeforeEach(func() {
+		var rInv big.Int
+		prime.SetString("198766463529478683931867765928436695041", 10)
+		rInv.SetString("133854242216446749056083838363708373830", 10)
+		var err error
+		dealer, err = NewDealer(prime, rInv, []big.Int{*big.NewInt(1234), *big.NewInt(5678)}, 8)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	open := func(lists []*TupleList, tuple, element int) *big.Int {
+		value, err := dealer.Open([]Share{lists[0].Tuples[tuple].Shares[element], lists[1].Tuples[tuple].Shares[element]})
+		Expect(err).NotTo(HaveOccurred())
+		return value
+	}
+	fetch := func(tt TupleType, requestID uuid.UUID) []*TupleList {
+		var lists []*TupleList
+		for i := int32(0); i < 2; i++ {
+			l, err := dealer.Client(i).GetTuples(5, tt, requestID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(l.Tuples).To(HaveLen(5))
+			lists = append(lists, l)
+		}
+		return lists
+	}
+	It("opens authenticated shares", func() {
+		shares, err := dealer.Share(big.NewInt(42))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(shares).To(HaveLen(2))
+		value, err := dealer.Open(shares)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value.String()).To(Equal("42"))
+	})
+	It("detects shares with wrong MACs", func() {
+		shares, _ := dealer.Share(big.NewInt(42))
+		shares[0].Mac = shares[1].Mac
+		_, err := dealer.Open(shares)
+		Expect(err).To(MatchError("MAC check failed"))
+	})
+	It("hands out shares of the same multiplication triples to all players", func() {
+		lists := fetch(MultiplicationTripleGfp, uuid.New())
+		for t := range lists[0].Tuples {
+			c := new(big.Int).Mul(open(lists, t, 0), open(lists, t, 1))
+			Expect(open(lists, t, 2).Cmp(c.Mod(c, &prime))).To(BeZero())
+		}
+	})
+	It("hands out shares of bits", func() {
+		lists := fetch(BitGfp, uuid.New())
+		for t := range lists[0].Tuples {
+			Expect(open(lists, t, 0).Int64()).To(BeNumerically("<=", 1))
+		}
+	})
+	It("hands out different tuples for different requests", func() {
+		first := fetch(InputMaskGfp, uuid.New())
+		second := fetch(InputMaskGfp, uuid.New())
+		Expect(first[0]).NotTo(Equal(second[0]))
+	})
+	It("hands out gf2n tuples of the configured storage size", func() {
+		lists := fetch(MultiplicationTripleGf2n, uuid.New())
+		Expect(lists[0].Tuples[0].Shares).To(HaveLen(3))
+		Expect(lists[0].Tuples[0].Shares[0].Value).To(Equal("AAAAAAAAAAA="))
+	})
+	It("forgets the tuples of a released reservation", func() {
+		requestID := uuid.New()
+		first, err := dealer.Client(0).GetTuples(1, InputMaskGfp, requestID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dealer.Client(0).(ReservationReleaser).ReleaseReservation(requestID)).To(Succeed())
+		second, err := dealer.Client(0).GetTuples(1, InputMaskGfp, requestID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(second).NotTo(Equal(first))
+	})
+	It("rejects players not taking part in the game", func() {
+		_, err := dealer.Client(2).GetTuples(1, BitGfp, uuid.New())
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -70,6 +70,12 @@ var (
 	MultiplicationTripleGfp = TupleType{"MULTIPLICATION_TRIPLE_GFP", "Triples", SPDZGfp}
 	// MultiplicationTripleGf2n describes the Triples tuple type in the GF(2^n) domain.
 	MultiplicationTripleGf2n = TupleType{"MULTIPLICATION_TRIPLE_GF2N", "Triples", SPDZGf2n}
+	// DaBitGfp describes the daBits tuple type, i.e., random bits shared in the Modulo a Prime as well as in the
+	// GF(2^n) domain. The file header is the one of the Modulo a Prime domain.
+	DaBitGfp = TupleType{"DABIT_GFP", "daBits", SPDZGfp}
+	// EdaBitGfp describes the edaBits tuple type, i.e., random values shared in the Modulo a Prime domain together with
+	// the sharings of their bits. The file header is the one of the Modulo a Prime domain.
+	EdaBitGfp = TupleType{"EDABIT_GFP", "edaBits", SPDZGfp}
 )
 
 // SupportedTupleTypes is a list of all tuple types supported by the castor client.
@@ -84,4 +90,45 @@ var SupportedTupleTypes = []TupleType{
 	SquareTupleGf2n,
 	MultiplicationTripleGfp,
 	MultiplicationTripleGf2n,
+	DaBitGfp,
+	EdaBitGfp,
+}
+
+// MixedCircuitTupleTypes are the tuple types only required by mixed-circuit operations. They are not provided by all
+// castor deployments and hence are not requested unless enabled explicitly.
+var MixedCircuitTupleTypes = []TupleType{
+	DaBitGfp,
+	EdaBitGfp,
+}
+
+// DefaultTupleTypes returns the supported tuple types requested unless configured otherwise, i.e., all but the
+// mixed-circuit ones.
+func DefaultTupleTypes() []TupleType {
+	var types []TupleType
+	for _, tt := range SupportedTupleTypes {
+		if !IsMixedCircuitTupleType(tt) {
+			types = append(types, tt)
+		}
+	}
+	return types
+}
+
+// IsMixedCircuitTupleType returns whether the given tuple type is only required by mixed-circuit operations.
+func IsMixedCircuitTupleType(tt TupleType) bool {
+	for _, m := range MixedCircuitTupleTypes {
+		if m == tt {
+			return true
+		}
+	}
+	return false
+}
+
+// TupleTypeByName returns the supported tuple type of the given name.
+func TupleTypeByName(name string) (TupleType, bool) {
+	for _, tt := range SupportedTupleTypes {
+		if tt.Name == name {
+			return tt, true
+		}
+	}
+	return TupleType{}, false
 }
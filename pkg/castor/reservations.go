@@ -0,0 +1,138 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package castor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/carbynestack/ephemeral/pkg/retry"
+	"github.com/google/uuid"
+)
+
+// ReservationStatus is the state of a reservation in castor.
+type ReservationStatus string
+
+const (
+	// ReservationLocked is the state of a reservation created by the castor master, but not activated yet. The tuples
+	// of a locked reservation are not handed out.
+	ReservationLocked ReservationStatus = "LOCKED"
+	// ReservationUnlocked is the state of an activated reservation. Its tuples are handed out once requested.
+	ReservationUnlocked ReservationStatus = "UNLOCKED"
+)
+
+// Reservation reserves tuples of a type for a request. The castor master creates the reservation of a request and
+// relays it to the castor slaves, so that all players are provided the shares of the same tuples.
+type Reservation struct {
+	ReservationID string               `json:"reservationId"`
+	TupleType     string               `json:"tupleType"`
+	Reservations  []ReservationElement `json:"reservations"`
+	Status        ReservationStatus    `json:"status"`
+}
+
+// ReservationElement is the part of a reservation located in a single tuple chunk of castor.
+type ReservationElement struct {
+	TupleChunkID   uuid.UUID `json:"tupleChunkId"`
+	ReservedTuples int64     `json:"reservedTuples"`
+	StartIndex     int64     `json:"startIndex"`
+}
+
+// ReservationClient is implemented by the castor clients managing the lifecycle of the reservations explicitly. Tuples
+// requested with GetTuples are reserved implicitly, the reservations are released once the tuples have been handed
+// out to all players. Reservations of requests not completed by all players, e.g., as the game was aborted, are kept
+// by castor unless they are released.
+type ReservationClient interface {
+	ReservationReleaser
+	// CreateReservation creates the given reservation, e.g., when relaying it from the castor master.
+	CreateReservation(r *Reservation) error
+	// ActivateReservation unlocks the reservation of the given request, so that its tuples are handed out.
+	ActivateReservation(requestID uuid.UUID) error
+}
+
+// ReservationReleaser is implemented by the castor clients able to release the reservations of requests not
+// completed, e.g., by the tuple streamers of an aborted game.
+type ReservationReleaser interface {
+	// ReleaseReservation releases the reservation of the given request. Releasing a reservation which does not exist,
+	// e.g., as it has been completed meanwhile, succeeds.
+	ReleaseReservation(requestID uuid.UUID) error
+}
+
+const reservationURI = "/intra-vcp/tuple-reservations"
+
+// CreateReservation creates the given reservation in castor.
+func (c *Client) CreateReservation(r *Reservation) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return c.doReservationRequest(http.MethodPost, reservationURI, body, false)
+}
+
+// ActivateReservation unlocks the reservation of the given request in castor.
+func (c *Client) ActivateReservation(requestID uuid.UUID) error {
+	body, err := json.Marshal(ReservationUnlocked)
+	if err != nil {
+		return err
+	}
+	return c.doReservationRequest(http.MethodPut, reservationURI+"/"+requestID.String(), body, false)
+}
+
+// ReleaseReservation releases the reservation of the given request in castor.
+func (c *Client) ReleaseReservation(requestID uuid.UUID) error {
+	return c.doReservationRequest(http.MethodDelete, reservationURI+"/"+requestID.String(), nil, true)
+}
+
+// doReservationRequest sends a request on the reservations of castor. The request is retried and guarded by the
+// circuit breaker like the requests for tuples. If missingOK is set, a reservation not found is not an error.
+func (c *Client) doReservationRequest(method string, uri string, body []byte, missingOK bool) error {
+	requestURL, err := c.URL.Parse(uri)
+	if err != nil {
+		return err
+	}
+	return retry.Do(context.Background(), c.Retry, func() error {
+		if c.Breaker != nil {
+			if ok, wait := c.Breaker.Allow(); !ok {
+				return retry.Permanent(&UnavailableError{RetryAfter: wait})
+			}
+		}
+		err := c.sendReservationRequest(method, requestURL.String(), body, missingOK)
+		if c.Breaker != nil {
+			if retry.IsPermanent(err) {
+				c.Breaker.Record(nil)
+			} else {
+				c.Breaker.Record(err)
+			}
+		}
+		return err
+	})
+}
+
+func (c *Client) sendReservationRequest(method string, requestURL string, body []byte, missingOK bool) error {
+	req, err := http.NewRequest(method, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("communication with castor failed: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusMultipleChoices || (missingOK && resp.StatusCode == http.StatusNotFound) {
+		return nil
+	}
+	bodyBytes, _ := ioutil.ReadAll(resp.Body)
+	err = fmt.Errorf("%s %s failed with response code #%d: %s", method, requestURL, resp.StatusCode, string(bodyBytes))
+	if resp.StatusCode < http.StatusInternalServerError {
+		return retry.Permanent(err)
+	}
+	return err
+}
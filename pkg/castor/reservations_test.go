@@ -0,0 +1,51 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package castor_test
+
+import (
+	"net/http"
+	"net/url"
+
+	. "github.com/carbynestack/ephemeral/pkg/utils"
+	"github.com/google/uuid"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/carbynestack/ephemeral/pkg/castor"
+)
+
+var _ = Describe("Reservations", func() {
+	requestID := uuid.MustParse("acc23dc8-7855-4a2f-bc89-494ba30a74d2")
+	myURL := url.URL{Host: "host:8080", Scheme: "http"}
+	client := func(path string, code int) *Client {
+		rt := &MockedRoundTripper{ExpectedPath: path, ExpectedResponseCode: code}
+		return &Client{URL: myURL, HTTPClient: &http.Client{Transport: rt}}
+	}
+	It("creates a reservation", func() {
+		c := client("/intra-vcp/tuple-reservations", http.StatusCreated)
+		Expect(c.CreateReservation(&Reservation{ReservationID: requestID.String(), TupleType: BitGfp.Name, Status: ReservationLocked})).To(Succeed())
+	})
+	It("activates a reservation", func() {
+		c := client("/intra-vcp/tuple-reservations/"+requestID.String(), http.StatusOK)
+		Expect(c.ActivateReservation(requestID)).To(Succeed())
+	})
+	It("fails to activate a reservation which does not exist", func() {
+		c := client("/intra-vcp/tuple-reservations/other", http.StatusOK)
+		Expect(c.ActivateReservation(requestID)).To(HaveOccurred())
+	})
+	It("releases a reservation", func() {
+		c := client("/intra-vcp/tuple-reservations/"+requestID.String(), http.StatusOK)
+		Expect(c.ReleaseReservation(requestID)).To(Succeed())
+	})
+	It("succeeds to release a reservation which does not exist", func() {
+		c := client("/intra-vcp/tuple-reservations/other", http.StatusOK)
+		Expect(c.ReleaseReservation(requestID)).To(Succeed())
+	})
+	It("fails to release a reservation if castor fails", func() {
+		c := client("/intra-vcp/tuple-reservations/"+requestID.String(), http.StatusInternalServerError)
+		Expect(c.ReleaseReservation(requestID)).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,190 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Certs", func() {
+	var (
+		dir       string
+		caFile    string
+		caKeyFile string
+		now       = time.Now()
+	)
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "certs")
+		Expect(err).NotTo(HaveOccurred())
+		caFile, caKeyFile = writeCA(dir, now.Add(365*24*time.Hour))
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+	Context("when issuing the certificates from a CA", func() {
+		It("issues a certificate for the party verified by the CA", func() {
+			src, err := NewCASource(caFile, caKeyFile, "P1", 24*time.Hour, time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+			b, err := src.Load(now)
+			Expect(err).NotTo(HaveOccurred())
+			cert := parseCert(b.Cert)
+			Expect(cert.Subject.CommonName).To(Equal("P1"))
+			roots := x509.NewCertPool()
+			Expect(roots.AppendCertsFromPEM(b.CA)).To(BeTrue())
+			_, err = cert.Verify(x509.VerifyOptions{Roots: roots, CurrentTime: now, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("renews the certificate once it is due", func() {
+			src, err := NewCASource(caFile, caKeyFile, "P1", 24*time.Hour, time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+			first, err := src.Load(now)
+			Expect(err).NotTo(HaveOccurred())
+			same, err := src.Load(now.Add(22 * time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(same.Equal(first)).To(BeTrue())
+			renewed, err := src.Load(now.Add(23*time.Hour + time.Minute))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(renewed.Equal(first)).To(BeFalse())
+		})
+		It("rejects a validity not exceeding the renewal", func() {
+			_, err := NewCASource(caFile, caKeyFile, "P1", time.Hour, time.Hour)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("when reading the certificates from a secret", func() {
+		It("fails if the certificate does not match the key", func() {
+			src := &SecretSource{CertFile: caFile, KeyFile: writeKey(dir), CAFile: caFile}
+			_, err := src.Load(now)
+			Expect(err).To(HaveOccurred())
+		})
+		It("reads the files", func() {
+			src := &SecretSource{CertFile: caFile, KeyFile: caKeyFile, CAFile: caFile}
+			b, err := src.Load(now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b.Cert).NotTo(BeEmpty())
+			Expect(b.Key).NotTo(BeEmpty())
+		})
+	})
+	Context("when provisioning the certificates", func() {
+		var (
+			src      *CASource
+			p        *Provisioner
+			data     string
+			rehashed []string
+		)
+		BeforeEach(func() {
+			var err error
+			src, err = NewCASource(caFile, caKeyFile, "P1", 24*time.Hour, time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+			data = filepath.Join(dir, "Player-Data")
+			rehashed = nil
+			p = NewProvisioner(src, 1, data, time.Minute, func(dir string) error {
+				rehashed = append(rehashed, dir)
+				return nil
+			})
+			p.now = func() time.Time { return now }
+		})
+		It("writes the certificate, the key and the CA into the player data directory", func() {
+			rotated, err := p.Sync()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rotated).To(BeTrue())
+			for _, f := range []string{"P1.pem", "P1.key", CAFileName} {
+				Expect(filepath.Join(data, f)).To(BeAnExistingFile())
+			}
+			info, err := os.Stat(filepath.Join(data, "P1.key"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+			Expect(rehashed).To(Equal([]string{data}))
+		})
+		It("rewrites the files only if the certificate is rotated", func() {
+			_, err := p.Sync()
+			Expect(err).NotTo(HaveOccurred())
+			first, err := ioutil.ReadFile(filepath.Join(data, "P1.pem"))
+			Expect(err).NotTo(HaveOccurred())
+
+			rotated, err := p.Sync()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rotated).To(BeFalse())
+
+			p.now = func() time.Time { return now.Add(24 * time.Hour) }
+			rotated, err = p.Sync()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rotated).To(BeTrue())
+			second, err := ioutil.ReadFile(filepath.Join(data, "P1.pem"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second).NotTo(Equal(first))
+			Expect(rehashed).To(HaveLen(2))
+		})
+		It("installs the current certificate into other directories", func() {
+			game := filepath.Join(dir, "Games", "g1", "Player-Data")
+			Expect(p.Install(game)).To(Succeed())
+			Expect(filepath.Join(game, "P1.pem")).NotTo(BeAnExistingFile())
+
+			_, err := p.Sync()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Install(game)).To(Succeed())
+			Expect(filepath.Join(game, "P1.pem")).To(BeAnExistingFile())
+		})
+	})
+})
+
+// writeCA writes a self-signed CA valid until the given time into the given directory. Returns the paths of the
+// certificate and the key.
+func writeCA(dir string, notAfter time.Time) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+	certFile := filepath.Join(dir, "ca.crt")
+	keyFile := filepath.Join(dir, "ca.key")
+	Expect(ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)).To(Succeed())
+	Expect(ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)).To(Succeed())
+	return certFile, keyFile
+}
+
+// writeKey writes a new private key into the given directory and returns its path.
+func writeKey(dir string) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+	path := filepath.Join(dir, "other.key")
+	Expect(ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)).To(Succeed())
+	return path
+}
+
+// parseCert parses the given PEM encoded certificate.
+func parseCert(data []byte) *x509.Certificate {
+	block, _ := pem.Decode(data)
+	Expect(block).NotTo(BeNil())
+	cert, err := x509.ParseCertificate(block.Bytes)
+	Expect(err).NotTo(HaveOccurred())
+	return cert
+}
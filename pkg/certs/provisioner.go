@@ -0,0 +1,152 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package certs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CAFileName is the name of the file the CA is written to in the player data directories.
+const CAFileName = "ca.pem"
+
+// PlayerName returns the common name of the certificate of the given MP-SPDZ party, i.e., "P<party>". It is the name
+// the files of the certificate are named after, and the server name the other players verify the party by.
+func PlayerName(party int32) string {
+	return fmt.Sprintf("P%d", party)
+}
+
+// Rehasher creates the links OpenSSL looks up the CAs in the given directory by, e.g., by running "openssl rehash".
+type Rehasher func(dir string) error
+
+// NewProvisioner returns a provisioner writing the certificates of the given MP-SPDZ party obtained from the given
+// source into the given player data directory. The source is checked for changes in the given interval.
+func NewProvisioner(source Source, party int32, dir string, interval time.Duration, rehash Rehasher) *Provisioner {
+	return &Provisioner{source: source, party: party, dir: dir, interval: interval, rehash: rehash, now: time.Now}
+}
+
+// Provisioner keeps the certificate and key of a player and the CA in the player data directory MP-SPDZ reads them from
+// for its encrypted channels, i.e., "P<party>.pem", "P<party>.key" and CAFileName. The files are replaced atomically,
+// hence a rotation affects the games started afterwards only, and running games are not interrupted.
+type Provisioner struct {
+	source   Source
+	party    int32
+	dir      string
+	interval time.Duration
+	rehash   Rehasher
+	now      func() time.Time
+	mux      sync.Mutex
+	current  *Bundle
+}
+
+// Sync obtains the current certificate from the source and writes it into the player data directory if it changed.
+// Returns whether the certificate has been rotated.
+func (p *Provisioner) Sync() (bool, error) {
+	b, err := p.source.Load(p.now())
+	if err != nil {
+		return false, err
+	}
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if b.Equal(p.current) {
+		return false, nil
+	}
+	if err := p.write(p.dir, b); err != nil {
+		return false, err
+	}
+	p.current = b
+	return true, nil
+}
+
+// Current returns the certificate written by the last successful Sync. Nil if Sync has not succeeded yet.
+func (p *Provisioner) Current() *Bundle {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.current
+}
+
+// Install writes the current certificate into the given player data directory, e.g., the one of the work directory of
+// a game. Nothing is written if Sync has not succeeded yet.
+func (p *Provisioner) Install(dir string) error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if p.current == nil {
+		return nil
+	}
+	return p.write(dir, p.current)
+}
+
+// Run synchronizes the certificate in the interval of the provisioner until done is closed.
+func (p *Provisioner) Run(logger *zap.SugaredLogger, done <-chan struct{}) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rotated, err := p.Sync()
+			if err != nil {
+				logger.Errorw("Failed to synchronize the player certificate", "Error", err)
+				continue
+			}
+			if rotated {
+				logger.Infow("Rotated the player certificate", "Party", p.party)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// write writes the given bundle into the given directory.
+func (p *Provisioner) write(dir string, b *Bundle) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	files := []struct {
+		name string
+		data []byte
+		mode os.FileMode
+	}{
+		{CAFileName, b.CA, 0644},
+		{PlayerName(p.party) + ".key", b.Key, 0600},
+		{PlayerName(p.party) + ".pem", b.Cert, 0644},
+	}
+	for _, f := range files {
+		if err := writeAtomically(filepath.Join(dir, f.name), f.data, f.mode); err != nil {
+			return err
+		}
+	}
+	if p.rehash == nil {
+		return nil
+	}
+	return p.rehash(dir)
+}
+
+// writeAtomically replaces the given file by writing a temporary file and renaming it, so that readers never see a
+// partially written file.
+func writeAtomically(path string, data []byte, mode os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
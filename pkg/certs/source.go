@@ -0,0 +1,179 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package certs
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// Kinds of sources the certificates of the players are obtained from.
+const (
+	// SourceSecret reads the certificates from the files of a mounted secret, e.g., issued by cert-manager.
+	SourceSecret = "secret"
+	// SourceCA issues the certificates from a CA whose certificate and key are read from files.
+	SourceCA = "ca"
+)
+
+// Bundle is the PEM encoded certificate and private key of a player, and the CA the certificates of the other players
+// are verified against.
+type Bundle struct {
+	Cert []byte
+	Key  []byte
+	CA   []byte
+}
+
+// Equal returns whether the given bundle has the same content.
+func (b *Bundle) Equal(o *Bundle) bool {
+	if b == nil || o == nil {
+		return b == o
+	}
+	return bytes.Equal(b.Cert, o.Cert) && bytes.Equal(b.Key, o.Key) && bytes.Equal(b.CA, o.CA)
+}
+
+// Source provides the current certificate of a player.
+type Source interface {
+	// Load returns the certificate to be used at the given time.
+	Load(now time.Time) (*Bundle, error)
+}
+
+// SecretSource reads the certificate from files, e.g., the "tls.crt", "tls.key" and "ca.crt" of a secret mounted into
+// the pod. Secrets updated by cert-manager are picked up as the kubelet updates the files.
+type SecretSource struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+}
+
+// Load reads the files and checks that the certificate matches the key.
+func (s *SecretSource) Load(time.Time) (*Bundle, error) {
+	cert, err := ioutil.ReadFile(s.CertFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ioutil.ReadFile(s.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	ca, err := ioutil.ReadFile(s.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tls.X509KeyPair(cert, key); err != nil {
+		return nil, fmt.Errorf("invalid certificate in %s: %v", s.CertFile, err)
+	}
+	return &Bundle{Cert: cert, Key: key, CA: ca}, nil
+}
+
+// NewCASource returns a source issuing certificates for the given common name, e.g., "P0" for MP-SPDZ party 0, from the
+// CA whose certificate and key are read from the given files. The certificates are valid for the given duration and
+// renewed once less than renewBefore is left.
+func NewCASource(caCertFile, caKeyFile, commonName string, validity, renewBefore time.Duration) (*CASource, error) {
+	if validity <= renewBefore {
+		return nil, errors.New("the validity of the certificates must exceed the time they are renewed before expiry")
+	}
+	return &CASource{
+		caCertFile:  caCertFile,
+		caKeyFile:   caKeyFile,
+		commonName:  commonName,
+		validity:    validity,
+		renewBefore: renewBefore,
+	}, nil
+}
+
+// CASource issues the certificates of a player from a CA. The CA is read again on every renewal, so that a rotated CA is
+// picked up.
+type CASource struct {
+	caCertFile  string
+	caKeyFile   string
+	commonName  string
+	validity    time.Duration
+	renewBefore time.Duration
+	mux         sync.Mutex
+	current     *Bundle
+	notAfter    time.Time
+}
+
+// Load returns the certificate issued last, or issues a new one if none has been issued yet or it is due for renewal.
+func (c *CASource) Load(now time.Time) (*Bundle, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.current != nil && now.Before(c.notAfter.Add(-c.renewBefore)) {
+		return c.current, nil
+	}
+	b, notAfter, err := c.issue(now)
+	if err != nil {
+		return nil, err
+	}
+	c.current, c.notAfter = b, notAfter
+	return b, nil
+}
+
+// issue creates a new key and a certificate signed by the CA.
+func (c *CASource) issue(now time.Time) (*Bundle, time.Time, error) {
+	caPEM, err := ioutil.ReadFile(c.caCertFile)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	caKeyPEM, err := ioutil.ReadFile(c.caKeyFile)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	ca, err := tls.X509KeyPair(caPEM, caKeyPEM)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("invalid CA in %s: %v", c.caCertFile, err)
+	}
+	caCert, err := x509.ParseCertificate(ca.Certificate[0])
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	notAfter := now.Add(c.validity)
+	if notAfter.After(caCert.NotAfter) {
+		notAfter = caCert.NotAfter
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: c.commonName},
+		DNSNames:     []string{c.commonName},
+		// Tolerate clock skew between the parties.
+		NotBefore:   now.Add(-5 * time.Minute),
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, ca.PrivateKey)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return &Bundle{
+		Cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		Key:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		CA:   caPEM,
+	}, notAfter, nil
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package conformance
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	"github.com/google/uuid"
+)
+
+// Version is the version of the conformance suite. It is increased whenever a case is added or the expected behavior
+// of a case changes.
+const Version = "1.0.0"
+
+// paramsMsg is the message returned for activations with an invalid combination of parameters.
+const paramsMsg = "either secret params or amphora secret share UUIDs must be specified, "
+
+// validParams are secret shares that pass the validation of an activation.
+var validParams = []string{
+	"AAAAAAAAAAAAAAAAAAAAAHV5WQAAAAAAAAAAAAAAAAA=",
+	"Qv9nIfmyLlZ3iFnFX5pMBKI8JwAAAAAAAAAAAAAAAAA="}
+
+// Case is a request sent to an ephemeral deployment together with the response it is expected to be answered with.
+type Case struct {
+	// Name identifies the case in the report.
+	Name string
+	// Method is the HTTP method of the request, POST if empty.
+	Method string
+	// Path is the path of the request relative to the target.
+	Path string
+	// ContentType is the content type of the request, none if empty.
+	ContentType string
+	// Header are additional headers of the request.
+	Header map[string]string
+	// Unauthorized omits the Authorization header from the request.
+	Unauthorized bool
+	// Body returns the body of the request. It is called for each request so that every request uses its own game.
+	Body func() ([]byte, error)
+	// Status is the expected status code of the response.
+	Status int
+	// Message is the expected body of the response.
+	Message string
+	// Timeout bounds the time to wait for the response, the timeout of the runner if zero.
+	Timeout time.Duration
+}
+
+// activation returns a body function encoding the activation produced by modify as JSON.
+func activation(modify func(act *Activation)) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		act := &Activation{
+			GameID: uuid.New().String(),
+			Output: OutputConfig{Type: PlainText},
+		}
+		modify(act)
+		return json.Marshal(act)
+	}
+}
+
+// Cases returns the cases of the conformance suite.
+func Cases() []Case {
+	return []Case{
+		{
+			Name:    "rejects methods other than POST",
+			Method:  http.MethodGet,
+			Status:  http.StatusMethodNotAllowed,
+			Message: "POST requests must be used to trigger a computation",
+		},
+		{
+			Name:        "rejects requests without authorization",
+			ContentType: "application/json",
+			Body: activation(func(act *Activation) {
+				act.SecretParams = validParams
+			}),
+			Unauthorized: true,
+			Status:       http.StatusUnauthorized,
+			Message:      "unauthorized request",
+		},
+		{
+			Name:        "rejects malformed request bodies",
+			ContentType: "application/json",
+			Body: func() ([]byte, error) {
+				return []byte("{"), nil
+			},
+			Status:  http.StatusBadRequest,
+			Message: "error decoding the request body",
+		},
+		{
+			Name:        "rejects game IDs that are not UUIDs",
+			ContentType: "application/json",
+			Body: activation(func(act *Activation) {
+				act.GameID = "game"
+				act.SecretParams = validParams
+			}),
+			Status:  http.StatusBadRequest,
+			Message: "GameID game is not a valid UUID",
+		},
+		{
+			Name:        "rejects wrongly encoded secret params",
+			ContentType: "application/json",
+			Body: activation(func(act *Activation) {
+				act.SecretParams = []string{
+					"AAAAAAAAAAAAAAAAAAAAAHV5WQAAAAAAAAAAAAAAAAA",
+					"Qv9nIfmyLlZ3iFnFX5pMBKI8JwAAAAAAAAAAAAAAAAA"}
+			}),
+			Status:  http.StatusBadRequest,
+			Message: "error decoding secret parameters: illegal base64 data at input byte 40",
+		},
+		{
+			Name:        "rejects activations with both secret params and amphora params",
+			ContentType: "application/json",
+			Body: activation(func(act *Activation) {
+				act.SecretParams = validParams
+				act.AmphoraParams = []string{"1", "2"}
+			}),
+			Status:  http.StatusBadRequest,
+			Message: paramsMsg + "not both of them",
+		},
+		{
+			Name:        "rejects activations without params",
+			ContentType: "application/json",
+			Body:        activation(func(act *Activation) {}),
+			Status:      http.StatusBadRequest,
+			Message:     paramsMsg + "none of them given",
+		},
+		{
+			Name:        "rejects invalid time budgets",
+			ContentType: "application/json",
+			Header:      map[string]string{"X-Time-Budget": "-1s"},
+			Body: activation(func(act *Activation) {
+				act.SecretParams = validParams
+			}),
+			Status:  http.StatusBadRequest,
+			Message: "invalid time budget: time budget must be positive, got -1s",
+		},
+		{
+			// The other players never join the game, hence the activation times out while waiting for them.
+			Name:        "times out when the players do not join the game",
+			ContentType: "application/json",
+			Header:      map[string]string{"X-Time-Budget": "5s"},
+			Body: activation(func(act *Activation) {
+				act.SecretParams = validParams
+			}),
+			Status:  http.StatusInternalServerError,
+			Message: "timeout during activation procedure",
+			Timeout: time.Minute,
+		},
+	}
+}
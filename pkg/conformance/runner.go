@@ -0,0 +1,171 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package conformance verifies that an ephemeral deployment answers invalid and failing activations with the status
+// codes and messages of the API, e.g., to check that an upgrade preserves the behavior clients rely on.
+package conformance
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout is the time to wait for a response if neither the runner nor the case define a timeout.
+const DefaultTimeout = 30 * time.Second
+
+// Runner sends the cases of the conformance suite to ephemeral deployments.
+type Runner struct {
+	// Token is the bearer token sent with the requests. An unsigned token for the subject "conformance" is used if
+	// empty, which suffices for deployments that do not verify tokens themselves.
+	Token string
+	// Timeout is the time to wait for a response to cases not defining a timeout, DefaultTimeout if zero.
+	Timeout time.Duration
+	// Client is the client used to send the requests, http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// Report is the outcome of a run of the conformance suite.
+type Report struct {
+	Version  string        `json:"version"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	Passed   int           `json:"passed"`
+	Failed   int           `json:"failed"`
+	Results  []Result      `json:"results"`
+}
+
+// Result is the outcome of a case sent to a target.
+type Result struct {
+	Case            string        `json:"case"`
+	Target          string        `json:"target"`
+	Passed          bool          `json:"passed"`
+	Status          int           `json:"status,omitempty"`
+	ExpectedStatus  int           `json:"expectedStatus"`
+	Message         string        `json:"message,omitempty"`
+	ExpectedMessage string        `json:"expectedMessage"`
+	Error           string        `json:"error,omitempty"`
+	Duration        time.Duration `json:"duration"`
+}
+
+// Run sends the cases to each of the targets, given as base URLs of the deployments, and reports the outcome.
+func (r *Runner) Run(targets []string, cases []Case) *Report {
+	report := &Report{
+		Version: Version,
+		Started: time.Now(),
+		Results: []Result{},
+	}
+	for _, target := range targets {
+		for _, c := range cases {
+			result := r.runCase(target, c)
+			if result.Passed {
+				report.Passed++
+			} else {
+				report.Failed++
+			}
+			report.Results = append(report.Results, result)
+		}
+	}
+	report.Duration = time.Since(report.Started)
+	return report
+}
+
+// runCase sends the request of the case to the target and compares the response to the expected one.
+func (r *Runner) runCase(target string, c Case) Result {
+	result := Result{
+		Case:            c.Name,
+		Target:          target,
+		ExpectedStatus:  c.Status,
+		ExpectedMessage: c.Message,
+	}
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+	req, err := r.newRequest(target, c)
+	if err != nil {
+		result.Error = fmt.Sprintf("error creating the request: %s", err)
+		return result
+	}
+	client := *r.client()
+	client.Timeout = r.timeout(c)
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Error = fmt.Sprintf("error sending the request: %s", err)
+		return result
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		result.Error = fmt.Sprintf("error reading the response: %s", err)
+		return result
+	}
+	result.Status = resp.StatusCode
+	result.Message = string(body)
+	result.Passed = result.Status == c.Status && result.Message == c.Message
+	return result
+}
+
+// newRequest returns the request of the case for the target.
+func (r *Runner) newRequest(target string, c Case) (*http.Request, error) {
+	method := c.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	var body io.Reader
+	if c.Body != nil {
+		b, err := c.Body()
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(target, "/")+"/"+strings.TrimPrefix(c.Path, "/"), body)
+	if err != nil {
+		return nil, err
+	}
+	if c.ContentType != "" {
+		req.Header.Set("Content-Type", c.ContentType)
+	}
+	if !c.Unauthorized {
+		req.Header.Set("Authorization", "Bearer "+r.token())
+	}
+	for k, v := range c.Header {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+func (r *Runner) client() *http.Client {
+	if r.Client == nil {
+		return http.DefaultClient
+	}
+	return r.Client
+}
+
+func (r *Runner) timeout(c Case) time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	if r.Timeout > 0 {
+		return r.Timeout
+	}
+	return DefaultTimeout
+}
+
+func (r *Runner) token() string {
+	if r.Token != "" {
+		return r.Token
+	}
+	enc := base64.URLEncoding.WithPadding(base64.NoPadding)
+	claims, _ := json.Marshal(map[string]string{"sub": "conformance"})
+	return enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." + enc.EncodeToString(claims) + "."
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package conformance_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/conformance"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Runner", func() {
+	var (
+		server   *httptest.Server
+		requests []*http.Request
+	)
+	BeforeEach(func() {
+		requests = nil
+		server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			requests = append(requests, req)
+			if req.Header.Get("Authorization") == "" {
+				writer.WriteHeader(http.StatusUnauthorized)
+				writer.Write([]byte("unauthorized request"))
+				return
+			}
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write([]byte("bad request"))
+		}))
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+	It("passes cases answered with the expected status and message", func() {
+		runner := &Runner{}
+		report := runner.Run([]string{server.URL}, []Case{
+			{Name: "bad", Status: http.StatusBadRequest, Message: "bad request"},
+			{Name: "unauthorized", Unauthorized: true, Status: http.StatusUnauthorized, Message: "unauthorized request"},
+		})
+		Expect(report.Version).To(Equal(Version))
+		Expect(report.Passed).To(Equal(2))
+		Expect(report.Failed).To(Equal(0))
+		Expect(report.Results[0].Target).To(Equal(server.URL))
+		Expect(requests[0].Method).To(Equal(http.MethodPost))
+		Expect(requests[0].Header.Get("Authorization")).To(HavePrefix("Bearer "))
+	})
+	It("fails cases answered with another status or message", func() {
+		runner := &Runner{Token: "token"}
+		report := runner.Run([]string{server.URL}, []Case{
+			{Name: "status", Status: http.StatusOK, Message: "bad request"},
+			{Name: "message", Status: http.StatusBadRequest, Message: "other"},
+		})
+		Expect(report.Passed).To(Equal(0))
+		Expect(report.Failed).To(Equal(2))
+		Expect(report.Results[0].Status).To(Equal(http.StatusBadRequest))
+		Expect(report.Results[1].Message).To(Equal("bad request"))
+		Expect(requests[0].Header.Get("Authorization")).To(Equal("Bearer token"))
+	})
+	It("sends the cases to each target", func() {
+		runner := &Runner{}
+		report := runner.Run([]string{server.URL, server.URL + "/"}, []Case{
+			{Name: "bad", Path: "/compile", Status: http.StatusBadRequest, Message: "bad request"},
+		})
+		Expect(report.Results).To(HaveLen(2))
+		for _, req := range requests {
+			Expect(req.URL.Path).To(Equal("/compile"))
+		}
+	})
+	It("fails cases not answered in time", func() {
+		slow := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		}))
+		defer slow.Close()
+		runner := &Runner{Timeout: 10 * time.Millisecond}
+		report := runner.Run([]string{slow.URL}, []Case{{Name: "slow", Status: http.StatusOK}})
+		Expect(report.Failed).To(Equal(1))
+		Expect(report.Results[0].Error).To(HavePrefix("error sending the request"))
+	})
+	It("encodes the report as JSON", func() {
+		runner := &Runner{}
+		report := runner.Run([]string{server.URL}, []Case{{Name: "bad", Status: http.StatusBadRequest, Message: "bad request"}})
+		body, err := json.Marshal(report)
+		Expect(err).NotTo(HaveOccurred())
+		var decoded map[string]interface{}
+		Expect(json.Unmarshal(body, &decoded)).To(Succeed())
+		Expect(decoded["version"]).To(Equal(Version))
+		Expect(decoded["results"]).To(HaveLen(1))
+	})
+	It("creates a game for each request of the cases", func() {
+		bodies := map[string]bool{}
+		for _, c := range Cases() {
+			if c.Body == nil {
+				continue
+			}
+			b, err := c.Body()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bodies).NotTo(HaveKey(string(b)))
+			bodies[string(b)] = true
+		}
+	})
+})
@@ -0,0 +1,271 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package depcheck verifies the dependencies of a service on startup, e.g., the reachability of other services and
+// the presence of binaries, so that misconfigurations are reported right away instead of failing games later on.
+package depcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Redacted replaces the values of secret settings in the configuration summary.
+const Redacted = "<redacted>"
+
+// secretNames are the parts of the names of settings whose values are redacted, in lower case.
+var secretNames = []string{"key", "token", "secret", "password", "passphrase", "credential"}
+
+// Check is a dependency of a service.
+type Check struct {
+	Name string
+	Run  func() error
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Name     string `json:"name"`
+	OK       bool   `json:"ok"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the outcome of the checks of a service along with a summary of its configuration.
+type Report struct {
+	Service string                 `json:"service"`
+	Time    time.Time              `json:"time"`
+	OK      bool                   `json:"ok"`
+	Config  map[string]interface{} `json:"config,omitempty"`
+	Results []Result               `json:"results"`
+}
+
+// Failed returns the results of the failed checks.
+func (r *Report) Failed() []Result {
+	var failed []Result
+	for _, res := range r.Results {
+		if !res.OK {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Log logs the configuration summary and the outcome of each check.
+func (r *Report) Log(logger *zap.SugaredLogger) {
+	logger.Infow("Starting "+r.Service, "Config", r.Config)
+	for _, res := range r.Results {
+		if res.OK {
+			logger.Infow("Dependency check passed", "Check", res.Name, "Duration", res.Duration)
+		} else {
+			logger.Errorw("Dependency check failed", "Check", res.Name, "Duration", res.Duration, "Error", res.Error)
+		}
+	}
+}
+
+// NewChecker returns a checker running the given checks of the given service. The configuration is summarized in the
+// reports with the secret settings redacted.
+func NewChecker(service string, config interface{}, checks []Check) *Checker {
+	return &Checker{service: service, config: config, checks: checks}
+}
+
+// Checker runs the dependency checks of a service and keeps the last report.
+type Checker struct {
+	service string
+	config  interface{}
+	checks  []Check
+	mux     sync.Mutex
+	last    *Report
+}
+
+// Run runs all checks concurrently and returns the report.
+func (c *Checker) Run() *Report {
+	report := &Report{
+		Service: c.service,
+		Time:    time.Now(),
+		OK:      true,
+		Config:  Redact(c.config),
+		Results: make([]Result, len(c.checks)),
+	}
+	wg := sync.WaitGroup{}
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			start := time.Now()
+			err := check.Run()
+			report.Results[i] = Result{Name: check.Name, OK: err == nil, Duration: time.Since(start).String()}
+			if err != nil {
+				report.Results[i].Error = err.Error()
+			}
+		}(i, check)
+	}
+	wg.Wait()
+	for _, res := range report.Results {
+		report.OK = report.OK && res.OK
+	}
+	c.mux.Lock()
+	c.last = report
+	c.mux.Unlock()
+	return report
+}
+
+// Last returns the last report, running the checks if they have not been run yet.
+func (c *Checker) Last() *Report {
+	c.mux.Lock()
+	last := c.last
+	c.mux.Unlock()
+	if last == nil {
+		return c.Run()
+	}
+	return last
+}
+
+// ServeHTTP serves the last report as JSON on GET requests and runs the checks again on POST requests.
+func (c *Checker) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	var report *Report
+	switch req.Method {
+	case http.MethodGet:
+		report = c.Last()
+	case http.MethodPost:
+		report = c.Run()
+	default:
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write([]byte("GET requests must be used to retrieve the dependency report, POST requests to check again"))
+		return
+	}
+	body, err := json.Marshal(report)
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write([]byte(fmt.Sprintf("error encoding the dependency report: %s", err)))
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(body)
+}
+
+// Redact returns the JSON representation of the given configuration with the values of the settings whose names hint
+// at secrets, e.g., gfpMacKey or tokens, replaced by Redacted. URLs are reduced to their scheme and host, as their user
+// info, path and query may carry credentials, e.g., the path of a webhook.
+func Redact(config interface{}) map[string]interface{} {
+	bytes, err := json.Marshal(config)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	var summary map[string]interface{}
+	if err := json.Unmarshal(bytes, &summary); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	redact(summary)
+	return summary
+}
+
+func redact(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, field := range v {
+			if isSecret(name) && field != nil && field != "" {
+				v[name] = Redacted
+				continue
+			}
+			if s, ok := field.(string); ok {
+				v[name] = redactURL(s)
+				continue
+			}
+			redact(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			if s, ok := item.(string); ok {
+				v[i] = redactURL(s)
+				continue
+			}
+			redact(item)
+		}
+	}
+}
+
+// redactURL returns the scheme and host of the given URL followed by Redacted if it carries user info, a path, a query
+// or a fragment. Values which are not URLs with a scheme and a host are returned as they are.
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return value
+	}
+	if u.User == nil && (u.Path == "" || u.Path == "/") && u.RawQuery == "" && u.Fragment == "" {
+		return value
+	}
+	return fmt.Sprintf("%s://%s/%s", u.Scheme, u.Host, Redacted)
+}
+
+func isSecret(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range secretNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reachable checks that a TCP connection can be established to the given address within the timeout.
+func Reachable(name, addr string, timeout time.Duration) Check {
+	return Check{Name: name, Run: func() error {
+		conn, err := net.DialTimeout("tcp", addr, timeout)
+		if err != nil {
+			return fmt.Errorf("%s is not reachable: %v", addr, err)
+		}
+		return conn.Close()
+	}}
+}
+
+// ReachableURL checks that a TCP connection can be established to the host of the given URL within the timeout. The
+// port defaults to the one of the scheme.
+func ReachableURL(name string, u url.URL, timeout time.Duration) Check {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return Reachable(name, net.JoinHostPort(u.Hostname(), port), timeout)
+}
+
+// Executable checks that the given file exists and is executable.
+func Executable(name, path string) Check {
+	return Check{Name: name, Run: func() error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || info.Mode().Perm()&0111 == 0 {
+			return fmt.Errorf("%s is not executable", path)
+		}
+		return nil
+	}}
+}
+
+// Writable checks that files can be created in the given directory.
+func Writable(name, dir string) Check {
+	return Check{Name: name, Run: func() error {
+		f, err := ioutil.TempFile(dir, ".depcheck-")
+		if err != nil {
+			return fmt.Errorf("%s is not writable: %v", dir, err)
+		}
+		f.Close()
+		return os.Remove(f.Name())
+	}}
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package discovery
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// Checkpoint is the state of the discovery service which is persisted across restarts.
+type Checkpoint struct {
+	Games    map[string]GameCheckpoint          `json:"games"`
+	Players  map[string]map[PlayerID]*pb.Player `json:"players"`
+	Pods     map[string]int32                   `json:"pods"`
+	Networks map[string]int32                   `json:"networks"`
+}
+
+// GameCheckpoint is the state of a running game.
+type GameCheckpoint struct {
+	State string `json:"state"`
+	// Events are the names of the events the game has received so far.
+	Events []string `json:"events"`
+}
+
+// Checkpoint returns the current state of the service. Only running games are included, finished games are dropped.
+func (s *ServiceNG) Checkpoint() (*Checkpoint, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	players, err := s.state.allPlayers()
+	if err != nil {
+		return nil, err
+	}
+	pods, err := s.state.pods()
+	if err != nil {
+		return nil, err
+	}
+	networks, err := s.state.networks()
+	if err != nil {
+		return nil, err
+	}
+	cp := &Checkpoint{
+		Games:    map[string]GameCheckpoint{},
+		Players:  players,
+		Pods:     pods,
+		Networks: networks,
+	}
+	for id, g := range s.games {
+		if g.finished() {
+			continue
+		}
+		cp.Games[id] = gameCheckpoint(g)
+	}
+	return cp, nil
+}
+
+// gameCheckpoint returns the current state of the given game.
+func gameCheckpoint(g *Game) GameCheckpoint {
+	var events []string
+	for _, ev := range g.History().GetEvents() {
+		events = append(events, ev.Name)
+	}
+	return GameCheckpoint{State: g.fsm.Current(), Events: events}
+}
+
+// Restore resumes the service from the given checkpoint. It must be called before the service is started.
+func (s *ServiceNG) Restore(cp *Checkpoint) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for id, p := range cp.Players {
+		for _, pl := range p {
+			if err := s.state.addPlayer(id, pl); err != nil {
+				return err
+			}
+		}
+	}
+	for pod, id := range cp.Pods {
+		if err := s.state.setPod(pod, id); err != nil {
+			return err
+		}
+	}
+	for pod, port := range cp.Networks {
+		if err := s.state.setNetwork(pod, port); err != nil {
+			return err
+		}
+	}
+	for id, gc := range cp.Games {
+		g, err := RestoreGame(ctx, id, gc.State, gc.Events, s.bus, s.stateTimeout, s.computationTimeout, s.logger, s.playerCount)
+		if err != nil {
+			return err
+		}
+		s.runGame(g)
+		s.games[id] = g
+		s.recordGame(g)
+		s.logger.Infow("Restored game", GameID, id, "State", gc.State)
+	}
+	return nil
+}
+
+// WriteCheckpoint persists the checkpoint to the given file.
+func WriteCheckpoint(path string, cp *Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReadCheckpoint reads the checkpoint from the given file. Returns nil if there is no checkpoint.
+func ReadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
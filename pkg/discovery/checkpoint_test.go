@@ -0,0 +1,94 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package discovery
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/discovery/fsm"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	mb "github.com/vardius/message-bus"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Checkpoint", func() {
+	var (
+		dir             string
+		frontendAddress = "192.168.0.1"
+		logger          = zap.NewNop().Sugar()
+	)
+	newService := func() (*ServiceNG, *Publisher) {
+		bus := mb.New(10000)
+		pb := &Publisher{
+			Bus: bus,
+			Fsm: &fsm.FSM{},
+		}
+		n := &FakeNetworker{FreePorts: []int32{30000, 30001}}
+		return NewServiceNG(bus, pb, 10*time.Second, 20*time.Second, &FakeTransport{}, n, frontendAddress, logger, ModeMaster, &FakeDClient{}, 2, NewMemoryStateStore()), pb
+	}
+	BeforeEach(func() {
+		dir, _ = ioutil.TempDir("", "discovery_checkpoint_")
+	})
+	AfterEach(func() {
+		_ = os.RemoveAll(dir)
+	})
+	It("reports a missing checkpoint", func() {
+		cp, err := ReadCheckpoint(filepath.Join(dir, "missing.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cp).To(BeNil())
+	})
+	It("resumes running games from a checkpoint", func() {
+		_, events := createPlayersAndPlayerReadyEvents(2, frontendAddress)
+		s1, _ := newService()
+		s1.processIn(events[0])
+		Eventually(func() string {
+			return s1.games["0"].fsm.Current()
+		}).Should(Equal(WaitPlayersReady))
+
+		path := filepath.Join(dir, "checkpoint.json")
+		cp, err := s1.Checkpoint()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(WriteCheckpoint(path, cp)).To(Succeed())
+		cp, err = ReadCheckpoint(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cp.Games).To(HaveKeyWithValue("0", GameCheckpoint{State: WaitPlayersReady, Events: []string{PlayerReady}}))
+
+		s2, pb := newService()
+		Expect(s2.Restore(cp)).To(Succeed())
+		Expect(s2.state.players("0")).To(HaveLen(1))
+		Expect(s2.state.networks()).To(Equal(cp.Networks))
+		Expect(s2.games["0"].fsm.Current()).To(Equal(WaitPlayersReady))
+
+		// The game continues once the remaining player is ready.
+		done := make(chan struct{})
+		g := &GamesWithBus{
+			Games: s2.games,
+			Bus:   s2.bus,
+		}
+		assertExternalEvent(GenerateEvents(PlayersReady, "0")[0], ClientOutgoingEventsTopic, g, done, func(states []string) {})
+		go s2.Start()
+		Expect(s2.WaitUntilReady(time.Second)).To(Succeed())
+		pb.PublishExternalEvent(events[1], ClientIncomingEventsTopic)
+		WaitDoneOrTimeout(done)
+	})
+	It("does not checkpoint finished games", func() {
+		_, events := createPlayersAndPlayerReadyEvents(2, frontendAddress)
+		s, _ := newService()
+		s.processIn(events[0])
+		s.games["0"].pb.Publish(GameDone, "0")
+		Eventually(func() string {
+			return s.games["0"].fsm.Current()
+		}).Should(Or(Equal(GameDone), Equal(fsm.Stopped)))
+		cp, err := s.Checkpoint()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cp.Games).To(BeEmpty())
+	})
+})
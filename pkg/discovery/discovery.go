@@ -7,6 +7,7 @@ package discovery
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/carbynestack/ephemeral/pkg/discovery/fsm"
 	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
 	t "github.com/carbynestack/ephemeral/pkg/discovery/transport/server"
@@ -22,6 +23,15 @@ const (
 	mpcPodNameLabel = "mpc.podName"
 )
 
+// Policies the networks of the pods, and hence their ports, are reused across games by.
+const (
+	// PortReusePod keeps the network of a pod until the pod is deleted or the games of the pod are evicted.
+	PortReusePod = "pod"
+	// PortReuseRefCount keeps the network of a pod as long as games which have not finished yet reference it, so that
+	// its port is returned to the pool once the last game on the pod finished.
+	PortReuseRefCount = "refcount"
+)
+
 var (
 	// BasePort is the base for the port number that is used by the proxy.
 	BasePort        = int32(5000)
@@ -38,12 +48,17 @@ type Event struct {
 // PlayerID is the id of the MPC player.
 type PlayerID int32
 
-// NewServiceNG returns a new instance of discovery service.
-func NewServiceNG(bus mb.MessageBus, pub *Publisher, stateTimeout time.Duration, computationTimeout time.Duration, tr t.Transport, n Networker, frontendAddress string, logger *zap.SugaredLogger, mode string, client DiscoveryClient, playerCount int) *ServiceNG {
+// PlayerIndex returns the index of the given player within its game, i.e., the player ID without the offset it is
+// shifted by on the wire.
+func PlayerIndex(pl *pb.Player) int32 {
+	// TODO: remove this 100 hack, it is a temp workaround for protobuf3.
+	return pl.Id - 100
+}
+
+// NewServiceNG returns a new instance of discovery service. The bookkeeping of players, pods and networks is kept in
+// the given state store.
+func NewServiceNG(bus mb.MessageBus, pub *Publisher, stateTimeout time.Duration, computationTimeout time.Duration, tr t.Transport, n Networker, frontendAddress string, logger *zap.SugaredLogger, mode string, client DiscoveryClient, playerCount int, store StateStore) *ServiceNG {
 	games := map[string]*Game{}
-	players := map[string]map[PlayerID]*pb.Player{}
-	pods := map[string]int32{}
-	networks := map[string]int32{}
 	errCh := make(chan error)
 	return &ServiceNG{
 		bus:                 bus,
@@ -53,10 +68,8 @@ func NewServiceNG(bus mb.MessageBus, pub *Publisher, stateTimeout time.Duration,
 		stateTimeout:        stateTimeout,
 		computationTimeout:  computationTimeout,
 		transport:           tr,
-		players:             players,
+		state:               &bookkeeping{store: store},
 		playerCount:         playerCount,
-		pods:                pods,
-		networks:            networks,
 		networker:           n,
 		homeFrontendAddress: frontendAddress,
 		logger:              logger,
@@ -71,10 +84,8 @@ type ServiceNG struct {
 	bus                 mb.MessageBus
 	pb                  *Publisher
 	games               map[string]*Game
-	players             map[string]map[PlayerID]*pb.Player
+	state               *bookkeeping
 	playerCount         int
-	pods                map[string]int32
-	networks            map[string]int32
 	mux                 sync.Mutex
 	errCh               chan error
 	stateTimeout        time.Duration
@@ -86,6 +97,41 @@ type ServiceNG struct {
 	mode                string
 	client              DiscoveryClient
 	startCh             chan struct{}
+	// observers are notified about the state transitions of all games.
+	observers []fsm.Observer
+	janitor   janitorCounters
+	// portReuse is the policy the networks of the pods are reused across games by.
+	portReuse string
+}
+
+// Observe registers observers which are notified about the state transitions of the games started afterwards.
+func (s *ServiceNG) Observe(observers ...fsm.Observer) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.observers = append(s.observers, observers...)
+}
+
+// SetTimeouts changes the state and computation timeouts of the games started afterwards. Running games keep the
+// timeouts they were started with.
+func (s *ServiceNG) SetTimeouts(stateTimeout time.Duration, computationTimeout time.Duration) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.stateTimeout = stateTimeout
+	s.computationTimeout = computationTimeout
+}
+
+// SetPortReuse sets the policy the networks of the pods are reused across games by, see PortReusePod and
+// PortReuseRefCount.
+func (s *ServiceNG) SetPortReuse(policy string) error {
+	switch policy {
+	case "", PortReusePod, PortReuseRefCount:
+	default:
+		return fmt.Errorf("unsupported port reuse policy %q", policy)
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.portReuse = policy
+	return nil
 }
 
 // Stop stops the service.
@@ -139,8 +185,9 @@ func (s *ServiceNG) WaitUntilReady(timeout time.Duration) error {
 func (s *ServiceNG) DeleteCallback(name string) {
 	s.mux.Lock()
 	defer s.mux.Unlock()
-	delete(s.networks, name)
-	delete(s.pods, name)
+	if err := s.state.deletePod(name); err != nil {
+		s.logger.Errorw("Failed to remove the pod from the bookkeeping", "Pod", name, "Error", err)
+	}
 }
 
 // readFromWire sends the messages from the discovery clients to the internal message bus.
@@ -159,7 +206,7 @@ func (s *ServiceNG) writeToWire() {
 	s.bus.Subscribe(ClientOutgoingEventsTopic, func(e interface{}) {
 		ev := e.(*pb.Event)
 		s.logger.Debugw("Forwarding message from wire to clients", "Event", ev)
-		// TODO: do not broadcast to all current games.
+		// The transport only forwards the event to the clients taking part in the game.
 		outCh <- ev
 	})
 }
@@ -185,28 +232,23 @@ func (s *ServiceNG) readFromMaster() {
 
 // registerPlayer creates player's network and registers it in the internal bookkeeping of the discovery service.
 func (s *ServiceNG) registerPlayer(pl *pb.Player, gameID string) error {
-	defer func() {
-		// Set the port of the player every time this message is called.
-		pl.Port = s.networks[pl.Pod]
-	}()
 	s.logger.Debug("Register PLayer", "player", pl, "gameId", gameID)
-	p, ok := s.players[gameID]
-	// Create a new map for the GameID
-	if !ok {
-		s.logger.Debug("Create new Player map")
-		players := map[PlayerID]*pb.Player{}
-		s.players[gameID] = players
+	p, err := s.state.players(gameID)
+	if err != nil {
+		return err
 	}
-	p, _ = s.players[gameID]
 
 	// Do not register the player twice.
 	if _, ok := p[PlayerID(pl.Id)]; ok {
 		s.logger.Debug("Player already registered")
-		return nil
+		return s.setPlayerPort(pl)
 	}
 
 	// Create a new network if it doesn't exist yet.
-	_, ok = s.networks[pl.Pod]
+	_, ok, err := s.state.network(pl.Pod)
+	if err != nil {
+		return err
+	}
 	if !ok {
 		s.logger.Debug("Create new network")
 		port, err := s.createNetwork(pl)
@@ -214,10 +256,27 @@ func (s *ServiceNG) registerPlayer(pl *pb.Player, gameID string) error {
 			s.logger.Errorf("Error creating network %v", err)
 			return err
 		}
-		s.networks[pl.Pod] = port
+		if err = s.state.setNetwork(pl.Pod, port); err != nil {
+			return err
+		}
 	}
-	s.pods[pl.Pod] = pl.Id
-	p[PlayerID(pl.Id)] = pl
+	if err = s.state.setPod(pl.Pod, pl.Id); err != nil {
+		return err
+	}
+	// Set the port of the player every time this message is called.
+	if err = s.setPlayerPort(pl); err != nil {
+		return err
+	}
+	return s.state.addPlayer(gameID, pl)
+}
+
+// setPlayerPort sets the port of the player to the one of the network created for its pod.
+func (s *ServiceNG) setPlayerPort(pl *pb.Player) error {
+	port, _, err := s.state.network(pl.Pod)
+	if err != nil {
+		return err
+	}
+	pl.Port = port
 	return nil
 }
 
@@ -240,7 +299,20 @@ func (s *ServiceNG) processInSlave(e interface{}) {
 	defer s.mux.Unlock()
 	ev := e.(*pb.Event)
 	player := ev.Players[0]
-	s.registerPlayer(player, ev.GameID)
+	if ev.Name == PlayerWithdraw {
+		if err := s.state.removePlayer(ev.GameID, player.Id); err != nil {
+			s.logger.Errorw("Failed to remove player", GameID, ev.GameID, "Error", err)
+		}
+		s.bus.Publish(MasterOutgoingEventsTopic, ev)
+		return
+	}
+	if err := s.registerPlayer(player, ev.GameID); err != nil {
+		s.logger.Errorw("Failed to register player", GameID, ev.GameID, "Error", err)
+		if errors.Is(err, ErrNoFreePorts) {
+			// The master fails the game for all parties.
+			ev = &pb.Event{Name: PortsExhausted, GameID: ev.GameID, Players: ev.Players}
+		}
+	}
 	s.bus.Publish(MasterOutgoingEventsTopic, ev)
 }
 
@@ -252,24 +324,42 @@ func (s *ServiceNG) processIn(e interface{}) {
 	ev := e.(*pb.Event)
 	player := ev.Players[0]
 	name := ev.Name
-	s.registerPlayer(player, ev.GameID)
+	if name == PlayerWithdraw {
+		s.withdrawPlayer(player, ev.GameID)
+		return
+	}
+	if name == PortsExhausted {
+		s.logger.Errorw("Rejecting player as no free port is left for its network", GameID, ev.GameID, "Pod", player.Pod)
+		s.rejectPlayer(ev.GameID, PortsExhausted)
+		return
+	}
+	if err := s.checkPodAffinity(player, ev.GameID); err != nil {
+		s.logger.Errorw("Rejecting player", GameID, ev.GameID, "error", err)
+		s.rejectPlayer(ev.GameID, PodAffinityMismatch)
+		return
+	}
+	if err := s.checkParams(player, ev.GameID); err != nil {
+		s.logger.Errorw("Rejecting player", GameID, ev.GameID, "error", err)
+		s.rejectPlayer(ev.GameID, ParamsMismatch)
+		return
+	}
+	if err := s.registerPlayer(player, ev.GameID); err != nil {
+		s.logger.Errorw("Failed to register player", GameID, ev.GameID, "Error", err)
+		if errors.Is(err, ErrNoFreePorts) {
+			s.rejectPlayer(ev.GameID, PortsExhausted)
+			return
+		}
+	}
 	g, ok := s.games[ev.GameID]
 	if !ok { // If game does not exist, create it
 		g, err := NewGame(ctx, ev.GameID, s.bus, s.stateTimeout, s.computationTimeout, s.logger, s.playerCount)
 		if err != nil {
 			s.errCh <- err
 		}
-		gameErrCh := make(chan error, 1)
-		go func() {
-			// Do not propagate this error to the client.
-			// Since should not be related to the client code, but would indicate a bug in the Game FSM.
-			if err, open := <-gameErrCh; open {
-				s.logger.Errorf("Game error: %s\n", err.Error())
-			}
-		}()
-		g.Init(gameErrCh)
+		s.runGame(g)
 		g.pb.Publish(name, ev.GameID)
 		s.games[ev.GameID] = g
+		s.recordGame(g)
 	} else if s.verifyGameState(g) {
 		g.pb.Publish(name, ev.GameID)
 	} else {
@@ -277,6 +367,133 @@ func (s *ServiceNG) processIn(e interface{}) {
 	}
 }
 
+// withdrawPlayer removes the player from a game waiting for its players and informs the other players of the game.
+// The game is dropped once no player remains. Players withdrawing from a game that has started fail the game.
+func (s *ServiceNG) withdrawPlayer(pl *pb.Player, gameID string) {
+	g, ok := s.games[gameID]
+	if !ok {
+		s.logger.Warnw("Ignoring withdrawal from an unknown game", GameID, gameID, "Player", pl.Id)
+		return
+	}
+	// The game is still in its initial state if the state machine did not process the first player yet.
+	if state := g.fsm.Current(); state != Init && state != WaitPlayersReady {
+		s.logger.Warnw("Player withdrew from a running game", GameID, gameID, "Player", pl.Id)
+		g.pb.Publish(PlayerWithdraw, gameID)
+		return
+	}
+	players, err := s.state.players(gameID)
+	if err != nil {
+		s.logger.Errorw("Failed to read the players of the game", GameID, gameID, "Error", err)
+		return
+	}
+	if _, ok := players[PlayerID(pl.Id)]; !ok {
+		s.logger.Warnw("Ignoring withdrawal of a player not registered for the game", GameID, gameID, "Player", pl.Id)
+		return
+	}
+	if err := s.state.removePlayer(gameID, pl.Id); err != nil {
+		s.logger.Errorw("Failed to remove player", GameID, gameID, "Error", err)
+		return
+	}
+	s.logger.Debugw("Player withdrew from the game", GameID, gameID, "Player", pl.Id)
+	if len(players) == 1 {
+		g.fsm.Stop()
+		s.bus.Close(gameID)
+		delete(s.games, gameID)
+		if err := s.state.deleteGame(gameID); err != nil {
+			s.logger.Errorw("Failed to remove the game", GameID, gameID, "Error", err)
+		}
+	} else {
+		g.pb.Publish(PlayerWithdraw, gameID)
+	}
+	s.pb.PublishExternalEvent(&pb.Event{
+		Name:    PlayerWithdrawn,
+		GameID:  gameID,
+		Players: []*pb.Player{pl},
+	}, ClientOutgoingEventsTopic)
+}
+
+// runGame starts the state machine of the game.
+func (s *ServiceNG) runGame(g *Game) {
+	g.Observe(s.observers...)
+	gameErrCh := make(chan error, 1)
+	go func() {
+		// Do not propagate this error to the client.
+		// Since should not be related to the client code, but would indicate a bug in the Game FSM.
+		if err, open := <-gameErrCh; open {
+			s.logger.Errorf("Game error: %s\n", err.Error())
+		}
+	}()
+	g.Init(gameErrCh)
+}
+
+// checkPodAffinity verifies that the player agrees with the players already registered for the game on the pods the
+// game is pinned to and that it runs on one of them.
+func (s *ServiceNG) checkPodAffinity(pl *pb.Player, gameID string) error {
+	players, err := s.state.players(gameID)
+	if err != nil {
+		return err
+	}
+	for _, other := range players {
+		if other.Id == pl.Id {
+			continue
+		}
+		if !equalPods(other.PodAffinity, pl.PodAffinity) {
+			return fmt.Errorf("player %d of game %s is pinned to pods %v, but player %d is pinned to pods %v", pl.Id, gameID, pl.PodAffinity, other.Id, other.PodAffinity)
+		}
+	}
+	if len(pl.PodAffinity) == 0 {
+		return nil
+	}
+	index := int(PlayerIndex(pl))
+	if index >= 0 && index < len(pl.PodAffinity) && pl.PodAffinity[index] == pl.Pod {
+		return nil
+	}
+	return fmt.Errorf("player %d of game %s runs on pod %s, but it is pinned to the pods %v", pl.Id, gameID, pl.Pod, pl.PodAffinity)
+}
+
+// checkParams verifies that the player is configured with the same SPDZ parameters as the players already registered
+// for the game.
+func (s *ServiceNG) checkParams(pl *pb.Player, gameID string) error {
+	players, err := s.state.players(gameID)
+	if err != nil {
+		return err
+	}
+	for _, other := range players {
+		if other.Id == pl.Id {
+			continue
+		}
+		if other.ParamsFingerprint != pl.ParamsFingerprint {
+			return fmt.Errorf("player %d of game %s has parameter fingerprint %q, but player %d has %q", pl.Id, gameID, pl.ParamsFingerprint, other.Id, other.ParamsFingerprint)
+		}
+	}
+	return nil
+}
+
+// rejectPlayer notifies the clients of the game about the reason the player was rejected and fails the game if it
+// exists.
+func (s *ServiceNG) rejectPlayer(gameID string, reason string) {
+	s.pb.PublishExternalEvent(&pb.Event{
+		Name:   reason,
+		GameID: gameID,
+	}, ClientOutgoingEventsTopic)
+	if g, ok := s.games[gameID]; ok {
+		g.pb.Publish(reason, gameID)
+	}
+}
+
+// equalPods returns true if both lists name the same pods in the same order.
+func equalPods(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // processOut converts the internal events to the format understandable by the
 // discovery clients.
 func (s *ServiceNG) processOut(e interface{}) {
@@ -284,14 +501,20 @@ func (s *ServiceNG) processOut(e interface{}) {
 	defer s.mux.Unlock()
 	ev := e.(*fsm.Event)
 	gameID := ev.Meta.SrcTopics[0]
-	players, ok := s.players[gameID]
+	players, err := s.state.players(gameID)
+	if err != nil {
+		s.logger.Errorw("Failed to read the players of the game", GameID, gameID, "Error", err)
+	}
 	pls := []*pb.Player{}
 	for _, p := range players {
 		pls = append(pls, p)
 	}
-	if !ok {
+	if len(pls) == 0 {
 		s.logger.Errorf("No player registered for the game with id %s", gameID)
 	}
+	if g, ok := s.games[gameID]; ok {
+		s.recordGame(g)
+	}
 	event := &pb.Event{
 		Name:    ev.Name,
 		GameID:  gameID,
@@ -300,6 +523,61 @@ func (s *ServiceNG) processOut(e interface{}) {
 	s.pb.PublishExternalEvent(event, ClientOutgoingEventsTopic)
 }
 
+// recordGame updates the state of the game in the state store. Finished games are removed along with their players,
+// and their networks are released if no other game references them and the networks are reference counted. The pods of
+// the players are kept by the game, so that their networks are released once the game is evicted otherwise.
+func (s *ServiceNG) recordGame(g *Game) {
+	if !g.finished() {
+		if err := s.state.setGame(g.id, gameCheckpoint(g)); err != nil {
+			s.logger.Errorw("Failed to record the state of the game", GameID, g.id, "Error", err)
+		}
+		return
+	}
+	players, err := s.state.players(g.id)
+	if err != nil {
+		s.logger.Errorw("Failed to read the players of the game", GameID, g.id, "Error", err)
+		return
+	}
+	if s.portReuse == PortReuseRefCount {
+		s.releaseUnreferencedNetworks(g.id)
+	}
+	for _, pl := range players {
+		g.pods = append(g.pods, pl.Pod)
+	}
+	if err := s.state.deleteGame(g.id); err != nil {
+		s.logger.Errorw("Failed to record the state of the game", GameID, g.id, "Error", err)
+	}
+}
+
+// releaseUnreferencedNetworks releases the networks of the pods of the given game that are not referenced by any game
+// which has not finished yet. It must be called with the lock held.
+func (s *ServiceNG) releaseUnreferencedNetworks(gameID string) {
+	players, err := s.state.players(gameID)
+	if err != nil {
+		s.logger.Errorw("Failed to read the players of the game", GameID, gameID, "Error", err)
+		return
+	}
+	var pods []string
+	for _, pl := range players {
+		pods = append(pods, pl.Pod)
+	}
+	referenced := map[string]bool{}
+	for id, g := range s.games {
+		if g.finished() {
+			continue
+		}
+		pls, err := s.state.players(id)
+		if err != nil {
+			s.logger.Errorw("Failed to read the players of the game", GameID, id, "Error", err)
+			return
+		}
+		for _, pl := range pls {
+			referenced[pl.Pod] = true
+		}
+	}
+	s.releaseNetworks(pods, referenced)
+}
+
 // verifyGameState checks whether it is still allowed to join the game.
 func (s *ServiceNG) verifyGameState(g *Game) bool {
 	if g.fsm.Current() != fsm.Stopped {
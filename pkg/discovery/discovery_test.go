@@ -61,7 +61,7 @@ func generateDiscoveryNGTestsWithPlayerCount(playerCount int) {
 
 		frontendAddress = "192.168.0.1"
 		conf := &FakeDClient{}
-		s = NewServiceNG(bus, pb, stateTimeout, computationTimeout, tr, n, frontendAddress, logger, ModeMaster, conf, playerCount)
+		s = NewServiceNG(bus, pb, stateTimeout, computationTimeout, tr, n, frontendAddress, logger, ModeMaster, conf, playerCount, NewMemoryStateStore())
 		g = &GamesWithBus{
 			Games: s.games,
 			Bus:   bus,
@@ -95,10 +95,12 @@ func generateDiscoveryNGTestsWithPlayerCount(playerCount int) {
 						Expect(event.Players[i].Ip).To(Equal(frontendAddress))
 						Expect(event.Players[i].Port).NotTo(BeZero())
 					}
-					Expect(len(s.pods)).To(Equal(playerCount))
+					pods, err := s.state.pods()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(len(pods)).To(Equal(playerCount))
 					for i := 0; i < playerCount; i++ {
 						podName := fmt.Sprintf("pod%d", i+1)
-						Expect(s.pods[podName]).To(Equal(int32(i)))
+						Expect(pods[podName]).To(Equal(int32(i)))
 					}
 				})
 				go s.Start()
@@ -126,6 +128,125 @@ func generateDiscoveryNGTestsWithPlayerCount(playerCount int) {
 				WaitDoneOrTimeout(done)
 			})
 		})
+		Context("players are pinned to different pods", func() {
+			It("rejects the player and fails the game", func() {
+				allPlayers, allPlayerReadyEvents := createPlayersAndPlayerReadyEvents(playerCount, frontendAddress)
+				allPlayers[0].Id, allPlayers[1].Id = 100, 101
+				allPlayers[0].PodAffinity = []string{"pod1", "pod2"}
+				allPlayers[1].PodAffinity = []string{"pod1", "pod3"}
+				mismatch := GenerateEvents(PodAffinityMismatch, "0")[0]
+				assertExternalEventBody(mismatch, ClientOutgoingEventsTopic, g, done, func(event *proto.Event) {
+					Expect(s.state.players("0")).NotTo(HaveKey(PlayerID(allPlayers[1].Id)))
+				})
+				go s.Start()
+				s.WaitUntilReady(timeout)
+				pb.PublishExternalEvent(allPlayerReadyEvents[0], ClientIncomingEventsTopic)
+				pb.PublishExternalEvent(allPlayerReadyEvents[1], ClientIncomingEventsTopic)
+				WaitDoneOrTimeout(done)
+			})
+		})
+		Context("players are configured with different parameters", func() {
+			It("rejects the player and fails the game", func() {
+				allPlayers, allPlayerReadyEvents := createPlayersAndPlayerReadyEvents(playerCount, frontendAddress)
+				allPlayers[0].ParamsFingerprint = "a"
+				allPlayers[1].ParamsFingerprint = "b"
+				mismatch := GenerateEvents(ParamsMismatch, "0")[0]
+				assertExternalEventBody(mismatch, ClientOutgoingEventsTopic, g, done, func(event *proto.Event) {
+					Expect(s.state.players("0")).NotTo(HaveKey(PlayerID(allPlayers[1].Id)))
+				})
+				go s.Start()
+				s.WaitUntilReady(timeout)
+				pb.PublishExternalEvent(allPlayerReadyEvents[0], ClientIncomingEventsTopic)
+				pb.PublishExternalEvent(allPlayerReadyEvents[1], ClientIncomingEventsTopic)
+				WaitDoneOrTimeout(done)
+			})
+		})
+		Context("no free port is left for the network of a player", func() {
+			It("rejects the player", func() {
+				n.FreePorts = nil
+				_, allPlayerReadyEvents := createPlayersAndPlayerReadyEvents(playerCount, frontendAddress)
+				exhausted := GenerateEvents(PortsExhausted, "0")[0]
+				assertExternalEventBody(exhausted, ClientOutgoingEventsTopic, g, done, func(event *proto.Event) {
+					Expect(s.state.players("0")).To(BeEmpty())
+				})
+				go s.Start()
+				s.WaitUntilReady(timeout)
+				pb.PublishExternalEvent(allPlayerReadyEvents[0], ClientIncomingEventsTopic)
+				WaitDoneOrTimeout(done)
+			})
+		})
+		Context("a player runs on a pod it is not pinned to", func() {
+			It("rejects the player", func() {
+				allPlayers, allPlayerReadyEvents := createPlayersAndPlayerReadyEvents(playerCount, frontendAddress)
+				allPlayers[0].Id = 100
+				allPlayers[0].PodAffinity = []string{"pod9"}
+				mismatch := GenerateEvents(PodAffinityMismatch, "0")[0]
+				assertExternalEventBody(mismatch, ClientOutgoingEventsTopic, g, done, func(event *proto.Event) {
+					Expect(s.state.players("0")).To(BeEmpty())
+				})
+				go s.Start()
+				s.WaitUntilReady(timeout)
+				pb.PublishExternalEvent(allPlayerReadyEvents[0], ClientIncomingEventsTopic)
+				WaitDoneOrTimeout(done)
+			})
+			It("rejects the player if it runs on the pod pinned for another player", func() {
+				allPlayers, allPlayerReadyEvents := createPlayersAndPlayerReadyEvents(playerCount, frontendAddress)
+				allPlayers[0].Id = 100
+				allPlayers[0].PodAffinity = []string{"pod2", "pod1"}
+				mismatch := GenerateEvents(PodAffinityMismatch, "0")[0]
+				assertExternalEventBody(mismatch, ClientOutgoingEventsTopic, g, done, func(event *proto.Event) {
+					Expect(s.state.players("0")).To(BeEmpty())
+				})
+				go s.Start()
+				s.WaitUntilReady(timeout)
+				pb.PublishExternalEvent(allPlayerReadyEvents[0], ClientIncomingEventsTopic)
+				WaitDoneOrTimeout(done)
+			})
+		})
+		Context("a player withdraws from the game", func() {
+			It("informs the other players and lets another player take its place", func() {
+				allPlayers, allPlayerReadyEvents := createPlayersAndPlayerReadyEvents(playerCount, frontendAddress)
+				withdraw := GenerateEvents(PlayerWithdraw, "0")[0]
+				withdraw.Players[0] = allPlayers[0]
+				withdrawn := GenerateEvents(PlayerWithdrawn, "0")[0]
+				playersReady := GenerateEvents(PlayersReady, "0")[0]
+				assertExternalEventBody(withdrawn, ClientOutgoingEventsTopic, g, done, func(event *proto.Event) {
+					Expect(event.Players).To(HaveLen(1))
+					Expect(event.Players[0].Id).To(Equal(allPlayers[0].Id))
+					Expect(s.state.players("0")).NotTo(HaveKey(PlayerID(allPlayers[0].Id)))
+				})
+				go s.Start()
+				s.WaitUntilReady(timeout)
+				for _, playerReadyEvent := range allPlayerReadyEvents[:playerCount-1] {
+					pb.PublishExternalEvent(playerReadyEvent, ClientIncomingEventsTopic)
+				}
+				pb.PublishExternalEvent(withdraw, ClientIncomingEventsTopic)
+				WaitDoneOrTimeout(done)
+				assertExternalEventBody(playersReady, ClientOutgoingEventsTopic, g, done, func(event *proto.Event) {
+					Expect(event.Players).To(HaveLen(playerCount))
+				})
+				pb.PublishExternalEvent(allPlayerReadyEvents[0], ClientIncomingEventsTopic)
+				pb.PublishExternalEvent(allPlayerReadyEvents[playerCount-1], ClientIncomingEventsTopic)
+				WaitDoneOrTimeout(done)
+			})
+			It("drops the game once no player remains", func() {
+				allPlayers, allPlayerReadyEvents := createPlayersAndPlayerReadyEvents(playerCount, frontendAddress)
+				withdraw := GenerateEvents(PlayerWithdraw, "0")[0]
+				withdraw.Players[0] = allPlayers[0]
+				withdrawn := GenerateEvents(PlayerWithdrawn, "0")[0]
+				assertExternalEventBody(withdrawn, ClientOutgoingEventsTopic, g, done, func(event *proto.Event) {
+					Expect(s.state.players("0")).To(BeEmpty())
+				})
+				go s.Start()
+				s.WaitUntilReady(timeout)
+				pb.PublishExternalEvent(allPlayerReadyEvents[0], ClientIncomingEventsTopic)
+				pb.PublishExternalEvent(withdraw, ClientIncomingEventsTopic)
+				WaitDoneOrTimeout(done)
+				s.mux.Lock()
+				defer s.mux.Unlock()
+				Expect(s.games).NotTo(HaveKey("0"))
+			})
+		})
 		Context("a single player sends 2 messages in a row", func() {
 			It("doesn't create the second network", func() {
 				playersReady := GenerateEvents(PlayersReady, "0")[0]
@@ -181,14 +302,14 @@ func generateDiscoveryNGTestsWithPlayerCount(playerCount int) {
 					allPlayerReadyEventsInGame1[i].Players[0] = allPlayers[i]
 				}
 				assertExternalEventBody(playersReady, ClientOutgoingEventsTopic, g, done, func(event *proto.Event) {
-					pp, _ := s.players["0"]
+					pp, _ := s.state.players("0")
 					for i := 0; i < playerCount; i++ {
 						p := pp[PlayerID(int32(i))]
 						Expect(p.Port).To(Equal(int32(30000 + i)))
 					}
 				})
 				assertExternalEventBody(playersReady1, ClientOutgoingEventsTopic, g, done, func(event *proto.Event) {
-					pp, _ := s.players["1"]
+					pp, _ := s.state.players("1")
 					for i := 0; i < playerCount; i++ {
 						p := pp[PlayerID(int32(i))]
 						Expect(p.Port).To(Equal(int32(30000 + i)))
@@ -341,9 +462,8 @@ func generateDiscoveryNGTestsWithPlayerCount(playerCount int) {
 					defer func() {
 						done <- struct{}{}
 					}()
-					Expect(len(s.players["0"])).To(Equal(1))
-					Expect(s.networks["a"]).To(Equal(int32(30000)))
-					Expect(len(s.networks)).To(Equal(1))
+					Expect(s.state.players("0")).To(HaveLen(1))
+					Expect(s.state.networks()).To(Equal(map[string]int32{"a": 30000}))
 					Expect(ev.Players[0].Port).To(Equal(int32(30000)))
 				}
 			})
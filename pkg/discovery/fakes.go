@@ -176,10 +176,20 @@ func (t *FakeTransport) Events(stream pb.Discovery_EventsServer) error {
 
 type FakeNetworker struct {
 	FreePorts []int32
+	// Released are the pods whose networks have been released.
+	Released []string
 }
 
 func (f *FakeNetworker) CreateNetwork(pl *pb.Player) (int32, error) {
+	if len(f.FreePorts) == 0 {
+		return 0, ErrNoFreePorts
+	}
 	port := f.FreePorts[0]
 	f.FreePorts = f.FreePorts[1:]
 	return port, nil
 }
+
+func (f *FakeNetworker) ReleaseNetwork(pod string) error {
+	f.Released = append(f.Released, pod)
+	return nil
+}
@@ -20,9 +20,10 @@ const (
 )
 
 // NewFSM returns a new finate state machine.
-func NewFSM(ctx context.Context, initState string, trn map[TransitionID]*Transition, cb map[string][]*Callback, stateTimeout time.Duration, logger *zap.SugaredLogger) (*FSM, error) {
+//
+// States time out after stateTimeout unless a timeout for the state is given in stateTimeouts, which may be nil.
+func NewFSM(ctx context.Context, initState string, trn map[TransitionID]*Transition, cb map[string][]*Callback, stateTimeout time.Duration, stateTimeouts map[string]time.Duration, logger *zap.SugaredLogger) (*FSM, error) {
 	var stateTimeoutCb *Callback
-	timer := time.NewTimer(stateTimeout)
 	beforeCallbacks := make(map[string][]*Callback)
 	afterCallbacks := make(map[string][]*Callback)
 	for k, c := range cb {
@@ -44,21 +45,23 @@ func NewFSM(ctx context.Context, initState string, trn map[TransitionID]*Transit
 	}
 	history := NewHistory()
 	history.AddState(initState)
-	return &FSM{
+	f := &FSM{
 		afterCallbacks:       afterCallbacks,
 		beforeCallbacks:      beforeCallbacks,
 		transitions:          trn,
 		current:              initState,
 		history:              history,
 		stateTimeoutCallback: stateTimeoutCb,
-		timer:                timer,
 		stateTimeout:         stateTimeout,
+		stateTimeouts:        stateTimeouts,
 		pingCh:               make(chan struct{}),
 		doneCh:               make(chan struct{}, 1),
 		queue:                []*Event{},
 		logger:               logger,
 		ctx:                  ctx,
-	}, nil
+	}
+	f.timer = time.NewTimer(f.timeoutOf(initState))
+	return f, nil
 }
 
 // FSM is a finate state machine.
@@ -75,12 +78,24 @@ type FSM struct {
 	doneCh               chan struct{}
 	timer                *time.Timer
 	stateTimeout         time.Duration
+	stateTimeouts        map[string]time.Duration
+	gameID               string
+	observers            []Observer
 	queue                []*Event
 	logger               *zap.SugaredLogger
 	mux                  sync.Mutex
 	ctx                  context.Context
 }
 
+// Observe registers observers which are notified about the state transitions of the FSM. The transitions are reported
+// along with the given game ID.
+func (f *FSM) Observe(gameID string, observers ...Observer) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.gameID = gameID
+	f.observers = append(f.observers, observers...)
+}
+
 // Write sends an event to the FSM FIFO queue and notifies the processor that new event arrived.
 func (f *FSM) Write(event *Event) {
 	f.mux.Lock()
@@ -103,6 +118,22 @@ func (f *FSM) Current() string {
 	return f.current
 }
 
+// Restore resumes the FSM in the given state as if the given events had been received already. The restored state
+// times out after the given duration. The method must be called before the FSM is run.
+func (f *FSM) Restore(state string, events []*Event, timeout time.Duration) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	for _, ev := range events {
+		f.history.AddEvent(ev)
+	}
+	f.current = state
+	f.history.AddState(state)
+	if !f.timer.Stop() && len(f.timer.C) > 0 {
+		<-f.timer.C
+	}
+	f.timer.Reset(timeout)
+}
+
 // Run consumes events from the queue until an error occurs or the FSM has been stopped.
 // The error is caused either by an unregistered event or by the callback itself.
 // If the FSM was stopped its state is updated, the timer is stopped and the error channel is closed.
@@ -194,16 +225,20 @@ func (f *FSM) doTransition(tr *Transition, event *Event) error {
 		return err
 	}
 	// Transition to the next state.
+	src := f.current
 	f.current = tr.Dst
 	f.history.AddState(f.current)
+	for _, o := range f.observers {
+		o.OnTransition(src, event.Name, f.current, f.gameID)
+	}
 	// Reset state timeout.
 	// See the description of time.Reset for the reasoning of the complicated setup.
 	f.timer.Stop()
 	if !f.timer.Stop() && len(f.timer.C) > 0 {
 		<-f.timer.C
 	}
-	timeout := f.stateTimeout
-	// Specific state timeout overrides the fsm's default state timeout.
+	timeout := f.timeoutOf(tr.Dst)
+	// Specific transition timeout overrides the state timeout.
 	if tr.Timeout > 0 {
 		timeout = tr.Timeout
 	}
@@ -216,6 +251,14 @@ func (f *FSM) doTransition(tr *Transition, event *Event) error {
 	return nil
 }
 
+// timeoutOf returns the timeout of the given state, which defaults to the fsm's state timeout.
+func (f *FSM) timeoutOf(state string) time.Duration {
+	if timeout, ok := f.stateTimeouts[state]; ok && timeout > 0 {
+		return timeout
+	}
+	return f.stateTimeout
+}
+
 // runCallbackIfExists executes a callback for a given state if it exists, does nothing otherwise.
 // It returns an error if user callback fails.
 func (f *FSM) runCallbackIfExists(callbacks map[string][]*Callback, state string, event *Event) error {
@@ -366,6 +409,21 @@ func (i *Transition) WithTimeout(d time.Duration) *Transition {
 	return i
 }
 
+// Observer is notified about the state transitions of an FSM, e.g., to record metrics or audit logs. It is called
+// synchronously once the state has been changed and before the callbacks after entering the state are executed, hence
+// it must neither block nor write to the FSM.
+type Observer interface {
+	OnTransition(src, event, dst, gameID string)
+}
+
+// ObserverFunc adapts a function to an Observer.
+type ObserverFunc func(src, event, dst, gameID string)
+
+// OnTransition calls the function.
+func (o ObserverFunc) OnTransition(src, event, dst, gameID string) {
+	o(src, event, dst, gameID)
+}
+
 // Action is a user defined function executed in the callback.
 type Action func(interface{}) error
 
@@ -50,7 +50,7 @@ var _ = Describe("FSM", func() {
 			transitions := map[TransitionID]*Transition{}
 			transitions[tr.ID] = tr
 
-			fsm, _ := NewFSM(ctx, "Init", transitions, callbacks, timeout, logger)
+			fsm, _ := NewFSM(ctx, "Init", transitions, callbacks, timeout, nil, logger)
 			go fsm.Run(errChan)
 			event := Event{
 				Name: "Register",
@@ -69,7 +69,7 @@ var _ = Describe("FSM", func() {
 			transitions := map[TransitionID]*Transition{}
 			transitions[tr.ID] = tr
 
-			fsm, _ := NewFSM(ctx, "Init", transitions, callbacks, timeout, logger)
+			fsm, _ := NewFSM(ctx, "Init", transitions, callbacks, timeout, nil, logger)
 			go fsm.Run(errChan)
 			event := Event{
 				Name: "Register",
@@ -116,7 +116,7 @@ var _ = Describe("FSM", func() {
 				transitions[t.ID] = t
 			}
 			timeout := 50 * time.Millisecond
-			fsm, _ := NewFSM(ctx, "Init", transitions, callbacks, timeout, logger)
+			fsm, _ := NewFSM(ctx, "Init", transitions, callbacks, timeout, nil, logger)
 			go fsm.Run(errChan)
 			resp := <-respCh
 			Expect(resp).To(Equal("timeout"))
@@ -140,7 +140,7 @@ var _ = Describe("FSM", func() {
 			callbacks := map[string][]*Callback{cb.Src: {cb}}
 			transitions := map[TransitionID]*Transition{tr.ID: tr}
 			timeout := 1 * time.Hour
-			fsm, _ := NewFSM(ctx, "Init", transitions, callbacks, timeout, logger)
+			fsm, _ := NewFSM(ctx, "Init", transitions, callbacks, timeout, nil, logger)
 			go fsm.Run(errChan)
 			fsm.Write(&Event{
 				Name: "StartTest",
@@ -156,6 +156,69 @@ var _ = Describe("FSM", func() {
 		})
 	})
 
+	Context("when per-state timeouts are set", func() {
+		It("overrides the default timeout when entering the state", func() {
+			respCh := make(chan string)
+			respond := func(interface{}) error {
+				respCh <- "timeout"
+				return nil
+			}
+			tr := WhenIn("Init").GotEvent("StartTest").GoTo("AwaitTimeout")
+			cb := WhenStateTimeout().Do(respond)
+			callbacks := map[string][]*Callback{cb.Src: {cb}}
+			transitions := map[TransitionID]*Transition{tr.ID: tr}
+			timeouts := map[string]time.Duration{"AwaitTimeout": 5 * time.Millisecond}
+			fsm, _ := NewFSM(ctx, "Init", transitions, callbacks, 1*time.Hour, timeouts, logger)
+			go fsm.Run(errChan)
+			fsm.Write(&Event{
+				Name: "StartTest",
+				Meta: &Metadata{FSM: fsm},
+			})
+			var resp string
+			select {
+			case resp = <-respCh:
+			case <-time.After(2 * time.Second):
+				Fail("timeout exceeded - per-state timeout not triggered")
+			}
+			Expect(resp).To(Equal("timeout"))
+		})
+		It("applies to the initial state", func() {
+			respCh := make(chan string)
+			respond := func(interface{}) error {
+				respCh <- "timeout"
+				return nil
+			}
+			cb := WhenStateTimeout().Do(respond)
+			callbacks := map[string][]*Callback{cb.Src: {cb}}
+			timeouts := map[string]time.Duration{"Init": 5 * time.Millisecond}
+			fsm, _ := NewFSM(ctx, "Init", map[TransitionID]*Transition{}, callbacks, 1*time.Hour, timeouts, logger)
+			go fsm.Run(errChan)
+			var resp string
+			select {
+			case resp = <-respCh:
+			case <-time.After(2 * time.Second):
+				Fail("timeout exceeded - per-state timeout not triggered")
+			}
+			Expect(resp).To(Equal("timeout"))
+		})
+	})
+
+	Context("when the FSM is observed", func() {
+		It("notifies the observers about each transition", func() {
+			tr := WhenIn("Init").GotEvent("Register").GoTo("Registering")
+			transitions := map[TransitionID]*Transition{tr.ID: tr}
+			fsm, _ := NewFSM(ctx, "Init", transitions, map[string][]*Callback{}, timeout, nil, logger)
+			type transition struct{ src, event, dst, gameID string }
+			observed := make(chan transition, 1)
+			fsm.Observe("game", ObserverFunc(func(src, event, dst, gameID string) {
+				observed <- transition{src, event, dst, gameID}
+			}))
+			go fsm.Run(errChan)
+			fsm.Write(&Event{Name: "Register", Meta: &Metadata{FSM: fsm}})
+			Eventually(observed).Should(Receive(Equal(transition{"Init", "Register", "Registering", "game"})))
+		})
+	})
+
 	Context("when staying the same state", func() {
 		It("executes registered callbacks for the state", func() {
 			respCh := make(chan string)
@@ -171,7 +234,7 @@ var _ = Describe("FSM", func() {
 			transitions := map[TransitionID]*Transition{}
 			transitions[tr.ID] = tr
 
-			fsm, _ := NewFSM(ctx, "Init", transitions, callbacks, timeout, logger)
+			fsm, _ := NewFSM(ctx, "Init", transitions, callbacks, timeout, nil, logger)
 			go fsm.Run(errChan)
 			event := Event{
 				Name: "Register",
@@ -203,7 +266,7 @@ var _ = Describe("FSM", func() {
 			tr := WhenIn("Init").GotEvent("Next").GoTo(afterInit)
 			callbacks[afterInit] = cb
 			transitions[tr.ID] = tr
-			fsm, _ := NewFSM(ctx, "Init", transitions, callbacks, timeout, logger)
+			fsm, _ := NewFSM(ctx, "Init", transitions, callbacks, timeout, nil, logger)
 			go fsm.Run(errChan)
 			event := Event{
 				Name: "Next",
@@ -233,7 +296,7 @@ var _ = Describe("FSM", func() {
 			transitions[tr.ID] = tr
 
 			errChan := make(chan error)
-			fsm, _ := NewFSM(ctx, "Init", transitions, callbacks, timeout, logger)
+			fsm, _ := NewFSM(ctx, "Init", transitions, callbacks, timeout, nil, logger)
 			go fsm.Run(errChan)
 			event := &Event{
 				Name: "Next",
@@ -8,6 +8,7 @@ import (
 	"context"
 	"errors"
 	"github.com/carbynestack/ephemeral/pkg/discovery/fsm"
+	"sync"
 	"time"
 
 	. "github.com/carbynestack/ephemeral/pkg/types"
@@ -28,6 +29,12 @@ type Game struct {
 	fsm *fsm.FSM
 	bus mb.MessageBus
 	pb  *Publisher
+	mux sync.Mutex
+	// changed is the time the game was created at or last changed its state.
+	changed time.Time
+	// pods are the pods the players of the game ran on once it finished and its players were removed from the
+	// bookkeeping.
+	pods []string
 }
 
 // Init starts the fsm of the Game with its initial state.
@@ -44,6 +51,31 @@ func (g *Game) History() *fsm.History {
 	return g.fsm.History()
 }
 
+// Observe registers observers which are notified about the state transitions of the game.
+func (g *Game) Observe(observers ...fsm.Observer) {
+	g.fsm.Observe(g.id, observers...)
+}
+
+// OnTransition records the time the game changed its state.
+func (g *Game) OnTransition(src, event, dst, gameID string) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	g.changed = time.Now()
+}
+
+// lastChanged returns the time the game was created at or last changed its state.
+func (g *Game) lastChanged() time.Time {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	return g.changed
+}
+
+// finished returns true if the game is over, i.e., it finished successfully or with an error.
+func (g *Game) finished() bool {
+	state := g.fsm.Current()
+	return state == fsm.Stopped || state == GameDone || state == GameError
+}
+
 // Bus returns the bus used by game.
 func (g *Game) Bus() mb.MessageBus {
 	return g.bus
@@ -70,7 +102,11 @@ func NewGame(ctx context.Context, id string, bus mb.MessageBus, stateTimeout tim
 	}
 	trs := []*fsm.Transition{
 		fsm.WhenIn(Init).GotEvent(PlayerReady).GoTo(WaitPlayersReady),
+		// Players may abort a game before it starts, e.g., if it is cancelled.
+		fsm.WhenIn(Init).GotEvent(GameFinishedWithError).GoTo(GameError),
+		fsm.WhenIn(WaitPlayersReady).GotEvent(GameFinishedWithError).GoTo(GameError),
 		fsm.WhenIn(WaitPlayersReady).GotEvent(PlayerReady).Stay(),
+		fsm.WhenIn(WaitPlayersReady).GotEvent(PlayerWithdraw).Stay(),
 		fsm.WhenIn(WaitPlayersReady).GotEvent(PlayersReady).GoTo(WaitTCPCheck),
 		fsm.WhenIn(WaitTCPCheck).GotEvent(TCPCheckSuccess).Stay(),
 		fsm.WhenIn(WaitTCPCheck).GotEvent(TCPCheckSuccessAll).GoTo(Playing).WithTimeout(computationTimeout),
@@ -81,10 +117,15 @@ func NewGame(ctx context.Context, id string, bus mb.MessageBus, stateTimeout tim
 		fsm.WhenIn(Playing).GotEvent(GameSuccess).GoTo(GameDone),
 		fsm.WhenIn(Playing).GotEvent(GameError).GoTo(GameError),
 		fsm.WhenInAnyState().GotEvent(StateTimeoutError).GoTo(GameError),
+		fsm.WhenInAnyState().GotEvent(PodAffinityMismatch).GoTo(GameError),
+		fsm.WhenInAnyState().GotEvent(ParamsMismatch).GoTo(GameError),
+		fsm.WhenInAnyState().GotEvent(PortsExhausted).GoTo(GameError),
+		// Players must not leave a game once it has started.
+		fsm.WhenInAnyState().GotEvent(PlayerWithdraw).GoTo(GameError),
 		fsm.WhenInAnyState().GotEvent(GameDone).GoTo(GameDone),
 	}
 	callbacks, transitions := fsm.InitCallbacksAndTransitions(cb, trs)
-	f, err := fsm.NewFSM(ctx, Init, transitions, callbacks, stateTimeout, logger)
+	f, err := fsm.NewFSM(ctx, Init, transitions, callbacks, stateTimeout, nil, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -95,12 +136,34 @@ func NewGame(ctx context.Context, id string, bus mb.MessageBus, stateTimeout tim
 		ev.Meta.FSM = f
 		f.Write(ev)
 	})
-	return &Game{
-		id:  id,
-		fsm: f,
-		bus: bus,
-		pb:  publisher,
-	}, nil
+	g := &Game{
+		id:      id,
+		fsm:     f,
+		bus:     bus,
+		pb:      publisher,
+		changed: time.Now(),
+	}
+	g.Observe(g)
+	return g, nil
+}
+
+// RestoreGame returns an instance of Game resumed in the given state, as if the events with the given names had been
+// received already.
+func RestoreGame(ctx context.Context, id string, state string, events []string, bus mb.MessageBus, stateTimeout time.Duration, computationTimeout time.Duration, logger *zap.SugaredLogger, playerCount int) (*Game, error) {
+	g, err := NewGame(ctx, id, bus, stateTimeout, computationTimeout, logger, playerCount)
+	if err != nil {
+		return nil, err
+	}
+	received := make([]*fsm.Event, len(events))
+	for i, name := range events {
+		received[i] = &fsm.Event{Name: name, GameID: id}
+	}
+	timeout := stateTimeout
+	if state == Playing {
+		timeout = computationTimeout
+	}
+	g.fsm.Restore(state, received, timeout)
+	return g, nil
 }
 
 // GameCallbacker contains methods to react on game events.
@@ -113,7 +176,11 @@ type GameCallbacker struct {
 // sendRegistered notifies the client that it was registered for the game.
 func (c *GameCallbacker) sendRegistered() func(e interface{}) error {
 	return func(e interface{}) error {
-		meta := e.(*fsm.Event).Meta
+		ev := e.(*fsm.Event)
+		if ev.Name == PlayerWithdraw {
+			return nil
+		}
+		meta := ev.Meta
 		c.logger.Debugw("Client registered", "meta", meta)
 		c.pb.Publish(Registered, ServiceEventsTopic, meta.TargetTopic)
 		return nil
@@ -168,6 +235,10 @@ func (c *GameCallbacker) checkSomethingReady(players int, in string, out string)
 		}
 		events := f.History().GetEvents()
 		readyPlayers := countEvents(events, in)
+		if in == PlayerReady {
+			// Players that withdrew from the game are no longer ready.
+			readyPlayers -= countEvents(events, PlayerWithdraw)
+		}
 		if readyPlayers == players {
 			c.logger.Debugf("Players ready - sending message %v", out)
 			// the targetTopic of previous event includes the game id we would need for further event forwarding.
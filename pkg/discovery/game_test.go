@@ -6,8 +6,10 @@ package discovery
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"github.com/carbynestack/ephemeral/pkg/discovery/fsm"
 	. "github.com/carbynestack/ephemeral/pkg/types"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -82,6 +84,49 @@ func generateTestsWithPlayerCount(playerCount int) {
 			WaitDoneOrTimeout(done)
 		})
 	})
+	Context("when the game is observed", func() {
+		It("reports the transitions along with the game id", func() {
+			transitions := make(chan string, 10)
+			game.Observe(fsm.ObserverFunc(func(src, event, dst, id string) {
+				transitions <- fmt.Sprintf("%s:%s --%s--> %s", id, src, event, dst)
+			}))
+			game.Init(errCh)
+			pb.Publish(PlayerReady, gameID)
+			Eventually(transitions).Should(Receive(Equal(fmt.Sprintf("%s:%s --%s--> %s", gameID, Init, PlayerReady, WaitPlayersReady))))
+		})
+	})
+	Context("when a player withdraws before all players are ready", func() {
+		It("waits for another player to become ready", func() {
+			game.Init(errCh)
+			Assert(PlayersReady, game, done, func(states []string) {
+				events := game.History().GetEvents()
+				Expect(countEvents(events, PlayerReady)).To(Equal(playerCount + 1))
+				Expect(countEvents(events, PlayerWithdraw)).To(Equal(1))
+			})
+			for i := 0; i < playerCount-1; i++ {
+				pb.Publish(PlayerReady, gameID)
+			}
+			pb.Publish(PlayerWithdraw, gameID)
+			pb.Publish(PlayerReady, gameID)
+			pb.Publish(PlayerReady, gameID)
+			WaitDoneOrTimeout(done)
+		})
+	})
+	Context("when a player withdraws from a running game", func() {
+		It("transitions to the GameError state", func() {
+			game.Init(errCh)
+			Assert(PlayersReady, game, done, func(states []string) {})
+			for i := 0; i < playerCount; i++ {
+				pb.Publish(PlayerReady, gameID)
+			}
+			WaitDoneOrTimeout(done)
+			Assert(GameDone, game, done, func(states []string) {
+				Expect(states[len(states)-2]).To(Equal(GameError))
+			}, ServiceEventsTopic)
+			pb.Publish(PlayerWithdraw, gameID)
+			WaitDoneOrTimeout(done)
+		})
+	})
 	Context("when at least one player fails", func() {
 		Context("during the game", func() {
 			It("transitions to the GameError state", func() {
@@ -151,6 +196,20 @@ func generateTestsWithPlayerCount(playerCount int) {
 				WaitDoneOrTimeout(done)
 			})
 		})
+		Context("before all players are ready", func() {
+			It("transitions to the GameError state", func() {
+				game.Init(errCh)
+				Assert(GameDone, game, done, func(states []string) {
+					statesAsserter := NewStatesAsserter(states)
+					statesAsserter.ExpectNext().To(Equal(Init))
+					statesAsserter.ExpectNext().To(Equal(WaitPlayersReady))
+					statesAsserter.ExpectNext().To(Equal(GameError))
+				}, ServiceEventsTopic)
+				pb.Publish(PlayerReady, gameID)
+				pb.Publish(GameFinishedWithError, gameID)
+				WaitDoneOrTimeout(done)
+			})
+		})
 	})
 	Context("state timeout occurs", func() {
 		It("transitions to the GameError state", func() {
@@ -0,0 +1,154 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package discovery
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/discovery/fsm"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// DefaultGameRetention is the time finished games are kept for if not configured.
+const DefaultGameRetention = 5 * time.Minute
+
+// maxJanitorInterval is the maximum interval the janitor looks for games to evict in.
+const maxJanitorInterval = time.Minute
+
+// JanitorStats are the counters of the janitor evicting stale games.
+type JanitorStats struct {
+	// FinishedGames is the number of games evicted after they finished.
+	FinishedGames int64 `json:"finishedGames"`
+	// StaleGames is the number of games evicted as they did not change their state for longer than their timeouts.
+	StaleGames int64 `json:"staleGames"`
+	// Players is the number of player registrations removed along with the games. The players of finished games are
+	// removed once the games finished, hence they are not counted.
+	Players int64 `json:"players"`
+	// Networks is the number of networks released as no game is left on their pods.
+	Networks int64 `json:"networks"`
+}
+
+// janitorCounters are the counters of the janitor, see JanitorStats.
+type janitorCounters struct {
+	finishedGames, staleGames, players, networks int64
+}
+
+// JanitorStats returns the counters of the janitor.
+func (s *ServiceNG) JanitorStats() JanitorStats {
+	return JanitorStats{
+		FinishedGames: atomic.LoadInt64(&s.janitor.finishedGames),
+		StaleGames:    atomic.LoadInt64(&s.janitor.staleGames),
+		Players:       atomic.LoadInt64(&s.janitor.players),
+		Networks:      atomic.LoadInt64(&s.janitor.networks),
+	}
+}
+
+// RunJanitor evicts the games which finished more than the given retention ago until done is closed. Games which did
+// not change their state for longer than the state and computation timeouts plus the retention are evicted as well, as
+// their state machine is stuck. The players of evicted games are removed from the bookkeeping, and the networks of the
+// pods no game is left on are released, so that their ports are returned to the pool.
+//
+// **Note:** Slaves do not run the games, hence they do not evict any players.
+func (s *ServiceNG) RunJanitor(retention time.Duration, done <-chan struct{}) {
+	interval := retention
+	if interval > maxJanitorInterval {
+		interval = maxJanitorInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.evictGames(time.Now(), retention)
+		case <-done:
+			return
+		}
+	}
+}
+
+// evictGames evicts the games which finished or got stuck, see RunJanitor.
+func (s *ServiceNG) evictGames(now time.Time, retention time.Duration) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	staleAfter := s.stateTimeout + s.computationTimeout + retention
+	var pods []string
+	for id, g := range s.games {
+		idle := now.Sub(g.lastChanged())
+		switch {
+		case g.finished() && idle > retention:
+			atomic.AddInt64(&s.janitor.finishedGames, 1)
+		case !g.finished() && idle > staleAfter:
+			atomic.AddInt64(&s.janitor.staleGames, 1)
+			s.logger.Warnw("Evicting stale game", GameID, id, "State", g.fsm.Current(), "Idle", idle)
+		default:
+			continue
+		}
+		pods = append(pods, s.evictGame(id, g)...)
+	}
+	if len(pods) == 0 {
+		return
+	}
+	players, err := s.state.allPlayers()
+	if err != nil {
+		s.logger.Errorw("Failed to read the players", "Error", err)
+		return
+	}
+	inUse := map[string]bool{}
+	for _, p := range players {
+		for _, pl := range p {
+			inUse[pl.Pod] = true
+		}
+	}
+	s.releaseNetworks(pods, inUse)
+}
+
+// evictGame stops the given game and removes it and its players from the bookkeeping. Returns the pods of the players.
+// It must be called with the lock held.
+func (s *ServiceNG) evictGame(id string, g *Game) []string {
+	if g.fsm.Current() != fsm.Stopped {
+		g.fsm.Stop()
+	}
+	s.bus.Close(id)
+	delete(s.games, id)
+	players, err := s.state.players(id)
+	if err != nil {
+		s.logger.Errorw("Failed to read the players of the game", GameID, id, "Error", err)
+	}
+	if err := s.state.deleteGame(id); err != nil {
+		s.logger.Errorw("Failed to remove the game", GameID, id, "Error", err)
+		return g.pods
+	}
+	// The players of a finished game were removed along with its record already, see recordGame.
+	pods := g.pods
+	for _, pl := range players {
+		atomic.AddInt64(&s.janitor.players, 1)
+		pods = append(pods, pl.Pod)
+	}
+	s.logger.Debugw("Evicted game", GameID, id, "Players", len(players))
+	return pods
+}
+
+// releaseNetworks releases the networks of the given pods which are not in use. It must be called with the lock held.
+func (s *ServiceNG) releaseNetworks(pods []string, inUse map[string]bool) {
+	for _, pod := range pods {
+		if inUse[pod] {
+			continue
+		}
+		// A pod may have run several of the evicted players.
+		inUse[pod] = true
+		if _, ok, err := s.state.network(pod); err != nil || !ok {
+			continue
+		}
+		if err := s.networker.ReleaseNetwork(pod); err != nil {
+			s.logger.Errorw("Failed to release the network", "Pod", pod, "Error", err)
+			continue
+		}
+		if err := s.state.deletePod(pod); err != nil {
+			s.logger.Errorw("Failed to remove the pod from the bookkeeping", "Pod", pod, "Error", err)
+		}
+		atomic.AddInt64(&s.janitor.networks, 1)
+	}
+}
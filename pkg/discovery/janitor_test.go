@@ -0,0 +1,84 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package discovery
+
+import (
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/discovery/fsm"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	mb "github.com/vardius/message-bus"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Janitor", func() {
+	var (
+		s                  *ServiceNG
+		n                  *FakeNetworker
+		stateTimeout       = 10 * time.Second
+		computationTimeout = 20 * time.Second
+		retention          = time.Minute
+		frontendAddress    = "192.168.0.1"
+	)
+	BeforeEach(func() {
+		bus := mb.New(10000)
+		pb := &Publisher{
+			Bus: bus,
+			Fsm: &fsm.FSM{},
+		}
+		n = &FakeNetworker{FreePorts: []int32{30000, 30001}}
+		s = NewServiceNG(bus, pb, stateTimeout, computationTimeout, &FakeTransport{}, n, frontendAddress, zap.NewNop().Sugar(), ModeMaster, &FakeDClient{}, 2, NewMemoryStateStore())
+		_, events := createPlayersAndPlayerReadyEvents(2, frontendAddress)
+		s.processIn(events[0])
+		Eventually(func() string {
+			return s.games["0"].fsm.Current()
+		}).Should(Equal(WaitPlayersReady))
+	})
+	It("evicts finished games after the retention and releases their networks", func() {
+		s.games["0"].pb.Publish(GameDone, "0")
+		Eventually(func() bool {
+			return s.games["0"].finished()
+		}).Should(BeTrue())
+		s.mux.Lock()
+		s.recordGame(s.games["0"])
+		s.mux.Unlock()
+		Expect(s.state.players("0")).To(BeEmpty())
+		Expect(s.state.allPlayers()).To(BeEmpty())
+
+		s.evictGames(time.Now(), retention)
+		Expect(s.games).To(HaveKey("0"))
+		Expect(n.Released).To(BeEmpty())
+
+		s.evictGames(time.Now().Add(2*retention), retention)
+		Expect(s.games).To(BeEmpty())
+		Expect(s.state.networks()).To(BeEmpty())
+		Expect(n.Released).To(Equal([]string{"pod1"}))
+		Expect(s.JanitorStats()).To(Equal(JanitorStats{FinishedGames: 1, Networks: 1}))
+	})
+	It("releases the networks of finished games right away if they are reference counted", func() {
+		Expect(s.SetPortReuse(PortReuseRefCount)).To(Succeed())
+		s.games["0"].pb.Publish(GameDone, "0")
+		Eventually(func() bool {
+			return s.games["0"].finished()
+		}).Should(BeTrue())
+		s.mux.Lock()
+		s.recordGame(s.games["0"])
+		s.mux.Unlock()
+		Expect(n.Released).To(Equal([]string{"pod1"}))
+		Expect(s.state.networks()).To(BeEmpty())
+	})
+	It("evicts games which are stuck for longer than their timeouts", func() {
+		s.evictGames(time.Now().Add(retention), retention)
+		Expect(s.games).To(HaveKey("0"))
+
+		s.evictGames(time.Now().Add(stateTimeout+computationTimeout+2*retention), retention)
+		Expect(s.games).To(BeEmpty())
+		Expect(n.Released).To(Equal([]string{"pod1"}))
+		Expect(s.JanitorStats()).To(Equal(JanitorStats{StaleGames: 1, Players: 1, Networks: 1}))
+	})
+})
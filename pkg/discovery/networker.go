@@ -12,8 +12,8 @@ import (
 	"sync"
 	"time"
 
-	cs "github.com/knative/pkg/client/clientset/versioned"
 	"go.uber.org/zap"
+	cs "istio.io/client-go/pkg/clientset/versioned"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubeinformers "k8s.io/client-go/informers"
@@ -27,10 +27,12 @@ const defaultNamespace = "default"
 // Networker is an interface that allows to retrieve ports and create network config for MPC apps.
 type Networker interface {
 	CreateNetwork(pl *pb.Player) (int32, error)
+	// ReleaseNetwork deletes the network created for the given pod and returns its port to the pool.
+	ReleaseNetwork(pod string) error
 }
 
-// NewIstioNetworker creates a new IstioNetworker
-func NewIstioNetworker(logger *zap.SugaredLogger, portRange string, delCh chan string) (*IstioNetworker, error) {
+// NewIstioNetworker creates a new IstioNetworker assigning the ports of the networks from the given pools.
+func NewIstioNetworker(logger *zap.SugaredLogger, ports *PortPools, delCh chan string) (*IstioNetworker, error) {
 	conf, err := rest.InClusterConfig()
 	if err != nil {
 		return nil, err
@@ -39,15 +41,11 @@ func NewIstioNetworker(logger *zap.SugaredLogger, portRange string, delCh chan s
 	networkClient := clientset.NewForConfigOrDie(conf)
 	istioClient := cs.NewForConfigOrDie(conf)
 
-	portState, err := NewPortsState(portRange, []int32{})
-	if err != nil {
-		return nil, err
-	}
-
 	return &IstioNetworker{
 		networkingClient: networkClient,
 		istioClient:      istioClient,
-		ports:            portState,
+		ports:            ports,
+		assigned:         map[string]int32{},
 		kubeConfig:       conf,
 		logger:           logger,
 		delCh:            delCh,
@@ -57,12 +55,14 @@ func NewIstioNetworker(logger *zap.SugaredLogger, portRange string, delCh chan s
 // IstioNetworker is an implementation of Networker interface which creates new networks backed by Istio configuration.
 type IstioNetworker struct {
 	networkingClient *clientset.Clientset
-	istioClient      *cs.Clientset
-	ports            *PortsState
+	istioClient      cs.Interface
+	ports            *PortPools
 	kubeConfig       *rest.Config
 	logger           *zap.SugaredLogger
 	delCh            chan string
 	mux              sync.Mutex
+	// assigned are the ports of the networks indexed by the pods they were created for.
+	assigned map[string]int32
 }
 
 // Run starts the Networker. This method initializes k8s informers and synchorinizes various caches.
@@ -115,9 +115,9 @@ func (i *IstioNetworker) Run() error {
 
 // CreateNetwork creates a network in the format acceptable by the network controller.
 func (i *IstioNetworker) CreateNetwork(pl *pb.Player) (int32, error) {
-	port, err := i.getPort()
+	port, err := i.getPort(pl)
 	if err != nil {
-		i.logger.Error(err, "not able to get a free port")
+		i.logger.Errorw("Not able to get a free port", "Namespace", pl.Namespace, "Error", err)
 		return 0, err
 	}
 	i.logger.Infof("Creating a new network for player %v", pl)
@@ -130,17 +130,29 @@ func (i *IstioNetworker) CreateNetwork(pl *pb.Player) (int32, error) {
 			Namespace: defaultNamespace,
 			Labels:    lb,
 		},
-		// TODO: remove this 100 hack, it is a temp workaround for protobuf3.
-		Spec: v1alpha1.NetworkSpec{TargetPort: BasePort + pl.Id - 100, Port: port},
+		Spec: v1alpha1.NetworkSpec{TargetPort: BasePort + PlayerIndex(pl), Port: port},
 	}
 	_, err = i.networkingClient.MpcV1alpha1().Networks(defaultNamespace).Create(&network)
 	if err != nil {
 		i.logger.Error(err)
+		i.releasePort(pl.Pod)
 		return 0, err
 	}
 	return port, nil
 }
 
+// ReleaseNetwork deletes the network created for the given pod and returns its port to the pool. Pods no network was
+// created for, e.g., the pods of foreign players, are ignored.
+func (i *IstioNetworker) ReleaseNetwork(pod string) error {
+	i.mux.Lock()
+	_, ok := i.assigned[pod]
+	i.mux.Unlock()
+	if !ok {
+		return nil
+	}
+	return i.deleteNetwork(pod)
+}
+
 // sync synchronizes its port state with k8s gateways.
 func (i *IstioNetworker) sync() error {
 	i.mux.Lock()
@@ -152,10 +164,33 @@ func (i *IstioNetworker) sync() error {
 	if err != nil {
 		return err
 	}
+	for ns, u := range i.ports.Utilization() {
+		i.logger.Debugw("Port pool utilization", "Namespace", ns, "Used", u.Used, "Size", u.Size)
+	}
 
 	return nil
 }
 
+// SetPortPools replaces the pools the ports of new networks are assigned from. The ports of existing networks are
+// marked as used in the new pools and returned to them once the networks are deleted, unless they are not part of the
+// new ranges anymore.
+func (i *IstioNetworker) SetPortPools(ports *PortPools) error {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+	used, err := i.getUsedPorts()
+	if err != nil {
+		return err
+	}
+	for _, port := range i.assigned {
+		used = append(used, port)
+	}
+	if err := ports.Sync(used); err != nil {
+		return err
+	}
+	i.ports = ports
+	return nil
+}
+
 // deleteNetwork executes the given callback and if the result is successful it deletes the network from k8s.
 func (i *IstioNetworker) deleteNetwork(name string) error {
 	err := i.networkingClient.MpcV1alpha1().Networks("default").Delete(name, &metav1.DeleteOptions{})
@@ -163,13 +198,15 @@ func (i *IstioNetworker) deleteNetwork(name string) error {
 		i.logger.Errorf("Error deleting the network: %s", err)
 		return err
 	}
+	i.releasePort(name)
 	return nil
 }
 
-// getUsedPorts iterates over the list of Istio gateways and collects that ports that are already in use.
+// getUsedPorts iterates over the list of Istio gateways and networks and collects that ports that are already in use.
+// The networks are taken into account as the network controller may place the gateways into another namespace.
 func (i *IstioNetworker) getUsedPorts() ([]int32, error) {
 	var usedPorts []int32
-	gateways, err := i.istioClient.NetworkingV1alpha3().Gateways("default").List(metav1.ListOptions{})
+	gateways, err := i.istioClient.NetworkingV1beta1().Gateways(defaultNamespace).List(metav1.ListOptions{})
 	if err != nil {
 		i.logger.Error(err, "unable to retrieve the gateways")
 		return []int32{}, err
@@ -177,16 +214,46 @@ func (i *IstioNetworker) getUsedPorts() ([]int32, error) {
 	// i.logger.Debug("The list of received gateways", gateways.Items)
 	for _, gw := range gateways.Items {
 		for _, server := range gw.Spec.Servers {
-			usedPorts = append(usedPorts, int32(server.Port.Number))
+			if server.Port != nil {
+				usedPorts = append(usedPorts, int32(server.Port.Number))
+			}
 		}
 	}
+	networks, err := i.networkingClient.MpcV1alpha1().Networks(defaultNamespace).List(metav1.ListOptions{})
+	if err != nil {
+		i.logger.Error(err, "unable to retrieve the networks")
+		return []int32{}, err
+	}
+	for _, n := range networks.Items {
+		usedPorts = append(usedPorts, n.Spec.Port)
+	}
 	return usedPorts, nil
 }
 
-func (i *IstioNetworker) getPort() (int32, error) {
-	port, err := i.ports.GetFreePort()
+// getPort assigns a port to the network of the player from the pool of its namespace.
+func (i *IstioNetworker) getPort(pl *pb.Player) (int32, error) {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+	port, err := i.ports.GetFreePort(pl.Namespace)
 	if err != nil {
 		return 0, err
 	}
+	i.assigned[pl.Pod] = port
 	return port, nil
 }
+
+// ReleasePort returns the port assigned to the network of the given pod to its pool without deleting the network, e.g., once
+// it has been deleted by others. Pods no port is assigned to are ignored.
+func (i *IstioNetworker) ReleasePort(pod string) {
+	i.releasePort(pod)
+}
+
+// releasePort returns the port assigned to the network of the given pod to its pool.
+func (i *IstioNetworker) releasePort(pod string) {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+	if port, ok := i.assigned[pod]; ok {
+		i.ports.Release(port)
+		delete(i.assigned, pod)
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// Policies the ports of player networks are allocated by.
+const (
+	// PortAllocationSequential assigns the lowest ports of the first range with free ports, after the released ones.
+	PortAllocationSequential = "sequential"
+	// PortAllocationRandom assigns a port chosen uniformly at random from the free ports of all ranges of a pool.
+	PortAllocationRandom = "random"
+)
+
+// ErrNoFreePorts is returned if all ports of a pool are in use.
+var ErrNoFreePorts = errors.New("no free ports")
+
+// NewPortPools returns the pools the ports of player networks are assigned from. The given ranges (start_port:end_port)
+// are shared by all namespaces, except for those the namespace ranges reserve ranges for. Ranges must not overlap, as
+// all networks are exposed on the same gateway.
+func NewPortPools(ranges []string, namespaceRanges map[string][]string) (*PortPools, error) {
+	var all []*PortsState
+	shared, err := newPortPool(ranges)
+	if err != nil {
+		return nil, err
+	}
+	all = append(all, shared.ranges...)
+	namespaces := map[string]*PortPool{}
+	for ns, rngs := range namespaceRanges {
+		if len(rngs) == 0 {
+			return nil, fmt.Errorf("no port ranges defined for namespace %s", ns)
+		}
+		pool, err := newPortPool(rngs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port ranges for namespace %s: %v", ns, err)
+		}
+		namespaces[ns] = pool
+		all = append(all, pool.ranges...)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].start < all[j].start
+	})
+	for i := 1; i < len(all); i++ {
+		if all[i].start <= all[i-1].end {
+			return nil, fmt.Errorf("port range %d:%d overlaps with %d:%d", all[i].start, all[i].end, all[i-1].start, all[i-1].end)
+		}
+	}
+	return &PortPools{shared: shared, namespaces: namespaces}, nil
+}
+
+// PortPools keeps a pool of ports shared by all namespaces and the pools reserved for specific namespaces.
+// Not thread safe, an external lock must be hold to use the pools.
+type PortPools struct {
+	shared     *PortPool
+	namespaces map[string]*PortPool
+}
+
+// PortPoolUtilization describes how many ports of a pool are in use.
+type PortPoolUtilization struct {
+	Used, Size int
+}
+
+// GetFreePort returns a port of the pool of the given namespace or an error if there are no free ports in it. The
+// shared pool is used if no ports are reserved for the namespace.
+func (p *PortPools) GetFreePort(namespace string) (int32, error) {
+	pool, ok := p.namespaces[namespace]
+	if !ok {
+		return p.shared.GetFreePort()
+	}
+	port, err := pool.GetFreePort()
+	if err != nil {
+		return 0, fmt.Errorf("%w in the port ranges of namespace %s", err, namespace)
+	}
+	return port, nil
+}
+
+// SetAllocation sets the policy the ports of all pools are allocated by. Random ports are drawn from the given source.
+func (p *PortPools) SetAllocation(allocation string, src rand.Source) error {
+	var rnd *rand.Rand
+	switch allocation {
+	case "", PortAllocationSequential:
+	case PortAllocationRandom:
+		rnd = rand.New(src)
+	default:
+		return fmt.Errorf("unsupported port allocation %q", allocation)
+	}
+	p.shared.rnd = rnd
+	for _, pool := range p.namespaces {
+		pool.rnd = rnd
+	}
+	return nil
+}
+
+// Release returns the port to the pool it was assigned from.
+func (p *PortPools) Release(port int32) {
+	p.shared.Release(port)
+	for _, pool := range p.namespaces {
+		pool.Release(port)
+	}
+}
+
+// Sync updates the state of all pools based on the currently used ports.
+func (p *PortPools) Sync(used []int32) error {
+	if err := p.shared.Sync(used); err != nil {
+		return err
+	}
+	for _, pool := range p.namespaces {
+		if err := pool.Sync(used); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Utilization returns the utilization of the pools indexed by their namespace. The shared pool is indexed by the empty
+// string.
+func (p *PortPools) Utilization() map[string]PortPoolUtilization {
+	utilization := map[string]PortPoolUtilization{"": p.shared.Utilization()}
+	for ns, pool := range p.namespaces {
+		utilization[ns] = pool.Utilization()
+	}
+	return utilization
+}
+
+func newPortPool(ranges []string) (*PortPool, error) {
+	pool := &PortPool{}
+	for _, rng := range ranges {
+		state, err := NewPortsState(rng, []int32{})
+		if err != nil {
+			return nil, err
+		}
+		if state.start > state.end {
+			return nil, fmt.Errorf("the port range %s must not end before it starts", rng)
+		}
+		pool.ranges = append(pool.ranges, state)
+	}
+	return pool, nil
+}
+
+// PortPool assigns ports from one or more port ranges, which are used in the given order.
+type PortPool struct {
+	ranges []*PortsState
+	// rnd chooses the ports at random if set, they are assigned sequentially otherwise.
+	rnd *rand.Rand
+}
+
+// GetFreePort returns a port from the first range with a free port or, for random allocation, a random free port of
+// any range. Returns ErrNoFreePorts if there are no free ports.
+func (p *PortPool) GetFreePort() (int32, error) {
+	if p.rnd != nil {
+		return p.getRandomFreePort()
+	}
+	for _, rng := range p.ranges {
+		if port, err := rng.GetFreePort(); err == nil {
+			return port, nil
+		}
+	}
+	return 0, ErrNoFreePorts
+}
+
+// getRandomFreePort returns a port chosen uniformly at random from the free ports of all ranges.
+func (p *PortPool) getRandomFreePort() (int32, error) {
+	free := 0
+	for _, rng := range p.ranges {
+		free += rng.free()
+	}
+	if free == 0 {
+		return 0, ErrNoFreePorts
+	}
+	index := p.rnd.Intn(free)
+	for _, rng := range p.ranges {
+		if index < rng.free() {
+			return rng.getFreePortAt(index), nil
+		}
+		index -= rng.free()
+	}
+	return 0, ErrNoFreePorts
+}
+
+// Release returns the port to the range it belongs to. Ports outside of the ranges of the pool are ignored.
+func (p *PortPool) Release(port int32) {
+	for _, rng := range p.ranges {
+		if rng.contains(port) {
+			rng.Release(port)
+		}
+	}
+}
+
+// Sync updates the state of the ranges based on the currently used ports.
+func (p *PortPool) Sync(used []int32) error {
+	for _, rng := range p.ranges {
+		if err := rng.Sync(used); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Utilization returns how many ports of the pool are in use.
+func (p *PortPool) Utilization() PortPoolUtilization {
+	var u PortPoolUtilization
+	for _, rng := range p.ranges {
+		u.Used += rng.used()
+		u.Size += rng.size()
+	}
+	return u
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package discovery
+
+import (
+	"errors"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PortPools", func() {
+	It("assigns ports from the next range once a range is exhausted", func() {
+		pools, err := NewPortPools([]string{"1000:1000", "2000:2001"}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		var ports []int32
+		for i := 0; i < 3; i++ {
+			port, err := pools.GetFreePort("apps")
+			Expect(err).NotTo(HaveOccurred())
+			ports = append(ports, port)
+		}
+		Expect(ports).To(Equal([]int32{1000, 2000, 2001}))
+		_, err = pools.GetFreePort("apps")
+		Expect(err).To(MatchError("no free ports"))
+	})
+	It("assigns ports from the ranges reserved for the namespace", func() {
+		pools, err := NewPortPools([]string{"1000:1001"}, map[string][]string{"tenant-a": {"3000:3000"}})
+		Expect(err).NotTo(HaveOccurred())
+		port, err := pools.GetFreePort("tenant-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(port).To(Equal(int32(3000)))
+		_, err = pools.GetFreePort("tenant-a")
+		Expect(err).To(MatchError("no free ports in the port ranges of namespace tenant-a"))
+		port, err = pools.GetFreePort("tenant-b")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(port).To(Equal(int32(1000)))
+	})
+	It("returns released ports to the pool they were assigned from", func() {
+		pools, _ := NewPortPools([]string{"1000:1001"}, map[string][]string{"tenant-a": {"3000:3001"}})
+		_, _ = pools.GetFreePort("")
+		_, _ = pools.GetFreePort("tenant-a")
+		Expect(pools.Utilization()).To(Equal(map[string]PortPoolUtilization{
+			"":         {Used: 1, Size: 2},
+			"tenant-a": {Used: 1, Size: 2},
+		}))
+		pools.Release(3000)
+		Expect(pools.Utilization()["tenant-a"]).To(Equal(PortPoolUtilization{Used: 0, Size: 2}))
+		Expect(pools.Utilization()[""]).To(Equal(PortPoolUtilization{Used: 1, Size: 2}))
+		port, err := pools.GetFreePort("tenant-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(port).To(Equal(int32(3000)))
+	})
+	It("syncs all pools with the used ports", func() {
+		pools, _ := NewPortPools([]string{"1000:1002"}, map[string][]string{"tenant-a": {"3000:3002"}})
+		Expect(pools.Sync([]int32{1001, 3000, 3002})).To(Succeed())
+		Expect(pools.Utilization()).To(Equal(map[string]PortPoolUtilization{
+			"":         {Used: 1, Size: 3},
+			"tenant-a": {Used: 2, Size: 3},
+		}))
+		port, _ := pools.GetFreePort("tenant-a")
+		Expect(port).To(Equal(int32(3001)))
+	})
+	Context("when the ports are allocated at random", func() {
+		It("assigns each free port of all ranges once", func() {
+			pools, _ := NewPortPools([]string{"1000:1002", "2000:2002"}, nil)
+			Expect(pools.SetAllocation(PortAllocationRandom, rand.NewSource(42))).To(Succeed())
+			Expect(pools.Sync([]int32{1001})).To(Succeed())
+			var ports []int32
+			for i := 0; i < 5; i++ {
+				port, err := pools.GetFreePort("")
+				Expect(err).NotTo(HaveOccurred())
+				ports = append(ports, port)
+			}
+			Expect(ports).To(ConsistOf(int32(1000), int32(1002), int32(2000), int32(2001), int32(2002)))
+			_, err := pools.GetFreePort("")
+			Expect(err).To(Equal(ErrNoFreePorts))
+			pools.Release(2001)
+			Expect(pools.GetFreePort("")).To(Equal(int32(2001)))
+		})
+		It("rejects unsupported policies", func() {
+			pools, _ := NewPortPools([]string{"1000:1002"}, nil)
+			Expect(pools.SetAllocation("lottery", rand.NewSource(42))).NotTo(Succeed())
+		})
+	})
+	It("reports exhausted namespace pools as out of free ports", func() {
+		pools, _ := NewPortPools([]string{"1000:1001"}, map[string][]string{"tenant-a": {"3000:3000"}})
+		_, _ = pools.GetFreePort("tenant-a")
+		_, err := pools.GetFreePort("tenant-a")
+		Expect(errors.Is(err, ErrNoFreePorts)).To(BeTrue())
+	})
+	Context("when the ranges are invalid", func() {
+		It("rejects overlapping ranges", func() {
+			_, err := NewPortPools([]string{"1000:1010"}, map[string][]string{"tenant-a": {"1010:1020"}})
+			Expect(err).To(MatchError("port range 1010:1020 overlaps with 1000:1010"))
+		})
+		It("rejects inverted ranges", func() {
+			_, err := NewPortPools([]string{"1010:1000"}, nil)
+			Expect(err).To(HaveOccurred())
+		})
+		It("rejects namespaces without ranges", func() {
+			_, err := NewPortPools([]string{"1000:1010"}, map[string][]string{"tenant-a": {}})
+			Expect(err).To(MatchError("no port ranges defined for namespace tenant-a"))
+		})
+	})
+})
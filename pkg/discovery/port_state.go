@@ -65,6 +65,64 @@ func (m *PortsState) GetFreePort() (int32, error) {
 	}
 }
 
+// Release returns a port that is no longer used, so that it is assigned again. Ports outside of the range or not
+// assigned yet are ignored.
+func (m *PortsState) Release(port int32) {
+	if port < m.start || port > m.lastUsed {
+		return
+	}
+	for _, p := range m.released {
+		if p == port {
+			return
+		}
+	}
+	m.released = append(m.released, port)
+}
+
+// getFreePortAt assigns the free port with the given index, counting the released ports first and the ports never
+// assigned afterwards in ascending order. The ports skipped to reach the port are marked as released. The index must
+// be less than free().
+func (m *PortsState) getFreePortAt(index int) int32 {
+	if index < len(m.released) {
+		port := m.released[index]
+		m.released = append(m.released[:index], m.released[index+1:]...)
+		return port
+	}
+	next := m.start
+	if m.lastUsed >= m.start {
+		next = m.lastUsed + 1
+	}
+	port := next + int32(index-len(m.released))
+	for p := next; p < port; p++ {
+		m.released = append(m.released, p)
+	}
+	m.lastUsed = port
+	return port
+}
+
+// free returns the number of ports that can be assigned.
+func (m *PortsState) free() int {
+	return m.size() - m.used()
+}
+
+// contains returns true if the port is within the range.
+func (m *PortsState) contains(port int32) bool {
+	return port >= m.start && port <= m.end
+}
+
+// size returns the number of ports in the range.
+func (m *PortsState) size() int {
+	return int(m.end - m.start + 1)
+}
+
+// used returns the number of ports currently assigned.
+func (m *PortsState) used() int {
+	if m.lastUsed < m.start {
+		return 0
+	}
+	return int(m.lastUsed-m.start+1) - len(m.released)
+}
+
 // Sync updates the state based on the currently used ports.
 // It populates the list of released ports and updates the lastUsed pointer.
 // Not thead safe, an external lock must be hold to execute this method.
@@ -129,4 +129,28 @@ var _ = Describe("PortsState", func() {
 			})
 		})
 	})
+
+	Context("releasing a port", func() {
+		It("assigns the port again", func() {
+			state, _ := NewPortsState("1000:1001", []int32{})
+			_, _ = state.GetFreePort()
+			_, _ = state.GetFreePort()
+			Expect(state.used()).To(Equal(2))
+			state.Release(1000)
+			state.Release(1000)
+			Expect(state.used()).To(Equal(1))
+			port, err := state.GetFreePort()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(port).To(Equal(int32(1000)))
+			_, err = state.GetFreePort()
+			Expect(err).To(HaveOccurred())
+		})
+		It("ignores ports that were not assigned", func() {
+			state, _ := NewPortsState("1000:1002", []int32{})
+			_, _ = state.GetFreePort()
+			state.Release(1001)
+			state.Release(2000)
+			Expect(state.released).To(BeEmpty())
+		})
+	})
 })
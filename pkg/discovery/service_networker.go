@@ -0,0 +1,262 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package discovery
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Implementations of the networks of the players.
+const (
+	// NetworkerIstio exposes the players via an Istio gateway per pod, created by the network controller.
+	NetworkerIstio = "istio"
+	// NetworkerService exposes the players via a Kubernetes service of type NodePort per pod.
+	NetworkerService = "service"
+)
+
+// serviceSuffix is appended to the name of the pod to name the service exposing its player.
+const serviceSuffix = "-mpc-nodeport"
+
+// NewServiceNetworker creates a new ServiceNetworker assigning the node ports of the services from the given pools.
+func NewServiceNetworker(logger *zap.SugaredLogger, ports *PortPools, delCh chan string) (*ServiceNetworker, error) {
+	conf, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &ServiceNetworker{
+		kubeClient: kubernetes.NewForConfigOrDie(conf),
+		ports:      ports,
+		assigned:   map[string]int32{},
+		logger:     logger,
+		delCh:      delCh,
+	}, nil
+}
+
+// ServiceNetworker is an implementation of the Networker interface which exposes the players via Kubernetes services
+// of type NodePort, i.e., without depending on Istio. The port assigned to a network is the node port of its service,
+// hence the port ranges must be within the node port range of the cluster, and the frontend address must reach the
+// nodes of the cluster.
+type ServiceNetworker struct {
+	kubeClient kubernetes.Interface
+	ports      *PortPools
+	logger     *zap.SugaredLogger
+	delCh      chan string
+	mux        sync.Mutex
+	// assigned are the ports of the networks indexed by the pods they were created for.
+	assigned map[string]int32
+}
+
+// Run starts the Networker. It synchronizes the state of the ports with the existing services and registers a
+// callback which deletes the service of a pod once the pod is deleted.
+func (s *ServiceNetworker) Run() error {
+	stopCh := make(chan struct{})
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(s.kubeClient, 10*time.Minute, kubeinformers.WithNamespace(defaultNamespace))
+	podInformer := kubeInformerFactory.Core().V1().Pods().Informer()
+	go podInformer.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, podInformer.HasSynced) {
+		close(stopCh)
+		return errors.New("Error syncing state of the pods")
+	}
+	if err := s.sync(); err != nil {
+		close(stopCh)
+		return err
+	}
+	podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			pod := newObj.(*v1.Pod)
+			if name, ok := pod.Labels[mpcPodNameLabel]; ok && pod.DeletionTimestamp != nil {
+				s.deleteService(name)
+				s.delCh <- name
+			}
+		},
+	})
+	go func() {
+		for range time.Tick(15 * time.Second) {
+			s.logger.Debug("Synchronizing state of the ports")
+			if err := s.sync(); err != nil {
+				s.logger.Errorw("Failed to synchronize the state of the ports", "Error", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// CreateNetwork labels the pod of the player and creates a service of type NodePort selecting it. Returns the node
+// port of the service.
+func (s *ServiceNetworker) CreateNetwork(pl *pb.Player) (int32, error) {
+	port, err := s.getPort(pl)
+	if err != nil {
+		s.logger.Errorw("Not able to get a free port", "Namespace", pl.Namespace, "Error", err)
+		return 0, err
+	}
+	s.logger.Infof("Creating a new service for player %v", pl)
+	if err := s.labelPod(pl.Pod); err != nil {
+		s.logger.Errorw("Not able to label the pod", "Pod", pl.Pod, "Error", err)
+		s.releasePort(pl.Pod)
+		return 0, err
+	}
+	targetPort := BasePort + PlayerIndex(pl)
+	labels := map[string]string{mpcPodNameLabel: pl.Pod}
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pl.Pod + serviceSuffix,
+			Namespace: defaultNamespace,
+			Labels:    labels,
+		},
+		Spec: v1.ServiceSpec{
+			Type:     v1.ServiceTypeNodePort,
+			Selector: labels,
+			Ports: []v1.ServicePort{{
+				Name:       "tcp",
+				Protocol:   v1.ProtocolTCP,
+				Port:       targetPort,
+				TargetPort: intstr.FromInt(int(targetPort)),
+				NodePort:   port,
+			}},
+		},
+	}
+	if _, err := s.kubeClient.CoreV1().Services(defaultNamespace).Create(service); err != nil {
+		s.logger.Errorw("Not able to create the service", "Pod", pl.Pod, "Error", err)
+		s.releasePort(pl.Pod)
+		return 0, err
+	}
+	return port, nil
+}
+
+// ReleaseNetwork deletes the service created for the given pod and returns its port to the pool. Pods no service was
+// created for, e.g., the pods of foreign players, are ignored.
+func (s *ServiceNetworker) ReleaseNetwork(pod string) error {
+	s.mux.Lock()
+	_, ok := s.assigned[pod]
+	s.mux.Unlock()
+	if !ok {
+		return nil
+	}
+	return s.deleteService(pod)
+}
+
+// SetPortPools replaces the pools the ports of new networks are assigned from. The ports of existing services are
+// marked as used in the new pools.
+func (s *ServiceNetworker) SetPortPools(ports *PortPools) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	used, err := s.getUsedPorts()
+	if err != nil {
+		return err
+	}
+	for _, port := range s.assigned {
+		used = append(used, port)
+	}
+	if err := ports.Sync(used); err != nil {
+		return err
+	}
+	s.ports = ports
+	return nil
+}
+
+// labelPod labels the pod with its name, so that the service can select it.
+func (s *ServiceNetworker) labelPod(name string) error {
+	pods := s.kubeClient.CoreV1().Pods(defaultNamespace)
+	pod, err := pods.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if _, ok := pod.Labels[mpcPodNameLabel]; ok {
+		return nil
+	}
+	if pod.Labels == nil {
+		pod.Labels = map[string]string{}
+	}
+	pod.Labels[mpcPodNameLabel] = name
+	_, err = pods.Update(pod)
+	return err
+}
+
+// deleteService deletes the service of the given pod and returns its port to the pool.
+func (s *ServiceNetworker) deleteService(pod string) error {
+	err := s.kubeClient.CoreV1().Services(defaultNamespace).Delete(pod+serviceSuffix, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		s.logger.Errorf("Error deleting the service: %s", err)
+		return err
+	}
+	s.releasePort(pod)
+	return nil
+}
+
+// sync synchronizes the state of the ports with the node ports of the existing services.
+func (s *ServiceNetworker) sync() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	used, err := s.getUsedPorts()
+	if err != nil {
+		return err
+	}
+	if err := s.ports.Sync(used); err != nil {
+		return err
+	}
+	for ns, u := range s.ports.Utilization() {
+		s.logger.Debugw("Port pool utilization", "Namespace", ns, "Used", u.Used, "Size", u.Size)
+	}
+	return nil
+}
+
+// getUsedPorts collects the node ports of the services of the players.
+func (s *ServiceNetworker) getUsedPorts() ([]int32, error) {
+	services, err := s.kubeClient.CoreV1().Services(defaultNamespace).List(metav1.ListOptions{LabelSelector: mpcPodNameLabel})
+	if err != nil {
+		return nil, err
+	}
+	var used []int32
+	for _, svc := range services.Items {
+		for _, p := range svc.Spec.Ports {
+			if p.NodePort != 0 {
+				used = append(used, p.NodePort)
+			}
+		}
+	}
+	return used, nil
+}
+
+// getPort assigns a port to the service of the player from the pool of its namespace.
+func (s *ServiceNetworker) getPort(pl *pb.Player) (int32, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	port, err := s.ports.GetFreePort(pl.Namespace)
+	if err != nil {
+		return 0, err
+	}
+	s.assigned[pl.Pod] = port
+	return port, nil
+}
+
+// ReleasePort returns the port assigned to the service of the given pod to its pool without deleting the service, e.g., once
+// it has been deleted by others. Pods no port is assigned to are ignored.
+func (s *ServiceNetworker) ReleasePort(pod string) {
+	s.releasePort(pod)
+}
+
+// releasePort returns the port assigned to the service of the given pod to its pool.
+func (s *ServiceNetworker) releasePort(pod string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if port, ok := s.assigned[pod]; ok {
+		s.ports.Release(port)
+		delete(s.assigned, pod)
+	}
+}
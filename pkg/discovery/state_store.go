@@ -0,0 +1,248 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package discovery
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+
+	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
+)
+
+// The buckets the state of the discovery service is kept in. The players are kept in a bucket per game, see
+// playersBucketOf, and the games having players are indexed in playerGamesBucket.
+const (
+	gamesBucket       = "games"
+	playersBucket     = "players"
+	playerGamesBucket = "playerGames"
+	podsBucket        = "pods"
+	networksBucket    = "networks"
+)
+
+// StateStore persists the bookkeeping of the discovery service. Entries are grouped in buckets and addressed by a key
+// that is unique within the bucket. Only the bookkeeping is shared via the store, the state machines of the games are
+// kept by each replica of the service.
+type StateStore interface {
+	// Get returns the value of the entry with the given key. The second return value is false if there is no such
+	// entry.
+	Get(bucket, key string) ([]byte, bool, error)
+	// Put creates or replaces the entry with the given key.
+	Put(bucket, key string, value []byte) error
+	// Delete removes the entry with the given key. Deleting an entry that does not exist is not an error.
+	Delete(bucket, key string) error
+	// List returns the entries of the bucket whose keys start with the given prefix.
+	List(bucket, prefix string) (map[string][]byte, error)
+}
+
+// NewMemoryStateStore returns a state store that keeps the state in memory, i.e., the state is lost once the service
+// terminates.
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{buckets: map[string]map[string][]byte{}}
+}
+
+// MemoryStateStore is a StateStore keeping the state in memory.
+type MemoryStateStore struct {
+	mux     sync.Mutex
+	buckets map[string]map[string][]byte
+}
+
+// Get returns the value of the entry with the given key.
+func (m *MemoryStateStore) Get(bucket, key string) ([]byte, bool, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	value, ok := m.buckets[bucket][key]
+	return value, ok, nil
+}
+
+// Put creates or replaces the entry with the given key.
+func (m *MemoryStateStore) Put(bucket, key string, value []byte) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	b, ok := m.buckets[bucket]
+	if !ok {
+		b = map[string][]byte{}
+		m.buckets[bucket] = b
+	}
+	b[key] = value
+	return nil
+}
+
+// Delete removes the entry with the given key.
+func (m *MemoryStateStore) Delete(bucket, key string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	delete(m.buckets[bucket], key)
+	return nil
+}
+
+// List returns the entries of the bucket whose keys start with the given prefix.
+func (m *MemoryStateStore) List(bucket, prefix string) (map[string][]byte, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	entries := map[string][]byte{}
+	for k, v := range m.buckets[bucket] {
+		if strings.HasPrefix(k, prefix) {
+			entries[k] = v
+		}
+	}
+	return entries, nil
+}
+
+// bookkeeping provides typed access to the state of the discovery service kept in a StateStore.
+type bookkeeping struct {
+	store StateStore
+}
+
+// players returns the players registered for the given game.
+func (b *bookkeeping) players(gameID string) (map[PlayerID]*pb.Player, error) {
+	entries, err := b.store.List(playersBucketOf(gameID), "")
+	if err != nil {
+		return nil, err
+	}
+	players := map[PlayerID]*pb.Player{}
+	for _, v := range entries {
+		var pl pb.Player
+		if err := json.Unmarshal(v, &pl); err != nil {
+			return nil, err
+		}
+		players[PlayerID(pl.Id)] = &pl
+	}
+	return players, nil
+}
+
+// allPlayers returns the players of all games indexed by the id of the game.
+func (b *bookkeeping) allPlayers() (map[string]map[PlayerID]*pb.Player, error) {
+	games, err := b.store.List(playerGamesBucket, "")
+	if err != nil {
+		return nil, err
+	}
+	players := map[string]map[PlayerID]*pb.Player{}
+	for gameID := range games {
+		p, err := b.players(gameID)
+		if err != nil {
+			return nil, err
+		}
+		if len(p) > 0 {
+			players[gameID] = p
+		}
+	}
+	return players, nil
+}
+
+// addPlayer registers the player for the given game.
+func (b *bookkeeping) addPlayer(gameID string, pl *pb.Player) error {
+	if err := b.put(playerGamesBucket, gameID, true); err != nil {
+		return err
+	}
+	return b.put(playersBucketOf(gameID), strconv.Itoa(int(pl.Id)), pl)
+}
+
+// removePlayer removes the player from the given game.
+func (b *bookkeeping) removePlayer(gameID string, id int32) error {
+	return b.store.Delete(playersBucketOf(gameID), strconv.Itoa(int(id)))
+}
+
+// pod returns the id of the player running on the given pod.
+func (b *bookkeeping) pod(name string) (int32, bool, error) {
+	return b.getInt32(podsBucket, name)
+}
+
+// pods returns the ids of the players indexed by the pods they run on.
+func (b *bookkeeping) pods() (map[string]int32, error) {
+	return b.listInt32(podsBucket)
+}
+
+// setPod records the player running on the given pod.
+func (b *bookkeeping) setPod(name string, playerID int32) error {
+	return b.put(podsBucket, name, playerID)
+}
+
+// network returns the port of the network created for the given pod.
+func (b *bookkeeping) network(pod string) (int32, bool, error) {
+	return b.getInt32(networksBucket, pod)
+}
+
+// networks returns the ports of the networks indexed by the pods they were created for.
+func (b *bookkeeping) networks() (map[string]int32, error) {
+	return b.listInt32(networksBucket)
+}
+
+// setNetwork records the port of the network created for the given pod.
+func (b *bookkeeping) setNetwork(pod string, port int32) error {
+	return b.put(networksBucket, pod, port)
+}
+
+// deletePod removes the pod and its network.
+func (b *bookkeeping) deletePod(name string) error {
+	if err := b.store.Delete(networksBucket, name); err != nil {
+		return err
+	}
+	return b.store.Delete(podsBucket, name)
+}
+
+// setGame records the state of the given game.
+func (b *bookkeeping) setGame(id string, g GameCheckpoint) error {
+	return b.put(gamesBucket, id, g)
+}
+
+// deleteGame removes the record of the given game along with its players.
+func (b *bookkeeping) deleteGame(id string) error {
+	players, err := b.store.List(playersBucketOf(id), "")
+	if err != nil {
+		return err
+	}
+	for k := range players {
+		if err := b.store.Delete(playersBucketOf(id), k); err != nil {
+			return err
+		}
+	}
+	if err := b.store.Delete(playerGamesBucket, id); err != nil {
+		return err
+	}
+	return b.store.Delete(gamesBucket, id)
+}
+
+func (b *bookkeeping) put(bucket, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return b.store.Put(bucket, key, data)
+}
+
+func (b *bookkeeping) getInt32(bucket, key string) (int32, bool, error) {
+	data, ok, err := b.store.Get(bucket, key)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	var value int32
+	if err := json.Unmarshal(data, &value); err != nil {
+		return 0, false, err
+	}
+	return value, true, nil
+}
+
+func (b *bookkeeping) listInt32(bucket string) (map[string]int32, error) {
+	entries, err := b.store.List(bucket, "")
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]int32{}
+	for k, data := range entries {
+		var value int32
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+		values[k] = value
+	}
+	return values, nil
+}
+
+// playersBucketOf returns the bucket of the players registered for the given game.
+func playersBucketOf(gameID string) string {
+	return playersBucket + "/" + gameID
+}
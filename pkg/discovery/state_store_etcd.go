@@ -0,0 +1,107 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package discovery
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"strings"
+	"time"
+
+	"go.etcd.io/etcd/clientv3"
+)
+
+// NewEtcdStateStore returns a state store keeping the state in etcd at the given endpoint, e.g., "etcd:2379". All keys
+// are prefixed with the given prefix, so that several deployments can share a single etcd cluster. The store
+// authenticates as the given user, if any, and the connection is secured with TLS if a TLS configuration is given.
+func NewEtcdStateStore(endpoint string, prefix string, username string, password string, tlsConf *tls.Config, timeout time.Duration) (*EtcdStateStore, error) {
+	if endpoint == "" {
+		return nil, errors.New("etcd endpoint must be provided")
+	}
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{endpoint},
+		Username:    username,
+		Password:    password,
+		TLS:         tlsConf,
+		DialTimeout: timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EtcdStateStore{
+		client:  client,
+		prefix:  prefix,
+		timeout: timeout,
+	}, nil
+}
+
+// EtcdStateStore is a StateStore keeping the state in etcd.
+type EtcdStateStore struct {
+	client  *clientv3.Client
+	prefix  string
+	timeout time.Duration
+}
+
+// Get returns the value of the entry with the given key.
+func (e *EtcdStateStore) Get(bucket, key string) ([]byte, bool, error) {
+	ctx, cancel := e.context()
+	defer cancel()
+	resp, err := e.client.Get(ctx, e.key(bucket, key))
+	if err != nil || len(resp.Kvs) == 0 {
+		return nil, false, err
+	}
+	return resp.Kvs[0].Value, true, nil
+}
+
+// Put creates or replaces the entry with the given key.
+func (e *EtcdStateStore) Put(bucket, key string, value []byte) error {
+	ctx, cancel := e.context()
+	defer cancel()
+	_, err := e.client.Put(ctx, e.key(bucket, key), string(value))
+	return err
+}
+
+// Delete removes the entry with the given key.
+func (e *EtcdStateStore) Delete(bucket, key string) error {
+	ctx, cancel := e.context()
+	defer cancel()
+	_, err := e.client.Delete(ctx, e.key(bucket, key))
+	return err
+}
+
+// List returns the entries of the bucket whose keys start with the given prefix.
+func (e *EtcdStateStore) List(bucket, prefix string) (map[string][]byte, error) {
+	ctx, cancel := e.context()
+	defer cancel()
+	resp, err := e.client.Get(ctx, e.key(bucket, prefix), clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	bucketPrefix := e.key(bucket, "")
+	entries := map[string][]byte{}
+	for _, kv := range resp.Kvs {
+		entries[strings.TrimPrefix(string(kv.Key), bucketPrefix)] = kv.Value
+	}
+	return entries, nil
+}
+
+// Close closes the connection to etcd.
+func (e *EtcdStateStore) Close() error {
+	return e.client.Close()
+}
+
+// key returns the etcd key of the entry with the given key in the given bucket.
+func (e *EtcdStateStore) key(bucket, key string) string {
+	return e.prefix + bucket + "/" + key
+}
+
+// context returns the context of a single request, which expires once the timeout of the store has elapsed.
+func (e *EtcdStateStore) context() (context.Context, context.CancelFunc) {
+	if e.timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), e.timeout)
+}
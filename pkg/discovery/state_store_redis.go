@@ -0,0 +1,84 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package discovery
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// NewRedisStateStore returns a state store keeping the state in Redis at the given address, e.g., "redis:6379". Each
+// bucket is stored as a hash whose key is prefixed with the given prefix. The store authenticates with the given
+// password, if any, and the connection is secured with TLS if a TLS configuration is given.
+func NewRedisStateStore(address string, prefix string, password string, tlsConf *tls.Config, timeout time.Duration) (*RedisStateStore, error) {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return nil, fmt.Errorf("invalid redis address %q: %v", address, err)
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:         address,
+		Password:     password,
+		TLSConfig:    tlsConf,
+		DialTimeout:  timeout,
+		ReadTimeout:  timeout,
+		WriteTimeout: timeout,
+	})
+	return &RedisStateStore{
+		client: client,
+		prefix: prefix,
+	}, nil
+}
+
+// RedisStateStore is a StateStore keeping the state in Redis.
+type RedisStateStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// Get returns the value of the entry with the given key.
+func (r *RedisStateStore) Get(bucket, key string) ([]byte, bool, error) {
+	value, err := r.client.HGet(r.prefix+bucket, key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Put creates or replaces the entry with the given key.
+func (r *RedisStateStore) Put(bucket, key string, value []byte) error {
+	return r.client.HSet(r.prefix+bucket, key, value).Err()
+}
+
+// Delete removes the entry with the given key.
+func (r *RedisStateStore) Delete(bucket, key string) error {
+	return r.client.HDel(r.prefix+bucket, key).Err()
+}
+
+// List returns the entries of the bucket whose keys start with the given prefix.
+func (r *RedisStateStore) List(bucket, prefix string) (map[string][]byte, error) {
+	values, err := r.client.HGetAll(r.prefix + bucket).Result()
+	if err != nil {
+		return nil, err
+	}
+	entries := map[string][]byte{}
+	for k, v := range values {
+		if strings.HasPrefix(k, prefix) {
+			entries[k] = []byte(v)
+		}
+	}
+	return entries, nil
+}
+
+// Close closes the connections to Redis.
+func (r *RedisStateStore) Close() error {
+	return r.client.Close()
+}
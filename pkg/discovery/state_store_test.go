@@ -0,0 +1,208 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package discovery
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+	"go.etcd.io/etcd/etcdserver/etcdserverpb"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+	"google.golang.org/grpc"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StateStore", func() {
+	Context("when keeping the state in memory", func() {
+		behavesLikeAStateStore(func() StateStore {
+			return NewMemoryStateStore()
+		})
+	})
+	Context("when keeping the state in etcd", func() {
+		var (
+			listener net.Listener
+			server   *grpc.Server
+		)
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			server = grpc.NewServer()
+			etcdserverpb.RegisterKVServer(server, &fakeEtcd{kvs: map[string][]byte{}})
+			go server.Serve(listener)
+		})
+		AfterEach(func() {
+			server.Stop()
+		})
+		behavesLikeAStateStore(func() StateStore {
+			store, err := NewEtcdStateStore(listener.Addr().String(), "ephemeral/", "", "", nil, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			return store
+		})
+		It("rejects missing endpoints", func() {
+			_, err := NewEtcdStateStore("", "", "", "", nil, time.Second)
+			Expect(err).To(MatchError("etcd endpoint must be provided"))
+		})
+	})
+	Context("when keeping the state in redis", func() {
+		var redis *miniredis.Miniredis
+		BeforeEach(func() {
+			var err error
+			redis, err = miniredis.Run()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		AfterEach(func() {
+			redis.Close()
+		})
+		behavesLikeAStateStore(func() StateStore {
+			store, err := NewRedisStateStore(redis.Addr(), "ephemeral:", "", nil, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			return store
+		})
+		It("authenticates with the password", func() {
+			redis.RequireAuth("secret")
+			store, err := NewRedisStateStore(redis.Addr(), "", "wrong", nil, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(store.Put(podsBucket, "pod1", []byte("1"))).NotTo(Succeed())
+			store, err = NewRedisStateStore(redis.Addr(), "", "secret", nil, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(store.Put(podsBucket, "pod1", []byte("1"))).To(Succeed())
+		})
+		It("keeps the players of each game in a hash of its own", func() {
+			store, err := NewRedisStateStore(redis.Addr(), "ephemeral:", "", nil, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			b := &bookkeeping{store: store}
+			Expect(b.addPlayer("0", &pb.Player{Id: 0, Pod: "pod1"})).To(Succeed())
+			Expect(b.addPlayer("1", &pb.Player{Id: 1, Pod: "pod2"})).To(Succeed())
+			Expect(redis.HKeys("ephemeral:players/0")).To(Equal([]string{"0"}))
+			Expect(redis.HKeys("ephemeral:players/1")).To(Equal([]string{"1"}))
+		})
+		It("rejects invalid addresses", func() {
+			_, err := NewRedisStateStore("redis", "", "", nil, time.Second)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("when keeping the bookkeeping in a store", func() {
+		var b *bookkeeping
+		BeforeEach(func() {
+			b = &bookkeeping{store: NewMemoryStateStore()}
+		})
+		It("returns the players by game", func() {
+			Expect(b.addPlayer("0", &pb.Player{Id: 0, Pod: "pod1"})).To(Succeed())
+			Expect(b.addPlayer("0", &pb.Player{Id: 1, Pod: "pod2"})).To(Succeed())
+			Expect(b.addPlayer("1", &pb.Player{Id: 0, Pod: "pod3"})).To(Succeed())
+			players, err := b.players("0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(players).To(HaveLen(2))
+			Expect(players[PlayerID(1)].Pod).To(Equal("pod2"))
+			all, err := b.allPlayers()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(all).To(HaveLen(2))
+			Expect(all["1"][PlayerID(0)].Pod).To(Equal("pod3"))
+		})
+		It("removes the players along with the game", func() {
+			Expect(b.setGame("0", GameCheckpoint{State: WaitPlayersReady})).To(Succeed())
+			Expect(b.addPlayer("0", &pb.Player{Id: 0, Pod: "pod1"})).To(Succeed())
+			Expect(b.addPlayer("1", &pb.Player{Id: 0, Pod: "pod2"})).To(Succeed())
+			Expect(b.deleteGame("0")).To(Succeed())
+			Expect(b.players("0")).To(BeEmpty())
+			all, err := b.allPlayers()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(all).To(HaveLen(1))
+			Expect(all).To(HaveKey("1"))
+		})
+		It("removes the network along with the pod", func() {
+			Expect(b.setPod("pod1", 0)).To(Succeed())
+			Expect(b.setNetwork("pod1", 30000)).To(Succeed())
+			port, ok, err := b.network("pod1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(port).To(Equal(int32(30000)))
+			Expect(b.deletePod("pod1")).To(Succeed())
+			Expect(b.pods()).To(BeEmpty())
+			Expect(b.networks()).To(BeEmpty())
+		})
+	})
+})
+
+func behavesLikeAStateStore(newStore func() StateStore) {
+	It("stores, lists and deletes entries", func() {
+		store := newStore()
+		_, ok, err := store.Get(podsBucket, "pod1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeFalse())
+
+		Expect(store.Put(podsBucket, "pod1", []byte("1"))).To(Succeed())
+		Expect(store.Put(podsBucket, "pod2", []byte("2"))).To(Succeed())
+		Expect(store.Put(networksBucket, "pod1", []byte("30000"))).To(Succeed())
+		value, ok, err := store.Get(podsBucket, "pod1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal([]byte("1")))
+
+		Expect(store.List(podsBucket, "")).To(Equal(map[string][]byte{"pod1": []byte("1"), "pod2": []byte("2")}))
+		Expect(store.List(podsBucket, "pod2")).To(Equal(map[string][]byte{"pod2": []byte("2")}))
+
+		Expect(store.Delete(podsBucket, "pod1")).To(Succeed())
+		Expect(store.Delete(podsBucket, "missing")).To(Succeed())
+		Expect(store.List(podsBucket, "")).To(Equal(map[string][]byte{"pod2": []byte("2")}))
+		Expect(store.List(networksBucket, "")).To(HaveLen(1))
+	})
+}
+
+// fakeEtcd implements the parts of the KV service of etcd used by the EtcdStateStore.
+type fakeEtcd struct {
+	etcdserverpb.KVServer
+	mux sync.Mutex
+	kvs map[string][]byte
+}
+
+func (f *fakeEtcd) Range(_ context.Context, req *etcdserverpb.RangeRequest) (*etcdserverpb.RangeResponse, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	resp := &etcdserverpb.RangeResponse{Header: &etcdserverpb.ResponseHeader{}}
+	for k, v := range f.kvs {
+		if inRange(k, req.Key, req.RangeEnd) {
+			resp.Kvs = append(resp.Kvs, &mvccpb.KeyValue{Key: []byte(k), Value: v})
+		}
+	}
+	resp.Count = int64(len(resp.Kvs))
+	return resp, nil
+}
+
+func (f *fakeEtcd) Put(_ context.Context, req *etcdserverpb.PutRequest) (*etcdserverpb.PutResponse, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.kvs[string(req.Key)] = req.Value
+	return &etcdserverpb.PutResponse{Header: &etcdserverpb.ResponseHeader{}}, nil
+}
+
+func (f *fakeEtcd) DeleteRange(_ context.Context, req *etcdserverpb.DeleteRangeRequest) (*etcdserverpb.DeleteRangeResponse, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	resp := &etcdserverpb.DeleteRangeResponse{Header: &etcdserverpb.ResponseHeader{}}
+	for k := range f.kvs {
+		if inRange(k, req.Key, req.RangeEnd) {
+			delete(f.kvs, k)
+			resp.Deleted++
+		}
+	}
+	return resp, nil
+}
+
+// inRange returns true if the key is the given key or, if an end is given, lies in the range between them.
+func inRange(k string, key []byte, end []byte) bool {
+	if len(end) == 0 {
+		return k == string(key)
+	}
+	return k >= string(key) && (string(end) == "\x00" || k < string(end))
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package testing provides a lightweight, in-process discovery service for tests. It runs the game state machine of
+// the real discovery service, but neither creates networks nor listens on a port. Clients are connected in memory.
+package testing
+
+import (
+	"errors"
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/discovery"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	mb "github.com/vardius/message-bus"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultFrontendAddress is the address of the players if none is configured.
+	DefaultFrontendAddress = "127.0.0.1"
+	// DefaultBasePort is the first port handed out by the networker if none is configured.
+	DefaultBasePort = int32(30000)
+	// DefaultStateTimeout is the state timeout of the games if none is configured.
+	DefaultStateTimeout = 10 * time.Second
+	// DefaultComputationTimeout is the computation timeout of the games if none is configured.
+	DefaultComputationTimeout = 20 * time.Second
+
+	busSize      = 10000
+	startTimeout = 5 * time.Second
+)
+
+// Config configures the discovery service. Only the player count is mandatory.
+type Config struct {
+	PlayerCount int
+	// FrontendAddress is the address of the players the networks are created for. Networks of players with other
+	// addresses are considered foreign and not created.
+	FrontendAddress    string
+	BasePort           int32
+	StateTimeout       time.Duration
+	ComputationTimeout time.Duration
+	Logger             *zap.SugaredLogger
+}
+
+// NewDiscovery starts a discovery service in master mode and waits until it is ready.
+func NewDiscovery(conf Config) (*Discovery, error) {
+	if conf.PlayerCount < 1 {
+		return nil, errors.New("the player count must be positive")
+	}
+	if conf.FrontendAddress == "" {
+		conf.FrontendAddress = DefaultFrontendAddress
+	}
+	if conf.BasePort == 0 {
+		conf.BasePort = DefaultBasePort
+	}
+	if conf.StateTimeout == 0 {
+		conf.StateTimeout = DefaultStateTimeout
+	}
+	if conf.ComputationTimeout == 0 {
+		conf.ComputationTimeout = DefaultComputationTimeout
+	}
+	if conf.Logger == nil {
+		conf.Logger = zap.NewNop().Sugar()
+	}
+	bus := mb.New(busSize)
+	tr := NewTransport()
+	n := NewNetworker(conf.BasePort)
+	s := discovery.NewServiceNG(bus, discovery.NewPublisher(bus), conf.StateTimeout, conf.ComputationTimeout, tr, n,
+		conf.FrontendAddress, conf.Logger, ModeMaster, &discovery.FakeDClient{}, conf.PlayerCount,
+		discovery.NewMemoryStateStore())
+	go s.Start()
+	if err := s.WaitUntilReady(startTimeout); err != nil {
+		s.Stop()
+		return nil, err
+	}
+	return &Discovery{
+		Service:   s,
+		Transport: tr,
+		Networker: n,
+		conf:      conf,
+	}, nil
+}
+
+// Discovery is an in-process discovery service.
+type Discovery struct {
+	Service   *discovery.ServiceNG
+	Transport *Transport
+	Networker *Networker
+	conf      Config
+}
+
+// FrontendAddress returns the address of the players the networks are created for.
+func (d *Discovery) FrontendAddress() string {
+	return d.conf.FrontendAddress
+}
+
+// Connect returns a client taking part in the given game.
+func (d *Discovery) Connect(gameID string) *Client {
+	return d.Transport.Connect(gameID)
+}
+
+// Stop stops the discovery service.
+func (d *Discovery) Stop() {
+	d.Service.Stop()
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package testing_test
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/carbynestack/ephemeral/pkg/discovery/testing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("In-process discovery", func() {
+
+	const (
+		gameID      = "71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4"
+		playerCount = 2
+	)
+
+	var (
+		d       *Discovery
+		clients []*Client
+		players []*pb.Player
+	)
+
+	BeforeEach(func() {
+		var err error
+		d, err = NewDiscovery(Config{PlayerCount: playerCount})
+		Expect(err).NotTo(HaveOccurred())
+		clients = make([]*Client, playerCount)
+		players = make([]*pb.Player, playerCount)
+		for i := range clients {
+			clients[i] = d.Connect(gameID)
+			players[i] = &pb.Player{
+				Ip:  d.FrontendAddress(),
+				Id:  int32(i),
+				Pod: fmt.Sprintf("pod%d", i),
+			}
+		}
+	})
+
+	AfterEach(func() {
+		for _, c := range clients {
+			c.Close()
+		}
+		d.Stop()
+	})
+
+	sendAll := func(name string) {
+		for i, c := range clients {
+			c.In <- &pb.Event{Name: name, GameID: gameID, Players: []*pb.Player{players[i]}}
+		}
+	}
+
+	Context("when the players play a game", func() {
+		It("runs the game to the end", func() {
+			sendAll(PlayerReady)
+			for _, c := range clients {
+				ev := expectEvent(c, PlayersReady)
+				Expect(ev.Players).To(HaveLen(playerCount))
+				for _, pl := range ev.Players {
+					Expect(pl.Port).To(Equal(d.Networker.Networks()[pl.Pod]))
+				}
+			}
+			sendAll(TCPCheckSuccess)
+			for _, c := range clients {
+				expectEvent(c, TCPCheckSuccessAll)
+			}
+			sendAll(GameFinishedWithSuccess)
+			for _, c := range clients {
+				expectEvent(c, GameSuccess)
+			}
+		})
+	})
+
+	Context("when a client of another game is connected", func() {
+		It("does not receive the events of the game", func() {
+			other := d.Connect("another-game")
+			defer other.Close()
+			sendAll(PlayerReady)
+			expectEvent(clients[0], PlayersReady)
+			Consistently(other.Out, 100*time.Millisecond).ShouldNot(Receive())
+		})
+	})
+
+	Context("when no player count is given", func() {
+		It("returns an error", func() {
+			_, err := NewDiscovery(Config{})
+			Expect(err).To(MatchError("the player count must be positive"))
+		})
+	})
+})
+
+var _ = Describe("Networker", func() {
+	It("assigns the same port to the same pod", func() {
+		n := NewNetworker(5000)
+		first, _ := n.CreateNetwork(&pb.Player{Pod: "a"})
+		second, _ := n.CreateNetwork(&pb.Player{Pod: "b"})
+		again, _ := n.CreateNetwork(&pb.Player{Pod: "a"})
+		Expect(first).To(Equal(int32(5000)))
+		Expect(second).To(Equal(int32(5001)))
+		Expect(again).To(Equal(first))
+		Expect(n.Networks()).To(Equal(map[string]int32{"a": 5000, "b": 5001}))
+	})
+})
+
+// expectEvent returns the first event with the given name received by the client, skipping the others.
+func expectEvent(c *Client, name string) *pb.Event {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-c.Out:
+			if ev.Name == name {
+				return ev
+			}
+		case <-timeout:
+			Fail(fmt.Sprintf("timeout while waiting for event %s", name))
+			return nil
+		}
+	}
+}
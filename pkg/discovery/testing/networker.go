@@ -0,0 +1,59 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package testing
+
+import (
+	"sync"
+
+	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
+)
+
+// NewNetworker returns a networker handing out the ports starting at the base port.
+func NewNetworker(basePort int32) *Networker {
+	return &Networker{
+		next:     basePort,
+		networks: map[string]int32{},
+	}
+}
+
+// Networker is a fake networker which does not create any resources. It assigns a port to each pod, the same pod is
+// given the same port every time.
+type Networker struct {
+	next     int32
+	networks map[string]int32
+	mux      sync.Mutex
+}
+
+// CreateNetwork returns the port assigned to the pod of the player.
+func (n *Networker) CreateNetwork(pl *pb.Player) (int32, error) {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	if port, ok := n.networks[pl.Pod]; ok {
+		return port, nil
+	}
+	port := n.next
+	n.next++
+	n.networks[pl.Pod] = port
+	return port, nil
+}
+
+// ReleaseNetwork forgets the port assigned to the pod.
+func (n *Networker) ReleaseNetwork(pod string) error {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	delete(n.networks, pod)
+	return nil
+}
+
+// Networks returns the ports assigned to the pods so far.
+func (n *Networker) Networks() map[string]int32 {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	networks := make(map[string]int32, len(n.networks))
+	for pod, port := range n.networks {
+		networks[pod] = port
+	}
+	return networks
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package testing
+
+import (
+	"errors"
+	"sync"
+
+	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
+)
+
+// clientBufferSize is the capacity of the channels of the clients.
+const clientBufferSize = 100
+
+// NewTransport returns a transport which passes the events to the clients in memory.
+func NewTransport() *Transport {
+	return &Transport{
+		in:      make(chan *pb.Event, clientBufferSize),
+		out:     make(chan *pb.Event, clientBufferSize),
+		clients: map[*Client]struct{}{},
+		done:    make(chan struct{}),
+	}
+}
+
+// Transport is an in-memory replacement of the gRPC transport server. Like the server, it forwards the events of a
+// game to the clients connected for this game only.
+type Transport struct {
+	in, out  chan *pb.Event
+	clients  map[*Client]struct{}
+	mux      sync.Mutex
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// Run forwards the events to the clients until the transport is stopped.
+func (t *Transport) Run(cb func()) error {
+	go t.broadcast()
+	cb()
+	<-t.done
+	return nil
+}
+
+// Stop stops forwarding events.
+func (t *Transport) Stop() {
+	t.stopOnce.Do(func() {
+		close(t.done)
+	})
+}
+
+// GetIn returns the channel of the events sent by the clients.
+func (t *Transport) GetIn() chan *pb.Event {
+	return t.in
+}
+
+// GetOut returns the channel of the events to be sent to the clients.
+func (t *Transport) GetOut() chan *pb.Event {
+	return t.out
+}
+
+// Events is not supported, the clients are connected by calling Connect instead.
+func (t *Transport) Events(stream pb.Discovery_EventsServer) error {
+	return errors.New("the in-memory transport does not serve gRPC streams")
+}
+
+// Connect returns a client receiving the events of the given game.
+func (t *Transport) Connect(gameID string) *Client {
+	c := &Client{
+		GameID:    gameID,
+		In:        make(chan *pb.Event, clientBufferSize),
+		Out:       make(chan *pb.Event, clientBufferSize),
+		transport: t,
+		done:      make(chan struct{}),
+	}
+	t.mux.Lock()
+	t.clients[c] = struct{}{}
+	t.mux.Unlock()
+	go c.forward()
+	return c
+}
+
+// broadcast sends the outgoing events to the clients of the respective game.
+func (t *Transport) broadcast() {
+	for {
+		select {
+		case ev := <-t.out:
+			t.mux.Lock()
+			for c := range t.clients {
+				if c.GameID == ev.GameID {
+					c.Out <- ev
+				}
+			}
+			t.mux.Unlock()
+		case <-t.done:
+			return
+		}
+	}
+}
+
+// disconnect stops forwarding events to the client.
+func (t *Transport) disconnect(c *Client) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	delete(t.clients, c)
+}
+
+// Client is connected to the in-memory transport. Events written to In are sent to the discovery service, the events
+// of the game are received from Out. Out must be drained, otherwise the transport blocks once the buffer is full.
+type Client struct {
+	GameID    string
+	In, Out   chan *pb.Event
+	transport *Transport
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Close disconnects the client from the transport.
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		c.transport.disconnect(c)
+		close(c.done)
+	})
+}
+
+// forward sends the events of the client to the discovery service until either the client is closed or the transport
+// is stopped.
+func (c *Client) forward() {
+	for {
+		select {
+		case ev := <-c.In:
+			select {
+			case c.transport.in <- ev:
+			case <-c.done:
+				return
+			case <-c.transport.done:
+				return
+			}
+		case <-c.done:
+			return
+		case <-c.transport.done:
+			return
+		}
+	}
+}
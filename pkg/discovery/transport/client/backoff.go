@@ -0,0 +1,51 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package client
+
+import (
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/retry"
+)
+
+const (
+	// DefaultReconnectTimeout is the maximum duration of reconnecting to the server if none is configured.
+	DefaultReconnectTimeout = 30 * time.Second
+	defaultInitialBackoff   = 100 * time.Millisecond
+	defaultMaxBackoff       = 5 * time.Second
+)
+
+// NewBackoff returns a backoff reconnecting for at most the given timeout, or DefaultReconnectTimeout if zero.
+func NewBackoff(timeout time.Duration) *Backoff {
+	if timeout == 0 {
+		timeout = DefaultReconnectTimeout
+	}
+	return &Backoff{
+		Initial: defaultInitialBackoff,
+		Max:     defaultMaxBackoff,
+		Timeout: timeout,
+	}
+}
+
+// Backoff defines the delays between the attempts to reconnect to the server. The delay doubles with each attempt from
+// Initial up to Max. Each delay is randomized, so that clients disconnected at the same time do not reconnect in
+// lockstep.
+type Backoff struct {
+	Initial, Max time.Duration
+	// Timeout is the maximum duration of reconnecting before the error is reported.
+	Timeout time.Duration
+}
+
+// config returns the retry config of the reconnects. Each delay is chosen at random from the upper half of the
+// exponentially growing interval.
+func (b *Backoff) config() retry.Config {
+	return retry.Config{
+		Policy: retry.Jittered{
+			Policy: retry.Exponential{Initial: b.Initial, Max: b.Max},
+			Factor: 0.5,
+		},
+		Timeout: b.Timeout,
+	}
+}
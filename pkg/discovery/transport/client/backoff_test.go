@@ -0,0 +1,38 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package client
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Backoff", func() {
+	It("applies the default timeout if none is given", func() {
+		Expect(NewBackoff(0).Timeout).To(Equal(DefaultReconnectTimeout))
+		Expect(NewBackoff(time.Minute).Timeout).To(Equal(time.Minute))
+	})
+	It("doubles the delay with each attempt", func() {
+		policy := (&Backoff{Initial: 100 * time.Millisecond, Max: time.Second}).config().Policy
+		for retry, upper := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+			delay := policy.Delay(retry + 1)
+			Expect(delay).To(BeNumerically(">=", upper/2))
+			Expect(delay).To(BeNumerically("<=", upper))
+		}
+	})
+	It("caps the delay at the maximum", func() {
+		policy := (&Backoff{Initial: 100 * time.Millisecond, Max: time.Second}).config().Policy
+		for _, retry := range []int{5, 10, 100} {
+			delay := policy.Delay(retry)
+			Expect(delay).To(BeNumerically(">=", 500*time.Millisecond))
+			Expect(delay).To(BeNumerically("<=", time.Second))
+		}
+	})
+	It("limits the duration of reconnecting by the timeout", func() {
+		Expect(NewBackoff(time.Minute).config().Timeout).To(Equal(time.Minute))
+	})
+})
@@ -7,14 +7,22 @@ package client
 import (
 	"context"
 	"errors"
+	"fmt"
 	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
+	"github.com/carbynestack/ephemeral/pkg/discovery/transport/security"
+	"github.com/carbynestack/ephemeral/pkg/retry"
+	"github.com/carbynestack/ephemeral/pkg/tracing"
 	"io"
+	"strconv"
+	"sync"
 	"time"
 
 	. "github.com/carbynestack/ephemeral/pkg/types"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -29,20 +37,52 @@ type TransportClientConfig struct {
 	// Host, Port - the server endpoint to connect to.
 	Host, Port string
 
+	// Fallbacks is a prioritized list of endpoints that are tried in order if the endpoint given by Host and Port
+	// cannot be reached.
+	Fallbacks []DiscoveryEndpoint
+
+	// Health keeps track of unreachable endpoints across clients. Unhealthy endpoints are tried last. Optional.
+	Health *EndpointHealth
+
+	// Failover marks the endpoint as unhealthy once the stream terminates, so that the client reconnects to the next
+	// healthy endpoint instead of the one it was connected to. Requires Health and Backoff to be set.
+	Failover bool
+
 	// EventScope defines the scope of events the client subscribes to. "all" - events from all games are current games, "ConnID" - events associated with this connection ID.
 	EventScope string
 
 	// ConnID is the ID of the connection. In case of pure discovery clients, it is equal the gameID.
 	ConnID string
 
+	// Namespace is the event namespace of the client, e.g., the virtual cloud it belongs to. The client only receives
+	// the events of the games of its namespace. The client belongs to the default namespace if empty.
+	Namespace string
+
 	// ConnectTimeout is the gRPC dial timeout.
 	ConnectTimeout time.Duration
 
+	// TLS secures the connection to the server. The connection is not encrypted if nil.
+	TLS *TLSConfig
+
+	// Token is presented to the server to authenticate the client. No token is presented if nil.
+	Token *TokenConfig
+
+	// Backoff defines how the client reconnects if the stream terminates. Errors of the stream are reported to ErrCh
+	// right away if nil.
+	Backoff *Backoff
+
+	// BufferSize is the maximum number of events sent to the server that are retained to be replayed after a
+	// reconnect. Defaults to DefaultBufferSize if zero.
+	BufferSize int
+
 	Logger *zap.SugaredLogger
 
 	Context context.Context
 }
 
+// DefaultBufferSize is the number of sent events retained for replay if no buffer size is configured.
+const DefaultBufferSize = 64
+
 // TransportConn is an interface for the underlying gRPC transport connection.
 type TransportConn interface {
 	Close() error
@@ -64,6 +104,24 @@ func NewClient(conf *TransportClientConfig) (*Client, error) {
 	}
 	cl := &Client{
 		conf: conf,
+		id:   uuid.New().String(),
+		// The sequence numbers start at the time the client is created, so that servers not told the ID of the client
+		// do not mistake the events of a restarted client with the same ConnID for events received already.
+		seq: uint64(time.Now().UnixNano()),
+	}
+	if conf.TLS != nil {
+		tlsConf, err := security.ClientTLSConfig(conf.TLS)
+		if err != nil {
+			return nil, err
+		}
+		cl.creds = credentials.NewTLS(tlsConf)
+	}
+	if conf.Token != nil {
+		token, err := security.NewTokenCredentials(conf.Token)
+		if err != nil {
+			return nil, err
+		}
+		cl.token = token
 	}
 	return cl, nil
 }
@@ -75,6 +133,7 @@ type TransportClient interface {
 	Connect() (*grpc.ClientConn, error)
 	Run(client pb.DiscoveryClient)
 	Stop() error
+	Endpoint() string
 }
 
 // Client is used to communicate with Discovery service.
@@ -82,9 +141,32 @@ type TransportClient interface {
 // To send events one writes to the Out channel, reading is done by consuming messages from the In channel.
 // Errors are forwarded to the errCh specified in the config. Thus it must be monitored.
 type Client struct {
-	conf   *TransportClientConfig
-	stream pb.Discovery_EventsClient
-	conn   TransportConn
+	conf     *TransportClientConfig
+	stream   pb.Discovery_EventsClient
+	conn     TransportConn
+	endpoint string
+	// creds secures the connection, it is not encrypted if nil.
+	creds credentials.TransportCredentials
+	// token authenticates the client, nil if the client is not authenticated.
+	token credentials.PerRPCCredentials
+	// mux guards the stream, the connection, its endpoint and the unacknowledged events, which are replaced on
+	// reconnects.
+	mux sync.Mutex
+	// streamCtx is the context the stream is opened with, it carries the metadata of the subscription.
+	streamCtx context.Context
+	// unacked are the events sent to the server that the server has not acknowledged yet, at most BufferSize. They are replayed in order once the client reconnected, as the server may not have received them.
+	unacked []*pb.Event
+	// id identifies the client to the server across reconnects.
+	id string
+	// seq is the sequence number of the last event sent to the server. The server drops replayed events it has
+	// received already by their sequence number.
+	seq uint64
+	// lastSeq is the highest sequence number received from the server. It is passed to the server on reconnects, so
+	// that the missed events are replayed.
+	lastSeq uint64
+	// sendMux serializes the events sent to the stream, as they are sent from both the forwarding and the receiving
+	// routine.
+	sendMux sync.Mutex
 }
 
 // GetIn returns In channel of the client.
@@ -97,32 +179,93 @@ func (c *Client) GetOut() chan *pb.Event {
 	return c.conf.Out
 }
 
-// Connect dials the server and returns a connection.
+// Connect dials the server and returns a connection. The primary endpoint and all fallbacks are tried in order until
+// a connection could be established. The error of the last attempt is returned if none of them is reachable.
 func (c *Client) Connect() (*grpc.ClientConn, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.connect()
+}
+
+// connect dials the server as described for Connect. Must be called with the lock held.
+func (c *Client) connect() (*grpc.ClientConn, error) {
+	var err error
+	for _, e := range c.endpoints() {
+		addr := Address(e)
+		var conn *grpc.ClientConn
+		conn, err = c.dial(addr)
+		if err != nil {
+			c.conf.Logger.Errorf("Error establishing a gRPC connection to %s: %v", addr, err)
+			if c.conf.Health != nil {
+				c.conf.Health.MarkFailed(addr)
+			}
+			continue
+		}
+		if c.conf.Health != nil {
+			c.conf.Health.MarkHealthy(addr)
+		}
+		c.conn = conn
+		c.endpoint = addr
+		c.conf.Logger.Debugw("Client gRPC connection established", "Endpoint", addr)
+		return conn, nil
+	}
+	return nil, err
+}
+
+// Endpoint returns the address of the endpoint the client is connected to. It is empty as long as no connection has
+// been established.
+func (c *Client) Endpoint() string {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.endpoint
+}
+
+// endpoints returns the primary endpoint followed by the fallbacks, unhealthy endpoints are moved to the end.
+func (c *Client) endpoints() []DiscoveryEndpoint {
+	endpoints := append([]DiscoveryEndpoint{{Host: c.conf.Host, Port: c.conf.Port}}, c.conf.Fallbacks...)
+	if c.conf.Health == nil {
+		return endpoints
+	}
+	return c.conf.Health.Order(endpoints)
+}
+
+func (c *Client) dial(addr string) (*grpc.ClientConn, error) {
 	ctx, cancelConnect := context.WithTimeout(context.Background(), c.conf.ConnectTimeout)
 	defer cancelConnect()
-	conn, err := grpc.DialContext(ctx, c.conf.Host+":"+c.conf.Port, grpc.WithBlock(), grpc.WithInsecure())
-	if err != nil {
-		c.conf.Logger.Errorf("Error establishing a gRPC connection: %v", err)
-		return nil, err
+	transportSecurity := grpc.WithInsecure()
+	if c.creds != nil {
+		transportSecurity = grpc.WithTransportCredentials(c.creds)
+	}
+	opts := []grpc.DialOption{grpc.WithBlock(), transportSecurity}
+	if c.token != nil {
+		opts = append(opts, grpc.WithPerRPCCredentials(c.token))
 	}
-	c.conn = conn
-	c.conf.Logger.Debug("Client gRPC connection established")
-	return conn, nil
+	return grpc.DialContext(ctx, addr, opts...)
 }
 
 // Run starts forwarding of the events. The functionality is started as separate go routines which run until the given
 // context is closed, or a communication error occurs.
 func (c *Client) Run(client pb.DiscoveryClient) {
 	ctx := c.conf.Context
-	ctx = metadata.AppendToOutgoingContext(ctx, ConnID, c.conf.ConnID, EventScope, c.conf.EventScope)
-	c.conf.Logger.Debug("Register client to events", ConnID, c.conf.ConnID, EventScope, c.conf.EventScope)
-	stream, err := client.Events(ctx)
+	ctx = metadata.AppendToOutgoingContext(ctx, ConnID, c.conf.ConnID, EventScope, c.conf.EventScope, ClientID, c.id)
+	if c.conf.Namespace != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, EventNamespace, c.conf.Namespace)
+	}
+	if traceparent := tracing.Traceparent(ctx); traceparent != "" {
+		// Let the discovery service correlate the connection with the trace of the activation.
+		ctx = metadata.AppendToOutgoingContext(ctx, tracing.TraceparentHeader, traceparent)
+	}
+	c.conf.Logger.Debug("Register client to events", ConnID, c.conf.ConnID, EventScope, c.conf.EventScope, EventNamespace, c.conf.Namespace)
+	// Passing the sequence number tells the server that the client acknowledges the events it receives.
+	stream, err := client.Events(metadata.AppendToOutgoingContext(ctx, LastSeq, "0"))
 	if err != nil {
 		c.conf.ErrCh <- err
 		return
 	}
+	c.mux.Lock()
 	c.stream = stream
+	c.streamCtx = ctx
+	c.mux.Unlock()
 
 	go func() {
 		for {
@@ -143,6 +286,8 @@ func (c *Client) Run(client pb.DiscoveryClient) {
 // Stop closes the underlying gRPC stream and its TCP connection.
 func (c *Client) Stop() error {
 	c.conf.Logger.Debug("Stopping client connection")
+	c.mux.Lock()
+	defer c.mux.Unlock()
 	err := c.stream.CloseSend()
 	if err != nil {
 		return err
@@ -162,18 +307,16 @@ func (c *Client) streamOut() error {
 			return nil
 		case ev := <-c.conf.Out:
 			c.conf.Logger.Debugf("Sending event %v", ev)
-			err := c.stream.Send(ev)
+			ev, stream := c.track(ev)
+			err := c.send(stream, ev)
+			if err != nil && c.conf.Backoff != nil {
+				// The unacknowledged events are replayed once the connection is re-established.
+				c.conf.Logger.Warnf("Reconnecting as sending the event failed: %v", err)
+				err = c.reconnect(stream)
+			}
 			if err != nil {
 				c.conf.Logger.Errorf("Close the event forwarding as an error occurred: %v", err)
-				select {
-				case c.conf.ErrCh <- err:
-				default:
-					// The ErrCh is a buffered channel shared by multiple subroutines. Any error written to the channel
-					// indicates that the current procedure has failed.
-					// While the "root" error is sufficient to indicate that the routine failed, it may cause further
-					// errors in other routines. If write to ErrCh fails, err is classified as a consequent error. In
-					// this case, "err" is discarded to prevent the routine from blocking.
-				}
+				c.reportError(err)
 				return nil
 			}
 		}
@@ -192,7 +335,8 @@ func (c *Client) streamIn() error {
 		}
 	}()
 	for {
-		ev, err := c.stream.Recv()
+		stream := c.currentStream()
+		ev, err := stream.Recv()
 		select {
 		case <-c.conf.Context.Done():
 			c.conf.Logger.Debugf("Stop receiiving events as context is done. (err: %v)", err)
@@ -201,22 +345,183 @@ func (c *Client) streamIn() error {
 			c.conf.Logger.Debugf("Received event %v", ev)
 			if err == io.EOF {
 				c.conf.Logger.Debug("Server closed the connection")
-				return nil
-			}
-			if err != nil {
+				if c.conf.Backoff == nil {
+					return nil
+				}
+			} else if err != nil {
 				c.conf.Logger.Errorf("Error from the gRPC stream %s", err.Error())
-				select {
-				case c.conf.ErrCh <- err:
-				default:
-					// The ErrCh is a buffered channel shared by multiple subroutines. Any error written to the channel
-					// indicates that the current procedure has failed.
-					// While the "root" error is sufficient to indicate that the routine failed, it may cause further
-					// errors in other routines. If write to ErrCh fails, err is classified as a consequent error. In
-					// this case, "err" is discarded to prevent the routine from blocking.
+			}
+			if err != nil && c.conf.Backoff != nil {
+				err = c.reconnect(stream)
+				if err == nil {
+					continue
 				}
+			}
+			if err != nil {
+				c.reportError(err)
 				return nil
 			}
+			if ev.GetName() == Ack {
+				c.acknowledge(ev.GetAck())
+				continue
+			}
+			if ev.GetSeq() > 0 {
+				if !c.receive(ev.GetSeq()) {
+					c.conf.Logger.Debugw("Dropping event received already", "Event", ev)
+					continue
+				}
+				c.ack(stream, ev)
+			}
 			c.conf.In <- ev
 		}
 	}
 }
+
+// reportError sends the error to the ErrCh without blocking.
+func (c *Client) reportError(err error) {
+	select {
+	case c.conf.ErrCh <- err:
+	default:
+		// The ErrCh is a buffered channel shared by multiple subroutines. Any error written to the channel
+		// indicates that the current procedure has failed.
+		// While the "root" error is sufficient to indicate that the routine failed, it may cause further
+		// errors in other routines. If write to ErrCh fails, err is classified as a consequent error. In
+		// this case, "err" is discarded to prevent the routine from blocking.
+	}
+}
+
+// currentStream returns the stream events are exchanged on.
+func (c *Client) currentStream() pb.Discovery_EventsClient {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.stream
+}
+
+// track assigns the next sequence number to a copy of the event and records the copy as unacknowledged. The oldest
+// unacknowledged event is given up if the buffer is full. Returns the copy and the stream it is sent on. The event
+// itself is left untouched, as it may carry the sequence number of an upstream server.
+func (c *Client) track(ev *pb.Event) (*pb.Event, pb.Discovery_EventsClient) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.seq++
+	sequenced := *ev
+	sequenced.Seq = c.seq
+	if size := c.bufferSize(); len(c.unacked) >= size {
+		c.conf.Logger.Warnw("Giving up replaying the event as the buffer is full", "Event", c.unacked[0], "BufferSize", size)
+		c.unacked = c.unacked[1:]
+	}
+	c.unacked = append(c.unacked, &sequenced)
+	return &sequenced, c.stream
+}
+
+// bufferSize returns the maximum number of unacknowledged events retained.
+func (c *Client) bufferSize() int {
+	if c.conf.BufferSize > 0 {
+		return c.conf.BufferSize
+	}
+	return DefaultBufferSize
+}
+
+// acknowledge marks the events sent up to the given sequence number as received by the server. The events sent later
+// are retained, as the server may not have received them yet.
+func (c *Client) acknowledge(seq uint64) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	for len(c.unacked) > 0 && c.unacked[0].Seq <= seq {
+		c.unacked = c.unacked[1:]
+	}
+}
+
+// receive records the sequence number of an event received from the server. It returns false if the event has been
+// received already, e.g., as it was re-delivered.
+func (c *Client) receive(seq uint64) bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if seq <= c.lastSeq {
+		return false
+	}
+	c.lastSeq = seq
+	return true
+}
+
+// ack acknowledges the event to the server, so that it is not re-delivered.
+func (c *Client) ack(stream pb.Discovery_EventsClient, ev *pb.Event) {
+	err := c.send(stream, &pb.Event{Name: Ack, GameID: ev.GameID, Ack: ev.Seq})
+	if err != nil {
+		// The broken stream is detected when receiving the next event.
+		c.conf.Logger.Warnf("Error acknowledging event %d: %v", ev.Seq, err)
+	}
+}
+
+// send sends the event to the stream.
+func (c *Client) send(stream pb.Discovery_EventsClient, ev *pb.Event) error {
+	c.sendMux.Lock()
+	defer c.sendMux.Unlock()
+	return stream.Send(ev)
+}
+
+// reconnect re-dials the server with a jittered exponential backoff and re-subscribes to the events with the same
+// ConnID once the given stream terminated. The unacknowledged events are replayed on the new stream. An error is
+// returned if no connection could be established within the timeout of the backoff.
+func (c *Client) reconnect(broken pb.Discovery_EventsClient) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.stream != broken {
+		// Another routine has reconnected already.
+		return nil
+	}
+	if c.conn != nil {
+		_ = c.conn.Close()
+	}
+	if c.conf.Failover && c.conf.Health != nil && c.endpoint != "" {
+		c.conf.Logger.Warnw("Failing over to the next discovery endpoint", "Endpoint", c.endpoint)
+		c.conf.Health.MarkFailed(c.endpoint)
+	}
+	conf := c.conf.Backoff.config()
+	attempts := 0
+	conf.Hooks = retry.Hooks{
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			c.conf.Logger.Warnw("Reconnecting to the discovery service failed", "Attempt", attempt, "Delay", delay, "Error", err)
+		},
+		OnDone: func(n int, _ error) {
+			attempts = n
+		},
+	}
+	err := retry.Do(c.streamCtx, conf, c.resubscribe)
+	if c.streamCtx.Err() != nil {
+		// The client is stopped, the routines exit as the context is done.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reconnect within %s: %v", c.conf.Backoff.Timeout, err)
+	}
+	c.conf.Logger.Infow("Reconnected to the discovery service", "Endpoint", c.endpoint, "Attempts", attempts)
+	return nil
+}
+
+// resubscribe establishes a new connection and stream and replays the unacknowledged events, if any. The server is
+// asked to replay the events received since the last one, unless the client connected to another endpoint, as the
+// sequence numbers of other servers are unrelated. Must be called with the lock held.
+func (c *Client) resubscribe() error {
+	previous := c.endpoint
+	conn, err := c.connect()
+	if err != nil {
+		return err
+	}
+	lastSeq := c.lastSeq
+	if c.endpoint != previous {
+		lastSeq = 0
+	}
+	ctx := metadata.AppendToOutgoingContext(c.streamCtx, LastSeq, strconv.FormatUint(lastSeq, 10))
+	stream, err := pb.NewDiscoveryClient(conn).Events(ctx)
+	for i := 0; err == nil && i < len(c.unacked); i++ {
+		err = c.send(stream, c.unacked[i])
+	}
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	c.stream = stream
+	c.lastSeq = lastSeq
+	return nil
+}
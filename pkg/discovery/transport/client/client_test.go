@@ -10,6 +10,8 @@ import (
 	. "github.com/carbynestack/ephemeral/pkg/discovery/transport/server"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
+	"io/ioutil"
+	"os"
 	"time"
 
 	. "github.com/carbynestack/ephemeral/pkg/types"
@@ -46,7 +48,9 @@ var _ = Describe("Client", func() {
 				Logger: logger,
 			}
 			gameID = "71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4"
-			tr = NewTransportServer(serverConf)
+			var err error
+			tr, err = NewTransportServer(serverConf)
+			Expect(err).NotTo(HaveOccurred())
 			conf := &TransportClientConfig{
 				In:             clientIn,
 				Out:            clientOut,
@@ -92,6 +96,204 @@ var _ = Describe("Client", func() {
 			}
 		})
 	})
+	Context("when the primary endpoint is unreachable", func() {
+		It("connects to the first reachable fallback and reports it", func() {
+			logger := zap.NewNop().Sugar()
+			errCh := make(chan error, 1)
+			tr, err := NewTransportServer(&TransportConfig{
+				In:     make(chan *pb.Event, 1),
+				Out:    make(chan *pb.Event, 1),
+				ErrCh:  errCh,
+				Port:   "9597",
+				Logger: logger,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer tr.Stop()
+			go tr.Run(func() {})
+			time.Sleep(100 * time.Millisecond)
+			health := NewEndpointHealth(time.Minute)
+			client, err := NewClient(&TransportClientConfig{
+				ErrCh:          errCh,
+				Host:           "localhost",
+				Port:           "9598",
+				Fallbacks:      []DiscoveryEndpoint{{Host: "localhost", Port: "9597"}},
+				Health:         health,
+				EventScope:     EventScopeAll,
+				ConnID:         "abc",
+				Logger:         logger,
+				ConnectTimeout: 500 * time.Millisecond,
+				Context:        context.TODO(),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			conn, err := client.Connect()
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+			Expect(client.Endpoint()).To(Equal("localhost:9597"))
+			Expect(health.IsHealthy("localhost:9598")).To(BeFalse())
+		})
+	})
+	Context("when the server authenticates its clients", func() {
+		It("presents the token read from the token file", func() {
+			tokenFile, err := ioutil.TempFile("", "discovery_token_")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.Remove(tokenFile.Name())
+			_, err = tokenFile.WriteString("s3cret\n")
+			Expect(err).NotTo(HaveOccurred())
+			logger := zap.NewNop().Sugar()
+			errCh := make(chan error, 1)
+			tr, err := NewTransportServer(&TransportConfig{
+				In:     make(chan *pb.Event, 1),
+				Out:    make(chan *pb.Event, 1),
+				ErrCh:  errCh,
+				Port:   "9599",
+				Auth:   &AuthConfig{Tokens: []string{"s3cret"}},
+				Logger: logger,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer tr.Stop()
+			go func() {
+				ev := <-tr.GetIn()
+				tr.GetOut() <- ev
+			}()
+			go tr.Run(cb)
+			time.Sleep(100 * time.Millisecond)
+			clientIn := make(chan *pb.Event, 1)
+			clientOut := make(chan *pb.Event, 1)
+			client, err := NewClient(&TransportClientConfig{
+				In:             clientIn,
+				Out:            clientOut,
+				ErrCh:          errCh,
+				Host:           "localhost",
+				Port:           "9599",
+				EventScope:     EventScopeSelf,
+				ConnID:         "42",
+				Token:          &TokenConfig{File: tokenFile.Name(), Insecure: true},
+				Logger:         logger,
+				ConnectTimeout: 10 * time.Second,
+				Context:        context.TODO(),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer client.Stop()
+			conn, err := client.Connect()
+			Expect(err).NotTo(HaveOccurred())
+			go client.Run(pb.NewDiscoveryClient(conn))
+			clientOut <- &pb.Event{GameID: "42"}
+			select {
+			case resp := <-clientIn:
+				Expect(resp.GameID).To(Equal("42"))
+			case err := <-errCh:
+				Expect(err).To(BeNil())
+			case <-time.After(5 * time.Second):
+				Fail("no response received")
+			}
+		})
+	})
+	Context("when the server restarts", func() {
+		It("reconnects and replays the unacknowledged event", func() {
+			logger := zap.NewNop().Sugar()
+			errCh := make(chan error, 1)
+			newServer := func(in chan *pb.Event) *TransportServer {
+				tr, err := NewTransportServer(&TransportConfig{
+					In:     in,
+					Out:    make(chan *pb.Event, 1),
+					ErrCh:  errCh,
+					Port:   "9600",
+					Logger: logger,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				go tr.Run(func() {})
+				time.Sleep(100 * time.Millisecond)
+				return tr
+			}
+			// Nobody reads the events the first server receives, so that it does not acknowledge them.
+			tr := newServer(make(chan *pb.Event))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			clientOut := make(chan *pb.Event, 1)
+			client, err := NewClient(&TransportClientConfig{
+				In:             make(chan *pb.Event, 1),
+				Out:            clientOut,
+				ErrCh:          errCh,
+				Host:           "localhost",
+				Port:           "9600",
+				EventScope:     EventScopeAll,
+				ConnID:         "abc",
+				Backoff:        &Backoff{Initial: 50 * time.Millisecond, Max: 200 * time.Millisecond, Timeout: 5 * time.Second},
+				Logger:         logger,
+				ConnectTimeout: 100 * time.Millisecond,
+				Context:        ctx,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			conn, err := client.Connect()
+			Expect(err).NotTo(HaveOccurred())
+			client.Run(pb.NewDiscoveryClient(conn))
+			ev := &pb.Event{GameID: "abc"}
+			clientOut <- ev
+			time.Sleep(100 * time.Millisecond)
+			// The server goes away before it acknowledged the event.
+			tr.Stop()
+			tr = newServer(make(chan *pb.Event, 1))
+			defer tr.Stop()
+			var replayed *pb.Event
+			Eventually(tr.GetIn(), 5*time.Second).Should(Receive(&replayed))
+			Expect(replayed.GameID).To(Equal("abc"))
+			Consistently(errCh, 200*time.Millisecond).ShouldNot(Receive())
+		})
+	})
+	Context("when the active server goes away and failover is enabled", func() {
+		It("fails over to the fallback and replays the unacknowledged event", func() {
+			logger := zap.NewNop().Sugar()
+			errCh := make(chan error, 1)
+			newServer := func(port string, in chan *pb.Event) *TransportServer {
+				tr, err := NewTransportServer(&TransportConfig{
+					In:     in,
+					Out:    make(chan *pb.Event, 1),
+					ErrCh:  errCh,
+					Port:   port,
+					Logger: logger,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				go tr.Run(func() {})
+				time.Sleep(100 * time.Millisecond)
+				return tr
+			}
+			// Nobody reads the events the primary receives, so that it does not acknowledge them.
+			primary := newServer("9601", make(chan *pb.Event))
+			backup := newServer("9602", make(chan *pb.Event, 1))
+			defer backup.Stop()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			clientOut := make(chan *pb.Event, 1)
+			client, err := NewClient(&TransportClientConfig{
+				In:             make(chan *pb.Event, 1),
+				Out:            clientOut,
+				ErrCh:          errCh,
+				Host:           "localhost",
+				Port:           "9601",
+				Fallbacks:      []DiscoveryEndpoint{{Host: "localhost", Port: "9602"}},
+				Health:         NewEndpointHealth(time.Minute),
+				Failover:       true,
+				EventScope:     EventScopeAll,
+				ConnID:         "abc",
+				Backoff:        &Backoff{Initial: 50 * time.Millisecond, Max: 200 * time.Millisecond, Timeout: 5 * time.Second},
+				Logger:         logger,
+				ConnectTimeout: 100 * time.Millisecond,
+				Context:        ctx,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			conn, err := client.Connect()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.Endpoint()).To(Equal("localhost:9601"))
+			client.Run(pb.NewDiscoveryClient(conn))
+			clientOut <- &pb.Event{GameID: "abc"}
+			time.Sleep(100 * time.Millisecond)
+			primary.Stop()
+			var replayed *pb.Event
+			Eventually(backup.GetIn(), 5*time.Second).Should(Receive(&replayed))
+			Expect(replayed.GameID).To(Equal("abc"))
+			Consistently(errCh, 200*time.Millisecond).ShouldNot(Receive())
+		})
+	})
 	Context("when creating a new client", func() {
 		It("returns an error if an empty connection id is provided", func() {
 			conf := &TransportClientConfig{
@@ -139,6 +341,17 @@ var _ = Describe("Client", func() {
 			_, err := NewClient(conf)
 			Expect(err).NotTo(HaveOccurred())
 		})
+		It("returns an error if an empty token is configured", func() {
+			conf := &TransportClientConfig{
+				ConnID:     "abc",
+				EventScope: EventScopeAll,
+				Host:       "localhost",
+				Port:       "8080",
+				Token:      &TokenConfig{},
+			}
+			_, err := NewClient(conf)
+			Expect(err).To(MatchError("either a token or a token file must be provided"))
+		})
 	})
 
 	Context("when sending events *to* the server", func() {
@@ -175,6 +388,49 @@ var _ = Describe("Client", func() {
 				<-st.sendCh
 			})
 		})
+		Context("when the server has not answered yet", func() {
+			It("numbers the events and retains a limited number of them for replay", func() {
+				conf.BufferSize = 2
+				cl := Client{
+					conf:   conf,
+					stream: &ScriptedStream{},
+				}
+				ev := &pb.Event{Name: PlayerReady, Seq: 42}
+				sent, _ := cl.track(ev)
+				Expect(sent.Seq).To(Equal(uint64(1)))
+				Expect(ev.Seq).To(Equal(uint64(42)))
+				cl.track(&pb.Event{Name: PlayerReady})
+				cl.track(&pb.Event{Name: PlayerReady})
+				Expect(cl.unacked).To(HaveLen(2))
+				Expect(cl.unacked[0].Seq).To(Equal(uint64(2)))
+				Expect(cl.unacked[1].Seq).To(Equal(uint64(3)))
+				cl.acknowledge(2)
+				Expect(cl.unacked).To(HaveLen(1))
+				Expect(cl.unacked[0].Seq).To(Equal(uint64(3)))
+				cl.acknowledge(3)
+				Expect(cl.unacked).To(BeEmpty())
+			})
+			It("retains the events the server has not acknowledged when receiving other events", func() {
+				conf.In = make(chan *pb.Event, 1)
+				conf.Logger = zap.NewNop().Sugar()
+				st := &ScriptedStream{events: []*pb.Event{
+					{Name: Ack, GameID: "42", Ack: 1},
+					{Name: PlayersReady, GameID: "42"},
+				}}
+				cl := Client{
+					conf:   conf,
+					stream: st,
+					conn:   &FakeTransportConn{},
+				}
+				cl.track(&pb.Event{Name: PlayerReady})
+				cl.track(&pb.Event{Name: PlayerReady})
+				Expect(cl.streamIn()).To(Succeed())
+				Expect(conf.In).To(HaveLen(1))
+				Expect((<-conf.In).Name).To(Equal(PlayersReady))
+				Expect(cl.unacked).To(HaveLen(1))
+				Expect(cl.unacked[0].Seq).To(Equal(uint64(2)))
+			})
+		})
 		Context("when an error occurs", func() {
 			It("forwards it to the error channel", func() {
 				st := &BrokenStream{}
@@ -270,6 +526,53 @@ var _ = Describe("Client", func() {
 				Expect(recorded.AllUntimed()[1].Entry.Message).To(Equal("Server closed the connection"))
 			})
 		})
+		Context("when the events carry sequence numbers", func() {
+			It("acknowledges them and drops the ones received already", func() {
+				conf.In = make(chan *pb.Event, 3)
+				conf.Logger = zap.NewNop().Sugar()
+				st := &ScriptedStream{events: []*pb.Event{
+					{Name: PlayersReady, GameID: "42", Seq: 1},
+					{Name: PlayersReady, GameID: "42", Seq: 1},
+					{Name: TCPCheckSuccessAll, GameID: "42", Seq: 2},
+				}}
+				cl := Client{
+					conf:   conf,
+					stream: st,
+					conn:   &FakeTransportConn{},
+				}
+				Expect(cl.streamIn()).To(Succeed())
+				Expect(conf.In).To(HaveLen(2))
+				Expect((<-conf.In).Name).To(Equal(PlayersReady))
+				Expect((<-conf.In).Name).To(Equal(TCPCheckSuccessAll))
+				Expect(st.sent).To(Equal([]*pb.Event{
+					{Name: Ack, GameID: "42", Ack: 1},
+					{Name: Ack, GameID: "42", Ack: 2},
+				}))
+				Expect(cl.lastSeq).To(Equal(uint64(2)))
+			})
+		})
+		Context("when the stream was closed and a backoff is configured", func() {
+			It("reports an error if the server cannot be reached within the timeout", func() {
+				errCh := make(chan error, 1)
+				cl := Client{
+					conf: &TransportClientConfig{
+						ErrCh:          errCh,
+						Host:           "localhost",
+						Port:           "9601",
+						ConnectTimeout: 10 * time.Millisecond,
+						Backoff:        &Backoff{Initial: 10 * time.Millisecond, Max: 20 * time.Millisecond, Timeout: 100 * time.Millisecond},
+						Logger:         zap.NewNop().Sugar(),
+						Context:        context.TODO(),
+					},
+					stream:    &BrokenStream{},
+					conn:      &FakeTransportConn{},
+					streamCtx: context.TODO(),
+				}
+				err := cl.streamIn()
+				Expect(err).To(BeNil())
+				Expect((<-errCh).Error()).To(HavePrefix("failed to reconnect within 100ms"))
+			})
+		})
 	})
 	Context("when using client interfaces", func() {
 		It("returns In channel", func() {
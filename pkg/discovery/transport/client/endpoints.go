@@ -0,0 +1,73 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package client
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// NewEndpointHealth returns a new health tracker. Endpoints that failed to connect are considered unhealthy for the
+// given cool down period.
+func NewEndpointHealth(coolDown time.Duration) *EndpointHealth {
+	return &EndpointHealth{
+		coolDown: coolDown,
+		failures: map[string]time.Time{},
+	}
+}
+
+// EndpointHealth keeps track of failed connection attempts to discovery endpoints. It is meant to be shared among
+// clients, so that endpoints known to be unreachable are tried last by subsequent connection attempts.
+type EndpointHealth struct {
+	coolDown time.Duration
+	failures map[string]time.Time
+	mux      sync.Mutex
+}
+
+// MarkFailed records a failed connection attempt for the given address.
+func (h *EndpointHealth) MarkFailed(addr string) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	h.failures[addr] = time.Now()
+}
+
+// MarkHealthy removes any previously recorded failure for the given address.
+func (h *EndpointHealth) MarkHealthy(addr string) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	delete(h.failures, addr)
+}
+
+// IsHealthy returns false if a connection attempt to the given address failed within the cool down period.
+func (h *EndpointHealth) IsHealthy(addr string) bool {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	failed, ok := h.failures[addr]
+	if !ok {
+		return true
+	}
+	return time.Since(failed) > h.coolDown
+}
+
+// Order returns the given endpoints with all healthy endpoints first. The relative priority of the endpoints is
+// preserved within the healthy and unhealthy groups.
+func (h *EndpointHealth) Order(endpoints []DiscoveryEndpoint) []DiscoveryEndpoint {
+	var healthy, unhealthy []DiscoveryEndpoint
+	for _, e := range endpoints {
+		if h.IsHealthy(Address(e)) {
+			healthy = append(healthy, e)
+		} else {
+			unhealthy = append(unhealthy, e)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// Address returns the dial address of an endpoint.
+func Address(e DiscoveryEndpoint) string {
+	return e.Host + ":" + e.Port
+}
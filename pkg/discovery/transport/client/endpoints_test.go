@@ -0,0 +1,44 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package client
+
+import (
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EndpointHealth", func() {
+	var (
+		primary  = DiscoveryEndpoint{Host: "primary", Port: "8080"}
+		fallback = DiscoveryEndpoint{Host: "fallback", Port: "8080"}
+	)
+	It("considers unknown endpoints healthy", func() {
+		h := NewEndpointHealth(time.Minute)
+		Expect(h.IsHealthy(Address(primary))).To(BeTrue())
+	})
+	It("considers failed endpoints unhealthy during the cool down period", func() {
+		h := NewEndpointHealth(time.Minute)
+		h.MarkFailed(Address(primary))
+		Expect(h.IsHealthy(Address(primary))).To(BeFalse())
+		h.MarkHealthy(Address(primary))
+		Expect(h.IsHealthy(Address(primary))).To(BeTrue())
+	})
+	It("considers failed endpoints healthy again after the cool down period", func() {
+		h := NewEndpointHealth(0)
+		h.MarkFailed(Address(primary))
+		time.Sleep(time.Millisecond)
+		Expect(h.IsHealthy(Address(primary))).To(BeTrue())
+	})
+	It("moves unhealthy endpoints to the end while preserving priority", func() {
+		h := NewEndpointHealth(time.Minute)
+		other := DiscoveryEndpoint{Host: "other", Port: "8080"}
+		h.MarkFailed(Address(primary))
+		Expect(h.Order([]DiscoveryEndpoint{primary, fallback, other})).To(Equal([]DiscoveryEndpoint{fallback, other, primary}))
+	})
+})
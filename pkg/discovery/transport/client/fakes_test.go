@@ -102,3 +102,28 @@ func (b *BrokenStream) SendMsg(m interface{}) error {
 func (b *BrokenStream) RecvMsg(m interface{}) error {
 	return nil
 }
+
+// ScriptedStream returns the given events from Recv followed by io.EOF and records the events sent.
+type ScriptedStream struct {
+	FakeStream
+	events []*pb.Event
+	sent   []*pb.Event
+}
+
+func (s *ScriptedStream) Send(ev *pb.Event) error {
+	s.sent = append(s.sent, ev)
+	return nil
+}
+
+func (s *ScriptedStream) Recv() (*pb.Event, error) {
+	if len(s.events) == 0 {
+		return nil, io.EOF
+	}
+	ev := s.events[0]
+	s.events = s.events[1:]
+	return ev, nil
+}
+
+func (s *ScriptedStream) CloseSend() error {
+	return nil
+}
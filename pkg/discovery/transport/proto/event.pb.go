@@ -30,6 +30,12 @@ type Player struct {
 	Pod                  string   `protobuf:"bytes,3,opt,name=pod,proto3" json:"pod,omitempty"`
 	Ip                   string   `protobuf:"bytes,4,opt,name=ip,proto3" json:"ip,omitempty"`
 	Port                 int32    `protobuf:"varint,5,opt,name=port,proto3" json:"port,omitempty"`
+	PodAffinity          []string `protobuf:"bytes,6,rep,name=podAffinity,proto3" json:"podAffinity,omitempty"`
+	ParamsFingerprint    string   `protobuf:"bytes,7,opt,name=paramsFingerprint,proto3" json:"paramsFingerprint,omitempty"`
+	Namespace            string   `protobuf:"bytes,8,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Features             []string `protobuf:"bytes,9,rep,name=features,proto3" json:"features,omitempty"`
+	Cluster              string   `protobuf:"bytes,10,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	LocalIp              string   `protobuf:"bytes,11,opt,name=localIp,proto3" json:"localIp,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -95,10 +101,55 @@ func (m *Player) GetPort() int32 {
 	return 0
 }
 
+func (m *Player) GetPodAffinity() []string {
+	if m != nil {
+		return m.PodAffinity
+	}
+	return nil
+}
+
+func (m *Player) GetParamsFingerprint() string {
+	if m != nil {
+		return m.ParamsFingerprint
+	}
+	return ""
+}
+
+func (m *Player) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *Player) GetFeatures() []string {
+	if m != nil {
+		return m.Features
+	}
+	return nil
+}
+
+func (m *Player) GetCluster() string {
+	if m != nil {
+		return m.Cluster
+	}
+	return ""
+}
+
+func (m *Player) GetLocalIp() string {
+	if m != nil {
+		return m.LocalIp
+	}
+	return ""
+}
+
 type Event struct {
 	GameID               string    `protobuf:"bytes,1,opt,name=gameID,proto3" json:"gameID,omitempty"`
 	Players              []*Player `protobuf:"bytes,2,rep,name=players,proto3" json:"players,omitempty"`
 	Name                 string    `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Traceparent          string    `protobuf:"bytes,4,opt,name=traceparent,proto3" json:"traceparent,omitempty"`
+	Seq                  uint64    `protobuf:"varint,5,opt,name=seq,proto3" json:"seq,omitempty"`
+	Ack                  uint64    `protobuf:"varint,6,opt,name=ack,proto3" json:"ack,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
 	XXX_unrecognized     []byte    `json:"-"`
 	XXX_sizecache        int32     `json:"-"`
@@ -150,6 +201,27 @@ func (m *Event) GetName() string {
 	return ""
 }
 
+func (m *Event) GetTraceparent() string {
+	if m != nil {
+		return m.Traceparent
+	}
+	return ""
+}
+
+func (m *Event) GetSeq() uint64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *Event) GetAck() uint64 {
+	if m != nil {
+		return m.Ack
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Player)(nil), "protobuf.Player")
 	proto.RegisterType((*Event)(nil), "protobuf.Event")
@@ -158,21 +230,28 @@ func init() {
 func init() { proto.RegisterFile("event.proto", fileDescriptor_2d17a9d3f0ddf27e) }
 
 var fileDescriptor_2d17a9d3f0ddf27e = []byte{
-	// 209 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x8e, 0xb1, 0x4e, 0x80, 0x30,
-	0x10, 0x86, 0x6d, 0x81, 0x2a, 0x47, 0xa2, 0xe4, 0x06, 0xd3, 0x38, 0x11, 0xa6, 0xc6, 0x81, 0x10,
-	0x9c, 0xdd, 0x70, 0x70, 0x33, 0xbc, 0x80, 0x01, 0xa9, 0xda, 0x44, 0x68, 0x53, 0x90, 0x84, 0xb7,
-	0x37, 0x9c, 0x10, 0x8c, 0x53, 0xff, 0xfb, 0x9a, 0xbb, 0xff, 0x83, 0x44, 0x2f, 0x7a, 0x9c, 0x0b,
-	0xe7, 0xed, 0x6c, 0xf1, 0x8a, 0x9e, 0xee, 0xfb, 0x3d, 0xff, 0x04, 0xf1, 0xf2, 0xd5, 0xae, 0xda,
-	0xe3, 0x35, 0x70, 0xd3, 0x4b, 0x96, 0x31, 0x15, 0x35, 0xdc, 0xf4, 0x28, 0xe1, 0xd2, 0xd1, 0xcf,
-	0x24, 0x39, 0xc1, 0x63, 0xc4, 0x14, 0x02, 0x67, 0x7b, 0x19, 0x64, 0x4c, 0xc5, 0xcd, 0x16, 0x69,
-	0xd7, 0xc9, 0x90, 0x00, 0x37, 0x0e, 0x11, 0x42, 0x67, 0xfd, 0x2c, 0x23, 0x5a, 0xa4, 0x9c, 0xbf,
-	0x42, 0xf4, 0xb4, 0x29, 0xe0, 0x2d, 0x88, 0x8f, 0x76, 0xd0, 0xcf, 0x35, 0x95, 0xc5, 0xcd, 0x3e,
-	0xe1, 0xfd, 0xdf, 0xc2, 0x40, 0x25, 0x55, 0x5a, 0x1c, 0x9a, 0xc5, 0xaf, 0xe3, 0xa9, 0x80, 0x10,
-	0x8e, 0xed, 0xa0, 0x77, 0x07, 0xca, 0xd5, 0x23, 0xc4, 0xb5, 0x99, 0xde, 0xec, 0xa2, 0xfd, 0x8a,
-	0x25, 0x08, 0x6a, 0x9b, 0xf0, 0xe6, 0xbc, 0x42, 0xe4, 0xee, 0x3f, 0xc8, 0x2f, 0x14, 0x2b, 0x59,
-	0x27, 0x88, 0x3e, 0xfc, 0x04, 0x00, 0x00, 0xff, 0xff, 0xcd, 0x92, 0x43, 0xf5, 0x29, 0x01, 0x00,
-	0x00,
+	// 330 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x65, 0x90, 0xc1, 0x4a, 0xc3, 0x40,
+	0x10, 0x86, 0x4d, 0xd2, 0xa6, 0xcd, 0x04, 0xb4, 0xce, 0x41, 0x96, 0xe2, 0xa1, 0xf4, 0x54, 0x44,
+	0x42, 0xa9, 0x67, 0x0f, 0x42, 0x15, 0x7a, 0x93, 0xbc, 0xc1, 0x36, 0xdd, 0x94, 0x60, 0x9a, 0xac,
+	0xbb, 0x9b, 0x42, 0x1f, 0x48, 0x9f, 0xd3, 0xdd, 0x69, 0x6a, 0x82, 0x9e, 0xf2, 0xff, 0xff, 0xcc,
+	0x64, 0x66, 0x3f, 0x88, 0xc5, 0x51, 0x54, 0x26, 0x91, 0xaa, 0x36, 0x35, 0x8e, 0xe9, 0xb3, 0x6d,
+	0xf2, 0xf9, 0xb7, 0x0f, 0xe1, 0x7b, 0xc9, 0x4f, 0x42, 0xe1, 0x35, 0xf8, 0xc5, 0x8e, 0x79, 0x33,
+	0x6f, 0x31, 0x4c, 0xad, 0x42, 0x06, 0x23, 0x49, 0x15, 0xcd, 0x7c, 0x0a, 0x2f, 0x16, 0x27, 0x10,
+	0xc8, 0x7a, 0xc7, 0x02, 0x9b, 0x46, 0xa9, 0x93, 0x34, 0x2b, 0xd9, 0x80, 0x02, 0xab, 0x10, 0x61,
+	0x20, 0x6b, 0x65, 0xd8, 0x90, 0x06, 0x49, 0xe3, 0x0c, 0x62, 0xdb, 0xfa, 0x92, 0xe7, 0x45, 0x55,
+	0x98, 0x13, 0x0b, 0x67, 0x81, 0x6d, 0xee, 0x47, 0xf8, 0x08, 0xb7, 0x92, 0x2b, 0x7e, 0xd0, 0x6f,
+	0x45, 0xb5, 0x17, 0x4a, 0xaa, 0xa2, 0x32, 0x6c, 0x44, 0x3f, 0xfd, 0x5f, 0xc0, 0x7b, 0x88, 0x2a,
+	0x7e, 0x10, 0x5a, 0xf2, 0x4c, 0xb0, 0x31, 0x75, 0x75, 0x01, 0x4e, 0x61, 0x9c, 0x0b, 0x6e, 0x1a,
+	0x25, 0x34, 0x8b, 0x68, 0xd5, 0xaf, 0x77, 0x2f, 0xcb, 0xca, 0x46, 0x1b, 0xa1, 0x18, 0xd0, 0xdc,
+	0xc5, 0xba, 0x4a, 0x59, 0x67, 0xbc, 0xdc, 0x48, 0x16, 0x9f, 0x2b, 0xad, 0x9d, 0x7f, 0x79, 0x30,
+	0x7c, 0x75, 0x08, 0xf1, 0x0e, 0xc2, 0xbd, 0x5d, 0xb3, 0x59, 0x13, 0xab, 0x28, 0x6d, 0x1d, 0x3e,
+	0xf4, 0x79, 0x05, 0x8b, 0x78, 0x35, 0x49, 0x2e, 0x98, 0x93, 0x33, 0xe2, 0x8e, 0xa0, 0xe5, 0xe3,
+	0x4e, 0x6d, 0x11, 0x92, 0x76, 0x7c, 0x8c, 0xb2, 0xa7, 0xdb, 0x97, 0xda, 0x35, 0x2d, 0xcc, 0x7e,
+	0xe4, 0xb8, 0x6b, 0xf1, 0x49, 0x50, 0x07, 0xa9, 0x93, 0x2e, 0xe1, 0xd9, 0x87, 0x65, 0x49, 0x89,
+	0x95, 0xab, 0x67, 0x88, 0xd6, 0x85, 0xce, 0xea, 0xa3, 0x50, 0x27, 0x5c, 0x42, 0x48, 0x37, 0x6b,
+	0xbc, 0xe9, 0x6e, 0xa1, 0x64, 0xfa, 0x37, 0x98, 0x5f, 0x2d, 0xbc, 0xa5, 0xb7, 0x0d, 0x29, 0x7d,
+	0xfa, 0x01, 0xc7, 0x2e, 0x2b, 0x4d, 0x2f, 0x02, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -0,0 +1,119 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package security
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io/ioutil"
+	"strings"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AuthorizationHeader is the gRPC metadata key the bearer token is passed in.
+const AuthorizationHeader = "authorization"
+
+const bearerPrefix = "Bearer "
+
+// NewAuthenticator returns an authenticator accepting the tokens defined by the config.
+func NewAuthenticator(conf *AuthConfig) (*Authenticator, error) {
+	if len(conf.Tokens) == 0 && conf.OIDC == nil {
+		return nil, errors.New("either static tokens or an OIDC provider must be configured to authenticate clients")
+	}
+	a := &Authenticator{}
+	for _, token := range conf.Tokens {
+		if token == "" {
+			return nil, errors.New("static tokens must not be empty")
+		}
+		a.tokens = append(a.tokens, []byte(token))
+	}
+	if conf.OIDC != nil {
+		verifier, err := newJWTVerifier(conf.OIDC)
+		if err != nil {
+			return nil, err
+		}
+		a.jwt = verifier
+	}
+	return a, nil
+}
+
+// Authenticator validates the bearer tokens presented by discovery clients.
+type Authenticator struct {
+	tokens [][]byte
+	// jwt verifies tokens issued by the OIDC provider, nil if none is configured.
+	jwt *jwtVerifier
+}
+
+// Authenticate checks the bearer token passed in the metadata of the given context.
+func (a *Authenticator) Authenticate(ctx context.Context) error {
+	meta, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return errors.New("no metadata in the stream context")
+	}
+	values := meta.Get(AuthorizationHeader)
+	if len(values) != 1 || !strings.HasPrefix(values[0], bearerPrefix) {
+		return errors.New("a single bearer token must be provided")
+	}
+	token := strings.TrimPrefix(values[0], bearerPrefix)
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare(t, []byte(token)) == 1 {
+			return nil
+		}
+	}
+	if a.jwt == nil {
+		return errors.New("invalid token")
+	}
+	return a.jwt.verify(token)
+}
+
+// StreamInterceptor returns a gRPC interceptor rejecting streams of unauthenticated clients.
+func (a *Authenticator) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := a.Authenticate(ss.Context()); err != nil {
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+		return handler(srv, ss)
+	}
+}
+
+// NewTokenCredentials returns credentials attaching the configured bearer token to each stream.
+func NewTokenCredentials(conf *TokenConfig) (*TokenCredentials, error) {
+	if conf.Token == "" && conf.File == "" {
+		return nil, errors.New("either a token or a token file must be provided")
+	}
+	return &TokenCredentials{conf: conf}, nil
+}
+
+// TokenCredentials are gRPC per RPC credentials carrying a bearer token.
+type TokenCredentials struct {
+	conf *TokenConfig
+}
+
+// GetRequestMetadata returns the authorization header. The token file, if any, is read on each call to pick up
+// rotated tokens.
+func (t *TokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token := t.conf.Token
+	if t.conf.File != "" {
+		data, err := ioutil.ReadFile(t.conf.File)
+		if err != nil {
+			return nil, err
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	return map[string]string{AuthorizationHeader: bearerPrefix + token}, nil
+}
+
+// RequireTransportSecurity returns true, so that tokens are not sent in plaintext, unless the config explicitly allows
+// insecure connections, e.g., if they are secured by a service mesh.
+func (t *TokenCredentials) RequireTransportSecurity() bool {
+	return !t.conf.Insecure
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package security
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/metadata"
+)
+
+var _ = Describe("Authenticator", func() {
+	withToken := func(token string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(AuthorizationHeader, "Bearer "+token))
+	}
+
+	Context("when static tokens are configured", func() {
+		var auth *Authenticator
+		BeforeEach(func() {
+			var err error
+			auth, err = NewAuthenticator(&AuthConfig{Tokens: []string{"s3cret", "other"}})
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("accepts the configured tokens", func() {
+			Expect(auth.Authenticate(withToken("s3cret"))).To(Succeed())
+			Expect(auth.Authenticate(withToken("other"))).To(Succeed())
+		})
+		It("rejects other tokens", func() {
+			Expect(auth.Authenticate(withToken("guess"))).To(MatchError("invalid token"))
+		})
+		It("rejects requests without a bearer token", func() {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(AuthorizationHeader, "s3cret"))
+			Expect(auth.Authenticate(ctx)).To(MatchError("a single bearer token must be provided"))
+			Expect(auth.Authenticate(context.Background())).To(MatchError("no metadata in the stream context"))
+		})
+	})
+
+	Context("when an OIDC provider is configured", func() {
+		var (
+			server *httptest.Server
+			rsaKey *rsa.PrivateKey
+			ecKey  *ecdsa.PrivateKey
+			auth   *Authenticator
+			claims map[string]interface{}
+		)
+		BeforeEach(func() {
+			rsaKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+			ecKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			mux := http.NewServeMux()
+			server = httptest.NewServer(mux)
+			mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode(map[string]string{"issuer": server.URL, "jwks_uri": server.URL + "/keys"})
+			})
+			mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+					{"kid": "rsa", "kty": "RSA", "n": encodeBigInt(rsaKey.N), "e": encodeBigInt(big.NewInt(int64(rsaKey.E)))},
+					{"kid": "ec", "kty": "EC", "crv": "P-256", "x": encodeBigInt(ecKey.X), "y": encodeBigInt(ecKey.Y)},
+				}})
+			})
+			var err error
+			auth, err = NewAuthenticator(&AuthConfig{OIDC: &OIDCConfig{Issuer: server.URL, Audience: "discovery"}})
+			Expect(err).NotTo(HaveOccurred())
+			claims = map[string]interface{}{
+				"iss": server.URL,
+				"aud": []string{"discovery"},
+				"exp": time.Now().Add(time.Hour).Unix(),
+			}
+		})
+		AfterEach(func() {
+			server.Close()
+		})
+		It("accepts tokens signed with RS256", func() {
+			Expect(auth.Authenticate(withToken(signRS256(rsaKey, "rsa", claims)))).To(Succeed())
+		})
+		It("accepts tokens signed with ES256", func() {
+			claims["aud"] = "discovery"
+			Expect(auth.Authenticate(withToken(signES256(ecKey, "ec", claims)))).To(Succeed())
+		})
+		It("rejects tokens with an invalid signature", func() {
+			otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+			Expect(auth.Authenticate(withToken(signRS256(otherKey, "rsa", claims)))).To(MatchError(ContainSubstring("failed to verify signature")))
+		})
+		It("rejects unsigned tokens", func() {
+			token := encodeSegment(map[string]string{"alg": "none", "kid": "rsa"}) + "." + encodeSegment(claims) + "."
+			Expect(auth.Authenticate(withToken(token))).To(MatchError(ContainSubstring("unsupported algorithm")))
+		})
+		It("rejects tokens signed with unknown keys", func() {
+			Expect(auth.Authenticate(withToken(signRS256(rsaKey, "unknown", claims)))).To(MatchError(ContainSubstring("failed to verify signature")))
+		})
+		It("rejects expired tokens", func() {
+			claims["exp"] = time.Now().Add(-time.Minute).Unix()
+			Expect(auth.Authenticate(withToken(signRS256(rsaKey, "rsa", claims)))).To(MatchError(ContainSubstring("token is expired")))
+		})
+		It("rejects tokens issued for other audiences", func() {
+			claims["aud"] = []string{"amphora"}
+			Expect(auth.Authenticate(withToken(signRS256(rsaKey, "rsa", claims)))).To(MatchError(ContainSubstring("expected audience \"discovery\"")))
+		})
+		It("rejects tokens issued by other issuers", func() {
+			claims["iss"] = "https://evil.example.com"
+			Expect(auth.Authenticate(withToken(signRS256(rsaKey, "rsa", claims)))).To(HaveOccurred())
+		})
+	})
+
+	Context("when the config is invalid", func() {
+		It("requires tokens or an OIDC provider", func() {
+			_, err := NewAuthenticator(&AuthConfig{})
+			Expect(err).To(HaveOccurred())
+		})
+		It("rejects empty static tokens", func() {
+			_, err := NewAuthenticator(&AuthConfig{Tokens: []string{""}})
+			Expect(err).To(MatchError("static tokens must not be empty"))
+		})
+		It("requires the audience of the OIDC tokens", func() {
+			_, err := NewAuthenticator(&AuthConfig{OIDC: &OIDCConfig{Issuer: "https://issuer.example.com"}})
+			Expect(err).To(MatchError("the audience of the tokens must be provided"))
+		})
+	})
+})
+
+var _ = Describe("TokenCredentials", func() {
+	It("attaches the static token", func() {
+		creds, err := NewTokenCredentials(&TokenConfig{Token: "s3cret"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(creds.GetRequestMetadata(context.Background())).To(Equal(map[string]string{AuthorizationHeader: "Bearer s3cret"}))
+	})
+	It("requires transport security unless insecure connections are allowed", func() {
+		creds, err := NewTokenCredentials(&TokenConfig{Token: "s3cret"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(creds.RequireTransportSecurity()).To(BeTrue())
+		creds, err = NewTokenCredentials(&TokenConfig{Token: "s3cret", Insecure: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(creds.RequireTransportSecurity()).To(BeFalse())
+	})
+	It("reads the token file on each request", func() {
+		file, err := ioutil.TempFile("", "discovery_token_")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.Remove(file.Name())
+		creds, err := NewTokenCredentials(&TokenConfig{Token: "ignored", File: file.Name()})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(file.Name(), []byte("first\n"), 0600)).To(Succeed())
+		Expect(creds.GetRequestMetadata(context.Background())).To(Equal(map[string]string{AuthorizationHeader: "Bearer first"}))
+		Expect(ioutil.WriteFile(file.Name(), []byte("rotated\n"), 0600)).To(Succeed())
+		Expect(creds.GetRequestMetadata(context.Background())).To(Equal(map[string]string{AuthorizationHeader: "Bearer rotated"}))
+	})
+})
+
+func signRS256(key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	payload := encodeSegment(map[string]string{"alg": "RS256", "kid": kid}) + "." + encodeSegment(claims)
+	digest := sha256.Sum256([]byte(payload))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	Expect(err).NotTo(HaveOccurred())
+	return payload + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func signES256(key *ecdsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	payload := encodeSegment(map[string]string{"alg": "ES256", "kid": kid}) + "." + encodeSegment(claims)
+	digest := sha256.Sum256([]byte(payload))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	Expect(err).NotTo(HaveOccurred())
+	// The signature is the concatenation of r and s, each padded to 32 bytes.
+	signature := make([]byte, 64)
+	rBytes, sBytes := r.Bytes(), s.Bytes()
+	copy(signature[32-len(rBytes):32], rBytes)
+	copy(signature[64-len(sBytes):], sBytes)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func encodeSegment(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
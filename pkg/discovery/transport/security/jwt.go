@@ -0,0 +1,82 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package security
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	"github.com/coreos/go-oidc"
+)
+
+// supportedSigningAlgs are the algorithms the tokens may be signed with.
+var supportedSigningAlgs = []string{oidc.RS256, oidc.ES256}
+
+func newJWTVerifier(conf *OIDCConfig) (*jwtVerifier, error) {
+	if conf.Issuer == "" {
+		return nil, errors.New("the issuer of the OIDC provider must be provided")
+	}
+	if conf.Audience == "" {
+		return nil, errors.New("the audience of the tokens must be provided")
+	}
+	return &jwtVerifier{
+		conf:   conf,
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+	}, nil
+}
+
+// jwtVerifier verifies JWTs signed with RS256 or ES256 by an OIDC provider. The configuration of the provider is
+// discovered on the first token, so that the discovery service starts even if the provider is not reachable yet.
+type jwtVerifier struct {
+	conf   *OIDCConfig
+	client *http.Client
+	now    func() time.Time
+
+	mux      sync.Mutex
+	verifier *oidc.IDTokenVerifier
+}
+
+// verify checks the signature and the claims of the token.
+func (v *jwtVerifier) verify(token string) error {
+	verifier, err := v.idTokenVerifier()
+	if err != nil {
+		return err
+	}
+	_, err = verifier.Verify(oidc.ClientContext(context.Background(), v.client), token)
+	return err
+}
+
+// idTokenVerifier returns the verifier of the tokens. The keys are fetched from the JWKSURL if set, or from the
+// location advertised by the issuer otherwise. They are refetched when a token is signed with an unknown key.
+func (v *jwtVerifier) idTokenVerifier() (*oidc.IDTokenVerifier, error) {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+	if v.verifier != nil {
+		return v.verifier, nil
+	}
+	// The key set keeps the context to fetch the keys later on, hence it must not be cancelled.
+	ctx := oidc.ClientContext(context.Background(), v.client)
+	config := &oidc.Config{
+		ClientID:             v.conf.Audience,
+		SupportedSigningAlgs: supportedSigningAlgs,
+		Now:                  v.now,
+	}
+	if v.conf.JWKSURL != "" {
+		v.verifier = oidc.NewVerifier(v.conf.Issuer, oidc.NewRemoteKeySet(ctx, v.conf.JWKSURL), config)
+		return v.verifier, nil
+	}
+	provider, err := oidc.NewProvider(ctx, v.conf.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	v.verifier = provider.Verifier(config)
+	return v.verifier, nil
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package security builds the TLS configurations of the discovery transport.
+package security
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// ServerTLSConfig returns the TLS configuration of a discovery server. The server presents the configured certificate.
+// If a CA bundle is configured, clients must present a certificate issued by one of the CAs, i.e., mutual TLS is
+// enforced. The allowed SANs restrict the clients, hence they require a CA bundle.
+func ServerTLSConfig(conf *TLSConfig) (*tls.Config, error) {
+	if conf.CertFile == "" || conf.KeyFile == "" {
+		return nil, errors.New("certificate and key must be provided to serve TLS")
+	}
+	if len(conf.AllowedSANs) > 0 && conf.CAFile == "" {
+		return nil, errors.New("a CA bundle must be provided to verify the SANs of the clients")
+	}
+	cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading the key pair: %v", err)
+	}
+	tlsConf := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if conf.CAFile == "" {
+		return tlsConf, nil
+	}
+	roots, err := loadCertPool(conf.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	// The client certificate is verified by verifyPeer, so that the SANs can be checked along with the chain.
+	tlsConf.ClientAuth = tls.RequireAnyClientCert
+	tlsConf.VerifyPeerCertificate = verifyPeer(roots, x509.ExtKeyUsageClientAuth, conf.AllowedSANs)
+	return tlsConf, nil
+}
+
+// ClientTLSConfig returns the TLS configuration of a discovery client. The certificate of the server is verified
+// against the configured CA bundle, or the system roots if none is configured. The configured certificate, if any, is
+// presented to the server.
+func ClientTLSConfig(conf *TLSConfig) (*tls.Config, error) {
+	tlsConf := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if conf.CertFile != "" || conf.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading the key pair: %v", err)
+		}
+		tlsConf.Certificates = []tls.Certificate{cert}
+	}
+	if conf.CAFile != "" {
+		roots, err := loadCertPool(conf.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConf.RootCAs = roots
+	}
+	if len(conf.AllowedSANs) > 0 {
+		// Servers are identified by their SANs instead of their host name, e.g., by SPIFFE IDs which are not bound to
+		// the address the server is reached at. Hence, the verification is done by verifyPeer instead.
+		tlsConf.InsecureSkipVerify = true
+		tlsConf.VerifyPeerCertificate = verifyPeer(tlsConf.RootCAs, x509.ExtKeyUsageServerAuth, conf.AllowedSANs)
+	}
+	return tlsConf, nil
+}
+
+// verifyPeer returns a function that verifies the certificate chain presented by the peer against the given roots and
+// checks that the certificate of the peer contains one of the allowed SANs, if any.
+func verifyPeer(roots *x509.CertPool, usage x509.ExtKeyUsage, allowedSANs []string) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("peer did not present a certificate")
+		}
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return fmt.Errorf("error parsing the certificate of the peer: %v", err)
+			}
+			certs[i] = cert
+		}
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{usage},
+		})
+		if err != nil {
+			return err
+		}
+		if len(allowedSANs) == 0 || hasAllowedSAN(certs[0], allowedSANs) {
+			return nil
+		}
+		return fmt.Errorf("certificate of the peer does not contain any of the allowed SANs %v", allowedSANs)
+	}
+}
+
+// hasAllowedSAN returns true if one of the URI or DNS SANs of the certificate is allowed.
+func hasAllowedSAN(cert *x509.Certificate, allowedSANs []string) bool {
+	var sans []string
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+	sans = append(sans, cert.DNSNames...)
+	for _, san := range sans {
+		for _, allowed := range allowedSANs {
+			if san == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// loadCertPool reads the PEM encoded CA bundle from the given file.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the CA bundle: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in the CA bundle %s", path)
+	}
+	return pool, nil
+}
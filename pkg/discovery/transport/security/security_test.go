@@ -0,0 +1,153 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package security
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Security", func() {
+	var (
+		dir        string
+		serverConf *TLSConfig
+		clientConf *TLSConfig
+	)
+	BeforeEach(func() {
+		dir, _ = ioutil.TempDir("", "discovery_tls_")
+		ca, caKey := newCertificate(dir, "ca", nil, nil, "", x509.ExtKeyUsageAny)
+		newCertificate(dir, "server", ca, caKey, "spiffe://vcp-1.carbynestack.io/discovery", x509.ExtKeyUsageServerAuth)
+		newCertificate(dir, "client", ca, caKey, "spiffe://vcp-2.carbynestack.io/discovery", x509.ExtKeyUsageClientAuth)
+		serverConf = &TLSConfig{
+			CertFile: filepath.Join(dir, "server.pem"),
+			KeyFile:  filepath.Join(dir, "server-key.pem"),
+			CAFile:   filepath.Join(dir, "ca.pem"),
+		}
+		clientConf = &TLSConfig{
+			CertFile: filepath.Join(dir, "client.pem"),
+			KeyFile:  filepath.Join(dir, "client-key.pem"),
+			CAFile:   filepath.Join(dir, "ca.pem"),
+		}
+	})
+	AfterEach(func() {
+		_ = os.RemoveAll(dir)
+	})
+	It("establishes a mutually authenticated connection", func() {
+		serverConf.AllowedSANs = []string{"spiffe://vcp-2.carbynestack.io/discovery"}
+		clientConf.AllowedSANs = []string{"spiffe://vcp-1.carbynestack.io/discovery"}
+		Expect(handshake(serverConf, clientConf)).To(Succeed())
+	})
+	It("rejects clients without a certificate", func() {
+		clientConf.CertFile, clientConf.KeyFile = "", ""
+		Expect(handshake(serverConf, clientConf)).NotTo(Succeed())
+	})
+	It("rejects clients whose certificate lacks an allowed SAN", func() {
+		serverConf.AllowedSANs = []string{"spiffe://vcp-3.carbynestack.io/discovery"}
+		Expect(handshake(serverConf, clientConf)).NotTo(Succeed())
+	})
+	It("rejects servers whose certificate lacks an allowed SAN", func() {
+		clientConf.AllowedSANs = []string{"spiffe://vcp-3.carbynestack.io/discovery"}
+		Expect(handshake(serverConf, clientConf)).NotTo(Succeed())
+	})
+	It("rejects peers whose certificate is issued by another CA", func() {
+		other := filepath.Join(dir, "other")
+		Expect(os.Mkdir(other, 0755)).To(Succeed())
+		newCertificate(other, "ca", nil, nil, "", x509.ExtKeyUsageAny)
+		clientConf.CAFile = filepath.Join(other, "ca.pem")
+		clientConf.AllowedSANs = []string{"spiffe://vcp-1.carbynestack.io/discovery"}
+		Expect(handshake(serverConf, clientConf)).NotTo(Succeed())
+	})
+	It("requires a key pair to serve TLS", func() {
+		_, err := ServerTLSConfig(&TLSConfig{CAFile: serverConf.CAFile})
+		Expect(err).To(MatchError("certificate and key must be provided to serve TLS"))
+	})
+	It("requires a CA bundle to verify the SANs of the clients", func() {
+		serverConf.CAFile = ""
+		serverConf.AllowedSANs = []string{"spiffe://vcp-2.carbynestack.io/discovery"}
+		_, err := ServerTLSConfig(serverConf)
+		Expect(err).To(MatchError("a CA bundle must be provided to verify the SANs of the clients"))
+	})
+	It("fails if the CA bundle does not contain certificates", func() {
+		Expect(ioutil.WriteFile(clientConf.CAFile, []byte("garbage"), 0644)).To(Succeed())
+		_, err := ClientTLSConfig(clientConf)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+// handshake performs a TLS handshake between a server and a client with the given configurations.
+func handshake(serverConf, clientConf *TLSConfig) error {
+	serverTLS, err := ServerTLSConfig(serverConf)
+	if err != nil {
+		return err
+	}
+	clientTLS, err := ClientTLSConfig(clientConf)
+	if err != nil {
+		return err
+	}
+	clientTLS.ServerName = "localhost"
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- tls.Server(serverConn, serverTLS).Handshake()
+		// Unblock the client if the server rejected it.
+		serverConn.Close()
+	}()
+	clientErr := tls.Client(clientConn, clientTLS).Handshake()
+	if err := <-serverErr; err != nil {
+		return err
+	}
+	return clientErr
+}
+
+// newCertificate writes a certificate and its key to dir. The certificate is self-signed if parent is nil.
+func newCertificate(dir string, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, san string, usage x509.ExtKeyUsage) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	if san != "" {
+		uri, _ := url.Parse(san)
+		template.URIs = []*url.URL{uri}
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ioutil.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)).To(Succeed())
+	Expect(ioutil.WriteFile(filepath.Join(dir, name+"-key.pem"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)).To(Succeed())
+	return cert, key
+}
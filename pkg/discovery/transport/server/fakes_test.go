@@ -102,3 +102,22 @@ func (f *BrokenListener) Close() error {
 func (f *BrokenListener) Addr() net.Addr {
 	return nil
 }
+
+// RecordingStream records the events sent to it.
+type RecordingStream struct {
+	FakeStream
+	sent []*pb.Event
+}
+
+func (r *RecordingStream) Send(ev *pb.Event) error {
+	r.sent = append(r.sent, ev)
+	return nil
+}
+
+func (r *RecordingStream) seqs() []uint64 {
+	var seqs []uint64
+	for _, ev := range r.sent {
+		seqs = append(seqs, ev.Seq)
+	}
+	return seqs
+}
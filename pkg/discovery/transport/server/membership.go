@@ -0,0 +1,157 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package server
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// finalEvents are the events after which a game does not send any further events to the slaves, i.e., it finished,
+// timed out or was aborted.
+var finalEvents = map[string]bool{
+	GameSuccess:         true,
+	GameError:           true,
+	StateTimeoutError:   true,
+	PodAffinityMismatch: true,
+	ParamsMismatch:      true,
+	PortsExhausted:      true,
+}
+
+// newMembership returns an empty membership forgetting the games no event was seen of for longer than the given TTL.
+// Games are only forgotten once they finished if the TTL is zero.
+func newMembership(ttl time.Duration) *membership {
+	return &membership{
+		games: map[string]time.Time{},
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+// membership keeps track of the games a discovery slave takes part in, i.e., the games it forwarded events for.
+type membership struct {
+	// games are the times the last event of the games was seen at.
+	games map[string]time.Time
+	ttl   time.Duration
+	now   func() time.Time
+	// swept is the time the expired games were removed at last.
+	swept time.Time
+	mux   sync.Mutex
+}
+
+// join records that the slave takes part in the given game. Nil memberships ignore the call.
+func (m *membership) join(gameID string) {
+	if m == nil {
+		return
+	}
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	now := m.now()
+	m.games[gameID] = now
+	if m.ttl > 0 && now.Sub(m.swept) >= m.ttl {
+		for id, seen := range m.games {
+			if now.Sub(seen) > m.ttl {
+				delete(m.games, id)
+			}
+		}
+		m.swept = now
+	}
+}
+
+// routes returns true if the event belongs to one of the games of the slave. Once the game has finished, timed out or
+// was aborted, it is forgotten after the event has been routed. Games no event was seen of for longer than the TTL
+// are forgotten as well, e.g., the ones evicted by the janitor.
+func (m *membership) routes(ev string, gameID string) bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	seen, ok := m.games[gameID]
+	if !ok {
+		return false
+	}
+	now := m.now()
+	switch {
+	case m.ttl > 0 && now.Sub(seen) > m.ttl:
+		delete(m.games, gameID)
+		return false
+	case finalEvents[ev]:
+		delete(m.games, gameID)
+	default:
+		m.games[gameID] = now
+	}
+	return true
+}
+
+// empty returns true if the slave takes part in no game.
+func (m *membership) empty() bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return len(m.games) == 0
+}
+
+// slaveMemberships keeps the memberships of the slaves by their namespace and connection ID, so that a slave which
+// reconnects receives the events of its games it missed. Each slave must connect with its own connection ID, e.g., the
+// name of its pod.
+type slaveMemberships struct {
+	mux    sync.Mutex
+	byConn map[string]*slaveMembership
+}
+
+// slaveMembership is the membership of a slave along with its open connections.
+type slaveMembership struct {
+	*membership
+	// conns is the number of open connections of the slave.
+	conns int
+	// closed is the time the last connection of the slave was closed at.
+	closed time.Time
+}
+
+// acquire returns the membership of the slave with the given namespace and connection ID. A new membership forgetting
+// the games after the given TTL is created for slaves which connect for the first time. The returned function must be
+// called once the connection is closed.
+//
+// The membership is dropped once the last connection of the slave is closed if it takes part in no game. Otherwise, it
+// is kept for the slave to reconnect until the TTL has elapsed.
+func (s *slaveMemberships) acquire(ns string, connID string, ttl time.Duration) (*membership, func()) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.byConn == nil {
+		s.byConn = map[string]*slaveMembership{}
+	}
+	key := ns + "/" + connID
+	m, ok := s.byConn[key]
+	if !ok {
+		m = &slaveMembership{membership: newMembership(ttl)}
+		s.byConn[key] = m
+	}
+	m.conns++
+	s.prune(m.now())
+	return m.membership, func() {
+		s.mux.Lock()
+		defer s.mux.Unlock()
+		m.conns--
+		if m.conns > 0 {
+			return
+		}
+		m.closed = m.now()
+		if m.empty() && s.byConn[key] == m {
+			delete(s.byConn, key)
+		}
+	}
+}
+
+// prune drops the memberships of the slaves without open connections which take part in no game or have not
+// reconnected within the TTL of their membership.
+func (s *slaveMemberships) prune(now time.Time) {
+	for key, m := range s.byConn {
+		if m.conns > 0 {
+			continue
+		}
+		if m.empty() || (m.ttl > 0 && now.Sub(m.closed) > m.ttl) {
+			delete(s.byConn, key)
+		}
+	}
+}
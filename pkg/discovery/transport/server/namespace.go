@@ -0,0 +1,48 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package server
+
+import (
+	"sync"
+)
+
+// namespaceLogSize is the number of games the namespace is retained for.
+const namespaceLogSize = 4096
+
+// gameNamespaces keeps track of the event namespaces the games belong to. A game belongs to the namespace of the
+// stream that sent its first event. The games claimed least recently are forgotten first.
+type gameNamespaces struct {
+	mux    sync.Mutex
+	byGame map[string]string
+	order  []string
+}
+
+// claim records that the given game belongs to the given namespace unless it belongs to another namespace already.
+// It returns false if the game belongs to another namespace.
+func (n *gameNamespaces) claim(gameID string, ns string) bool {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	if n.byGame == nil {
+		n.byGame = map[string]string{}
+	}
+	if owner, ok := n.byGame[gameID]; ok {
+		return owner == ns
+	}
+	n.byGame[gameID] = ns
+	n.order = append(n.order, gameID)
+	if len(n.order) > namespaceLogSize {
+		delete(n.byGame, n.order[0])
+		n.order = n.order[1:]
+	}
+	return true
+}
+
+// of returns the namespace the given game belongs to. Games not claimed by any stream belong to the default
+// namespace, i.e., the empty one.
+func (n *gameNamespaces) of(gameID string) string {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	return n.byGame[gameID]
+}
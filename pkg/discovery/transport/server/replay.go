@@ -0,0 +1,229 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package server
+
+import (
+	"sync"
+	"time"
+
+	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
+
+	"go.uber.org/zap"
+)
+
+// DefaultRedeliveryInterval is the duration after which events not acknowledged by a client are re-delivered by
+// default.
+const DefaultRedeliveryInterval = 5 * time.Second
+
+const (
+	// replayLogSize is the number of recently broadcast events that are retained to be replayed to reconnecting
+	// clients.
+	replayLogSize = 1024
+	// maxRedeliveries is the number of times an unacknowledged event is re-delivered before it is given up.
+	maxRedeliveries = 3
+	// receiveLogSize is the number of clients the sequence number of the last event received is retained for.
+	receiveLogSize = 1024
+)
+
+// replayLog assigns sequence numbers to the broadcast events and retains the most recent ones.
+type replayLog struct {
+	mux    sync.Mutex
+	seq    uint64
+	events []*pb.Event
+}
+
+// broadcast assigns the next sequence number to a copy of the event, retains the copy and hands it to publish. The
+// event itself is left untouched, as it may carry the sequence number of an upstream server.
+func (l *replayLog) broadcast(ev *pb.Event, publish func(*pb.Event)) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.seq++
+	sequenced := *ev
+	sequenced.Seq = l.seq
+	l.events = append(l.events, &sequenced)
+	if len(l.events) > replayLogSize {
+		l.events = l.events[len(l.events)-replayLogSize:]
+	}
+	publish(&sequenced)
+}
+
+// subscribe passes the retained events with a sequence number greater than seq in the order they were broadcast to
+// subscribe. No event broadcast concurrently is missed, i.e., it is either passed to subscribe or published after
+// subscribe returned.
+func (l *replayLog) subscribe(seq uint64, subscribe func(missed []*pb.Event)) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	var missed []*pb.Event
+	for _, ev := range l.events {
+		if ev.Seq > seq {
+			missed = append(missed, ev)
+		}
+	}
+	subscribe(missed)
+}
+
+// receiveLog keeps track of the sequence numbers of the events received from the clients, so that events replayed by a
+// reconnecting client are received only once. The clients received from least recently are forgotten first.
+type receiveLog struct {
+	mux   sync.Mutex
+	last  map[string]uint64
+	order []string
+}
+
+// receive records the sequence number of an event received from the client with the given ID. It returns false if
+// the event has been received already. Events without a sequence number are always received.
+func (l *receiveLog) receive(clientID string, seq uint64) bool {
+	if seq == 0 {
+		return true
+	}
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if l.last == nil {
+		l.last = map[string]uint64{}
+	}
+	last, known := l.last[clientID]
+	if seq <= last {
+		return false
+	}
+	l.last[clientID] = seq
+	if known {
+		for i, id := range l.order {
+			if id == clientID {
+				l.order = append(l.order[:i], l.order[i+1:]...)
+				break
+			}
+		}
+	}
+	l.order = append(l.order, clientID)
+	if len(l.order) > receiveLogSize {
+		delete(l.last, l.order[0])
+		l.order = l.order[1:]
+	}
+	return true
+}
+
+// newDelivery returns the delivery of events to the given stream. The events are tracked until acknowledged if
+// acknowledged is true, i.e., if the client is known to acknowledge the events it receives.
+func newDelivery(stream pb.Discovery_EventsServer, acknowledged bool, logger *zap.SugaredLogger) *delivery {
+	return &delivery{
+		stream:       stream,
+		acknowledged: acknowledged,
+		logger:       logger,
+	}
+}
+
+// delivery serializes the events sent to a stream and keeps track of the events the client has not acknowledged yet.
+type delivery struct {
+	stream       pb.Discovery_EventsServer
+	acknowledged bool
+	logger       *zap.SugaredLogger
+
+	mux sync.Mutex
+	// backlog are the events missed by a reconnecting client. They are sent ahead of any other event.
+	backlog []*pb.Event
+	// last is the highest sequence number sent to the stream.
+	last    uint64
+	pending []*pendingEvent
+}
+
+type pendingEvent struct {
+	ev       *pb.Event
+	sentAt   time.Time
+	attempts int
+}
+
+// send sends the backlog, if any, followed by the event. Events that have already been sent are skipped.
+func (d *delivery) send(ev *pb.Event) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	if err := d.flushBacklog(); err != nil {
+		return err
+	}
+	return d.sendLocked(ev)
+}
+
+// queue adds the events to the backlog.
+func (d *delivery) queue(events []*pb.Event) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.backlog = append(d.backlog, events...)
+}
+
+// flush sends the backlog, if it has not been sent along with an event yet.
+func (d *delivery) flush() error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return d.flushBacklog()
+}
+
+func (d *delivery) flushBacklog() error {
+	backlog := d.backlog
+	d.backlog = nil
+	for _, ev := range backlog {
+		if err := d.sendLocked(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *delivery) sendLocked(ev *pb.Event) error {
+	if ev.Seq != 0 && ev.Seq <= d.last {
+		return nil
+	}
+	if err := d.stream.Send(ev); err != nil {
+		return err
+	}
+	if ev.Seq == 0 {
+		return nil
+	}
+	d.last = ev.Seq
+	if d.acknowledged {
+		d.pending = append(d.pending, &pendingEvent{ev: ev, sentAt: time.Now()})
+		if len(d.pending) > replayLogSize {
+			d.pending = d.pending[len(d.pending)-replayLogSize:]
+		}
+	}
+	return nil
+}
+
+// ack marks the events up to the given sequence number as received by the client. Nil deliveries ignore the call.
+func (d *delivery) ack(seq uint64) {
+	if d == nil {
+		return
+	}
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	for len(d.pending) > 0 && d.pending[0].ev.Seq <= seq {
+		d.pending = d.pending[1:]
+	}
+}
+
+// redeliver re-sends the events that have not been acknowledged within the given interval. Events are given up after
+// maxRedeliveries attempts.
+func (d *delivery) redeliver(interval time.Duration) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	now := time.Now()
+	var pending []*pendingEvent
+	for _, p := range d.pending {
+		if now.Sub(p.sentAt) < interval {
+			pending = append(pending, p)
+			continue
+		}
+		if p.attempts == maxRedeliveries {
+			d.logger.Warnw("Giving up re-delivering the event", "Event", p.ev, "Attempts", p.attempts)
+			continue
+		}
+		p.attempts++
+		p.sentAt = now
+		d.logger.Debugw("Re-delivering unacknowledged event", "Event", p.ev, "Attempt", p.attempts)
+		if err := d.stream.Send(p.ev); err != nil {
+			d.logger.Errorf("Error re-delivering the event %s", p.ev.Name)
+		}
+		pending = append(pending, p)
+	}
+	d.pending = pending
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package server
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Replay", func() {
+	Context("when broadcasting events", func() {
+		It("assigns increasing sequence numbers to copies of the events", func() {
+			var log replayLog
+			var published []*pb.Event
+			publish := func(ev *pb.Event) { published = append(published, ev) }
+			upstream := &pb.Event{Name: "a", Seq: 42}
+			log.broadcast(upstream, publish)
+			log.broadcast(&pb.Event{Name: "b"}, publish)
+			Expect(published).To(HaveLen(2))
+			Expect(published[0].Seq).To(Equal(uint64(1)))
+			Expect(published[0].Name).To(Equal("a"))
+			Expect(published[1].Seq).To(Equal(uint64(2)))
+			Expect(upstream.Seq).To(Equal(uint64(42)))
+		})
+		It("passes the events missed since the given sequence number to the subscriber", func() {
+			var log replayLog
+			for i := 0; i < 3; i++ {
+				log.broadcast(&pb.Event{}, func(*pb.Event) {})
+			}
+			var missed []*pb.Event
+			log.subscribe(1, func(events []*pb.Event) { missed = events })
+			Expect(missed).To(HaveLen(2))
+			Expect(missed[0].Seq).To(Equal(uint64(2)))
+			Expect(missed[1].Seq).To(Equal(uint64(3)))
+		})
+		It("retains a limited number of events", func() {
+			var log replayLog
+			for i := 0; i < replayLogSize+1; i++ {
+				log.broadcast(&pb.Event{}, func(*pb.Event) {})
+			}
+			var missed []*pb.Event
+			log.subscribe(0, func(events []*pb.Event) { missed = events })
+			Expect(missed).To(HaveLen(replayLogSize))
+			Expect(missed[0].Seq).To(Equal(uint64(2)))
+		})
+	})
+
+	Context("when receiving events from clients", func() {
+		It("drops the events received already per client", func() {
+			var log receiveLog
+			Expect(log.receive("a", 1)).To(BeTrue())
+			Expect(log.receive("a", 2)).To(BeTrue())
+			Expect(log.receive("b", 1)).To(BeTrue())
+			Expect(log.receive("a", 1)).To(BeFalse())
+			Expect(log.receive("a", 2)).To(BeFalse())
+			Expect(log.receive("a", 0)).To(BeTrue())
+			Expect(log.receive("a", 3)).To(BeTrue())
+		})
+		It("forgets the clients received from least recently", func() {
+			var log receiveLog
+			log.receive("a", 1)
+			for i := 0; i < receiveLogSize; i++ {
+				log.receive(fmt.Sprint(i), 1)
+			}
+			Expect(log.receive("a", 1)).To(BeTrue())
+			Expect(log.order).To(HaveLen(receiveLogSize))
+		})
+	})
+
+	Context("when delivering events to a stream", func() {
+		var (
+			st  *RecordingStream
+			del *delivery
+		)
+		BeforeEach(func() {
+			st = &RecordingStream{}
+			del = newDelivery(st, true, zap.NewNop().Sugar())
+		})
+		It("sends the backlog ahead of the event and skips events sent already", func() {
+			del.queue([]*pb.Event{{Seq: 1}, {Seq: 2}})
+			Expect(del.send(&pb.Event{Seq: 3})).To(Succeed())
+			Expect(del.send(&pb.Event{Seq: 2})).To(Succeed())
+			Expect(st.seqs()).To(Equal([]uint64{1, 2, 3}))
+		})
+		It("re-delivers the events that have not been acknowledged in time", func() {
+			Expect(del.send(&pb.Event{Seq: 1})).To(Succeed())
+			Expect(del.send(&pb.Event{Seq: 2})).To(Succeed())
+			del.ack(1)
+			del.redeliver(0)
+			Expect(st.seqs()).To(Equal([]uint64{1, 2, 2}))
+			del.redeliver(time.Hour)
+			Expect(st.seqs()).To(Equal([]uint64{1, 2, 2}))
+		})
+		It("gives up re-delivering an event after the maximum number of attempts", func() {
+			Expect(del.send(&pb.Event{Seq: 1})).To(Succeed())
+			for i := 0; i <= maxRedeliveries; i++ {
+				del.redeliver(0)
+			}
+			Expect(st.sent).To(HaveLen(1 + maxRedeliveries))
+			Expect(del.pending).To(BeEmpty())
+		})
+		It("does not track the events if the client does not acknowledge them", func() {
+			del = newDelivery(st, false, zap.NewNop().Sugar())
+			Expect(del.send(&pb.Event{Seq: 1})).To(Succeed())
+			del.redeliver(0)
+			Expect(st.seqs()).To(Equal([]uint64{1}))
+		})
+	})
+})
@@ -7,15 +7,21 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
+	"github.com/carbynestack/ephemeral/pkg/discovery/transport/security"
+	"github.com/carbynestack/ephemeral/pkg/tracing"
 	"io"
 	"net"
+	"strconv"
+	"time"
 
 	. "github.com/carbynestack/ephemeral/pkg/types"
 
 	mb "github.com/vardius/message-bus"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -32,6 +38,25 @@ type TransportConfig struct {
 	// Port - the port to open up the connection.
 	Port string
 
+	// TLS secures the connections of the clients. The connections are not encrypted if nil.
+	TLS *TLSConfig
+
+	// Auth defines the bearer tokens clients must present. Clients are not authenticated if nil.
+	Auth *AuthConfig
+
+	// RedeliveryInterval is the duration after which events not acknowledged by a client are re-delivered. Events are
+	// not re-delivered if zero.
+	RedeliveryInterval time.Duration
+
+	// MembershipTTL is the duration after which a slave stops receiving the events of a game it has not seen an event
+	// of, e.g., as the game was evicted. It must exceed the timeouts of the games. Games are only forgotten once they
+	// finished, timed out or were aborted if zero.
+	MembershipTTL time.Duration
+
+	// Namespaces restricts the event namespaces clients may connect with. Clients of any namespace are accepted if
+	// empty.
+	Namespaces []string
+
 	Logger *zap.SugaredLogger
 }
 
@@ -45,14 +70,32 @@ type Transport interface {
 }
 
 // NewTransportServer returns a new transport server.
-func NewTransportServer(conf *TransportConfig) *TransportServer {
+func NewTransportServer(conf *TransportConfig) (*TransportServer, error) {
 	conf.Logger.Debug("Creating new TransportServer")
+	var opts []grpc.ServerOption
+	if conf.TLS != nil {
+		tlsConf, err := security.ServerTLSConfig(conf.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConf)))
+	}
+	if conf.Auth != nil {
+		auth, err := security.NewAuthenticator(conf.Auth)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.StreamInterceptor(auth.StreamInterceptor()))
+	}
 	tr := &TransportServer{
 		conf:       conf,
 		mb:         mb.New(10000),
-		grpcServer: grpc.NewServer(),
+		grpcServer: grpc.NewServer(opts...),
+		// The sequence numbers start at the time the server is created, so that they keep increasing across restarts
+		// and clients do not mistake the events of a restarted server for events received already.
+		replay: replayLog{seq: uint64(time.Now().UnixNano())},
 	}
-	return tr
+	return tr, nil
 }
 
 // TransportServer is a server the dispatches messsages from and to GRPC based transport.
@@ -60,6 +103,14 @@ type TransportServer struct {
 	conf       *TransportConfig
 	grpcServer *grpc.Server
 	mb         mb.MessageBus
+	// replay retains the broadcast events for clients that reconnect.
+	replay replayLog
+	// received drops the events replayed by clients that reconnect which have been received already.
+	received receiveLog
+	// namespaces keeps the events of a game within the namespace of the clients taking part in it.
+	namespaces gameNamespaces
+	// memberships are the games of the slaves, kept across reconnects.
+	memberships slaveMemberships
 }
 
 // GetIn returns the input channel of the transport.
@@ -101,15 +152,53 @@ func (d *TransportServer) Events(stream pb.Discovery_EventsServer) error {
 	if err != nil {
 		return err
 	}
-	d.conf.Logger.Debugw("Start handling events", ConnID, connID, EventScope, scope)
+	lastSeq, acknowledged, err := d.extractLastSeq(ctx)
+	if err != nil {
+		return err
+	}
+	ns, err := d.extractNamespace(ctx)
+	if err != nil {
+		return err
+	}
+	meta, _ := metadata.FromIncomingContext(ctx)
+	d.conf.Logger.Debugw("Start handling events", ConnID, connID, EventScope, scope, EventNamespace, ns, "Traceparent", meta.Get(tracing.TraceparentHeader), LastSeq, lastSeq)
+	// Slaves only receive the events of the games they take part in, including the events replayed once they
+	// reconnect.
+	var games *membership
+	if scope == EventScopeAll {
+		var release func()
+		games, release = d.memberships.acquire(ns, connID, d.conf.MembershipTTL)
+		defer release()
+	}
+	del := newDelivery(stream, acknowledged, d.conf.Logger)
 	// Read all outgoing events from the broadcast topic.
-	_ = d.mb.Subscribe(broadcastTopic, d.forwardToStream(stream, scope, connID))
+	forward := d.forwardToStream(del, scope, connID, ns, games)
+	d.replay.subscribe(lastSeq, func(missed []*pb.Event) {
+		// Events are only replayed to clients that reconnect, new clients have not missed anything.
+		if lastSeq > 0 {
+			var backlog []*pb.Event
+			for _, ev := range missed {
+				if d.routes(ev, scope, connID, ns, games) {
+					backlog = append(backlog, ev)
+				}
+			}
+			d.conf.Logger.Debugw("Replaying missed events", ConnID, connID, "Events", len(backlog))
+			del.queue(backlog)
+		}
+		_ = d.mb.Subscribe(broadcastTopic, forward)
+	})
+	if err := del.flush(); err != nil {
+		d.conf.Logger.Errorw("Error replaying the missed events", ConnID, connID, "Error", err)
+	}
+	if acknowledged && d.conf.RedeliveryInterval > 0 {
+		go d.redeliver(ctx, del)
+	}
 	errCh := make(chan error)
-	go d.forwardFromStream(stream, errCh)
+	go d.forwardFromStream(stream, connID, d.extractClientID(ctx, connID), ns, errCh, games, del)
 	// Block until we receive an error.
 	err = <-errCh
 	d.conf.Logger.Debugw("Event handling received error", "Error", err, ConnID, connID, EventScope, scope)
-	_ = d.mb.Unsubscribe(broadcastTopic, d.forwardToStream(stream, scope, connID))
+	_ = d.mb.Unsubscribe(broadcastTopic, forward)
 	d.conf.Logger.Debug("Unsubscribed forwardToStream from the broadcast topic")
 	return err
 }
@@ -127,8 +216,10 @@ func (d *TransportServer) broadcast(done chan struct{}) {
 	for {
 		select {
 		case ev := <-d.conf.Out:
-			d.conf.Logger.Debugw("Broadcast outgoing event", "Event", ev)
-			d.mb.Publish(broadcastTopic, ev)
+			d.replay.broadcast(ev, func(sequenced *pb.Event) {
+				d.conf.Logger.Debugw("Broadcast outgoing event", "Event", sequenced)
+				d.mb.Publish(broadcastTopic, sequenced)
+			})
 		case <-done:
 			d.conf.Logger.Debug("Stopped broadcasting")
 			return
@@ -156,36 +247,115 @@ func (d *TransportServer) extractMeta(ctx context.Context) (connID string, scope
 	return connID, scope, errors.New("no metadata in the stream context")
 }
 
+// extractLastSeq extracts the highest sequence number a reconnecting client has received from the stream metadata.
+// acknowledged is true if the client passed it, i.e., if the client acknowledges the events it receives.
+func (d *TransportServer) extractLastSeq(ctx context.Context) (seq uint64, acknowledged bool, err error) {
+	meta, _ := metadata.FromIncomingContext(ctx)
+	values := meta.Get(LastSeq)
+	if len(values) == 0 {
+		return 0, false, nil
+	}
+	if len(values) != 1 {
+		return 0, false, errors.New("LastSeq must contain exactly one element")
+	}
+	seq, err = strconv.ParseUint(values[0], 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid LastSeq: %v", err)
+	}
+	return seq, true, nil
+}
+
+// extractClientID extracts the ID of the client from the stream metadata. The connection ID is used for clients that
+// do not pass it.
+func (d *TransportServer) extractClientID(ctx context.Context, connID string) string {
+	meta, _ := metadata.FromIncomingContext(ctx)
+	if values := meta.Get(ClientID); len(values) == 1 {
+		return values[0]
+	}
+	return connID
+}
+
+// extractNamespace extracts the event namespace of the client from the stream metadata. Clients that do not pass it
+// belong to the default namespace. Namespaces other than the configured ones are rejected, if any are configured.
+func (d *TransportServer) extractNamespace(ctx context.Context) (string, error) {
+	meta, _ := metadata.FromIncomingContext(ctx)
+	values := meta.Get(EventNamespace)
+	if len(values) > 1 {
+		return "", errors.New("EventNamespace must contain at most one element")
+	}
+	ns := ""
+	if len(values) == 1 {
+		ns = values[0]
+	}
+	if len(d.conf.Namespaces) == 0 {
+		return ns, nil
+	}
+	for _, allowed := range d.conf.Namespaces {
+		if ns == allowed {
+			return ns, nil
+		}
+	}
+	return "", fmt.Errorf("namespace %q is not served", ns)
+}
+
+// redeliver periodically re-delivers the events the client has not acknowledged until the stream is closed.
+func (d *TransportServer) redeliver(ctx context.Context, del *delivery) {
+	ticker := time.NewTicker(d.conf.RedeliveryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			del.redeliver(d.conf.RedeliveryInterval)
+		}
+	}
+}
+
 // forwardToStream returns a function that is used as an event handler for the message bus. Depending on the event scope it forwards the events to the corresponding message bus topic.
-func (d *TransportServer) forwardToStream(stream pb.Discovery_EventsServer, scope, connID string) func(e interface{}) {
+func (d *TransportServer) forwardToStream(del *delivery, scope, connID, ns string, games *membership) func(e interface{}) {
 	return func(e interface{}) {
 		ev := e.(*pb.Event)
-		switch scope {
-		// This is the slave, forward all events.
-		case EventScopeAll:
-			d.sendEvent(stream, ev)
-		// This is an ordinary discovery client, only the events belonging to the gameID are forwarded.
-		case EventScopeSelf:
-			if connID == ev.GameID {
-				d.sendEvent(stream, ev)
-			}
-		default:
-			d.conf.Logger.Errorf("Unknown event scope %v", scope)
+		if d.routes(ev, scope, connID, ns, games) {
+			d.sendEvent(del, ev)
 		}
 	}
 }
 
+// routes returns true if the event is to be sent to a stream of the given scope, connection ID and namespace. Events
+// are never sent to streams of other namespaces than the one of their game.
+func (d *TransportServer) routes(ev *pb.Event, scope, connID, ns string, games *membership) bool {
+	if d.namespaces.of(ev.GameID) != ns {
+		return false
+	}
+	switch scope {
+	// This is the slave, only the events of the games it takes part in are forwarded.
+	case EventScopeAll:
+		return games.routes(ev.Name, ev.GameID)
+	// This is an ordinary discovery client, only the events belonging to the gameID are forwarded.
+	case EventScopeSelf:
+		return connID == ev.GameID
+	default:
+		d.conf.Logger.Errorf("Unknown event scope %v", scope)
+		return false
+	}
+}
+
 // sendEvent sents out an event and potentially prints an error.
-func (d *TransportServer) sendEvent(stream pb.Discovery_EventsServer, ev *pb.Event) {
+func (d *TransportServer) sendEvent(del *delivery, ev *pb.Event) {
 	d.conf.Logger.Debugw("Broadcasting event", "Event", ev)
-	err := stream.Send(ev)
+	err := del.send(ev)
 	if err != nil {
 		d.conf.Logger.Errorf("Error broadcasting the event %s", ev.Name)
 	}
 }
 
-// forwardFromStream consumes events from the stream and forwards it to the In channel.
-func (d *TransportServer) forwardFromStream(stream pb.Discovery_EventsServer, errCh chan error) {
+// forwardFromStream consumes events from the stream and forwards it to the In channel. The games of the events are
+// recorded in the membership of the stream, if any, and claimed for the namespace of the stream. Acknowledgements are
+// passed to the delivery of the stream instead. Events replayed by the client with the given ID that have been received
+// already are dropped, as are events of games of other namespaces. The sequenced events are confirmed to
+// the client once they have been handled, whether forwarded or dropped.
+func (d *TransportServer) forwardFromStream(stream pb.Discovery_EventsServer, connID, clientID, ns string, errCh chan error, games *membership, del *delivery) {
 	ctx := stream.Context()
 	for {
 		select {
@@ -204,7 +374,38 @@ func (d *TransportServer) forwardFromStream(stream pb.Discovery_EventsServer, er
 				return
 			}
 			d.conf.Logger.Debugw("Received event from stream", "Event", ev)
+			if ev.Ack > 0 {
+				del.ack(ev.Ack)
+			}
+			if ev.Name == Ack {
+				continue
+			}
+			if !d.received.receive(clientID, ev.Seq) {
+				d.conf.Logger.Debugw("Dropping event received already", ConnID, connID, "Event", ev)
+				d.confirm(del, ev)
+				continue
+			}
+			if !d.namespaces.claim(ev.GameID, ns) {
+				d.conf.Logger.Warnw("Dropping event of a game of another namespace", ConnID, connID, EventNamespace, ns, "Event", ev)
+				d.confirm(del, ev)
+				continue
+			}
+			games.join(ev.GameID)
 			d.conf.In <- ev
+			d.confirm(del, ev)
 		}
 	}
 }
+
+// confirm acknowledges the sequenced event to the client, so that the client stops retaining it for replay. Events are
+// only acknowledged to clients that acknowledge the events they receive themselves, as other clients do not expect
+// acknowledgements.
+func (d *TransportServer) confirm(del *delivery, ev *pb.Event) {
+	if ev.Seq == 0 || !del.acknowledged {
+		return
+	}
+	if err := del.send(&pb.Event{Name: Ack, GameID: ev.GameID, Ack: ev.Seq}); err != nil {
+		// The broken stream is detected when receiving the next event.
+		d.conf.Logger.Warnf("Error acknowledging event %d: %v", ev.Seq, err)
+	}
+}
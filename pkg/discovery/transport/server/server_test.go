@@ -8,6 +8,7 @@ import (
 	"context"
 
 	"net"
+	"strconv"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -18,11 +19,14 @@ import (
 	. "github.com/onsi/gomega"
 
 	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
+	"github.com/carbynestack/ephemeral/pkg/discovery/transport/security"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 var _ = Describe("Server", func() {
@@ -50,7 +54,9 @@ var _ = Describe("Server", func() {
 				Port:   port,
 				Logger: logger,
 			}
-			tr = NewTransportServer(conf)
+			var err error
+			tr, err = NewTransportServer(conf)
+			Expect(err).NotTo(HaveOccurred())
 			stopCh = make(chan struct{})
 		})
 		AfterEach(func() {
@@ -160,23 +166,26 @@ var _ = Describe("Server", func() {
 					Expect(err).To(BeNil())
 
 					time.Sleep(200 * time.Millisecond)
-					sendEvents(stream1, game42)
-					sendEvents(stream2, game43)
+					// The slave takes part in game 42 only.
+					sendEvents(stream0, game42)
 					ev, err := stream0.Recv()
 					Expect(err).To(BeNil())
-					Expect(ev.GameID).NotTo(Equal(""))
-
-					ev, err = stream0.Recv()
-					Expect(err).To(BeNil())
-					Expect(ev.GameID).NotTo(Equal(""))
+					Expect(ev.GameID).To(Equal(game42))
 
 					ev, err = stream1.Recv()
 					Expect(err).To(BeNil())
 					Expect(ev.GameID).To(Equal(game42))
 
+					sendEvents(stream2, game43)
 					ev, err = stream2.Recv()
 					Expect(err).To(BeNil())
 					Expect(ev.GameID).To(Equal(game43))
+
+					// The event of game 43 is not routed to the slave, hence the next one it receives is of game 42.
+					sendEvents(stream1, game42)
+					ev, err = stream0.Recv()
+					Expect(err).To(BeNil())
+					Expect(ev.GameID).To(Equal(game42))
 				})
 			})
 			Context("one of the clients disconnects", func() {
@@ -221,6 +230,127 @@ var _ = Describe("Server", func() {
 				})
 			})
 		})
+		Context("when a client numbers its events", func() {
+			It("acknowledges them, including the ones received already", func() {
+				game42 := "42"
+				go echoServer(tr, stopCh)
+				go tr.Run(cb)
+				time.Sleep(100 * time.Millisecond)
+				conn, _ = grpc.Dial("localhost:"+port, grpc.WithInsecure())
+				client := pb.NewDiscoveryClient(conn)
+				ctx, _ := getContext(game42, EventScopeSelf, deadline)
+				stream, err := client.Events(metadata.AppendToOutgoingContext(ctx, LastSeq, "0"))
+				Expect(err).To(BeNil())
+				Expect(stream.Send(&pb.Event{GameID: game42, Seq: 7})).To(Succeed())
+				var acks []uint64
+				for i := 0; i < 2; i++ {
+					ev, err := stream.Recv()
+					Expect(err).To(BeNil())
+					if ev.Name == Ack {
+						acks = append(acks, ev.Ack)
+					}
+				}
+				Expect(acks).To(Equal([]uint64{7}))
+				// The replayed event is dropped but acknowledged again.
+				Expect(stream.Send(&pb.Event{GameID: game42, Seq: 7})).To(Succeed())
+				ev, err := stream.Recv()
+				Expect(err).To(BeNil())
+				Expect(ev).To(Equal(&pb.Event{Name: Ack, GameID: game42, Ack: 7}))
+			})
+			It("numbers the events per client rather than per connection ID", func() {
+				game42 := "42"
+				go echoServer(tr, stopCh)
+				go tr.Run(cb)
+				time.Sleep(100 * time.Millisecond)
+				conn, _ = grpc.Dial("localhost:"+port, grpc.WithInsecure())
+				client := pb.NewDiscoveryClient(conn)
+				ctx, _ := getContext(game42, EventScopeSelf, deadline)
+				// The players of a game share the connection ID of the game.
+				for _, sent := range []struct {
+					clientID string
+					seq      uint64
+				}{{"a", 7}, {"b", 3}} {
+					stream, err := client.Events(metadata.AppendToOutgoingContext(ctx, LastSeq, "0", ClientID, sent.clientID))
+					Expect(err).To(BeNil())
+					Expect(stream.Send(&pb.Event{GameID: game42, Seq: sent.seq})).To(Succeed())
+					// The event is echoed along with its acknowledgement unless dropped as received already.
+					for i := 0; i < 2; i++ {
+						_, err := stream.Recv()
+						Expect(err).To(BeNil())
+					}
+				}
+			})
+		})
+		Context("when a client reconnects", func() {
+			It("replays the events it missed", func() {
+				game42 := "42"
+				go echoServer(tr, stopCh)
+				go tr.Run(cb)
+				time.Sleep(100 * time.Millisecond)
+				conn, _ = grpc.Dial("localhost:"+port, grpc.WithInsecure())
+				client := pb.NewDiscoveryClient(conn)
+				ctx, _ := getContext(game42, EventScopeSelf, deadline)
+				stream1, err := client.Events(metadata.AppendToOutgoingContext(ctx, LastSeq, "0"))
+				Expect(err).To(BeNil())
+				sendEvents(stream1, game42)
+				ev, err := stream1.Recv()
+				Expect(err).To(BeNil())
+				received := ev.Seq
+				Expect(received).To(BeNumerically(">", 0))
+				Expect(stream1.CloseSend()).To(Succeed())
+
+				// The events are broadcast while the first client is gone.
+				stream2, err := client.Events(ctx)
+				Expect(err).To(BeNil())
+				sendEvents(stream2, game42, game42)
+				for i := 0; i < 2; i++ {
+					_, err = stream2.Recv()
+					Expect(err).To(BeNil())
+				}
+
+				stream3, err := client.Events(metadata.AppendToOutgoingContext(ctx, LastSeq, strconv.FormatUint(received, 10)))
+				Expect(err).To(BeNil())
+				for i := uint64(1); i <= 2; i++ {
+					ev, err = stream3.Recv()
+					Expect(err).To(BeNil())
+					Expect(ev.Seq).To(Equal(received + i))
+				}
+			})
+			It("replays the events of its games to a slave", func() {
+				game42 := "42"
+				go echoServer(tr, stopCh)
+				go tr.Run(cb)
+				time.Sleep(100 * time.Millisecond)
+				conn, _ = grpc.Dial("localhost:"+port, grpc.WithInsecure())
+				client := pb.NewDiscoveryClient(conn)
+				slaveCtx, _ := getContext("slave", EventScopeAll, deadline)
+				stream1, err := client.Events(metadata.AppendToOutgoingContext(slaveCtx, LastSeq, "0"))
+				Expect(err).To(BeNil())
+				sendEvents(stream1, game42)
+				ev, err := stream1.Recv()
+				Expect(err).To(BeNil())
+				received := ev.Seq
+				Expect(stream1.CloseSend()).To(Succeed())
+
+				// The events of the game are broadcast while the slave is gone.
+				ctx, _ := getContext(game42, EventScopeSelf, deadline)
+				stream2, err := client.Events(ctx)
+				Expect(err).To(BeNil())
+				sendEvents(stream2, game42, game42)
+				for i := 0; i < 2; i++ {
+					_, err = stream2.Recv()
+					Expect(err).To(BeNil())
+				}
+
+				stream3, err := client.Events(metadata.AppendToOutgoingContext(slaveCtx, LastSeq, strconv.FormatUint(received, 10)))
+				Expect(err).To(BeNil())
+				for i := uint64(1); i <= 2; i++ {
+					ev, err = stream3.Recv()
+					Expect(err).To(BeNil())
+					Expect(ev.Seq).To(Equal(received + i))
+				}
+			})
+		})
 	})
 
 	Context("when extracting stream metadata", func() {
@@ -286,7 +416,7 @@ var _ = Describe("Server", func() {
 				errCh := make(chan error, 1)
 				ts := TransportServer{}
 				cancel()
-				ts.forwardFromStream(st, errCh)
+				ts.forwardFromStream(st, "abc", "abc", "", errCh, nil, nil)
 				err := <-errCh
 				Expect(err.Error()).To(Equal("context canceled"))
 			})
@@ -305,7 +435,7 @@ var _ = Describe("Server", func() {
 				}
 				st := &FakeStream{}
 
-				f := ts.forwardToStream(st, invalidScope, "abc")
+				f := ts.forwardToStream(newDelivery(st, false, conf.Logger), invalidScope, "abc", "", nil)
 				ev := &pb.Event{}
 				f(ev)
 				Expect(recorded.Len()).To(Equal(1))
@@ -313,6 +443,125 @@ var _ = Describe("Server", func() {
 			})
 		})
 	})
+	Context("when forwarding events to a slave", func() {
+		It("forwards only the events of the games the slave takes part in", func() {
+			ts := TransportServer{
+				conf: &TransportConfig{Logger: zap.NewNop().Sugar()},
+			}
+			st := &FakeStream{sendCh: make(chan struct{}, 10)}
+			games := newMembership(0)
+			games.join("42")
+			f := ts.forwardToStream(newDelivery(st, false, ts.conf.Logger), EventScopeAll, "slave", "", games)
+			f(&pb.Event{Name: PlayersReady, GameID: "43"})
+			Expect(st.sendCh).To(BeEmpty())
+			f(&pb.Event{Name: PlayersReady, GameID: "42"})
+			Expect(st.sendCh).To(HaveLen(1))
+		})
+		It("keeps the membership of a slave across reconnects", func() {
+			ts := TransportServer{}
+			games, release := ts.memberships.acquire("vcp-1", "slave-0", 0)
+			games.join("42")
+			release()
+			reconnected, _ := ts.memberships.acquire("vcp-1", "slave-0", 0)
+			Expect(reconnected).To(BeIdenticalTo(games))
+			other, _ := ts.memberships.acquire("vcp-1", "slave-1", 0)
+			Expect(other.routes(PlayersReady, "42")).To(BeFalse())
+			otherNamespace, _ := ts.memberships.acquire("vcp-2", "slave-0", 0)
+			Expect(otherNamespace.routes(PlayersReady, "42")).To(BeFalse())
+		})
+		It("drops the membership of a slave taking part in no game once it disconnects", func() {
+			ts := TransportServer{}
+			_, release := ts.memberships.acquire("vcp-1", "slave-0", 0)
+			Expect(ts.memberships.byConn).To(HaveLen(1))
+			release()
+			Expect(ts.memberships.byConn).To(BeEmpty())
+		})
+		It("drops the membership of a slave which does not reconnect within the TTL", func() {
+			ts := TransportServer{}
+			now := time.Now()
+			games, release := ts.memberships.acquire("vcp-1", "slave-0", time.Minute)
+			games.now = func() time.Time { return now }
+			games.join("42")
+			release()
+			Expect(ts.memberships.byConn).To(HaveLen(1))
+			now = now.Add(2 * time.Minute)
+			ts.memberships.acquire("vcp-1", "slave-1", time.Minute)
+			ts.memberships.prune(now)
+			Expect(ts.memberships.byConn).To(HaveLen(1))
+			Expect(ts.memberships.byConn).To(HaveKey("vcp-1/slave-1"))
+		})
+		It("forgets the game once it has finished", func() {
+			games := newMembership(0)
+			games.join("42")
+			Expect(games.routes(GameSuccess, "42")).To(BeTrue())
+			Expect(games.routes(PlayersReady, "42")).To(BeFalse())
+		})
+		It("forgets the game once it has timed out or was aborted", func() {
+			games := newMembership(0)
+			games.join("42")
+			games.join("43")
+			Expect(games.routes(StateTimeoutError, "42")).To(BeTrue())
+			Expect(games.routes(PlayersReady, "42")).To(BeFalse())
+			Expect(games.routes(ParamsMismatch, "43")).To(BeTrue())
+			Expect(games.routes(PlayersReady, "43")).To(BeFalse())
+		})
+		It("forgets the games no event was seen of for longer than the TTL", func() {
+			now := time.Now()
+			games := newMembership(time.Minute)
+			games.now = func() time.Time { return now }
+			games.join("42")
+			games.join("43")
+			now = now.Add(30 * time.Second)
+			Expect(games.routes(PlayersReady, "42")).To(BeTrue())
+			now = now.Add(45 * time.Second)
+			Expect(games.routes(PlayersReady, "42")).To(BeTrue())
+			Expect(games.routes(PlayersReady, "43")).To(BeFalse())
+			now = now.Add(2 * time.Minute)
+			games.join("44")
+			Expect(games.games).To(HaveLen(1))
+		})
+	})
+	Context("when clients connect with event namespaces", func() {
+		var ts *TransportServer
+		BeforeEach(func() {
+			ts = &TransportServer{
+				conf: &TransportConfig{Logger: zap.NewNop().Sugar(), Namespaces: []string{"vcp-1", "vcp-2"}},
+			}
+		})
+		It("forwards the events of a game only to the slaves of its namespace", func() {
+			Expect(ts.namespaces.claim("42", "vcp-1")).To(BeTrue())
+			st1 := &FakeStream{sendCh: make(chan struct{}, 10)}
+			games1 := newMembership(0)
+			games1.join("42")
+			st2 := &FakeStream{sendCh: make(chan struct{}, 10)}
+			games2 := newMembership(0)
+			games2.join("42")
+			ts.forwardToStream(newDelivery(st1, false, ts.conf.Logger), EventScopeAll, "slave", "vcp-1", games1)(&pb.Event{Name: PlayersReady, GameID: "42"})
+			ts.forwardToStream(newDelivery(st2, false, ts.conf.Logger), EventScopeAll, "slave", "vcp-2", games2)(&pb.Event{Name: PlayersReady, GameID: "42"})
+			Expect(st1.sendCh).To(HaveLen(1))
+			Expect(st2.sendCh).To(BeEmpty())
+		})
+		It("does not let other namespaces take part in a game", func() {
+			Expect(ts.namespaces.claim("42", "vcp-1")).To(BeTrue())
+			Expect(ts.namespaces.claim("42", "vcp-1")).To(BeTrue())
+			Expect(ts.namespaces.claim("42", "vcp-2")).To(BeFalse())
+			Expect(ts.namespaces.of("42")).To(Equal("vcp-1"))
+			Expect(ts.namespaces.of("43")).To(BeEmpty())
+		})
+		It("rejects namespaces which are not served", func() {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(EventNamespace, "vcp-1"))
+			Expect(ts.extractNamespace(ctx)).To(Equal("vcp-1"))
+			ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(EventNamespace, "vcp-3"))
+			_, err := ts.extractNamespace(ctx)
+			Expect(err).To(HaveOccurred())
+			_, err = ts.extractNamespace(context.Background())
+			Expect(err).To(HaveOccurred())
+		})
+		It("accepts any namespace if none are configured", func() {
+			ts.conf.Namespaces = nil
+			Expect(ts.extractNamespace(context.Background())).To(BeEmpty())
+		})
+	})
 	Context("when the events are sent back to the stream", func() {
 		Context("when there is an error", func() {
 			It("prints out an error message", func() {
@@ -324,7 +573,7 @@ var _ = Describe("Server", func() {
 					conf: conf,
 				}
 				st := &BrokenStream{}
-				ts.sendEvent(st, &pb.Event{Name: "abc"})
+				ts.sendEvent(newDelivery(st, false, conf.Logger), &pb.Event{Name: "abc"})
 				Expect(recorded.Len()).To(Equal(1))
 				Expect(recorded.AllUntimed()[0].Entry.Message).To(Equal("Error broadcasting the event abc"))
 			})
@@ -360,6 +609,75 @@ var _ = Describe("Server", func() {
 		})
 	})
 
+	Context("when clients must authenticate", func() {
+		var (
+			tr     *TransportServer
+			conn   *grpc.ClientConn
+			port   = "30001"
+			stopCh chan struct{}
+		)
+		BeforeEach(func() {
+			var err error
+			tr, err = NewTransportServer(&TransportConfig{
+				In:     make(chan *pb.Event),
+				Out:    make(chan *pb.Event),
+				ErrCh:  make(chan error),
+				Port:   port,
+				Auth:   &AuthConfig{Tokens: []string{"s3cret"}},
+				Logger: zap.NewNop().Sugar(),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			stopCh = make(chan struct{})
+			go echoServer(tr, stopCh)
+			go tr.Run(func() {})
+			time.Sleep(100 * time.Millisecond)
+			conn, err = grpc.Dial("localhost:"+port, grpc.WithInsecure())
+			Expect(err).NotTo(HaveOccurred())
+		})
+		AfterEach(func() {
+			conn.Close()
+			tr.Stop()
+			stopCh <- struct{}{}
+		})
+		It("serves clients presenting a valid token", func() {
+			ctx, cancel := getContext("42", EventScopeSelf, 10*time.Second)
+			defer cancel()
+			ctx = metadata.AppendToOutgoingContext(ctx, security.AuthorizationHeader, "Bearer s3cret")
+			stream, err := pb.NewDiscoveryClient(conn).Events(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			sendEvents(stream, "42")
+			ev, err := stream.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ev.GameID).To(Equal("42"))
+		})
+		It("rejects clients presenting an invalid token", func() {
+			ctx, cancel := getContext("42", EventScopeSelf, 10*time.Second)
+			defer cancel()
+			ctx = metadata.AppendToOutgoingContext(ctx, security.AuthorizationHeader, "Bearer guess")
+			stream, err := pb.NewDiscoveryClient(conn).Events(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = stream.Recv()
+			Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+		})
+		It("rejects clients presenting no token", func() {
+			ctx, cancel := getContext("42", EventScopeSelf, 10*time.Second)
+			defer cancel()
+			stream, err := pb.NewDiscoveryClient(conn).Events(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = stream.Recv()
+			Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+		})
+	})
+	Context("when the authentication is misconfigured", func() {
+		It("fails to create the server", func() {
+			_, err := NewTransportServer(&TransportConfig{
+				Auth:   &AuthConfig{},
+				Logger: zap.NewNop().Sugar(),
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Context("when net listener fails", func() {
 		It("returns an error", func() {
 			p := "7777"
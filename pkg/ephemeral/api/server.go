@@ -0,0 +1,410 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package api provides the gRPC API of ephemeral. It offers typed clients, deadline propagation and streamed results
+// in addition to the JSON/HTTP API.
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/ephemeral"
+	"github.com/carbynestack/ephemeral/pkg/types"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// NewServer returns the gRPC API backed by the given HTTP handler chain of ephemeral.
+func NewServer(handler http.Handler) *Server {
+	return &Server{handler: handler}
+}
+
+// Server serves the gRPC API by translating the calls into requests to the HTTP handler chain, so that both APIs share
+// the validation, the quotas and the lifecycle of the games.
+type Server struct {
+	handler http.Handler
+}
+
+// Compile compiles the program of the activation and reports the compiler output.
+func (s *Server) Compile(ctx context.Context, req *CompileRequest) (*CompileResponse, error) {
+	httpReq, err := newRequest(ctx, http.MethodPost, "/compile", req.GetActivation())
+	if err != nil {
+		return nil, err
+	}
+	code, body := s.serve(httpReq)
+	defer body.Close()
+	// Programs failing to compile are reported in the response.
+	if code != http.StatusOK && code != http.StatusUnprocessableEntity {
+		return nil, statusError(code, body)
+	}
+	var resp CompileResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, status.Errorf(codes.Internal, "error decoding the compilation report: %s", err)
+	}
+	return &resp, nil
+}
+
+// Activate runs the game of the activation and sends the output values to the client as they are read from the MPC
+// runtime. A truncation warning is sent along with the last response.
+func (s *Server) Activate(req *ActivateRequest, stream Ephemeral_ActivateServer) error {
+	return s.activate(stream.Context(), req, stream.Send)
+}
+
+// Games runs the games requested on the stream one after another. The output values of each game are sent as they are
+// read, followed by a response marking the game as done along with the status it finished with. A game failing or
+// being cancelled does not close the stream, the stream is closed once the client has closed its side and the queued
+// games have finished.
+func (s *Server) Games(stream Ephemeral_GamesServer) error {
+	ctx := stream.Context()
+	requests := make(chan *GameRequest)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case requests <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var queue []*ActivateRequest
+	var cancel context.CancelFunc
+	done := make(chan error, 1)
+	closed := false
+	for {
+		if cancel == nil && len(queue) > 0 {
+			var gameCtx context.Context
+			gameCtx, cancel = context.WithCancel(ctx)
+			go func(req *ActivateRequest) {
+				done <- s.runGame(gameCtx, req, stream.Send)
+			}(queue[0])
+			queue = queue[1:]
+		}
+		if cancel == nil && closed {
+			return nil
+		}
+		select {
+		case req := <-requests:
+			if req.GetCancel() && cancel != nil {
+				cancel()
+			}
+			if act := req.GetActivate(); act != nil {
+				queue = append(queue, act)
+			}
+		case err := <-recvErr:
+			if err != io.EOF {
+				if cancel != nil {
+					cancel()
+					<-done
+				}
+				return err
+			}
+			closed = true
+		case err := <-done:
+			cancel()
+			cancel = nil
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Status returns the outcome of a game whose result is delivered in the background.
+func (s *Server) Status(ctx context.Context, req *StatusRequest) (*StatusResponse, error) {
+	httpReq, err := http.NewRequest(http.MethodGet, ephemeral.ExecutionsPath+req.GetGameID(), http.NoBody)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	httpReq = withAuthorization(ctx, httpReq.WithContext(ctx))
+	code, body := s.serve(httpReq)
+	defer body.Close()
+	if code != http.StatusOK {
+		return nil, statusError(code, body)
+	}
+	var resp StatusResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, status.Errorf(codes.Internal, "error decoding the execution: %s", err)
+	}
+	return &resp, nil
+}
+
+// activate runs the game of the activation and passes the output values to send as they are read from the MPC runtime.
+func (s *Server) activate(ctx context.Context, req *ActivateRequest, send func(*ActivateResponse) error) error {
+	path := "/"
+	if req.GetCompile() {
+		path = "/?compile=true"
+	}
+	httpReq, err := newRequest(ctx, http.MethodPost, path, req.GetActivation())
+	if err != nil {
+		return err
+	}
+	code, body := s.serve(httpReq)
+	defer body.Close()
+	if code != http.StatusOK {
+		return statusError(code, body)
+	}
+	return streamResult(body, send)
+}
+
+// runGame runs a game requested on the Games stream and sends its output values followed by the response marking it as
+// done. Only errors sending to the client are returned, the game failing is reported in the last response.
+func (s *Server) runGame(ctx context.Context, req *ActivateRequest, send func(*GameResponse) error) error {
+	gameID := req.GetActivation().GetGameID()
+	var sendErr error
+	err := s.activate(ctx, req, func(resp *ActivateResponse) error {
+		sendErr = send(&GameResponse{GameID: gameID, Response: resp})
+		return sendErr
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	st := status.Convert(err)
+	return send(&GameResponse{GameID: gameID, Done: true, Code: int32(st.Code()), Error: st.Message()})
+}
+
+// serve runs the request against the HTTP handler chain. It returns the status code of the response and its body,
+// which is read while the handler is still writing it. Closing the body discards the remaining output.
+func (s *Server) serve(req *http.Request) (int, io.ReadCloser) {
+	pr, pw := io.Pipe()
+	w := &pipeWriter{
+		header: http.Header{},
+		pipe:   pw,
+		status: make(chan int, 1),
+	}
+	go func() {
+		defer pw.Close()
+		s.handler.ServeHTTP(w, req)
+		// Handlers responding without a body do not necessarily write the header.
+		w.WriteHeader(http.StatusOK)
+	}()
+	return <-w.status, pr
+}
+
+// newRequest returns an HTTP request carrying the activation as JSON. The deadline of the call is passed on as the time
+// budget of the activation unless the activation defines a shorter one, and the activation is cancelled along with the
+// call.
+func newRequest(ctx context.Context, method, path string, act *Activation) (*http.Request, error) {
+	a, err := toActivation(act)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, status.Error(codes.DeadlineExceeded, "the deadline of the call has expired")
+		}
+		a.TimeBudget = remaining.String()
+	}
+	body, err := json.Marshal(a)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "error encoding the activation: %s", err)
+	}
+	req, err := http.NewRequest(method, path, bytes.NewReader(body))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	req.Header.Set("Content-Type", ephemeral.ContentTypeJSON)
+	return withAuthorization(ctx, req.WithContext(ephemeral.WithCancelOnDisconnect(ctx))), nil
+}
+
+// withAuthorization passes the authorization metadata of the call on to the request.
+func withAuthorization(ctx context.Context, req *http.Request) *http.Request {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if auth := md.Get("authorization"); len(auth) > 0 {
+			req.Header.Set("Authorization", auth[0])
+		}
+	}
+	return req
+}
+
+// toActivation converts the activation of the gRPC API into the one of the HTTP API. Activations carrying fields
+// unknown to the service are rejected, as running them without would change the outcome of the game unnoticed.
+func toActivation(act *Activation) (*types.Activation, error) {
+	if hasUnknownFields(act) {
+		return nil, status.Error(codes.InvalidArgument, "the activation contains fields unknown to the service")
+	}
+	a := &types.Activation{
+		AmphoraParams: act.GetAmphoraParams(),
+		SecretParams:  act.GetSecretParams(),
+		GameID:        act.GetGameID(),
+		Code:          act.GetCode(),
+		Output:        types.OutputConfig{Type: act.GetOutput().GetType()},
+		PodAffinity:   act.GetPodAffinity(),
+		SessionID:     act.GetSessionID(),
+		Protocol:      act.GetProtocol(),
+		Encoding:      act.GetEncoding(),
+		Signature:     act.GetSignature(),
+		Seed:          act.GetSeed(),
+		Backend:       act.GetBackend(),
+		Labels:        act.GetLabels(),
+	}
+	if opts := act.GetCompilerOptions(); opts != nil {
+		a.CompilerOptions = &types.CompilerOptions{
+			OptimizationLevel: int(opts.GetOptimizationLevel()),
+			BitLength:         int(opts.GetBitLength()),
+			Budget:            int(opts.GetBudget()),
+			Prime:             opts.GetPrime(),
+		}
+	}
+	return a, nil
+}
+
+// hasUnknownFields returns true if the activation or one of its messages contains fields unknown to the service.
+func hasUnknownFields(act *Activation) bool {
+	if act == nil {
+		return false
+	}
+	if out := act.GetOutput(); out != nil && len(out.XXX_unrecognized) > 0 {
+		return true
+	}
+	if opts := act.GetCompilerOptions(); opts != nil && len(opts.XXX_unrecognized) > 0 {
+		return true
+	}
+	return len(act.XXX_unrecognized) > 0
+}
+
+// streamResult decodes the JSON encoded result while it is written and sends each output value to the client as soon
+// as it has been read.
+func streamResult(body io.Reader, send func(*ActivateResponse) error) error {
+	dec := json.NewDecoder(body)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	var warning *TruncationWarning
+	var failure string
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return status.Errorf(codes.Internal, "error decoding the result: %s", err)
+		}
+		switch key {
+		case "response":
+			if err := expectDelim(dec, '['); err != nil {
+				return err
+			}
+			for dec.More() {
+				var value string
+				if err := dec.Decode(&value); err != nil {
+					return status.Errorf(codes.Internal, "error decoding the result: %s", err)
+				}
+				if err := send(&ActivateResponse{Values: []string{value}}); err != nil {
+					return err
+				}
+			}
+			if err := expectDelim(dec, ']'); err != nil {
+				return err
+			}
+		case "warning":
+			err = dec.Decode(&warning)
+		case "error":
+			err = dec.Decode(&failure)
+		default:
+			var ignored json.RawMessage
+			err = dec.Decode(&ignored)
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "error decoding the result: %s", err)
+		}
+	}
+	if failure != "" {
+		return status.Error(codes.Internal, failure)
+	}
+	if warning != nil {
+		return send(&ActivateResponse{Warning: warning})
+	}
+	return nil
+}
+
+// expectDelim reads the next token and returns an error if it is not the given delimiter.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return status.Errorf(codes.Internal, "error decoding the result: %s", err)
+	}
+	if t != delim {
+		return status.Errorf(codes.Internal, "error decoding the result: expected %s, got %v", delim, t)
+	}
+	return nil
+}
+
+// statusError converts an error response of the HTTP API into a gRPC status error.
+func statusError(code int, body io.Reader) error {
+	msg, _ := ioutil.ReadAll(body)
+	return status.Error(grpcCode(code), strings.TrimSpace(string(msg)))
+}
+
+// grpcCode returns the gRPC status code corresponding to the HTTP status code.
+func grpcCode(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusUnsupportedMediaType:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusConflict:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout, http.StatusRequestTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusInternalServerError:
+		return codes.Internal
+	default:
+		return codes.Unknown
+	}
+}
+
+// pipeWriter is an http.ResponseWriter passing the body through a pipe. The status code is reported once the header is
+// written.
+type pipeWriter struct {
+	header http.Header
+	pipe   *io.PipeWriter
+	status chan int
+	once   sync.Once
+}
+
+// Header returns the header of the response.
+func (w *pipeWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader reports the status code, subsequent calls are ignored.
+func (w *pipeWriter) WriteHeader(code int) {
+	w.once.Do(func() {
+		w.status <- code
+	})
+}
+
+// Write writes the body to the pipe, it blocks until the body is read or the reader is closed.
+func (w *pipeWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.pipe.Write(b)
+}
+
+// Flush is a no-op, the body is passed on unbuffered.
+func (w *pipeWriter) Flush() {}
@@ -0,0 +1,239 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/types"
+
+	"github.com/golang/protobuf/proto"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// FakeActivateServer records the responses sent to the client.
+type FakeActivateServer struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses []*ActivateResponse
+}
+
+func (f *FakeActivateServer) Send(resp *ActivateResponse) error {
+	f.responses = append(f.responses, resp)
+	return nil
+}
+
+func (f *FakeActivateServer) Context() context.Context {
+	return f.ctx
+}
+
+// FakeGamesServer passes the requests of the client to the server and records the responses sent to the client.
+type FakeGamesServer struct {
+	grpc.ServerStream
+	ctx       context.Context
+	requests  chan *GameRequest
+	mux       sync.Mutex
+	responses []*GameResponse
+}
+
+func (f *FakeGamesServer) Send(resp *GameResponse) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.responses = append(f.responses, resp)
+	return nil
+}
+
+func (f *FakeGamesServer) Recv() (*GameRequest, error) {
+	req, ok := <-f.requests
+	if !ok {
+		return nil, io.EOF
+	}
+	return req, nil
+}
+
+func (f *FakeGamesServer) Context() context.Context {
+	return f.ctx
+}
+
+var _ = Describe("Server", func() {
+	var (
+		req        *http.Request
+		act        types.Activation
+		httpStatus int
+		body       string
+		server     *Server
+		ctx        context.Context
+	)
+	BeforeEach(func() {
+		req = nil
+		act = types.Activation{}
+		httpStatus = http.StatusOK
+		body = ""
+		server = NewServer(http.HandlerFunc(func(writer http.ResponseWriter, r *http.Request) {
+			req = r
+			b, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(b, &act)
+			writer.WriteHeader(httpStatus)
+			writer.Write([]byte(body))
+		}))
+		ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer token"))
+	})
+	Context("when activating a game", func() {
+		var stream *FakeActivateServer
+		BeforeEach(func() {
+			stream = &FakeActivateServer{ctx: ctx}
+		})
+		It("sends each output value to the client", func() {
+			body = `{"response":["a","b"],"warning":{"truncated":true,"reason":"limit","offset":2}}`
+			err := server.Activate(&ActivateRequest{Activation: &Activation{GameID: "71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4"}}, stream)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stream.responses).To(HaveLen(3))
+			Expect(stream.responses[0].Values).To(Equal([]string{"a"}))
+			Expect(stream.responses[1].Values).To(Equal([]string{"b"}))
+			Expect(stream.responses[2].Warning).To(Equal(&TruncationWarning{Truncated: true, Reason: "limit", Offset: 2}))
+			Expect(act.GameID).To(Equal("71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4"))
+			Expect(req.Header.Get("Authorization")).To(Equal("Bearer token"))
+			Expect(req.Header.Get("Content-Type")).To(Equal("application/json"))
+		})
+		It("passes the compiler options and the labels on", func() {
+			body = `{"response":[]}`
+			err := server.Activate(&ActivateRequest{Activation: &Activation{
+				CompilerOptions: &CompilerOptions{OptimizationLevel: 2, BitLength: 32, Prime: "p"},
+				Labels:          map[string]string{"team": "a"},
+			}}, stream)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(act.CompilerOptions).To(Equal(&types.CompilerOptions{OptimizationLevel: 2, BitLength: 32, Prime: "p"}))
+			Expect(act.Labels).To(Equal(map[string]string{"team": "a"}))
+		})
+		It("rejects activations carrying fields unknown to the service", func() {
+			var a Activation
+			// Field 99 is not defined by the API.
+			Expect(proto.Unmarshal([]byte{0x98, 0x06, 0x01}, &a)).To(Succeed())
+			err := server.Activate(&ActivateRequest{Activation: &a}, stream)
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+			Expect(req).To(BeNil())
+		})
+		It("compiles the program if requested", func() {
+			body = `{"response":[]}`
+			Expect(server.Activate(&ActivateRequest{Activation: &Activation{}, Compile: true}, stream)).To(Succeed())
+			Expect(req.URL.Query().Get("compile")).To(Equal("true"))
+		})
+		It("passes the deadline of the call on as time budget", func() {
+			body = `{"response":[]}`
+			deadlineCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			defer cancel()
+			stream.ctx = deadlineCtx
+			Expect(server.Activate(&ActivateRequest{Activation: &Activation{}}, stream)).To(Succeed())
+			budget, err := time.ParseDuration(act.TimeBudget)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(budget).To(BeNumerically("~", time.Minute, time.Second))
+		})
+		It("fails if the computation fails after values have been streamed", func() {
+			body = `{"response":["a"],"error":"timeout during activation procedure"}`
+			err := server.Activate(&ActivateRequest{Activation: &Activation{}}, stream)
+			Expect(stream.responses).To(HaveLen(1))
+			Expect(status.Code(err)).To(Equal(codes.Internal))
+			Expect(status.Convert(err).Message()).To(Equal("timeout during activation procedure"))
+		})
+		It("converts error responses into status errors", func() {
+			httpStatus = http.StatusBadRequest
+			body = "GameID 1 is not a valid UUID"
+			err := server.Activate(&ActivateRequest{Activation: &Activation{GameID: "1"}}, stream)
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+			Expect(status.Convert(err).Message()).To(Equal("GameID 1 is not a valid UUID"))
+		})
+	})
+	Context("when running games on a stream", func() {
+		var stream *FakeGamesServer
+		BeforeEach(func() {
+			stream = &FakeGamesServer{ctx: ctx, requests: make(chan *GameRequest, 3)}
+		})
+		It("runs the games one after another and marks each one as done", func() {
+			body = `{"response":["a"]}`
+			stream.requests <- &GameRequest{Activate: &ActivateRequest{Activation: &Activation{GameID: "g1"}}}
+			stream.requests <- &GameRequest{Activate: &ActivateRequest{Activation: &Activation{GameID: "g2"}}}
+			close(stream.requests)
+			Expect(server.Games(stream)).To(Succeed())
+			Expect(stream.responses).To(HaveLen(4))
+			Expect(stream.responses[0].GameID).To(Equal("g1"))
+			Expect(stream.responses[0].Response.Values).To(Equal([]string{"a"}))
+			Expect(stream.responses[1]).To(Equal(&GameResponse{GameID: "g1", Done: true}))
+			Expect(stream.responses[2].GameID).To(Equal("g2"))
+			Expect(stream.responses[3]).To(Equal(&GameResponse{GameID: "g2", Done: true}))
+			Expect(req.Header.Get("Authorization")).To(Equal("Bearer token"))
+		})
+		It("reports failing games without closing the stream", func() {
+			httpStatus = http.StatusBadRequest
+			body = "GameID 1 is not a valid UUID"
+			stream.requests <- &GameRequest{Activate: &ActivateRequest{Activation: &Activation{GameID: "1"}}}
+			stream.requests <- &GameRequest{Activate: &ActivateRequest{Activation: &Activation{GameID: "2"}}}
+			close(stream.requests)
+			Expect(server.Games(stream)).To(Succeed())
+			Expect(stream.responses).To(Equal([]*GameResponse{
+				{GameID: "1", Done: true, Code: int32(codes.InvalidArgument), Error: "GameID 1 is not a valid UUID"},
+				{GameID: "2", Done: true, Code: int32(codes.InvalidArgument), Error: "GameID 1 is not a valid UUID"},
+			}))
+		})
+		It("cancels the running game on request", func() {
+			started := make(chan struct{})
+			server = NewServer(http.HandlerFunc(func(writer http.ResponseWriter, r *http.Request) {
+				close(started)
+				<-r.Context().Done()
+				writer.WriteHeader(http.StatusInternalServerError)
+				writer.Write([]byte("the game has been cancelled"))
+			}))
+			stream.requests <- &GameRequest{Activate: &ActivateRequest{Activation: &Activation{GameID: "g"}}}
+			go func() {
+				<-started
+				stream.requests <- &GameRequest{Cancel: true}
+				close(stream.requests)
+			}()
+			Expect(server.Games(stream)).To(Succeed())
+			Expect(stream.responses).To(Equal([]*GameResponse{
+				{GameID: "g", Done: true, Code: int32(codes.Internal), Error: "the game has been cancelled"},
+			}))
+		})
+	})
+	Context("when compiling a program", func() {
+		It("reports programs failing to compile", func() {
+			httpStatus = http.StatusUnprocessableEntity
+			body = `{"success":false,"stdout":"","stderr":"syntax error","threads":0,"bytecodeSize":0}`
+			resp, err := server.Compile(ctx, &CompileRequest{Activation: &Activation{Code: "x ="}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Success).To(BeFalse())
+			Expect(resp.Stderr).To(Equal("syntax error"))
+			Expect(req.URL.Path).To(Equal("/compile"))
+			Expect(act.Code).To(Equal("x ="))
+		})
+	})
+	Context("when retrieving the status of a game", func() {
+		It("returns the execution", func() {
+			body = `{"gameID":"g","status":"Succeeded","response":["1"]}`
+			resp, err := server.Status(ctx, &StatusRequest{GameID: "g"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Status).To(Equal("Succeeded"))
+			Expect(resp.Response).To(Equal([]string{"1"}))
+			Expect(req.Method).To(Equal(http.MethodGet))
+			Expect(req.URL.Path).To(Equal("/executions/g"))
+		})
+		It("returns NotFound for unknown games", func() {
+			httpStatus = http.StatusNotFound
+			body = "no execution found for game g"
+			_, err := server.Status(ctx, &StatusRequest{GameID: "g"})
+			Expect(status.Code(err)).To(Equal(codes.NotFound))
+		})
+	})
+})
@@ -0,0 +1,83 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"fmt"
+	"sort"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// Names of the backends activations select with Activation.Backend.
+const (
+	// SPDZBackend executes the games with the MPC protocols of MP-SPDZ. It is the default backend.
+	SPDZBackend = "spdz"
+	// SimulatorBackend executes the games in cleartext, see PlaintextSimulator.
+	SimulatorBackend = "simulator"
+)
+
+// Backend executes the games of the activations selecting it once the players of the game are ready.
+type Backend interface {
+	// Activate executes the game of the given context and returns its result.
+	Activate(ctx *CtxConfig) ([]byte, error)
+}
+
+// NewBackends returns a registry of the given default backend, registered as SPDZBackend.
+func NewBackends(spdz Backend) *Backends {
+	return &Backends{backends: map[string]Backend{SPDZBackend: spdz}}
+}
+
+// Backends is the registry of the backends activations select from.
+type Backends struct {
+	backends map[string]Backend
+}
+
+// Register adds the given backend under the given name. It must be called before the games are activated.
+func (b *Backends) Register(name string, backend Backend) {
+	b.backends[name] = backend
+}
+
+// Activate executes the game of the given context with the backend selected by the activation.
+func (b *Backends) Activate(ctx *CtxConfig) ([]byte, error) {
+	backend, ok := b.backends[backendName(ctx.Act)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported backend %s, supported are %v", backendName(ctx.Act), b.Names())
+	}
+	return backend.Activate(ctx)
+}
+
+// Names returns the names of the registered backends in alphabetical order.
+func (b *Backends) Names() []string {
+	var names []string
+	for name := range b.backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// backendName returns the name of the backend selected by the given activation.
+func backendName(act *Activation) string {
+	if act.Backend == "" {
+		return SPDZBackend
+	}
+	return act.Backend
+}
+
+// validateBackend verifies that the backend selected by the given activation is available with the given config.
+func validateBackend(act *Activation, conf *SPDZEngineTypedConfig) error {
+	switch backendName(act) {
+	case SPDZBackend:
+		return nil
+	case SimulatorBackend:
+		if conf == nil || conf.Simulator == nil {
+			return fmt.Errorf("the %s backend is not enabled", SimulatorBackend)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported backend %s", act.Backend)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"context"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// NamedFakeBackend responds with its name.
+type NamedFakeBackend struct {
+	name string
+}
+
+func (b *NamedFakeBackend) Activate(ctx *CtxConfig) ([]byte, error) {
+	return []byte(b.name), nil
+}
+
+var _ = Describe("Backends", func() {
+	var backends *Backends
+	BeforeEach(func() {
+		backends = NewBackends(&NamedFakeBackend{name: SPDZBackend})
+	})
+	It("executes the games with the SPDZ backend by default", func() {
+		res, err := backends.Activate(&CtxConfig{Act: &Activation{}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(res)).To(Equal(SPDZBackend))
+	})
+	It("executes the games with the selected backend", func() {
+		backends.Register(SimulatorBackend, &NamedFakeBackend{name: SimulatorBackend})
+		res, err := backends.Activate(&CtxConfig{Act: &Activation{Backend: SimulatorBackend}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(res)).To(Equal(SimulatorBackend))
+		Expect(backends.Names()).To(Equal([]string{SimulatorBackend, SPDZBackend}))
+	})
+	It("fails to execute the games of an unknown backend", func() {
+		_, err := backends.Activate(&CtxConfig{Act: &Activation{Backend: SimulatorBackend}})
+		Expect(err).To(MatchError("unsupported backend simulator, supported are [spdz]"))
+	})
+	Context("when validating the backend of an activation", func() {
+		It("accepts the simulator only if it is enabled", func() {
+			act := &Activation{Backend: SimulatorBackend}
+			Expect(validateBackend(act, &SPDZEngineTypedConfig{})).To(MatchError("the simulator backend is not enabled"))
+			Expect(validateBackend(act, &SPDZEngineTypedConfig{Simulator: &SimulatorConfig{}})).To(Succeed())
+			Expect(validateBackend(&Activation{}, &SPDZEngineTypedConfig{})).To(Succeed())
+		})
+		It("rejects unknown backends", func() {
+			Expect(validateBackend(&Activation{Backend: "garbled"}, &SPDZEngineTypedConfig{})).To(MatchError("unsupported backend garbled"))
+		})
+	})
+	Context("when emulating a program", func() {
+		It("runs the emulator on the compiled program", func() {
+			s := &SPDZEngine{config: &SPDZEngineTypedConfig{}, baseDir: "/mp-spdz"}
+			p := NewPlaintextSimulator(s, &SimulatorConfig{Args: []string{"-v"}})
+			ctx := &CtxConfig{Act: &Activation{GameID: "game"}, Context: context.TODO()}
+			Expect(p.command(s.layout(ctx))).To(Equal("./emulate.x mpc-program -v"))
+		})
+	})
+})
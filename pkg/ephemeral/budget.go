@@ -0,0 +1,225 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// timeBudgetHeader is the request header carrying the time budget of an activation if it is not set in the body.
+const timeBudgetHeader = "X-Time-Budget"
+
+// maxEstimatedPrograms is the number of programs the durations of past activations are kept for.
+const maxEstimatedPrograms = 1000
+
+// maxDurationSamples is the number of recent activations of a program the expected duration is derived from.
+const maxDurationSamples = 100
+
+// timeBudget returns the time budget of the activation, taken from the activation or else from the request header.
+// Returns zero if no budget is given.
+func timeBudget(act *Activation, req *http.Request) (time.Duration, error) {
+	value := act.TimeBudget
+	if value == "" {
+		value = req.Header.Get(timeBudgetHeader)
+	}
+	if value == "" {
+		return 0, nil
+	}
+	budget, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if budget <= 0 {
+		return 0, fmt.Errorf("time budget must be positive, got %s", value)
+	}
+	return budget, nil
+}
+
+// programHash identifies the program of an activation in the duration estimates.
+func programHash(act *Activation) string {
+	h := sha256.Sum256([]byte(act.Code))
+	return hex.EncodeToString(h[:])
+}
+
+// durationEstimate is the shortest duration observed for the phases of the activations of a program. A zero duration
+// means that the phase has not been observed yet.
+type durationEstimate struct {
+	Compilation time.Duration
+	Discovery   time.Duration
+	Execution   time.Duration
+	// samples are the durations of the discovery and execution of the recent activations, overwritten round-robin
+	// starting at next once maxDurationSamples are kept.
+	samples []time.Duration
+	next    int
+}
+
+// addSample records the duration of the discovery and execution of an activation, replacing the oldest one if the
+// maximum number of samples is kept.
+func (e *durationEstimate) addSample(duration time.Duration) {
+	if len(e.samples) < maxDurationSamples {
+		e.samples = append(e.samples, duration)
+		return
+	}
+	e.samples[e.next] = duration
+	e.next = (e.next + 1) % maxDurationSamples
+}
+
+// expectedDuration describes the durations of the discovery and execution of recent activations of a program by their
+// percentiles.
+type expectedDuration struct {
+	Samples int
+	P50     time.Duration
+	P90     time.Duration
+	P99     time.Duration
+}
+
+// Total returns the shortest duration an activation is expected to take.
+func (e durationEstimate) Total() time.Duration {
+	return e.Compilation + e.Discovery + e.Execution
+}
+
+// newDurationEstimates returns empty duration estimates.
+func newDurationEstimates() *durationEstimates {
+	return &durationEstimates{programs: map[string]*durationEstimate{}}
+}
+
+// durationEstimates keeps the shortest durations observed for compiling and executing programs and for the discovery
+// of games. As they are used to reject activations whose time budget is obviously insufficient, the minimum rather than
+// the average is kept.
+type durationEstimates struct {
+	mux       sync.Mutex
+	discovery time.Duration
+	programs  map[string]*durationEstimate
+}
+
+// estimate returns the expected minimum duration of the activation. The compilation is only accounted for if the
+// program is compiled.
+func (d *durationEstimates) estimate(act *Activation, compile bool) durationEstimate {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	e := durationEstimate{Discovery: d.discovery}
+	if p, ok := d.programs[programHash(act)]; ok {
+		e.Execution = p.Execution
+		if compile {
+			e.Compilation = p.Compilation
+		}
+	}
+	return e
+}
+
+// expected returns the expected duration of the discovery and execution of the activation, based on the recent
+// successful activations of its program. Returns false if the program has not been activated successfully before.
+func (d *durationEstimates) expected(act *Activation) (expectedDuration, bool) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	p, ok := d.programs[programHash(act)]
+	if !ok || len(p.samples) == 0 {
+		return expectedDuration{}, false
+	}
+	sorted := append([]time.Duration{}, p.samples...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	return expectedDuration{
+		Samples: len(sorted),
+		P50:     percentile(sorted, 50),
+		P90:     percentile(sorted, 90),
+		P99:     percentile(sorted, 99),
+	}, true
+}
+
+// percentile returns the given percentile of the sorted durations using the nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// observeCompilation records the duration of compiling the program of the activation.
+func (d *durationEstimates) observeCompilation(act *Activation, duration time.Duration) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	p := d.program(programHash(act))
+	p.Compilation = shortest(p.Compilation, duration)
+}
+
+// observeActivation records the durations of the discovery and the execution of a successful activation.
+func (d *durationEstimates) observeActivation(act *Activation, discovery, execution time.Duration) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.discovery = shortest(d.discovery, discovery)
+	p := d.program(programHash(act))
+	p.Execution = shortest(p.Execution, execution)
+	p.addSample(discovery + execution)
+}
+
+// program returns the estimate of the program with the given hash. An arbitrary program is evicted if the estimates
+// of too many programs are kept.
+func (d *durationEstimates) program(hash string) *durationEstimate {
+	if p, ok := d.programs[hash]; ok {
+		return p
+	}
+	if len(d.programs) >= maxEstimatedPrograms {
+		for h := range d.programs {
+			delete(d.programs, h)
+			break
+		}
+	}
+	p := &durationEstimate{}
+	d.programs[hash] = p
+	return p
+}
+
+// shortest returns the shorter of both durations, ignoring an unobserved, i.e., zero, current duration.
+func shortest(current, observed time.Duration) time.Duration {
+	if current == 0 || observed < current {
+		return observed
+	}
+	return current
+}
+
+// activationTimer measures the discovery and execution phases of an activation by observing the transition of the
+// player into the Playing state.
+type activationTimer struct {
+	mux     sync.Mutex
+	started time.Time
+	playing time.Time
+}
+
+// newActivationTimer returns a timer of an activation starting now.
+func newActivationTimer() *activationTimer {
+	return &activationTimer{started: time.Now()}
+}
+
+// OnTransition records the time the game starts playing.
+func (t *activationTimer) OnTransition(src, event, dst, gameID string) {
+	if dst != Playing {
+		return
+	}
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.playing = time.Now()
+}
+
+// phases returns the durations of the discovery and the execution up to now. Returns false if the game did not start
+// playing.
+func (t *activationTimer) phases() (time.Duration, time.Duration, bool) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if t.playing.IsZero() {
+		return 0, 0, false
+	}
+	return t.playing.Sub(t.started), time.Since(t.playing), true
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Time budgets", func() {
+	Context("when reading the time budget", func() {
+		var req *http.Request
+		BeforeEach(func() {
+			req, _ = http.NewRequest("POST", "/", nil)
+		})
+		It("returns zero if no budget is given", func() {
+			budget, err := timeBudget(&Activation{}, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(budget).To(BeZero())
+		})
+		It("reads the budget from the header", func() {
+			req.Header.Set(timeBudgetHeader, "90s")
+			budget, err := timeBudget(&Activation{}, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(budget).To(Equal(90 * time.Second))
+		})
+		It("prefers the budget of the activation", func() {
+			req.Header.Set(timeBudgetHeader, "90s")
+			budget, err := timeBudget(&Activation{TimeBudget: "2m"}, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(budget).To(Equal(2 * time.Minute))
+		})
+		It("rejects malformed and non-positive budgets", func() {
+			_, err := timeBudget(&Activation{TimeBudget: "soon"}, req)
+			Expect(err).To(HaveOccurred())
+			_, err = timeBudget(&Activation{TimeBudget: "-1s"}, req)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("time budget must be positive, got -1s"))
+		})
+	})
+	Context("when estimating the duration of an activation", func() {
+		var (
+			estimates *durationEstimates
+			act       *Activation
+		)
+		BeforeEach(func() {
+			estimates = newDurationEstimates()
+			act = &Activation{Code: "print_ln('hello')"}
+		})
+		It("expects nothing if no activation has been observed", func() {
+			Expect(estimates.estimate(act, true).Total()).To(BeZero())
+		})
+		It("keeps the shortest durations observed", func() {
+			estimates.observeCompilation(act, 3*time.Second)
+			estimates.observeCompilation(act, 2*time.Second)
+			estimates.observeActivation(act, time.Second, 5*time.Second)
+			estimates.observeActivation(act, 2*time.Second, 4*time.Second)
+			Expect(estimates.estimate(act, true)).To(Equal(durationEstimate{
+				Compilation: 2 * time.Second,
+				Discovery:   time.Second,
+				Execution:   4 * time.Second,
+			}))
+		})
+		It("accounts for the compilation only if the program is compiled", func() {
+			estimates.observeCompilation(act, 3*time.Second)
+			Expect(estimates.estimate(act, false).Total()).To(BeZero())
+		})
+		It("uses the discovery of any program but the execution of the same program only", func() {
+			estimates.observeActivation(act, time.Second, 5*time.Second)
+			e := estimates.estimate(&Activation{Code: "print_ln('other')"}, true)
+			Expect(e).To(Equal(durationEstimate{Discovery: time.Second}))
+		})
+		It("bounds the number of programs kept", func() {
+			for i := 0; i <= maxEstimatedPrograms; i++ {
+				estimates.observeCompilation(&Activation{Code: string(rune(i))}, time.Second)
+			}
+			Expect(estimates.programs).To(HaveLen(maxEstimatedPrograms))
+		})
+		It("expects no duration if the program has not been activated before", func() {
+			estimates.observeCompilation(act, time.Second)
+			_, ok := estimates.expected(act)
+			Expect(ok).To(BeFalse())
+		})
+		It("expects the percentiles of the durations of the recent activations", func() {
+			for i := 1; i <= 10; i++ {
+				estimates.observeActivation(act, time.Second, time.Duration(i)*time.Second)
+			}
+			e, ok := estimates.expected(act)
+			Expect(ok).To(BeTrue())
+			Expect(e).To(Equal(expectedDuration{Samples: 10, P50: 6 * time.Second, P90: 10 * time.Second, P99: 11 * time.Second}))
+		})
+		It("keeps the most recent activations only", func() {
+			for i := 0; i < maxDurationSamples; i++ {
+				estimates.observeActivation(act, 0, time.Hour)
+			}
+			for i := 0; i < maxDurationSamples; i++ {
+				estimates.observeActivation(act, 0, time.Second)
+			}
+			e, _ := estimates.expected(act)
+			Expect(e).To(Equal(expectedDuration{Samples: maxDurationSamples, P50: time.Second, P90: time.Second, P99: time.Second}))
+		})
+	})
+	Context("when timing an activation", func() {
+		It("reports the phases once the game is playing", func() {
+			clock := newActivationTimer()
+			_, _, ok := clock.phases()
+			Expect(ok).To(BeFalse())
+			clock.OnTransition(Registering, PlayersReady, Playing, "")
+			discovery, execution, ok := clock.phases()
+			Expect(ok).To(BeTrue())
+			Expect(discovery).To(BeNumerically(">=", 0))
+			Expect(execution).To(BeNumerically(">=", 0))
+		})
+	})
+})
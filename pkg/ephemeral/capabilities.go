@@ -0,0 +1,95 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	. "github.com/carbynestack/ephemeral/pkg/ephemeral/io"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// Optional features reported in the capabilities.
+const (
+	FeaturePartialResults  = "partialResults"
+	FeatureCompileCache    = "compileCache"
+	FeatureProxyPortSets   = "proxyPortSets"
+	FeatureQuota           = "quota"
+	FeatureNotifications   = "notifications"
+	FeatureOutputStreaming = "outputStreaming"
+	// FeatureInsecurePreprocessing warns clients that the results are computed with fake preprocessing data. It is also
+	// advertised by the players of a game proposing to generate fake preprocessing data.
+	FeatureInsecurePreprocessing = "insecurePreprocessing"
+	// FeatureCompression is also advertised by the players of a game to negotiate the compression of their traffic.
+	FeatureCompression = "compression"
+	// FeaturePlaintextSimulator announces that games can be executed in cleartext by the simulator backend.
+	FeaturePlaintextSimulator = "plaintextSimulator"
+)
+
+// NewCapabilities returns the capabilities of a deployment with the given configuration.
+func NewCapabilities(conf *SPDZEngineTypedConfig) *Capabilities {
+	c := &Capabilities{
+		OutputTypes: outputTypes(conf),
+		MaxPlayers:  conf.PlayerCount,
+		Field: FieldParameters{
+			Prime:          conf.Prime.String(),
+			PrimeBitLength: conf.Prime.BitLen(),
+			Gf2nBitLength:  conf.Gf2nBitLength,
+		},
+		ContentTypes:   acceptedContentTypes(conf),
+		Features:       []string{},
+		MaxThreads:     conf.MaxThreads,
+		InputEncodings: InputEncodings,
+	}
+	for _, p := range supportedSPDZProtocols(conf) {
+		c.Protocols = append(c.Protocols, p.Descriptor)
+	}
+	for _, t := range supportedTupleTypes(conf) {
+		c.TupleTypes = append(c.TupleTypes, t.Name)
+	}
+	c.MPCProtocols, c.DefaultMPCProtocol = protocolNames(DefaultProtocols()), MascotProtocol
+	if len(conf.Protocols) > 0 {
+		c.MPCProtocols, c.DefaultMPCProtocol = protocolNames(conf.Protocols), conf.DefaultProtocol
+	}
+	if conf.AllowPartialResults {
+		c.Features = append(c.Features, FeaturePartialResults)
+	}
+	if conf.CompileCacheSize > 0 {
+		c.Features = append(c.Features, FeatureCompileCache)
+	}
+	if conf.ProxyPortRange != "" {
+		c.Features = append(c.Features, FeatureProxyPortSets)
+	}
+	if conf.Quota != nil {
+		c.Features = append(c.Features, FeatureQuota)
+	}
+	if conf.Notifications != nil {
+		c.Features = append(c.Features, FeatureNotifications)
+	}
+	if conf.OutputStreamBufferSize > 0 {
+		c.Features = append(c.Features, FeatureOutputStreaming)
+	}
+	if conf.InsecurePreprocessing != nil {
+		c.Features = append(c.Features, FeatureInsecurePreprocessing)
+	}
+	if conf.ProxyCompression != nil {
+		c.Features = append(c.Features, FeatureCompression)
+	}
+	if conf.Simulator != nil {
+		c.Features = append(c.Features, FeaturePlaintextSimulator)
+	}
+	return c
+}
+
+// outputTypes returns the output types of the registered converters which are supported by the deployment, i.e., the
+// object store output type is omitted if no object store is configured.
+func outputTypes(conf *SPDZEngineTypedConfig) []string {
+	types := []string{}
+	for _, t := range ConverterNames() {
+		if t == ObjectStore && conf.ObjectStoreClient == nil {
+			continue
+		}
+		types = append(types, t)
+	}
+	return types
+}
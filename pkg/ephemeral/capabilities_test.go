@@ -0,0 +1,65 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"github.com/carbynestack/ephemeral/pkg/castor"
+	"github.com/carbynestack/ephemeral/pkg/objectstore"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Capabilities", func() {
+	It("reports the protocols, tuple types and field parameters", func() {
+		conf := &SPDZEngineTypedConfig{PlayerCount: 2, Gf2nBitLength: 40}
+		conf.Prime.SetInt64(17)
+		c := NewCapabilities(conf)
+		Expect(c.Protocols).To(Equal([]string{"SPDZ gfp", "SPDZ gf2n_"}))
+		Expect(c.TupleTypes).To(ContainElement("MULTIPLICATION_TRIPLE_GFP"))
+		Expect(c.OutputTypes).To(ConsistOf(AmphoraSecret, FixedPoint, JSONArray, PlainText, SecretShare, SignedInt))
+		Expect(c.MaxPlayers).To(Equal(int32(2)))
+		Expect(c.Field).To(Equal(FieldParameters{Prime: "17", PrimeBitLength: 5, Gf2nBitLength: 40}))
+		Expect(c.ContentTypes).To(Equal([]string{ContentTypeJSON}))
+		Expect(c.InputEncodings).To(Equal([]string{EncodingBase64, EncodingHex, EncodingJSON}))
+		Expect(c.Features).To(BeEmpty())
+		Expect(c.MPCProtocols).To(Equal([]string{MascotProtocol}))
+		Expect(c.DefaultMPCProtocol).To(Equal(MascotProtocol))
+	})
+	It("reports the object store output type if an object store is configured", func() {
+		c := NewCapabilities(&SPDZEngineTypedConfig{ObjectStoreClient: &objectstore.Client{}})
+		Expect(c.OutputTypes).To(ContainElement(ObjectStore))
+	})
+	It("reports gfp only if gf2n is disabled", func() {
+		c := NewCapabilities(&SPDZEngineTypedConfig{Gf2nDisabled: true})
+		Expect(c.Protocols).To(Equal([]string{"SPDZ gfp"}))
+		Expect(c.TupleTypes).To(HaveLen(5))
+		Expect(c.TupleTypes).NotTo(ContainElement("MULTIPLICATION_TRIPLE_GF2N"))
+	})
+	It("reports the mixed-circuit tuple types only if enabled", func() {
+		c := NewCapabilities(&SPDZEngineTypedConfig{})
+		Expect(c.TupleTypes).NotTo(ContainElement("DABIT_GFP"))
+		c = NewCapabilities(&SPDZEngineTypedConfig{TupleTypes: []castor.TupleType{castor.DaBitGfp, castor.BitGf2n}, Gf2nDisabled: true})
+		Expect(c.TupleTypes).To(Equal([]string{"DABIT_GFP"}))
+	})
+	It("reports the declared protocols", func() {
+		conf := &SPDZEngineTypedConfig{
+			Protocols:       map[string]ProtocolConfig{"semi": {Executable: "./Semi-Party.x"}, "hemi": {Executable: "./Hemi-Party.x"}},
+			DefaultProtocol: "semi",
+		}
+		c := NewCapabilities(conf)
+		Expect(c.MPCProtocols).To(Equal([]string{"hemi", "semi"}))
+		Expect(c.DefaultMPCProtocol).To(Equal("semi"))
+	})
+	It("reports the optional features enabled", func() {
+		conf := &SPDZEngineTypedConfig{AllowPartialResults: true, CompileCacheSize: 4}
+		Expect(NewCapabilities(conf).Features).To(Equal([]string{FeaturePartialResults, FeatureCompileCache}))
+	})
+	It("reports insecure preprocessing if enabled", func() {
+		conf := &SPDZEngineTypedConfig{InsecurePreprocessing: &InsecurePreprocessingConfig{Enabled: true, TupleCount: 10}}
+		Expect(NewCapabilities(conf).Features).To(Equal([]string{FeatureInsecurePreprocessing}))
+	})
+})
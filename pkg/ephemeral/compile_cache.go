@@ -0,0 +1,199 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// CompileCacheKey returns the cache key of a program compiled from the given source code with the given compiler
+// command.
+func CompileCacheKey(source string, command string) string {
+	h := sha256.New()
+	h.Write([]byte(command))
+	h.Write([]byte{0})
+	h.Write([]byte(source))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NewCompileCache returns a cache keeping the artifacts of up to size compiled programs in dir. The artifacts are the
+// files below root matching the given glob patterns.
+func NewCompileCache(root string, dir string, patterns []string, size int) *CompileCache {
+	return &CompileCache{
+		root:     root,
+		dir:      dir,
+		patterns: patterns,
+		size:     size,
+		lru:      list.New(),
+		entries:  map[string]*list.Element{},
+	}
+}
+
+// CompileCache stores the artifacts of compiled programs, so that recompiling identical programs can be skipped. The
+// least recently used program is evicted once the cache is full.
+type CompileCache struct {
+	root     string
+	dir      string
+	patterns []string
+	size     int
+	lru      *list.List
+	entries  map[string]*list.Element
+	mux      sync.Mutex
+}
+
+type compileCacheEntry struct {
+	key    string
+	digest string
+}
+
+// Restore makes the artifacts of the program with the given key available below root. The artifacts are only copied
+// if the ones in place do not match already. Returns false if the program is not cached.
+func (c *CompileCache) Restore(key string) (bool, error) {
+	return c.RestoreIn(c.root, key)
+}
+
+// RestoreIn makes the artifacts of the program with the given key available below the given directory, see Restore.
+func (c *CompileCache) RestoreIn(root, key string) (bool, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return false, nil
+	}
+	c.lru.MoveToFront(el)
+	current, err := c.artifacts(root)
+	if err != nil {
+		return false, err
+	}
+	digest, err := c.digest(root, current)
+	if err != nil {
+		return false, err
+	}
+	if digest == el.Value.(*compileCacheEntry).digest {
+		return true, nil
+	}
+	for _, f := range current {
+		if err := os.Remove(filepath.Join(root, f)); err != nil {
+			return false, err
+		}
+	}
+	cached, err := c.artifacts(c.entryDir(key))
+	if err != nil {
+		return false, err
+	}
+	for _, f := range cached {
+		if err := copyFile(filepath.Join(c.entryDir(key), f), filepath.Join(root, f)); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Store adds the artifacts currently in place below root as the program with the given key.
+func (c *CompileCache) Store(key string) error {
+	return c.StoreFrom(c.root, key)
+}
+
+// StoreFrom adds the artifacts currently in place below the given directory as the program with the given key, see
+// Store.
+func (c *CompileCache) StoreFrom(root, key string) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	files, err := c.artifacts(root)
+	if err != nil {
+		return err
+	}
+	digest, err := c.digest(root, files)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(c.entryDir(key)); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := copyFile(filepath.Join(root, f), filepath.Join(c.entryDir(key), f)); err != nil {
+			return err
+		}
+	}
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*compileCacheEntry).digest = digest
+		c.lru.MoveToFront(el)
+		return nil
+	}
+	c.entries[key] = c.lru.PushFront(&compileCacheEntry{key: key, digest: digest})
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		entry := c.lru.Remove(oldest).(*compileCacheEntry)
+		delete(c.entries, entry.key)
+		if err := os.RemoveAll(c.entryDir(entry.key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Len returns the number of cached programs.
+func (c *CompileCache) Len() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.lru.Len()
+}
+
+func (c *CompileCache) entryDir(key string) string {
+	return filepath.Join(c.dir, key)
+}
+
+// artifacts returns the sorted paths, relative to base, of all files matching the patterns of the cache.
+func (c *CompileCache) artifacts(base string) ([]string, error) {
+	var files []string
+	for _, p := range c.patterns {
+		matches, err := filepath.Glob(filepath.Join(base, p))
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			rel, err := filepath.Rel(base, m)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, rel)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// digest hashes the names and contents of the given files.
+func (c *CompileCache) digest(base string, files []string) (string, error) {
+	h := sha256.New()
+	for _, f := range files {
+		data, err := ioutil.ReadFile(filepath.Join(base, f))
+		if err != nil {
+			return "", err
+		}
+		h.Write([]byte(f))
+		h.Write([]byte{0})
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func copyFile(src, dst string) error {
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, data, 0644)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CompileCache", func() {
+	var (
+		root, dir string
+		cache     *CompileCache
+	)
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+	read := func(rel string) string {
+		data, err := ioutil.ReadFile(filepath.Join(root, rel))
+		Expect(err).NotTo(HaveOccurred())
+		return string(data)
+	}
+	BeforeEach(func() {
+		root, _ = ioutil.TempDir("", "ephemeral_root_")
+		dir, _ = ioutil.TempDir("", "ephemeral_cache_")
+		cache = NewCompileCache(root, dir, []string{"Schedules/p.sch", "Bytecode/p-*.bc"}, 2)
+	})
+	AfterEach(func() {
+		_ = os.RemoveAll(root)
+		_ = os.RemoveAll(dir)
+	})
+	It("derives different keys for different sources and commands", func() {
+		Expect(CompileCacheKey("a", "compile")).To(Equal(CompileCacheKey("a", "compile")))
+		Expect(CompileCacheKey("a", "compile")).NotTo(Equal(CompileCacheKey("b", "compile")))
+		Expect(CompileCacheKey("a", "compile")).NotTo(Equal(CompileCacheKey("a", "compile -O")))
+	})
+	Context("when the program is not cached", func() {
+		It("reports a miss", func() {
+			cached, err := cache.Restore("unknown")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cached).To(BeFalse())
+		})
+	})
+	Context("when the program is cached", func() {
+		BeforeEach(func() {
+			write("Schedules/p.sch", "1")
+			write("Bytecode/p-0.bc", "first")
+			Expect(cache.Store("first")).To(Succeed())
+		})
+		It("keeps the artifacts in place if they match", func() {
+			cached, err := cache.Restore("first")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cached).To(BeTrue())
+			Expect(read("Bytecode/p-0.bc")).To(Equal("first"))
+		})
+		It("restores the artifacts if they were replaced by another program", func() {
+			Expect(os.Remove(filepath.Join(root, "Bytecode/p-0.bc"))).To(Succeed())
+			write("Schedules/p.sch", "2")
+			write("Bytecode/p-0.bc", "second")
+			write("Bytecode/p-1.bc", "second")
+			cached, err := cache.Restore("first")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cached).To(BeTrue())
+			Expect(read("Schedules/p.sch")).To(Equal("1"))
+			Expect(read("Bytecode/p-0.bc")).To(Equal("first"))
+			Expect(filepath.Join(root, "Bytecode/p-1.bc")).NotTo(BeAnExistingFile())
+		})
+		It("evicts the least recently used program once full", func() {
+			Expect(cache.Store("second")).To(Succeed())
+			_, _ = cache.Restore("first")
+			Expect(cache.Store("third")).To(Succeed())
+			Expect(cache.Len()).To(Equal(2))
+			cached, _ := cache.Restore("second")
+			Expect(cached).To(BeFalse())
+			Expect(filepath.Join(dir, "second")).NotTo(BeADirectory())
+			cached, _ = cache.Restore("first")
+			Expect(cached).To(BeTrue())
+		})
+	})
+})
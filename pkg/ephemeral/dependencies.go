@@ -0,0 +1,60 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/amphora"
+	"github.com/carbynestack/ephemeral/pkg/castor"
+	"github.com/carbynestack/ephemeral/pkg/depcheck"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// DependenciesPath is the path the report of the dependency checks is served on.
+const DependenciesPath = "/admin/dependencies"
+
+// dependencyDialTimeout is the time to wait for a connection to another service if no connect timeout is configured.
+const dependencyDialTimeout = 5 * time.Second
+
+// DependencyChecks returns the checks of the dependencies of a service with the given configuration, i.e., that
+// castor, unless preprocessing data is always generated locally, amphora and the discovery service are reachable, that the
+// MP-SPDZ compiler and the virtual machines of the protocols are present and that the preprocessing folder is
+// writable.
+func DependencyChecks(conf *SPDZEngineTypedConfig) []depcheck.Check {
+	timeout := conf.DiscoveryConfig.ConnectTimeout
+	if timeout <= 0 {
+		timeout = dependencyDialTimeout
+	}
+	checks := []depcheck.Check{
+		depcheck.Reachable("discovery", net.JoinHostPort(conf.DiscoveryConfig.Host, conf.DiscoveryConfig.Port), timeout),
+	}
+	if c, ok := conf.CastorClient.(*castor.Client); ok && (conf.InsecurePreprocessing == nil || conf.InsecurePreprocessing.Fallback) {
+		checks = append(checks, depcheck.ReachableURL("castor", c.URL, timeout))
+	}
+	if c, ok := conf.AmphoraClient.(*amphora.Client); ok {
+		checks = append(checks, depcheck.ReachableURL("amphora", c.URL, timeout))
+	}
+	mpSpdz := mpSpdzConfig(conf)
+	checks = append(checks, depcheck.Executable("mp-spdz compiler", filepath.Join(mpSpdz.BaseDir, "compile.py")))
+	protocols := conf.Protocols
+	if len(protocols) == 0 {
+		protocols = DefaultProtocols()
+	}
+	for _, name := range protocolNames(protocols) {
+		executable := protocols[name].Executable
+		if !filepath.IsAbs(executable) {
+			executable = filepath.Join(mpSpdz.BaseDir, strings.TrimPrefix(executable, "./"))
+		}
+		checks = append(checks, depcheck.Executable("mp-spdz "+name, executable))
+	}
+	if conf.PrepFolder != "" {
+		checks = append(checks, depcheck.Writable("preprocessing folder", conf.PrepFolder))
+	}
+	return checks
+}
@@ -0,0 +1,267 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	. "github.com/carbynestack/ephemeral/pkg/ephemeral/io"
+	apb "github.com/carbynestack/ephemeral/pkg/ephemeral/proto"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	// ContentTypeJSON is the media type of JSON encoded activations and results.
+	ContentTypeJSON = "application/json"
+	// ContentTypeProtobuf is the media type of protobuf encoded activations and results.
+	ContentTypeProtobuf = "application/x-protobuf"
+	// ContentTypeMultipart is the media type of activations uploaded along with binary secret parameters. The results
+	// are encoded in JSON.
+	ContentTypeMultipart = "multipart/form-data"
+	// ContentTypeNDJSON is the media type of the compiler output streamed as newline delimited JSON.
+	ContentTypeNDJSON = "application/x-ndjson"
+)
+
+const (
+	// multipartActivation is the name of the part holding the JSON encoded activation.
+	multipartActivation = "activation"
+	// multipartSecretParams is the name of the parts holding the binary secret parameters.
+	multipartSecretParams = "secretParams"
+)
+
+// SupportedContentTypes are the media types activations and results can be encoded with.
+var SupportedContentTypes = []string{ContentTypeJSON, ContentTypeProtobuf, ContentTypeMultipart}
+
+// InputEncodings are the encodings the secret parameters of an activation can be given in.
+var InputEncodings = []string{EncodingBase64, EncodingHex, EncodingJSON}
+
+// decodeActivation decodes an activation encoded with the given content type. JSON encoded activations are decoded
+// while the body is read, so that large secret parameter arrays are not buffered as a whole in addition.
+func decodeActivation(contentType string, body io.Reader, act *Activation) error {
+	if contentType != ContentTypeProtobuf {
+		return decodeJSON(body, act)
+	}
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	var msg apb.Activation
+	err = proto.Unmarshal(data, &msg)
+	if err != nil {
+		return err
+	}
+	act.AmphoraParams = msg.GetAmphoraParams()
+	act.SecretParams = msg.GetSecretParams()
+	act.GameID = msg.GetGameID()
+	act.Code = msg.GetCode()
+	act.Output.Type = msg.GetOutput().GetType()
+	for _, f := range msg.GetOutput().GetSchema() {
+		act.Output.Schema = append(act.Output.Schema, OutputField{Name: f.GetName(), Count: int(f.GetCount())})
+	}
+	act.PodAffinity = msg.GetPodAffinity()
+	act.Labels = msg.GetLabels()
+	act.SessionID = msg.GetSessionID()
+	act.Protocol = msg.GetProtocol()
+	act.TimeBudget = msg.GetTimeBudget()
+	if opts := msg.GetCompilerOptions(); opts != nil {
+		act.CompilerOptions = &CompilerOptions{
+			OptimizationLevel: int(opts.GetOptimizationLevel()),
+			BitLength:         int(opts.GetBitLength()),
+			Budget:            int(opts.GetBudget()),
+			Prime:             opts.GetPrime(),
+		}
+	}
+	act.Diagnostics = msg.GetDiagnostics()
+	for _, c := range msg.GetConnections() {
+		act.Connections = append(act.Connections, ClientConnection{Port: c.GetPort(), Params: int(c.GetParams())})
+	}
+	act.Encoding = msg.GetEncoding()
+	if c := msg.GetCompression(); c != nil {
+		compression := c.GetValue()
+		act.Compression = &compression
+	}
+	act.Signature = msg.GetSignature()
+	act.Seed = msg.GetSeed()
+	act.Backend = msg.GetBackend()
+	return nil
+}
+
+// decodeMultipartActivation decodes a multipart activation. The activation is read from the JSON encoded part named
+// activation, the other parts named secretParams are appended to its secret parameters. The secret parameters given as
+// parts are taken as they are, i.e., they are neither base64 nor otherwise encoded.
+func decodeMultipartActivation(contentType string, body io.Reader, act *Activation) error {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return err
+	}
+	reader := multipart.NewReader(body, params["boundary"])
+	found := false
+	var secretParams []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		switch part.FormName() {
+		case multipartActivation:
+			if err := decodeJSON(part, act); err != nil {
+				return err
+			}
+			found = true
+		case multipartSecretParams:
+			data, err := ioutil.ReadAll(part)
+			if err != nil {
+				return err
+			}
+			secretParams = append(secretParams, base64.StdEncoding.EncodeToString(data))
+		default:
+			return fmt.Errorf("unexpected part %s", part.FormName())
+		}
+	}
+	if !found {
+		return fmt.Errorf("the %s part is missing", multipartActivation)
+	}
+	if len(secretParams) > 0 {
+		if act.Encoding != "" && !strings.EqualFold(act.Encoding, EncodingBase64) {
+			return fmt.Errorf("binary secret parameters cannot be combined with the %s encoding", act.Encoding)
+		}
+		act.SecretParams = append(act.SecretParams, secretParams...)
+	}
+	return nil
+}
+
+// decodeSecretParams converts the secret parameters of the activation from its encoding into base64 encoded secret
+// shares. Clear values given in JSON are secret shared as described for ClearInputSharer.
+func decodeSecretParams(act *Activation, conf *SPDZEngineTypedConfig) error {
+	switch strings.ToLower(act.Encoding) {
+	case "", EncodingBase64:
+	case EncodingHex:
+		for i, p := range act.SecretParams {
+			b, err := hex.DecodeString(p)
+			if err != nil {
+				return fmt.Errorf("parameter %d is not hex encoded: %v", i, err)
+			}
+			act.SecretParams[i] = base64.StdEncoding.EncodeToString(b)
+		}
+	case EncodingJSON:
+		if len(act.SecretParams) == 0 {
+			break
+		}
+		sharer, err := NewClearInputSharer(conf)
+		if err != nil {
+			return err
+		}
+		for i, p := range act.SecretParams {
+			var values []int64
+			if err := json.Unmarshal([]byte(p), &values); err != nil {
+				return fmt.Errorf("parameter %d is not a JSON array of 64 bit integers: %v", i, err)
+			}
+			if len(values) == 0 {
+				return fmt.Errorf("parameter %d does not contain any value", i)
+			}
+			act.SecretParams[i] = sharer.Share(values)
+		}
+	default:
+		return fmt.Errorf("unsupported encoding %s, must be one of %s", act.Encoding, strings.Join(InputEncodings, ", "))
+	}
+	act.Encoding = EncodingBase64
+	return nil
+}
+
+// encodeResult converts the JSON encoded result of a computation to the given content type.
+func encodeResult(contentType string, result []byte) ([]byte, error) {
+	if contentType != ContentTypeProtobuf {
+		return result, nil
+	}
+	var res Result
+	err := json.Unmarshal(result, &res)
+	if err != nil {
+		return nil, err
+	}
+	msg := &apb.Result{
+		Response: res.Response,
+		Error:    res.Error,
+		Ports:    res.Ports,
+		Secrets:  res.Secrets,
+	}
+	if res.Warning != nil {
+		msg.Warning = &apb.TruncationWarning{
+			Truncated: res.Warning.Truncated,
+			Reason:    res.Warning.Reason,
+			Offset:    int32(res.Warning.Offset),
+		}
+	}
+	if res.Diagnostics != nil {
+		msg.Diagnostics = &apb.Diagnostics{
+			InputBytes:  res.Diagnostics.InputBytes,
+			OutputBytes: res.Diagnostics.OutputBytes,
+		}
+		for _, a := range res.Diagnostics.Amphora {
+			msg.Diagnostics.Amphora = append(msg.Diagnostics.Amphora, &apb.AmphoraInteraction{
+				Operation:  a.Operation,
+				SecretId:   a.SecretID,
+				Size:       a.Size,
+				DurationMs: a.DurationMs,
+				Status:     int32(a.Status),
+				Error:      a.Error,
+			})
+		}
+	}
+	if len(res.Outputs) > 0 {
+		msg.Outputs = make(map[string]*apb.Values, len(res.Outputs))
+		for name, values := range res.Outputs {
+			msg.Outputs[name] = &apb.Values{Values: values}
+		}
+	}
+	if r := res.Reproducibility; r != nil {
+		msg.Reproducibility = &apb.Reproducibility{
+			Seed:               r.Seed,
+			Preprocessing:      r.Preprocessing,
+			InsecureTupleCount: int32(r.InsecureTupleCount),
+			Protocol:           r.Protocol,
+			ProgramHash:        r.ProgramHash,
+			RuntimeArgs:        r.RuntimeArgs,
+			Prime:              r.Prime,
+			Gf2NBitLength:      r.Gf2nBitLength,
+			PlayerCount:        r.PlayerCount,
+			PlayerID:           r.PlayerID,
+			Versions:           r.Versions,
+		}
+		if opts := r.CompilerOptions; opts != nil {
+			msg.Reproducibility.CompilerOptions = &apb.CompilerOptions{
+				OptimizationLevel: int32(opts.OptimizationLevel),
+				BitLength:         int32(opts.BitLength),
+				Budget:            int32(opts.Budget),
+				Prime:             opts.Prime,
+			}
+		}
+	}
+	return proto.Marshal(msg)
+}
+
+// decodeJSON decodes the single JSON value read from the given reader into v. Data following the value is rejected.
+func decodeJSON(r io.Reader, v interface{}) error {
+	dec := json.NewDecoder(r)
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after the JSON value")
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"bytes"
+	"encoding/json"
+
+	. "github.com/carbynestack/ephemeral/pkg/ephemeral/io"
+	apb "github.com/carbynestack/ephemeral/pkg/ephemeral/proto"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Encoding", func() {
+	Context("when decoding a protobuf encoded activation", func() {
+		It("decodes all fields of the activation", func() {
+			data, err := proto.Marshal(&apb.Activation{
+				AmphoraParams: []string{"a"},
+				SecretParams:  []string{"YQ=="},
+				GameID:        "71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4",
+				Code:          "print_ln('a')",
+				Output: &apb.OutputConfig{
+					Type:   AmphoraSecret,
+					Schema: []*apb.OutputField{{Name: "sum", Count: 1}, {Name: "mean", Count: 2}},
+				},
+				PodAffinity:     []string{"ephemeral-0"},
+				CompilerOptions: &apb.CompilerOptions{OptimizationLevel: 2, BitLength: 32, Budget: 1000, Prime: "p"},
+				Labels:          map[string]string{"tenant": "a"},
+				SessionID:       "session",
+				Protocol:        "mascot",
+				TimeBudget:      "1m",
+				Diagnostics:     true,
+				Connections:     []*apb.ClientConnection{{Port: 14000, Params: 1}},
+				Encoding:        EncodingHex,
+				Compression:     &wrappers.BoolValue{Value: false},
+				Signature:       "signature",
+				Seed:            "seed",
+				Backend:         "backend",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			var act Activation
+			Expect(decodeActivation(ContentTypeProtobuf, bytes.NewReader(data), &act)).To(Succeed())
+			compression := false
+			Expect(act).To(Equal(Activation{
+				AmphoraParams: []string{"a"},
+				SecretParams:  []string{"YQ=="},
+				GameID:        "71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4",
+				Code:          "print_ln('a')",
+				Output: OutputConfig{
+					Type:   AmphoraSecret,
+					Schema: []OutputField{{Name: "sum", Count: 1}, {Name: "mean", Count: 2}},
+				},
+				PodAffinity:     []string{"ephemeral-0"},
+				CompilerOptions: &CompilerOptions{OptimizationLevel: 2, BitLength: 32, Budget: 1000, Prime: "p"},
+				Labels:          map[string]string{"tenant": "a"},
+				SessionID:       "session",
+				Protocol:        "mascot",
+				TimeBudget:      "1m",
+				Diagnostics:     true,
+				Connections:     []ClientConnection{{Port: 14000, Params: 1}},
+				Encoding:        EncodingHex,
+				Compression:     &compression,
+				Signature:       "signature",
+				Seed:            "seed",
+				Backend:         "backend",
+			}))
+		})
+		It("leaves the compression unset if not given", func() {
+			data, _ := proto.Marshal(&apb.Activation{GameID: "71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4"})
+			var act Activation
+			Expect(decodeActivation(ContentTypeProtobuf, bytes.NewReader(data), &act)).To(Succeed())
+			Expect(act.Compression).To(BeNil())
+		})
+	})
+	Context("when encoding a result with protobuf", func() {
+		It("encodes all fields of the result", func() {
+			result, _ := json.Marshal(&Result{
+				Response: []string{"a"},
+				Warning:  &TruncationWarning{Truncated: true, Reason: "size", Offset: 1},
+				Error:    "failed",
+				Diagnostics: &Diagnostics{
+					InputBytes:  1,
+					OutputBytes: 2,
+					Amphora: []AmphoraInteraction{
+						{Operation: "create", SecretID: "id", Size: 3, DurationMs: 4, Status: 500, Error: "failed"},
+					},
+				},
+				Ports:   []string{"14000"},
+				Outputs: map[string][]string{"sum": {"b"}},
+				Secrets: map[string]string{"sum": "id"},
+				Reproducibility: &Reproducibility{
+					Seed:               "seed",
+					Preprocessing:      PreprocessingInsecure,
+					InsecureTupleCount: 5,
+					Protocol:           "mascot",
+					ProgramHash:        "hash",
+					CompilerOptions:    &CompilerOptions{OptimizationLevel: 1, BitLength: 64, Budget: 10, Prime: "p"},
+					RuntimeArgs:        []string{"-v"},
+					Prime:              "p",
+					Gf2nBitLength:      40,
+					PlayerCount:        2,
+					PlayerID:           1,
+					Versions:           map[string]string{"ephemeral": "1.0.0"},
+				},
+			})
+			data, err := encodeResult(ContentTypeProtobuf, result)
+			Expect(err).NotTo(HaveOccurred())
+			var res apb.Result
+			Expect(proto.Unmarshal(data, &res)).To(Succeed())
+			Expect(proto.Equal(&res, &apb.Result{
+				Response: []string{"a"},
+				Warning:  &apb.TruncationWarning{Truncated: true, Reason: "size", Offset: 1},
+				Error:    "failed",
+				Diagnostics: &apb.Diagnostics{
+					InputBytes:  1,
+					OutputBytes: 2,
+					Amphora: []*apb.AmphoraInteraction{
+						{Operation: "create", SecretId: "id", Size: 3, DurationMs: 4, Status: 500, Error: "failed"},
+					},
+				},
+				Ports:   []string{"14000"},
+				Outputs: map[string]*apb.Values{"sum": {Values: []string{"b"}}},
+				Secrets: map[string]string{"sum": "id"},
+				Reproducibility: &apb.Reproducibility{
+					Seed:               "seed",
+					Preprocessing:      PreprocessingInsecure,
+					InsecureTupleCount: 5,
+					Protocol:           "mascot",
+					ProgramHash:        "hash",
+					CompilerOptions:    &apb.CompilerOptions{OptimizationLevel: 1, BitLength: 64, Budget: 10, Prime: "p"},
+					RuntimeArgs:        []string{"-v"},
+					Prime:              "p",
+					Gf2NBitLength:      40,
+					PlayerCount:        2,
+					PlayerID:           1,
+					Versions:           map[string]string{"ephemeral": "1.0.0"},
+				},
+			})).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,113 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// ExecutionsPath is the path the executions are served on, followed by the id of the game.
+const ExecutionsPath = "/executions/"
+
+// Statuses of an execution.
+const (
+	// ExecutionDeliveryPending is the status of an execution whose computation succeeded, but whose result is still
+	// being delivered.
+	ExecutionDeliveryPending = "SucceededDeliveryPending"
+	// ExecutionSucceeded is the status of an execution whose result has been delivered.
+	ExecutionSucceeded = "Succeeded"
+	// ExecutionFailed is the status of an execution whose result could not be delivered.
+	ExecutionFailed = "Failed"
+)
+
+// maxExecutions is the number of executions kept, the oldest ones are dropped first.
+const maxExecutions = 1000
+
+// Execution is the outcome of an activation whose result is delivered in the background.
+type Execution struct {
+	GameID string `json:"gameID"`
+	Status string `json:"status"`
+	// Response is the result of the execution once it has been delivered, e.g., the ids of the Amphora secrets.
+	Response []string `json:"response,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	// owner is the user that triggered the execution, only they may retrieve it.
+	owner string
+}
+
+// newExecutions returns an empty store of executions.
+func newExecutions() *executions {
+	return &executions{byGameID: map[string]*Execution{}}
+}
+
+// executions keeps the executions whose result is delivered in the background.
+type executions struct {
+	mux      sync.Mutex
+	byGameID map[string]*Execution
+	order    []string
+}
+
+// put records the execution, replacing an existing one of the same game.
+func (e *executions) put(ex Execution) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	if _, ok := e.byGameID[ex.GameID]; !ok {
+		e.order = append(e.order, ex.GameID)
+	}
+	e.byGameID[ex.GameID] = &ex
+	for len(e.order) > maxExecutions {
+		delete(e.byGameID, e.order[0])
+		e.order = e.order[1:]
+	}
+}
+
+// get returns the execution of the given game.
+func (e *executions) get(gameID string) (Execution, bool) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	ex, ok := e.byGameID[gameID]
+	if !ok {
+		return Execution{}, false
+	}
+	return *ex, true
+}
+
+// ExecutionsHandler serves the execution of the game given in the path to the user that triggered it.
+func (s *Server) ExecutionsHandler(writer http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		msg := "GET requests must be used to retrieve an execution"
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return
+	}
+	user, err := GetUserFromAuthHeader(req.Header.Get("Authorization"), s.authUserIdField)
+	if err != nil {
+		msg := "unauthorized request"
+		writer.WriteHeader(http.StatusUnauthorized)
+		writer.Write([]byte(msg))
+		s.logger.Errorw(msg, "Error", err)
+		return
+	}
+	gameID := strings.TrimPrefix(req.URL.Path, ExecutionsPath)
+	ex, ok := s.executions.get(gameID)
+	// Executions of other users are reported as missing to not disclose their existence.
+	if !ok || ex.owner != user {
+		msg := fmt.Sprintf("no execution found for game %s", gameID)
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write([]byte(msg))
+		s.logger.Errorw(msg, GameID, gameID)
+		return
+	}
+	body, _ := json.Marshal(ex)
+	writer.Header().Set("Content-Type", ContentTypeJSON)
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(body)
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Executions", func() {
+	const gameID = "71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4"
+	authHeader := func(user string) string {
+		claims := base64.StdEncoding.WithPadding(base64.NoPadding).EncodeToString([]byte(fmt.Sprintf(`{"sub":"%s"}`, user)))
+		return fmt.Sprintf("Bearer header.%s.signature", claims)
+	}
+	var (
+		s  *Server
+		rr *httptest.ResponseRecorder
+	)
+	BeforeEach(func() {
+		s = NewServer("sub", nil, nil, nil, zap.NewNop().Sugar(), &SPDZEngineTypedConfig{})
+		rr = httptest.NewRecorder()
+		s.executions.put(Execution{GameID: gameID, Status: ExecutionSucceeded, Response: []string{gameID}, owner: "someID"})
+	})
+	It("drops the oldest executions", func() {
+		e := newExecutions()
+		for i := 0; i <= maxExecutions; i++ {
+			e.put(Execution{GameID: fmt.Sprintf("game-%d", i)})
+		}
+		_, ok := e.get("game-0")
+		Expect(ok).To(BeFalse())
+		_, ok = e.get(fmt.Sprintf("game-%d", maxExecutions))
+		Expect(ok).To(BeTrue())
+	})
+	It("serves the execution to the user that triggered it", func() {
+		req, _ := http.NewRequest(http.MethodGet, ExecutionsPath+gameID, nil)
+		req.Header.Add("Authorization", authHeader("someID"))
+		s.ExecutionsHandler(rr, req)
+		Expect(rr.Code).To(Equal(http.StatusOK))
+		Expect(rr.Header().Get("Content-Type")).To(Equal(ContentTypeJSON))
+		Expect(rr.Body.String()).To(Equal(fmt.Sprintf(`{"gameID":"%s","status":"Succeeded","response":["%s"]}`, gameID, gameID)))
+	})
+	It("does not disclose the executions of other users", func() {
+		req, _ := http.NewRequest(http.MethodGet, ExecutionsPath+gameID, nil)
+		req.Header.Add("Authorization", authHeader("otherID"))
+		s.ExecutionsHandler(rr, req)
+		Expect(rr.Code).To(Equal(http.StatusNotFound))
+	})
+	It("rejects unauthorized requests", func() {
+		req, _ := http.NewRequest(http.MethodGet, ExecutionsPath+gameID, nil)
+		s.ExecutionsHandler(rr, req)
+		Expect(rr.Code).To(Equal(http.StatusUnauthorized))
+	})
+	It("rejects other methods than GET", func() {
+		req, _ := http.NewRequest(http.MethodPost, ExecutionsPath+gameID, nil)
+		s.ExecutionsHandler(rr, req)
+		Expect(rr.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
@@ -7,6 +7,11 @@ package ephemeral
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
 	"github.com/carbynestack/ephemeral/pkg/discovery/fsm"
 	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
 	. "github.com/carbynestack/ephemeral/pkg/types"
@@ -47,6 +52,9 @@ func (f *FakeTransportClient) Run(client pb.DiscoveryClient) {
 func (f *FakeTransportClient) Stop() error {
 	return nil
 }
+func (f *FakeTransportClient) Endpoint() string {
+	return "discovery:8080"
+}
 
 type BrokenFakeTransportClient struct {
 }
@@ -66,9 +74,13 @@ func (f *BrokenFakeTransportClient) Run(client pb.DiscoveryClient) {
 func (f *BrokenFakeTransportClient) Stop() error {
 	return nil
 }
+func (f *BrokenFakeTransportClient) Endpoint() string {
+	return ""
+}
 
 type FakePlayer struct {
 	Initialized bool
+	Cancelled   bool
 	history     *fsm.History
 }
 
@@ -88,6 +100,9 @@ func (f *FakePlayer) Bus() mb.MessageBus {
 func (f *FakePlayer) PublishEvent(name, topic string, event *pb.Event) {
 	return
 }
+func (f *FakePlayer) Cancel() {
+	f.Cancelled = true
+}
 
 type FakeExecutor struct {
 }
@@ -96,6 +111,67 @@ func (f *FakeExecutor) CallCMD(ctx context.Context, cmd []string, dir string) ([
 	return []byte{}, []byte{}, nil
 }
 
+type CountingFakeExecutor struct {
+	Calls int
+}
+
+func (f *CountingFakeExecutor) CallCMD(ctx context.Context, cmd []string, dir string) ([]byte, []byte, error) {
+	f.Calls++
+	return []byte{}, []byte{}, nil
+}
+
+type RecordingFakeExecutor struct {
+	Commands [][]string
+}
+
+func (f *RecordingFakeExecutor) CallCMD(ctx context.Context, cmd []string, dir string) ([]byte, []byte, error) {
+	f.Commands = append(f.Commands, cmd)
+	return []byte{}, []byte{}, nil
+}
+
+type BlockingFakeExecutor struct {
+	release chan struct{}
+}
+
+func (f *BlockingFakeExecutor) CallCMD(ctx context.Context, cmd []string, dir string) ([]byte, []byte, error) {
+	<-f.release
+	return []byte{}, []byte{}, nil
+}
+
+type OutputFakeExecutor struct {
+	stdout string
+}
+
+func (f *OutputFakeExecutor) CallCMD(ctx context.Context, cmd []string, dir string) ([]byte, []byte, error) {
+	return []byte(f.stdout), []byte{}, nil
+}
+
+// CompilingFakeExecutor writes the schedule and bytecode of a program declaring the given number of threads to the
+// directory the compiler runs in and streams the given output.
+type CompilingFakeExecutor struct {
+	threads int
+	stdout  string
+	dirs    []string
+}
+
+func (f *CompilingFakeExecutor) CallCMD(ctx context.Context, cmd []string, dir string) ([]byte, []byte, error) {
+	return f.CallCMDWithOutput(ctx, cmd, dir, nil, nil)
+}
+
+func (f *CompilingFakeExecutor) CallCMDWithOutput(ctx context.Context, cmd []string, dir string, stdout io.Writer, stderr io.Writer) ([]byte, []byte, error) {
+	f.dirs = append(f.dirs, dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "Programs/Schedules", appName+".sch"), []byte(fmt.Sprintf("%d\n", f.threads)), 0644); err != nil {
+		return nil, nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "Programs/Bytecode", appName+"-0.bc"), []byte("12345"), 0644); err != nil {
+		return nil, nil, err
+	}
+	if stdout != nil {
+		stdout.Write([]byte(f.stdout))
+	}
+	return []byte(f.stdout), []byte{}, nil
+}
+
 type BrokenFakeExecutor struct {
 }
 
@@ -123,6 +199,19 @@ func (f *FakeProxy) Stop() {
 	return
 }
 
+// CountingFakeProxy records how often it has been started and stopped.
+type CountingFakeProxy struct {
+	runs, stops int
+}
+
+func (f *CountingFakeProxy) Run(*CtxConfig, chan error) error {
+	f.runs++
+	return nil
+}
+func (f *CountingFakeProxy) Stop() {
+	f.stops++
+}
+
 type BrokenFakeProxy struct {
 }
 
@@ -136,10 +225,10 @@ func (f *BrokenFakeProxy) Stop() {
 type FakeFeeder struct {
 }
 
-func (f *FakeFeeder) LoadFromSecretStoreAndFeed(act *Activation, feedPort string, ctx *CtxConfig) ([]byte, error) {
+func (f *FakeFeeder) LoadFromSecretStoreAndFeed(act *Activation, feedPorts []string, ctx *CtxConfig) ([]byte, error) {
 	return []byte(ctx.Act.AmphoraParams[0]), nil
 }
-func (f *FakeFeeder) LoadFromRequestAndFeed(act *Activation, feedPort string, ctx *CtxConfig) ([]byte, error) {
+func (f *FakeFeeder) LoadFromRequestAndFeed(act *Activation, feedPorts []string, ctx *CtxConfig) ([]byte, error) {
 	return []byte(ctx.Act.SecretParams[0]), nil
 }
 func (f *FakeFeeder) Close() error {
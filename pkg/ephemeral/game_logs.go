@@ -0,0 +1,307 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// GamesPath is the path the logs of the MPC runtime are served on, as in /games/{id}/logs.
+const GamesPath = "/games/"
+
+// gameLogsSuffix is the path following the id of the game when requesting its logs.
+const gameLogsSuffix = "/logs"
+
+// Streams of the MPC runtime.
+const (
+	streamStdout = "stdout"
+	streamStderr = "stderr"
+)
+
+// progressInterval is the interval progress events are sent in to the readers of the logs of a game whose duration can
+// be estimated.
+const progressInterval = 5 * time.Second
+
+// Limits of the logs kept, the oldest games and lines are dropped first.
+const (
+	maxGameLogs     = 100
+	maxGameLogLines = 10000
+)
+
+// logLine is a line written by the MPC runtime to one of its streams.
+type logLine struct {
+	stream string
+	text   string
+}
+
+// newGameLog returns an empty log of a game triggered by the given user.
+func newGameLog(owner string) *gameLog {
+	return &gameLog{owner: owner, started: time.Now(), changed: make(chan struct{})}
+}
+
+// gameLog keeps the output of the MPC runtime of a game and notifies the readers tailing it about new lines.
+type gameLog struct {
+	mux     sync.Mutex
+	owner   string
+	started time.Time
+	// expected is the expected duration of the game, nil if it cannot be estimated.
+	expected *expectedDuration
+	lines    []logLine
+	// dropped is the number of lines dropped from the head of the log.
+	dropped int
+	// partial are the unterminated lines of the streams.
+	partial map[string][]byte
+	done    bool
+	// changed is closed and replaced once lines are added or the log is finished.
+	changed chan struct{}
+}
+
+// writer returns a writer appending the lines written to it to the given stream of the log.
+func (g *gameLog) writer(stream string) *gameLogWriter {
+	return &gameLogWriter{log: g, stream: stream}
+}
+
+// write appends the complete lines of p to the given stream.
+func (g *gameLog) write(stream string, p []byte) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	if g.done {
+		return
+	}
+	if g.partial == nil {
+		g.partial = map[string][]byte{}
+	}
+	buf := append(g.partial[stream], p...)
+	for {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			break
+		}
+		g.append(stream, strings.TrimSuffix(string(buf[:i]), "\r"))
+		buf = buf[i+1:]
+	}
+	g.partial[stream] = append([]byte{}, buf...)
+	g.notify()
+}
+
+// expect sets the expected duration of the game reported in the progress events.
+func (g *gameLog) expect(e expectedDuration) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	g.expected = &e
+}
+
+// progress returns the progress of the game or nil if its duration cannot be estimated.
+func (g *gameLog) progress() *gameProgress {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	if g.expected == nil {
+		return nil
+	}
+	elapsed := time.Since(g.started)
+	remaining := g.expected.P50 - elapsed
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &gameProgress{
+		Elapsed:             elapsed.Round(time.Millisecond).String(),
+		Remaining:           remaining.Round(time.Millisecond).String(),
+		EstimatedCompletion: g.started.Add(g.expected.P50).UTC().Format(time.RFC3339),
+		Expected: map[string]string{
+			"p50": g.expected.P50.Round(time.Millisecond).String(),
+			"p90": g.expected.P90.Round(time.Millisecond).String(),
+			"p99": g.expected.P99.Round(time.Millisecond).String(),
+		},
+		Samples: g.expected.Samples,
+	}
+}
+
+// gameProgress is the data of the progress events. The durations are estimated from the recent activations of the
+// program of the game.
+type gameProgress struct {
+	Elapsed   string `json:"elapsed"`
+	Remaining string `json:"remaining"`
+	// EstimatedCompletion is the time the result is expected to be available at, based on the median duration.
+	EstimatedCompletion string `json:"estimatedCompletion"`
+	// Expected are the percentiles of the duration, indexed by "p50", "p90" and "p99".
+	Expected map[string]string `json:"expected"`
+	Samples  int               `json:"samples"`
+}
+
+// finish flushes the unterminated lines and wakes up the readers for the last time.
+func (g *gameLog) finish() {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	if g.done {
+		return
+	}
+	for _, stream := range []string{streamStdout, streamStderr} {
+		if len(g.partial[stream]) > 0 {
+			g.append(stream, string(g.partial[stream]))
+		}
+	}
+	g.partial = nil
+	g.done = true
+	g.notify()
+}
+
+// append adds a line and drops the oldest one if the log is full. The caller must hold the lock.
+func (g *gameLog) append(stream string, text string) {
+	g.lines = append(g.lines, logLine{stream: stream, text: text})
+	if len(g.lines) > maxGameLogLines {
+		g.lines = g.lines[1:]
+		g.dropped++
+	}
+}
+
+// notify wakes up the readers. The caller must hold the lock.
+func (g *gameLog) notify() {
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
+
+// read returns the lines following the given offset, the offset of the next line, whether the log is finished and a
+// channel closed once the log changes. Lines dropped in the meantime are skipped.
+func (g *gameLog) read(offset int) ([]logLine, int, bool, <-chan struct{}) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	if offset < g.dropped {
+		offset = g.dropped
+	}
+	lines := append([]logLine{}, g.lines[offset-g.dropped:]...)
+	return lines, g.dropped + len(g.lines), g.done, g.changed
+}
+
+// gameLogWriter is an io.Writer appending to a stream of a game log.
+type gameLogWriter struct {
+	log    *gameLog
+	stream string
+}
+
+// Write appends the lines written to the stream. It never fails, so that the MPC runtime is not affected by the log.
+func (w *gameLogWriter) Write(p []byte) (int, error) {
+	w.log.write(w.stream, p)
+	return len(p), nil
+}
+
+// newGameLogs returns an empty store of game logs.
+func newGameLogs() *gameLogs {
+	return &gameLogs{byGameID: map[string]*gameLog{}}
+}
+
+// gameLogs keeps the logs of the most recent games.
+type gameLogs struct {
+	mux      sync.Mutex
+	byGameID map[string]*gameLog
+	order    []string
+}
+
+// start creates the log of the given game, replacing an existing one.
+func (l *gameLogs) start(gameID string, owner string) *gameLog {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if _, ok := l.byGameID[gameID]; !ok {
+		l.order = append(l.order, gameID)
+	}
+	log := newGameLog(owner)
+	l.byGameID[gameID] = log
+	for len(l.order) > maxGameLogs {
+		delete(l.byGameID, l.order[0])
+		l.order = l.order[1:]
+	}
+	return log
+}
+
+// get returns the log of the given game.
+func (l *gameLogs) get(gameID string) (*gameLog, bool) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	log, ok := l.byGameID[gameID]
+	return log, ok
+}
+
+// GameLogsHandler tails the output of the MPC runtime of the game given in the path, as in /games/{id}/logs, to the
+// user that triggered it, or to admins if authorization scopes are configured. The lines are sent as server-sent events
+// named after the stream they were written to, an end event is sent once the runtime has finished. If the duration of
+// the game can be estimated from past activations of its program, progress events reporting the elapsed and the
+// expected duration are sent periodically while it runs.
+func (s *Server) GameLogsHandler(writer http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		msg := "GET requests must be used to retrieve the logs of a game"
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return
+	}
+	user, err := GetUserFromAuthHeader(req.Header.Get("Authorization"), s.authUserIdField)
+	if err != nil {
+		msg := "unauthorized request"
+		writer.WriteHeader(http.StatusUnauthorized)
+		writer.Write([]byte(msg))
+		s.logger.Errorw(msg, "Error", err)
+		return
+	}
+	path := strings.TrimPrefix(req.URL.Path, GamesPath)
+	if !strings.HasSuffix(path, gameLogsSuffix) {
+		msg := fmt.Sprintf("no resource found at %s", req.URL.Path)
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return
+	}
+	gameID := strings.TrimSuffix(path, gameLogsSuffix)
+	log, ok := s.gameLogs.get(gameID)
+	// Logs of other users are reported as missing to not disclose their existence, unless requested by an admin.
+	if !ok || (log.owner != user && !s.isAdmin(req)) {
+		msg := fmt.Sprintf("no logs found for game %s", gameID)
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write([]byte(msg))
+		s.logger.Errorw(msg, GameID, gameID)
+		return
+	}
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.WriteHeader(http.StatusOK)
+	flusher, _ := writer.(http.Flusher)
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	sendProgress := true
+	for offset := 0; ; {
+		lines, next, done, changed := log.read(offset)
+		if p := log.progress(); p != nil && sendProgress && !done {
+			data, _ := json.Marshal(p)
+			fmt.Fprintf(writer, "event: progress\ndata: %s\n\n", data)
+		}
+		sendProgress = false
+		for _, l := range lines {
+			fmt.Fprintf(writer, "event: %s\ndata: %s\n\n", l.stream, l.text)
+		}
+		offset = next
+		if done {
+			writer.Write([]byte("event: end\ndata: \n\n"))
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if done {
+			return
+		}
+		select {
+		case <-changed:
+		case <-ticker.C:
+			sendProgress = true
+		case <-req.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Game logs", func() {
+	const gameID = "71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4"
+
+	Context("when writing to a game log", func() {
+		var log *gameLog
+		BeforeEach(func() {
+			log = newGameLog("someID")
+		})
+		It("splits the output into lines per stream", func() {
+			log.writer(streamStdout).Write([]byte("a\nb"))
+			log.writer(streamStderr).Write([]byte("c\r\n"))
+			log.writer(streamStdout).Write([]byte("c\n"))
+			lines, next, done, _ := log.read(0)
+			Expect(lines).To(Equal([]logLine{{streamStdout, "a"}, {streamStderr, "c"}, {streamStdout, "bc"}}))
+			Expect(next).To(Equal(3))
+			Expect(done).To(BeFalse())
+		})
+		It("flushes unterminated lines when finished", func() {
+			log.writer(streamStdout).Write([]byte("a"))
+			log.finish()
+			lines, _, done, _ := log.read(0)
+			Expect(lines).To(Equal([]logLine{{streamStdout, "a"}}))
+			Expect(done).To(BeTrue())
+		})
+		It("wakes up readers on changes", func() {
+			_, _, _, changed := log.read(0)
+			log.writer(streamStdout).Write([]byte("a\n"))
+			Eventually(changed).Should(BeClosed())
+		})
+		It("drops the oldest lines once full", func() {
+			for i := 0; i <= maxGameLogLines; i++ {
+				log.writer(streamStdout).Write([]byte(fmt.Sprintf("%d\n", i)))
+			}
+			lines, next, _, _ := log.read(0)
+			Expect(lines).To(HaveLen(maxGameLogLines))
+			Expect(lines[0].text).To(Equal("1"))
+			Expect(next).To(Equal(maxGameLogLines + 1))
+		})
+	})
+	Context("when keeping the logs of several games", func() {
+		It("drops the logs of the oldest games", func() {
+			logs := newGameLogs()
+			for i := 0; i <= maxGameLogs; i++ {
+				logs.start(fmt.Sprint(i), "someID")
+			}
+			_, ok := logs.get("0")
+			Expect(ok).To(BeFalse())
+			_, ok = logs.get(fmt.Sprint(maxGameLogs))
+			Expect(ok).To(BeTrue())
+		})
+	})
+	Context("when tailing the logs of a game", func() {
+		var (
+			s          *Server
+			rr         *httptest.ResponseRecorder
+			authHeader string
+		)
+		BeforeEach(func() {
+			s = NewServer("sub", nil, nil, nil, zap.NewNop().Sugar(), &SPDZEngineTypedConfig{})
+			rr = httptest.NewRecorder()
+			authHeader = fmt.Sprintf("Bearer header.%s.signature", base64.StdEncoding.WithPadding(base64.NoPadding).EncodeToString([]byte(`{"sub":"someID"}`)))
+		})
+		request := func(path string) *http.Request {
+			req, _ := http.NewRequest(http.MethodGet, path, nil)
+			req.Header.Add("Authorization", authHeader)
+			return req
+		}
+		It("sends the lines as server-sent events until the runtime finishes", func() {
+			log := s.gameLogs.start(gameID, "someID")
+			log.writer(streamStdout).Write([]byte("a\n"))
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				log.writer(streamStderr).Write([]byte("b\n"))
+				log.finish()
+			}()
+			s.GameLogsHandler(rr, request(GamesPath+gameID+gameLogsSuffix))
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(rr.Header().Get("Content-Type")).To(Equal("text/event-stream"))
+			Expect(rr.Body.String()).To(Equal("event: stdout\ndata: a\n\nevent: stderr\ndata: b\n\nevent: end\ndata: \n\n"))
+		})
+		It("reports the progress of games whose duration can be estimated", func() {
+			log := s.gameLogs.start(gameID, "someID")
+			log.expect(expectedDuration{Samples: 3, P50: time.Hour, P90: 2 * time.Hour, P99: 3 * time.Hour})
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				log.finish()
+			}()
+			s.GameLogsHandler(rr, request(GamesPath+gameID+gameLogsSuffix))
+			body := rr.Body.String()
+			Expect(body).To(HavePrefix("event: progress\ndata: {"))
+			Expect(body).To(HaveSuffix("}\n\nevent: end\ndata: \n\n"))
+			data := strings.TrimSuffix(strings.TrimPrefix(body, "event: progress\ndata: "), "\n\nevent: end\ndata: \n\n")
+			var p gameProgress
+			Expect(json.Unmarshal([]byte(data), &p)).To(Succeed())
+			Expect(p.Expected).To(Equal(map[string]string{"p50": "1h0m0s", "p90": "2h0m0s", "p99": "3h0m0s"}))
+			Expect(p.Samples).To(Equal(3))
+			remaining, err := time.ParseDuration(p.Remaining)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(remaining).To(BeNumerically("~", time.Hour, time.Second))
+			completion, err := time.Parse(time.RFC3339, p.EstimatedCompletion)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(completion).To(BeTemporally("~", time.Now().Add(time.Hour), 2*time.Second))
+		})
+		It("responds with 404 for games of other users", func() {
+			s.gameLogs.start(gameID, "otherID")
+			s.GameLogsHandler(rr, request(GamesPath+gameID+gameLogsSuffix))
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+			Expect(rr.Body.String()).To(Equal("no logs found for game " + gameID))
+		})
+		It("serves the logs of games of other users to admins", func() {
+			s.config.AuthScopes = &AuthScopesConfig{Claim: "scope"}
+			authHeader = fmt.Sprintf("Bearer header.%s.signature", base64.StdEncoding.WithPadding(base64.NoPadding).EncodeToString([]byte(`{"sub":"someID","scope":"admin"}`)))
+			s.gameLogs.start(gameID, "otherID").finish()
+			s.GameLogsHandler(rr, request(GamesPath+gameID+gameLogsSuffix))
+			Expect(rr.Code).To(Equal(http.StatusOK))
+		})
+		It("responds with 404 for other resources of a game", func() {
+			s.gameLogs.start(gameID, "someID")
+			s.GameLogsHandler(rr, request(GamesPath+gameID))
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+		})
+		It("responds with 401 if the request is not authorized", func() {
+			authHeader = ""
+			s.GameLogsHandler(rr, request(GamesPath+gameID+gameLogsSuffix))
+			Expect(rr.Code).To(Equal(http.StatusUnauthorized))
+		})
+		It("responds with 405 for other methods than GET", func() {
+			req := request(GamesPath + gameID + gameLogsSuffix)
+			req.Method = http.MethodPost
+			s.GameLogsHandler(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+})
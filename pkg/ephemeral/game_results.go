@@ -0,0 +1,117 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/results"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// gameResultSuffix is the path following the id of the game when requesting its result.
+const gameResultSuffix = "/result"
+
+// GamesHandler serves the resources of the games, i.e., /games/{id}/logs and /games/{id}/result, and cancels games on
+// DELETE /games/{id}.
+func (s *Server) GamesHandler(writer http.ResponseWriter, req *http.Request) {
+	switch {
+	case req.Method == http.MethodDelete && !strings.Contains(strings.TrimPrefix(req.URL.Path, GamesPath), "/"):
+		s.GameCancelHandler(writer, req)
+	case strings.HasSuffix(req.URL.Path, gameResultSuffix):
+		s.GameResultHandler(writer, req)
+	default:
+		s.GameLogsHandler(writer, req)
+	}
+}
+
+// cacheResult keeps the result of the finished game, if a result cache is configured and the result does not exceed the
+// maximum size. Failing to keep the result does not fail the game, as the result has already been returned to the
+// client.
+func (s *Server) cacheResult(ctx *CtxConfig, result []byte) {
+	if s.config.ResultCache == nil {
+		return
+	}
+	if s.config.MaxResultSize > 0 && int64(len(result)) > s.config.MaxResultSize {
+		s.activationLogger(ctx).Warnw("Not caching the result of the game exceeding the maximum size", GameID, ctx.Act.GameID, "MaxResultSize", s.config.MaxResultSize)
+		return
+	}
+	err := s.config.ResultCache.Put(results.Entry{
+		GameID:   ctx.Act.GameID,
+		Owner:    ctx.AuthorizedUser,
+		Result:   result,
+		Finished: time.Now(),
+	})
+	if err != nil {
+		s.activationLogger(ctx).Errorw(fmt.Sprintf("Failed to cache the result of the game: %s", err), GameID, ctx.Act.GameID)
+	}
+}
+
+// GameResultHandler returns the cached result of the game given in the path, as in /games/{id}/result, to the user
+// that triggered it, or to admins if authorization scopes are configured. The result is encoded as requested by the
+// Accept header, like the response of the activation.
+func (s *Server) GameResultHandler(writer http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		msg := "GET requests must be used to retrieve the result of a game"
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return
+	}
+	user, err := GetUserFromAuthHeader(req.Header.Get("Authorization"), s.authUserIdField)
+	if err != nil {
+		msg := "unauthorized request"
+		writer.WriteHeader(http.StatusUnauthorized)
+		writer.Write([]byte(msg))
+		s.logger.Errorw(msg, "Error", err)
+		return
+	}
+	gameID := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, GamesPath), gameResultSuffix)
+	if s.config.ResultCache == nil {
+		msg := "results are not cached"
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write([]byte(msg))
+		s.logger.Errorw(msg, GameID, gameID)
+		return
+	}
+	if !isValidUUID(gameID) {
+		msg := fmt.Sprintf("game id %s is not a valid UUID", gameID)
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return
+	}
+	entry, err := s.config.ResultCache.Get(gameID)
+	if err != nil && err != results.ErrNotFound {
+		msg := fmt.Sprintf("error reading the result of game %s: %s", gameID, err)
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write([]byte(msg))
+		s.logger.Errorw(msg, GameID, gameID)
+		return
+	}
+	// Results of other users are reported as missing to not disclose their existence, unless requested by an admin.
+	if err == results.ErrNotFound || (entry.Owner != user && !s.isAdmin(req)) {
+		msg := fmt.Sprintf("no result found for game %s", gameID)
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write([]byte(msg))
+		s.logger.Errorw(msg, GameID, gameID)
+		return
+	}
+	contentType := s.responseContentType(req)
+	body, err := encodeResult(contentType, entry.Result)
+	if err != nil {
+		msg := fmt.Sprintf("error encoding the result: %s", err)
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write([]byte(msg))
+		s.logger.Errorw(msg, GameID, gameID)
+		return
+	}
+	writer.Header().Set("Content-Type", contentType)
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(body)
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/results"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Game results", func() {
+	const gameID = "71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4"
+	var (
+		s          *Server
+		store      *results.MemoryStore
+		rr         *httptest.ResponseRecorder
+		authHeader string
+	)
+	BeforeEach(func() {
+		store = results.NewMemoryStore(results.DefaultMaxResults, results.DefaultTTL)
+		s = NewServer("sub", nil, nil, nil, zap.NewNop().Sugar(), &SPDZEngineTypedConfig{ResultCache: store})
+		rr = httptest.NewRecorder()
+		authHeader = fmt.Sprintf("Bearer header.%s.signature", base64.StdEncoding.WithPadding(base64.NoPadding).EncodeToString([]byte(`{"sub":"someID"}`)))
+	})
+	request := func(path string) *http.Request {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Add("Authorization", authHeader)
+		return req
+	}
+	put := func(owner string) {
+		Expect(store.Put(results.Entry{GameID: gameID, Owner: owner, Result: []byte(`{"response":["a"]}`), Finished: time.Now()})).To(Succeed())
+	}
+	It("caches the results of finished games", func() {
+		s.cacheResult(&CtxConfig{Act: &Activation{GameID: gameID}, AuthorizedUser: "someID"}, []byte(`{"response":["a"]}`))
+		e, err := store.Get(gameID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(e.Owner).To(Equal("someID"))
+		Expect(e.Result).To(MatchJSON(`{"response":["a"]}`))
+	})
+	It("does not cache results exceeding the maximum size", func() {
+		s.config.MaxResultSize = 8
+		s.cacheResult(&CtxConfig{Act: &Activation{GameID: gameID}, AuthorizedUser: "someID"}, []byte(`{"response":["a"]}`))
+		_, err := store.Get(gameID)
+		Expect(err).To(Equal(results.ErrNotFound))
+	})
+	It("returns the cached result of a game", func() {
+		put("someID")
+		s.GamesHandler(rr, request(GamesPath+gameID+gameResultSuffix))
+		Expect(rr.Code).To(Equal(http.StatusOK))
+		Expect(rr.Header().Get("Content-Type")).To(Equal(ContentTypeJSON))
+		Expect(rr.Body.String()).To(MatchJSON(`{"response":["a"]}`))
+	})
+	It("responds with 404 for games without a cached result", func() {
+		s.GamesHandler(rr, request(GamesPath+gameID+gameResultSuffix))
+		Expect(rr.Code).To(Equal(http.StatusNotFound))
+		Expect(rr.Body.String()).To(Equal("no result found for game " + gameID))
+	})
+	It("responds with 404 for games of other users", func() {
+		put("otherID")
+		s.GamesHandler(rr, request(GamesPath+gameID+gameResultSuffix))
+		Expect(rr.Code).To(Equal(http.StatusNotFound))
+		Expect(rr.Body.String()).To(Equal("no result found for game " + gameID))
+	})
+	It("returns the results of games of other users to admins", func() {
+		s.config.AuthScopes = &AuthScopesConfig{Claim: "scope"}
+		authHeader = fmt.Sprintf("Bearer header.%s.signature", base64.StdEncoding.WithPadding(base64.NoPadding).EncodeToString([]byte(`{"sub":"someID","scope":"admin"}`)))
+		put("otherID")
+		s.GamesHandler(rr, request(GamesPath+gameID+gameResultSuffix))
+		Expect(rr.Code).To(Equal(http.StatusOK))
+	})
+	It("responds with 400 if the game id is not a UUID", func() {
+		s.GamesHandler(rr, request(GamesPath+"notAUUID"+gameResultSuffix))
+		Expect(rr.Code).To(Equal(http.StatusBadRequest))
+	})
+	It("responds with 404 if the results are not cached", func() {
+		s.config.ResultCache = nil
+		s.GamesHandler(rr, request(GamesPath+gameID+gameResultSuffix))
+		Expect(rr.Code).To(Equal(http.StatusNotFound))
+	})
+	It("responds with 401 if the request is not authorized", func() {
+		authHeader = ""
+		s.GamesHandler(rr, request(GamesPath+gameID+gameResultSuffix))
+		Expect(rr.Code).To(Equal(http.StatusUnauthorized))
+	})
+})
@@ -0,0 +1,190 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// errGameCancelled is the error a cancelled game fails with.
+var errGameCancelled = errors.New("the game has been cancelled")
+
+// GameInFlightError is returned when a game is activated while a game of the same id is executing on the pod.
+type GameInFlightError struct {
+	GameID string `json:"gameId"`
+	// State is the state of the player of the executing game.
+	State string    `json:"state"`
+	Since time.Time `json:"since"`
+}
+
+func (e *GameInFlightError) Error() string {
+	return fmt.Sprintf("game %s is already executing on this pod since %s, its player is in state %s", e.GameID,
+		e.Since.Format(time.RFC3339), e.State)
+}
+
+// newGamesInFlight returns an empty registry of the games in flight.
+func newGamesInFlight() *gamesInFlight {
+	return &gamesInFlight{games: map[string]*gameInFlight{}}
+}
+
+// gamesInFlight keeps track of the games executing on the pod by their ids, so that a game id reused while the first
+// game is executing is rejected instead of colliding with the first game on its topics, ports and work directory.
+type gamesInFlight struct {
+	mux   sync.Mutex
+	games map[string]*gameInFlight
+}
+
+// gameInFlight is a game executing on the pod. It observes the state transitions of the player of the game.
+type gameInFlight struct {
+	mux     sync.Mutex
+	state   string
+	started time.Time
+	// owner is the user that triggered the game.
+	owner string
+	// refs is the number of parties holding on to the game, see gamesInFlight.retain.
+	refs int
+	// cancelled is closed once the game has been cancelled.
+	cancelled chan struct{}
+}
+
+// OnTransition records the state the player of the game entered.
+func (g *gameInFlight) OnTransition(src, event, dst, gameID string) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	g.state = dst
+}
+
+// cancel requests the cancellation of the game. Returns false if the game has already been cancelled.
+func (g *gameInFlight) cancel() bool {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	select {
+	case <-g.cancelled:
+		return false
+	default:
+		close(g.cancelled)
+		return true
+	}
+}
+
+// isCancelled returns true if the cancellation of the game has been requested.
+func (g *gameInFlight) isCancelled() bool {
+	select {
+	case <-g.cancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// begin registers the game of the given id triggered by the given user. Returns an error if a game of the same id is in
+// flight.
+func (f *gamesInFlight) begin(gameID string, owner string) *GameInFlightError {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if g, ok := f.games[gameID]; ok {
+		g.mux.Lock()
+		defer g.mux.Unlock()
+		return &GameInFlightError{GameID: gameID, State: g.state, Since: g.started}
+	}
+	f.games[gameID] = &gameInFlight{state: Init, started: time.Now(), owner: owner, refs: 1, cancelled: make(chan struct{})}
+	return nil
+}
+
+// retain keeps the game of the given id registered until end is called once more, e.g., while its result is delivered
+// in the background.
+func (f *gamesInFlight) retain(gameID string) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if g, ok := f.games[gameID]; ok {
+		g.refs++
+	}
+}
+
+// end unregisters the game of the given id once it has been ended as often as it has been begun and retained.
+func (f *gamesInFlight) end(gameID string) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	g, ok := f.games[gameID]
+	if !ok {
+		return
+	}
+	g.refs--
+	if g.refs <= 0 {
+		delete(f.games, gameID)
+	}
+}
+
+// get returns the game of the given id, nil if it is not in flight.
+func (f *gamesInFlight) get(gameID string) *gameInFlight {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return f.games[gameID]
+}
+
+// writeGameInFlightError responds with the conflict and the state of the game executing on the pod.
+func writeGameInFlightError(writer http.ResponseWriter, err *GameInFlightError) {
+	body, _ := json.Marshal(struct {
+		Error string `json:"error"`
+		*GameInFlightError
+	}{err.Error(), err})
+	writer.Header().Set("Content-Type", ContentTypeJSON)
+	writer.WriteHeader(http.StatusConflict)
+	writer.Write(body)
+}
+
+// abortError returns the error of an activation whose context is done, i.e., whether its game has been cancelled or it
+// timed out.
+func (s *Server) abortError(ctx *CtxConfig) error {
+	if game := s.gamesInFlight.get(ctx.Act.GameID); game != nil && game.isCancelled() {
+		return errGameCancelled
+	}
+	return errors.New("timeout during activation procedure")
+}
+
+// GameCancelHandler cancels the game given in the path, as in DELETE /games/{id}, on behalf of the user that triggered
+// it, or of admins if authorization scopes are configured. The cancellation is asynchronous: the player reports the
+// failure of the game to the discovery service, so that the other players abort the game as well, and the MPC runtime
+// and the tuple streamers of the game are terminated. The activation of the game is answered with 409.
+func (s *Server) GameCancelHandler(writer http.ResponseWriter, req *http.Request) {
+	user, err := GetUserFromAuthHeader(req.Header.Get("Authorization"), s.authUserIdField)
+	if err != nil {
+		msg := "unauthorized request"
+		writer.WriteHeader(http.StatusUnauthorized)
+		writer.Write([]byte(msg))
+		s.logger.Errorw(msg, "Error", err)
+		return
+	}
+	gameID := strings.TrimPrefix(req.URL.Path, GamesPath)
+	if !isValidUUID(gameID) {
+		msg := fmt.Sprintf("game id %s is not a valid UUID", gameID)
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return
+	}
+	game := s.gamesInFlight.get(gameID)
+	// Games of other users are reported as missing to not disclose their existence, unless cancelled by an admin.
+	if game == nil || (game.owner != user && !s.isAdmin(req)) {
+		msg := fmt.Sprintf("no game in flight found for game %s", gameID)
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write([]byte(msg))
+		s.logger.Errorw(msg, GameID, gameID)
+		return
+	}
+	if game.cancel() {
+		s.logger.Infow("Game cancellation requested", GameID, gameID, "User", user)
+	}
+	writer.WriteHeader(http.StatusAccepted)
+	writer.Write([]byte(fmt.Sprintf("cancelling game %s", gameID)))
+}
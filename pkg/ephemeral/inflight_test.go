@@ -0,0 +1,106 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Games in flight", func() {
+	const gameID = "71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4"
+	var games *gamesInFlight
+	BeforeEach(func() {
+		games = newGamesInFlight()
+	})
+	It("rejects a game whose id is in flight", func() {
+		Expect(games.begin(gameID, "someID")).To(BeNil())
+		games.get(gameID).OnTransition(Registering, PlayersReady, Playing, gameID)
+		err := games.begin(gameID, "someID")
+		Expect(err).NotTo(BeNil())
+		Expect(err.State).To(Equal(Playing))
+		Expect(games.begin("a5d8a2b4-4c4e-4b8e-9a53-1f0b6a4c8f11", "someID")).To(BeNil())
+	})
+	It("accepts the id again once the game has ended", func() {
+		Expect(games.begin(gameID, "someID")).To(BeNil())
+		games.end(gameID)
+		Expect(games.get(gameID)).To(BeNil())
+		Expect(games.begin(gameID, "someID")).To(BeNil())
+	})
+	It("keeps a retained game until it has been ended as often", func() {
+		Expect(games.begin(gameID, "someID")).To(BeNil())
+		games.retain(gameID)
+		games.end(gameID)
+		Expect(games.begin(gameID, "someID")).NotTo(BeNil())
+		games.end(gameID)
+		Expect(games.begin(gameID, "someID")).To(BeNil())
+	})
+	It("cancels a game once", func() {
+		Expect(games.begin(gameID, "someID")).To(BeNil())
+		game := games.get(gameID)
+		Expect(game.owner).To(Equal("someID"))
+		Expect(game.cancel()).To(BeTrue())
+		Expect(game.cancelled).To(BeClosed())
+		Expect(game.cancel()).To(BeFalse())
+	})
+	Context("when cancelling a game on DELETE /games/{id}", func() {
+		var (
+			s          *Server
+			rr         *httptest.ResponseRecorder
+			authHeader string
+		)
+		BeforeEach(func() {
+			s = NewServer("sub", nil, nil, nil, zap.NewNop().Sugar(), &SPDZEngineTypedConfig{})
+			rr = httptest.NewRecorder()
+			authHeader = fmt.Sprintf("Bearer header.%s.signature", base64.StdEncoding.WithPadding(base64.NoPadding).EncodeToString([]byte(`{"sub":"someID"}`)))
+		})
+		request := func(path string) *http.Request {
+			req, _ := http.NewRequest(http.MethodDelete, path, nil)
+			req.Header.Add("Authorization", authHeader)
+			return req
+		}
+		It("cancels the game", func() {
+			Expect(s.gamesInFlight.begin(gameID, "someID")).To(BeNil())
+			s.GamesHandler(rr, request(GamesPath+gameID))
+			Expect(rr.Code).To(Equal(http.StatusAccepted))
+			Expect(s.gamesInFlight.get(gameID).isCancelled()).To(BeTrue())
+			Expect(s.abortError(&CtxConfig{Act: &Activation{GameID: gameID}})).To(Equal(errGameCancelled))
+		})
+		It("responds with 404 for games of other users", func() {
+			Expect(s.gamesInFlight.begin(gameID, "otherID")).To(BeNil())
+			s.GamesHandler(rr, request(GamesPath+gameID))
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+			Expect(s.gamesInFlight.get(gameID).isCancelled()).To(BeFalse())
+		})
+		It("cancels games of other users on behalf of admins", func() {
+			s.config.AuthScopes = &AuthScopesConfig{Claim: "scope"}
+			authHeader = fmt.Sprintf("Bearer header.%s.signature", base64.StdEncoding.WithPadding(base64.NoPadding).EncodeToString([]byte(`{"sub":"someID","scope":"admin"}`)))
+			Expect(s.gamesInFlight.begin(gameID, "otherID")).To(BeNil())
+			s.GamesHandler(rr, request(GamesPath+gameID))
+			Expect(rr.Code).To(Equal(http.StatusAccepted))
+		})
+		It("responds with 404 for games not in flight", func() {
+			s.GamesHandler(rr, request(GamesPath+gameID))
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+		})
+		It("responds with 400 if the game id is not a UUID", func() {
+			s.GamesHandler(rr, request(GamesPath+"notAUUID"))
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+		})
+		It("responds with 401 if the request is not authorized", func() {
+			authHeader = ""
+			s.GamesHandler(rr, request(GamesPath+gameID))
+			Expect(rr.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+})
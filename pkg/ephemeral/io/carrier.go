@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/carbynestack/ephemeral/pkg/amphora"
+	. "github.com/carbynestack/ephemeral/pkg/types"
 	"go.uber.org/zap"
 	"io"
 	"io/ioutil"
@@ -20,6 +21,30 @@ import (
 // Result contains the response from SPDZ runtime computation.
 type Result struct {
 	Response []string `json:"response"`
+	// Warning is set if the response contains only a part of the computation output.
+	Warning *TruncationWarning `json:"warning,omitempty"`
+	// Error is set if the computation failed after parts of the output have already been streamed to the client.
+	Error string `json:"error,omitempty"`
+	// Diagnostics are set if requested by the activation.
+	Diagnostics *Diagnostics `json:"diagnostics,omitempty"`
+	// Ports are the ports the client connections of the activation were established to, in the order of the
+	// connections. Only set if the activation defines client connections.
+	Ports []string `json:"ports,omitempty"`
+	// Outputs are the values of the named outputs if the activation declares a result schema. Response is not set in
+	// this case, except for AmphoraSecret outputs.
+	Outputs map[string][]string `json:"outputs,omitempty"`
+	// Secrets are the ids of the Amphora secrets of the named outputs if the activation declares a result schema.
+	Secrets map[string]string `json:"secrets,omitempty"`
+	// Reproducibility describes the parameters of the game affecting its outcome or performance. Only set if the
+	// activation pins a seed or requests diagnostics.
+	Reproducibility *Reproducibility `json:"reproducibility,omitempty"`
+}
+
+// TruncationWarning describes why and where the output of a computation was truncated.
+type TruncationWarning struct {
+	Truncated bool   `json:"truncated"`
+	Reason    string `json:"reason"`
+	Offset    int    `json:"offset"`
 }
 
 var connectionInfo = "ConnectionInfo"
@@ -34,7 +59,9 @@ type AbstractCarrier interface {
 	Connect(context.Context, int32, string, string) error
 	Close() error
 	Send([]amphora.SecretShare) error
+	SendStream(io.Reader, int64, int) error
 	Read(ResponseConverter, bool) (*Result, error)
+	Stream(ResponseConverter, int, func([]Parcel) error) error
 }
 
 // Carrier is a TCP client for TCP sockets.
@@ -45,6 +72,9 @@ type Carrier struct {
 	connection *ConnectionInfo
 	Logger     *zap.SugaredLogger
 	mux        sync.Mutex
+	// AllowPartialResults defines whether the successfully converted prefix of a corrupt response is returned instead
+	// of an error.
+	AllowPartialResults bool
 }
 
 // Connect establishes a TCP connection to a socket on a given host and port.
@@ -137,6 +167,45 @@ func (c *Carrier) Send(secret []amphora.SecretShare) error {
 	return nil
 }
 
+// SendStream transmits size bytes of decoded secret shares read from data to the socket in chunks of at most
+// bufferSize bytes. In contrast to Send, the shares are never held in memory as a whole and reading from data is paused
+// while writing to the socket blocks, i.e., while the MPC runtime does not keep up with the input.
+func (c *Carrier) SendStream(data io.Reader, size int64, bufferSize int) error {
+	if size < 1 {
+		return errors.New(ErrMarshal)
+	}
+	if size%BodySize != 0 {
+		return errors.New(ErrParcelToSPDZ + ErrInvalidBodySize)
+	}
+	if size >= int64(MaxLength) {
+		return errors.New(ErrParcelToSPDZ + ErrSizeTooBig)
+	}
+	header := make([]byte, ParcelSizeLength)
+	binary.LittleEndian.PutUint32(header, uint32(size))
+	if _, err := c.Conn.Write(header); err != nil {
+		return err
+	}
+	if bufferSize < BodySize {
+		bufferSize = BodySize
+	}
+	buf := make([]byte, bufferSize)
+	for remaining := size; remaining > 0; {
+		n := int64(len(buf))
+		if remaining < n {
+			n = remaining
+		}
+		if _, err := io.ReadFull(data, buf[:n]); err != nil {
+			return fmt.Errorf("error reading the input: %w", err)
+		}
+		if _, err := c.Conn.Write(buf[:n]); err != nil {
+			return err
+		}
+		remaining -= n
+	}
+	c.Logger.Debugw("Secret data streamed to socket", connectionInfo, c.connection, "Size", size)
+	return nil
+}
+
 // Returns a new Slice with the header appended
 // The header consists of the clientId as string:
 // - 1 Long (4 Byte) that contains the length of the string in bytes
@@ -161,7 +230,74 @@ func (c *Carrier) Read(conv ResponseConverter, bulkObjects bool) (*Result, error
 	}
 	out, err := c.Packer.Unmarshal(&resp, conv, bulkObjects)
 	if err != nil {
-		return nil, err
+		truncated, ok := err.(*TruncatedResultError)
+		if !ok || !c.AllowPartialResults || len(truncated.Converted) == 0 {
+			return nil, err
+		}
+		c.Logger.Warnw("Returning truncated result", connectionInfo, c.connection, "Reason", truncated.Reason, "Offset", truncated.Offset)
+		return &Result{
+			Response: truncated.Converted,
+			Warning: &TruncationWarning{
+				Truncated: true,
+				Reason:    truncated.Reason,
+				Offset:    truncated.Offset,
+			},
+		}, nil
 	}
 	return &Result{Response: out}, nil
 }
+
+// Stream reads the response from the TCP connection in chunks of at most bufferSize bytes and passes the converted
+// parcels of each chunk to emit. In contrast to Read, the memory used is bounded regardless of the size of the response
+// and reading from the connection is paused while emit blocks. If the response is corrupt, a *TruncatedResultError is
+// returned after all complete words have been emitted.
+func (c *Carrier) Stream(conv ResponseConverter, bufferSize int, emit func([]Parcel) error) error {
+	header := make([]byte, ParcelSizeLength)
+	_, err := io.ReadFull(c.Conn, header)
+	if err == io.EOF {
+		c.Logger.Errorw("Carrier read closed with empty response", connectionInfo, c.connection)
+		return errors.New("empty result from socket")
+	}
+	if err == io.ErrUnexpectedEOF {
+		return &TruncatedResultError{Reason: ErrSPDZToParcel + "missing size header"}
+	}
+	if err != nil {
+		return err
+	}
+	wordSize := binary.LittleEndian.Uint32(header)
+	if wordSize == 0 {
+		return &TruncatedResultError{Reason: ErrSPDZToParcel + ErrInvalidBodySize + ", size header is 0", Offset: ParcelSizeLength}
+	}
+	chunkSize := conv.ChunkSize()
+	size := bufferSize - bufferSize%chunkSize
+	if size < chunkSize {
+		size = chunkSize
+	}
+	buf := make([]byte, size)
+	offset := ParcelSizeLength
+	for {
+		n, err := io.ReadFull(c.Conn, buf)
+		complete := n - n%chunkSize
+		if complete > 0 {
+			parcels, convErr := conv.Convert(buf[:complete])
+			if convErr != nil {
+				return &TruncatedResultError{Reason: ErrSPDZToParcel + convErr.Error(), Offset: offset}
+			}
+			if emitErr := emit(parcels); emitErr != nil {
+				return emitErr
+			}
+			offset += complete
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			length := offset - ParcelSizeLength + n - complete
+			if n != complete || uint32(length)%wordSize != 0 {
+				msg := ErrSPDZToParcel + ErrInvalidBodySize + fmt.Sprintf(", actual size is %d\n", length)
+				return &TruncatedResultError{Reason: msg, Offset: offset}
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
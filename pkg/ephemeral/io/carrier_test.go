@@ -5,6 +5,7 @@
 package io_test
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"github.com/carbynestack/ephemeral/pkg/amphora"
@@ -12,6 +13,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"go.uber.org/zap"
+	"io/ioutil"
 	"net"
 	"sync"
 )
@@ -123,6 +125,52 @@ var _ = Describe("Carrier", func() {
 		})
 	})
 
+	Context("when streaming secret shares to the carrier", func() {
+		var carrier Carrier
+		BeforeEach(func() {
+			carrier = Carrier{
+				Dialer: dialer,
+				Logger: zap.NewNop().Sugar(),
+			}
+			go server.Read(connectionOutput)
+			carrier.Connect(ctx, playerID, "", "")
+		})
+		It("writes the size header followed by the shares in chunks bounded by the buffer size", func() {
+			data := bytes.Repeat([]byte{1}, 5*BodySize)
+			chunks := make(chan int, 10)
+			go func() {
+				buf := make([]byte, 1024)
+				for {
+					n, err := server.Read(buf)
+					if err != nil {
+						close(chunks)
+						return
+					}
+					chunks <- n
+				}
+			}()
+			err := carrier.SendStream(bytes.NewReader(data), int64(len(data)), 2*BodySize)
+			carrier.Close()
+			Expect(err).NotTo(HaveOccurred())
+			var sizes []int
+			for n := range chunks {
+				sizes = append(sizes, n)
+			}
+			Expect(sizes).To(Equal([]int{ParcelSizeLength, 2 * BodySize, 2 * BodySize, BodySize}))
+		})
+		It("rejects inputs which are not a multiple of the share size", func() {
+			err := carrier.SendStream(bytes.NewReader(make([]byte, 33)), 33, 64)
+			carrier.Close()
+			Expect(err).To(MatchError(ErrParcelToSPDZ + ErrInvalidBodySize))
+		})
+		It("returns an error if the input ends early", func() {
+			go ioutil.ReadAll(server)
+			err := carrier.SendStream(bytes.NewReader(make([]byte, BodySize)), 2*BodySize, 64)
+			carrier.Close()
+			Expect(err).To(MatchError("error reading the input: unexpected EOF"))
+		})
+	})
+
 	Context("when reading secret shares from the carrier", func() {
 		It("sends back the message from the socket", func() {
 			serverResponse := []byte{byte(1)}
@@ -184,6 +232,114 @@ var _ = Describe("Carrier", func() {
 		})
 	})
 
+	Context("when reading a corrupt response from the carrier", func() {
+		var serverResponse []byte
+		BeforeEach(func() {
+			size := make([]byte, 4)
+			size[0] = 32
+			// One complete secret share followed by a truncated one.
+			serverResponse = append(size, make([]byte, 48)...)
+		})
+		It("returns the converted prefix with a warning when partial results are allowed", func() {
+			carrier := Carrier{
+				Dialer:              dialer,
+				Packer:              &SPDZPacker{},
+				Logger:              zap.NewNop().Sugar(),
+				AllowPartialResults: true,
+			}
+			go server.Read(connectionOutput)
+			carrier.Connect(ctx, playerID, "", "")
+			go func() {
+				server.Write(serverResponse)
+				server.Close()
+			}()
+			res, err := carrier.Read(&SecretSharesConverter{}, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(res.Response)).To(Equal(1))
+			Expect(res.Warning).NotTo(BeNil())
+			Expect(res.Warning.Truncated).To(BeTrue())
+			Expect(res.Warning.Offset).To(Equal(36))
+		})
+		It("returns an error when partial results are not allowed", func() {
+			carrier := Carrier{
+				Dialer: dialer,
+				Packer: &SPDZPacker{},
+				Logger: zap.NewNop().Sugar(),
+			}
+			go server.Read(connectionOutput)
+			carrier.Connect(ctx, playerID, "", "")
+			go func() {
+				server.Write(serverResponse)
+				server.Close()
+			}()
+			_, err := carrier.Read(&SecretSharesConverter{}, false)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when streaming secret shares from the carrier", func() {
+		var carrier Carrier
+		BeforeEach(func() {
+			carrier = Carrier{
+				Dialer: dialer,
+				Packer: &SPDZPacker{},
+				Logger: zap.NewNop().Sugar(),
+			}
+			go server.Read(connectionOutput)
+			carrier.Connect(ctx, playerID, "", "")
+		})
+		AfterEach(func() {
+			carrier.Close()
+		})
+		respond := func(shares int, extra int) {
+			size := make([]byte, 4)
+			size[0] = 32
+			// The response may still be written after the spec finished, i.e., once server refers to the pipe of the
+			// next spec.
+			conn := server
+			go func() {
+				conn.Write(append(size, make([]byte, shares*32+extra)...))
+				conn.Close()
+			}()
+		}
+		It("emits the converted shares in chunks bounded by the buffer size", func() {
+			respond(5, 0)
+			var chunks []int
+			err := carrier.Stream(&SecretSharesConverter{}, 64, func(parcels []Parcel) error {
+				chunks = append(chunks, len(parcels))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(chunks).To(Equal([]int{2, 2, 1}))
+		})
+		It("returns a truncation error after emitting the complete shares", func() {
+			respond(1, 16)
+			emitted := 0
+			err := carrier.Stream(&SecretSharesConverter{}, 64, func(parcels []Parcel) error {
+				emitted += len(parcels)
+				return nil
+			})
+			Expect(emitted).To(Equal(1))
+			truncated, ok := err.(*TruncatedResultError)
+			Expect(ok).To(BeTrue())
+			Expect(truncated.Offset).To(Equal(36))
+		})
+		It("stops reading when emitting fails", func() {
+			respond(5, 0)
+			err := carrier.Stream(&SecretSharesConverter{}, 32, func(parcels []Parcel) error {
+				return fmt.Errorf("client gone")
+			})
+			Expect(err).To(MatchError("client gone"))
+		})
+		It("returns an error for an empty response", func() {
+			server.Close()
+			err := carrier.Stream(&SecretSharesConverter{}, 32, func(parcels []Parcel) error {
+				return nil
+			})
+			Expect(err).To(MatchError("empty result from socket"))
+		})
+	})
+
 	Context("when connecting as Player0", func() {
 		playerID := int32(0)
 		It("will receive and handle the server's fileHeader", func() {
@@ -0,0 +1,74 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package io
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// NewClearInputSharer returns a sharer of clear inputs for the player of the given configuration.
+func NewClearInputSharer(conf *SPDZEngineTypedConfig) (*ClearInputSharer, error) {
+	if conf.Prime.Sign() <= 0 {
+		return nil, errors.New("the prime must be configured to share clear inputs")
+	}
+	if conf.Prime.BitLen() > WordSize*8 {
+		return nil, fmt.Errorf("the prime must not exceed %d bits to share clear inputs", WordSize*8)
+	}
+	var r big.Int
+	if r.ModInverse(&conf.RInv, &conf.Prime) == nil {
+		return nil, errors.New("rInv is not invertible modulo the prime")
+	}
+	return &ClearInputSharer{
+		prime:  conf.Prime,
+		r:      r,
+		macKey: conf.GfpMacKey,
+		holder: conf.PartyNumber(conf.PlayerID) == 0,
+	}, nil
+}
+
+// ClearInputSharer converts clear inputs into the player's shares of a trivial sharing, i.e., party 0 holds the values
+// while the other parties hold zero. Each party authenticates the values with its share of the MAC key, so that the MAC
+// shares add up to the MACs of the values. Hence, the inputs are not secret, but they can be passed to programs reading
+// secret shares.
+type ClearInputSharer struct {
+	prime  big.Int
+	r      big.Int
+	macKey big.Int
+	// holder is true for party 0, which holds the values.
+	holder bool
+}
+
+// Share returns the base64 encoded shares of the values in the layout consumed by the SPDZ runtime, i.e., each share is
+// followed by its MAC. Negative values are represented modulo the prime.
+func (s *ClearInputSharer) Share(values []int64) string {
+	body := make([]byte, 0, len(values)*BodySize)
+	for _, v := range values {
+		value := new(big.Int).Mod(big.NewInt(v), &s.prime)
+		share := new(big.Int)
+		if s.holder {
+			share.Set(value)
+		}
+		mac := new(big.Int).Mul(value, &s.macKey)
+		body = append(body, s.encode(share)...)
+		body = append(body, s.encode(mac)...)
+	}
+	return base64.StdEncoding.EncodeToString(body)
+}
+
+// encode returns the little endian Montgomery representation of the value.
+func (s *ClearInputSharer) encode(value *big.Int) []byte {
+	m := new(big.Int).Mul(value, &s.r)
+	be := m.Mod(m, &s.prime).Bytes()
+	le := make([]byte, WordSize)
+	for i := range be {
+		le[i] = be[len(be)-1-i]
+	}
+	return le
+}
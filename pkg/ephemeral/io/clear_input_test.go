@@ -0,0 +1,98 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package io
+
+import (
+	"encoding/base64"
+	"math/big"
+
+	"github.com/carbynestack/ephemeral/pkg/castor"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Clear input sharer", func() {
+	var (
+		prime, rInv big.Int
+		macKeys     []big.Int
+		confs       []*SPDZEngineTypedConfig
+	)
+	BeforeEach(func() {
+		prime.SetString("198766463529478683931867765928436695041", 10)
+		rInv.SetString("133854242216446749056083838363708373830", 10)
+		macKeys = []big.Int{*big.NewInt(1234), *big.NewInt(5678)}
+		confs = make([]*SPDZEngineTypedConfig, len(macKeys))
+		for i := range confs {
+			confs[i] = &SPDZEngineTypedConfig{
+				Prime:     prime,
+				RInv:      rInv,
+				GfpMacKey: macKeys[i],
+				PlayerID:  int32(i),
+			}
+		}
+	})
+
+	// open reconstructs the values from the shares of all players and verifies their MACs.
+	open := func(shares []string) []*big.Int {
+		dealer, err := castor.NewDealer(prime, rInv, macKeys, 8)
+		Expect(err).NotTo(HaveOccurred())
+		bodies := make([][]byte, len(shares))
+		for i := range shares {
+			bodies[i], err = base64.StdEncoding.DecodeString(shares[i])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(bodies[i]) % BodySize).To(BeZero())
+		}
+		var values []*big.Int
+		for offset := 0; offset < len(bodies[0]); offset += BodySize {
+			playerShares := make([]castor.Share, len(bodies))
+			for i := range bodies {
+				playerShares[i] = castor.Share{
+					Value: base64.StdEncoding.EncodeToString(bodies[i][offset : offset+WordSize]),
+					Mac:   base64.StdEncoding.EncodeToString(bodies[i][offset+WordSize : offset+BodySize]),
+				}
+			}
+			v, err := dealer.Open(playerShares)
+			Expect(err).NotTo(HaveOccurred())
+			values = append(values, v)
+		}
+		return values
+	}
+
+	Context("when sharing clear values", func() {
+		It("returns authenticated shares of the values", func() {
+			shares := make([]string, len(confs))
+			for i, conf := range confs {
+				sharer, err := NewClearInputSharer(conf)
+				Expect(err).NotTo(HaveOccurred())
+				shares[i] = sharer.Share([]int64{42, -1})
+			}
+			values := open(shares)
+			Expect(values).To(HaveLen(2))
+			Expect(values[0].Int64()).To(Equal(int64(42)))
+			Expect(values[1]).To(Equal(new(big.Int).Sub(&prime, big.NewInt(1))))
+		})
+		It("lets the party the player is mapped to hold the values", func() {
+			shares := make([]string, len(confs))
+			for i, conf := range confs {
+				conf.PartyNumbers = []int32{1, 0}
+				sharer, err := NewClearInputSharer(conf)
+				Expect(err).NotTo(HaveOccurred())
+				shares[i] = sharer.Share([]int64{7})
+			}
+			Expect(open(shares)[0].Int64()).To(Equal(int64(7)))
+			body, _ := base64.StdEncoding.DecodeString(shares[0])
+			Expect(body[:WordSize]).To(Equal(make([]byte, WordSize)))
+		})
+	})
+
+	Context("when the prime is not configured", func() {
+		It("returns an error", func() {
+			_, err := NewClearInputSharer(&SPDZEngineTypedConfig{})
+			Expect(err).To(MatchError("the prime must be configured to share clear inputs"))
+		})
+	})
+})
@@ -10,11 +10,17 @@ import (
 	"fmt"
 	"math"
 	"math/big"
+	"strings"
 )
 
 // ResponseConverter is an interface for a struct that mutates the response from SPDZ runtime to a required format.
+//
+// Converters are selected by the output type of an activation, see RegisterConverter.
 type ResponseConverter interface {
-	convert(in []byte) ([]Parcel, error)
+	// Convert converts the words received from SPDZ runtime into parcels.
+	Convert(in []byte) ([]Parcel, error)
+	// ChunkSize returns the number of bytes that make up a single converted object.
+	ChunkSize() int
 }
 
 // SecretSharesConverter is to be used for encoding base64 secret shared responses received from SPDZ runtime.
@@ -22,8 +28,13 @@ type SecretSharesConverter struct {
 	Params []interface{}
 }
 
+// ChunkSize returns the size of a secret share including its MAC.
+func (b *SecretSharesConverter) ChunkSize() int {
+	return WordSize * 2
+}
+
 // Convert encodes a byte array in base64.
-func (b *SecretSharesConverter) convert(in []byte) ([]Parcel, error) {
+func (b *SecretSharesConverter) Convert(in []byte) ([]Parcel, error) {
 	shareSize := WordSize * 2 // it is 32 bytes, value + MAC
 	rem := math.Remainder(float64(len(in)), float64(shareSize))
 	if rem > 0 {
@@ -56,12 +67,21 @@ type PlaintextConverter struct {
 	Params []interface{}
 }
 
-// convert converts a binary output delivered by SPDZ runtime to a human readable int64 number.
+// ChunkSize returns the size of a single plain text word.
+func (s *PlaintextConverter) ChunkSize() int {
+	return WordSize
+}
+
+// Convert converts a binary output delivered by SPDZ runtime to a human readable int64 number.
 // rInv - is the inverse of R in Montgomery notation.
 // p - is the prime number used in MPC computation.
-func (s *PlaintextConverter) convert(in []byte) ([]Parcel, error) {
-	rInv := s.Params[0].(*big.Int)
-	p := s.Params[1].(*big.Int)
+func (s *PlaintextConverter) Convert(in []byte) ([]Parcel, error) {
+	return s.convertWords(in, (*big.Int).String)
+}
+
+// convertWords decodes every word of a binary output delivered by SPDZ runtime and encodes the representation returned
+// by format in base64.
+func (s *PlaintextConverter) convertWords(in []byte, format func(*big.Int) string) ([]Parcel, error) {
 	rem := math.Remainder(float64(len(in)), float64(WordSize))
 	if rem > float64(0) {
 		return nil, errors.New(ErrInvalidWordSize + fmt.Sprintf(": received %d", len(in)))
@@ -71,16 +91,8 @@ func (s *PlaintextConverter) convert(in []byte) ([]Parcel, error) {
 	for i := 0; i < chunks; i++ {
 		begin := i * WordSize
 		end := begin + WordSize
-		chunk := in[begin:end]
-		limb1 := s.littleToBigEndian(chunk[:8])
-		limb2 := s.littleToBigEndian(chunk[8:])
-		arr := s.swapLimbs(limb1, limb2)
-		inputBigInt := new(big.Int)
-		inputBigInt.SetBytes(arr)
-		result := new(big.Int)
-		result.Mul(inputBigInt, rInv)
-		result.Mod(result, p)
-		resp := base64.StdEncoding.EncodeToString([]byte(result.String()))
+		result := s.decode(in[begin:end])
+		resp := base64.StdEncoding.EncodeToString([]byte(format(result)))
 		size, err := lenToBytes(in)
 		if err != nil {
 			return nil, err
@@ -95,6 +107,21 @@ func (s *PlaintextConverter) convert(in []byte) ([]Parcel, error) {
 	return parcels, nil
 }
 
+// decode converts a single word from Montgomery notation into a number in the range [0, p).
+func (s *PlaintextConverter) decode(chunk []byte) *big.Int {
+	rInv := s.Params[0].(*big.Int)
+	p := s.Params[1].(*big.Int)
+	limb1 := s.littleToBigEndian(chunk[:8])
+	limb2 := s.littleToBigEndian(chunk[8:])
+	arr := s.swapLimbs(limb1, limb2)
+	inputBigInt := new(big.Int)
+	inputBigInt.SetBytes(arr)
+	result := new(big.Int)
+	result.Mul(inputBigInt, rInv)
+	result.Mod(result, p)
+	return result
+}
+
 // littleToBigEndian converts Little Endian notation to Big Endian.
 func (s *PlaintextConverter) littleToBigEndian(in []byte) []byte {
 	out := make([]byte, len(in))
@@ -109,3 +136,75 @@ func (s *PlaintextConverter) littleToBigEndian(in []byte) []byte {
 func (s *PlaintextConverter) swapLimbs(l1, l2 []byte) []byte {
 	return append(l2, l1...)
 }
+
+// SignedIntConverter converts plain-text responses from SPDZ into signed integers. As in MP-SPDZ, numbers larger than
+// p/2 represent negative integers.
+type SignedIntConverter struct {
+	PlaintextConverter
+}
+
+// Convert converts a binary output delivered by SPDZ runtime to human readable signed integers.
+func (s *SignedIntConverter) Convert(in []byte) ([]Parcel, error) {
+	return s.convertWords(in, func(v *big.Int) string {
+		return s.signed(v).String()
+	})
+}
+
+// signed maps a number in the range [0, p) to the range (-p/2, p/2].
+func (s *SignedIntConverter) signed(v *big.Int) *big.Int {
+	p := s.Params[1].(*big.Int)
+	if v.Cmp(new(big.Int).Rsh(p, 1)) > 0 {
+		return new(big.Int).Sub(v, p)
+	}
+	return v
+}
+
+// DefaultFixedPointPrecision is the number of fractional bits used by MP-SPDZ for sfix values by default.
+const DefaultFixedPointPrecision = 16
+
+// FixedPointConverter converts plain-text responses from SPDZ into decimal numbers. The output words are interpreted as
+// signed integers scaled by 2^Precision, which is how MP-SPDZ represents sfix values.
+type FixedPointConverter struct {
+	SignedIntConverter
+	Precision uint
+}
+
+// Convert converts a binary output delivered by SPDZ runtime to human readable decimal numbers.
+func (s *FixedPointConverter) Convert(in []byte) ([]Parcel, error) {
+	return s.convertWords(in, func(v *big.Int) string {
+		mant := new(big.Float).SetInt(s.signed(v))
+		return new(big.Float).SetMantExp(mant, -int(s.Precision)).Text('f', -1)
+	})
+}
+
+// JSONArrayConverter converts plain-text responses from SPDZ into a single JSON array of signed integers. Note that
+// if the output is streamed, a separate array is emitted for every chunk that is read from the runtime.
+type JSONArrayConverter struct {
+	SignedIntConverter
+}
+
+// Convert converts a binary output delivered by SPDZ runtime to a JSON array.
+func (s *JSONArrayConverter) Convert(in []byte) ([]Parcel, error) {
+	parcels, err := s.SignedIntConverter.Convert(in)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, len(parcels))
+	for i, prc := range parcels {
+		v, err := base64.StdEncoding.DecodeString(prc.BodyBase64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = string(v)
+	}
+	body := []byte("[" + strings.Join(values, ",") + "]")
+	size, err := lenToBytes(body)
+	if err != nil {
+		return nil, err
+	}
+	return []Parcel{{
+		Size:       size,
+		Body:       body,
+		BodyBase64: base64.StdEncoding.EncodeToString(body),
+	}}, nil
+}
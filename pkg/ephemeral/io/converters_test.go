@@ -32,14 +32,14 @@ var _ = Describe("Converters", func() {
 			It("returns a plain-text integer", func() {
 				spdzFormat := "Jf8uKaLlN9MhlQdaTPP1Rw==" // 25ff 2e29 a2e5 37d3 2195 075a 4cf3 f547
 				bytes, _ := base64.StdEncoding.DecodeString(spdzFormat)
-				parcels, err := conv.convert(bytes)
+				parcels, err := conv.Convert(bytes)
 				decoded, _ := base64.StdEncoding.DecodeString(parcels[0].BodyBase64)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(string(decoded)).To(Equal("111"))
 			})
 			It("returns an error when invalid message size is provided", func() {
 				bytes := make([]byte, 1)
-				_, err := conv.convert(bytes)
+				_, err := conv.Convert(bytes)
 				Expect(err.Error()).To(Equal(ErrInvalidWordSize + ": received 1"))
 			})
 		})
@@ -48,10 +48,64 @@ var _ = Describe("Converters", func() {
 				spdzFormat := "Jf8uKaLlN9MhlQdaTPP1Rw==" // 25ff 2e29 a2e5 37d3 2195 075a 4cf3 f547
 				bytes, _ := base64.StdEncoding.DecodeString(spdzFormat)
 				message := append(bytes, bytes...)
-				parcels, err := conv.convert(message)
+				parcels, err := conv.Convert(message)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(len(parcels)).To(Equal(2))
 			})
 		})
 	})
+	Context("when converting signed and fixed-point output", func() {
+		var (
+			rInv, p   big.Int
+			plaintext PlaintextConverter
+		)
+		// Words of -5, -1.5 and 3.25 as sfix with a precision of 16 bits.
+		decode := func(words ...string) []byte {
+			var bytes []byte
+			for _, w := range words {
+				b, _ := base64.StdEncoding.DecodeString(w)
+				bytes = append(bytes, b...)
+			}
+			return bytes
+		}
+		bodies := func(parcels []Parcel) []string {
+			var res []string
+			for _, prc := range parcels {
+				b, _ := base64.StdEncoding.DecodeString(prc.BodyBase64)
+				res = append(res, string(b))
+			}
+			return res
+		}
+		BeforeEach(func() {
+			rInv.SetString("116525037434575252203671714714489805504", 10)
+			p.SetString("172035116406933162231178957667602464769", 10)
+			plaintext = PlaintextConverter{Params: []interface{}{&rInv, &p}}
+		})
+		It("returns negative integers for large field elements", func() {
+			conv := SignedIntConverter{PlaintextConverter: plaintext}
+			parcels, err := conv.Convert(decode("Jf8uKaLlN9MhlQdaTPP1Rw==", "CgCep8HGpTYt8j9fCZU/Dg=="))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bodies(parcels)).To(Equal([]string{"111", "-5"}))
+		})
+		It("returns decimal numbers for fixed-point values", func() {
+			conv := FixedPointConverter{
+				SignedIntConverter: SignedIntConverter{PlaintextConverter: plaintext},
+				Precision:          DefaultFixedPointPrecision,
+			}
+			parcels, err := conv.Convert(decode("jPemT8kVGL0spv+qQNT2Og==", "UpJvlNRz6sJPSYnvQYzPLA=="))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bodies(parcels)).To(Equal([]string{"-1.5", "3.25"}))
+		})
+		It("returns a single JSON array", func() {
+			conv := JSONArrayConverter{SignedIntConverter: SignedIntConverter{PlaintextConverter: plaintext}}
+			parcels, err := conv.Convert(decode("Jf8uKaLlN9MhlQdaTPP1Rw==", "CgCep8HGpTYt8j9fCZU/Dg=="))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bodies(parcels)).To(Equal([]string{"[111,-5]"}))
+		})
+		It("returns an error when invalid message size is provided", func() {
+			conv := JSONArrayConverter{SignedIntConverter: SignedIntConverter{PlaintextConverter: plaintext}}
+			_, err := conv.Convert(make([]byte, 1))
+			Expect(err.Error()).To(Equal(ErrInvalidWordSize + ": received 1"))
+		})
+	})
 })
@@ -0,0 +1,125 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package io
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/amphora"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// The operations of Amphora interactions.
+const (
+	AmphoraGet    = "get"
+	AmphoraCreate = "create"
+)
+
+// Diagnostics describe the data an execution has consumed and produced. They are collected if requested by the
+// activation.
+type Diagnostics struct {
+	// InputBytes is the size of the secret shared input parameters fed to the MPC runtime.
+	InputBytes int64 `json:"inputBytes"`
+	// OutputBytes is the size of the output read from the MPC runtime.
+	OutputBytes int64 `json:"outputBytes"`
+	// Amphora are the interactions with Amphora in the order they have been started.
+	Amphora []AmphoraInteraction `json:"amphora,omitempty"`
+}
+
+// AmphoraInteraction describes reading or creating a secret share in Amphora.
+type AmphoraInteraction struct {
+	Operation string `json:"operation"`
+	SecretID  string `json:"secretId"`
+	// Size is the size of the secret share data in bytes.
+	Size int64 `json:"size"`
+	// DurationMs is the latency of the interaction in milliseconds.
+	DurationMs int64 `json:"durationMs"`
+	// Status is the response code of Amphora, 0 if no response was received.
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// newDiagnostics returns empty diagnostics if requested by the activation, nil otherwise. All methods can be called on
+// nil diagnostics, in which case nothing is recorded.
+func newDiagnostics(act *Activation) *Diagnostics {
+	if !act.Diagnostics {
+		return nil
+	}
+	return &Diagnostics{}
+}
+
+// recordAmphora records an interaction with Amphora started at the given time. The status of successful interactions
+// is the one expected for the operation.
+func (d *Diagnostics) recordAmphora(op, secretID string, size int64, started time.Time, err error) {
+	if d == nil {
+		return
+	}
+	interaction := AmphoraInteraction{
+		Operation:  op,
+		SecretID:   secretID,
+		Size:       size,
+		DurationMs: time.Since(started).Nanoseconds() / int64(time.Millisecond),
+	}
+	switch {
+	case err != nil:
+		interaction.Status = amphora.StatusCode(err)
+		interaction.Error = err.Error()
+	case op == AmphoraGet:
+		interaction.Status = http.StatusOK
+	default:
+		interaction.Status = http.StatusCreated
+	}
+	d.Amphora = append(d.Amphora, interaction)
+}
+
+// addInput adds the given number of input bytes.
+func (d *Diagnostics) addInput(n int64) {
+	if d == nil {
+		return
+	}
+	d.InputBytes += n
+}
+
+// addOutput adds the given number of output bytes.
+func (d *Diagnostics) addOutput(n int64) {
+	if d == nil {
+		return
+	}
+	d.OutputBytes += n
+}
+
+// outputBytes returns the number of output bytes, 0 for nil diagnostics.
+func (d *Diagnostics) outputBytes() int64 {
+	if d == nil {
+		return 0
+	}
+	return d.OutputBytes
+}
+
+// wrap attaches the diagnostics to the given error, if any.
+func (d *Diagnostics) wrap(err error) error {
+	if d == nil || err == nil {
+		return err
+	}
+	return &DiagnosedError{Err: err, Diagnostics: d}
+}
+
+// DiagnosedError is returned if an execution failed whose diagnostics have been requested.
+type DiagnosedError struct {
+	Err         error
+	Diagnostics *Diagnostics
+}
+
+func (e *DiagnosedError) Error() string {
+	return e.Err.Error()
+}
+
+// decodedLen returns the number of bytes encoded in the given base64 string.
+func decodedLen(s string) int64 {
+	return int64(base64.RawStdEncoding.DecodedLen(len(strings.TrimRight(s, "="))))
+}
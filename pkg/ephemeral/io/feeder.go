@@ -5,62 +5,111 @@
 package io
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"github.com/carbynestack/ephemeral/pkg/amphora"
+	"github.com/carbynestack/ephemeral/pkg/audit"
 	"github.com/carbynestack/ephemeral/pkg/ephemeral/network"
 	. "github.com/carbynestack/ephemeral/pkg/types"
+	"io"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
-// Feeder is an interface.
+// Feeder is an interface. The feed ports are the ports of the client connections of the activation.
 type Feeder interface {
 	// LoadFromSecretStoreAndFeed loads input parameters from Amphora.
-	LoadFromSecretStoreAndFeed(act *Activation, feedPort string, ctx *CtxConfig) ([]byte, error)
+	LoadFromSecretStoreAndFeed(act *Activation, feedPorts []string, ctx *CtxConfig) ([]byte, error)
 	// LoadFromRequestAndFeed loads input parameters from the request body.
 	//
 	// Deprecated: providing secrets in the request body is not recommended and will be removed in the future.
-	LoadFromRequestAndFeed(act *Activation, feedPort string, ctx *CtxConfig) ([]byte, error)
+	LoadFromRequestAndFeed(act *Activation, feedPorts []string, ctx *CtxConfig) ([]byte, error)
 	Close() error
 }
 
 // NewAmphoraFeeder returns a new instance of amphora feeder.
 func NewAmphoraFeeder(l *zap.SugaredLogger, conf *SPDZEngineTypedConfig) *AmphoraFeeder {
 	dialer := network.RetryingDialerWithContext(conf.RetrySleep, conf.NetworkEstablishTimeout, l)
-
-	carrier := &Carrier{
-		Dialer: dialer,
-		Packer: &SPDZPacker{
-			MaxBulkSize: conf.MaxBulkSize,
-		},
-		Logger: l,
+	newCarrier := func() AbstractCarrier {
+		return &Carrier{
+			Dialer: dialer,
+			Packer: &SPDZPacker{
+				MaxBulkSize: conf.MaxBulkSize,
+			},
+			Logger:              l,
+			AllowPartialResults: conf.AllowPartialResults,
+		}
 	}
 	return &AmphoraFeeder{
-		logger:  l,
-		conf:    conf,
-		carrier: carrier,
+		logger:     l,
+		conf:       conf,
+		carrier:    newCarrier(),
+		newCarrier: newCarrier,
 	}
 }
 
 // AmphoraFeeder provides parameters to the SPDZ execution based on the given activation.
 type AmphoraFeeder struct {
-	logger  *zap.SugaredLogger
-	conf    *SPDZEngineTypedConfig
+	logger *zap.SugaredLogger
+	conf   *SPDZEngineTypedConfig
+	// carrier is connected to the first client connection of the activation, the output is read from it.
 	carrier AbstractCarrier
+	// newCarrier creates the carriers of the other client connections, if any.
+	newCarrier func() AbstractCarrier
 }
 
 // LoadFromSecretStoreAndFeed loads input parameters from Amphora.
-func (f *AmphoraFeeder) LoadFromSecretStoreAndFeed(act *Activation, feedPort string, ctx *CtxConfig) ([]byte, error) {
+func (f *AmphoraFeeder) LoadFromSecretStoreAndFeed(act *Activation, feedPorts []string, ctx *CtxConfig) ([]byte, error) {
 	var data []string
 	inputs := []ActivationInput{}
 	client := f.conf.AmphoraClient
-	for i := range act.AmphoraParams {
-		osh, err := client.GetSecretShare(act.AmphoraParams[i], ctx.Spdz.ProgramIdentifier)
+	diag := newDiagnostics(act)
+	counts, err := paramsPerConnection(act, len(act.AmphoraParams), feedPorts)
+	if err != nil {
+		return nil, diag.wrap(err)
+	}
+	// Streamed inputs are spooled one secret at a time, so that at most one secret is held in memory.
+	var spool *inputSpool
+	if f.conf.InputStreamBufferSize > 0 {
+		var err error
+		spool, err = newInputSpool()
 		if err != nil {
-			return nil, err
+			return nil, diag.wrap(fmt.Errorf("failed to create the input spool: %w", err))
+		}
+		defer spool.Close()
+	}
+	// The secrets are read at once if supported by the client, unless they are spooled one at a time.
+	var fetched []amphora.SecretShare
+	if bulk, ok := client.(amphora.BulkClient); ok && spool == nil {
+		started := time.Now()
+		fetched, err = bulk.GetSecretShares(act.AmphoraParams, ctx.Spdz.ProgramIdentifier)
+		if err != nil {
+			for _, id := range act.AmphoraParams {
+				diag.recordAmphora(AmphoraGet, id, 0, started, err)
+			}
+			return nil, diag.wrap(err)
+		}
+		for _, osh := range fetched {
+			diag.recordAmphora(AmphoraGet, osh.SecretID, decodedLen(osh.Data), started, nil)
+		}
+	}
+	for i := range act.AmphoraParams {
+		var osh amphora.SecretShare
+		if fetched != nil {
+			osh = fetched[i]
+		} else {
+			started := time.Now()
+			osh, err = client.GetSecretShare(act.AmphoraParams[i], ctx.Spdz.ProgramIdentifier)
+			diag.recordAmphora(AmphoraGet, act.AmphoraParams[i], decodedLen(osh.Data), started, err)
+			if err != nil {
+				return nil, diag.wrap(err)
+			}
 		}
 		policy := DefaultPolicy
 		owner, _ := findValueForKeyInTags(osh.Tags, "owner")
@@ -70,7 +119,11 @@ func (f *AmphoraFeeder) LoadFromSecretStoreAndFeed(act *Activation, feedPort str
 			Owner:        owner,
 			AccessPolicy: policy,
 		})
-		data = append(data, osh.Data)
+		if spool == nil {
+			data = append(data, osh.Data)
+		} else if err := spool.add(osh.Data); err != nil {
+			return nil, diag.wrap(fmt.Errorf("failed to spool secret %s: %w", osh.SecretID, err))
+		}
 	}
 	t := time.Now()
 	opaInput := map[string]interface{}{
@@ -85,43 +138,65 @@ func (f *AmphoraFeeder) LoadFromSecretStoreAndFeed(act *Activation, feedPort str
 	}
 	canExecute, err := f.conf.OpaClient.CanExecute(opaInput)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check if program can be executed: %w", err)
+		return nil, diag.wrap(fmt.Errorf("failed to check if program can be executed: %w", err))
 	}
 	if !canExecute {
-		return nil, fmt.Errorf("unauthorized: program cannot be executed")
+		return nil, diag.wrap(fmt.Errorf("unauthorized: program cannot be executed"))
 	}
-	resp, err := f.feedAndRead(data, feedPort, ctx)
+	var feedInputs []*feedInput
+	if spool != nil {
+		feedInputs = spool.inputs(counts)
+	} else {
+		feedInputs = splitParams(data, counts)
+	}
+	resp, err := f.feedAndRead(feedInputs, feedPorts, ctx, opaInput, diag)
 	if err != nil {
 		return nil, err
 	}
-	// Write to amphora if required and return amphora secret ids.
-	if act.Output.Type == AmphoraSecret {
-		ids, err := f.writeToAmphora(act, opaInput, *resp)
-		if err != nil {
-			return nil, err
-		}
-		resp.Response = ids
-	}
 	return json.Marshal(&resp)
 }
 
 // LoadFromRequestAndFeed loads input parameteters from the request body.
 //
 // Deprecated: providing secrets in the request body is not recommended and will be removed in the future.
-func (f *AmphoraFeeder) LoadFromRequestAndFeed(act *Activation, feedPort string, ctx *CtxConfig) ([]byte, error) {
-	resp, err := f.feedAndRead(act.SecretParams, feedPort, ctx)
+func (f *AmphoraFeeder) LoadFromRequestAndFeed(act *Activation, feedPorts []string, ctx *CtxConfig) ([]byte, error) {
+	diag := newDiagnostics(act)
+	counts, err := paramsPerConnection(act, len(act.SecretParams), feedPorts)
+	if err != nil {
+		return nil, diag.wrap(err)
+	}
+	resp, err := f.feedAndRead(splitParams(act.SecretParams, counts), feedPorts, ctx, map[string]interface{}{}, diag)
 	if err != nil {
 		return nil, err
 	}
-	// Write to amphora if required and return amphora secret ids.
-	if act.Output.Type == AmphoraSecret {
-		ids, err := f.writeToAmphora(act, map[string]interface{}{}, *resp)
-		if err != nil {
-			return nil, err
+	return json.Marshal(&resp)
+}
+
+// paramsPerConnection returns the number of parameters sent on each of the client connections of the activation. All
+// parameters are sent on a single connection if the activation defines none.
+func paramsPerConnection(act *Activation, params int, feedPorts []string) ([]int, error) {
+	if len(act.Connections) == 0 {
+		if len(feedPorts) != 1 {
+			return nil, fmt.Errorf("expected a single feed port, got %d", len(feedPorts))
 		}
-		resp.Response = ids
+		return []int{params}, nil
 	}
-	return json.Marshal(&resp)
+	if len(feedPorts) != len(act.Connections) {
+		return nil, fmt.Errorf("expected a feed port for each of the %d connections, got %d", len(act.Connections), len(feedPorts))
+	}
+	counts := make([]int, len(act.Connections))
+	total := 0
+	for i, conn := range act.Connections {
+		if conn.Params < 0 {
+			return nil, fmt.Errorf("number of parameters of connection %d must not be negative, got %d", i, conn.Params)
+		}
+		counts[i] = conn.Params
+		total += conn.Params
+	}
+	if total != params {
+		return nil, fmt.Errorf("the connections take %d parameters, but %d are given", total, params)
+	}
+	return counts, nil
 }
 
 // Close closes the underlying socket connection.
@@ -130,51 +205,267 @@ func (f *AmphoraFeeder) Close() error {
 	return f.carrier.Close()
 }
 
-// feedAndRead takes a slice of base64 encoded secret shared parameters along with the port where SPDZ runtime is
-// listening for the input. The base64 input params are converted into a form digestable by SPDZ and sent to the socket.
-// The runtime must send back a response for this function to finish without an error.
-func (f *AmphoraFeeder) feedAndRead(params []string, feedPort string, ctx *CtxConfig) (*Result, error) {
-	var conv ResponseConverter
-	f.logger.Debugw(fmt.Sprintf("Received secret shared parameters \"%.10s...\" (len: %d)", params, len(params)), GameID, ctx.Act.GameID)
-	isBulk := false
+// feedAndRead takes the secret shared input parameters of each client connection along with the ports where SPDZ
+// runtime is listening for the input. The input params are converted into a form digestable by SPDZ and sent to the
+// sockets. The runtime must send back a response for this function to finish without an error. The response is written
+// to Amphora if required, in which case the ids of the created secrets are returned. The diagnostics, if any, are
+// attached to the result or the error.
+func (f *AmphoraFeeder) feedAndRead(inputs []*feedInput, feedPorts []string, ctx *CtxConfig, opaInput map[string]interface{}, diag *Diagnostics) (*Result, error) {
+	for _, input := range inputs {
+		diag.addInput(input.len())
+	}
+	resp, err := f.feed(inputs, feedPorts, ctx, opaInput, diag)
+	if err != nil {
+		return nil, diag.wrap(err)
+	}
+	resp.Diagnostics = diag
+	resp.Reproducibility = ctx.Reproducibility
+	if len(ctx.Act.Connections) > 0 {
+		resp.Ports = feedPorts
+	}
+	delivered := map[string]interface{}{"outputType": ctx.Act.Output.Type}
+	if ctx.Act.Output.Type == AmphoraSecret {
+		delivered["secretIDs"] = resp.Response
+	}
+	if strings.EqualFold(ctx.Act.Output.Type, ObjectStore) {
+		delivered["objectKey"] = ctx.Act.GameID
+	}
+	ctx.Audit.Add(audit.ResultDelivered, delivered)
+	return resp, nil
+}
+
+// feed implements feedAndRead.
+func (f *AmphoraFeeder) feed(inputs []*feedInput, feedPorts []string, ctx *CtxConfig, opaInput map[string]interface{}, diag *Diagnostics) (*Result, error) {
+	var size int64
+	for _, input := range inputs {
+		size += input.len()
+	}
+	f.logger.Debugw(fmt.Sprintf("Received secret shared parameters (size: %d bytes)", size), GameID, ctx.Act.GameID)
 	// It must be defined in the Activation whether plaintext or secret shared output is expected.
-	switch strings.ToUpper(ctx.Act.Output.Type) {
-	case PlainText:
-		mpcParams := []interface{}{&f.conf.RInv, &f.conf.Prime}
-		conv = &PlaintextConverter{
-			Params: mpcParams,
-		}
-	case SecretShare:
-		conv = &SecretSharesConverter{}
-	case AmphoraSecret:
-		conv = &SecretSharesConverter{}
-		isBulk = true
-	default:
-		return nil, fmt.Errorf("no output config is given, either %s, %s or %s must be defined", PlainText, SecretShare, AmphoraSecret)
-	}
-	err := f.carrier.Connect(ctx.Context, ctx.Spdz.PlayerID, "localhost", feedPort)
-	defer f.carrier.Close()
+	conv, err := NewConverter(ctx.Act.Output.Type, f.conf)
+	if err != nil {
+		return nil, err
+	}
+	toObjectStore := strings.EqualFold(ctx.Act.Output.Type, ObjectStore)
+	if toObjectStore && f.conf.ObjectStoreClient == nil {
+		return nil, fmt.Errorf("unsupported output type %s, no object store is configured", ObjectStore)
+	}
+	schema := ctx.Act.Output.Schema
+	if toObjectStore && len(schema) > 0 {
+		return nil, fmt.Errorf("a result schema cannot be declared for output type %s", ObjectStore)
+	}
+	// The values of the named outputs are split before they are stored in secrets of their own.
+	isBulk := (strings.EqualFold(ctx.Act.Output.Type, AmphoraSecret) || toObjectStore) && len(schema) == 0
+	carriers := []AbstractCarrier{f.carrier}
+	for range inputs[1:] {
+		carriers = append(carriers, f.newCarrier())
+	}
+	for _, c := range carriers {
+		defer c.Close()
+	}
+	if err := f.connectAndSend(ctx, carriers, inputs, feedPorts); err != nil {
+		return nil, err
+	}
+	f.logger.Debug("Parameters written to carrier")
+	toAmphora := ctx.Act.Output.Type == AmphoraSecret
+	// Outputs with a result schema are split once they have been read completely, hence they are never streamed.
+	switch {
+	case len(schema) > 0:
+	case toAmphora && f.conf.OutputStreamBufferSize > 0:
+		return f.streamToAmphora(conv, ctx, opaInput, diag)
+	case !isBulk && ctx.Output != nil:
+		return f.streamToOutput(conv, ctx, diag)
+	}
+	resp, err := f.carrier.Read(conv, isBulk)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range resp.Response {
+		diag.addOutput(decodedLen(v))
+	}
+	if len(schema) > 0 {
+		outputs, err := splitOutputs(resp.Response, schema)
+		if err != nil {
+			return nil, err
+		}
+		if !toAmphora {
+			resp.Response = nil
+			resp.Outputs = outputs
+			return resp, nil
+		}
+		startDelivery(ctx)
+		secrets, err := f.writeOutputsToAmphora(ctx.Act, opaInput, outputs, diag)
+		if err != nil {
+			return nil, err
+		}
+		resp.Response = make([]string, len(schema))
+		for i, field := range schema {
+			resp.Response[i] = secrets[field.Name]
+		}
+		resp.Secrets = secrets
+		return resp, nil
+	}
+	// Write to amphora if required and return amphora secret ids.
+	if toAmphora {
+		startDelivery(ctx)
+		ids, err := f.writeToAmphora(ctx.Act, opaInput, *resp, diag)
+		if err != nil {
+			return nil, err
+		}
+		resp.Response = ids
+	}
+	// Upload to the object store if required and return a presigned URL of the object.
+	if toObjectStore {
+		startDelivery(ctx)
+		u, err := f.writeToObjectStore(ctx.Act, *resp)
+		if err != nil {
+			return nil, err
+		}
+		resp.Response = []string{u}
+	}
+	return resp, nil
+}
+
+// connectAndSend connects the carriers to the given ports and writes the inputs to them. The program may accept the
+// client connections and read their inputs in any order, hence each connection is served concurrently. Connections to
+// the same port are established in order though, as the program tells them apart by the order they are accepted in.
+func (f *AmphoraFeeder) connectAndSend(ctx *CtxConfig, carriers []AbstractCarrier, inputs []*feedInput, ports []string) error {
+	party := ctx.Spdz.PartyNumber(ctx.Spdz.PlayerID)
+	errs := make([]error, len(carriers))
+	previous := map[string]chan struct{}{}
+	wg := sync.WaitGroup{}
+	for i := range carriers {
+		connected := make(chan struct{})
+		wait := previous[ports[i]]
+		previous[ports[i]] = connected
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if wait != nil {
+				<-wait
+			}
+			err := carriers[i].Connect(ctx.Context, party, "localhost", ports[i])
+			close(connected)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			f.logger.Debugw("Carrier connected", "Port", ports[i])
+			errs[i] = f.send(carriers[i], inputs[i])
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send writes the input to the carrier. The input is streamed in chunks if an input stream buffer size is configured.
+func (f *AmphoraFeeder) send(carrier AbstractCarrier, input *feedInput) error {
+	if f.conf.InputStreamBufferSize <= 0 {
+		var secrets []amphora.SecretShare
+		for i := range input.params {
+			secret := amphora.SecretShare{
+				Data: input.params[i],
+			}
+			secrets = append(secrets, secret)
+		}
+		return carrier.Send(secrets)
+	}
+	data, err := input.reader()
+	if err != nil {
+		return err
+	}
+	return carrier.SendStream(data, input.len(), f.conf.InputStreamBufferSize)
+}
+
+// streamToOutput passes the converted response to the output channel of the context while it is read from the socket.
+// The channel is closed once the response has been read completely or reading it failed.
+func (f *AmphoraFeeder) streamToOutput(conv ResponseConverter, ctx *CtxConfig, diag *Diagnostics) (*Result, error) {
+	defer close(ctx.Output)
+	err := f.carrier.Stream(conv, f.conf.OutputStreamBufferSize, func(parcels []Parcel) error {
+		values := make([]string, len(parcels))
+		for i := range parcels {
+			values[i] = parcels[i].BodyBase64
+			diag.addOutput(int64(len(parcels[i].Body)))
+		}
+		select {
+		case ctx.Output <- values:
+			return nil
+		case <-ctx.Context.Done():
+			return ctx.Context.Err()
+		}
+	})
+	if truncated, ok := err.(*TruncatedResultError); ok && f.conf.AllowPartialResults && truncated.Offset > ParcelSizeLength {
+		f.logger.Warnw("Streamed truncated result", GameID, ctx.Act.GameID, "Reason", truncated.Reason, "Offset", truncated.Offset)
+		return &Result{
+			Warning: &TruncationWarning{
+				Truncated: true,
+				Reason:    truncated.Reason,
+				Offset:    truncated.Offset,
+			},
+		}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
-	f.logger.Debug("Carrier connected")
-	var secrets []amphora.SecretShare
-	for i := range params {
-		secret := amphora.SecretShare{
-			Data: params[i],
+	return &Result{}, nil
+}
+
+// streamToAmphora uploads the response to Amphora while it is read from the socket and returns the id of the created
+// secret.
+func (f *AmphoraFeeder) streamToAmphora(conv ResponseConverter, ctx *CtxConfig, opaInput map[string]interface{}, diag *Diagnostics) (*Result, error) {
+	tags, err := f.outputTags(ctx.Act, opaInput)
+	if err != nil {
+		return nil, err
+	}
+	data, dataWriter := io.Pipe()
+	uploadErrCh := make(chan error, 1)
+	started := time.Now()
+	go func() {
+		err := f.conf.AmphoraClient.CreateSecretShareFromReader(ctx.Act.GameID, tags, data)
+		// Unblock the carrier if the upload stopped reading the data.
+		data.CloseWithError(err)
+		uploadErrCh <- err
+	}()
+	err = f.carrier.Stream(conv, f.conf.OutputStreamBufferSize, func(parcels []Parcel) error {
+		for i := range parcels {
+			if _, err := dataWriter.Write(parcels[i].Body); err != nil {
+				return err
+			}
+			diag.addOutput(int64(len(parcels[i].Body)))
 		}
-		secrets = append(secrets, secret)
+		return nil
+	})
+	// Closing with a nil error completes the upload, any other error aborts it.
+	dataWriter.CloseWithError(err)
+	if err == nil {
+		startDelivery(ctx)
 	}
-	err = f.carrier.Send(secrets)
+	uploadErr := <-uploadErrCh
+	diag.recordAmphora(AmphoraCreate, ctx.Act.GameID, diag.outputBytes(), started, uploadErr)
 	if err != nil {
 		return nil, err
 	}
-	f.logger.Debug("Parameters written to carrier")
-	return f.carrier.Read(conv, isBulk)
+	if uploadErr != nil {
+		return nil, uploadErr
+	}
+	f.logger.Infow(fmt.Sprintf("Created secret share with id %s", ctx.Act.GameID), GameID, ctx.Act.GameID)
+	return &Result{Response: []string{ctx.Act.GameID}}, nil
 }
 
-func (f *AmphoraFeeder) writeToAmphora(act *Activation, opaInput map[string]interface{}, resp Result) ([]string, error) {
-	client := f.conf.AmphoraClient
+// startDelivery signals that the computation has finished and the result is being delivered.
+func startDelivery(ctx *CtxConfig) {
+	if ctx.Delivery != nil {
+		close(ctx.Delivery)
+	}
+}
+
+// outputTags returns the tags of the secret the output of the given activation is stored in.
+func (f *AmphoraFeeder) outputTags(act *Activation, opaInput map[string]interface{}) ([]amphora.Tag, error) {
 	generatedTags, err := f.conf.OpaClient.GenerateTags(opaInput)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate tags for program output: %w", err)
@@ -191,14 +482,36 @@ func (f *AmphoraFeeder) writeToAmphora(act *Activation, opaInput map[string]inte
 			Value:     act.GameID,
 		},
 	}
-	tags = append(tags, generatedTags...)
+	keys := make([]string, 0, len(act.Labels))
+	for k := range act.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		tags = append(tags, amphora.Tag{
+			ValueType: "STRING",
+			Key:       LabelPrefix + k,
+			Value:     act.Labels[k],
+		})
+	}
+	return append(tags, generatedTags...), nil
+}
+
+func (f *AmphoraFeeder) writeToAmphora(act *Activation, opaInput map[string]interface{}, resp Result, diag *Diagnostics) ([]string, error) {
+	client := f.conf.AmphoraClient
+	tags, err := f.outputTags(act, opaInput)
+	if err != nil {
+		return nil, err
+	}
 	os := amphora.SecretShare{
 		SecretID: act.GameID,
 		// When writing to Amphora, the slice has exactly 1 element.
 		Data: resp.Response[0],
 		Tags: tags,
 	}
+	started := time.Now()
 	err = client.CreateSecretShare(&os)
+	diag.recordAmphora(AmphoraCreate, os.SecretID, decodedLen(os.Data), started, err)
 	f.logger.Infow(fmt.Sprintf("Created secret share with id %s", os.SecretID), GameID, act.GameID)
 	if err != nil {
 		return nil, err
@@ -206,6 +519,130 @@ func (f *AmphoraFeeder) writeToAmphora(act *Activation, opaInput map[string]inte
 	return []string{act.GameID}, nil
 }
 
+// OutputNameTag is the key of the tag holding the name of the output a secret was created for, if the activation
+// declares a result schema.
+const OutputNameTag = "outputName"
+
+// splitOutputs assigns the values of the response to the named outputs of the schema, in order.
+func splitOutputs(values []string, schema []OutputField) (map[string][]string, error) {
+	total := 0
+	for _, field := range schema {
+		total += field.Count
+	}
+	if total != len(values) {
+		return nil, fmt.Errorf("the result schema declares %d values, but the program returned %d", total, len(values))
+	}
+	outputs := make(map[string][]string, len(schema))
+	offset := 0
+	for _, field := range schema {
+		outputs[field.Name] = values[offset : offset+field.Count]
+		offset += field.Count
+	}
+	return outputs, nil
+}
+
+// writeOutputsToAmphora stores each of the named outputs in a secret of its own and returns the ids of the secrets by
+// output name. The ids are derived from the game id and the name of the output.
+func (f *AmphoraFeeder) writeOutputsToAmphora(act *Activation, opaInput map[string]interface{}, outputs map[string][]string, diag *Diagnostics) (map[string]string, error) {
+	gameID, err := uuid.Parse(act.GameID)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := f.outputTags(act, opaInput)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	secrets := make(map[string]string, len(outputs))
+	shares := make([]*amphora.SecretShare, 0, len(names))
+	for _, name := range names {
+		var data []byte
+		for _, v := range outputs[name] {
+			share, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, share...)
+		}
+		os := amphora.SecretShare{
+			SecretID: outputSecretID(gameID, name),
+			Data:     base64.StdEncoding.EncodeToString(data),
+			Tags: append(append([]amphora.Tag{}, tags...), amphora.Tag{
+				ValueType: "STRING",
+				Key:       OutputNameTag,
+				Value:     name,
+			}),
+		}
+		shares = append(shares, &os)
+		secrets[name] = os.SecretID
+	}
+	if bulk, ok := f.conf.AmphoraClient.(amphora.BulkClient); ok {
+		started := time.Now()
+		err := bulk.CreateSecretShares(shares)
+		for _, os := range shares {
+			diag.recordAmphora(AmphoraCreate, os.SecretID, decodedLen(os.Data), started, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for _, os := range shares {
+			started := time.Now()
+			err := f.conf.AmphoraClient.CreateSecretShare(os)
+			diag.recordAmphora(AmphoraCreate, os.SecretID, decodedLen(os.Data), started, err)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, name := range names {
+		f.logger.Infow(fmt.Sprintf("Created secret share with id %s for output %s", secrets[name], name), GameID, act.GameID)
+	}
+	return secrets, nil
+}
+
+// outputSecretID returns the id of the secret the named output of the game with the given id is stored in.
+func outputSecretID(gameID uuid.UUID, name string) string {
+	return uuid.NewMD5(gameID, []byte(name)).String()
+}
+
+// OutputSecretIDs returns the ids of the secrets the output of the given activation is written to, if written to
+// Amphora. As the ids are derived from the game id, they are the same for all players.
+func OutputSecretIDs(act *Activation) ([]string, error) {
+	if len(act.Output.Schema) == 0 {
+		return []string{act.GameID}, nil
+	}
+	gameID, err := uuid.Parse(act.GameID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(act.Output.Schema))
+	for i, field := range act.Output.Schema {
+		ids[i] = outputSecretID(gameID, field.Name)
+	}
+	return ids, nil
+}
+
+// writeToObjectStore uploads the packed response to the object store, using the game id as key, and returns a
+// presigned URL the client can download it from.
+func (f *AmphoraFeeder) writeToObjectStore(act *Activation, resp Result) (string, error) {
+	client := f.conf.ObjectStoreClient
+	// As for Amphora, the bulk response has exactly 1 element.
+	data, err := base64.StdEncoding.DecodeString(resp.Response[0])
+	if err != nil {
+		return "", err
+	}
+	if err := client.PutObject(act.GameID, data); err != nil {
+		return "", err
+	}
+	f.logger.Infow(fmt.Sprintf("Uploaded object with key %s", act.GameID), GameID, act.GameID, "Size", len(data))
+	return client.PresignGet(act.GameID)
+}
+
 func findValueForKeyInTags(tags []amphora.Tag, key string) (string, bool) {
 	for _, tag := range tags {
 		if tag.Key == key {
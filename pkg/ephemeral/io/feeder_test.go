@@ -5,14 +5,19 @@
 package io
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"github.com/carbynestack/ephemeral/pkg/amphora"
+	"github.com/carbynestack/ephemeral/pkg/audit"
 	. "github.com/carbynestack/ephemeral/pkg/types"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"go.uber.org/zap"
+	"io"
+	"io/ioutil"
 )
 
 var _ = Describe("Feeder", func() {
@@ -50,7 +55,7 @@ var _ = Describe("Feeder", func() {
 		Context("when reading objects from amphora", func() {
 			Context("when output type is plaintext", func() {
 				It("responds with the result", func() {
-					res, err := f.LoadFromSecretStoreAndFeed(act, "", conf)
+					res, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
 					Expect(err).NotTo(HaveOccurred())
 					var response Result
 					json.Unmarshal(res, &response)
@@ -61,7 +66,7 @@ var _ = Describe("Feeder", func() {
 			Context("when output type is secret share", func() {
 				It("responds with the result", func() {
 					act.Output.Type = SecretShare
-					res, err := f.LoadFromSecretStoreAndFeed(act, "", conf)
+					res, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
 					Expect(err).NotTo(HaveOccurred())
 					var response Result
 					json.Unmarshal(res, &response)
@@ -72,7 +77,7 @@ var _ = Describe("Feeder", func() {
 			Context("when output type is amphora secret", func() {
 				It("responds with the secretID=gameID", func() {
 					act.Output.Type = AmphoraSecret
-					res, err := f.LoadFromSecretStoreAndFeed(act, "", conf)
+					res, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
 					Expect(err).NotTo(HaveOccurred())
 					var response Result
 					json.Unmarshal(res, &response)
@@ -80,18 +85,96 @@ var _ = Describe("Feeder", func() {
 					Expect(carrier.isBulk).To(BeTrue())
 				})
 			})
+			Context("when a result schema is declared", func() {
+				var amphoraClient *FakeAmphoraClient
+				BeforeEach(func() {
+					amphoraClient = &FakeAmphoraClient{}
+					f.conf.AmphoraClient = amphoraClient
+					share := func(b byte) string {
+						return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+					}
+					carrier.response = []string{share(1), share(2), share(3)}
+					act.Output.Schema = []OutputField{{Name: "sum", Count: 1}, {Name: "products", Count: 2}}
+				})
+				It("splits the response into the named outputs", func() {
+					act.Output.Type = SecretShare
+					res, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
+					Expect(err).NotTo(HaveOccurred())
+					var response Result
+					json.Unmarshal(res, &response)
+					Expect(response.Response).To(BeEmpty())
+					Expect(response.Outputs).To(Equal(map[string][]string{
+						"sum":      carrier.response[:1],
+						"products": carrier.response[1:],
+					}))
+				})
+				It("creates a secret per named output", func() {
+					act.Output.Type = AmphoraSecret
+					res, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(carrier.isBulk).To(BeFalse())
+					var response Result
+					json.Unmarshal(res, &response)
+					Expect(response.Secrets).To(HaveLen(2))
+					Expect(response.Response).To(Equal([]string{response.Secrets["sum"], response.Secrets["products"]}))
+					Expect(amphoraClient.created).To(HaveLen(2))
+					for _, secret := range amphoraClient.created {
+						name, ok := findValueForKeyInTags(secret.Tags, OutputNameTag)
+						Expect(ok).To(BeTrue())
+						Expect(secret.SecretID).To(Equal(response.Secrets[name]))
+						gameID, _ := findValueForKeyInTags(secret.Tags, "gameID")
+						Expect(gameID).To(Equal(act.GameID))
+					}
+					products, _ := base64.StdEncoding.DecodeString(amphoraClient.created[0].Data)
+					Expect(products).To(Equal(append(bytes.Repeat([]byte{2}, 32), bytes.Repeat([]byte{3}, 32)...)))
+				})
+				It("creates the secrets at once if supported", func() {
+					bulkClient := &FakeBulkAmphoraClient{}
+					f.conf.AmphoraClient = bulkClient
+					act.Output.Type = AmphoraSecret
+					_, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(bulkClient.bulkCreates).To(Equal(1))
+					Expect(bulkClient.created).To(HaveLen(2))
+				})
+				It("derives the ids of the secrets from the game id", func() {
+					act.Output.Type = AmphoraSecret
+					res, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
+					Expect(err).NotTo(HaveOccurred())
+					var response Result
+					json.Unmarshal(res, &response)
+					Expect(OutputSecretIDs(act)).To(Equal(response.Response))
+				})
+				It("returns an error if the number of values does not match", func() {
+					act.Output.Type = SecretShare
+					act.Output.Schema[1].Count = 3
+					_, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
+					Expect(err).To(MatchError("the result schema declares 4 values, but the program returned 3"))
+				})
+			})
 			Context("when no output type is given", func() {
 				It("returns an error", func() {
 					act.Output.Type = ""
-					res, err := f.LoadFromSecretStoreAndFeed(act, "", conf)
+					res, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
 					Expect(err).To(HaveOccurred())
 					Expect(res).To(BeNil())
 				})
 			})
+			Context("when the client reads several objects at once", func() {
+				It("reads all objects with a single request", func() {
+					bulkClient := &FakeBulkAmphoraClient{}
+					f.conf.AmphoraClient = bulkClient
+					act.AmphoraParams = []string{"a", "b"}
+					_, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(bulkClient.bulkGets).To(Equal(1))
+					Expect(carrier.secrets).To(HaveLen(2))
+				})
+			})
 			Context("when getting an object fails", func() {
 				It("returns an error", func() {
 					f.conf.AmphoraClient = &BrokenReadFakeAmphoraClient{}
-					res, err := f.LoadFromSecretStoreAndFeed(act, "", conf)
+					res, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(Equal("amphora read error"))
 					Expect(res).To(BeNil())
@@ -101,7 +184,7 @@ var _ = Describe("Feeder", func() {
 				It("returns an error", func() {
 					f.conf.AmphoraClient = &BrokenWriteFakeAmphoraClient{}
 					act.Output.Type = AmphoraSecret
-					res, err := f.LoadFromSecretStoreAndFeed(act, "", conf)
+					res, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(Equal("amphora create error"))
 					Expect(res).To(BeNil())
@@ -112,7 +195,7 @@ var _ = Describe("Feeder", func() {
 			Context("when output is to be written in the http response", func() {
 				It("responds with the result", func() {
 					act.Output.Type = SecretShare
-					res, err := f.LoadFromRequestAndFeed(act, "", conf)
+					res, err := f.LoadFromRequestAndFeed(act, []string{""}, conf)
 					Expect(err).NotTo(HaveOccurred())
 					var response Result
 					json.Unmarshal(res, &response)
@@ -123,19 +206,26 @@ var _ = Describe("Feeder", func() {
 			Context("when output is to be written to amphora", func() {
 				It("responds with the secretID=gameID", func() {
 					act.Output.Type = AmphoraSecret
-					res, err := f.LoadFromRequestAndFeed(act, "", conf)
+					res, err := f.LoadFromRequestAndFeed(act, []string{""}, conf)
 					Expect(err).NotTo(HaveOccurred())
 					var response Result
 					json.Unmarshal(res, &response)
 					Expect(response.Response[0]).To(Equal(act.GameID))
 					Expect(carrier.isBulk).To(BeTrue())
 				})
+				It("signals the delivery of the result", func() {
+					act.Output.Type = AmphoraSecret
+					conf.Delivery = make(chan struct{})
+					_, err := f.LoadFromRequestAndFeed(act, []string{""}, conf)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(conf.Delivery).To(BeClosed())
+				})
 			})
 			Context("when creating an object fails", func() {
 				It("returns an error", func() {
 					f.conf.AmphoraClient = &BrokenWriteFakeAmphoraClient{}
 					act.Output.Type = AmphoraSecret
-					res, err := f.LoadFromRequestAndFeed(act, "", conf)
+					res, err := f.LoadFromRequestAndFeed(act, []string{""}, conf)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(Equal("amphora create error"))
 					Expect(res).To(BeNil())
@@ -145,7 +235,7 @@ var _ = Describe("Feeder", func() {
 				It("returns an error", func() {
 					f.carrier = &BrokenConnectFakeCarrier{}
 					act.Output.Type = AmphoraSecret
-					res, err := f.LoadFromRequestAndFeed(act, "", conf)
+					res, err := f.LoadFromRequestAndFeed(act, []string{""}, conf)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(Equal("carrier connect error"))
 					Expect(res).To(BeNil())
@@ -155,7 +245,7 @@ var _ = Describe("Feeder", func() {
 				It("returns an error", func() {
 					f.carrier = &BrokenSendFakeCarrier{}
 					act.Output.Type = AmphoraSecret
-					res, err := f.LoadFromRequestAndFeed(act, "", conf)
+					res, err := f.LoadFromRequestAndFeed(act, []string{""}, conf)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(Equal("carrier send error"))
 					Expect(res).To(BeNil())
@@ -164,6 +254,233 @@ var _ = Describe("Feeder", func() {
 		})
 	})
 
+	Context("when streaming the input", func() {
+		var share string
+		BeforeEach(func() {
+			f.conf.InputStreamBufferSize = 64
+			share = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, BodySize))
+		})
+		It("streams the parameters of the request", func() {
+			act.SecretParams = []string{share, share}
+			_, err := f.LoadFromRequestAndFeed(act, []string{""}, conf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(carrier.input).To(Equal(bytes.Repeat([]byte{1}, 2*BodySize)))
+		})
+		It("rejects parameters which are not a multiple of the share size", func() {
+			act.SecretParams = []string{base64.StdEncoding.EncodeToString([]byte{1})}
+			_, err := f.LoadFromRequestAndFeed(act, []string{""}, conf)
+			Expect(err).To(MatchError(ErrInvalidBodySize))
+		})
+		It("spools the secrets read from amphora before streaming them", func() {
+			f.conf.AmphoraClient = &FakeAmphoraClient{data: share}
+			act.AmphoraParams = []string{"a", "b"}
+			act.Diagnostics = true
+			res, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(carrier.input).To(Equal(bytes.Repeat([]byte{1}, 2*BodySize)))
+			var response Result
+			json.Unmarshal(res, &response)
+			Expect(response.Diagnostics.InputBytes).To(Equal(int64(2 * BodySize)))
+		})
+		It("rejects secrets which are not a multiple of the share size", func() {
+			f.conf.AmphoraClient = &FakeAmphoraClient{data: base64.StdEncoding.EncodeToString([]byte{1})}
+			_, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
+			Expect(err).To(MatchError("failed to spool secret a: " + ErrInvalidBodySize))
+		})
+	})
+
+	Context("when the activation defines several client connections", func() {
+		var extra []*FakeCarrier
+		BeforeEach(func() {
+			extra = nil
+			f.newCarrier = func() AbstractCarrier {
+				c := &FakeCarrier{}
+				extra = append(extra, c)
+				return c
+			}
+			act.AmphoraParams = nil
+			act.SecretParams = []string{"a", "b", "c"}
+			act.Connections = []ClientConnection{{Params: 2}, {Port: 14000, Params: 1}}
+		})
+		It("sends the parameters of each connection to its port and returns the ports", func() {
+			res, err := f.LoadFromRequestAndFeed(act, []string{"10000", "14000"}, conf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(carrier.port).To(Equal("10000"))
+			Expect(carrier.secrets).To(Equal([]amphora.SecretShare{{Data: "a"}, {Data: "b"}}))
+			Expect(extra).To(HaveLen(1))
+			Expect(extra[0].port).To(Equal("14000"))
+			Expect(extra[0].secrets).To(Equal([]amphora.SecretShare{{Data: "c"}}))
+			var response Result
+			json.Unmarshal(res, &response)
+			Expect(response.Response).To(Equal([]string{"yay"}))
+			Expect(response.Ports).To(Equal([]string{"10000", "14000"}))
+		})
+		It("streams the spooled secrets of each connection", func() {
+			f.conf.InputStreamBufferSize = 64
+			share := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, BodySize))
+			f.conf.AmphoraClient = &FakeAmphoraClient{data: share}
+			act.SecretParams = nil
+			act.AmphoraParams = []string{"a", "b"}
+			act.Connections = []ClientConnection{{Params: 1}, {Params: 1}}
+			_, err := f.LoadFromSecretStoreAndFeed(act, []string{"10000", "10000"}, conf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(carrier.input).To(Equal(bytes.Repeat([]byte{1}, BodySize)))
+			Expect(extra[0].input).To(Equal(bytes.Repeat([]byte{1}, BodySize)))
+		})
+		It("returns an error if the connections do not take all parameters", func() {
+			act.Connections = []ClientConnection{{Params: 1}}
+			_, err := f.LoadFromRequestAndFeed(act, []string{"10000"}, conf)
+			Expect(err).To(MatchError("the connections take 1 parameters, but 3 are given"))
+		})
+		It("returns an error if a port is missing", func() {
+			_, err := f.LoadFromRequestAndFeed(act, []string{"10000"}, conf)
+			Expect(err).To(MatchError("expected a feed port for each of the 2 connections, got 1"))
+		})
+	})
+
+	Context("when output is to be uploaded to the object store", func() {
+		var store *FakeObjectStoreClient
+		BeforeEach(func() {
+			store = &FakeObjectStoreClient{objects: map[string][]byte{}}
+			f.conf.ObjectStoreClient = store
+			carrier.response = []string{"b3V0cHV0"}
+			act.Output.Type = ObjectStore
+		})
+		It("uploads the packed output with the game id as key and responds with a presigned URL", func() {
+			res, err := f.LoadFromRequestAndFeed(act, []string{""}, conf)
+			Expect(err).NotTo(HaveOccurred())
+			var response Result
+			json.Unmarshal(res, &response)
+			Expect(response.Response).To(Equal([]string{"http://minio:9000/results/" + act.GameID + "?X-Amz-Signature=signature"}))
+			Expect(store.objects).To(Equal(map[string][]byte{act.GameID: []byte("output")}))
+			Expect(carrier.isBulk).To(BeTrue())
+		})
+		It("returns an error if the upload fails", func() {
+			store.err = errors.New("object store error")
+			_, err := f.LoadFromRequestAndFeed(act, []string{""}, conf)
+			Expect(err).To(MatchError("object store error"))
+		})
+		It("returns an error if no object store is configured", func() {
+			f.conf.ObjectStoreClient = nil
+			_, err := f.LoadFromRequestAndFeed(act, []string{""}, conf)
+			Expect(err).To(MatchError("unsupported output type OBJECTSTORE, no object store is configured"))
+		})
+	})
+
+	Context("when diagnostics are requested", func() {
+		BeforeEach(func() {
+			act.Diagnostics = true
+		})
+		It("reports the input and output size along with the amphora interactions", func() {
+			act.Output.Type = AmphoraSecret
+			res, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
+			Expect(err).NotTo(HaveOccurred())
+			var response Result
+			json.Unmarshal(res, &response)
+			Expect(response.Diagnostics).NotTo(BeNil())
+			Expect(response.Diagnostics.OutputBytes).To(Equal(int64(2)))
+			Expect(response.Diagnostics.Amphora).To(HaveLen(2))
+			Expect(response.Diagnostics.Amphora[0].Operation).To(Equal(AmphoraGet))
+			Expect(response.Diagnostics.Amphora[0].SecretID).To(Equal("a"))
+			Expect(response.Diagnostics.Amphora[0].Status).To(Equal(200))
+			Expect(response.Diagnostics.Amphora[1].Operation).To(Equal(AmphoraCreate))
+			Expect(response.Diagnostics.Amphora[1].SecretID).To(Equal(act.GameID))
+			Expect(response.Diagnostics.Amphora[1].Status).To(Equal(201))
+		})
+		It("reports the size of the input parameters", func() {
+			act.SecretParams = []string{"AAAA", "AA=="}
+			res, err := f.LoadFromRequestAndFeed(act, []string{""}, conf)
+			Expect(err).NotTo(HaveOccurred())
+			var response Result
+			json.Unmarshal(res, &response)
+			Expect(response.Diagnostics.InputBytes).To(Equal(int64(4)))
+			Expect(response.Diagnostics.Amphora).To(BeEmpty())
+		})
+		It("attaches the diagnostics to the error if reading a secret fails", func() {
+			f.conf.AmphoraClient = &BrokenReadFakeAmphoraClient{}
+			_, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
+			Expect(err).To(BeAssignableToTypeOf(&DiagnosedError{}))
+			Expect(err.Error()).To(Equal("amphora read error"))
+			diag := err.(*DiagnosedError).Diagnostics
+			Expect(diag.Amphora).To(HaveLen(1))
+			Expect(diag.Amphora[0].Error).To(Equal("amphora read error"))
+			Expect(diag.Amphora[0].Status).To(Equal(0))
+		})
+		It("does not report diagnostics unless requested", func() {
+			act.Diagnostics = false
+			res, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
+			Expect(err).NotTo(HaveOccurred())
+			var response Result
+			json.Unmarshal(res, &response)
+			Expect(response.Diagnostics).To(BeNil())
+		})
+	})
+	Context("when the game is audited", func() {
+		It("records the ids of the secrets the result is stored in", func() {
+			act.Output.Type = AmphoraSecret
+			conf.Audit = audit.NewAuditor(zap.NewNop().Sugar()).NewTrail(audit.Record{GameID: act.GameID})
+			_, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
+			Expect(err).NotTo(HaveOccurred())
+			events := conf.Audit.Record().Events
+			Expect(events[len(events)-1].Name).To(Equal(audit.ResultDelivered))
+			Expect(events[len(events)-1].Details).To(Equal(map[string]interface{}{
+				"outputType": AmphoraSecret,
+				"secretIDs":  []string{act.GameID},
+			}))
+		})
+	})
+	Context("when tagging the output", func() {
+		It("adds a tag for each label of the activation", func() {
+			act.Labels = map[string]string{"tenant": "acme", "job": "nightly"}
+			tags, err := f.outputTags(act, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tags).To(Equal([]amphora.Tag{
+				{ValueType: "STRING", Key: "gameID", Value: act.GameID},
+				{ValueType: "STRING", Key: "label.job", Value: "nightly"},
+				{ValueType: "STRING", Key: "label.tenant", Value: "acme"},
+			}))
+		})
+	})
+
+	Context("when streaming the output", func() {
+		BeforeEach(func() {
+			f.conf.OutputStreamBufferSize = 32
+		})
+		It("passes the values to the output channel of the context", func() {
+			conf.Output = make(chan []string, 1)
+			res, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(carrier.streamed).To(BeTrue())
+			Expect(conf.Output).To(Receive(Equal([]string{"yay"})))
+			Expect(conf.Output).To(BeClosed())
+			var response Result
+			json.Unmarshal(res, &response)
+			Expect(response.Response).To(BeEmpty())
+		})
+		It("reads the whole output if the context has no output channel", func() {
+			_, err := f.LoadFromSecretStoreAndFeed(act, []string{""}, conf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(carrier.streamed).To(BeFalse())
+		})
+		It("uploads the output to amphora while reading it", func() {
+			client := &FakeAmphoraClient{}
+			f.conf.AmphoraClient = client
+			act.Output.Type = AmphoraSecret
+			res, err := f.LoadFromRequestAndFeed(act, []string{""}, conf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.streamed).To(Equal([]byte("yay")))
+			var response Result
+			json.Unmarshal(res, &response)
+			Expect(response.Response).To(Equal([]string{act.GameID}))
+		})
+		It("returns an error if the upload fails", func() {
+			f.conf.AmphoraClient = &BrokenWriteFakeAmphoraClient{}
+			act.Output.Type = AmphoraSecret
+			_, err := f.LoadFromRequestAndFeed(act, []string{""}, conf)
+			Expect(err).To(MatchError("amphora create error"))
+		})
+	})
+
 	Context("when creating a new instance of feeder", func() {
 		It("sets required parameters and returns a new instance", func() {
 			l := zap.NewNop().Sugar()
@@ -188,14 +505,71 @@ func (f *FakeOpaClient) CanExecute(interface{}) (bool, error) {
 }
 
 type FakeAmphoraClient struct {
+	streamed []byte
+	// data is the data of the secret shares read.
+	data string
+	// created are the secret shares created.
+	created []amphora.SecretShare
+	// deleted are the ids of the secret shares deleted.
+	deleted []string
 }
 
-func (f *FakeAmphoraClient) GetSecretShare(string, string) (amphora.SecretShare, error) {
-	return amphora.SecretShare{}, nil
+func (f *FakeAmphoraClient) GetSecretShare(id string, _ string) (amphora.SecretShare, error) {
+	return amphora.SecretShare{SecretID: id, Data: f.data}, nil
+}
+func (f *FakeAmphoraClient) CreateSecretShare(secret *amphora.SecretShare) error {
+	f.created = append(f.created, *secret)
+	return nil
+}
+func (f *FakeAmphoraClient) CreateSecretShareFromReader(secretID string, tags []amphora.Tag, data io.Reader) error {
+	var err error
+	f.streamed, err = ioutil.ReadAll(data)
+	return err
+}
+func (f *FakeAmphoraClient) DeleteSecretShare(secretID string) error {
+	f.deleted = append(f.deleted, secretID)
+	return nil
+}
+
+// FakeBulkAmphoraClient reads and creates several secret shares at once.
+type FakeBulkAmphoraClient struct {
+	FakeAmphoraClient
+	bulkGets    int
+	bulkCreates int
+}
+
+func (f *FakeBulkAmphoraClient) GetSecretShares(ids []string, programIdentifier string) ([]amphora.SecretShare, error) {
+	f.bulkGets++
+	shares := make([]amphora.SecretShare, 0, len(ids))
+	for _, id := range ids {
+		os, _ := f.GetSecretShare(id, programIdentifier)
+		shares = append(shares, os)
+	}
+	return shares, nil
+}
+func (f *FakeBulkAmphoraClient) CreateSecretShares(shares []*amphora.SecretShare) error {
+	f.bulkCreates++
+	for _, os := range shares {
+		f.created = append(f.created, *os)
+	}
+	return nil
+}
+
+type FakeObjectStoreClient struct {
+	objects map[string][]byte
+	err     error
 }
-func (f *FakeAmphoraClient) CreateSecretShare(*amphora.SecretShare) error {
+
+func (f *FakeObjectStoreClient) PutObject(key string, data []byte) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.objects[key] = data
 	return nil
 }
+func (f *FakeObjectStoreClient) PresignGet(key string) (string, error) {
+	return "http://minio:9000/results/" + key + "?X-Amz-Signature=signature", nil
+}
 
 type BrokenReadFakeAmphoraClient struct {
 }
@@ -206,6 +580,12 @@ func (f *BrokenReadFakeAmphoraClient) GetSecretShare(string, string) (amphora.Se
 func (f *BrokenReadFakeAmphoraClient) CreateSecretShare(*amphora.SecretShare) error {
 	return nil
 }
+func (f *BrokenReadFakeAmphoraClient) CreateSecretShareFromReader(string, []amphora.Tag, io.Reader) error {
+	return nil
+}
+func (f *BrokenReadFakeAmphoraClient) DeleteSecretShare(string) error {
+	return nil
+}
 
 type BrokenWriteFakeAmphoraClient struct {
 }
@@ -216,28 +596,59 @@ func (f *BrokenWriteFakeAmphoraClient) GetSecretShare(string, string) (amphora.S
 func (f *BrokenWriteFakeAmphoraClient) CreateSecretShare(*amphora.SecretShare) error {
 	return errors.New("amphora create error")
 }
+func (f *BrokenWriteFakeAmphoraClient) CreateSecretShareFromReader(string, []amphora.Tag, io.Reader) error {
+	return errors.New("amphora create error")
+}
+func (f *BrokenWriteFakeAmphoraClient) DeleteSecretShare(string) error {
+	return nil
+}
 
 type FakeCarrier struct {
-	isBulk bool
+	isBulk   bool
+	streamed bool
+	// port is the port the carrier is connected to.
+	port string
+	// secrets are the secret shares sent.
+	secrets []amphora.SecretShare
+	// input is the input sent as a stream.
+	input []byte
+	// response is returned by Read instead of the default response if set.
+	response []string
 }
 
-func (f *FakeCarrier) Connect(context.Context, int32, string, string) error {
+func (f *FakeCarrier) Connect(ctx context.Context, playerID int32, host string, port string) error {
+	f.port = port
 	return nil
 }
 
 func (f *FakeCarrier) Read(conv ResponseConverter, isBulk bool) (*Result, error) {
 	f.isBulk = isBulk
+	if f.response != nil {
+		return &Result{Response: f.response}, nil
+	}
 	return &Result{Response: []string{"yay"}}, nil
 }
 
+func (f *FakeCarrier) Stream(conv ResponseConverter, bufferSize int, emit func([]Parcel) error) error {
+	f.streamed = true
+	return emit([]Parcel{{Body: []byte("yay"), BodyBase64: "yay"}})
+}
+
 func (f *FakeCarrier) Close() error {
 	return nil
 }
 
-func (f *FakeCarrier) Send([]amphora.SecretShare) error {
+func (f *FakeCarrier) Send(secrets []amphora.SecretShare) error {
+	f.secrets = secrets
 	return nil
 }
 
+func (f *FakeCarrier) SendStream(data io.Reader, size int64, bufferSize int) error {
+	var err error
+	f.input, err = ioutil.ReadAll(io.LimitReader(data, size))
+	return err
+}
+
 type BrokenConnectFakeCarrier struct {
 	isBulk bool
 }
@@ -251,6 +662,10 @@ func (f *BrokenConnectFakeCarrier) Read(conv ResponseConverter, isBulk bool) (*R
 	return &Result{Response: []string{"yay"}}, nil
 }
 
+func (f *BrokenConnectFakeCarrier) Stream(ResponseConverter, int, func([]Parcel) error) error {
+	return nil
+}
+
 func (f *BrokenConnectFakeCarrier) Close() error {
 	return nil
 }
@@ -259,6 +674,10 @@ func (f *BrokenConnectFakeCarrier) Send([]amphora.SecretShare) error {
 	return nil
 }
 
+func (f *BrokenConnectFakeCarrier) SendStream(io.Reader, int64, int) error {
+	return nil
+}
+
 type BrokenSendFakeCarrier struct {
 	isBulk bool
 }
@@ -272,6 +691,10 @@ func (f *BrokenSendFakeCarrier) Read(conv ResponseConverter, isBulk bool) (*Resu
 	return &Result{Response: []string{"yay"}}, nil
 }
 
+func (f *BrokenSendFakeCarrier) Stream(ResponseConverter, int, func([]Parcel) error) error {
+	return nil
+}
+
 func (f *BrokenSendFakeCarrier) Close() error {
 	return nil
 }
@@ -279,3 +702,7 @@ func (f *BrokenSendFakeCarrier) Close() error {
 func (f *BrokenSendFakeCarrier) Send([]amphora.SecretShare) error {
 	return errors.New("carrier send error")
 }
+
+func (f *BrokenSendFakeCarrier) SendStream(io.Reader, int64, int) error {
+	return errors.New("carrier send error")
+}
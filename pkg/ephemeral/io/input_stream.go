@@ -0,0 +1,119 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package io
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// feedInput is the input of a game. It is either kept as base64 encoded secret shares or, if the input is streamed,
+// read from data.
+type feedInput struct {
+	params []string
+	data   io.Reader
+	size   int64
+}
+
+// len returns the number of bytes of the decoded input.
+func (in *feedInput) len() int64 {
+	if in.data != nil {
+		return in.size
+	}
+	var n int64
+	for _, p := range in.params {
+		n += decodedLen(p)
+	}
+	return n
+}
+
+// reader returns a reader of the decoded input. The parameters are decoded while they are read.
+func (in *feedInput) reader() (io.Reader, error) {
+	if in.data != nil {
+		return in.data, nil
+	}
+	readers := make([]io.Reader, len(in.params))
+	for i, p := range in.params {
+		if decodedLen(p)%BodySize != 0 {
+			return nil, errors.New(ErrInvalidBodySize)
+		}
+		readers[i] = base64.NewDecoder(base64.StdEncoding, strings.NewReader(p))
+	}
+	return io.MultiReader(readers...), nil
+}
+
+// splitParams assigns the parameters to the inputs of the client connections, each taking the given number of
+// parameters in order. The counts must add up to the number of parameters.
+func splitParams(params []string, counts []int) []*feedInput {
+	inputs := make([]*feedInput, len(counts))
+	next := 0
+	for i, count := range counts {
+		inputs[i] = &feedInput{params: params[next : next+count]}
+		next += count
+	}
+	return inputs
+}
+
+// newInputSpool returns an empty spool in the temporary directory, i.e., the directory given by $TMPDIR.
+func newInputSpool() (*inputSpool, error) {
+	file, err := ioutil.TempFile("", "ephemeral-input-")
+	if err != nil {
+		return nil, err
+	}
+	return &inputSpool{file: file}, nil
+}
+
+// inputSpool buffers the decoded secret shares read from Amphora in a temporary file. The size of the input must be
+// sent to the MPC runtime ahead of the input, hence the secrets are spooled before the input is streamed, instead of
+// being held in memory.
+type inputSpool struct {
+	file *os.File
+	size int64
+	// sizes are the decoded sizes of the spooled secret shares.
+	sizes []int64
+}
+
+// add appends the decoded secret share to the spool.
+func (s *inputSpool) add(b64 string) error {
+	n, err := io.Copy(s.file, base64.NewDecoder(base64.StdEncoding, strings.NewReader(b64)))
+	s.size += n
+	s.sizes = append(s.sizes, n)
+	if err != nil {
+		return err
+	}
+	if n%BodySize != 0 {
+		return errors.New(ErrInvalidBodySize)
+	}
+	return nil
+}
+
+// inputs returns the spooled inputs of the client connections, each taking the given number of secret shares in
+// order. The counts must add up to the number of spooled secret shares. The inputs are read from independent sections
+// of the spool, so that they can be sent concurrently.
+func (s *inputSpool) inputs(counts []int) []*feedInput {
+	inputs := make([]*feedInput, len(counts))
+	var offset int64
+	next := 0
+	for i, count := range counts {
+		var size int64
+		for _, n := range s.sizes[next : next+count] {
+			size += n
+		}
+		inputs[i] = &feedInput{data: io.NewSectionReader(s.file, offset, size), size: size}
+		offset += size
+		next += count
+	}
+	return inputs
+}
+
+// Close removes the spool.
+func (s *inputSpool) Close() error {
+	s.file.Close()
+	return os.Remove(s.file.Name())
+}
@@ -83,6 +83,13 @@ func (p *SPDZPacker) Marshal(b64 []string, dst *[]byte) (err error) {
 func (p *SPDZPacker) Unmarshal(in *[]byte, conv ResponseConverter, bulkSecrets bool) ([]string, error) {
 	prc, err := spdzToParcels(in, conv)
 	if err != nil {
+		// A partially converted bulk object would be a different secret, hence the prefix is only exposed for
+		// individual objects.
+		if truncated, ok := err.(*TruncatedResultError); ok && !bulkSecrets {
+			for i := range prc {
+				truncated.Converted = append(truncated.Converted, prc[i].BodyBase64)
+			}
+		}
 		return nil, err
 	}
 	strings := []string{}
@@ -159,24 +166,65 @@ func parcelsToSPDZ(prc []Parcel, o *[]byte) error {
 	return nil
 }
 
+// TruncatedResultError is returned when the bytes received from a SPDZ socket could not be converted completely. It
+// carries the successfully converted prefix of the response, which is empty if nothing could be converted.
+type TruncatedResultError struct {
+	// Reason describes why the conversion has failed.
+	Reason string
+	// Offset is the position in the received bytes up to which the response was converted successfully.
+	Offset int
+	// Converted is the successfully converted prefix of the response.
+	Converted []string
+}
+
+// Error returns the reason of the failed conversion.
+func (e *TruncatedResultError) Error() string {
+	return e.Reason
+}
+
 // spdzToParcels decodes the bytes received from a SPDZ socket.
 // i - input byte slice received from SPDZ runtime.
 // p - resulting Parcel
 // converter - a function to mutate the output, e.g. to specify different logic for plain text and secret shared values.
+//
+// If the output is corrupt, a *TruncatedResultError is returned along with the parcels that could be converted.
 func spdzToParcels(i *[]byte, converter ResponseConverter) ([]Parcel, error) {
+	if len(*i) < ParcelSizeLength {
+		return nil, &TruncatedResultError{Reason: ErrSPDZToParcel + "missing size header"}
+	}
 	size := (*i)[:ParcelSizeLength]
 	body := (*i)[ParcelSizeLength:]
 	s := binary.LittleEndian.Uint32(size)
-	if uint32(len(body))%s != 0 {
-		return nil, errors.New(ErrSPDZToParcel + ErrInvalidBodySize + fmt.Sprintf(", actual size is %d\n", len(body)))
+	if s == 0 || uint32(len(body))%s != 0 {
+		msg := ErrSPDZToParcel + ErrInvalidBodySize + fmt.Sprintf(", actual size is %d\n", len(body))
+		return convertPrefix(body, converter, msg)
 	}
-	parcels, err := converter.convert(body)
+	parcels, err := converter.Convert(body)
 	if err != nil {
-		return nil, errors.New(ErrSPDZToParcel + err.Error())
+		return convertPrefix(body, converter, ErrSPDZToParcel+err.Error())
 	}
 	return parcels, nil
 }
 
+// convertPrefix converts the longest prefix of body consisting of complete words of the given converter. The parcels
+// are returned together with a *TruncatedResultError describing the reason and the offset of the truncation.
+func convertPrefix(body []byte, converter ResponseConverter, reason string) ([]Parcel, error) {
+	offset := len(body) - len(body)%converter.ChunkSize()
+	truncated := &TruncatedResultError{
+		Reason: reason,
+		Offset: ParcelSizeLength + offset,
+	}
+	if offset == 0 {
+		return nil, truncated
+	}
+	parcels, err := converter.Convert(body[:offset])
+	if err != nil {
+		truncated.Offset = ParcelSizeLength
+		return nil, truncated
+	}
+	return parcels, truncated
+}
+
 // lenToBytes is a helper method to convert byte slice len to a Little Endian 4 bytes sequence.
 func lenToBytes(i []byte) ([]byte, error) {
 	if len(i) > int(MaxLength) {
@@ -305,6 +305,34 @@ var _ = Describe("Packer operations", func() {
 				Expect(err).NotTo(HaveOccurred())
 				Expect(resp[0]).To(Equal(b64Body))
 			})
+			Context("when the response is corrupt", func() {
+				It("returns an error carrying the converted prefix", func() {
+					packer := SPDZPacker{}
+					size := make([]byte, 4)
+					body := make([]byte, 32)
+					size[0] = 32
+					body[0] = 32
+					message := append(size, body...)
+					message = append(message, make([]byte, 16)...)
+					b64Body := base64.StdEncoding.EncodeToString(body)
+					_, err := packer.Unmarshal(&message, &SecretSharesConverter{}, false)
+					Expect(err).To(HaveOccurred())
+					truncated, ok := err.(*TruncatedResultError)
+					Expect(ok).To(BeTrue())
+					Expect(truncated.Converted).To(Equal([]string{b64Body}))
+					Expect(truncated.Offset).To(Equal(36))
+				})
+				It("does not expose a prefix for bulk objects", func() {
+					packer := SPDZPacker{}
+					size := make([]byte, 4)
+					size[0] = 32
+					message := append(size, make([]byte, 48)...)
+					_, err := packer.Unmarshal(&message, &SecretSharesConverter{}, true)
+					truncated, ok := err.(*TruncatedResultError)
+					Expect(ok).To(BeTrue())
+					Expect(truncated.Converted).To(BeEmpty())
+				})
+			})
 			Context("when a response for amphora is required", func() {
 				It("packs several parcels into one base64 string", func() {
 					packer := SPDZPacker{}
@@ -0,0 +1,108 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package io
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// ConverterFactory creates a ResponseConverter for the given engine configuration.
+type ConverterFactory func(conf *SPDZEngineTypedConfig) ResponseConverter
+
+var (
+	convertersMu sync.RWMutex
+	converters   = map[string]ConverterFactory{}
+)
+
+func init() {
+	plaintext := func(conf *SPDZEngineTypedConfig) PlaintextConverter {
+		return PlaintextConverter{Params: []interface{}{&conf.RInv, &conf.Prime}}
+	}
+	builtins := map[string]ConverterFactory{
+		PlainText: func(conf *SPDZEngineTypedConfig) ResponseConverter {
+			c := plaintext(conf)
+			return &c
+		},
+		SecretShare: func(*SPDZEngineTypedConfig) ResponseConverter {
+			return &SecretSharesConverter{}
+		},
+		AmphoraSecret: func(*SPDZEngineTypedConfig) ResponseConverter {
+			return &SecretSharesConverter{}
+		},
+		ObjectStore: func(*SPDZEngineTypedConfig) ResponseConverter {
+			return &SecretSharesConverter{}
+		},
+		SignedInt: func(conf *SPDZEngineTypedConfig) ResponseConverter {
+			return &SignedIntConverter{PlaintextConverter: plaintext(conf)}
+		},
+		FixedPoint: func(conf *SPDZEngineTypedConfig) ResponseConverter {
+			return &FixedPointConverter{
+				SignedIntConverter: SignedIntConverter{PlaintextConverter: plaintext(conf)},
+				Precision:          DefaultFixedPointPrecision,
+			}
+		},
+		JSONArray: func(conf *SPDZEngineTypedConfig) ResponseConverter {
+			return &JSONArrayConverter{SignedIntConverter: SignedIntConverter{PlaintextConverter: plaintext(conf)}}
+		},
+	}
+	for name, factory := range builtins {
+		if err := RegisterConverter(name, factory); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterConverter makes a converter available under the given output type. Output types are case-insensitive and
+// hyphens are ignored, i.e. "secret-share" selects the converter registered as SECRETSHARE. It is meant to be called
+// on start-up, e.g. from an init function, and fails if a converter is already registered under the name.
+func RegisterConverter(name string, factory ConverterFactory) error {
+	key := normalizeOutputType(name)
+	if key == "" {
+		return fmt.Errorf("the name of a converter must not be empty")
+	}
+	if factory == nil {
+		return fmt.Errorf("the factory of converter %s must not be nil", name)
+	}
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	if _, ok := converters[key]; ok {
+		return fmt.Errorf("a converter is already registered for output type %s", key)
+	}
+	converters[key] = factory
+	return nil
+}
+
+// NewConverter returns the converter registered for the given output type.
+func NewConverter(outputType string, conf *SPDZEngineTypedConfig) (ResponseConverter, error) {
+	convertersMu.RLock()
+	factory, ok := converters[normalizeOutputType(outputType)]
+	convertersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported output type %q, supported are %v", outputType, ConverterNames())
+	}
+	return factory(conf), nil
+}
+
+// ConverterNames returns the sorted output types converters are registered for.
+func ConverterNames() []string {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	names := make([]string, 0, len(converters))
+	for name := range converters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// normalizeOutputType returns the key a converter for the given output type is registered under.
+func normalizeOutputType(name string) string {
+	return strings.ToUpper(strings.Replace(strings.TrimSpace(name), "-", "", -1))
+}
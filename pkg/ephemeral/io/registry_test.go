@@ -0,0 +1,60 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package io
+
+import (
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Converter registry", func() {
+	conf := &SPDZEngineTypedConfig{}
+
+	It("provides the built-in converters", func() {
+		Expect(ConverterNames()).To(ContainElement(PlainText))
+		Expect(ConverterNames()).To(ContainElement(SecretShare))
+		Expect(ConverterNames()).To(ContainElement(AmphoraSecret))
+		Expect(ConverterNames()).To(ContainElement(SignedInt))
+		Expect(ConverterNames()).To(ContainElement(FixedPoint))
+		Expect(ConverterNames()).To(ContainElement(JSONArray))
+	})
+	It("ignores the case and hyphens of the output type", func() {
+		conv, err := NewConverter("secret-share", conf)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conv).To(BeAssignableToTypeOf(&SecretSharesConverter{}))
+		conv, err = NewConverter("fixed-point", conf)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conv.(*FixedPointConverter).Precision).To(Equal(uint(DefaultFixedPointPrecision)))
+	})
+	It("returns an error for unknown output types", func() {
+		_, err := NewConverter("unknown", conf)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix(`unsupported output type "unknown", supported are [`))
+	})
+	It("makes registered converters available", func() {
+		err := RegisterConverter("test-converter", func(*SPDZEngineTypedConfig) ResponseConverter {
+			return &SecretSharesConverter{}
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ConverterNames()).To(ContainElement("TESTCONVERTER"))
+		_, err = NewConverter("TestConverter", conf)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	It("rejects converters registered twice", func() {
+		err := RegisterConverter("plain-text", func(*SPDZEngineTypedConfig) ResponseConverter {
+			return &SecretSharesConverter{}
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("a converter is already registered for output type PLAINTEXT"))
+	})
+	It("rejects converters without a name", func() {
+		err := RegisterConverter(" ", func(*SPDZEngineTypedConfig) ResponseConverter {
+			return &SecretSharesConverter{}
+		})
+		Expect(err).To(HaveOccurred())
+	})
+})
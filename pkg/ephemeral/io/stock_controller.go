@@ -0,0 +1,109 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package io
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// fetchHeadroom is the number of times a batch of tuples must last as long as fetching the next batch takes, so that
+// the next batch is ready before MP-SPDZ consumed the current one.
+const fetchHeadroom = 2
+
+// newStockController returns a controller fetching chunks of the configured size, or of the tuple stock if not set.
+func newStockController(conf *AdaptiveStockConfig, tupleStock int32) *stockController {
+	chunkSize := conf.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = tupleStock
+	}
+	maxChunks := conf.MaxChunks
+	if maxChunks < 1 {
+		maxChunks = 1
+	}
+	maxConcurrency := conf.MaxConcurrentFetches
+	if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+	return &stockController{
+		chunkSize:      chunkSize,
+		maxChunks:      maxChunks,
+		maxConcurrency: maxConcurrency,
+		chunks:         1,
+	}
+}
+
+// stockController adapts the number of chunks of tuples fetched at a time to the rate MP-SPDZ consumes the tuples. It
+// scales up if MP-SPDZ waits for tuples, so that big programs are not starved, and scales down if the tuples last much
+// longer than fetching them takes, so that small programs waste few tuples.
+type stockController struct {
+	chunkSize      int32
+	maxChunks      int32
+	maxConcurrency int
+
+	mux    sync.Mutex
+	chunks int32
+	// fetchLatency is the time fetching the last batch took.
+	fetchLatency time.Duration
+	// chunkBytes is the amount of tuple data per chunk.
+	chunkBytes int
+}
+
+// batch returns the number of chunks to fetch next and the number of chunks to request concurrently.
+func (c *stockController) batch() (int32, int) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	concurrency := c.maxConcurrency
+	if int(c.chunks) < concurrency {
+		concurrency = int(c.chunks)
+	}
+	return c.chunks, concurrency
+}
+
+// fetched records that fetching a batch of the given number of chunks and bytes took the given time.
+func (c *stockController) fetched(latency time.Duration, chunks int32, bytes int) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.fetchLatency = latency
+	if chunks > 0 {
+		c.chunkBytes = bytes / int(chunks)
+	}
+}
+
+// drained records that MP-SPDZ consumed a batch of the given number of bytes within the given time. stalled is true if
+// the next batch was not ready by then.
+func (c *stockController) drained(bytes int, d time.Duration, stalled bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if stalled {
+		c.chunks = minChunks(2*c.chunks, c.maxChunks)
+		return
+	}
+	if d <= 0 || c.chunkBytes == 0 {
+		return
+	}
+	rate := float64(bytes) / d.Seconds()
+	needed := int32(math.Ceil(rate * fetchHeadroom * c.fetchLatency.Seconds() / float64(c.chunkBytes)))
+	if needed < 1 {
+		needed = 1
+	}
+	needed = minChunks(needed, c.maxChunks)
+	if needed < c.chunks && needed < c.chunks/2 {
+		// The batches shrink gradually, so that bursts of consumption do not starve the computation.
+		needed = c.chunks / 2
+	}
+	c.chunks = needed
+}
+
+func minChunks(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package io
+
+import (
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stock controller", func() {
+	var c *stockController
+	BeforeEach(func() {
+		c = newStockController(&AdaptiveStockConfig{MaxChunks: 8, MaxConcurrentFetches: 2}, 1000)
+	})
+	It("defaults the chunk size to the tuple stock and starts with a single chunk", func() {
+		Expect(c.chunkSize).To(Equal(int32(1000)))
+		chunks, concurrency := c.batch()
+		Expect(chunks).To(Equal(int32(1)))
+		Expect(concurrency).To(Equal(1))
+	})
+	It("doubles the chunks up to the maximum if MP-SPDZ waits for tuples", func() {
+		for i := 0; i < 4; i++ {
+			c.drained(1000, time.Second, true)
+		}
+		chunks, concurrency := c.batch()
+		Expect(chunks).To(Equal(int32(8)))
+		Expect(concurrency).To(Equal(2))
+	})
+	It("fetches enough chunks to last twice as long as fetching them takes", func() {
+		c.fetched(time.Second, 1, 100)
+		// 400 bytes per second, i.e., 800 bytes are consumed while the next batch is fetched.
+		c.drained(100, 250*time.Millisecond, false)
+		chunks, _ := c.batch()
+		Expect(chunks).To(Equal(int32(8)))
+	})
+	It("halves the chunks at most if the tuples are consumed slowly", func() {
+		c.chunks = 8
+		c.fetched(10*time.Millisecond, 8, 800)
+		c.drained(800, time.Minute, false)
+		chunks, _ := c.batch()
+		Expect(chunks).To(Equal(int32(4)))
+		c.drained(400, time.Minute, false)
+		c.drained(200, time.Minute, false)
+		c.drained(100, time.Minute, false)
+		chunks, _ = c.batch()
+		Expect(chunks).To(Equal(int32(1)))
+	})
+})
@@ -0,0 +1,141 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package io
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/castor"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// NewStreamerPool returns a pool keeping at most maxStreamers streamers for at most maxIdle between games.
+func NewStreamerPool(maxStreamers int, maxIdle time.Duration) *StreamerPool {
+	return &StreamerPool{
+		maxStreamers: maxStreamers,
+		maxIdle:      maxIdle,
+		idle:         map[string][]*warmStreamer{},
+		now:          time.Now,
+		pipeWriters:  DefaultPipeWriterFactory,
+	}
+}
+
+// StreamerPool keeps the pipes and file headers of the tuple streamers of a game for the next game of the same
+// parameters, i.e., tuple type, thread, field and tuple stock. The streamers created from a warm one fetch their first
+// batch of tuples right away instead of waiting for MP-SPDZ to open the pipe, which cuts the start latency of rapid
+// sequential games. Only the streamers whose pipe was opened by MP-SPDZ are kept, so that no tuples are fetched for
+// tuple types the games do not use.
+//
+// The tuples are requested for the game the streamer is acquired for, i.e., the reservations of castor are the same
+// as without the pool and all players are provided the same tuples.
+type StreamerPool struct {
+	maxStreamers int
+	maxIdle      time.Duration
+	now          func() time.Time
+	// pipeWriters creates the pipe writers of new streamers.
+	pipeWriters PipeWriterFactory
+	mux         sync.Mutex
+	idle        map[string][]*warmStreamer
+	size        int
+}
+
+// warmStreamer are the resources of a streamer kept between games.
+type warmStreamer struct {
+	pipeWriter PipeWriter
+	headerData []byte
+	since      time.Time
+}
+
+// Acquire returns a streamer for the given game. It reuses the pipe and header of a warm streamer of the same
+// parameters if available and creates a new streamer otherwise.
+func (p *StreamerPool) Acquire(l *zap.SugaredLogger, tt castor.TupleType, conf *SPDZEngineTypedConfig, playerDataDir string, gameID uuid.UUID, threadNr int) (*CastorTupleStreamer, error) {
+	headerData, err := generateHeader(tt.SpdzProtocol, conf)
+	if err != nil {
+		return nil, fmt.Errorf("error creating header: %v", err)
+	}
+	key := fmt.Sprintf("%s|%d|%x", filepath.Join(playerDataDir, GetTupleFileName(tt, conf, threadNr)), conf.TupleStock, headerData)
+	if warm := p.take(key); warm != nil {
+		logger := l.With(GameID, gameID, TupleType, tt, "ThreadNr", threadNr)
+		logger.Debug("Reusing warm tuple streamer")
+		ts := newCastorTupleStreamer(logger, tt, conf, gameID, threadNr, warm.pipeWriter, warm.headerData)
+		ts.prefetch = true
+		ts.poolKey = key
+		return ts, nil
+	}
+	ts, err := NewCastorTupleStreamerWithWriterFactory(l, tt, conf, playerDataDir, gameID, threadNr, p.pipeWriters)
+	if err != nil {
+		return nil, err
+	}
+	ts.poolKey = key
+	return ts, nil
+}
+
+// Release keeps the pipe and header of the given terminated streamer for the next game of the same parameters. The
+// streamer is dropped if MP-SPDZ did not open its pipe, it was not acquired from the pool or the pool is full.
+func (p *StreamerPool) Release(ts *CastorTupleStreamer) {
+	if ts.poolKey == "" || !ts.opened {
+		return
+	}
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.evictIdle()
+	if p.size >= p.maxStreamers {
+		return
+	}
+	p.idle[ts.poolKey] = append(p.idle[ts.poolKey], &warmStreamer{
+		pipeWriter: ts.pipeWriter,
+		headerData: ts.headerData,
+		since:      p.now(),
+	})
+	p.size++
+}
+
+// Size returns the number of warm streamers in the pool.
+func (p *StreamerPool) Size() int {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.evictIdle()
+	return p.size
+}
+
+// take removes a warm streamer of the given parameters from the pool. Returns nil if there is none.
+func (p *StreamerPool) take(key string) *warmStreamer {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.evictIdle()
+	warm := p.idle[key]
+	if len(warm) == 0 {
+		return nil
+	}
+	ws := warm[len(warm)-1]
+	p.idle[key] = warm[:len(warm)-1]
+	p.size--
+	return ws
+}
+
+// evictIdle drops the streamers idle for longer than the maximum idle time. It must be called with the lock held.
+func (p *StreamerPool) evictIdle() {
+	for key, warm := range p.idle {
+		kept := warm[:0]
+		for _, ws := range warm {
+			if p.now().Sub(ws.since) <= p.maxIdle {
+				kept = append(kept, ws)
+			}
+		}
+		p.size -= len(warm) - len(kept)
+		if len(kept) == 0 {
+			delete(p.idle, key)
+		} else {
+			p.idle[key] = kept
+		}
+	}
+}
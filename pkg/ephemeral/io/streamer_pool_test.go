@@ -0,0 +1,93 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package io
+
+import (
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/carbynestack/ephemeral/pkg/castor"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("StreamerPool", func() {
+	var (
+		pool    *StreamerPool
+		conf    *SPDZEngineTypedConfig
+		logger  *zap.SugaredLogger
+		created int
+	)
+	BeforeEach(func() {
+		pool = NewStreamerPool(2, time.Minute)
+		created = 0
+		pool.pipeWriters = func(_ *zap.SugaredLogger, filePath string, _ time.Duration) (PipeWriter, error) {
+			created++
+			return &FakeConsumingPipeWriter{filePath: filePath}, nil
+		}
+		prime, _ := big.NewInt(0).SetString("198766463529478683931867765928436695041", 10)
+		conf = &SPDZEngineTypedConfig{Prime: *prime, TupleStock: tupleStock, CastorClient: &FakeCastorClient{}}
+		logger = zap.NewNop().Sugar()
+	})
+	acquire := func(gameID uuid.UUID) *CastorTupleStreamer {
+		ts, err := pool.Acquire(logger, castor.BitGfp, conf, "Player-Data/0-p-128", gameID, 0)
+		Expect(err).NotTo(HaveOccurred())
+		return ts
+	}
+
+	It("reuses the pipe of a streamer for the next game of the same parameters", func() {
+		first := acquire(uuid.New())
+		Expect(first.prefetch).To(BeFalse())
+		first.opened = true
+		pool.Release(first)
+		Expect(pool.Size()).To(Equal(1))
+		gameID := uuid.New()
+		second := acquire(gameID)
+		Expect(created).To(Equal(1))
+		Expect(second.pipeWriter).To(BeIdenticalTo(first.pipeWriter))
+		Expect(second.prefetch).To(BeTrue())
+		Expect(second.baseRequestID).To(Equal(uuid.NewMD5(gameID, []byte(castor.BitGfp.Name+"0"))))
+		Expect(pool.Size()).To(BeZero())
+	})
+	It("creates a new streamer for other parameters", func() {
+		first := acquire(uuid.New())
+		first.opened = true
+		pool.Release(first)
+		conf.TupleStock = 2 * tupleStock
+		acquire(uuid.New())
+		Expect(created).To(Equal(2))
+		Expect(pool.Size()).To(Equal(1))
+	})
+	It("drops streamers whose pipe was not opened", func() {
+		pool.Release(acquire(uuid.New()))
+		Expect(pool.Size()).To(BeZero())
+	})
+	It("keeps at most the maximum number of streamers", func() {
+		var streamers []*CastorTupleStreamer
+		for i := 0; i < 3; i++ {
+			ts := acquire(uuid.New())
+			ts.opened = true
+			streamers = append(streamers, ts)
+		}
+		for _, ts := range streamers {
+			pool.Release(ts)
+		}
+		Expect(pool.Size()).To(Equal(2))
+	})
+	It("drops streamers idle for too long", func() {
+		ts := acquire(uuid.New())
+		ts.opened = true
+		pool.Release(ts)
+		pool.now = func() time.Time {
+			return time.Now().Add(2 * time.Minute)
+		}
+		Expect(pool.Size()).To(BeZero())
+	})
+})
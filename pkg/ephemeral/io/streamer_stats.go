@@ -0,0 +1,132 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package io
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	"go.uber.org/zap"
+)
+
+// StreamerStats are the statistics of one or more tuple streamers.
+type StreamerStats struct {
+	// Streamers is the number of tuple streamers the statistics are aggregated from.
+	Streamers int64
+	// UnusedStreamers is the number of tuple streamers whose pipe was never opened by MP-SPDZ, i.e., which were not
+	// required by the program. They fetch no tuples unless prefetching.
+	UnusedStreamers int64
+	// Tuples is the number of tuples fetched from castor.
+	Tuples int64
+	// FetchedBytes is the amount of tuple data fetched from castor.
+	FetchedBytes int64
+	// WrittenBytes is the amount of tuple data written to the pipes read by MP-SPDZ, excluding the file headers.
+	WrittenBytes int64
+	// DiscardedBytes is the amount of tuple data fetched from castor, but not consumed by MP-SPDZ until the streamers
+	// terminated.
+	DiscardedBytes int64
+	// CachedBytes is the amount of tuple data fetched from castor, but not consumed by MP-SPDZ, which was kept in the
+	// tuple cache for later games.
+	CachedBytes int64
+	// ReusedBytes is the amount of tuple data taken from the tuple cache instead of fetching it from castor.
+	ReusedBytes int64
+	// Fetches is the number of requests to castor.
+	Fetches int64
+	// TotalFetchLatency is the time spent waiting for castor to return the tuples.
+	TotalFetchLatency time.Duration
+	// MaxFetchLatency is the longest time spent waiting for castor to return the tuples.
+	MaxFetchLatency time.Duration
+	// Stalls is the number of times MP-SPDZ consumed all the tuples written to a pipe before the next tuples were
+	// fetched from castor, i.e., the computation waited for tuples.
+	Stalls int64
+	// StallTime is the time MP-SPDZ waited for tuples.
+	StallTime time.Duration
+	// ReleasedReservations is the number of castor reservations released as their tuples were neither written to
+	// the pipes nor cached, e.g., as the game was aborted.
+	ReleasedReservations int64
+}
+
+// MeanFetchLatency returns the average time spent waiting for castor to return the tuples.
+func (s StreamerStats) MeanFetchLatency() time.Duration {
+	if s.Fetches == 0 {
+		return 0
+	}
+	return s.TotalFetchLatency / time.Duration(s.Fetches)
+}
+
+// Add returns the statistics aggregated with the given ones.
+func (s StreamerStats) Add(o StreamerStats) StreamerStats {
+	s.Streamers += o.Streamers
+	s.UnusedStreamers += o.UnusedStreamers
+	s.Tuples += o.Tuples
+	s.FetchedBytes += o.FetchedBytes
+	s.WrittenBytes += o.WrittenBytes
+	s.DiscardedBytes += o.DiscardedBytes
+	s.CachedBytes += o.CachedBytes
+	s.ReusedBytes += o.ReusedBytes
+	s.Fetches += o.Fetches
+	s.TotalFetchLatency += o.TotalFetchLatency
+	if o.MaxFetchLatency > s.MaxFetchLatency {
+		s.MaxFetchLatency = o.MaxFetchLatency
+	}
+	s.Stalls += o.Stalls
+	s.StallTime += o.StallTime
+	s.ReleasedReservations += o.ReleasedReservations
+	return s
+}
+
+// Log logs the statistics as the summary of the streamers of the given tuple type.
+func (s StreamerStats) Log(logger *zap.SugaredLogger, tupleType string) {
+	logger.Infow("Tuple streaming summary", TupleType, tupleType, "Streamers", s.Streamers,
+		"UnusedStreamers", s.UnusedStreamers, "Tuples", s.Tuples,
+		"FetchedBytes", s.FetchedBytes, "WrittenBytes", s.WrittenBytes, "DiscardedBytes", s.DiscardedBytes,
+		"CachedBytes", s.CachedBytes, "ReusedBytes", s.ReusedBytes, "Fetches", s.Fetches, "MeanFetchLatency", s.MeanFetchLatency(), "MaxFetchLatency", s.MaxFetchLatency,
+		"Stalls", s.Stalls, "StallTime", s.StallTime, "ReleasedReservations", s.ReleasedReservations)
+}
+
+// NewStreamerMetrics returns empty tuple streamer metrics.
+func NewStreamerMetrics() *StreamerMetrics {
+	return &StreamerMetrics{
+		tupleTypes: map[string]StreamerStats{},
+	}
+}
+
+// StreamerMetrics aggregates the statistics of the tuple streamers of all games per tuple type.
+type StreamerMetrics struct {
+	mux        sync.Mutex
+	tupleTypes map[string]StreamerStats
+}
+
+// Add adds the statistics of streamers of the given tuple type.
+func (m *StreamerMetrics) Add(tupleType string, s StreamerStats) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.tupleTypes[tupleType] = m.tupleTypes[tupleType].Add(s)
+}
+
+// Snapshot returns the current statistics per tuple type.
+func (m *StreamerMetrics) Snapshot() map[string]StreamerStats {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	snapshot := make(map[string]StreamerStats, len(m.tupleTypes))
+	for tt, s := range m.tupleTypes {
+		snapshot[tt] = s
+	}
+	return snapshot
+}
+
+// TupleTypes returns the tuple types of the given statistics in alphabetical order.
+func TupleTypes(stats map[string]StreamerStats) []string {
+	types := make([]string, 0, len(stats))
+	for tt := range stats {
+		types = append(types, tt)
+	}
+	sort.Strings(types)
+	return types
+}
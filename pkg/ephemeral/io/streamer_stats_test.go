@@ -0,0 +1,34 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package io
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Streamer stats", func() {
+	It("aggregates the statistics per tuple type", func() {
+		m := NewStreamerMetrics()
+		m.Add("bits", StreamerStats{Streamers: 1, Fetches: 1, TotalFetchLatency: time.Second, MaxFetchLatency: time.Second, Stalls: 1})
+		m.Add("bits", StreamerStats{Streamers: 1, Fetches: 3, TotalFetchLatency: 3 * time.Second, MaxFetchLatency: 2 * time.Second})
+		m.Add("triples", StreamerStats{Streamers: 1, DiscardedBytes: 42})
+		snapshot := m.Snapshot()
+		Expect(TupleTypes(snapshot)).To(Equal([]string{"bits", "triples"}))
+		bits := snapshot["bits"]
+		Expect(bits.Streamers).To(Equal(int64(2)))
+		Expect(bits.Fetches).To(Equal(int64(4)))
+		Expect(bits.MeanFetchLatency()).To(Equal(time.Second))
+		Expect(bits.MaxFetchLatency).To(Equal(2 * time.Second))
+		Expect(bits.Stalls).To(Equal(int64(1)))
+		Expect(snapshot["triples"].DiscardedBytes).To(Equal(int64(42)))
+	})
+	It("reports no latency if nothing has been fetched", func() {
+		Expect(StreamerStats{}.MeanFetchLatency()).To(Equal(time.Duration(0)))
+	})
+})
@@ -20,6 +20,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -114,10 +115,15 @@ type TupleStreamer interface {
 	StartStreamTuples(terminateCh chan struct{}, errCh chan error, wg *sync.WaitGroup)
 }
 
-// GetTupleFileName returns the filename for a given tuple type, spdz configuration and thread number
+// GetTupleFileName returns the filename for a given tuple type, spdz configuration and thread number. The files of
+// edaBits are named after their bit length instead of the protocol.
 func GetTupleFileName(tt castor.TupleType, conf *SPDZEngineTypedConfig, threadNr int) string {
+	if tt == castor.EdaBitGfp {
+		return fmt.Sprintf("%s-%d-P%d-T%d",
+			tt.PreprocessingName, conf.EdaBitLength, conf.PartyNumber(conf.PlayerID), threadNr)
+	}
 	return fmt.Sprintf("%s-%s-P%d-T%d",
-		tt.PreprocessingName, tt.SpdzProtocol.Shorthand, conf.PlayerID, threadNr)
+		tt.PreprocessingName, tt.SpdzProtocol.Shorthand, conf.PartyNumber(conf.PlayerID), threadNr)
 }
 
 // NewCastorTupleStreamer returns a new instance of castor tuple streamer.
@@ -140,15 +146,26 @@ func NewCastorTupleStreamerWithWriterFactory(l *zap.SugaredLogger, tt castor.Tup
 		return nil, fmt.Errorf("error creating header: %v", err)
 	}
 	loggerWithContext.Debugf("Generated tuple file header: %x", headerData)
-	return &CastorTupleStreamer{
-		logger:        loggerWithContext,
+	return newCastorTupleStreamer(loggerWithContext, tt, conf, gameID, threadNr, pipeWriter, headerData), nil
+}
+
+// newCastorTupleStreamer returns a streamer for the given game writing to the given pipe.
+func newCastorTupleStreamer(logger *zap.SugaredLogger, tt castor.TupleType, conf *SPDZEngineTypedConfig, gameID uuid.UUID, threadNr int, pipeWriter PipeWriter, headerData []byte) *CastorTupleStreamer {
+	ts := &CastorTupleStreamer{
+		logger:        logger,
 		pipeWriter:    pipeWriter,
 		tupleType:     tt,
 		stockSize:     conf.TupleStock,
 		castorClient:  conf.CastorClient,
 		baseRequestID: uuid.NewMD5(gameID, []byte(tt.Name+strconv.Itoa(threadNr))),
 		headerData:    headerData,
-	}, nil
+		cache:         conf.TupleCache,
+	}
+	if conf.AdaptiveStock != nil {
+		ts.adaptive = newStockController(conf.AdaptiveStock, conf.TupleStock)
+		ts.doubleBuffering = conf.AdaptiveStock.DoubleBuffering
+	}
+	return ts
 }
 
 // CastorTupleStreamer provides tuples to the SPDZ execution for the given type and configuration.
@@ -163,7 +180,7 @@ type CastorTupleStreamer struct {
 	headerData     []byte
 	streamData     []byte
 	streamerDoneCh chan struct{}
-	tupleBufferCh  chan []byte
+	tupleBufferCh  chan tupleBatch
 	fetchTuplesCh  chan struct{}
 	// bufferLckCh is used as a synchronization lock, where one routine can lock the channel by writing to it. Each
 	// consecutive write will block the writing routine until the channel has been unlocked by reading from it. In
@@ -172,17 +189,92 @@ type CastorTupleStreamer struct {
 	// Reading is supposed to be performed by the initial routine which wrote to the channel.
 	bufferLckCh   chan struct{}
 	streamedBytes int
+	// fetchedBytes is the amount of tuple data fetched from castor. It is accessed atomically.
+	fetchedBytes int64
+	// statsMux guards stats.
+	statsMux sync.Mutex
+	stats    StreamerStats
+	// adaptive adapts the number of tuples fetched at a time. The stock size is fetched at a time if nil.
+	adaptive *stockController
+	// doubleBuffering keeps two batches of tuples ready instead of one.
+	doubleBuffering bool
+	// batchBytes and batchStart are the size of the batch of tuples being written to the pipe and the time writing it
+	// started at.
+	batchBytes int
+	batchStart time.Time
+	// cache keeps the tuples fetched, but not consumed, for later games requesting the same reservations. Unconsumed
+	// tuples are discarded if nil.
+	cache *castor.TupleCache
+	// prefetch fetches the first batch of tuples before MP-SPDZ opens the pipe.
+	prefetch bool
+	// opened is set once MP-SPDZ opened the pipe.
+	opened bool
+	// poolKey identifies the parameters of the streamer in a StreamerPool. Empty if not created by a pool.
+	poolKey string
+	// reservationsMux guards reservations.
+	reservationsMux sync.Mutex
+	// reservations are the requests to castor whose tuples have neither been written to the pipe nor cached yet,
+	// including the requests in flight. They are released once the streamer terminates.
+	reservations map[uuid.UUID]struct{}
+}
+
+// tupleChunk is the tuple data of a single request to castor.
+type tupleChunk struct {
+	requestID uuid.UUID
+	data      []byte
+}
+
+// tupleBatch is a batch of tuples fetched at a time, consisting of the chunks in the order they are written to the pipe.
+type tupleBatch []tupleChunk
+
+// size returns the amount of tuple data of the batch.
+func (b tupleBatch) size() int {
+	size := 0
+	for _, c := range b {
+		size += len(c.data)
+	}
+	return size
+}
+
+// FetchedTupleBytes returns the amount of tuple data fetched from castor so far.
+func (ts *CastorTupleStreamer) FetchedTupleBytes() int64 {
+	return atomic.LoadInt64(&ts.fetchedBytes)
+}
+
+// Stats returns the statistics of the streamer. The written and discarded bytes are only known once the streamer
+// terminated.
+func (ts *CastorTupleStreamer) Stats() StreamerStats {
+	ts.statsMux.Lock()
+	defer ts.statsMux.Unlock()
+	stats := ts.stats
+	stats.Streamers = 1
+	stats.FetchedBytes = ts.FetchedTupleBytes()
+	return stats
+}
+
+// updateStats applies the change to the statistics of the streamer.
+func (ts *CastorTupleStreamer) updateStats(change func(*StreamerStats)) {
+	ts.statsMux.Lock()
+	defer ts.statsMux.Unlock()
+	change(&ts.stats)
 }
 
 // StartStreamTuples repeatedly downloads a given type of tuples from castor and streams it to the according file as
-// required by MP-SPDZ
+// required by MP-SPDZ. Unless prefetching, the first tuples are requested once MP-SPDZ opens the pipe, so that no
+// tuples are fetched for the tuple types and threads the program does not use.
 func (ts *CastorTupleStreamer) StartStreamTuples(terminateCh chan struct{}, errCh chan error, wg *sync.WaitGroup) {
+	batches := 1
+	if ts.doubleBuffering {
+		batches = 2
+	}
 	ts.streamData = append(ts.streamData, ts.headerData...)
 	ts.streamerDoneCh = make(chan struct{})
-	ts.fetchTuplesCh = make(chan struct{}, 1)
+	ts.fetchTuplesCh = make(chan struct{}, batches)
 	ts.bufferLckCh = make(chan struct{}, 1)
-	ts.tupleBufferCh = make(chan []byte, 1)
-	ts.fetchTuplesCh <- struct{}{}
+	ts.tupleBufferCh = make(chan tupleBatch, batches)
+	for i := 0; i < batches; i++ {
+		ts.fetchTuplesCh <- struct{}{}
+	}
 	go func() {
 		defer func() {
 			close(ts.streamerDoneCh)
@@ -195,11 +287,16 @@ func (ts *CastorTupleStreamer) StartStreamTuples(terminateCh chan struct{}, errC
 			case <-time.After(10 * time.Second):
 				// However, we will not wait for too long for the bufferData routine to finish
 			}
-			discardedTupleBytes := 0
-			select {
-			case buffered := <-ts.tupleBufferCh:
-				discardedTupleBytes = len(buffered)
-			default:
+			discardedTupleBytes, cachedTupleBytes := 0, 0
+			for buffered := true; buffered; {
+				select {
+				case batch := <-ts.tupleBufferCh:
+					cached := ts.spill(batch)
+					cachedTupleBytes += cached
+					discardedTupleBytes += batch.size() - cached
+				default:
+					buffered = false
+				}
 			}
 			var streamedTupleBytes int
 			if ts.streamedBytes > len(ts.headerData) {
@@ -210,8 +307,19 @@ func (ts *CastorTupleStreamer) StartStreamTuples(terminateCh chan struct{}, errC
 			} else {
 				discardedTupleBytes += len(ts.streamData) - len(ts.headerData) + ts.streamedBytes
 			}
-			ts.logger.Debugw("Terminate tuple streamer",
-				"Provided bytes", streamedTupleBytes, "Discarded bytes", discardedTupleBytes)
+			released := ts.releaseReservations()
+			ts.updateStats(func(s *StreamerStats) {
+				s.WrittenBytes = int64(streamedTupleBytes)
+				s.DiscardedBytes = int64(discardedTupleBytes)
+				s.CachedBytes = int64(cachedTupleBytes)
+				s.ReleasedReservations = int64(released)
+				if !ts.opened {
+					s.UnusedStreamers = 1
+				}
+			})
+			ts.logger.Debugw("Terminate tuple streamer", "Provided bytes", streamedTupleBytes,
+				"Discarded bytes", discardedTupleBytes, "Cached bytes", cachedTupleBytes,
+				"Released reservations", released)
 			_ = ts.pipeWriter.Close()
 			wg.Done()
 		}()
@@ -224,14 +332,23 @@ func (ts *CastorTupleStreamer) StartStreamTuples(terminateCh chan struct{}, errC
 			}
 			close(pipeWriterReady)
 		}()
+		streamerErrorCh := make(chan error, 1)
+		jobsDoneCh := make(chan struct{}, 2)
+		if ts.prefetch {
+			go ts.bufferData(terminateCh, streamerErrorCh, jobsDoneCh)
+		}
 		select {
 		case <-terminateCh:
 			return
+		case err := <-streamerErrorCh:
+			errCh <- err
+			return
 		case <-pipeWriterReady:
 		}
-		streamerErrorCh := make(chan error, 1)
-		jobsDoneCh := make(chan struct{}, 2)
-		go ts.bufferData(terminateCh, streamerErrorCh, jobsDoneCh)
+		ts.opened = true
+		if !ts.prefetch {
+			go ts.bufferData(terminateCh, streamerErrorCh, jobsDoneCh)
+		}
 		go ts.writeDataToPipe(terminateCh, jobsDoneCh)
 		select {
 		case <-terminateCh:
@@ -256,9 +373,9 @@ func (ts *CastorTupleStreamer) bufferData(terminateCh chan struct{}, streamerErr
 			return
 		case <-ts.fetchTuplesCh:
 			ts.bufferLckCh <- struct{}{}
-			tupleData, err := ts.getTupleData()
+			batch, err := ts.getBatch()
 			if err == nil {
-				ts.tupleBufferCh <- tupleData
+				ts.tupleBufferCh <- batch
 			}
 			<-ts.bufferLckCh
 			if err != nil {
@@ -270,19 +387,169 @@ func (ts *CastorTupleStreamer) bufferData(terminateCh chan struct{}, streamerErr
 	}
 }
 
-func (ts *CastorTupleStreamer) getTupleData() ([]byte, error) {
-	requestID := uuid.NewMD5(ts.baseRequestID, []byte(strconv.Itoa(ts.requestCycle)))
-	ts.requestCycle++
-	tupleList, err := ts.castorClient.GetTuples(ts.stockSize, ts.tupleType, requestID)
+// getBatch fetches the next batch of tuples from castor. The batch consists of the number of chunks chosen by the
+// adaptive controller, which are requested concurrently, or of the stock size if the stock is not adapted.
+func (ts *CastorTupleStreamer) getBatch() (tupleBatch, error) {
+	if ts.adaptive == nil {
+		cycle := ts.requestCycle
+		ts.requestCycle++
+		chunk, err := ts.getTupleData(cycle, ts.stockSize)
+		if err != nil {
+			return nil, err
+		}
+		return tupleBatch{chunk}, nil
+	}
+	chunks, concurrency := ts.adaptive.batch()
+	start := time.Now()
+	data := make(tupleBatch, chunks)
+	errs := make([]error, chunks)
+	sem := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for i := range data {
+		// The chunks are numbered in the order they are written to the pipe, so that all players request the same
+		// tuples for the same position of the stream.
+		cycle := ts.requestCycle
+		ts.requestCycle++
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i, cycle int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			data[i], errs[i] = ts.getTupleData(cycle, ts.adaptive.chunkSize)
+		}(i, cycle)
+	}
+	wg.Wait()
+	for i := range errs {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+	}
+	ts.adaptive.fetched(time.Since(start), chunks, data.size())
+	ts.logger.Debugw("Fetched batch of tuples", "Chunks", chunks, "ConcurrentFetches", concurrency)
+	return data, nil
+}
+
+// getTupleData fetches the given number of tuples from castor, or takes them from the cache if they were fetched for
+// an earlier game, but not consumed. The request ID is derived from the given cycle.
+func (ts *CastorTupleStreamer) getTupleData(cycle int, count int32) (tupleChunk, error) {
+	requestID := uuid.NewMD5(ts.baseRequestID, []byte(strconv.Itoa(cycle)))
+	if ts.cache != nil {
+		if tupleData, ok := ts.cache.Take(ts.tupleType, requestID); ok {
+			ts.reserve(requestID)
+			ts.updateStats(func(s *StreamerStats) {
+				s.ReusedBytes += int64(len(tupleData))
+			})
+			ts.logger.Debugw("Took cached tuples", "RequestID", requestID)
+			return tupleChunk{requestID: requestID, data: tupleData}, nil
+		}
+	}
+	// The reservation is tracked before the request is sent, as castor may have reserved the tuples even if the request
+	// fails or the streamer terminates meanwhile.
+	ts.reserve(requestID)
+	start := time.Now()
+	tupleList, err := ts.castorClient.GetTuples(count, ts.tupleType, requestID)
+	if unavailable, ok := err.(*castor.UnavailableError); ok {
+		// The computation waits for castor to recover for one period of the circuit breaker before it fails.
+		ts.logger.Warnw("Castor is unavailable, waiting for it to recover", "RequestID", requestID, "RetryAfter", unavailable.RetryAfter)
+		select {
+		case <-ts.streamerDoneCh:
+			return tupleChunk{}, err
+		case <-time.After(unavailable.RetryAfter):
+		}
+		start = time.Now()
+		tupleList, err = ts.castorClient.GetTuples(count, ts.tupleType, requestID)
+	}
 	if err != nil {
-		return nil, err
+		return tupleChunk{}, err
 	}
+	latency := time.Since(start)
+	ts.updateStats(func(s *StreamerStats) {
+		s.Fetches++
+		s.Tuples += int64(len(tupleList.Tuples))
+		s.TotalFetchLatency += latency
+		if latency > s.MaxFetchLatency {
+			s.MaxFetchLatency = latency
+		}
+	})
 	ts.logger.Debugw("Fetched new tuples from Castor", "RequestID", requestID)
 	tupleData, err := ts.tupleListToByteArray(tupleList)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing received tuple list: %v", err)
+		return tupleChunk{}, fmt.Errorf("error parsing received tuple list: %v", err)
 	}
-	return tupleData, nil
+	atomic.AddInt64(&ts.fetchedBytes, int64(len(tupleData)))
+	return tupleChunk{requestID: requestID, data: tupleData}, nil
+}
+
+// spill puts the chunks of a batch never written to the pipe into the cache and returns the amount of tuple data
+// cached. The reservations of the chunks not cached are logged, so that the unused tuples can be accounted for, and
+// released once the streamer terminates.
+func (ts *CastorTupleStreamer) spill(batch tupleBatch) int {
+	cached := 0
+	for _, chunk := range batch {
+		if ts.cache != nil {
+			err := ts.cache.Put(ts.tupleType, chunk.requestID, chunk.data)
+			if err == nil {
+				// The reservation is kept for the game taking the tuples from the cache.
+				ts.settle(chunk.requestID)
+				cached += len(chunk.data)
+				continue
+			}
+			ts.logger.Warnw("Error caching unused tuples", "RequestID", chunk.requestID, "Error", err)
+		}
+		ts.logger.Infow("Discarding unused tuples", "RequestID", chunk.requestID, "Bytes", len(chunk.data))
+	}
+	return cached
+}
+
+// reserve tracks the reservation of the given request until it is settled.
+func (ts *CastorTupleStreamer) reserve(requestID uuid.UUID) {
+	ts.reservationsMux.Lock()
+	defer ts.reservationsMux.Unlock()
+	if ts.reservations == nil {
+		ts.reservations = map[uuid.UUID]struct{}{}
+	}
+	ts.reservations[requestID] = struct{}{}
+}
+
+// settle stops tracking the reservation of the given request, as its tuples have been written to the pipe or cached.
+func (ts *CastorTupleStreamer) settle(requestID uuid.UUID) {
+	ts.reservationsMux.Lock()
+	defer ts.reservationsMux.Unlock()
+	delete(ts.reservations, requestID)
+}
+
+// releaseReservations releases the reservations not settled, so that castor does not keep the tuples reserved for
+// requests the game will not complete, e.g., the tuples prefetched or requested concurrently when the game was
+// aborted. Returns the number of reservations released. The reservations are logged only if the castor client cannot
+// release them.
+func (ts *CastorTupleStreamer) releaseReservations() int {
+	ts.reservationsMux.Lock()
+	requestIDs := make([]uuid.UUID, 0, len(ts.reservations))
+	for requestID := range ts.reservations {
+		requestIDs = append(requestIDs, requestID)
+	}
+	ts.reservations = nil
+	ts.reservationsMux.Unlock()
+	if len(requestIDs) == 0 {
+		return 0
+	}
+	releaser, ok := ts.castorClient.(castor.ReservationReleaser)
+	if !ok {
+		ts.logger.Infow("Keeping unsettled reservations, the castor client cannot release them", "RequestIDs", requestIDs)
+		return 0
+	}
+	released := 0
+	for _, requestID := range requestIDs {
+		if err := releaser.ReleaseReservation(requestID); err != nil {
+			ts.logger.Warnw("Error releasing reservation", "RequestID", requestID, "Error", err)
+			continue
+		}
+		ts.logger.Debugw("Released reservation", "RequestID", requestID)
+		released++
+	}
+	return released
 }
 
 // writeDataToPipe pulls more tuples from Castor if required and writes the data to the pipe
@@ -299,15 +566,34 @@ func (ts *CastorTupleStreamer) writeDataToPipe(terminateCh chan struct{}, doneCh
 			return
 		default:
 			if ts.streamData == nil || len(ts.streamData) == 0 {
+				var tuples tupleBatch
 				select {
-				case <-terminateCh:
-					return
-				case <-ts.streamerDoneCh:
-					return
-				case tuples := <-ts.tupleBufferCh:
-					ts.streamData = append(ts.streamData, tuples...)
-					ts.fetchTuplesCh <- struct{}{}
+				case tuples = <-ts.tupleBufferCh:
+					ts.drained(false)
+				default:
+					ts.drained(true)
+					// All tuples have been written, MP-SPDZ waits until the next ones are fetched from castor.
+					stalledSince := time.Now()
+					select {
+					case <-terminateCh:
+						return
+					case <-ts.streamerDoneCh:
+						return
+					case tuples = <-ts.tupleBufferCh:
+					}
+					stallTime := time.Since(stalledSince)
+					ts.updateStats(func(s *StreamerStats) {
+						s.Stalls++
+						s.StallTime += stallTime
+					})
+				}
+				for _, chunk := range tuples {
+					ts.streamData = append(ts.streamData, chunk.data...)
+					ts.settle(chunk.requestID)
 				}
+				ts.batchBytes = tuples.size()
+				ts.batchStart = time.Now()
+				ts.fetchTuplesCh <- struct{}{}
 			}
 			c, err := ts.pipeWriter.Write(ts.streamData)
 			ts.streamData = ts.streamData[c:]
@@ -328,6 +614,15 @@ func (ts *CastorTupleStreamer) writeDataToPipe(terminateCh chan struct{}, doneCh
 	}
 }
 
+// drained passes the time writing the last batch of tuples took to the adaptive controller, if any. stalled is true
+// if the next batch is not ready yet.
+func (ts *CastorTupleStreamer) drained(stalled bool) {
+	if ts.adaptive == nil || ts.batchBytes == 0 {
+		return
+	}
+	ts.adaptive.drained(ts.batchBytes, time.Since(ts.batchStart), stalled)
+}
+
 // tupleListToByteArray converts a given list of tuple to a byte array
 func (ts *CastorTupleStreamer) tupleListToByteArray(tl *castor.TupleList) ([]byte, error) {
 	var result []byte
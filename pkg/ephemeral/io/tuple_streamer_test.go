@@ -17,6 +17,7 @@ import (
 	"os"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -90,6 +91,59 @@ var _ = Describe("Tuple Streamer", func() {
 				Expect(fbwpw.writeCalled).To(BeFalse())
 			})
 		})
+		Context("when MP-SPDZ does not open the pipe", func() {
+			var (
+				opw *FakeOpenablePipeWriter
+				ccc *CountingCastorClient
+			)
+			BeforeEach(func() {
+				opw = &FakeOpenablePipeWriter{opened: make(chan struct{})}
+				ccc = &CountingCastorClient{}
+				ts.pipeWriter = opw
+				ts.castorClient = ccc
+			})
+			It("does not fetch tuples", func() {
+				wg.Add(1)
+				ts.StartStreamTuples(terminate, errCh, wg)
+				Consistently(ccc.Calls, 200*time.Millisecond).Should(BeZero())
+				close(terminate)
+				wg.Wait()
+				stats := ts.Stats()
+				Expect(stats.FetchedBytes).To(BeZero())
+				Expect(stats.UnusedStreamers).To(Equal(int64(1)))
+			})
+			It("fetches tuples once the pipe is opened", func() {
+				wg.Add(1)
+				ts.StartStreamTuples(terminate, errCh, wg)
+				Consistently(ccc.Calls, 200*time.Millisecond).Should(BeZero())
+				close(opw.opened)
+				Eventually(ccc.Calls).Should(BeNumerically(">", 0))
+				close(terminate)
+				wg.Wait()
+				Expect(ts.Stats().UnusedStreamers).To(BeZero())
+			})
+			It("fetches the first tuples right away when prefetching", func() {
+				ts.prefetch = true
+				wg.Add(1)
+				ts.StartStreamTuples(terminate, errCh, wg)
+				Eventually(ccc.Calls).Should(Equal(int64(1)))
+				close(terminate)
+				wg.Wait()
+			})
+			It("releases the reservations of the prefetched tuples once terminated", func() {
+				rcc := &ReleasingCastorClient{}
+				ts.castorClient = rcc
+				ts.baseRequestID = uuid.MustParse("acc23dc8-7855-4a2f-bc89-494ba30a74d2")
+				ts.prefetch = true
+				wg.Add(1)
+				ts.StartStreamTuples(terminate, errCh, wg)
+				Eventually(rcc.Requested).Should(HaveLen(1))
+				close(terminate)
+				wg.Wait()
+				Expect(rcc.Released()).To(Equal(rcc.Requested()))
+				Expect(ts.Stats().ReleasedReservations).To(Equal(int64(1)))
+			})
+		})
 		Context("when streamData is empty", func() {
 			Context("when castor client returns an error", func() {
 				BeforeEach(func() {
@@ -193,9 +247,78 @@ var _ = Describe("Tuple Streamer", func() {
 						Expect(ts.streamedBytes).To(Equal(len(initialStreamData) - fpcpw.writeLess))
 						Expect(ts.streamData).To(Equal(initialStreamData[ts.streamedBytes:]))
 					})
+					It("records the statistics", func() {
+						wg.Add(1)
+						ts.StartStreamTuples(terminate, errCh, wg)
+						wg.Wait()
+						close(terminate)
+						close(errCh)
+						stats := ts.Stats()
+						Expect(stats.Streamers).To(Equal(int64(1)))
+						Expect(stats.Fetches).To(BeNumerically(">=", 1))
+						Expect(stats.Tuples).To(Equal(stats.Fetches))
+						Expect(stats.FetchedBytes).To(Equal(stats.Fetches * int64(len(initialStreamData))))
+						Expect(stats.WrittenBytes).To(Equal(int64(len(initialStreamData) - fpcpw.writeLess)))
+						Expect(stats.DiscardedBytes).To(BeNumerically(">=", fpcpw.writeLess))
+					})
 				})
 			})
 		})
+		Context("when the tuple stock is adapted", func() {
+			It("requests the chunks of a batch concurrently and assembles them in order", func() {
+				rcc := &RecordingCastorClient{values: map[uuid.UUID]string{}}
+				for i := 0; i < 3; i++ {
+					rcc.values[uuid.NewMD5(ts.baseRequestID, []byte(strconv.Itoa(i)))] = strconv.Itoa(i)
+				}
+				ts.castorClient = rcc
+				ts.adaptive = newStockController(&AdaptiveStockConfig{ChunkSize: 10, MaxChunks: 4, MaxConcurrentFetches: 2}, tupleStock)
+				ts.adaptive.chunks = 3
+				batch, err := ts.getBatch()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(batch).To(HaveLen(3))
+				for i, chunk := range batch {
+					Expect(chunk.requestID).To(Equal(uuid.NewMD5(ts.baseRequestID, []byte(strconv.Itoa(i)))))
+					Expect(string(chunk.data)).To(Equal(strconv.Itoa(i)))
+				}
+				Expect(rcc.counts).To(Equal([]int32{10, 10, 10}))
+				Expect(ts.requestCycle).To(Equal(3))
+				Expect(ts.Stats().Fetches).To(Equal(int64(3)))
+			})
+		})
+		Context("when a tuple cache is configured", func() {
+			var (
+				dir       string
+				requestID uuid.UUID
+			)
+			BeforeEach(func() {
+				var err error
+				dir, err = ioutil.TempDir("", "tuple-cache")
+				Expect(err).NotTo(HaveOccurred())
+				ts.cache, err = castor.NewTupleCache(dir, time.Hour)
+				Expect(err).NotTo(HaveOccurred())
+				requestID = uuid.NewMD5(ts.baseRequestID, []byte(strconv.Itoa(0)))
+			})
+			AfterEach(func() {
+				os.RemoveAll(dir)
+			})
+			It("takes cached tuples instead of fetching them from castor", func() {
+				Expect(ts.cache.Put(ts.tupleType, requestID, []byte("cached"))).To(Succeed())
+				ts.castorClient = &BrokenDownloadCastorClient{}
+				chunk, err := ts.getTupleData(0, tupleStock)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(chunk.data)).To(Equal("cached"))
+				stats := ts.Stats()
+				Expect(stats.ReusedBytes).To(Equal(int64(len("cached"))))
+				Expect(stats.Fetches).To(BeZero())
+			})
+			It("caches the tuples never written to the pipe", func() {
+				cached := ts.spill(tupleBatch{{requestID: requestID, data: []byte("unused")}})
+				Expect(cached).To(Equal(len("unused")))
+				data, ok := ts.cache.Take(ts.tupleType, requestID)
+				Expect(ok).To(BeTrue())
+				Expect(string(data)).To(Equal("unused"))
+			})
+		})
 	})
 
 	Context("when creating a new instance of castor tuple streamer", func() {
@@ -228,6 +351,15 @@ var _ = Describe("Tuple Streamer", func() {
 			Expect(ts.headerData).To(Equal(expectedHeader))
 			Expect(ts.requestCycle).To(Equal(0))
 		})
+		It("names the tuple file after the party the player is mapped to", func() {
+			conf := &SPDZEngineTypedConfig{PlayerID: 0, PartyNumbers: []int32{1, 0}}
+			Expect(GetTupleFileName(castor.BitGfp, conf, 1)).To(Equal("Bits-p-P1-T1"))
+		})
+		It("names the files of edaBits after their bit length", func() {
+			conf := &SPDZEngineTypedConfig{PlayerID: 1, EdaBitLength: 64}
+			Expect(GetTupleFileName(castor.DaBitGfp, conf, 0)).To(Equal("daBits-p-P1-T0"))
+			Expect(GetTupleFileName(castor.EdaBitGfp, conf, 0)).To(Equal("edaBits-64-P1-T0"))
+		})
 		Context("when header cannot be generated", func() {
 			Context("when protocol is unsupported", func() {
 				It("return error", func() {
@@ -525,6 +657,24 @@ func (fbwpr *FakeBlockingWritePipeWriter) Close() error {
 	return nil
 }
 
+// FakeOpenablePipeWriter blocks opening the pipe until opened is closed, like a pipe MP-SPDZ has not opened yet.
+type FakeOpenablePipeWriter struct {
+	opened chan struct{}
+}
+
+func (fopw *FakeOpenablePipeWriter) Open() error {
+	<-fopw.opened
+	return nil
+}
+
+func (fopw *FakeOpenablePipeWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (fopw *FakeOpenablePipeWriter) Close() error {
+	return nil
+}
+
 type FakePartialConsumingFailSecondCallPipeWriter struct {
 	count     int
 	writeLess int
@@ -562,6 +712,71 @@ func (fcc *FakeCastorClient) GetTuples(int32, castor.TupleType, uuid.UUID) (*cas
 	return tl, nil
 }
 
+// RecordingCastorClient returns a tuple with the value registered for the request ID and records the requested counts.
+type RecordingCastorClient struct {
+	mux    sync.Mutex
+	values map[uuid.UUID]string
+	counts []int32
+}
+
+func (rcc *RecordingCastorClient) GetTuples(count int32, _ castor.TupleType, requestID uuid.UUID) (*castor.TupleList, error) {
+	rcc.mux.Lock()
+	defer rcc.mux.Unlock()
+	rcc.counts = append(rcc.counts, count)
+	share := castor.Share{Value: base64.StdEncoding.EncodeToString([]byte(rcc.values[requestID]))}
+	return &castor.TupleList{Tuples: []castor.Tuple{{Shares: []castor.Share{share}}}}, nil
+}
+
+// CountingCastorClient returns empty tuple lists and counts the requests.
+type CountingCastorClient struct {
+	calls int64
+}
+
+func (ccc *CountingCastorClient) GetTuples(int32, castor.TupleType, uuid.UUID) (*castor.TupleList, error) {
+	atomic.AddInt64(&ccc.calls, 1)
+	return &castor.TupleList{}, nil
+}
+
+// Calls returns the number of requests so far.
+func (ccc *CountingCastorClient) Calls() int64 {
+	return atomic.LoadInt64(&ccc.calls)
+}
+
+// ReleasingCastorClient returns empty tuple lists and records the requested and the released reservations.
+type ReleasingCastorClient struct {
+	mux       sync.Mutex
+	requested []uuid.UUID
+	released  []uuid.UUID
+}
+
+func (rcc *ReleasingCastorClient) GetTuples(_ int32, _ castor.TupleType, requestID uuid.UUID) (*castor.TupleList, error) {
+	rcc.mux.Lock()
+	defer rcc.mux.Unlock()
+	rcc.requested = append(rcc.requested, requestID)
+	return &castor.TupleList{}, nil
+}
+
+func (rcc *ReleasingCastorClient) ReleaseReservation(requestID uuid.UUID) error {
+	rcc.mux.Lock()
+	defer rcc.mux.Unlock()
+	rcc.released = append(rcc.released, requestID)
+	return nil
+}
+
+// Requested returns the reservations requested so far.
+func (rcc *ReleasingCastorClient) Requested() []uuid.UUID {
+	rcc.mux.Lock()
+	defer rcc.mux.Unlock()
+	return append([]uuid.UUID{}, rcc.requested...)
+}
+
+// Released returns the reservations released so far.
+func (rcc *ReleasingCastorClient) Released() []uuid.UUID {
+	rcc.mux.Lock()
+	defer rcc.mux.Unlock()
+	return append([]uuid.UUID{}, rcc.released...)
+}
+
 type BrokenDownloadCastorClient struct{}
 
 func (fcc *BrokenDownloadCastorClient) GetTuples(int32, castor.TupleType, uuid.UUID) (*castor.TupleList, error) {
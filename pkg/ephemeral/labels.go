@@ -0,0 +1,60 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"fmt"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+	"regexp"
+	"sort"
+)
+
+// Limits of the labels of an activation.
+const (
+	maxLabels      = 16
+	maxLabelLength = 63
+)
+
+var (
+	labelKeyPattern   = regexp.MustCompile(`^[a-z0-9]([a-z0-9_.-]*[a-z0-9])?$`)
+	labelValuePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]*$`)
+)
+
+// validateLabels checks the number of labels and their format. Keys must consist of lower case letters, digits, '-',
+// '_' and '.', starting and ending with a letter or digit. Values may additionally contain upper case letters. Neither
+// may be longer than 63 characters.
+func validateLabels(labels map[string]string) error {
+	if len(labels) > maxLabels {
+		return fmt.Errorf("at most %d labels are allowed", maxLabels)
+	}
+	for k, v := range labels {
+		if len(k) > maxLabelLength || !labelKeyPattern.MatchString(k) {
+			return fmt.Errorf("invalid label key %q", k)
+		}
+		if len(v) > maxLabelLength || !labelValuePattern.MatchString(v) {
+			return fmt.Errorf("invalid value of label %s", k)
+		}
+	}
+	return nil
+}
+
+// sortedLabelKeys returns the keys of the labels in lexical order.
+func sortedLabelKeys(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// labelFields returns the labels as key value pairs to be attached to log entries.
+func labelFields(labels map[string]string) []interface{} {
+	var fields []interface{}
+	for _, k := range sortedLabelKeys(labels) {
+		fields = append(fields, LabelPrefix+k, labels[k])
+	}
+	return fields
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Labels", func() {
+	Context("when validating labels", func() {
+		It("accepts well-formed labels", func() {
+			Expect(validateLabels(map[string]string{"tenant": "ACME", "cost-center.id": "42_a", "empty": ""})).To(Succeed())
+		})
+		It("rejects too many labels", func() {
+			labels := map[string]string{}
+			for i := 0; i <= maxLabels; i++ {
+				labels[fmt.Sprintf("key%d", i)] = "value"
+			}
+			Expect(validateLabels(labels)).To(MatchError("at most 16 labels are allowed"))
+		})
+		It("rejects malformed keys", func() {
+			Expect(validateLabels(map[string]string{"-tenant": "acme"})).To(MatchError("invalid label key \"-tenant\""))
+			Expect(validateLabels(map[string]string{strings.Repeat("a", 64): "acme"})).To(HaveOccurred())
+		})
+		It("rejects malformed values", func() {
+			Expect(validateLabels(map[string]string{"tenant": "a cme"})).To(MatchError("invalid value of label tenant"))
+		})
+	})
+	It("returns the labels as sorted log fields", func() {
+		fields := labelFields(map[string]string{"tenant": "acme", "job": "nightly"})
+		Expect(fields).To(Equal([]interface{}{"label.job", "nightly", "label.tenant", "acme"}))
+	})
+})
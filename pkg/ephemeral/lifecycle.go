@@ -0,0 +1,131 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// errDraining is returned when admitting a game while the service is draining.
+	errDraining = errors.New("the service is terminating and does not accept new games")
+	// errBusy is returned when admitting a game while the maximum number of games is in flight.
+	errBusy = errors.New("the service is busy with other games")
+)
+
+// NewLifecycle returns the lifecycle of a service that accepts new games.
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{changed: make(chan struct{})}
+}
+
+// Lifecycle keeps track of the games in flight, so that the termination of the pod can be postponed until they have
+// finished. Once draining, no new games are accepted.
+type Lifecycle struct {
+	mux      sync.Mutex
+	draining bool
+	active   int
+	// compiling is the number of programs being compiled.
+	compiling int
+	// changed is closed and replaced whenever the number of games in flight decreases.
+	changed chan struct{}
+}
+
+// Begin registers a new game. Returns false if the service is draining, i.e., the game must be rejected.
+func (l *Lifecycle) Begin() bool {
+	return l.Admit(0) == nil
+}
+
+// Admit registers a new game unless the service is draining or the given maximum number of games is in flight, in
+// which case the game must be rejected. The number of games is not limited if max is not positive.
+func (l *Lifecycle) Admit(max int) error {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if l.draining {
+		return errDraining
+	}
+	if max > 0 && l.active >= max {
+		return errBusy
+	}
+	l.active++
+	return nil
+}
+
+// Retain registers a game that has already begun and continues in the background, regardless of whether the service
+// is draining.
+func (l *Lifecycle) Retain() {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.active++
+}
+
+// End unregisters a game registered by Begin or Retain.
+func (l *Lifecycle) End() {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.active--
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// Drain stops accepting new games.
+func (l *Lifecycle) Drain() {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.draining = true
+}
+
+// Draining returns whether new games are rejected.
+func (l *Lifecycle) Draining() bool {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.draining
+}
+
+// Active returns the number of games in flight.
+func (l *Lifecycle) Active() int {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.active
+}
+
+// BeginCompilation registers a program being compiled.
+func (l *Lifecycle) BeginCompilation() {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.compiling++
+}
+
+// EndCompilation unregisters a program registered by BeginCompilation.
+func (l *Lifecycle) EndCompilation() {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.compiling--
+}
+
+// Compiling returns the number of programs being compiled.
+func (l *Lifecycle) Compiling() int {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.compiling
+}
+
+// Wait blocks until no game is in flight anymore. Returns the error of the context if it is done before.
+func (l *Lifecycle) Wait(ctx context.Context) error {
+	for {
+		l.mux.Lock()
+		active, changed := l.active, l.changed
+		l.mux.Unlock()
+		if active == 0 {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
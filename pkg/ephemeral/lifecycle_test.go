@@ -0,0 +1,72 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lifecycle", func() {
+	var l *Lifecycle
+
+	BeforeEach(func() {
+		l = NewLifecycle()
+	})
+
+	It("accepts games until draining", func() {
+		Expect(l.Begin()).To(BeTrue())
+		Expect(l.Active()).To(Equal(1))
+		l.Drain()
+		Expect(l.Draining()).To(BeTrue())
+		Expect(l.Begin()).To(BeFalse())
+		Expect(l.Active()).To(Equal(1))
+	})
+	It("admits games up to the given maximum", func() {
+		Expect(l.Admit(2)).To(Succeed())
+		Expect(l.Admit(2)).To(Succeed())
+		Expect(l.Admit(2)).To(MatchError(errBusy))
+		Expect(l.Active()).To(Equal(2))
+		l.End()
+		Expect(l.Admit(2)).To(Succeed())
+		l.Drain()
+		Expect(l.Admit(0)).To(MatchError(errDraining))
+	})
+	It("keeps track of the programs being compiled", func() {
+		l.BeginCompilation()
+		l.BeginCompilation()
+		l.EndCompilation()
+		Expect(l.Compiling()).To(Equal(1))
+	})
+	It("retains games continuing in the background while draining", func() {
+		l.Drain()
+		l.Retain()
+		Expect(l.Active()).To(Equal(1))
+	})
+	It("waits until the games in flight have finished", func() {
+		l.Begin()
+		l.Begin()
+		done := make(chan error, 1)
+		go func() {
+			done <- l.Wait(context.Background())
+		}()
+		l.End()
+		Consistently(done, 50*time.Millisecond).ShouldNot(Receive())
+		l.End()
+		Eventually(done).Should(Receive(BeNil()))
+	})
+	It("returns right away if no game is in flight", func() {
+		Expect(l.Wait(context.Background())).To(Succeed())
+	})
+	It("stops waiting once the context is done", func() {
+		l.Begin()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		Expect(l.Wait(ctx)).To(Equal(context.DeadlineExceeded))
+	})
+})
@@ -0,0 +1,78 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// newLimitedBody returns the body of the given request limited to the given number of bytes. The body is not limited
+// if the limit is not positive.
+func newLimitedBody(req *http.Request, limit int64) *limitedBody {
+	return &limitedBody{
+		body:      req.Body,
+		limit:     limit,
+		remaining: limit,
+		exceeded:  limit > 0 && req.ContentLength > limit,
+	}
+}
+
+// limitedBody reads a request body up to a maximum number of bytes. Reading fails once the body turns out to be
+// larger, so that abusive requests are rejected before they are buffered as a whole. Decoders may wrap the error of
+// the reader, hence whether the limit has been exceeded is reported by the body itself.
+type limitedBody struct {
+	body      io.Reader
+	limit     int64
+	remaining int64
+	exceeded  bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, b.err()
+	}
+	if b.limit <= 0 {
+		return b.body.Read(p)
+	}
+	if b.remaining <= 0 {
+		// Probe whether the body continues beyond the limit.
+		var probe [1]byte
+		n, err := io.ReadFull(b.body, probe[:])
+		if n > 0 {
+			b.exceeded = true
+			return 0, b.err()
+		}
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return 0, err
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+func (b *limitedBody) err() error {
+	return fmt.Errorf("the request body exceeds the maximum size of %d bytes", b.limit)
+}
+
+// writeRequestTooLarge responds with 413 if the given body exceeded its limit. Returns whether a response was written.
+func writeRequestTooLarge(writer http.ResponseWriter, logger *zap.SugaredLogger, body *limitedBody) bool {
+	if !body.exceeded {
+		return false
+	}
+	msg := body.err().Error()
+	writer.WriteHeader(http.StatusRequestEntityTooLarge)
+	writer.Write([]byte(msg))
+	logger.Error(msg)
+	return true
+}
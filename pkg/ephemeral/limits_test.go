@@ -0,0 +1,44 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Limited request body", func() {
+	request := func(body string) *http.Request {
+		req, _ := http.NewRequest("POST", "/", struct{ io.Reader }{strings.NewReader(body)})
+		return req
+	}
+	It("reads a body of the maximum size", func() {
+		body := newLimitedBody(request("abcd"), 4)
+		Expect(ioutil.ReadAll(body)).To(Equal([]byte("abcd")))
+		Expect(body.exceeded).To(BeFalse())
+	})
+	It("fails once the body exceeds the maximum size", func() {
+		body := newLimitedBody(request("abcde"), 4)
+		_, err := ioutil.ReadAll(body)
+		Expect(err).To(MatchError("the request body exceeds the maximum size of 4 bytes"))
+		Expect(body.exceeded).To(BeTrue())
+	})
+	It("fails right away if a larger body is announced", func() {
+		req, _ := http.NewRequest("POST", "/", strings.NewReader("abcde"))
+		body := newLimitedBody(req, 4)
+		Expect(body.exceeded).To(BeTrue())
+		_, err := body.Read(make([]byte, 1))
+		Expect(err).To(HaveOccurred())
+	})
+	It("does not limit the body if no maximum size is set", func() {
+		body := newLimitedBody(request("abcde"), 0)
+		Expect(ioutil.ReadAll(body)).To(Equal([]byte("abcde")))
+	})
+})
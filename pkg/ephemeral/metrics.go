@@ -0,0 +1,117 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/carbynestack/ephemeral/pkg/castor"
+	"github.com/carbynestack/ephemeral/pkg/ephemeral/network"
+)
+
+// MetricsPath is the path the metrics of the service are served on.
+const MetricsPath = "/metrics"
+
+// Metrics are the metrics of the service served on MetricsPath.
+type Metrics struct {
+	// TupleStreamers are the statistics of the tuple streamers of all games so far per tuple type.
+	TupleStreamers map[string]TupleStreamerStats `json:"tupleStreamers"`
+	// TupleCache are the statistics of the tuple cache. Nil if no tuple cache is configured.
+	TupleCache *TupleCacheStats `json:"tupleCache,omitempty"`
+	// Egress are the statistics of the rate limited traffic sent to the other players.
+	Egress EgressStats `json:"egress"`
+}
+
+// TupleStreamerStats are the statistics of the tuple streamers of a tuple type, see io.StreamerStats.
+type TupleStreamerStats struct {
+	Streamers        int64  `json:"streamers"`
+	UnusedStreamers  int64  `json:"unusedStreamers"`
+	Tuples           int64  `json:"tuples"`
+	FetchedBytes     int64  `json:"fetchedBytes"`
+	WrittenBytes     int64  `json:"writtenBytes"`
+	DiscardedBytes   int64  `json:"discardedBytes"`
+	CachedBytes      int64  `json:"cachedBytes"`
+	ReusedBytes      int64  `json:"reusedBytes"`
+	Fetches          int64  `json:"fetches"`
+	MeanFetchLatency string `json:"meanFetchLatency"`
+	MaxFetchLatency  string `json:"maxFetchLatency"`
+	Stalls           int64  `json:"stalls"`
+	StallTime        string `json:"stallTime"`
+}
+
+// TupleCacheStats are the statistics of the tuple cache, see castor.TupleCacheStats.
+type TupleCacheStats struct {
+	CachedBytes  int64 `json:"cachedBytes"`
+	ReusedBytes  int64 `json:"reusedBytes"`
+	ExpiredBytes int64 `json:"expiredBytes"`
+}
+
+// EgressStats are the statistics of the rate limited traffic sent to the other players, see network.EgressStats.
+type EgressStats struct {
+	Bytes         int64  `json:"bytes"`
+	Throttles     int64  `json:"throttles"`
+	ThrottledTime string `json:"throttledTime"`
+}
+
+// newMetrics returns the current metrics of the service, including the statistics of the given tuple cache, if any.
+func newMetrics(cache *castor.TupleCache) *Metrics {
+	m := &Metrics{TupleStreamers: map[string]TupleStreamerStats{}}
+	for tt, s := range TupleStreamerMetrics.Snapshot() {
+		m.TupleStreamers[tt] = TupleStreamerStats{
+			Streamers:        s.Streamers,
+			UnusedStreamers:  s.UnusedStreamers,
+			Tuples:           s.Tuples,
+			FetchedBytes:     s.FetchedBytes,
+			WrittenBytes:     s.WrittenBytes,
+			DiscardedBytes:   s.DiscardedBytes,
+			CachedBytes:      s.CachedBytes,
+			ReusedBytes:      s.ReusedBytes,
+			Fetches:          s.Fetches,
+			MeanFetchLatency: s.MeanFetchLatency().String(),
+			MaxFetchLatency:  s.MaxFetchLatency.String(),
+			Stalls:           s.Stalls,
+			StallTime:        s.StallTime.String(),
+		}
+	}
+	egress := network.EgressMetrics.Snapshot()
+	m.Egress = EgressStats{
+		Bytes:         egress.Bytes,
+		Throttles:     egress.Throttles,
+		ThrottledTime: egress.ThrottledTime.String(),
+	}
+	if cache != nil {
+		s := cache.Stats()
+		m.TupleCache = &TupleCacheStats{
+			CachedBytes:  s.CachedBytes,
+			ReusedBytes:  s.ReusedBytes,
+			ExpiredBytes: s.ExpiredBytes,
+		}
+	}
+	return m
+}
+
+// MetricsHandler serves the metrics of the service as JSON.
+func (s *Server) MetricsHandler(writer http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		msg := "GET requests must be used to retrieve the metrics"
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return
+	}
+	body, err := json.Marshal(newMetrics(s.config.TupleCache))
+	if err != nil {
+		msg := fmt.Sprintf("error encoding the metrics: %s", err)
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return
+	}
+	writer.Header().Set("Content-Type", ContentTypeJSON)
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(body)
+}
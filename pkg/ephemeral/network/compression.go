@@ -0,0 +1,89 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package network
+
+import (
+	"compress/flate"
+	"context"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/google/tcpproxy"
+)
+
+// newCompressedConn returns a connection compressing the data written to and decompressing the data read from the
+// given connection to another player's proxy. Each write is flushed, so that the other player receives the data right
+// away.
+func newCompressedConn(conn net.Conn, level int) (*compressedConn, error) {
+	w, err := flate.NewWriter(conn, level)
+	if err != nil {
+		return nil, err
+	}
+	return &compressedConn{Conn: conn, w: w}, nil
+}
+
+// compressedConn compresses the traffic of a connection between the proxies of two players with DEFLATE.
+type compressedConn struct {
+	net.Conn
+	w *flate.Writer
+	// r is created on the first read.
+	r        io.ReadCloser
+	readOnce sync.Once
+	writeMux sync.Mutex
+}
+
+// Read reads and decompresses data from the underlying connection.
+func (c *compressedConn) Read(b []byte) (int, error) {
+	c.readOnce.Do(func() {
+		c.r = flate.NewReader(c.Conn)
+	})
+	return c.r.Read(b)
+}
+
+// Write compresses the data, writes it to the underlying connection and flushes it.
+func (c *compressedConn) Write(b []byte) (int, error) {
+	c.writeMux.Lock()
+	defer c.writeMux.Unlock()
+	n, err := c.w.Write(b)
+	if err != nil {
+		return n, err
+	}
+	return n, c.w.Flush()
+}
+
+// compressingDialer returns a dialer whose connections to the proxies of the other players are compressed. The
+// connections are established by the given dialer.
+func compressingDialer(level int, dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		compressed, err := newCompressedConn(conn, level)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return compressed, nil
+	}
+}
+
+// DecompressingTarget receives the compressed traffic of the other players and forwards it to MP-SPDZ.
+type DecompressingTarget struct {
+	Next  tcpproxy.Target
+	Level int
+}
+
+// HandleConn decompresses the data read from the connection of another player before it is passed to the next
+// target, and compresses the data written back.
+func (t *DecompressingTarget) HandleConn(conn net.Conn) {
+	compressed, err := newCompressedConn(conn, t.Level)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	t.Next.HandleConn(compressed)
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package network
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"net"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Proxy compression", func() {
+	Context("when sending data through compressed connections", func() {
+		It("transfers the data in both directions", func() {
+			local, remote := net.Pipe()
+			sender, err := newCompressedConn(local, flate.BestSpeed)
+			Expect(err).NotTo(HaveOccurred())
+			defer sender.Close()
+			receiver, err := newCompressedConn(remote, flate.BestSpeed)
+			Expect(err).NotTo(HaveOccurred())
+			defer receiver.Close()
+
+			data := bytes.Repeat([]byte("shares"), 1000)
+			go sender.Write(data)
+			received := make([]byte, len(data))
+			_, err = io.ReadFull(receiver, received)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(received).To(Equal(data))
+
+			go receiver.Write([]byte("ack"))
+			ack := make([]byte, 3)
+			_, err = io.ReadFull(sender, ack)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(ack)).To(Equal("ack"))
+		})
+		It("sends less data for compressible traffic", func() {
+			local, remote := net.Pipe()
+			defer remote.Close()
+			var sent int
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				buf := make([]byte, 1024)
+				for {
+					n, err := remote.Read(buf)
+					sent += n
+					if err != nil {
+						return
+					}
+				}
+			}()
+			conn, err := newCompressedConn(local, flate.BestSpeed)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = conn.Write(make([]byte, 10000))
+			Expect(err).NotTo(HaveOccurred())
+			conn.Close()
+			<-done
+			Expect(sent).To(BeNumerically("<", 1000))
+		})
+	})
+})
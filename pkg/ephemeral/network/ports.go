@@ -0,0 +1,96 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// NewPortSetAllocator returns a new allocator handing out disjoint sets of setSize consecutive ports from the given
+// range. The range must be given in the form start_port:end_port, e.g. 20000:20999.
+func NewPortSetAllocator(rng string, setSize int32) (*PortSetAllocator, error) {
+	ports := strings.Split(rng, ":")
+	if len(ports) != 2 {
+		return nil, errors.New("the range must contain a port range in the form start_port:end_port, e.g. 20000:20999")
+	}
+	start, err := strconv.Atoi(ports[0])
+	if err != nil {
+		return nil, err
+	}
+	end, err := strconv.Atoi(ports[1])
+	if err != nil {
+		return nil, err
+	}
+	if setSize < 1 || int32(end-start+1) < setSize {
+		return nil, fmt.Errorf("port range %s is too small for sets of %d ports", rng, setSize)
+	}
+	return &PortSetAllocator{
+		start:   int32(start),
+		end:     int32(end),
+		setSize: setSize,
+		used:    map[string]int32{},
+	}, nil
+}
+
+// PortSetAllocator assigns each game a set of local ports, so that proxies of concurrent games do not conflict.
+type PortSetAllocator struct {
+	start, end, setSize int32
+	used                map[string]int32
+	mux                 sync.Mutex
+}
+
+// Acquire returns the first port of the set assigned to the given game. Subsequent calls for the same game return the
+// same set until it is released.
+func (a *PortSetAllocator) Acquire(gameID string) (int32, error) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	if base, ok := a.used[gameID]; ok {
+		return base, nil
+	}
+	taken := map[int32]bool{}
+	for _, base := range a.used {
+		taken[base] = true
+	}
+	for base := a.start; base+a.setSize-1 <= a.end; base += a.setSize {
+		if !taken[base] {
+			a.used[gameID] = base
+			return base, nil
+		}
+	}
+	return 0, errors.New("no free proxy port sets")
+}
+
+// Release returns the port set of the given game to the pool.
+func (a *PortSetAllocator) Release(gameID string) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	delete(a.used, gameID)
+}
+
+// ReusePortListen opens a TCP listener with SO_REUSEPORT set, which allows several proxies to bind the same address.
+func ReusePortListen(network, address string) (net.Listener, error) {
+	lc := net.ListenConfig{
+		Control: func(network, address string, c syscall.RawConn) error {
+			var err error
+			ctrlErr := c.Control(func(fd uintptr) {
+				err = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+			})
+			if ctrlErr != nil {
+				return ctrlErr
+			}
+			return err
+		},
+	}
+	return lc.Listen(context.Background(), network, address)
+}
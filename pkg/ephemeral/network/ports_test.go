@@ -0,0 +1,72 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package network
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PortSetAllocator", func() {
+	const (
+		game1 = "71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4"
+		game2 = "81b2a100-f3f6-11e9-81b4-2a2ae2dbcce4"
+	)
+	Context("when creating the allocator", func() {
+		It("returns an error for a malformed range", func() {
+			_, err := NewPortSetAllocator("20000", 2)
+			Expect(err).To(HaveOccurred())
+		})
+		It("returns an error if the range cannot hold a single set", func() {
+			_, err := NewPortSetAllocator("20000:20000", 2)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("when acquiring port sets", func() {
+		var a *PortSetAllocator
+		BeforeEach(func() {
+			var err error
+			a, err = NewPortSetAllocator("20000:20003", 2)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("assigns disjoint sets to concurrent games", func() {
+			base1, err := a.Acquire(game1)
+			Expect(err).NotTo(HaveOccurred())
+			base2, err := a.Acquire(game2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(base1).To(Equal(int32(20000)))
+			Expect(base2).To(Equal(int32(20002)))
+		})
+		It("returns the same set for the same game", func() {
+			base1, _ := a.Acquire(game1)
+			base2, _ := a.Acquire(game1)
+			Expect(base2).To(Equal(base1))
+		})
+		It("returns an error if all sets are in use", func() {
+			_, _ = a.Acquire(game1)
+			_, _ = a.Acquire(game2)
+			_, err := a.Acquire("other")
+			Expect(err).To(HaveOccurred())
+		})
+		It("reuses released sets", func() {
+			base1, _ := a.Acquire(game1)
+			_, _ = a.Acquire(game2)
+			a.Release(game1)
+			base3, err := a.Acquire("other")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(base3).To(Equal(base1))
+		})
+	})
+	Context("when listening with SO_REUSEPORT", func() {
+		It("allows two listeners on the same address", func() {
+			l1, err := ReusePortListen("tcp", "localhost:20100")
+			Expect(err).NotTo(HaveOccurred())
+			defer l1.Close()
+			l2, err := ReusePortListen("tcp", "localhost:20100")
+			Expect(err).NotTo(HaveOccurred())
+			defer l2.Close()
+		})
+	})
+})
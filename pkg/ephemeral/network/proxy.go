@@ -5,6 +5,7 @@
 package network
 
 import (
+	"compress/flate"
 	"context"
 	"errors"
 	"fmt"
@@ -12,6 +13,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/carbynestack/ephemeral/pkg/retry"
 	. "github.com/carbynestack/ephemeral/pkg/types"
 
 	"github.com/google/tcpproxy"
@@ -35,9 +37,22 @@ func NewProxy(lg *zap.SugaredLogger, conf *SPDZEngineTypedConfig, checker Networ
 		retrySleep:   conf.RetrySleep,
 		retryTimeout: conf.NetworkEstablishTimeout,
 		tcpChecker:   checker,
+		reusePort:    conf.ProxyReusePort,
+		egressLimit:  conf.EgressLimit,
+		compression:  conf.ProxyCompression,
+		tls:          newTLSConnector(conf),
 	}
 }
 
+// newTLSConnector returns the connector securing the connections to the other players if TLS is enabled. Returns nil
+// otherwise.
+func newTLSConnector(conf *SPDZEngineTypedConfig) *TLSConnector {
+	if !conf.TlsEnabled || conf.PlayerCerts == nil {
+		return nil
+	}
+	return NewTLSConnector(ProvisionedBundle(conf.PlayerCerts))
+}
+
 // Proxy is a wrapper around the tcpproxy and ping aware proxy.
 // It establishes the connection between MPC master and slave.
 type Proxy struct {
@@ -47,6 +62,14 @@ type Proxy struct {
 	proxy        *tcpproxy.Proxy
 	ctx          *CtxConfig
 	tcpChecker   NetworkChecker
+	// reusePort defines whether the listeners are opened with SO_REUSEPORT.
+	reusePort bool
+	// egressLimit limits the rate data is sent to each of the other players at. Nil if not limited.
+	egressLimit *EgressLimitConfig
+	// compression defines the compression of the traffic of the games negotiating it. Nil if not supported.
+	compression *ProxyCompressionConfig
+	// tls secures the connections to the gateways of the other players. Nil if they are plaintext.
+	tls *TLSConnector
 	// activeProxyIndicatorCh indicates that proxy was successfully started (see [tcpproxy.Proxy.Start]) if the channel
 	// is closed.
 	activeProxyIndicatorCh chan struct{}
@@ -55,6 +78,9 @@ type Proxy struct {
 // Run start the tcpproxy, makes sure it has started by means of a ping.
 func (p *Proxy) Run(ctx *CtxConfig, errCh chan error) error {
 	p.proxy = &tcpproxy.Proxy{}
+	if p.reusePort {
+		p.proxy.ListenFunc = ReusePortListen
+	}
 	p.ctx = ctx
 
 	var pats []*PingAwareTarget
@@ -62,6 +88,9 @@ func (p *Proxy) Run(ctx *CtxConfig, errCh chan error) error {
 		pat := p.addProxyEntry(proxyEntry)
 		pats = append(pats, pat)
 	}
+	if ctx.InboundProxy != nil {
+		p.addInboundProxy(ctx.InboundProxy)
+	}
 
 	err := p.checkConnectionToPeers()
 	if err != nil {
@@ -134,6 +163,22 @@ func (p *Proxy) addProxyEntry(config *ProxyConfig) *PingAwareTarget {
 	address := config.Host + ":" + config.Port
 	p.logger.Infow(fmt.Sprintf("Adding TCP Proxy Entry for 'localhost:%s' -> '%s'", config.LocalPort, address), GameID, p.ctx.Act.GameID)
 	dialProxy := tcpproxy.DialProxy{Addr: address, DialTimeout: timeout}
+	dial := (&net.Dialer{Timeout: timeout}).DialContext
+	if p.egressLimit != nil {
+		// All connections to the player share a single bucket, so that the limit applies per player and game.
+		bucket := newTokenBucket(p.egressLimit.BytesPerSecond, p.egressLimit.Burst)
+		dial = rateLimitedDialer(bucket, dial)
+		dialProxy.DialContext = dial
+	}
+	if p.tls != nil && config.ServerName != "" {
+		// The egress limit applies to the encrypted data.
+		dial = p.tls.Dialer(config.ServerName, dial)
+		dialProxy.DialContext = dial
+	}
+	if p.ctx.Compression {
+		// The data is compressed before the egress limit applies. Compression is never combined with TLS.
+		dialProxy.DialContext = compressingDialer(p.compressionLevel(), dial)
+	}
 	pat := &PingAwareTarget{
 		Next:   &dialProxy,
 		Logger: p.logger,
@@ -142,6 +187,25 @@ func (p *Proxy) addProxyEntry(config *ProxyConfig) *PingAwareTarget {
 	return pat
 }
 
+// addInboundProxy decompresses the traffic of the other players received on the local port and forwards it to
+// MP-SPDZ.
+func (p *Proxy) addInboundProxy(config *ProxyConfig) {
+	address := config.Host + ":" + config.Port
+	p.logger.Infow(fmt.Sprintf("Adding inbound TCP Proxy Entry for ':%s' -> '%s'", config.LocalPort, address), GameID, p.ctx.Act.GameID)
+	p.proxy.AddRoute(":"+config.LocalPort, &DecompressingTarget{
+		Next:  &tcpproxy.DialProxy{Addr: address, DialTimeout: timeout},
+		Level: p.compressionLevel(),
+	})
+}
+
+// compressionLevel returns the configured compression level, or the fastest level if not configured.
+func (p *Proxy) compressionLevel() int {
+	if p.compression == nil || p.compression.Level == 0 {
+		return flate.BestSpeed
+	}
+	return p.compression.Level
+}
+
 func (p *Proxy) checkTCPConnectionToPeer(ctx context.Context, config *ProxyConfig) error {
 	p.logger.Info(fmt.Sprintf("Checking if connection to peer works for config: %s", config))
 	err := p.tcpChecker.Verify(ctx, config.Host, config.Port)
@@ -167,21 +231,19 @@ func (p *Proxy) Stop() {
 // RetryingDialer tries to establish a TCP connection to a socket until the timeout is reached.
 func RetryingDialer(sleep, timeout time.Duration, sideEffect func()) func(addr, port string) (conn net.Conn, err error) {
 	return func(addr, port string) (conn net.Conn, err error) {
-		started := time.Now()
-		for {
-			var tcpAddr *net.TCPAddr
-			tcpAddr, err = net.ResolveTCPAddr("tcp", addr+":"+port)
-			if err != nil {
-				return nil, err
-			}
-			conn, err = net.DialTCP("tcp", nil, tcpAddr)
-			if err != nil && time.Now().Sub(started) < timeout {
-				sideEffect()
-				time.Sleep(sleep)
-				continue
-			}
-			break
+		conf := retry.Config{
+			Policy:  retry.Constant{Interval: sleep},
+			Timeout: timeout,
+			Hooks: retry.Hooks{
+				OnRetry: func(int, time.Duration, error) {
+					sideEffect()
+				},
+			},
 		}
+		err = retry.Do(context.Background(), conf, func() error {
+			conn, err = dialTCP(addr, port)
+			return err
+		})
 		return conn, err
 	}
 }
@@ -197,35 +259,52 @@ func RetryingDialerWithContext(sleep time.Duration, timeout time.Duration, l *za
 func RetryingDialerWithContextAndLogTimeout(sleep time.Duration, timeout time.Duration, l *zap.SugaredLogger, logPeriod time.Duration) func(ctx context.Context, addr, port string) (conn net.Conn, err error) {
 	return func(ctx context.Context, addr, port string) (conn net.Conn, err error) {
 		started := time.Now()
-		logTicker := time.NewTicker(logPeriod)
-		connectTimer := time.NewTimer(0)
-		defer logTicker.Stop()
-		defer connectTimer.Stop()
-		for {
-			select {
-			case <-ctx.Done():
-				return conn, errors.New(fmt.Sprintf("cancelled connection attempt for %s:%s - context done", addr, port))
-			case <-logTicker.C:
-				l.Debugf("Connection attempt to %s:%s active for %s", addr, port, time.Now().Sub(started))
-			case <-connectTimer.C:
-				var tcpAddr *net.TCPAddr
-				tcpAddr, err = net.ResolveTCPAddr("tcp", addr+":"+port)
-				if err != nil {
-					return nil, err
-				}
-				conn, err = net.DialTCP("tcp", nil, tcpAddr)
-				if err != nil && time.Now().Sub(started) < timeout {
-					connectTimer.Reset(sleep)
-					continue
-				}
-				if conn.(*net.TCPConn) != nil {
-					if err := conn.(*net.TCPConn).SetKeepAlive(true); err != nil {
-						return nil, err
-					}
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			logTicker := time.NewTicker(logPeriod)
+			defer logTicker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-logTicker.C:
+					l.Debugf("Connection attempt to %s:%s active for %s", addr, port, time.Now().Sub(started))
 				}
-				l.Debugw("Dialer done", "Conn", conn, "Err", err)
-				return conn, err
 			}
+		}()
+		conf := retry.Config{
+			Policy:  retry.Constant{Interval: sleep},
+			Timeout: timeout,
 		}
+		err = retry.Do(ctx, conf, func() error {
+			conn, err = dialTCP(addr, port)
+			return err
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, errors.New(fmt.Sprintf("cancelled connection attempt for %s:%s - context done", addr, port))
+			}
+			l.Debugw("Dialer done", "Conn", conn, "Err", err)
+			return nil, err
+		}
+		if err := conn.(*net.TCPConn).SetKeepAlive(true); err != nil {
+			return nil, err
+		}
+		l.Debugw("Dialer done", "Conn", conn, "Err", err)
+		return conn, nil
+	}
+}
+
+// dialTCP establishes a TCP connection to the socket. Errors resolving the address are not retried.
+func dialTCP(addr, port string) (net.Conn, error) {
+	tcpAddr, err := net.ResolveTCPAddr("tcp", addr+":"+port)
+	if err != nil {
+		return nil, retry.Permanent(err)
+	}
+	conn, err := net.DialTCP("tcp", nil, tcpAddr)
+	if err != nil {
+		return nil, err
 	}
+	return conn, nil
 }
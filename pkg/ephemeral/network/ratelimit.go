@@ -0,0 +1,123 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package network
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// EgressMetrics are the statistics of the traffic sent to the other players by the rate limited proxies of all games.
+var EgressMetrics = &EgressStatsCollector{}
+
+// EgressStats are the statistics of the traffic sent to the other players.
+type EgressStats struct {
+	// Bytes is the amount of data sent.
+	Bytes int64
+	// Throttles is the number of writes delayed as the rate limit was exceeded.
+	Throttles int64
+	// ThrottledTime is the time the writes were delayed for.
+	ThrottledTime time.Duration
+}
+
+// EgressStatsCollector aggregates egress statistics. It is safe for concurrent use.
+type EgressStatsCollector struct {
+	mux   sync.Mutex
+	stats EgressStats
+}
+
+// Snapshot returns the current statistics.
+func (c *EgressStatsCollector) Snapshot() EgressStats {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.stats
+}
+
+func (c *EgressStatsCollector) sent(bytes int, throttled time.Duration) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.stats.Bytes += int64(bytes)
+	if throttled > 0 {
+		c.stats.Throttles++
+		c.stats.ThrottledTime += throttled
+	}
+}
+
+// newTokenBucket returns a bucket refilled with the given number of bytes per second and holding at most burst bytes.
+// The bucket starts full.
+func newTokenBucket(bytesPerSecond, burst int64) *tokenBucket {
+	return &tokenBucket{
+		rate:   float64(bytesPerSecond),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// tokenBucket limits the rate data is sent at. It is safe for concurrent use.
+type tokenBucket struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mux    sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes the given number of bytes from the bucket and returns the time to wait before sending them. The
+// bucket goes into debt for writes exceeding the available bytes, so that subsequent writes wait accordingly.
+func (b *tokenBucket) reserve(bytes int) time.Duration {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+	b.tokens -= float64(bytes)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// rateLimitedConn delays the writes to the underlying connection to stay within the rate of its token bucket.
+type rateLimitedConn struct {
+	net.Conn
+	bucket  *tokenBucket
+	metrics *EgressStatsCollector
+	sleep   func(time.Duration)
+}
+
+// Write writes the data once the token bucket allows for it.
+func (c *rateLimitedConn) Write(b []byte) (int, error) {
+	wait := c.bucket.reserve(len(b))
+	if wait > 0 {
+		c.sleep(wait)
+	}
+	n, err := c.Conn.Write(b)
+	c.metrics.sent(n, wait)
+	return n, err
+}
+
+// dialFunc establishes a connection to another player.
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// rateLimitedDialer returns a dialer whose connections, established by the given dialer, share the given token bucket.
+func rateLimitedDialer(bucket *tokenBucket, dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		return &rateLimitedConn{Conn: conn, bucket: bucket, metrics: EgressMetrics, sleep: time.Sleep}, nil
+	}
+}
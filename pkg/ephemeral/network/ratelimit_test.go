@@ -0,0 +1,67 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package network
+
+import (
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Egress rate limiting", func() {
+	var (
+		now    time.Time
+		bucket *tokenBucket
+	)
+	BeforeEach(func() {
+		now = time.Unix(0, 0)
+		bucket = newTokenBucket(1000, 500)
+		bucket.now = func() time.Time { return now }
+	})
+	Context("when reserving bytes", func() {
+		It("lets a burst pass right away", func() {
+			Expect(bucket.reserve(500)).To(BeZero())
+		})
+		It("delays writes exceeding the available bytes", func() {
+			Expect(bucket.reserve(500)).To(BeZero())
+			Expect(bucket.reserve(250)).To(Equal(250 * time.Millisecond))
+			Expect(bucket.reserve(250)).To(Equal(500 * time.Millisecond))
+		})
+		It("refills the bucket over time up to the burst", func() {
+			Expect(bucket.reserve(500)).To(BeZero())
+			now = now.Add(time.Hour)
+			Expect(bucket.reserve(500)).To(BeZero())
+			Expect(bucket.reserve(100)).To(Equal(100 * time.Millisecond))
+		})
+	})
+	Context("when writing to a rate limited connection", func() {
+		It("sleeps before writing and records the throttling", func() {
+			local, remote := net.Pipe()
+			defer remote.Close()
+			go func() {
+				buf := make([]byte, 1000)
+				for {
+					if _, err := remote.Read(buf); err != nil {
+						return
+					}
+				}
+			}()
+			metrics := &EgressStatsCollector{}
+			var slept time.Duration
+			conn := &rateLimitedConn{Conn: local, bucket: bucket, metrics: metrics, sleep: func(d time.Duration) {
+				slept += d
+			}}
+			defer conn.Close()
+			_, err := conn.Write(make([]byte, 500))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = conn.Write(make([]byte, 100))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(slept).To(Equal(100 * time.Millisecond))
+			Expect(metrics.Snapshot()).To(Equal(EgressStats{Bytes: 600, Throttles: 1, ThrottledTime: 100 * time.Millisecond}))
+		})
+	})
+})
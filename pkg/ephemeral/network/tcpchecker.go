@@ -6,11 +6,14 @@ package network
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"time"
 
+	"github.com/carbynestack/ephemeral/pkg/retry"
+
 	"go.uber.org/zap"
 )
 
@@ -50,19 +53,29 @@ type TCPChecker struct {
 
 // Verify checks network connectivity between the players and communicates its results to discovery and players FSM.
 func (t *TCPChecker) Verify(ctx context.Context, host, port string) error {
-	done := time.After(t.conf.RetryTimeout)
-	for {
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("TCPCheck for '%s:%s' aborted after %d attempts", host, port, t.retries)
-		case <-done:
-			return fmt.Errorf("TCPCheck for '%s:%s' failed after %s and %d attempts", host, port, t.conf.RetryTimeout.String(), t.retries)
-		default:
-			if t.tryToConnect(host, port) {
-				return nil
-			}
-			t.sleepAndIncrement()
+	conf := retry.Config{
+		Policy:  retry.Constant{Interval: t.conf.DialTimeout},
+		Timeout: t.conf.RetryTimeout,
+		Hooks: retry.Hooks{
+			OnRetry: func(int, time.Duration, error) {
+				t.retries++
+				t.conf.Logger.Debugf("Retrying TCPCheck after %s", t.conf.DialTimeout)
+			},
+		},
+	}
+	err := retry.Do(ctx, conf, func() error {
+		if t.tryToConnect(host, port) {
+			return nil
 		}
+		return errors.New("player not reachable")
+	})
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() != nil:
+		return fmt.Errorf("TCPCheck for '%s:%s' aborted after %d attempts", host, port, t.retries)
+	default:
+		return fmt.Errorf("TCPCheck for '%s:%s' failed after %s and %d attempts", host, port, t.conf.RetryTimeout.String(), t.retries)
 	}
 }
 
@@ -111,10 +124,3 @@ func (t *TCPChecker) tryToConnect(host, port string) bool {
 	}
 	return true
 }
-
-// sleepAndIncrement sleeps for the period of DialTimeout, increments the number of retries and prints out a log entry.
-func (t *TCPChecker) sleepAndIncrement() {
-	t.retries++
-	time.Sleep(t.conf.DialTimeout)
-	t.conf.Logger.Debugf("Retrying TCPCheck after %s", t.conf.DialTimeout)
-}
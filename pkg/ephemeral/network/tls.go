@@ -0,0 +1,94 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/certs"
+)
+
+// BundleFunc returns the current certificate of the player, the CA included.
+type BundleFunc func() (*certs.Bundle, error)
+
+// ProvisionedBundle returns the certificate kept by the given provisioner, so that the connections pick up rotated
+// certificates.
+func ProvisionedBundle(p *certs.Provisioner) BundleFunc {
+	return func() (*certs.Bundle, error) {
+		b := p.Current()
+		if b == nil {
+			return nil, errors.New("the player certificates have not been provisioned yet")
+		}
+		return b, nil
+	}
+}
+
+// NewTLSConnector returns a connector securing the connections to the other players with the certificates returned by
+// the given function.
+func NewTLSConnector(bundle BundleFunc) *TLSConnector {
+	return &TLSConnector{bundle: bundle}
+}
+
+// TLSConnector wraps the connections of the proxy to the gateways of the other players into mutual TLS. The player
+// authenticates with its own certificate, and the gateway must present a certificate for the server name of the other
+// player issued by the CA of the player, i.e., the CA is pinned and the public roots are not trusted. The certificates
+// are read on each connection, hence a rotation affects the connections established afterwards only.
+type TLSConnector struct {
+	bundle BundleFunc
+}
+
+// Config returns the TLS configuration of a connection to the player with the given server name, e.g., "P1".
+func (c *TLSConnector) Config(serverName string) (*tls.Config, error) {
+	b, err := c.bundle()
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.X509KeyPair(b.Cert, b.Key)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(b.CA) {
+		return nil, errors.New("the CA of the player certificates contains no certificate")
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      roots,
+		ServerName:   serverName,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// Dialer returns a dialer whose connections to the player with the given server name are secured with TLS. The
+// connections are established by the given dialer, and the handshake completes before the connection is returned.
+func (c *TLSConnector) Dialer(serverName string, dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conf, err := c.Config(serverName)
+		if err != nil {
+			return nil, err
+		}
+		conn, err := dial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		tlsConn := tls.Client(conn, conf)
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		} else {
+			conn.SetDeadline(time.Now().Add(timeout))
+		}
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn.SetDeadline(time.Time{})
+		return tlsConn, nil
+	}
+}
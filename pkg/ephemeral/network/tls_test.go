@@ -0,0 +1,140 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package network
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/certs"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TLSConnector", func() {
+	var (
+		dir      string
+		client   *certs.Bundle
+		server   *certs.Bundle
+		listener net.Listener
+	)
+	// issue issues a certificate for the given party from the CA in the given directory.
+	issue := func(dir string, party int32) *certs.Bundle {
+		src, err := certs.NewCASource(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"), certs.PlayerName(party), time.Hour, time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+		b, err := src.Load(time.Now())
+		Expect(err).NotTo(HaveOccurred())
+		return b
+	}
+	// serve accepts a connection requiring mutual TLS with the given certificate and echoes the data sent.
+	serve := func(b *certs.Bundle) {
+		cert, err := tls.X509KeyPair(b.Cert, b.Key)
+		Expect(err).NotTo(HaveOccurred())
+		roots := x509.NewCertPool()
+		Expect(roots.AppendCertsFromPEM(b.CA)).To(BeTrue())
+		listener, err = tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientCAs:    roots,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		go func() {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			buf := make([]byte, 6)
+			if _, err := conn.Read(buf); err == nil {
+				conn.Write(buf)
+			}
+		}()
+	}
+	dial := func(connector *TLSConnector, serverName string) (net.Conn, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return connector.Dialer(serverName, (&net.Dialer{}).DialContext)(ctx, "tcp", listener.Addr().String())
+	}
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "tls")
+		Expect(err).NotTo(HaveOccurred())
+		writeTestCA(dir)
+		client = issue(dir, 0)
+		server = issue(dir, 1)
+	})
+	AfterEach(func() {
+		if listener != nil {
+			listener.Close()
+		}
+		os.RemoveAll(dir)
+	})
+	It("establishes a mutual TLS connection to the player with the given server name", func() {
+		serve(server)
+		conn, err := dial(NewTLSConnector(func() (*certs.Bundle, error) { return client, nil }), "P1")
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+		_, err = conn.Write([]byte("shares"))
+		Expect(err).NotTo(HaveOccurred())
+		buf := make([]byte, 6)
+		_, err = conn.Read(buf)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(buf)).To(Equal("shares"))
+	})
+	It("rejects a gateway presenting the certificate of another player", func() {
+		serve(server)
+		_, err := dial(NewTLSConnector(func() (*certs.Bundle, error) { return client, nil }), "P2")
+		Expect(err).To(HaveOccurred())
+	})
+	It("rejects a gateway whose certificate is issued by another CA", func() {
+		other, err := ioutil.TempDir("", "tls")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(other)
+		writeTestCA(other)
+		serve(issue(other, 1))
+		_, err = dial(NewTLSConnector(func() (*certs.Bundle, error) { return client, nil }), "P1")
+		Expect(err).To(HaveOccurred())
+	})
+	It("fails if the certificates have not been provisioned yet", func() {
+		serve(server)
+		p := certs.NewProvisioner(nil, 0, dir, time.Minute, nil)
+		_, err := dial(NewTLSConnector(ProvisionedBundle(p)), "P1")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+// writeTestCA writes a self-signed CA as "ca.crt" and "ca.key" into the given directory.
+func writeTestCA(dir string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ioutil.WriteFile(filepath.Join(dir, "ca.crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)).To(Succeed())
+	Expect(ioutil.WriteFile(filepath.Join(dir, "ca.key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)).To(Succeed())
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/carbynestack/ephemeral/pkg/ephemeral/network"
 	. "github.com/carbynestack/ephemeral/pkg/types"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	mb "github.com/vardius/message-bus"
@@ -32,6 +33,25 @@ type PlayerParams struct {
 	// Address of the frontend gateway (e.g. Istio).
 	IP   string
 	Name string
+	// PodAffinity are the pods the game is pinned to, if any.
+	PodAffinity []string
+	// ParamsFingerprint identifies the SPDZ parameters the player is configured with.
+	ParamsFingerprint string
+	// Traceparent is the W3C trace context of the activation the player takes part in, if traced.
+	Traceparent string
+	// Namespace is the Kubernetes namespace the player runs in.
+	Namespace string
+	// StateTimeouts are the timeouts of individual states, e.g. Registering, overriding the state and computation
+	// timeouts.
+	StateTimeouts map[string]time.Duration
+	// Succeeded, if set, is closed once the discovery service reported that all players finished the game successfully.
+	// The player is done only then, so that it learns about the failure of the other players.
+	Succeeded chan struct{}
+	// Features are the optional features the player supports for the game, e.g., FeatureCompression.
+	Features []string
+	// Cluster is the cluster the player runs in and LocalIP the address it is reached at by the players in the same
+	// cluster. Both are empty if the player is reached via the gateway only.
+	Cluster, LocalIP string
 }
 
 // NewPlayer returns an fsm based model of the MPC player.
@@ -42,29 +62,47 @@ func NewPlayer(ctx context.Context, bus mb.MessageBus, stateTimeout time.Duratio
 		fsm.AfterEnter(Playing).Do(call.playing(playerParams.Name, me)),
 		fsm.AfterEnter(PlayerFinishedWithError).Do(call.finishWithError(playerParams.Name)),
 		fsm.AfterEnter(PlayerFinishedWithSuccess).Do(call.finishWithSuccess(playerParams.Name)),
+		fsm.AfterEnter(GameSuccess).Do(call.gameSucceeded(playerParams.Name)),
 		fsm.AfterEnter(PlayerDone).Do(call.done()),
 		fsm.WhenStateTimeout().Do(call.finishWithError(playerParams.Name)),
 	}
 	trs := []*fsm.Transition{
 		fsm.WhenIn(Init).GotEvent(Register).GoTo(Registering),
-		fsm.WhenIn(Registering).GotEvent(PlayersReady).GoTo(Playing).WithTimeout(computationTimeout),
+		fsm.WhenIn(Registering).GotEvent(PlayersReady).GoTo(Playing),
+		// Other players may leave the game before it starts and be replaced by others.
+		fsm.WhenIn(Init).GotEvent(PlayerWithdrawn).Stay(),
+		fsm.WhenIn(Registering).GotEvent(PlayerWithdrawn).Stay(),
 		fsm.WhenIn(Playing).GotEvent(PlayerFinishedWithSuccess).GoTo(PlayerFinishedWithSuccess),
 		fsm.WhenIn(Playing).GotEvent(PlayingError).GoTo(PlayerFinishedWithError),
+		fsm.WhenIn(PlayerFinishedWithSuccess).GotEvent(GameSuccess).GoTo(GameSuccess),
 		fsm.WhenInAnyState().GotEvent(GameError).GoTo(PlayerFinishedWithError),
+		fsm.WhenInAnyState().GotEvent(PodAffinityMismatch).GoTo(PlayerFinishedWithError),
+		fsm.WhenInAnyState().GotEvent(ParamsMismatch).GoTo(PlayerFinishedWithError),
+		fsm.WhenInAnyState().GotEvent(PortsExhausted).GoTo(PlayerFinishedWithError),
 		fsm.WhenInAnyState().GotEvent(PlayerDone).GoTo(PlayerDone),
 		fsm.WhenInAnyState().GotEvent(StateTimeoutError).GoTo(PlayerFinishedWithError),
 	}
 	callbacks, transitions := fsm.InitCallbacksAndTransitions(cbs, trs)
-	f, err := fsm.NewFSM(ctx, "Init", transitions, callbacks, stateTimeout, logger)
+	f, err := fsm.NewFSM(ctx, "Init", transitions, callbacks, stateTimeout, playerStateTimeouts(computationTimeout, playerParams.StateTimeouts), logger)
 	// We can only update publisher's FSM after fsm is created.
 	call.pb.Fsm = f
 	if err != nil {
 		return nil, err
 	}
 
+	var playersReady int32
 	err = bus.Subscribe(rawEventsTopic, func(e interface{}) {
 		// Convert the events from the wire to the format understandable by the FSM.
 		ev := e.(*pb.Event)
+		if ev.Name == PlayersReady && atomic.AddInt32(&playersReady, 1) > 1 {
+			// The discovery service may resend the event, which the FSM does not accept once the game has started.
+			// The engine only reports the changes of the players.
+			logger.Debugw("Received repeated PlayersReady event", GameID, playerParams.GameID)
+			if err := me.Execute(ev); err != nil {
+				logger.Errorf("Error handling repeated PlayersReady event: %v", err)
+			}
+			return
+		}
 		call.pb.PublishWithBody(ev.Name, playerParams.Name, ev)
 	})
 	err = bus.Subscribe(playerParams.Name, func(e interface{}) {
@@ -85,6 +123,36 @@ func NewPlayer(ctx context.Context, bus mb.MessageBus, stateTimeout time.Duratio
 	}, nil
 }
 
+// playerStateTimeouts returns the timeouts of the player's states. The Playing state times out after the computation
+// timeout unless overridden.
+func playerStateTimeouts(computationTimeout time.Duration, overrides map[string]time.Duration) map[string]time.Duration {
+	timeouts := map[string]time.Duration{Playing: computationTimeout}
+	for state, timeout := range overrides {
+		timeouts[state] = timeout
+	}
+	return timeouts
+}
+
+// activationTimeout returns the maximum duration of an activation, i.e., the sum of the timeouts of the player's
+// states plus a state timeout for finishing the game.
+func activationTimeout(stateTimeout, computationTimeout time.Duration, overrides map[string]time.Duration) time.Duration {
+	timeouts := playerStateTimeouts(computationTimeout, overrides)
+	total := stateTimeout
+	for _, state := range []string{Init, Registering, Playing} {
+		if timeout, ok := timeouts[state]; ok && timeout > 0 {
+			total += timeout
+		} else {
+			total += stateTimeout
+		}
+	}
+	return total
+}
+
+// Observe registers observers which are notified about the state transitions of the player.
+func (p *Player1) Observe(observers ...fsm.Observer) {
+	p.fsm.Observe(p.params.GameID, observers...)
+}
+
 // AbstractPlayer is an interface of a player.
 type AbstractPlayer interface {
 	Init()
@@ -92,6 +160,7 @@ type AbstractPlayer interface {
 	History() *fsm.History
 	Bus() mb.MessageBus
 	PublishEvent(name, topic string, event *pb.Event)
+	Cancel()
 }
 
 // Player1 stores the FSM of the player, it manages the state and runs callbacks as response on internal/external events.
@@ -139,6 +208,13 @@ func (p *Player1) PublishEvent(name, topic string, event *pb.Event) {
 	p.call.pb.PublishWithBody(name, topic, event)
 }
 
+// Cancel notifies the discovery service that the player aborts the game, so that the other players do not wait for it
+// until the game times out. The event is sent directly, as the state machine does not process events while the MPC
+// runtime executes.
+func (p *Player1) Cancel() {
+	p.call.sendEvent(GameFinishedWithError, DiscoveryTopic, nil)
+}
+
 // NewCallbacker returns a new instance of callbacker
 func NewCallbacker(bus mb.MessageBus, playerParams *PlayerParams, errCh chan error, logger *zap.SugaredLogger) *Callbacker {
 	return &Callbacker{
@@ -168,6 +244,9 @@ func (c *Callbacker) registration() func(e interface{}) error {
 // sendPlayerReady notifies discovery service about its readiness.
 func (c *Callbacker) sendPlayerReady() func(e interface{}) error {
 	return func(e interface{}) error {
+		if e.(*fsm.Event).Name == PlayerWithdrawn {
+			return nil
+		}
 		c.sendEvent(PlayerReady, DiscoveryTopic, e)
 		return nil
 	}
@@ -212,10 +291,22 @@ func (c *Callbacker) finishWithError(id string) func(e interface{}) error {
 	}
 }
 
-// finishWithSuccess notifies discovery service about successful execution.
+// finishWithSuccess notifies discovery service about successful execution. Unless the outcome of the game is awaited,
+// the player is done.
 func (c *Callbacker) finishWithSuccess(id string) func(e interface{}) error {
 	return func(e interface{}) error {
 		c.sendEvent(GameFinishedWithSuccess, DiscoveryTopic, e)
+		if c.playerParams.Succeeded == nil {
+			c.sendEvent(PlayerDone, id, e)
+		}
+		return nil
+	}
+}
+
+// gameSucceeded signals that all players finished the game successfully.
+func (c *Callbacker) gameSucceeded(id string) func(e interface{}) error {
+	return func(e interface{}) error {
+		close(c.playerParams.Succeeded)
 		c.sendEvent(PlayerDone, id, e)
 		return nil
 	}
@@ -232,14 +323,21 @@ func (c *Callbacker) done() func(e interface{}) error {
 // sendEvent sends out an event to discovery service through the message bus.
 func (c *Callbacker) sendEvent(name, topic string, e interface{}) {
 	event := &pb.Event{
-		GameID: c.playerParams.GameID,
-		Name:   name,
+		GameID:      c.playerParams.GameID,
+		Name:        name,
+		Traceparent: c.playerParams.Traceparent,
 		Players: []*pb.Player{
 			&pb.Player{
-				Id:      c.playerParams.PlayerID,
-				Players: c.playerParams.Players,
-				Pod:     c.playerParams.Pod,
-				Ip:      c.playerParams.IP,
+				Id:                c.playerParams.PlayerID,
+				Players:           c.playerParams.Players,
+				Pod:               c.playerParams.Pod,
+				Ip:                c.playerParams.IP,
+				PodAffinity:       c.playerParams.PodAffinity,
+				ParamsFingerprint: c.playerParams.ParamsFingerprint,
+				Namespace:         c.playerParams.Namespace,
+				Features:          c.playerParams.Features,
+				Cluster:           c.playerParams.Cluster,
+				LocalIp:           c.playerParams.LocalIP,
 			},
 		},
 	}
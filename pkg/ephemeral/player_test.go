@@ -9,6 +9,8 @@ import (
 	"time"
 
 	. "github.com/carbynestack/ephemeral/pkg/discovery"
+	"github.com/carbynestack/ephemeral/pkg/discovery/fsm"
+	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
 
 	. "github.com/carbynestack/ephemeral/pkg/types"
 
@@ -81,6 +83,61 @@ var _ = Describe("Player", func() {
 		})
 	})
 
+	Context("when the outcome of the game is awaited", func() {
+		var outcome string
+		BeforeEach(func() {
+			params.Succeeded = make(chan struct{})
+			// The other players finish the game with the given outcome.
+			_ = bus.Subscribe(DiscoveryTopic, func(e interface{}) {
+				ev := e.(*fsm.Event)
+				if ev.Name == GameFinishedWithSuccess {
+					bus.Publish(id, &fsm.Event{Meta: ev.Meta, Name: outcome})
+				}
+			})
+		})
+		It("is done once all players finished the game successfully", func() {
+			outcome = GameSuccess
+			client := NewFakeDiscoveryClient(bus, id)
+			pl, _ := NewPlayer(ctx, bus, timeout, timeout, &me, params, errCh, logger)
+			client.Run()
+			Assert(PlayerDone, pl, done, func(states []string) {
+				Expect(states[3]).To(Equal(PlayerFinishedWithSuccess))
+				Expect(states[4]).To(Equal(GameSuccess))
+			}, ServiceEventsTopic)
+			pl.Init()
+			WaitDoneOrTimeout(done)
+			Expect(params.Succeeded).To(BeClosed())
+		})
+		It("fails if another player failed", func() {
+			outcome = GameError
+			client := NewFakeDiscoveryClient(bus, id)
+			pl, _ := NewPlayer(ctx, bus, timeout, timeout, &me, params, errCh, logger)
+			client.Run()
+			Assert(PlayerDone, pl, done, func(states []string) {
+				Expect(states[3]).To(Equal(PlayerFinishedWithSuccess))
+				Expect(states[4]).To(Equal(PlayerFinishedWithError))
+			}, ServiceEventsTopic)
+			pl.Init()
+			WaitDoneOrTimeout(done)
+			Expect(params.Succeeded).NotTo(BeClosed())
+		})
+	})
+
+	Context("when the activation is traced", func() {
+		It("sends the trace context along with its events", func() {
+			params.Traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+			events := make(chan *pb.Event, 10)
+			_ = bus.Subscribe(DiscoveryTopic, func(e interface{}) {
+				events <- e.(*fsm.Event).Meta.TransportMsg
+			})
+			pl, _ := NewPlayer(ctx, bus, timeout, timeout, &me, params, errCh, logger)
+			pl.Init()
+			var ev *pb.Event
+			Eventually(events).Should(Receive(&ev))
+			Expect(ev.Name).To(Equal(PlayerReady))
+			Expect(ev.Traceparent).To(Equal(params.Traceparent))
+		})
+	})
 	Context("when GameError is received from the discovery service", func() {
 		Context("in Registering state", func() {
 			It("transitions to the PlayerDone state", func() {
@@ -111,4 +168,16 @@ var _ = Describe("Player", func() {
 			})
 		})
 	})
+	Context("when per-state timeouts are configured", func() {
+		It("plays with the computation timeout unless overridden", func() {
+			timeouts := playerStateTimeouts(time.Hour, nil)
+			Expect(timeouts).To(Equal(map[string]time.Duration{Playing: time.Hour}))
+			timeouts = playerStateTimeouts(time.Hour, map[string]time.Duration{Registering: time.Minute, Playing: 2 * time.Hour})
+			Expect(timeouts).To(Equal(map[string]time.Duration{Registering: time.Minute, Playing: 2 * time.Hour}))
+		})
+		It("bounds the activation by the sum of the state timeouts", func() {
+			Expect(activationTimeout(time.Second, time.Hour, nil)).To(Equal(3*time.Second + time.Hour))
+			Expect(activationTimeout(time.Second, time.Hour, map[string]time.Duration{Registering: time.Minute})).To(Equal(2*time.Second + time.Minute + time.Hour))
+		})
+	})
 })
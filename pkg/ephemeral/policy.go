@@ -0,0 +1,79 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"fmt"
+	"net/http"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// AdmissionStage is the stage of the input of the admission policy, which distinguishes it from the input of the
+// policy evaluated once the inputs have been read from Amphora.
+const AdmissionStage = "admission"
+
+// PolicyInputBuilder builds the input of the admission policy for an activation.
+type PolicyInputBuilder func(ctx *CtxConfig) map[string]interface{}
+
+// DefaultPolicyInput describes the program, the caller, the Amphora secrets read and the output type of the
+// activation. It omits the time, so that the decisions can be cached.
+func DefaultPolicyInput(ctx *CtxConfig) map[string]interface{} {
+	secretIDs := ctx.Act.AmphoraParams
+	if secretIDs == nil {
+		secretIDs = []string{}
+	}
+	return map[string]interface{}{
+		"stage":       AdmissionStage,
+		"subject":     ctx.Spdz.ProgramIdentifier,
+		"executor":    ctx.AuthorizedUser,
+		"programHash": programHash(ctx.Act),
+		"secretIds":   secretIDs,
+		"outputType":  ctx.Act.Output.Type,
+		"playerCount": ctx.Spdz.PlayerCount,
+	}
+}
+
+// SetPolicyInput replaces the builder of the input of the admission policy. It must be called before the server
+// handles requests.
+func (s *Server) SetPolicyInput(builder PolicyInputBuilder) {
+	s.policyInput = builder
+}
+
+// PolicyFilter rejects activations denied by the admission policy with the reason of the decision. All activations
+// are forwarded if no admission policy is configured.
+func (s *Server) PolicyFilter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		conf, ok := req.Context().Value(ctxConf).(*CtxConfig)
+		if !ok {
+			writer.WriteHeader(http.StatusBadRequest)
+			s.logger.Error("No context config provided")
+			return
+		}
+		if conf.Spdz.AdmissionPolicy == nil {
+			next.ServeHTTP(writer, req)
+			return
+		}
+		decision, err := conf.Spdz.AdmissionPolicy.Decide(s.policyInput(conf))
+		if err != nil {
+			msg := fmt.Sprintf("failed to check if program can be executed: %s", err)
+			writer.WriteHeader(http.StatusInternalServerError)
+			writer.Write([]byte(msg))
+			s.logger.Errorw(msg, GameID, conf.Act.GameID)
+			return
+		}
+		if !decision.Allowed {
+			msg := "unauthorized: program cannot be executed"
+			if decision.Reason != "" {
+				msg = fmt.Sprintf("%s: %s", msg, decision.Reason)
+			}
+			writer.WriteHeader(http.StatusForbidden)
+			writer.Write([]byte(msg))
+			s.logger.Warnw(msg, GameID, conf.Act.GameID, "User", conf.AuthorizedUser)
+			return
+		}
+		next.ServeHTTP(writer, req)
+	})
+}
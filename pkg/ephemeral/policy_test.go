@@ -0,0 +1,101 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/carbynestack/ephemeral/pkg/opa"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Admission policy", func() {
+	var (
+		s       *Server
+		rr      *httptest.ResponseRecorder
+		called  bool
+		next    http.Handler
+		decider *fakeDecider
+		conf    *CtxConfig
+	)
+	BeforeEach(func() {
+		s = NewServer("sub", nil, nil, nil, zap.NewNop().Sugar(), &SPDZEngineTypedConfig{})
+		rr = httptest.NewRecorder()
+		called = false
+		next = http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			called = true
+		})
+		decider = &fakeDecider{decision: opa.Decision{Allowed: true}}
+		conf = &CtxConfig{
+			AuthorizedUser: "alice",
+			Act: &Activation{
+				GameID:        "71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4",
+				Code:          "print_ln('hello')",
+				AmphoraParams: []string{"a", "b"},
+				Output:        OutputConfig{Type: AmphoraSecret},
+			},
+			Spdz: &SPDZEngineTypedConfig{ProgramIdentifier: "ephemeral-generic", PlayerCount: 2, AdmissionPolicy: decider},
+		}
+	})
+	request := func() *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "/", nil)
+		return req.WithContext(context.WithValue(context.Background(), ctxConf, conf))
+	}
+	It("forwards activations if no admission policy is configured", func() {
+		conf.Spdz.AdmissionPolicy = nil
+		s.PolicyFilter(next).ServeHTTP(rr, request())
+		Expect(called).To(BeTrue())
+	})
+	It("forwards activations allowed by the policy", func() {
+		s.PolicyFilter(next).ServeHTTP(rr, request())
+		Expect(called).To(BeTrue())
+		Expect(decider.input).To(Equal(map[string]interface{}{
+			"stage":       AdmissionStage,
+			"subject":     "ephemeral-generic",
+			"executor":    "alice",
+			"programHash": programHash(conf.Act),
+			"secretIds":   []string{"a", "b"},
+			"outputType":  AmphoraSecret,
+			"playerCount": int32(2),
+		}))
+	})
+	It("rejects activations denied by the policy with the reason", func() {
+		decider.decision = opa.Decision{Reason: "secret a is not owned by alice"}
+		s.PolicyFilter(next).ServeHTTP(rr, request())
+		Expect(called).To(BeFalse())
+		Expect(rr.Code).To(Equal(http.StatusForbidden))
+		Expect(rr.Body.String()).To(Equal("unauthorized: program cannot be executed: secret a is not owned by alice"))
+	})
+	It("rejects activations if the policy cannot be evaluated", func() {
+		decider.err = errors.New("opa is down")
+		s.PolicyFilter(next).ServeHTTP(rr, request())
+		Expect(called).To(BeFalse())
+		Expect(rr.Code).To(Equal(http.StatusInternalServerError))
+	})
+	It("uses the configured policy input builder", func() {
+		s.SetPolicyInput(func(ctx *CtxConfig) map[string]interface{} {
+			return map[string]interface{}{"user": ctx.AuthorizedUser}
+		})
+		s.PolicyFilter(next).ServeHTTP(rr, request())
+		Expect(decider.input).To(Equal(map[string]interface{}{"user": "alice"}))
+	})
+})
+
+type fakeDecider struct {
+	decision opa.Decision
+	err      error
+	input    interface{}
+}
+
+func (f *fakeDecider) Decide(input interface{}) (opa.Decision, error) {
+	f.input = input
+	return f.decision, f.err
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"fmt"
+
+	d "github.com/carbynestack/ephemeral/pkg/discovery"
+	"github.com/carbynestack/ephemeral/pkg/portplan"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// NewPortPlan returns the ports used by the engine of the given config, i.e., the ports MP-SPDZ listens on for the
+// other players and the clients, and the local ports of the proxies reaching the other players. The client ports of
+// activations defining their own connections are not known upfront and hence not included. The plan is not validated.
+func NewPortPlan(conf *SPDZEngineTypedConfig) (*portplan.Plan, error) {
+	plan := &portplan.Plan{}
+	own := d.BasePort + conf.PlayerID
+	if conf.ProxyPortRange == "" {
+		// The proxies reaching the other players listen on the ports next to the one of MP-SPDZ.
+		if conf.PlayerCount > 0 {
+			plan.Add("MP-SPDZ players and proxies", portplan.Range{Start: d.BasePort, End: d.BasePort + conf.PlayerCount - 1})
+		}
+	} else {
+		plan.Add("MP-SPDZ players", portplan.Range{Start: own, End: own})
+		if err := plan.AddRange("game proxies", conf.ProxyPortRange); err != nil {
+			return plan, err
+		}
+	}
+	if conf.ProxyCompression != nil {
+		internal := own + conf.ProxyCompression.InternalPortOffset
+		plan.Add("MP-SPDZ players behind the compressing proxy", portplan.Range{Start: internal, End: internal})
+	}
+	feed := basePort + conf.PartyNumber(conf.PlayerID)
+	plan.Add("MP-SPDZ clients", portplan.Range{Start: feed, End: feed})
+	return plan, nil
+}
+
+// DefaultProxyPortRange returns the range the proxy ports of the games are assigned from if none is configured. The
+// range follows the ports MP-SPDZ listens on for the other players and holds a set of ports for each of the games that
+// may overlap while being set up and torn down.
+func DefaultProxyPortRange(playerCount int32) string {
+	start := d.BasePort + playerCount
+	return fmt.Sprintf("%d:%d", start, start+int32(parallelGames)*playerCount-1)
+}
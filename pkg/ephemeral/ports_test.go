@@ -0,0 +1,47 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"github.com/carbynestack/ephemeral/pkg/portplan"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Port plan", func() {
+	It("includes the ports of the players, the proxies and the clients", func() {
+		plan, err := NewPortPlan(&SPDZEngineTypedConfig{PlayerID: 1, PlayerCount: 3})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Allocations()).To(Equal([]portplan.Allocation{
+			{Component: "MP-SPDZ players and proxies", Range: portplan.Range{Start: 5000, End: 5002}},
+			{Component: "MP-SPDZ clients", Range: portplan.Range{Start: 10001, End: 10001}},
+		}))
+	})
+	It("includes the proxy port range and the internal port of compressed traffic", func() {
+		plan, err := NewPortPlan(&SPDZEngineTypedConfig{
+			PlayerID:         1,
+			PlayerCount:      2,
+			PartyNumbers:     []int32{1, 0},
+			ProxyPortRange:   "25000:25999",
+			ProxyCompression: &ProxyCompressionConfig{InternalPortOffset: 20000},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Allocations()).To(Equal([]portplan.Allocation{
+			{Component: "MP-SPDZ players", Range: portplan.Range{Start: 5001, End: 5001}},
+			{Component: "MP-SPDZ clients", Range: portplan.Range{Start: 10000, End: 10000}},
+			{Component: "game proxies", Range: portplan.Range{Start: 25000, End: 25999}},
+			{Component: "MP-SPDZ players behind the compressing proxy", Range: portplan.Range{Start: 25001, End: 25001}},
+		}))
+		Expect(plan.Validate()).To(MatchError("the ports 25000:25999 of the game proxies overlap with the ports 25001 of the MP-SPDZ players behind the compressing proxy"))
+	})
+	It("assigns the default proxy ports next to the ones of MP-SPDZ", func() {
+		Expect(DefaultProxyPortRange(3)).To(Equal("5003:5014"))
+		plan, err := NewPortPlan(&SPDZEngineTypedConfig{PlayerID: 2, PlayerCount: 3, ProxyPortRange: DefaultProxyPortRange(3)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Validate()).To(Succeed())
+	})
+})
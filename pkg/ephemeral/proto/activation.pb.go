@@ -0,0 +1,945 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: activation.proto
+
+package protobuf
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	wrappers "github.com/golang/protobuf/ptypes/wrappers"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type OutputConfig struct {
+	Type                 string         `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Schema               []*OutputField `protobuf:"bytes,2,rep,name=schema,proto3" json:"schema,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *OutputConfig) Reset()         { *m = OutputConfig{} }
+func (m *OutputConfig) String() string { return proto.CompactTextString(m) }
+func (*OutputConfig) ProtoMessage()    {}
+func (*OutputConfig) Descriptor() ([]byte, []int) {
+	return fileDescriptor_baec3c6aeacf77ef, []int{0}
+}
+
+func (m *OutputConfig) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OutputConfig.Unmarshal(m, b)
+}
+func (m *OutputConfig) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OutputConfig.Marshal(b, m, deterministic)
+}
+func (m *OutputConfig) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OutputConfig.Merge(m, src)
+}
+func (m *OutputConfig) XXX_Size() int {
+	return xxx_messageInfo_OutputConfig.Size(m)
+}
+func (m *OutputConfig) XXX_DiscardUnknown() {
+	xxx_messageInfo_OutputConfig.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OutputConfig proto.InternalMessageInfo
+
+func (m *OutputConfig) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *OutputConfig) GetSchema() []*OutputField {
+	if m != nil {
+		return m.Schema
+	}
+	return nil
+}
+
+type OutputField struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Count                int32    `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OutputField) Reset()         { *m = OutputField{} }
+func (m *OutputField) String() string { return proto.CompactTextString(m) }
+func (*OutputField) ProtoMessage()    {}
+func (*OutputField) Descriptor() ([]byte, []int) {
+	return fileDescriptor_baec3c6aeacf77ef, []int{1}
+}
+
+func (m *OutputField) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OutputField.Unmarshal(m, b)
+}
+func (m *OutputField) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OutputField.Marshal(b, m, deterministic)
+}
+func (m *OutputField) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OutputField.Merge(m, src)
+}
+func (m *OutputField) XXX_Size() int {
+	return xxx_messageInfo_OutputField.Size(m)
+}
+func (m *OutputField) XXX_DiscardUnknown() {
+	xxx_messageInfo_OutputField.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OutputField proto.InternalMessageInfo
+
+func (m *OutputField) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *OutputField) GetCount() int32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type Activation struct {
+	AmphoraParams        []string            `protobuf:"bytes,1,rep,name=amphoraParams,proto3" json:"amphoraParams,omitempty"`
+	SecretParams         []string            `protobuf:"bytes,2,rep,name=secretParams,proto3" json:"secretParams,omitempty"`
+	GameID               string              `protobuf:"bytes,3,opt,name=gameID,proto3" json:"gameID,omitempty"`
+	Code                 string              `protobuf:"bytes,4,opt,name=code,proto3" json:"code,omitempty"`
+	Output               *OutputConfig       `protobuf:"bytes,5,opt,name=output,proto3" json:"output,omitempty"`
+	PodAffinity          []string            `protobuf:"bytes,6,rep,name=podAffinity,proto3" json:"podAffinity,omitempty"`
+	CompilerOptions      *CompilerOptions    `protobuf:"bytes,7,opt,name=compilerOptions,proto3" json:"compilerOptions,omitempty"`
+	Labels               map[string]string   `protobuf:"bytes,8,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	SessionID            string              `protobuf:"bytes,9,opt,name=sessionID,proto3" json:"sessionID,omitempty"`
+	Protocol             string              `protobuf:"bytes,10,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	TimeBudget           string              `protobuf:"bytes,11,opt,name=timeBudget,proto3" json:"timeBudget,omitempty"`
+	Diagnostics          bool                `protobuf:"varint,12,opt,name=diagnostics,proto3" json:"diagnostics,omitempty"`
+	Connections          []*ClientConnection `protobuf:"bytes,13,rep,name=connections,proto3" json:"connections,omitempty"`
+	Encoding             string              `protobuf:"bytes,14,opt,name=encoding,proto3" json:"encoding,omitempty"`
+	Compression          *wrappers.BoolValue `protobuf:"bytes,15,opt,name=compression,proto3" json:"compression,omitempty"`
+	Signature            string              `protobuf:"bytes,16,opt,name=signature,proto3" json:"signature,omitempty"`
+	Seed                 string              `protobuf:"bytes,17,opt,name=seed,proto3" json:"seed,omitempty"`
+	Backend              string              `protobuf:"bytes,18,opt,name=backend,proto3" json:"backend,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *Activation) Reset()         { *m = Activation{} }
+func (m *Activation) String() string { return proto.CompactTextString(m) }
+func (*Activation) ProtoMessage()    {}
+func (*Activation) Descriptor() ([]byte, []int) {
+	return fileDescriptor_baec3c6aeacf77ef, []int{2}
+}
+
+func (m *Activation) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Activation.Unmarshal(m, b)
+}
+func (m *Activation) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Activation.Marshal(b, m, deterministic)
+}
+func (m *Activation) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Activation.Merge(m, src)
+}
+func (m *Activation) XXX_Size() int {
+	return xxx_messageInfo_Activation.Size(m)
+}
+func (m *Activation) XXX_DiscardUnknown() {
+	xxx_messageInfo_Activation.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Activation proto.InternalMessageInfo
+
+func (m *Activation) GetAmphoraParams() []string {
+	if m != nil {
+		return m.AmphoraParams
+	}
+	return nil
+}
+
+func (m *Activation) GetSecretParams() []string {
+	if m != nil {
+		return m.SecretParams
+	}
+	return nil
+}
+
+func (m *Activation) GetGameID() string {
+	if m != nil {
+		return m.GameID
+	}
+	return ""
+}
+
+func (m *Activation) GetCode() string {
+	if m != nil {
+		return m.Code
+	}
+	return ""
+}
+
+func (m *Activation) GetOutput() *OutputConfig {
+	if m != nil {
+		return m.Output
+	}
+	return nil
+}
+
+func (m *Activation) GetPodAffinity() []string {
+	if m != nil {
+		return m.PodAffinity
+	}
+	return nil
+}
+
+func (m *Activation) GetCompilerOptions() *CompilerOptions {
+	if m != nil {
+		return m.CompilerOptions
+	}
+	return nil
+}
+
+func (m *Activation) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *Activation) GetSessionID() string {
+	if m != nil {
+		return m.SessionID
+	}
+	return ""
+}
+
+func (m *Activation) GetProtocol() string {
+	if m != nil {
+		return m.Protocol
+	}
+	return ""
+}
+
+func (m *Activation) GetTimeBudget() string {
+	if m != nil {
+		return m.TimeBudget
+	}
+	return ""
+}
+
+func (m *Activation) GetDiagnostics() bool {
+	if m != nil {
+		return m.Diagnostics
+	}
+	return false
+}
+
+func (m *Activation) GetConnections() []*ClientConnection {
+	if m != nil {
+		return m.Connections
+	}
+	return nil
+}
+
+func (m *Activation) GetEncoding() string {
+	if m != nil {
+		return m.Encoding
+	}
+	return ""
+}
+
+func (m *Activation) GetCompression() *wrappers.BoolValue {
+	if m != nil {
+		return m.Compression
+	}
+	return nil
+}
+
+func (m *Activation) GetSignature() string {
+	if m != nil {
+		return m.Signature
+	}
+	return ""
+}
+
+func (m *Activation) GetSeed() string {
+	if m != nil {
+		return m.Seed
+	}
+	return ""
+}
+
+func (m *Activation) GetBackend() string {
+	if m != nil {
+		return m.Backend
+	}
+	return ""
+}
+
+type ClientConnection struct {
+	Port                 int32    `protobuf:"varint,1,opt,name=port,proto3" json:"port,omitempty"`
+	Params               int32    `protobuf:"varint,2,opt,name=params,proto3" json:"params,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ClientConnection) Reset()         { *m = ClientConnection{} }
+func (m *ClientConnection) String() string { return proto.CompactTextString(m) }
+func (*ClientConnection) ProtoMessage()    {}
+func (*ClientConnection) Descriptor() ([]byte, []int) {
+	return fileDescriptor_baec3c6aeacf77ef, []int{3}
+}
+
+func (m *ClientConnection) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClientConnection.Unmarshal(m, b)
+}
+func (m *ClientConnection) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClientConnection.Marshal(b, m, deterministic)
+}
+func (m *ClientConnection) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClientConnection.Merge(m, src)
+}
+func (m *ClientConnection) XXX_Size() int {
+	return xxx_messageInfo_ClientConnection.Size(m)
+}
+func (m *ClientConnection) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClientConnection.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ClientConnection proto.InternalMessageInfo
+
+func (m *ClientConnection) GetPort() int32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+func (m *ClientConnection) GetParams() int32 {
+	if m != nil {
+		return m.Params
+	}
+	return 0
+}
+
+type CompilerOptions struct {
+	OptimizationLevel    int32    `protobuf:"varint,1,opt,name=optimizationLevel,proto3" json:"optimizationLevel,omitempty"`
+	BitLength            int32    `protobuf:"varint,2,opt,name=bitLength,proto3" json:"bitLength,omitempty"`
+	Budget               int32    `protobuf:"varint,3,opt,name=budget,proto3" json:"budget,omitempty"`
+	Prime                string   `protobuf:"bytes,4,opt,name=prime,proto3" json:"prime,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CompilerOptions) Reset()         { *m = CompilerOptions{} }
+func (m *CompilerOptions) String() string { return proto.CompactTextString(m) }
+func (*CompilerOptions) ProtoMessage()    {}
+func (*CompilerOptions) Descriptor() ([]byte, []int) {
+	return fileDescriptor_baec3c6aeacf77ef, []int{4}
+}
+
+func (m *CompilerOptions) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CompilerOptions.Unmarshal(m, b)
+}
+func (m *CompilerOptions) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CompilerOptions.Marshal(b, m, deterministic)
+}
+func (m *CompilerOptions) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CompilerOptions.Merge(m, src)
+}
+func (m *CompilerOptions) XXX_Size() int {
+	return xxx_messageInfo_CompilerOptions.Size(m)
+}
+func (m *CompilerOptions) XXX_DiscardUnknown() {
+	xxx_messageInfo_CompilerOptions.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CompilerOptions proto.InternalMessageInfo
+
+func (m *CompilerOptions) GetOptimizationLevel() int32 {
+	if m != nil {
+		return m.OptimizationLevel
+	}
+	return 0
+}
+
+func (m *CompilerOptions) GetBitLength() int32 {
+	if m != nil {
+		return m.BitLength
+	}
+	return 0
+}
+
+func (m *CompilerOptions) GetBudget() int32 {
+	if m != nil {
+		return m.Budget
+	}
+	return 0
+}
+
+func (m *CompilerOptions) GetPrime() string {
+	if m != nil {
+		return m.Prime
+	}
+	return ""
+}
+
+type TruncationWarning struct {
+	Truncated            bool     `protobuf:"varint,1,opt,name=truncated,proto3" json:"truncated,omitempty"`
+	Reason               string   `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	Offset               int32    `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TruncationWarning) Reset()         { *m = TruncationWarning{} }
+func (m *TruncationWarning) String() string { return proto.CompactTextString(m) }
+func (*TruncationWarning) ProtoMessage()    {}
+func (*TruncationWarning) Descriptor() ([]byte, []int) {
+	return fileDescriptor_baec3c6aeacf77ef, []int{5}
+}
+
+func (m *TruncationWarning) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TruncationWarning.Unmarshal(m, b)
+}
+func (m *TruncationWarning) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TruncationWarning.Marshal(b, m, deterministic)
+}
+func (m *TruncationWarning) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TruncationWarning.Merge(m, src)
+}
+func (m *TruncationWarning) XXX_Size() int {
+	return xxx_messageInfo_TruncationWarning.Size(m)
+}
+func (m *TruncationWarning) XXX_DiscardUnknown() {
+	xxx_messageInfo_TruncationWarning.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TruncationWarning proto.InternalMessageInfo
+
+func (m *TruncationWarning) GetTruncated() bool {
+	if m != nil {
+		return m.Truncated
+	}
+	return false
+}
+
+func (m *TruncationWarning) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *TruncationWarning) GetOffset() int32 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+type AmphoraInteraction struct {
+	Operation            string   `protobuf:"bytes,1,opt,name=operation,proto3" json:"operation,omitempty"`
+	SecretId             string   `protobuf:"bytes,2,opt,name=secretId,proto3" json:"secretId,omitempty"`
+	Size                 int64    `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	DurationMs           int64    `protobuf:"varint,4,opt,name=durationMs,proto3" json:"durationMs,omitempty"`
+	Status               int32    `protobuf:"varint,5,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string   `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AmphoraInteraction) Reset()         { *m = AmphoraInteraction{} }
+func (m *AmphoraInteraction) String() string { return proto.CompactTextString(m) }
+func (*AmphoraInteraction) ProtoMessage()    {}
+func (*AmphoraInteraction) Descriptor() ([]byte, []int) {
+	return fileDescriptor_baec3c6aeacf77ef, []int{6}
+}
+
+func (m *AmphoraInteraction) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AmphoraInteraction.Unmarshal(m, b)
+}
+func (m *AmphoraInteraction) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AmphoraInteraction.Marshal(b, m, deterministic)
+}
+func (m *AmphoraInteraction) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AmphoraInteraction.Merge(m, src)
+}
+func (m *AmphoraInteraction) XXX_Size() int {
+	return xxx_messageInfo_AmphoraInteraction.Size(m)
+}
+func (m *AmphoraInteraction) XXX_DiscardUnknown() {
+	xxx_messageInfo_AmphoraInteraction.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AmphoraInteraction proto.InternalMessageInfo
+
+func (m *AmphoraInteraction) GetOperation() string {
+	if m != nil {
+		return m.Operation
+	}
+	return ""
+}
+
+func (m *AmphoraInteraction) GetSecretId() string {
+	if m != nil {
+		return m.SecretId
+	}
+	return ""
+}
+
+func (m *AmphoraInteraction) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *AmphoraInteraction) GetDurationMs() int64 {
+	if m != nil {
+		return m.DurationMs
+	}
+	return 0
+}
+
+func (m *AmphoraInteraction) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *AmphoraInteraction) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type Diagnostics struct {
+	InputBytes           int64                 `protobuf:"varint,1,opt,name=inputBytes,proto3" json:"inputBytes,omitempty"`
+	OutputBytes          int64                 `protobuf:"varint,2,opt,name=outputBytes,proto3" json:"outputBytes,omitempty"`
+	Amphora              []*AmphoraInteraction `protobuf:"bytes,3,rep,name=amphora,proto3" json:"amphora,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *Diagnostics) Reset()         { *m = Diagnostics{} }
+func (m *Diagnostics) String() string { return proto.CompactTextString(m) }
+func (*Diagnostics) ProtoMessage()    {}
+func (*Diagnostics) Descriptor() ([]byte, []int) {
+	return fileDescriptor_baec3c6aeacf77ef, []int{7}
+}
+
+func (m *Diagnostics) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Diagnostics.Unmarshal(m, b)
+}
+func (m *Diagnostics) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Diagnostics.Marshal(b, m, deterministic)
+}
+func (m *Diagnostics) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Diagnostics.Merge(m, src)
+}
+func (m *Diagnostics) XXX_Size() int {
+	return xxx_messageInfo_Diagnostics.Size(m)
+}
+func (m *Diagnostics) XXX_DiscardUnknown() {
+	xxx_messageInfo_Diagnostics.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Diagnostics proto.InternalMessageInfo
+
+func (m *Diagnostics) GetInputBytes() int64 {
+	if m != nil {
+		return m.InputBytes
+	}
+	return 0
+}
+
+func (m *Diagnostics) GetOutputBytes() int64 {
+	if m != nil {
+		return m.OutputBytes
+	}
+	return 0
+}
+
+func (m *Diagnostics) GetAmphora() []*AmphoraInteraction {
+	if m != nil {
+		return m.Amphora
+	}
+	return nil
+}
+
+type Reproducibility struct {
+	Seed                 string            `protobuf:"bytes,1,opt,name=seed,proto3" json:"seed,omitempty"`
+	Preprocessing        string            `protobuf:"bytes,2,opt,name=preprocessing,proto3" json:"preprocessing,omitempty"`
+	InsecureTupleCount   int32             `protobuf:"varint,3,opt,name=insecureTupleCount,proto3" json:"insecureTupleCount,omitempty"`
+	Protocol             string            `protobuf:"bytes,4,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	ProgramHash          string            `protobuf:"bytes,5,opt,name=programHash,proto3" json:"programHash,omitempty"`
+	CompilerOptions      *CompilerOptions  `protobuf:"bytes,6,opt,name=compilerOptions,proto3" json:"compilerOptions,omitempty"`
+	RuntimeArgs          []string          `protobuf:"bytes,7,rep,name=runtimeArgs,proto3" json:"runtimeArgs,omitempty"`
+	Prime                string            `protobuf:"bytes,8,opt,name=prime,proto3" json:"prime,omitempty"`
+	Gf2NBitLength        int32             `protobuf:"varint,9,opt,name=gf2nBitLength,proto3" json:"gf2nBitLength,omitempty"`
+	PlayerCount          int32             `protobuf:"varint,10,opt,name=playerCount,proto3" json:"playerCount,omitempty"`
+	PlayerID             int32             `protobuf:"varint,11,opt,name=playerID,proto3" json:"playerID,omitempty"`
+	Versions             map[string]string `protobuf:"bytes,12,rep,name=versions,proto3" json:"versions,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *Reproducibility) Reset()         { *m = Reproducibility{} }
+func (m *Reproducibility) String() string { return proto.CompactTextString(m) }
+func (*Reproducibility) ProtoMessage()    {}
+func (*Reproducibility) Descriptor() ([]byte, []int) {
+	return fileDescriptor_baec3c6aeacf77ef, []int{8}
+}
+
+func (m *Reproducibility) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Reproducibility.Unmarshal(m, b)
+}
+func (m *Reproducibility) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Reproducibility.Marshal(b, m, deterministic)
+}
+func (m *Reproducibility) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Reproducibility.Merge(m, src)
+}
+func (m *Reproducibility) XXX_Size() int {
+	return xxx_messageInfo_Reproducibility.Size(m)
+}
+func (m *Reproducibility) XXX_DiscardUnknown() {
+	xxx_messageInfo_Reproducibility.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Reproducibility proto.InternalMessageInfo
+
+func (m *Reproducibility) GetSeed() string {
+	if m != nil {
+		return m.Seed
+	}
+	return ""
+}
+
+func (m *Reproducibility) GetPreprocessing() string {
+	if m != nil {
+		return m.Preprocessing
+	}
+	return ""
+}
+
+func (m *Reproducibility) GetInsecureTupleCount() int32 {
+	if m != nil {
+		return m.InsecureTupleCount
+	}
+	return 0
+}
+
+func (m *Reproducibility) GetProtocol() string {
+	if m != nil {
+		return m.Protocol
+	}
+	return ""
+}
+
+func (m *Reproducibility) GetProgramHash() string {
+	if m != nil {
+		return m.ProgramHash
+	}
+	return ""
+}
+
+func (m *Reproducibility) GetCompilerOptions() *CompilerOptions {
+	if m != nil {
+		return m.CompilerOptions
+	}
+	return nil
+}
+
+func (m *Reproducibility) GetRuntimeArgs() []string {
+	if m != nil {
+		return m.RuntimeArgs
+	}
+	return nil
+}
+
+func (m *Reproducibility) GetPrime() string {
+	if m != nil {
+		return m.Prime
+	}
+	return ""
+}
+
+func (m *Reproducibility) GetGf2NBitLength() int32 {
+	if m != nil {
+		return m.Gf2NBitLength
+	}
+	return 0
+}
+
+func (m *Reproducibility) GetPlayerCount() int32 {
+	if m != nil {
+		return m.PlayerCount
+	}
+	return 0
+}
+
+func (m *Reproducibility) GetPlayerID() int32 {
+	if m != nil {
+		return m.PlayerID
+	}
+	return 0
+}
+
+func (m *Reproducibility) GetVersions() map[string]string {
+	if m != nil {
+		return m.Versions
+	}
+	return nil
+}
+
+type Values struct {
+	Values               []string `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Values) Reset()         { *m = Values{} }
+func (m *Values) String() string { return proto.CompactTextString(m) }
+func (*Values) ProtoMessage()    {}
+func (*Values) Descriptor() ([]byte, []int) {
+	return fileDescriptor_baec3c6aeacf77ef, []int{9}
+}
+
+func (m *Values) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Values.Unmarshal(m, b)
+}
+func (m *Values) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Values.Marshal(b, m, deterministic)
+}
+func (m *Values) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Values.Merge(m, src)
+}
+func (m *Values) XXX_Size() int {
+	return xxx_messageInfo_Values.Size(m)
+}
+func (m *Values) XXX_DiscardUnknown() {
+	xxx_messageInfo_Values.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Values proto.InternalMessageInfo
+
+func (m *Values) GetValues() []string {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+type Result struct {
+	Response             []string           `protobuf:"bytes,1,rep,name=response,proto3" json:"response,omitempty"`
+	Warning              *TruncationWarning `protobuf:"bytes,2,opt,name=warning,proto3" json:"warning,omitempty"`
+	Error                string             `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	Diagnostics          *Diagnostics       `protobuf:"bytes,4,opt,name=diagnostics,proto3" json:"diagnostics,omitempty"`
+	Ports                []string           `protobuf:"bytes,5,rep,name=ports,proto3" json:"ports,omitempty"`
+	Outputs              map[string]*Values `protobuf:"bytes,6,rep,name=outputs,proto3" json:"outputs,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Secrets              map[string]string  `protobuf:"bytes,7,rep,name=secrets,proto3" json:"secrets,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Reproducibility      *Reproducibility   `protobuf:"bytes,8,opt,name=reproducibility,proto3" json:"reproducibility,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *Result) Reset()         { *m = Result{} }
+func (m *Result) String() string { return proto.CompactTextString(m) }
+func (*Result) ProtoMessage()    {}
+func (*Result) Descriptor() ([]byte, []int) {
+	return fileDescriptor_baec3c6aeacf77ef, []int{10}
+}
+
+func (m *Result) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Result.Unmarshal(m, b)
+}
+func (m *Result) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Result.Marshal(b, m, deterministic)
+}
+func (m *Result) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Result.Merge(m, src)
+}
+func (m *Result) XXX_Size() int {
+	return xxx_messageInfo_Result.Size(m)
+}
+func (m *Result) XXX_DiscardUnknown() {
+	xxx_messageInfo_Result.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Result proto.InternalMessageInfo
+
+func (m *Result) GetResponse() []string {
+	if m != nil {
+		return m.Response
+	}
+	return nil
+}
+
+func (m *Result) GetWarning() *TruncationWarning {
+	if m != nil {
+		return m.Warning
+	}
+	return nil
+}
+
+func (m *Result) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *Result) GetDiagnostics() *Diagnostics {
+	if m != nil {
+		return m.Diagnostics
+	}
+	return nil
+}
+
+func (m *Result) GetPorts() []string {
+	if m != nil {
+		return m.Ports
+	}
+	return nil
+}
+
+func (m *Result) GetOutputs() map[string]*Values {
+	if m != nil {
+		return m.Outputs
+	}
+	return nil
+}
+
+func (m *Result) GetSecrets() map[string]string {
+	if m != nil {
+		return m.Secrets
+	}
+	return nil
+}
+
+func (m *Result) GetReproducibility() *Reproducibility {
+	if m != nil {
+		return m.Reproducibility
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*OutputConfig)(nil), "protobuf.OutputConfig")
+	proto.RegisterType((*OutputField)(nil), "protobuf.OutputField")
+	proto.RegisterType((*Activation)(nil), "protobuf.Activation")
+	proto.RegisterMapType((map[string]string)(nil), "protobuf.Activation.LabelsEntry")
+	proto.RegisterType((*ClientConnection)(nil), "protobuf.ClientConnection")
+	proto.RegisterType((*CompilerOptions)(nil), "protobuf.CompilerOptions")
+	proto.RegisterType((*TruncationWarning)(nil), "protobuf.TruncationWarning")
+	proto.RegisterType((*AmphoraInteraction)(nil), "protobuf.AmphoraInteraction")
+	proto.RegisterType((*Diagnostics)(nil), "protobuf.Diagnostics")
+	proto.RegisterType((*Reproducibility)(nil), "protobuf.Reproducibility")
+	proto.RegisterMapType((map[string]string)(nil), "protobuf.Reproducibility.VersionsEntry")
+	proto.RegisterType((*Values)(nil), "protobuf.Values")
+	proto.RegisterType((*Result)(nil), "protobuf.Result")
+	proto.RegisterMapType((map[string]*Values)(nil), "protobuf.Result.OutputsEntry")
+	proto.RegisterMapType((map[string]string)(nil), "protobuf.Result.SecretsEntry")
+}
+
+func init() { proto.RegisterFile("activation.proto", fileDescriptor_baec3c6aeacf77ef) }
+
+var fileDescriptor_baec3c6aeacf77ef = []byte{
+	// 1111 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x56, 0xdd, 0x6e, 0x23, 0xb5,
+	0x17, 0xd7, 0x34, 0x1f, 0x4d, 0xce, 0xb4, 0xff, 0x76, 0xad, 0x3f, 0xab, 0x21, 0x94, 0x55, 0x14,
+	0xad, 0x20, 0x17, 0x90, 0x95, 0x82, 0x60, 0x97, 0x05, 0x21, 0xb5, 0x29, 0x88, 0x4a, 0x45, 0x0b,
+	0x66, 0xb5, 0x5c, 0x4f, 0x66, 0x9c, 0xa9, 0xb5, 0x13, 0x7b, 0x64, 0x7b, 0xba, 0xca, 0xde, 0x71,
+	0xc5, 0x05, 0xaf, 0xc0, 0x43, 0xf0, 0x64, 0x3c, 0x03, 0xf2, 0xb1, 0x27, 0xe3, 0xa4, 0xe5, 0xa2,
+	0x57, 0xf1, 0xf9, 0xf9, 0xf8, 0x7c, 0xfe, 0xce, 0x99, 0xc0, 0x69, 0x9a, 0x19, 0x7e, 0x9b, 0x1a,
+	0x2e, 0xc5, 0xac, 0x52, 0xd2, 0x48, 0x32, 0xc0, 0x9f, 0x65, 0xbd, 0x1a, 0x3d, 0x29, 0xa4, 0x2c,
+	0x4a, 0xf6, 0xac, 0x01, 0x9e, 0xbd, 0x53, 0x69, 0x55, 0x31, 0xa5, 0x9d, 0xe6, 0xe4, 0x17, 0x38,
+	0x7a, 0x55, 0x9b, 0xaa, 0x36, 0x0b, 0x29, 0x56, 0xbc, 0x20, 0x04, 0xba, 0x66, 0x53, 0xb1, 0x24,
+	0x1a, 0x47, 0xd3, 0x21, 0xc5, 0x33, 0xf9, 0x1c, 0xfa, 0x3a, 0xbb, 0x61, 0xeb, 0x34, 0x39, 0x18,
+	0x77, 0xa6, 0xf1, 0xfc, 0x83, 0x59, 0x63, 0x6d, 0xe6, 0xde, 0xfe, 0xc0, 0x59, 0x99, 0x53, 0xaf,
+	0x34, 0x79, 0x0e, 0x71, 0x00, 0x5b, 0x8b, 0x22, 0x5d, 0x6f, 0x2d, 0xda, 0x33, 0xf9, 0x3f, 0xf4,
+	0x32, 0x59, 0x0b, 0x93, 0x1c, 0x8c, 0xa3, 0x69, 0x8f, 0x3a, 0x61, 0xf2, 0x4f, 0x0f, 0xe0, 0x7c,
+	0x9b, 0x0a, 0x79, 0x0a, 0xc7, 0xe9, 0xba, 0xba, 0x91, 0x2a, 0xfd, 0x39, 0x55, 0xe9, 0x5a, 0x27,
+	0xd1, 0xb8, 0x33, 0x1d, 0xd2, 0x5d, 0x90, 0x4c, 0xe0, 0x48, 0xb3, 0x4c, 0x31, 0xe3, 0x95, 0x0e,
+	0x50, 0x69, 0x07, 0x23, 0x8f, 0xa1, 0x5f, 0xa4, 0x6b, 0x76, 0x75, 0x99, 0x74, 0x30, 0x08, 0x2f,
+	0xd9, 0xd0, 0x32, 0x99, 0xb3, 0xa4, 0xeb, 0x42, 0xb3, 0x67, 0x32, 0x83, 0xbe, 0xc4, 0xe8, 0x93,
+	0xde, 0x38, 0x9a, 0xc6, 0xf3, 0xc7, 0xfb, 0xc9, 0xba, 0x42, 0x51, 0xaf, 0x45, 0xc6, 0x10, 0x57,
+	0x32, 0x3f, 0x5f, 0xad, 0xb8, 0xe0, 0x66, 0x93, 0xf4, 0xd1, 0x7d, 0x08, 0x91, 0x05, 0x9c, 0x64,
+	0x72, 0x5d, 0xf1, 0x92, 0xa9, 0x57, 0x95, 0xcd, 0x4c, 0x27, 0x87, 0x68, 0xfa, 0xc3, 0xd6, 0xf4,
+	0x62, 0x57, 0x81, 0xee, 0xbf, 0x20, 0x2f, 0xa0, 0x5f, 0xa6, 0x4b, 0x56, 0xea, 0x64, 0x80, 0x3d,
+	0x18, 0xb7, 0x6f, 0xdb, 0x92, 0xcd, 0xae, 0x51, 0xe5, 0x7b, 0x61, 0xd4, 0x86, 0x7a, 0x7d, 0x72,
+	0x06, 0x43, 0xcd, 0xb4, 0xe6, 0x52, 0x5c, 0x5d, 0x26, 0x43, 0xcc, 0xb4, 0x05, 0xc8, 0x08, 0x1c,
+	0x57, 0x32, 0x59, 0x26, 0x80, 0x97, 0x5b, 0x99, 0x3c, 0x01, 0x30, 0x7c, 0xcd, 0x2e, 0xea, 0xbc,
+	0x60, 0x26, 0x89, 0xf1, 0x36, 0x40, 0x6c, 0xea, 0x39, 0x4f, 0x0b, 0x21, 0xb5, 0xe1, 0x99, 0x4e,
+	0x8e, 0xc6, 0xd1, 0x74, 0x40, 0x43, 0x88, 0x7c, 0x0b, 0x71, 0x26, 0x85, 0x60, 0x99, 0x4b, 0xfb,
+	0x18, 0x43, 0x1f, 0x05, 0x69, 0x97, 0x9c, 0x09, 0xb3, 0xd8, 0xaa, 0xd0, 0x50, 0xdd, 0xc6, 0xc6,
+	0x44, 0x26, 0x73, 0x2e, 0x8a, 0xe4, 0x7f, 0x2e, 0xb6, 0x46, 0x76, 0x96, 0xd7, 0x95, 0x72, 0x89,
+	0x24, 0x27, 0x58, 0xd0, 0xd1, 0xcc, 0xb1, 0xbd, 0x75, 0x70, 0x21, 0x65, 0xf9, 0x26, 0x2d, 0x6b,
+	0x46, 0x43, 0x75, 0xac, 0x09, 0x2f, 0x44, 0x6a, 0x6a, 0xc5, 0x92, 0x53, 0x5f, 0x93, 0x06, 0xb0,
+	0xb4, 0xd0, 0x8c, 0xe5, 0xc9, 0x23, 0x47, 0x0b, 0x7b, 0x26, 0x09, 0x1c, 0x2e, 0xd3, 0xec, 0x2d,
+	0x13, 0x79, 0x42, 0x10, 0x6e, 0xc4, 0xd1, 0xd7, 0x10, 0x07, 0x65, 0x27, 0xa7, 0xd0, 0x79, 0xcb,
+	0x36, 0x9e, 0xed, 0xf6, 0x68, 0xc9, 0x7e, 0x6b, 0x43, 0x40, 0xb2, 0x0f, 0xa9, 0x13, 0x5e, 0x1e,
+	0xbc, 0x88, 0x26, 0xdf, 0xc1, 0xe9, 0x7e, 0x05, 0xac, 0xf3, 0x4a, 0x2a, 0x83, 0x06, 0x7a, 0x14,
+	0xcf, 0x96, 0xbf, 0x55, 0xc3, 0x6e, 0x8b, 0x7a, 0x69, 0xf2, 0x67, 0x04, 0x27, 0x7b, 0xcc, 0x21,
+	0x9f, 0xc1, 0x23, 0x59, 0x19, 0xbe, 0xe6, 0xef, 0x91, 0x12, 0xd7, 0xec, 0x96, 0x95, 0xde, 0xd8,
+	0xdd, 0x0b, 0x5b, 0x88, 0x25, 0x37, 0xd7, 0x4c, 0x14, 0xe6, 0xc6, 0x1b, 0x6f, 0x01, 0xeb, 0x77,
+	0xe9, 0x9a, 0xdf, 0x71, 0x7e, 0x9d, 0x64, 0x33, 0xaa, 0x14, 0x5f, 0x37, 0x83, 0xe3, 0x84, 0x49,
+	0x0a, 0x8f, 0x5e, 0xab, 0x5a, 0x64, 0x68, 0xfe, 0xb7, 0x54, 0x09, 0xdb, 0xa7, 0x33, 0x18, 0x1a,
+	0x07, 0xb2, 0x1c, 0xc3, 0x18, 0xd0, 0x16, 0xb0, 0x0e, 0x14, 0x4b, 0xb5, 0x14, 0xbe, 0x36, 0x5e,
+	0xb2, 0xb8, 0x5c, 0xad, 0x74, 0xeb, 0xd8, 0x49, 0x93, 0xbf, 0x23, 0x20, 0xe7, 0x6e, 0xfc, 0xaf,
+	0x84, 0x61, 0x2a, 0x75, 0x35, 0x3b, 0x83, 0xa1, 0xac, 0x98, 0x42, 0xc7, 0xbe, 0xf2, 0x2d, 0x60,
+	0x69, 0xe4, 0xb6, 0xc1, 0x55, 0xee, 0xdd, 0x6c, 0x65, 0x6c, 0x35, 0x7f, 0xcf, 0xd0, 0x4d, 0x87,
+	0xe2, 0xd9, 0xd2, 0x3e, 0xaf, 0xdd, 0xdb, 0x9f, 0x34, 0xa6, 0xd8, 0xa1, 0x01, 0x62, 0x83, 0xd3,
+	0x26, 0x35, 0xb5, 0xc6, 0x0d, 0xd1, 0xa3, 0x5e, 0xb2, 0x55, 0x61, 0x4a, 0x49, 0x95, 0xf4, 0x5d,
+	0x55, 0x50, 0x98, 0xfc, 0x11, 0x41, 0x7c, 0x19, 0x8c, 0xc4, 0x13, 0x00, 0x2e, 0xaa, 0xda, 0x5c,
+	0x6c, 0x0c, 0xd3, 0x18, 0x6c, 0x87, 0x06, 0x88, 0x1d, 0x2a, 0xb7, 0x59, 0x9c, 0xc2, 0x01, 0x2a,
+	0x84, 0x10, 0xf9, 0x0a, 0x0e, 0xfd, 0x0a, 0x4c, 0x3a, 0x38, 0x50, 0x67, 0xc1, 0x2e, 0xb8, 0x53,
+	0x1c, 0xda, 0x28, 0x4f, 0xfe, 0xea, 0xc2, 0x09, 0x65, 0x95, 0x92, 0x79, 0x9d, 0xf1, 0x25, 0x2f,
+	0xed, 0x6e, 0x6a, 0xa8, 0x1e, 0x05, 0x54, 0x7f, 0x0a, 0xc7, 0x95, 0xb2, 0x7a, 0x99, 0x9d, 0x16,
+	0x51, 0xf8, 0xa2, 0xed, 0x82, 0x64, 0x06, 0x84, 0x0b, 0xcd, 0xb2, 0x5a, 0xb1, 0xd7, 0x75, 0x55,
+	0xb2, 0x05, 0xee, 0x73, 0xd7, 0xae, 0x7b, 0x6e, 0x76, 0x16, 0x4d, 0x77, 0x6f, 0xd1, 0xd8, 0x1d,
+	0xaa, 0x64, 0xa1, 0xd2, 0xf5, 0x8f, 0xa9, 0xbe, 0xc1, 0xb2, 0x0e, 0x69, 0x08, 0xdd, 0xb7, 0x43,
+	0xfb, 0x0f, 0xde, 0xa1, 0x63, 0x88, 0x55, 0x2d, 0xec, 0x02, 0x3b, 0x57, 0x85, 0x5d, 0xc2, 0xb8,
+	0xaa, 0x03, 0xa8, 0x25, 0xf6, 0x20, 0x20, 0xb6, 0x2d, 0x48, 0xb1, 0x9a, 0x8b, 0x8b, 0xed, 0xa0,
+	0x0c, 0x31, 0xcb, 0x5d, 0x10, 0x93, 0x28, 0xd3, 0x0d, 0x53, 0xae, 0x12, 0x80, 0x3a, 0x21, 0x84,
+	0x25, 0x40, 0xf1, 0xea, 0x12, 0xb7, 0x69, 0x8f, 0x6e, 0x65, 0xb2, 0x80, 0xc1, 0x2d, 0x53, 0x1a,
+	0x33, 0x3b, 0xc2, 0xae, 0x7e, 0xda, 0x66, 0xb6, 0xd7, 0xb5, 0xd9, 0x1b, 0xaf, 0xe9, 0x16, 0xfd,
+	0xf6, 0xe1, 0xe8, 0x1b, 0x38, 0xde, 0xb9, 0x7a, 0xd0, 0x32, 0x1a, 0x43, 0x1f, 0x37, 0x25, 0x12,
+	0x1c, 0xe1, 0xe6, 0x8b, 0xeb, 0xa5, 0xc9, 0xef, 0x5d, 0xe8, 0x53, 0xa6, 0xeb, 0x12, 0x53, 0x51,
+	0x4c, 0x57, 0x52, 0x68, 0xe6, 0x95, 0xb6, 0x32, 0xf9, 0x12, 0x0e, 0xdf, 0xb9, 0xe9, 0x47, 0x27,
+	0xf1, 0xfc, 0xa3, 0x36, 0x93, 0x3b, 0x0b, 0x82, 0x36, 0xba, 0xed, 0xf8, 0x74, 0x82, 0xf1, 0x21,
+	0xcf, 0x77, 0xbf, 0x31, 0xdd, 0x71, 0xb4, 0xfb, 0x07, 0x24, 0x18, 0xad, 0xdd, 0x4f, 0x8f, 0x6d,
+	0xa5, 0x54, 0xc6, 0x0e, 0x69, 0x07, 0x5b, 0x69, 0x05, 0xf2, 0x1c, 0x0e, 0xdd, 0x28, 0x69, 0xfc,
+	0x52, 0xc7, 0xf3, 0x8f, 0xc3, 0x2a, 0xdb, 0xd4, 0xfc, 0x57, 0xde, 0xd7, 0xb6, 0xd1, 0xb6, 0x0f,
+	0xdd, 0xd2, 0x70, 0xbc, 0xb9, 0xef, 0xe1, 0xaf, 0xee, 0xde, 0x3f, 0xf4, 0xda, 0x96, 0xb9, 0x6a,
+	0xb7, 0x7d, 0xc9, 0x60, 0x9f, 0xb9, 0x7b, 0xfd, 0xa5, 0xfb, 0x2f, 0x46, 0xd7, 0xcd, 0xbf, 0xb4,
+	0xff, 0xec, 0xeb, 0x27, 0x61, 0x5f, 0xe3, 0xf9, 0x69, 0x6b, 0xdc, 0x35, 0x35, 0xe8, 0xf4, 0xe8,
+	0x25, 0x1c, 0x85, 0xb1, 0x3e, 0x84, 0x25, 0xcb, 0x3e, 0xda, 0xfd, 0xe2, 0xdf, 0x01, 0x00, 0xe6,
+	0xc8, 0xb2, 0x46, 0x74, 0x0a, 0x00, 0x00,
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/carbynestack/ephemeral/pkg/castor"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// MascotProtocol is the name of the maliciously secure protocol executed with Player-Online.x on the tuples provided
+// by Castor. It is the only protocol supported if none are configured.
+const MascotProtocol = "mascot"
+
+// DefaultProtocols returns the protocols supported if none are configured.
+func DefaultProtocols() map[string]ProtocolConfig {
+	var families []string
+	for _, tt := range castor.SupportedTupleTypes {
+		if !contains(families, tt.PreprocessingName) {
+			families = append(families, tt.PreprocessingName)
+		}
+	}
+	return map[string]ProtocolConfig{
+		MascotProtocol: {
+			Executable:    "./Player-Online.x",
+			TupleFamilies: families,
+		},
+	}
+}
+
+// ValidateProtocol verifies that the protocol names an executable and only requires tuple families provided by
+// Castor.
+func ValidateProtocol(name string, p ProtocolConfig) error {
+	if p.Executable == "" {
+		return fmt.Errorf("protocol %s does not define an executable", name)
+	}
+	for _, f := range p.TupleFamilies {
+		if len(tupleTypesOf([]string{f}, castor.SupportedTupleTypes)) == 0 {
+			return fmt.Errorf("protocol %s requires the unsupported tuple family %s", name, f)
+		}
+	}
+	return nil
+}
+
+// protocolNames returns the sorted names of the given protocols.
+func protocolNames(protocols map[string]ProtocolConfig) []string {
+	names := make([]string, 0, len(protocols))
+	for name := range protocols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveProtocol returns the name and declaration of the protocol selected by the activation, or of the default
+// protocol if the activation does not select one.
+func resolveProtocol(act *Activation, conf *SPDZEngineTypedConfig) (string, ProtocolConfig, error) {
+	protocols, name := DefaultProtocols(), MascotProtocol
+	if conf != nil && len(conf.Protocols) > 0 {
+		protocols, name = conf.Protocols, conf.DefaultProtocol
+	}
+	if act.Protocol != "" {
+		name = act.Protocol
+	}
+	p, ok := protocols[name]
+	if !ok {
+		return "", ProtocolConfig{}, fmt.Errorf("unsupported protocol %s, supported are %v", name, protocolNames(protocols))
+	}
+	return name, p, nil
+}
+
+// tupleTypesOf returns the tuple types of the given families among the given types.
+func tupleTypesOf(families []string, supported []castor.TupleType) []castor.TupleType {
+	var types []castor.TupleType
+	for _, tt := range supported {
+		if contains(families, tt.PreprocessingName) {
+			types = append(types, tt)
+		}
+	}
+	return types
+}
+
+// contains returns true if the given strings contain s.
+func contains(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// The reasons why no proxy entry is created for a player.
+const (
+	SkippedSelf        = "self"
+	SkippedMissingPort = "missing port"
+	SkippedDuplicateID = "duplicate id"
+)
+
+// SkippedPlayer is a player no proxy entry is created for.
+type SkippedPlayer struct {
+	// ID is the SPDZ id of the player.
+	ID     int32  `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// ProxyEntriesDiagnosis is the outcome of computing the proxy entries for the players of a game.
+type ProxyEntriesDiagnosis struct {
+	// Entries are ordered by the id of the players.
+	Entries []*ProxyConfig  `json:"proxyEntries"`
+	Skipped []SkippedPlayer `json:"skipped"`
+}
+
+// complete returns whether an entry has been created for every player except this one.
+func (d *ProxyEntriesDiagnosis) complete() bool {
+	self := 0
+	for _, s := range d.Skipped {
+		if s.Reason != SkippedSelf {
+			return false
+		}
+		self++
+	}
+	return self == 1
+}
+
+// ProxyEntriesError is returned if the proxy entries could not be created for all other players. It is reported to the
+// client as is, so that the skipped players can be told apart.
+type ProxyEntriesError struct {
+	Diagnosis *ProxyEntriesDiagnosis
+}
+
+// Error lists the skipped players along with the reasons.
+func (e *ProxyEntriesError) Error() string {
+	skipped := make([]string, len(e.Diagnosis.Skipped))
+	for i, s := range e.Diagnosis.Skipped {
+		skipped[i] = fmt.Sprintf("%d (%s)", s.ID, s.Reason)
+	}
+	return fmt.Sprintf("could not get all ProxyEntries, skipped players: %s", strings.Join(skipped, ", "))
+}
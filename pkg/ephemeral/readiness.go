@@ -0,0 +1,118 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/depcheck"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// ReadinessDetail is the readiness of the service served on requests with the detail query parameter set.
+type ReadinessDetail struct {
+	Ready bool `json:"ready"`
+	// Reason is why the service is not ready. Empty if it is ready.
+	Reason string `json:"reason,omitempty"`
+	// Dependencies is the last report of the dependency checks without the configuration summary, as the readiness is
+	// served without authorization. Nil if the dependencies are not checked.
+	Dependencies *depcheck.Report `json:"dependencies,omitempty"`
+}
+
+// ReadinessHandler serves the readiness probe of the pod. The service reports not to be ready while it is draining,
+// while a program is compiled if configured, and while the maximum number of games is in flight, so that the serving
+// layer, e.g., the Knative activator, routes new activations to other pods instead of queueing them here. With the
+// detail query parameter set, the readiness is served as JSON along with the report of the dependency checks.
+func (s *Server) ReadinessHandler(writer http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		msg := "GET requests must be used to probe the readiness"
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return
+	}
+	readiness := s.readiness()
+	if _, ok := req.URL.Query()["detail"]; ok {
+		s.serveReadinessDetail(writer, readiness)
+		return
+	}
+	if reason := s.notReadyReason(readiness); reason != "" {
+		setRetryAfter(writer.Header(), readiness.RetryAfter)
+		writer.WriteHeader(http.StatusServiceUnavailable)
+		writer.Write([]byte(reason))
+		s.logger.Debugw("Reporting not to be ready", "Reason", reason)
+		return
+	}
+	writer.WriteHeader(http.StatusOK)
+	writer.Write([]byte("ready"))
+}
+
+// serveReadinessDetail serves the readiness along with the report of the dependency checks as JSON.
+func (s *Server) serveReadinessDetail(writer http.ResponseWriter, readiness ReadinessTypedConfig) {
+	reason := s.notReadyReason(readiness)
+	detail := ReadinessDetail{Ready: reason == "", Reason: reason}
+	if s.dependencies != nil {
+		report := *s.dependencies.Last()
+		report.Config = nil
+		detail.Dependencies = &report
+	}
+	body, err := json.Marshal(detail)
+	if err != nil {
+		msg := fmt.Sprintf("error encoding the readiness: %s", err)
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return
+	}
+	writer.Header().Set("Content-Type", ContentTypeJSON)
+	if reason != "" {
+		setRetryAfter(writer.Header(), readiness.RetryAfter)
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		writer.WriteHeader(http.StatusOK)
+	}
+	writer.Write(body)
+}
+
+// CheckDependencies sets the checker whose last report is served along with the readiness.
+func (s *Server) CheckDependencies(checker *depcheck.Checker) {
+	s.dependencies = checker
+}
+
+// notReadyReason returns why the service does not accept new activations, or an empty string if it is ready.
+func (s *Server) notReadyReason(readiness ReadinessTypedConfig) string {
+	if s.lifecycle.Draining() {
+		return errDraining.Error()
+	}
+	if readiness.NotReadyWhileCompiling && s.lifecycle.Compiling() > 0 {
+		return "the service is compiling a program"
+	}
+	if readiness.MaxGames > 0 && s.lifecycle.Active() >= readiness.MaxGames {
+		return fmt.Sprintf("%s, %d games are in flight", errBusy, s.lifecycle.Active())
+	}
+	return ""
+}
+
+// readiness returns the readiness config of the server.
+func (s *Server) readiness() ReadinessTypedConfig {
+	if s.config == nil {
+		return ReadinessTypedConfig{}
+	}
+	return s.config.Readiness
+}
+
+// setRetryAfter asks the client to retry a rejected request after the given duration, in whole seconds. The header is
+// omitted if no duration is given.
+func setRetryAfter(header http.Header, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	header.Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/depcheck"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Readiness", func() {
+	var (
+		s      *Server
+		config *SPDZEngineTypedConfig
+		rr     *httptest.ResponseRecorder
+	)
+	probe := func() {
+		rr = httptest.NewRecorder()
+		s.ReadinessHandler(rr, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	}
+
+	BeforeEach(func() {
+		config = &SPDZEngineTypedConfig{Readiness: ReadinessTypedConfig{RetryAfter: 1500 * time.Millisecond}}
+		s = NewServer("sub", nil, nil, nil, zap.NewNop().Sugar(), config)
+	})
+
+	It("is ready while idle", func() {
+		probe()
+		Expect(rr.Code).To(Equal(http.StatusOK))
+	})
+	It("is not ready while draining", func() {
+		s.Drain()
+		probe()
+		Expect(rr.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(rr.Header().Get("Retry-After")).To(Equal("2"))
+	})
+	It("is not ready while compiling if configured", func() {
+		s.lifecycle.BeginCompilation()
+		probe()
+		Expect(rr.Code).To(Equal(http.StatusOK))
+		config.Readiness.NotReadyWhileCompiling = true
+		probe()
+		Expect(rr.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(rr.Body.String()).To(Equal("the service is compiling a program"))
+		s.lifecycle.EndCompilation()
+		probe()
+		Expect(rr.Code).To(Equal(http.StatusOK))
+	})
+	Context("when the number of games is limited", func() {
+		BeforeEach(func() {
+			config.Readiness.MaxGames = 1
+		})
+		It("is not ready while the maximum number of games is in flight", func() {
+			Expect(s.lifecycle.Admit(1)).To(Succeed())
+			probe()
+			Expect(rr.Code).To(Equal(http.StatusServiceUnavailable))
+			s.lifecycle.End()
+			probe()
+			Expect(rr.Code).To(Equal(http.StatusOK))
+		})
+		It("rejects further activations with a retry after header", func() {
+			Expect(s.lifecycle.Admit(1)).To(Succeed())
+			rr = httptest.NewRecorder()
+			handler := http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+				writer.WriteHeader(http.StatusOK)
+			})
+			s.DrainFilter(handler).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", nil))
+			Expect(rr.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(rr.Header().Get("Retry-After")).To(Equal("2"))
+			Expect(rr.Body.String()).To(Equal("the service is busy with other games"))
+		})
+	})
+	It("serves the readiness along with the dependency report on request", func() {
+		s.CheckDependencies(depcheck.NewChecker("ephemeral", config, []depcheck.Check{
+			{Name: "castor", Run: func() error { return errors.New("unreachable") }},
+		}))
+		s.Drain()
+		rr = httptest.NewRecorder()
+		s.ReadinessHandler(rr, httptest.NewRequest(http.MethodGet, "/ready?detail", nil))
+		Expect(rr.Code).To(Equal(http.StatusServiceUnavailable))
+		var detail ReadinessDetail
+		Expect(json.Unmarshal(rr.Body.Bytes(), &detail)).To(Succeed())
+		Expect(detail.Ready).To(BeFalse())
+		Expect(detail.Reason).NotTo(BeEmpty())
+		Expect(detail.Dependencies.OK).To(BeFalse())
+		Expect(detail.Dependencies.Failed()[0].Name).To(Equal("castor"))
+		Expect(detail.Dependencies.Config).To(BeNil())
+	})
+	It("accepts only GET requests", func() {
+		rr = httptest.NewRecorder()
+		s.ReadinessHandler(rr, httptest.NewRequest(http.MethodPost, "/ready", nil))
+		Expect(rr.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
@@ -0,0 +1,87 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime"
+
+	"github.com/carbynestack/ephemeral/pkg/allowlist"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// Version is the version of ephemeral reported along with the results of the games. It is set at build time, e.g.,
+// with -ldflags "-X github.com/carbynestack/ephemeral/pkg/ephemeral.Version=v0.5.0".
+var Version = "dev"
+
+// maxSeedLength is the size of the seeds of MP-SPDZ's pseudo random generator in bytes.
+const maxSeedLength = 16
+
+// seedPattern restricts the seeds to characters which are passed on to MP-SPDZ as is.
+var seedPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// validateSeed verifies that the seed of the given activation, if any, can be applied, i.e., that insecure
+// preprocessing is enabled and the seed is valid.
+func validateSeed(act *Activation, conf *SPDZEngineTypedConfig) error {
+	if act.Seed == "" {
+		return nil
+	}
+	if conf == nil || conf.InsecurePreprocessing == nil {
+		return errors.New("a seed can only be pinned if insecure preprocessing is enabled")
+	}
+	if len(act.Seed) > maxSeedLength || !seedPattern.MatchString(act.Seed) {
+		return fmt.Errorf("the seed must consist of at most %d letters, digits, dashes and underscores", maxSeedLength)
+	}
+	return nil
+}
+
+// preprocessingSource returns where the preprocessing data of the given game comes from, i.e., PreprocessingCastor,
+// PreprocessingInsecure as agreed on by the players or PreprocessingNone.
+func (s *SPDZEngine) preprocessingSource(ctx *CtxConfig) (string, error) {
+	_, protocol, err := resolveProtocol(ctx.Act, s.config)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case len(tupleTypesOf(protocol.TupleFamilies, supportedTupleTypes(s.config))) == 0:
+		return PreprocessingNone, nil
+	case ctx.InsecurePreprocessing:
+		return PreprocessingInsecure, nil
+	default:
+		return PreprocessingCastor, nil
+	}
+}
+
+// newReproducibility returns the parameters of the given game affecting its outcome or performance. The source of the
+// preprocessing data of the game must have been decided already.
+func newReproducibility(ctx *CtxConfig, conf *SPDZEngineTypedConfig) *Reproducibility {
+	name, protocol, _ := resolveProtocol(ctx.Act, conf)
+	r := &Reproducibility{
+		Preprocessing:   ctx.Preprocessing,
+		Protocol:        name,
+		CompilerOptions: ctx.Act.CompilerOptions,
+		RuntimeArgs:     append(append([]string{}, protocol.Flags...), conf.MPSPDZ.ExtraArgs...),
+		Prime:           conf.Prime.String(),
+		PlayerCount:     ctx.Spdz.PlayerCount,
+		PlayerID:        conf.PlayerID,
+		Versions:        map[string]string{"ephemeral": Version, "go": runtime.Version()},
+	}
+	if ctx.Act.Code != "" {
+		r.ProgramHash = allowlist.Hash(ctx.Act.Code)
+	}
+	if !conf.Gf2nDisabled {
+		r.Gf2nBitLength = conf.Gf2nBitLength
+	}
+	if ctx.Preprocessing == PreprocessingInsecure {
+		r.Seed = ctx.Act.Seed
+		r.InsecureTupleCount = conf.InsecurePreprocessing.TupleCount
+	}
+	if conf.MPSPDZ.Version != "" {
+		r.Versions["mpSpdz"] = conf.MPSPDZ.Version
+	}
+	return r
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/carbynestack/ephemeral/pkg/allowlist"
+	"github.com/carbynestack/ephemeral/pkg/castor"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Reproducibility", func() {
+	var conf *SPDZEngineTypedConfig
+	BeforeEach(func() {
+		conf = &SPDZEngineTypedConfig{
+			Prime:                 *big.NewInt(17),
+			Gf2nBitLength:         40,
+			PlayerID:              1,
+			InsecurePreprocessing: &InsecurePreprocessingConfig{Enabled: true, TupleCount: 100},
+		}
+	})
+	Context("when validating the seed", func() {
+		It("accepts activations without a seed", func() {
+			Expect(validateSeed(&Activation{}, &SPDZEngineTypedConfig{})).To(Succeed())
+		})
+		It("accepts letters, digits, dashes and underscores", func() {
+			Expect(validateSeed(&Activation{Seed: "benchmark_1-a"}, conf)).To(Succeed())
+		})
+		It("rejects seeds if insecure preprocessing is disabled", func() {
+			conf.InsecurePreprocessing = nil
+			Expect(validateSeed(&Activation{Seed: "benchmark"}, conf)).To(MatchError("a seed can only be pinned if insecure preprocessing is enabled"))
+		})
+		It("rejects seeds which are too long or contain other characters", func() {
+			for _, seed := range []string{"a-seed-with-17-ch", "seed; rm -rf", "seed 1"} {
+				Expect(validateSeed(&Activation{Seed: seed}, conf)).To(HaveOccurred(), seed)
+			}
+		})
+	})
+	Context("when deciding on the source of the preprocessing data", func() {
+		var s *SPDZEngine
+		BeforeEach(func() {
+			s = &SPDZEngine{config: conf}
+		})
+		It("generates fake preprocessing data if the players agreed on it", func() {
+			Expect(s.preprocessingSource(&CtxConfig{Act: &Activation{}, InsecurePreprocessing: true})).To(Equal(PreprocessingInsecure))
+		})
+		It("fetches the tuples from castor otherwise", func() {
+			Expect(s.preprocessingSource(&CtxConfig{Act: &Activation{}})).To(Equal(PreprocessingCastor))
+		})
+		It("uses no preprocessing data if the protocol generates it itself", func() {
+			conf.Protocols = map[string]ProtocolConfig{"semi": {Executable: "./Semi-Party.x"}}
+			conf.DefaultProtocol = "semi"
+			Expect(s.preprocessingSource(&CtxConfig{Act: &Activation{}})).To(Equal(PreprocessingNone))
+		})
+		It("fails for unsupported protocols", func() {
+			_, err := s.preprocessingSource(&CtxConfig{Act: &Activation{Protocol: "unknown"}})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("when collecting the parameters of a game", func() {
+		var ctx *CtxConfig
+		BeforeEach(func() {
+			conf.MPSPDZ.ExtraArgs = []string{"--batch-size", "1000"}
+			ctx = &CtxConfig{
+				Act:           &Activation{GameID: "game", Code: "print_ln('hello')", Seed: "benchmark-1"},
+				Spdz:          &SPDZEngineTypedConfig{PlayerCount: 2},
+				Preprocessing: PreprocessingInsecure,
+			}
+		})
+		It("includes the seed and the parameters of the computation", func() {
+			conf.MPSPDZ.Version = "0.3.8"
+			r := newReproducibility(ctx, conf)
+			Expect(r).To(Equal(&Reproducibility{
+				Seed:               "benchmark-1",
+				Preprocessing:      PreprocessingInsecure,
+				InsecureTupleCount: 100,
+				Protocol:           MascotProtocol,
+				ProgramHash:        allowlist.Hash("print_ln('hello')"),
+				RuntimeArgs:        []string{"--batch-size", "1000"},
+				Prime:              "17",
+				Gf2nBitLength:      40,
+				PlayerCount:        2,
+				PlayerID:           1,
+				Versions:           map[string]string{"ephemeral": Version, "go": runtime.Version(), "mpSpdz": "0.3.8"},
+			}))
+		})
+		It("omits the seed if the tuples are fetched from castor", func() {
+			ctx.Preprocessing = PreprocessingCastor
+			conf.Gf2nDisabled = true
+			r := newReproducibility(ctx, conf)
+			Expect(r.Seed).To(BeEmpty())
+			Expect(r.InsecureTupleCount).To(BeZero())
+			Expect(r.Gf2nBitLength).To(BeZero())
+			Expect(r.Versions).NotTo(HaveKey("mpSpdz"))
+		})
+	})
+	Context("when generating fake preprocessing data", func() {
+		var (
+			cmder *RecordingFakeExecutor
+			s     *SPDZEngine
+			ctx   *CtxConfig
+			dir   string
+		)
+		BeforeEach(func() {
+			dir, _ = ioutil.TempDir("", "ephemeral_")
+			conf.GfpMacKey = *big.NewInt(5)
+			conf.Gf2nMacKey = "0xb"
+			cmder = &RecordingFakeExecutor{}
+			s = &SPDZEngine{config: conf, cmder: cmder, baseDir: "/mp-spdz", playerDataPaths: map[castor.SPDZProtocol]string{
+				castor.SPDZGfp:  filepath.Join(dir, "2-p-5") + "/",
+				castor.SPDZGf2n: filepath.Join(dir, "2-2-40") + "/",
+			}}
+			for _, path := range s.playerDataPaths {
+				Expect(os.MkdirAll(path, 0755)).To(Succeed())
+			}
+			ctx = &CtxConfig{
+				Act:     &Activation{GameID: "71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4", Seed: "benchmark-1"},
+				Context: context.TODO(),
+				Spdz:    &SPDZEngineTypedConfig{PlayerCount: 2},
+			}
+		})
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+		It("passes the seed on to MP-SPDZ", func() {
+			Expect(s.generateInsecurePreprocessing(ctx, s.layout(ctx), zap.NewNop().Sugar())).To(Succeed())
+			Expect(cmder.Commands).To(Equal([][]string{{"./Fake-Offline.x 2 -lgp 5 -lg2 40 -P 17 --default 100 --seed benchmark-1"}}))
+		})
+		It("derives the seed from the game id if none is pinned", func() {
+			ctx.Act.Seed = ""
+			Expect(s.generateInsecurePreprocessing(ctx, s.layout(ctx), zap.NewNop().Sugar())).To(Succeed())
+			Expect(cmder.Commands).To(Equal([][]string{{"./Fake-Offline.x 2 -lgp 5 -lg2 40 -P 17 --default 100 --seed 71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4"}}))
+		})
+		It("writes fake MAC key shares of all players derived from the seed", func() {
+			readKeys := func() map[string]string {
+				keys := map[string]string{}
+				for _, file := range []string{"2-p-5/Player-MAC-Keys-p-P0", "2-p-5/Player-MAC-Keys-p-P1",
+					"2-2-40/Player-MAC-Keys-2-P0", "2-2-40/Player-MAC-Keys-2-P1"} {
+					data, err := ioutil.ReadFile(filepath.Join(dir, file))
+					Expect(err).NotTo(HaveOccurred())
+					keys[file] = string(data)
+				}
+				return keys
+			}
+			Expect(s.generateInsecurePreprocessing(ctx, s.layout(ctx), zap.NewNop().Sugar())).To(Succeed())
+			keys := readKeys()
+			Expect(keys["2-p-5/Player-MAC-Keys-p-P1"]).NotTo(Equal("2 5"))
+			Expect(keys["2-2-40/Player-MAC-Keys-2-P1"]).NotTo(Equal("2 0xb"))
+			Expect(keys["2-2-40/Player-MAC-Keys-2-P0"]).To(HavePrefix("2 0x"))
+			Expect(keys["2-p-5/Player-MAC-Keys-p-P0"]).NotTo(Equal(keys["2-p-5/Player-MAC-Keys-p-P1"]))
+			// The other players derive the same shares from the same seed, but games of other seeds get other ones.
+			Expect(s.generateInsecurePreprocessing(ctx, s.layout(ctx), zap.NewNop().Sugar())).To(Succeed())
+			Expect(readKeys()).To(Equal(keys))
+			ctx.Act.Seed = "benchmark-2"
+			Expect(s.generateInsecurePreprocessing(ctx, s.layout(ctx), zap.NewNop().Sugar())).To(Succeed())
+			Expect(readKeys()).NotTo(Equal(keys))
+		})
+		It("restores the MAC key shares of the player", func() {
+			conf.PlayerCount = 2
+			conf.PlayerID = 1
+			Expect(s.generateInsecurePreprocessing(ctx, s.layout(ctx), zap.NewNop().Sugar())).To(Succeed())
+			s.restoreMacKeys(s.layout(ctx), zap.NewNop().Sugar())
+			for file, content := range map[string]string{
+				"2-p-5/Player-MAC-Keys-p-P1":  "2 5",
+				"2-2-40/Player-MAC-Keys-2-P1": "2 0xb",
+			} {
+				data, err := ioutil.ReadFile(filepath.Join(dir, file))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(data)).To(Equal(content))
+			}
+		})
+	})
+})
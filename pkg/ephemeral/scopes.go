@@ -0,0 +1,130 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Scopes the endpoints of the service require if authorization scopes are configured.
+const (
+	// ScopeCompile allows to compile programs, either on /compile or as part of an activation.
+	ScopeCompile = "compile"
+	// ScopeExecute allows to activate the deployed program and to manage sessions, executions and the logs of the own
+	// games.
+	ScopeExecute = "execute"
+	// ScopeAdmin grants all other scopes, allows to tail the logs of the games of other users and to export the history
+	// of the executions.
+	ScopeAdmin = "admin"
+)
+
+// ScopeFilter rejects requests whose token does not grant the given scope. Requests asking to compile the program, as
+// in ?compile=true, additionally require the compile scope. All requests are forwarded if no authorization scopes are
+// configured.
+func (s *Server) ScopeFilter(scope string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if s.config.AuthScopes == nil {
+			next.ServeHTTP(writer, req)
+			return
+		}
+		granted, err := s.grantedScopes(req)
+		if err != nil {
+			msg := "unauthorized request"
+			writer.WriteHeader(http.StatusUnauthorized)
+			writer.Write([]byte(msg))
+			s.logger.Errorw(msg, "Error", err)
+			return
+		}
+		required := []string{scope}
+		if compile, _ := strconv.ParseBool(req.URL.Query().Get("compile")); compile && scope != ScopeCompile {
+			required = append(required, ScopeCompile)
+		}
+		for _, r := range required {
+			if !hasScope(granted, r) {
+				msg := fmt.Sprintf("forbidden, the %s scope is required", r)
+				writer.WriteHeader(http.StatusForbidden)
+				writer.Write([]byte(msg))
+				s.logger.Errorw(msg, "Path", req.URL.Path)
+				return
+			}
+		}
+		next.ServeHTTP(writer, req)
+	})
+}
+
+// isAdmin returns true if authorization scopes are configured and the token of the request grants the admin scope.
+func (s *Server) isAdmin(req *http.Request) bool {
+	if s.config.AuthScopes == nil {
+		return false
+	}
+	granted, err := s.grantedScopes(req)
+	return err == nil && hasScope(granted, ScopeAdmin)
+}
+
+// grantedScopes returns the scopes granted by the bearer token of the request.
+func (s *Server) grantedScopes(req *http.Request) ([]string, error) {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		return nil, fmt.Errorf("no token provided")
+	}
+	return GetScopesFromToken(token, s.config.AuthScopes.Claim)
+}
+
+// hasScope returns true if the granted scopes include the given scope or the admin scope.
+func hasScope(granted []string, scope string) bool {
+	for _, g := range granted {
+		if g == scope || g == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// GetScopesFromToken returns the scopes held by the given claim of the JWT, e.g., "scope" or "realm_access.roles". The
+// claim may be a space-separated string, as defined by RFC 8693, or a list of strings. No scopes are returned if the
+// claim is missing.
+func GetScopesFromToken(token string, claim string) ([]string, error) {
+	jwtParts := strings.Split(token, ".")
+	if len(jwtParts) != 3 {
+		return nil, fmt.Errorf("invalid JWT format")
+	}
+	jwt, err := base64.URLEncoding.WithPadding(base64.NoPadding).DecodeString(jwtParts[1])
+	if err != nil {
+		return nil, fmt.Errorf("error decoding JWT claims: %w", err)
+	}
+	var claims interface{}
+	if err := json.Unmarshal(jwt, &claims); err != nil {
+		return nil, fmt.Errorf("error unmarshalling JWT claims: %w", err)
+	}
+	for _, part := range strings.Split(claim, ".") {
+		claimsMap, ok := claims.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		claims = claimsMap[part]
+	}
+	switch value := claims.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return strings.Fields(value), nil
+	case []interface{}:
+		scopes := make([]string, 0, len(value))
+		for _, v := range value {
+			scope, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("claim %s must only contain strings", claim)
+			}
+			scopes = append(scopes, scope)
+		}
+		return scopes, nil
+	}
+	return nil, fmt.Errorf("claim %s must be a string or a list of strings", claim)
+}
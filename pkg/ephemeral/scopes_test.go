@@ -0,0 +1,91 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Scopes", func() {
+	token := func(claims string) string {
+		return fmt.Sprintf("header.%s.signature", base64.StdEncoding.WithPadding(base64.NoPadding).EncodeToString([]byte(claims)))
+	}
+
+	Context("when reading the scopes of a token", func() {
+		It("splits space-separated scopes", func() {
+			Expect(GetScopesFromToken(token(`{"scope":"compile execute"}`), "scope")).To(Equal([]string{"compile", "execute"}))
+		})
+		It("reads lists of scopes from nested claims", func() {
+			Expect(GetScopesFromToken(token(`{"realm_access":{"roles":["admin"]}}`), "realm_access.roles")).To(Equal([]string{"admin"}))
+		})
+		It("returns no scopes if the claim is missing", func() {
+			Expect(GetScopesFromToken(token(`{"sub":"someID"}`), "scope")).To(BeEmpty())
+		})
+		It("fails for claims of other types", func() {
+			_, err := GetScopesFromToken(token(`{"scope":42}`), "scope")
+			Expect(err).To(MatchError("claim scope must be a string or a list of strings"))
+		})
+	})
+
+	Context("when filtering requests", func() {
+		var (
+			s      *Server
+			rr     *httptest.ResponseRecorder
+			called bool
+			next   http.Handler
+		)
+		BeforeEach(func() {
+			s = NewServer("sub", nil, nil, nil, zap.NewNop().Sugar(), &SPDZEngineTypedConfig{AuthScopes: &AuthScopesConfig{Claim: "scope"}})
+			rr = httptest.NewRecorder()
+			called = false
+			next = http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				called = true
+			})
+		})
+		request := func(path string, claims string) *http.Request {
+			req, _ := http.NewRequest(http.MethodPost, path, nil)
+			if claims != "" {
+				req.Header.Set("Authorization", "Bearer "+token(claims))
+			}
+			return req
+		}
+		It("forwards requests granted the required scope", func() {
+			s.ScopeFilter(ScopeExecute, next).ServeHTTP(rr, request("/", `{"scope":"execute"}`))
+			Expect(called).To(BeTrue())
+		})
+		It("rejects requests lacking the required scope", func() {
+			s.ScopeFilter(ScopeExecute, next).ServeHTTP(rr, request("/", `{"scope":"compile"}`))
+			Expect(called).To(BeFalse())
+			Expect(rr.Code).To(Equal(http.StatusForbidden))
+			Expect(rr.Body.String()).To(Equal("forbidden, the execute scope is required"))
+		})
+		It("requires the compile scope for activations compiling the program", func() {
+			s.ScopeFilter(ScopeExecute, next).ServeHTTP(rr, request("/?compile=true", `{"scope":"execute"}`))
+			Expect(called).To(BeFalse())
+			Expect(rr.Body.String()).To(Equal("forbidden, the compile scope is required"))
+		})
+		It("grants all scopes to admins", func() {
+			s.ScopeFilter(ScopeCompile, next).ServeHTTP(rr, request("/?compile=true", `{"scope":"admin"}`))
+			Expect(called).To(BeTrue())
+		})
+		It("rejects requests without a token", func() {
+			s.ScopeFilter(ScopeCompile, next).ServeHTTP(rr, request("/compile", ""))
+			Expect(rr.Code).To(Equal(http.StatusUnauthorized))
+		})
+		It("forwards all requests if no scopes are configured", func() {
+			s.config.AuthScopes = nil
+			s.ScopeFilter(ScopeCompile, next).ServeHTTP(rr, request("/compile", ""))
+			Expect(called).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,326 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/carbynestack/ephemeral/pkg/castor"
+	d "github.com/carbynestack/ephemeral/pkg/discovery"
+	c "github.com/carbynestack/ephemeral/pkg/discovery/transport/client"
+	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
+	ts "github.com/carbynestack/ephemeral/pkg/discovery/transport/server"
+	. "github.com/carbynestack/ephemeral/pkg/ephemeral/io"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+	. "github.com/carbynestack/ephemeral/pkg/utils"
+	"github.com/google/uuid"
+	mb "github.com/vardius/message-bus"
+	"go.uber.org/zap"
+)
+
+const (
+	selfTestPlayerCount = 2
+	// selfTestPortOffset shifts the ports the players of the self-test listen on, so that they do not conflict with a
+	// game the server is running at the same time.
+	selfTestPortOffset = int32(1000)
+	// selfTestProxyBasePort is the first port of the proxy port ranges of the players.
+	selfTestProxyBasePort = 12000
+	selfTestTupleStock    = 100
+	selfTestAuthField     = "sub"
+)
+
+// selfTestFactors are the secrets multiplied by the self-test.
+var selfTestFactors = []int64{6, 7}
+
+// selfTestProgram multiplies the two secrets it receives, which consumes a multiplication triple.
+var selfTestProgram = fmt.Sprintf(`listen(%[1]d)
+client_socket_id = regint()
+acceptclientconnection(client_socket_id, %[1]d)
+v = sint.read_from_socket(client_socket_id, 2)
+resp = Array(1, sint)
+resp[0] = v[0] * v[1]
+sint.write_to_socket(client_socket_id, resp)
+`, basePort+selfTestPortOffset)
+
+// RunSelfTest compiles and runs a game multiplying two secrets between two players in this process. The players share
+// an MP-SPDZ workspace which is separate from the one of the server, an in-process discovery service and tuples
+// generated by a dealer in place of castor. An error is returned if the game fails or its result is wrong.
+func RunSelfTest(conf *SPDZEngineTypedConfig, logger *zap.SugaredLogger) error {
+	workspace, err := newSelfTestWorkspace(mpSpdzConfig(conf).BaseDir)
+	if err != nil {
+		return fmt.Errorf("error creating the workspace: %v", err)
+	}
+	defer os.RemoveAll(workspace)
+	discoveryPort, stopDiscovery, err := startSelfTestDiscovery(conf, logger)
+	if err != nil {
+		return fmt.Errorf("error starting the discovery service: %v", err)
+	}
+	defer stopDiscovery()
+	macKeys := make([]big.Int, selfTestPlayerCount)
+	for i := range macKeys {
+		key, err := rand.Int(rand.Reader, &conf.Prime)
+		if err != nil {
+			return err
+		}
+		macKeys[i] = *key
+	}
+	dealer, err := castor.NewDealer(conf.Prime, conf.RInv, macKeys, conf.Gf2nStorageSize)
+	if err != nil {
+		return err
+	}
+	params := make([][]string, selfTestPlayerCount)
+	expected := big.NewInt(1)
+	for _, f := range selfTestFactors {
+		shares, err := dealer.Share(big.NewInt(f))
+		if err != nil {
+			return err
+		}
+		for i := range params {
+			param, err := encodeSelfTestShare(shares[i])
+			if err != nil {
+				return err
+			}
+			params[i] = append(params[i], param)
+		}
+		expected.Mul(expected, big.NewInt(f))
+	}
+	gameID := uuid.New().String()
+	results := make([]castor.Share, selfTestPlayerCount)
+	errs := make([]error, selfTestPlayerCount)
+	wg := sync.WaitGroup{}
+	for i := 0; i < selfTestPlayerCount; i++ {
+		handler, err := newSelfTestPlayer(conf, int32(i), macKeys[i], dealer, workspace, discoveryPort, logger)
+		if err != nil {
+			return fmt.Errorf("error creating player %d: %v", i, err)
+		}
+		act := &Activation{
+			GameID:       gameID,
+			Code:         selfTestProgram,
+			SecretParams: params[i],
+			Output:       OutputConfig{Type: SecretShare},
+		}
+		// The players share the workspace, hence the program is compiled by the first player only. The other players
+		// do not start the computation before the first player has joined the game.
+		compile := i == 0
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = activateSelfTestPlayer(handler, act, compile)
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("player %d failed: %v", i, err)
+		}
+	}
+	result, err := dealer.Open(results)
+	if err != nil {
+		return fmt.Errorf("error opening the result: %v", err)
+	}
+	if result.Cmp(expected) != 0 {
+		return fmt.Errorf("expected the result %s, got %s", expected, result)
+	}
+	return nil
+}
+
+// newSelfTestWorkspace creates a working directory for MP-SPDZ linking to the installation in the given directory. The
+// workspace has its own programs and preprocessing data, so that the self-test does not interfere with the games of
+// the server.
+func newSelfTestWorkspace(dir string) (string, error) {
+	workspace, err := ioutil.TempDir("", "ephemeral-self-test-")
+	if err != nil {
+		return "", err
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		os.RemoveAll(workspace)
+		return "", err
+	}
+	for _, e := range entries {
+		switch e.Name() {
+		case "Programs", "Player-Data", filepath.Base(ipFile):
+			continue
+		}
+		if err := os.Symlink(filepath.Join(dir, e.Name()), filepath.Join(workspace, e.Name())); err != nil {
+			os.RemoveAll(workspace)
+			return "", err
+		}
+	}
+	for _, p := range []string{"Programs/Source", "Programs/Schedules", "Programs/Bytecode", "Player-Data"} {
+		if err := os.MkdirAll(filepath.Join(workspace, p), 0755); err != nil {
+			os.RemoveAll(workspace)
+			return "", err
+		}
+	}
+	return workspace, nil
+}
+
+// startSelfTestDiscovery starts a discovery service on a free local port. It returns the port and a function stopping
+// the service.
+func startSelfTestDiscovery(conf *SPDZEngineTypedConfig, logger *zap.SugaredLogger) (string, func(), error) {
+	lis, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return "", nil, err
+	}
+	port := strconv.Itoa(lis.Addr().(*net.TCPAddr).Port)
+	lis.Close()
+	bus := mb.New(defaultBusSize)
+	tr, err := ts.NewTransportServer(&ts.TransportConfig{
+		In:     make(chan *pb.Event),
+		Out:    make(chan *pb.Event),
+		ErrCh:  make(chan error),
+		Port:   port,
+		Logger: logger,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	n := &loopbackNetworker{portOffset: selfTestPortOffset}
+	s := d.NewServiceNG(bus, d.NewPublisher(bus), conf.StateTimeout, conf.ComputationTimeout, tr, n, proxyAddress, logger, ModeMaster, &c.Client{}, selfTestPlayerCount, d.NewMemoryStateStore())
+	go func() {
+		if err := s.Start(); err != nil {
+			logger.Errorw("Discovery service failed", "Error", err)
+		}
+	}()
+	if err := s.WaitUntilReady(conf.StateTimeout); err != nil {
+		s.Stop()
+		return "", nil, err
+	}
+	return port, s.Stop, nil
+}
+
+// loopbackNetworker assigns each player the port its SPDZ runtime listens on, as all players share the local network.
+type loopbackNetworker struct {
+	portOffset int32
+}
+
+// CreateNetwork returns the port of the player.
+func (n *loopbackNetworker) CreateNetwork(pl *pb.Player) (int32, error) {
+	return d.BasePort + n.portOffset + d.PlayerIndex(pl), nil
+}
+
+// ReleaseNetwork does nothing, as no resources are created for the networks.
+func (n *loopbackNetworker) ReleaseNetwork(pod string) error {
+	return nil
+}
+
+// newSelfTestPlayer returns the handler chain of the given player. The player uses the public SPDZ parameters of the
+// configuration, but its own MAC key and the tuples of the dealer.
+func newSelfTestPlayer(base *SPDZEngineTypedConfig, id int32, macKey big.Int, dealer *castor.Dealer, workspace string, discoveryPort string, logger *zap.SugaredLogger) (http.Handler, error) {
+	conf := *base
+	conf.PlayerID = id
+	conf.PlayerCount = selfTestPlayerCount
+	conf.PartyNumbers = nil
+	conf.GfpMacKey = macKey
+	conf.CastorClient = dealer.Client(id)
+	conf.TupleStock = selfTestTupleStock
+	conf.PrepFolder = filepath.Join(workspace, "Player-Data")
+	conf.FrontendURL = proxyAddress
+	conf.DiscoveryConfig = DiscoveryClientTypedConfig{
+		Host:           proxyAddress,
+		Port:           discoveryPort,
+		ConnectTimeout: base.DiscoveryConfig.ConnectTimeout,
+	}
+	port := selfTestProxyBasePort + int(id)*selfTestPlayerCount
+	conf.ProxyPortRange = fmt.Sprintf("%d:%d", port, port+selfTestPlayerCount-1)
+	conf.CompileCacheSize = 0
+	conf.AcceptedContentTypes = nil
+	conf.OutputStreamBufferSize = 0
+	conf.Quota = nil
+	conf.Notifications = nil
+	conf.Tracer = nil
+	logger = logger.With("Player", id)
+	engine, err := NewSPDZEngine(logger, NewCommander(), &conf)
+	if err != nil {
+		return nil, err
+	}
+	engine.baseDir = workspace
+	engine.ipFile = filepath.Join(workspace, filepath.Base(ipFile))
+	engine.sourceCodePath = filepath.Join(workspace, "Programs/Source", appName+".mpc")
+	engine.schedulePath = filepath.Join(workspace, "Programs/Schedules", appName+".sch")
+	engine.portOffset = selfTestPortOffset
+	server := NewServer(selfTestAuthField, engine.Compile, engine.CompileWithReport, engine.Activate, logger, &conf)
+	server.pod = fmt.Sprintf("self-test-%d", id)
+	return server.MethodFilter(server.RequestFilter(server.CompilationHandler(http.HandlerFunc(server.ActivationHandler)))), nil
+}
+
+// activateSelfTestPlayer sends the activation to the handler of a player and returns the player's share of the result.
+func activateSelfTestPlayer(handler http.Handler, act *Activation, compile bool) (castor.Share, error) {
+	body, err := json.Marshal(act)
+	if err != nil {
+		return castor.Share{}, err
+	}
+	target := "/"
+	if compile {
+		target = "/?compile=true"
+	}
+	req := httptest.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	req.Header.Set("Content-Type", ContentTypeJSON)
+	req.Header.Set("Authorization", "Bearer "+selfTestToken())
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		return castor.Share{}, fmt.Errorf("activation responded with status code %d: %s", rec.Code, rec.Body.String())
+	}
+	var res Result
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		return castor.Share{}, fmt.Errorf("error decoding the result: %v", err)
+	}
+	if len(res.Response) != 1 {
+		return castor.Share{}, fmt.Errorf("expected a single result, got %d", len(res.Response))
+	}
+	return decodeSelfTestShare(res.Response[0])
+}
+
+// selfTestToken returns an unsigned JWT identifying the self-test as the user of the activations.
+func selfTestToken() string {
+	enc := base64.URLEncoding.WithPadding(base64.NoPadding)
+	header := enc.EncodeToString([]byte(`{"alg":"none"}`))
+	claims := enc.EncodeToString([]byte(fmt.Sprintf(`{"%s":"self-test"}`, selfTestAuthField)))
+	return header + "." + claims + "."
+}
+
+// encodeSelfTestShare concatenates value and MAC of the share as expected for secret parameters.
+func encodeSelfTestShare(share castor.Share) (string, error) {
+	value, err := base64.StdEncoding.DecodeString(share.Value)
+	if err != nil {
+		return "", err
+	}
+	mac, err := base64.StdEncoding.DecodeString(share.Mac)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(append(value, mac...)), nil
+}
+
+// decodeSelfTestShare splits a secret shared result into value and MAC.
+func decodeSelfTestShare(b64 string) (castor.Share, error) {
+	data, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return castor.Share{}, err
+	}
+	if len(data) != BodySize {
+		return castor.Share{}, errors.New(ErrInvalidBodySize)
+	}
+	return castor.Share{
+		Value: base64.StdEncoding.EncodeToString(data[:BodySize/2]),
+		Mac:   base64.StdEncoding.EncodeToString(data[BodySize/2:]),
+	}, nil
+}
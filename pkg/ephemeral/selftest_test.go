@@ -0,0 +1,69 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/carbynestack/ephemeral/pkg/castor"
+	d "github.com/carbynestack/ephemeral/pkg/discovery"
+	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Self-test", func() {
+	Context("when creating the workspace", func() {
+		var (
+			installation string
+			workspace    string
+		)
+		BeforeEach(func() {
+			installation, _ = ioutil.TempDir("", "mp-spdz_")
+			Expect(ioutil.WriteFile(filepath.Join(installation, "Player-Online.x"), []byte("binary"), 0755)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(installation, "Programs/Source"), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(installation, "Programs/Source/mpc-program.mpc"), []byte("code"), 0644)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(installation, "Player-Data"), 0755)).To(Succeed())
+		})
+		AfterEach(func() {
+			_ = os.RemoveAll(installation)
+			_ = os.RemoveAll(workspace)
+		})
+		It("links the installation but keeps programs and preprocessing data separate", func() {
+			var err error
+			workspace, err = newSelfTestWorkspace(installation)
+			Expect(err).NotTo(HaveOccurred())
+			target, err := os.Readlink(filepath.Join(workspace, "Player-Online.x"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(target).To(Equal(filepath.Join(installation, "Player-Online.x")))
+			sources, err := ioutil.ReadDir(filepath.Join(workspace, "Programs/Source"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sources).To(BeEmpty())
+			info, err := os.Lstat(filepath.Join(workspace, "Player-Data"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.IsDir()).To(BeTrue())
+		})
+	})
+	It("assigns players the port their runtime listens on", func() {
+		n := &loopbackNetworker{portOffset: selfTestPortOffset}
+		port, err := n.CreateNetwork(&pb.Player{Id: 101})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(port).To(Equal(d.BasePort + selfTestPortOffset + 1))
+	})
+	It("encodes shares as secret parameters and back", func() {
+		share := castor.Share{Value: "AQAAAAAAAAAAAAAAAAAAAA==", Mac: "AgAAAAAAAAAAAAAAAAAAAA=="}
+		param, err := encodeSelfTestShare(share)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decodeSelfTestShare(param)).To(Equal(share))
+	})
+	It("authenticates as the self-test user", func() {
+		user, err := GetUserFromAuthHeader("Bearer "+selfTestToken(), selfTestAuthField)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(user).To(Equal("self-test"))
+	})
+})
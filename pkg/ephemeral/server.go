@@ -5,19 +5,28 @@
 package ephemeral
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/carbynestack/ephemeral/pkg/allowlist"
+	"github.com/carbynestack/ephemeral/pkg/audit"
+	"github.com/carbynestack/ephemeral/pkg/busmetrics"
+	"github.com/carbynestack/ephemeral/pkg/depcheck"
 	"github.com/carbynestack/ephemeral/pkg/discovery/fsm"
+	. "github.com/carbynestack/ephemeral/pkg/ephemeral/io"
+	"github.com/carbynestack/ephemeral/pkg/notify"
+	"github.com/carbynestack/ephemeral/pkg/tracing"
 	. "github.com/carbynestack/ephemeral/pkg/types"
-	. "github.com/carbynestack/ephemeral/pkg/utils"
-	"io/ioutil"
+	"math"
+	"math/big"
 	"mime"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -35,24 +44,74 @@ type contextConf string
 const paramsMsg = "either secret params or amphora secret share UUIDs must be specified, %s"
 
 var (
-	// The number of parallel games that could run per container.
-	parallelGames  = 1
+	// The number of games that could overlap per container, i.e., a game being set up while the previous ones are
+	// torn down. Each of them gets its own set of proxy ports from the default proxy port range. The MPC runtimes of a
+	// container do not run at the same time, as they listen on the same player and client ports.
+	parallelGames  = 4
 	defaultBusSize = 10000
 	ctxConf        = contextConf("contextConf")
+	// cancelOnDisconnect marks the requests whose activation is cancelled along with the request.
+	cancelOnDisconnect = contextConf("cancelOnDisconnect")
+	// discoveryHealth is shared by all discovery clients, so that unreachable endpoints are tried last by subsequent
+	// activations.
+	discoveryHealth = c.NewEndpointHealth(30 * time.Second)
+	// PlayerBusMetrics are the metrics of the message buses the players communicate over.
+	PlayerBusMetrics = busmetrics.New(busmetrics.DefaultStallThreshold)
+	// TupleStreamerMetrics are the statistics of the tuple streamers of all games per tuple type.
+	TupleStreamerMetrics = NewStreamerMetrics()
+)
+
+// discoveryEndpointHeader is the response header used to report the discovery endpoint an activation was served by.
+const discoveryEndpointHeader = "X-Discovery-Endpoint"
+
+// insecurePreprocessingHeader marks the responses of activations computed with fake preprocessing data.
+const insecurePreprocessingHeader = "X-Insecure-Preprocessing"
+
+// plaintextSimulationHeader marks the responses of games executed by the plaintext simulator.
+const plaintextSimulationHeader = "X-Plaintext-Simulation"
+
+// Response headers reporting the expected duration of an activation, based on the recent activations of its program.
+const (
+	expectedDurationP50Header = "X-Expected-Duration-P50"
+	expectedDurationP90Header = "X-Expected-Duration-P90"
+	expectedDurationP99Header = "X-Expected-Duration-P99"
+	// estimatedCompletionHeader is the time the result is expected to be available at, based on the median duration.
+	estimatedCompletionHeader = "X-Estimated-Completion"
+)
+
+// Response headers reporting the quotas of the requesting user.
+const (
+	quotaExecutionsLimitHeader     = "X-Quota-Executions-Limit"
+	quotaExecutionsRemainingHeader = "X-Quota-Executions-Remaining"
+	quotaTupleBytesLimitHeader     = "X-Quota-Tuple-Bytes-Limit"
+	quotaTupleBytesRemainingHeader = "X-Quota-Tuple-Bytes-Remaining"
 )
 
 // NewServer returns a new server.
 func NewServer(authUserIdField string,
 	compile func(*CtxConfig) error,
+	compileWithReport func(*CtxConfig) (*CompilationReport, error),
 	activate func(*CtxConfig) ([]byte, error), logger *zap.SugaredLogger, config *SPDZEngineTypedConfig) *Server {
+	games, abortGames := context.WithCancel(context.Background())
 	return &Server{
-		authUserIdField: authUserIdField,
-		player:          &PlayerWithIO{},
-		compile:         compile,
-		activate:        activate,
-		logger:          logger,
-		config:          config,
-		executor:        NewCommander(),
+		authUserIdField:   authUserIdField,
+		player:            &PlayerWithIO{},
+		compile:           compile,
+		compileWithReport: compileWithReport,
+		activate:          activate,
+		logger:            logger,
+		config:            config,
+		executions:        newExecutions(),
+		newSession:        newSession,
+		lifecycle:         NewLifecycle(),
+		sessions:          newComputationSessions(config.SessionIdleTimeout, func(string) {}),
+		estimates:         newDurationEstimates(),
+		gameLogs:          newGameLogs(),
+		gamesInFlight:     newGamesInFlight(),
+		policyInput:       DefaultPolicyInput,
+		castorAvailable:   castorAvailable(config),
+		games:             games,
+		abortGames:        abortGames,
 	}
 }
 
@@ -61,13 +120,110 @@ type Server struct {
 	authUserIdField string
 	player          AbstractPlayerWithIO
 	compile         func(*CtxConfig) error
-	activate        func(*CtxConfig) ([]byte, error)
-	logger          *zap.SugaredLogger
-	config          *SPDZEngineTypedConfig
-	respCh          chan []byte
-	errCh           chan error
-	execErrCh       chan error
-	executor        Executor
+	// compileWithReport compiles the program without activating a game and reports the compiler diagnostics.
+	compileWithReport func(*CtxConfig) (*CompilationReport, error)
+	activate          func(*CtxConfig) ([]byte, error)
+	logger            *zap.SugaredLogger
+	config            *SPDZEngineTypedConfig
+	// newSession allocates the session of an activation.
+	newSession func() *session
+	// pod overrides the name of the pod the server runs in if set.
+	pod string
+	// executions keeps the outcome of activations whose result is delivered after the response has been sent.
+	executions *executions
+	// lifecycle keeps track of the games in flight to postpone the termination of the pod.
+	lifecycle *Lifecycle
+	// observers are notified about the state transitions of the players.
+	observers []fsm.Observer
+	// sessions are the open computation sessions.
+	sessions *computationSessions
+	// onGameFinished is called with the id of each game once it has finished. May be nil.
+	onGameFinished func(gameID string)
+	// estimates are the durations of past activations the time budgets of new ones are checked against.
+	estimates *durationEstimates
+	// gameLogs keep the output of the MPC runtime of the recent games.
+	gameLogs *gameLogs
+	// gamesInFlight are the games executing on the pod by their ids.
+	gamesInFlight *gamesInFlight
+	// dependencies checks the dependencies of the service. Nil if they are not checked.
+	dependencies *depcheck.Checker
+	// policyInput builds the input of the admission policy.
+	policyInput PolicyInputBuilder
+	// castorAvailable reports whether castor is reachable. It decides whether the player proposes to generate fake
+	// preprocessing data if insecure preprocessing is configured as a fallback.
+	castorAvailable func() bool
+	// games is the context the contexts of the activations are derived from. It is cancelled by abortGames.
+	games      context.Context
+	abortGames context.CancelFunc
+}
+
+// Observe registers observers which are notified about the state transitions of the players of subsequent activations.
+// It must be called before the server handles requests.
+func (s *Server) Observe(observers ...fsm.Observer) {
+	s.observers = append(s.observers, observers...)
+}
+
+// OnSessionClosed registers a function which is called with the id of a computation session once it has been closed,
+// either on request or after its idle timeout. It must be called before the server handles requests.
+func (s *Server) OnSessionClosed(onClose func(id string)) {
+	s.sessions.onClose = onClose
+}
+
+// OnGameFinished registers a function which is called with the id of a game once it has finished, successfully or
+// not. It must be called before the server handles requests.
+func (s *Server) OnGameFinished(onFinished func(gameID string)) {
+	s.onGameFinished = onFinished
+}
+
+// DrainFilter rejects new activations once the server is draining or the maximum number of games is in flight, and
+// keeps track of the games in flight otherwise. Clients are asked to retry rejected activations later on.
+func (s *Server) DrainFilter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		readiness := s.readiness()
+		if err := s.lifecycle.Admit(readiness.MaxGames); err != nil {
+			msg := err.Error()
+			setRetryAfter(writer.Header(), readiness.RetryAfter)
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			writer.Write([]byte(msg))
+			s.logger.Warn(msg)
+			return
+		}
+		defer s.lifecycle.End()
+		next.ServeHTTP(writer, req)
+	})
+}
+
+// PreStop is called once the pod is terminated, e.g., when Knative scales the revision down. It stops accepting new
+// games and postpones the shutdown of the server until the games in flight have finished, at most for the configured
+// pre-stop timeout. Meanwhile, the results delivered in the background can still be fetched. The shutdown is not
+// postponed if no timeout is configured.
+func (s *Server) PreStop() {
+	s.lifecycle.Drain()
+	active := s.lifecycle.Active()
+	timeout := s.config.Snapshot().PreStopTimeout
+	s.logger.Infow("Draining before termination", "ActiveGames", active, "PreStopTimeout", timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := s.lifecycle.Wait(ctx); err != nil {
+		s.logger.Warnw("Terminating with games in flight", "ActiveGames", s.lifecycle.Active(), "Error", err)
+	}
+}
+
+// Drain stops accepting new games.
+func (s *Server) Drain() {
+	s.lifecycle.Drain()
+}
+
+// WithCancelOnDisconnect returns a context whose requests cancel their activation once the context is done, e.g., when
+// the caller of the gRPC API cancels the call. The activations of other requests continue if the client disconnects.
+func WithCancelOnDisconnect(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cancelOnDisconnect, true)
+}
+
+// AbortGames cancels the contexts of the running activations, e.g., once the drain timeout has expired on termination.
+// The activations are not cancelled if the client disconnects, as their results may be delivered in the background.
+func (s *Server) AbortGames() {
+	s.abortGames()
 }
 
 // MethodFilter assures that only HTTP POST requests are able to get through.
@@ -75,10 +231,10 @@ func (s *Server) MethodFilter(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
 		switch req.Method {
 		case "POST":
-			if s.hasContentType(req, "application/json") {
+			if _, ok := s.requestContentType(req); ok {
 				next.ServeHTTP(writer, req)
 			} else {
-				msg := "application/json content type must be provided"
+				msg := fmt.Sprintf("%s content type must be provided", strings.Join(s.acceptedContentTypes(), " or "))
 				writer.WriteHeader(http.StatusUnsupportedMediaType)
 				writer.Write([]byte(msg))
 				s.logger.Error(msg)
@@ -98,30 +254,8 @@ func (s *Server) MethodFilter(next http.Handler) http.Handler {
 func (s *Server) RequestFilter(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
 		var act Activation
-		authorizedUser, err := GetUserFromAuthHeader(req.Header.Get("Authorization"), s.authUserIdField)
-		if err != nil {
-			msg := "unauthorized request"
-			writer.WriteHeader(http.StatusUnauthorized)
-			writer.Write([]byte(msg))
-			s.logger.Errorw(msg, "Error", err)
-			return
-		}
-		if req.Body == nil {
-			msg := "request body is nil"
-			writer.WriteHeader(http.StatusBadRequest)
-			writer.Write([]byte(msg))
-			s.logger.Error(msg)
-			return
-		}
-		bodyBytes, _ := ioutil.ReadAll(req.Body)
-		req.Body.Close()
-		req.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
-		err = json.Unmarshal(bodyBytes, &act)
-		if err != nil {
-			msg := "error decoding the request body"
-			writer.WriteHeader(http.StatusBadRequest)
-			writer.Write([]byte(msg))
-			s.logger.Error(msg)
+		authorizedUser, ok := s.decodeRequest(writer, req, &act)
+		if !ok {
 			return
 		}
 		if !isValidUUID(act.GameID) {
@@ -145,6 +279,13 @@ func (s *Server) RequestFilter(next http.Handler) http.Handler {
 			s.logger.Error(msg)
 			return
 		}
+		if err := decodeSecretParams(&act, s.config); err != nil {
+			msg := fmt.Sprintf("error decoding secret parameters: %s", err)
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write([]byte(msg))
+			s.logger.Error(msg)
+			return
+		}
 		if len(act.SecretParams) > 0 {
 			for _, str := range act.SecretParams {
 				_, err := base64.StdEncoding.DecodeString(str)
@@ -157,11 +298,97 @@ func (s *Server) RequestFilter(next http.Handler) http.Handler {
 				}
 			}
 		}
-		con := context.Background()
+		if len(act.PodAffinity) > 0 && len(act.PodAffinity) != int(s.config.PlayerCount) {
+			msg := fmt.Sprintf("pod affinity must name a pod for each of the %d players", s.config.PlayerCount)
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write([]byte(msg))
+			s.logger.Error(msg)
+			return
+		}
+		if err := validateConnections(&act, s.config); err != nil {
+			msg := fmt.Sprintf("invalid connections: %s", err)
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write([]byte(msg))
+			s.logger.Error(msg)
+			return
+		}
+		if err := validateOutputSchema(&act.Output); err != nil {
+			msg := fmt.Sprintf("invalid result schema: %s", err)
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write([]byte(msg))
+			s.logger.Error(msg)
+			return
+		}
+		if err := validateLabels(act.Labels); err != nil {
+			msg := fmt.Sprintf("invalid labels: %s", err)
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write([]byte(msg))
+			s.logger.Error(msg)
+			return
+		}
+		if err := validateSeed(&act, s.config); err != nil {
+			msg := fmt.Sprintf("invalid seed: %s", err)
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write([]byte(msg))
+			s.logger.Error(msg)
+			return
+		}
+		if err := validateBackend(&act, s.config); err != nil {
+			msg := fmt.Sprintf("invalid backend: %s", err)
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write([]byte(msg))
+			s.logger.Error(msg)
+			return
+		}
+		if _, _, err := resolveProtocol(&act, s.config); err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write([]byte(err.Error()))
+			s.logger.Error(err.Error())
+			return
+		}
+		budget, err := timeBudget(&act, req)
+		if err != nil {
+			msg := fmt.Sprintf("invalid time budget: %s", err)
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write([]byte(msg))
+			s.logger.Error(msg)
+			return
+		}
+		// A game id reused while the first game is executing would collide with its topics, ports and work directory.
+		if err := s.gamesInFlight.begin(act.GameID, authorizedUser); err != nil {
+			writeGameInFlightError(writer, err)
+			s.logger.Errorw(err.Error(), GameID, act.GameID)
+			return
+		}
+		defer s.gamesInFlight.end(act.GameID)
+		if act.SessionID != "" {
+			if err := s.sessions.begin(&act, authorizedUser); err != nil {
+				msg := fmt.Sprintf("invalid session: %s", err)
+				writer.WriteHeader(http.StatusBadRequest)
+				writer.Write([]byte(msg))
+				s.logger.Error(msg)
+				return
+			}
+			defer s.sessions.end(act.SessionID)
+		}
+		con, cancel := context.WithCancel(s.games)
+		defer cancel()
+		if req.Context().Value(cancelOnDisconnect) == true {
+			go func() {
+				select {
+				case <-req.Context().Done():
+					cancel()
+				case <-con.Done():
+				}
+			}()
+		}
 		ctx := &CtxConfig{
 			AuthorizedUser: authorizedUser,
 			Act:            &act,
-			Spdz:           s.config,
+			Spdz:           s.config.Snapshot(),
+		}
+		if budget > 0 {
+			ctx.Deadline = time.Now().Add(budget)
 		}
 		con = context.WithValue(con, ctxConf, ctx)
 		r := req.Clone(con)
@@ -170,6 +397,82 @@ func (s *Server) RequestFilter(next http.Handler) http.Handler {
 	})
 }
 
+// decodeRequest authenticates the request and decodes the activation from its body. Returns the authorized user, or
+// false once the request has been answered with an error.
+func (s *Server) decodeRequest(writer http.ResponseWriter, req *http.Request, act *Activation) (string, bool) {
+	authorizedUser, err := GetUserFromAuthHeader(req.Header.Get("Authorization"), s.authUserIdField)
+	if err != nil {
+		msg := "unauthorized request"
+		writer.WriteHeader(http.StatusUnauthorized)
+		writer.Write([]byte(msg))
+		s.logger.Errorw(msg, "Error", err)
+		return "", false
+	}
+	if req.Body == nil {
+		msg := "request body is nil"
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return "", false
+	}
+	body := newLimitedBody(req, s.config.MaxRequestSize)
+	contentType, _ := s.requestContentType(req)
+	if contentType == ContentTypeMultipart {
+		err = decodeMultipartActivation(req.Header.Get("Content-Type"), body, act)
+	} else {
+		err = decodeActivation(contentType, body, act)
+	}
+	req.Body.Close()
+	if writeRequestTooLarge(writer, s.logger, body) {
+		return "", false
+	}
+	if err != nil {
+		msg := "error decoding the request body"
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return "", false
+	}
+	return authorizedUser, true
+}
+
+// QuotaFilter rejects requests of users who exceeded their quotas and reports the quotas in the response headers.
+func (s *Server) QuotaFilter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if s.config == nil || s.config.Quota == nil {
+			next.ServeHTTP(writer, req)
+			return
+		}
+		conf, ok := req.Context().Value(ctxConf).(*CtxConfig)
+		if !ok {
+			writer.WriteHeader(http.StatusBadRequest)
+			s.logger.Error("No context config provided")
+			return
+		}
+		status, err := s.config.Quota.Acquire(conf.AuthorizedUser)
+		if err != nil {
+			s.logger.Warnw("Failed to persist the quota usage", "Error", err)
+		}
+		if status.ExecutionsLimit > 0 {
+			writer.Header().Set(quotaExecutionsLimitHeader, strconv.Itoa(status.ExecutionsLimit))
+			writer.Header().Set(quotaExecutionsRemainingHeader, strconv.Itoa(status.ExecutionsRemaining))
+		}
+		if status.TupleBytesLimit > 0 {
+			writer.Header().Set(quotaTupleBytesLimitHeader, strconv.FormatInt(status.TupleBytesLimit, 10))
+			writer.Header().Set(quotaTupleBytesRemainingHeader, strconv.FormatInt(status.TupleBytesRemaining, 10))
+		}
+		if status.Rejected {
+			msg := fmt.Sprintf("quota exceeded for user %s", conf.AuthorizedUser)
+			writer.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(status.RetryAfter.Seconds()))))
+			writer.WriteHeader(http.StatusTooManyRequests)
+			writer.Write([]byte(msg))
+			s.logger.Errorw(msg, GameID, conf.Act.GameID)
+			return
+		}
+		next.ServeHTTP(writer, req)
+	})
+}
+
 func GetUserFromAuthHeader(header string, idField string) (string, error) {
 	token := strings.TrimPrefix(header, "Bearer ")
 	if token == "" {
@@ -216,13 +519,48 @@ func (s *Server) CompilationHandler(next http.Handler) http.Handler {
 			s.logger.Error("No context config provided")
 			return
 		}
-		s.logger.Debugf("Executing Compilation Handler: %v", conf.Act)
-		// These channels initialized here, because they must be unique
-		// for each incoming request.
-		s.respCh = make(chan []byte)
-		s.errCh = make(chan error, parallelGames)
-		s.execErrCh = make(chan error, parallelGames)
+		logger := s.activationLogger(conf)
+		logger.Debugf("Executing Compilation Handler: %v", conf.Act)
+		if err := validateCompilerOptions(conf.Act.CompilerOptions, conf.Spdz); err != nil {
+			msg := fmt.Sprintf("invalid compiler options: %s", err)
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write([]byte(msg))
+			logger.Errorw(msg, GameID, conf.Act.GameID)
+			return
+		}
+		// An ambiguous compile parameter is rejected below.
+		compileRequested, _ := strconv.ParseBool(req.URL.Query().Get("compile"))
+		if err := s.checkTimeBudget(conf, compileRequested); err != nil {
+			msg := err.Error()
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write([]byte(msg))
+			logger.Errorw(msg, GameID, conf.Act.GameID)
+			return
+		}
+		// The activation is traced from here on, as part of the trace of the caller if the request carries a trace
+		// context.
+		parent, _ := tracing.ParseTraceparent(req.Header.Get(tracing.TraceparentHeader))
+		ctx, span := s.config.Tracer.Start(req.Context(), "activation", parent)
+		span.SetAttribute(GameID, conf.Act.GameID)
+		for k, v := range conf.Act.Labels {
+			span.SetAttribute(LabelPrefix+k, v)
+		}
+		defer span.Finish()
+		req = req.WithContext(ctx)
 
+		// Bypass the compile cache when compiling if the parameter is specified.
+		forceParam := req.URL.Query().Get("forceCompile")
+		if forceParam != "" {
+			force, err := strconv.ParseBool(forceParam)
+			if err != nil {
+				msg := fmt.Sprintf("error when reading the forceCompile parameter: %s\n", err)
+				writer.WriteHeader(http.StatusBadRequest)
+				writer.Write([]byte(msg))
+				logger.Errorw(msg, GameID, conf.Act.GameID)
+				return
+			}
+			conf.ForceCompile = force
+		}
 		// Compile the code if the parameter is specified.
 		compileParam := req.URL.Query().Get("compile")
 		if compileParam != "" {
@@ -231,75 +569,817 @@ func (s *Server) CompilationHandler(next http.Handler) http.Handler {
 				msg := fmt.Sprintf("error when reading the compile parameter: %s\n", err)
 				writer.WriteHeader(http.StatusBadRequest)
 				writer.Write([]byte(msg))
-				s.logger.Errorw(msg, GameID, conf.Act.GameID)
+				logger.Errorw(msg, GameID, conf.Act.GameID)
 				return
 			}
 			if compile {
-				s.logger.Infow("Compiling the application", GameID, conf.Act.GameID)
+				if err := checkProgramAllowed(conf.Spdz.ProgramAllowList, conf.Act); err != nil {
+					msg := fmt.Sprintf("program cannot be compiled: %s", err)
+					writer.WriteHeader(http.StatusForbidden)
+					writer.Write([]byte(msg))
+					logger.Warnw(msg, GameID, conf.Act.GameID, "User", conf.AuthorizedUser)
+					return
+				}
+			}
+			s.startAuditTrail(conf)
+			if compile {
+				logger.Infow("Compiling the application", GameID, conf.Act.GameID)
+				_, compileSpan := tracing.StartSpan(ctx, "compile")
+				conf.Audit.Add(audit.CompilationStarted, nil)
+				start := time.Now()
+				s.lifecycle.BeginCompilation()
 				err := s.compile(conf)
+				s.lifecycle.EndCompilation()
+				conf.Audit.Add(audit.CompilationFinished, map[string]interface{}{"success": err == nil})
+				compileSpan.SetError(err)
+				compileSpan.Finish()
 				if err != nil {
+					span.SetError(err)
 					msg := fmt.Sprintf("error compiling the code: %s\n", err)
-					writer.WriteHeader(http.StatusServiceUnavailable)
+					conf.Audit.Finish(errors.New(msg))
+					// Programs failing to compile are not retried, as opposed to failures of the compiler.
+					status := http.StatusServiceUnavailable
+					if IsCompilationError(err) {
+						status = http.StatusUnprocessableEntity
+					}
+					writer.WriteHeader(status)
 					writer.Write([]byte(msg))
-					s.logger.Errorw(msg, GameID, conf.Act.GameID)
+					logger.Errorw(msg, GameID, conf.Act.GameID)
 					return
 				}
-				s.logger.Debugw("Finished compiling the application", GameID, conf.Act.GameID)
+				s.estimates.observeCompilation(conf.Act, time.Since(start))
+				conf.Compiled = true
+				logger.Debugw("Finished compiling the application", GameID, conf.Act.GameID)
 			}
 		}
-		s.logger.Debug("Compilation handler done")
+		s.startAuditTrail(conf)
+		logger.Debug("Compilation handler done")
 		next.ServeHTTP(writer, req)
 	})
 }
 
+// checkProgramAllowed returns an error if the program of the activation is not on the given allow list. All programs
+// are allowed if the allow list is nil.
+func checkProgramAllowed(allowList *allowlist.AllowList, act *Activation) error {
+	if allowList == nil {
+		return nil
+	}
+	return allowList.Check(act.Code, act.Signature)
+}
+
+// CompileOnlyHandler compiles the program of the request without activating a game and responds with the compiler
+// diagnostics. Programs failing to compile are answered with 422 and the output of the compiler. Clients accepting
+// newline delimited JSON receive the output of the compiler while it runs instead.
+func (s *Server) CompileOnlyHandler(writer http.ResponseWriter, req *http.Request) {
+	var act Activation
+	if _, ok := s.decodeRequest(writer, req, &act); !ok {
+		return
+	}
+	if act.Code == "" {
+		msg := "code must be provided"
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return
+	}
+	if err := validateCompilerOptions(act.CompilerOptions, s.config); err != nil {
+		msg := fmt.Sprintf("invalid compiler options: %s", err)
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return
+	}
+	if err := checkProgramAllowed(s.config.ProgramAllowList, &act); err != nil {
+		msg := fmt.Sprintf("program cannot be compiled: %s", err)
+		writer.WriteHeader(http.StatusForbidden)
+		writer.Write([]byte(msg))
+		s.logger.Warn(msg)
+		return
+	}
+	ctx := &CtxConfig{Act: &act, Spdz: s.config, Context: req.Context()}
+	if accepts(req, ContentTypeNDJSON) {
+		s.streamCompilation(writer, ctx)
+		return
+	}
+	s.lifecycle.BeginCompilation()
+	report, err := s.compileWithReport(ctx)
+	s.lifecycle.EndCompilation()
+	if err != nil {
+		msg := fmt.Sprintf("error compiling the code: %s\n", err)
+		writer.WriteHeader(http.StatusServiceUnavailable)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return
+	}
+	body, err := json.Marshal(report)
+	if err != nil {
+		msg := fmt.Sprintf("error encoding the compilation report: %s", err)
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return
+	}
+	writer.Header().Set("Content-Type", ContentTypeJSON)
+	if report.Success {
+		writer.WriteHeader(http.StatusOK)
+	} else {
+		writer.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	writer.Write(body)
+}
+
+// streamCompilation compiles the program and writes the output of the compiler to the response as newline delimited
+// JSON while the compiler runs, followed by the report. As the status code has already been sent, a program failing to
+// compile is reported as unsuccessful by the report only.
+func (s *Server) streamCompilation(writer http.ResponseWriter, ctx *CtxConfig) {
+	writer.Header().Set("Content-Type", ContentTypeNDJSON)
+	writer.WriteHeader(http.StatusOK)
+	out := &compilerOutput{enc: json.NewEncoder(writer)}
+	out.flusher, _ = writer.(http.Flusher)
+	ctx.CompilerStdout = &compilerStream{out: out}
+	ctx.CompilerStderr = &compilerStream{out: out, stderr: true}
+	s.lifecycle.BeginCompilation()
+	report, err := s.compileWithReport(ctx)
+	s.lifecycle.EndCompilation()
+	if err != nil {
+		msg := fmt.Sprintf("error compiling the code: %s", err)
+		out.write(&CompilationOutput{Error: msg})
+		s.logger.Error(msg)
+		return
+	}
+	report.Stdout, report.Stderr = "", ""
+	out.write(&CompilationOutput{Report: report})
+}
+
+// compilerOutput writes the lines of the streamed compiler output and flushes each of them, so that the client
+// receives the output while the compiler runs.
+type compilerOutput struct {
+	mux     sync.Mutex
+	enc     *json.Encoder
+	flusher http.Flusher
+}
+
+func (o *compilerOutput) write(line *CompilationOutput) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.enc.Encode(line)
+	if o.flusher != nil {
+		o.flusher.Flush()
+	}
+}
+
+// compilerStream writes the stdout or stderr of the compiler to the streamed output.
+type compilerStream struct {
+	out    *compilerOutput
+	stderr bool
+}
+
+func (w *compilerStream) Write(p []byte) (int, error) {
+	line := &CompilationOutput{Stdout: string(p)}
+	if w.stderr {
+		line = &CompilationOutput{Stderr: string(p)}
+	}
+	w.out.write(line)
+	return len(p), nil
+}
+
+// CapabilitiesHandler responds with the capabilities of the deployment, so that clients can adapt to the features
+// available.
+func (s *Server) CapabilitiesHandler(writer http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		msg := "GET requests must be used to retrieve the capabilities"
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return
+	}
+	body, err := json.Marshal(NewCapabilities(s.config))
+	if err != nil {
+		msg := fmt.Sprintf("error encoding the capabilities: %s", err)
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return
+	}
+	writer.Header().Set("Content-Type", ContentTypeJSON)
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(body)
+}
+
+// maxCompilerBitLength is the largest bit length of secret integers supported by the compiler options.
+const maxCompilerBitLength = 1024
+
+// validateCompilerOptions verifies that the compiler options are in range and match the configuration of the VCP.
+func validateCompilerOptions(opts *CompilerOptions, conf *SPDZEngineTypedConfig) error {
+	if opts == nil {
+		return nil
+	}
+	if opts.OptimizationLevel < 0 || opts.OptimizationLevel > 3 {
+		return fmt.Errorf("optimization level must be between 0 and 3, got %d", opts.OptimizationLevel)
+	}
+	if opts.BitLength < 0 || opts.BitLength > maxCompilerBitLength {
+		return fmt.Errorf("bit length must be between 0 and %d, got %d", maxCompilerBitLength, opts.BitLength)
+	}
+	if opts.Budget < 0 {
+		return fmt.Errorf("budget must not be negative, got %d", opts.Budget)
+	}
+	if opts.Prime != "" {
+		var prime big.Int
+		if _, ok := prime.SetString(opts.Prime, 10); !ok || prime.Sign() <= 0 {
+			return fmt.Errorf("prime %s is not a positive decimal number", opts.Prime)
+		}
+		if conf != nil && prime.Cmp(&conf.Prime) != 0 {
+			return fmt.Errorf("prime %s does not match the prime of the VCP", opts.Prime)
+		}
+	}
+	return nil
+}
+
+// validateConnections verifies that the client connections of the activation listen on valid ports and take all
+// parameters of the activation.
+func validateConnections(act *Activation, conf *SPDZEngineTypedConfig) error {
+	if len(act.Connections) == 0 {
+		return nil
+	}
+	params := 0
+	for i, conn := range act.Connections {
+		if conn.Port < 0 || int(conn.Port)+int(conf.PlayerCount) > 65536 {
+			return fmt.Errorf("port %d of connection %d is out of range", conn.Port, i)
+		}
+		if conn.Params < 0 {
+			return fmt.Errorf("number of parameters of connection %d must not be negative, got %d", i, conn.Params)
+		}
+		params += conn.Params
+	}
+	if given := len(act.AmphoraParams) + len(act.SecretParams); params != given {
+		return fmt.Errorf("the connections take %d parameters, but %d are given", params, given)
+	}
+	return nil
+}
+
+// validateOutputSchema verifies that the named outputs of the result schema are unique and not empty. A result schema
+// cannot be declared for outputs uploaded to the object store, as these are stored in a single object.
+func validateOutputSchema(output *OutputConfig) error {
+	if len(output.Schema) == 0 {
+		return nil
+	}
+	if strings.EqualFold(output.Type, ObjectStore) {
+		return fmt.Errorf("not supported for output type %s", ObjectStore)
+	}
+	names := make(map[string]bool, len(output.Schema))
+	for i, field := range output.Schema {
+		if field.Name == "" {
+			return fmt.Errorf("output %d has no name", i)
+		}
+		if names[field.Name] {
+			return fmt.Errorf("output %s is declared more than once", field.Name)
+		}
+		names[field.Name] = true
+		if field.Count <= 0 {
+			return fmt.Errorf("the number of values of output %s must be positive, got %d", field.Name, field.Count)
+		}
+	}
+	return nil
+}
+
 // ActivationHandler is the http handler starts the Player FSM.
 func (s *Server) ActivationHandler(writer http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
 	ctxConfig := ctx.Value(ctxConf).(*CtxConfig)
-	con, cancel := context.WithTimeout(ctx, ctxConfig.Spdz.StateTimeout*3+ctxConfig.Spdz.ComputationTimeout)
+	logger := s.activationLogger(ctxConfig)
+	// The game keeps the settings it was started with if the configuration is reloaded in the meantime.
+	config := s.config.Snapshot()
+	timeout := activationTimeout(ctxConfig.Spdz.StateTimeout, ctxConfig.Spdz.ComputationTimeout, ctxConfig.Spdz.StateTimeouts)
+	// The activation is not continued beyond the time budget of the client.
+	if !ctxConfig.Deadline.IsZero() && time.Until(ctxConfig.Deadline) < timeout {
+		timeout = time.Until(ctxConfig.Deadline)
+	}
+	con, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 	deadline, _ := con.Deadline()
-	s.logger.Debugw("Created Activation context", "Context", con, "Deadline", deadline)
+	logger.Debugw("Created Activation context", "Context", con, "Deadline", deadline)
 	ctxConfig.Context = con
 	pod, err := s.getPodName()
 	if err != nil {
+		ctxConfig.Audit.Finish(err)
 		writer.WriteHeader(http.StatusInternalServerError)
-		s.logger.Errorw(fmt.Sprintf("Error retrieving pod name: %s", err), GameID, ctxConfig.Act.GameID)
+		logger.Errorw(fmt.Sprintf("Error retrieving pod name: %s", err), GameID, ctxConfig.Act.GameID)
+		return
+	}
+	logger.Debugf("Retrieved pod name %v", pod)
+	if err := checkPodAffinity(ctxConfig, pod); err != nil {
+		msg := err.Error()
+		ctxConfig.Audit.Finish(err)
+		writer.WriteHeader(http.StatusConflict)
+		writer.Write([]byte(msg))
+		logger.Errorw(msg, GameID, ctxConfig.Act.GameID)
+		return
 	}
-	s.logger.Debugf("Retrieved pod name %v", pod)
 
-	spdz := NewSPDZWrapper(ctxConfig, s.respCh, s.execErrCh, s.logger, s.activate)
+	// The output of the MPC runtime can be tailed on /games/{id}/logs while the game runs.
+	runtimeLog := s.gameLogs.start(ctxConfig.Act.GameID, ctxConfig.AuthorizedUser)
+	defer runtimeLog.finish()
+	ctxConfig.RuntimeStdout = runtimeLog.writer(streamStdout)
+	ctxConfig.RuntimeStderr = runtimeLog.writer(streamStderr)
+
+	if backendName(ctxConfig.Act) == SimulatorBackend {
+		writer.Header().Set(plaintextSimulationHeader, "true")
+	}
+	// Stream the output values to the client while they are read from the MPC runtime. Results written to Amphora,
+	// results split by a result schema and protobuf encoded results are sent as a whole.
+	if s.config.OutputStreamBufferSize > 0 && !strings.EqualFold(ctxConfig.Act.Output.Type, AmphoraSecret) &&
+		len(ctxConfig.Act.Output.Schema) == 0 && s.responseContentType(req) == ContentTypeJSON {
+		ctxConfig.Output = make(chan []string, 1)
+	}
+	// Once the computation has finished, the delivery of the result is bounded by its own timeout instead of the
+	// remaining activation deadline.
+	var delivery chan struct{}
+	if config.ResultDeliveryTimeout > 0 {
+		delivery = make(chan struct{})
+		ctxConfig.Delivery = delivery
+	}
+	// Results written to Amphora are answered only once all players stored their shares, so that the outputs of a game
+	// another player failed are discarded rather than reported.
+	if ctxConfig.Act.Output.Type == AmphoraSecret {
+		ctxConfig.GameSucceeded = make(chan struct{})
+	}
+	// Each player proposes to generate fake preprocessing data on its own, the players agree on it once they are ready.
+	if backendName(ctxConfig.Act) != SimulatorBackend {
+		ctxConfig.InsecurePreprocessing = useInsecurePreprocessing(config, s.castorAvailable, logger, ctxConfig.Act.GameID)
+	}
+	sess := s.newSession()
+	clock := newActivationTimer()
+	spdz := NewSPDZWrapper(ctxConfig, sess.respCh, sess.execErrCh, logger, s.activate)
 	plIO := s.getPlayer(func() AbstractPlayerWithIO {
-		pl, err := NewPlayerWithIO(ctxConfig, &s.config.DiscoveryConfig, pod, spdz, s.config.StateTimeout, s.config.ComputationTimeout, s.errCh, s.logger)
+		observers := append(s.observers[:len(s.observers):len(s.observers)], clock)
+		if ctxConfig.Audit != nil {
+			observers = append(observers, ctxConfig.Audit)
+		}
+		if game := s.gamesInFlight.get(ctxConfig.Act.GameID); game != nil {
+			observers = append(observers, game)
+		}
+		pl, err := NewPlayerWithIO(ctxConfig, &config.DiscoveryConfig, pod, spdz, config.StateTimeout, config.ComputationTimeout, sess.errCh, logger, observers...)
 		if err != nil {
-			s.logger.Errorf("Failed to initialize Player: %v", err)
+			logger.Errorf("Failed to initialize Player: %v", err)
 		}
 		return pl
 	})
 
+	if expected, ok := s.estimates.expected(ctxConfig.Act); ok {
+		setExpectedDuration(writer.Header(), expected, time.Now())
+		runtimeLog.expect(expected)
+	}
 	plIO.Start()
+	// A game cancelled on DELETE /games/{id} is reported as failed to the discovery service, so that the other players
+	// do not wait for it, and its activation context is cancelled, which terminates the MPC runtime and the tuple
+	// streamers.
+	if game := s.gamesInFlight.get(ctxConfig.Act.GameID); game != nil {
+		go func() {
+			select {
+			case <-game.cancelled:
+				logger.Infow("Cancelling the game", GameID, ctxConfig.Act.GameID)
+				plIO.Cancel()
+				cancel()
+			case <-con.Done():
+			}
+		}()
+	}
+	if endpoint := plIO.DiscoveryEndpoint(); endpoint != "" {
+		logger.Debugw("Using discovery endpoint", GameID, ctxConfig.Act.GameID, "Endpoint", endpoint)
+		writer.Header().Set(discoveryEndpointHeader, endpoint)
+	}
 
-	select {
-	case stdout := <-s.respCh:
-		writer.WriteHeader(http.StatusOK)
-		writer.Write(stdout)
-	case err := <-s.errCh:
-		msg := fmt.Sprintf("error while talking to Discovery: %s", err)
-		writer.WriteHeader(http.StatusInternalServerError)
-		writer.Write([]byte(msg))
-		s.logger.Errorw(msg, GameID, ctxConfig.Act.GameID)
-	case err := <-s.execErrCh:
-		msg := fmt.Sprintf("error during MPC execution: %s", err)
-		writer.WriteHeader(http.StatusInternalServerError)
-		writer.Write([]byte(msg))
-		s.logger.Errorw(msg, GameID, ctxConfig.Act.GameID)
-	case <-con.Done():
-		msg := fmt.Sprintf("timeout during activation procedure")
+	output := ctxConfig.Output
+	succeeded := ctxConfig.GameSucceeded
+	activationDone := con.Done()
+	var deliveryTimeout <-chan time.Time
+	var failure error
+	// result is the result held back until all players finished the game successfully.
+	var result []byte
+	for finished := false; !finished; {
+		finished = true
+		select {
+		case <-delivery:
+			logger.Debugw("Delivering the result", GameID, ctxConfig.Act.GameID, "Timeout", config.ResultDeliveryTimeout)
+			delivery = nil
+			activationDone = nil
+			timer := time.NewTimer(config.ResultDeliveryTimeout)
+			defer timer.Stop()
+			deliveryTimeout = timer.C
+			finished = false
+		case <-deliveryTimeout:
+			if result != nil {
+				// The result has been delivered, only the outcome of the game is outstanding.
+				deliveryTimeout = nil
+				activationDone = con.Done()
+				finished = false
+				break
+			}
+			s.deferDelivery(writer, ctxConfig, sess, plIO)
+			return
+		case values, ok := <-output:
+			if !ok {
+				// Nothing has been streamed, hence the outcome is reported as usual.
+				output = nil
+				finished = false
+				break
+			}
+			failure = s.streamResult(writer, ctxConfig, sess, values, plIO)
+			if failure == nil {
+				s.observeActivation(ctxConfig, clock)
+			}
+		case <-succeeded:
+			succeeded = nil
+			if result == nil {
+				finished = false
+				break
+			}
+			s.writeResult(writer, req, ctxConfig, result, clock)
+		case stdout := <-sess.respCh:
+			if succeeded != nil {
+				logger.Debugw("Awaiting the outcome of the game", GameID, ctxConfig.Act.GameID)
+				result = stdout
+				finished = false
+				break
+			}
+			s.writeResult(writer, req, ctxConfig, stdout, clock)
+		case err := <-sess.errCh:
+			msg := fmt.Sprintf("error while talking to Discovery: %s", err)
+			failure = errors.New(msg)
+			writer.WriteHeader(http.StatusInternalServerError)
+			writer.Write([]byte(msg))
+			logger.Errorw(msg, GameID, ctxConfig.Act.GameID)
+		case err := <-sess.execErrCh:
+			msg := fmt.Sprintf("error during MPC execution: %s", err)
+			failure = errors.New(msg)
+			if proxyErr, ok := err.(*ProxyEntriesError); ok {
+				writeProxyEntriesError(writer, msg, proxyErr)
+			} else if diagnosedErr, ok := err.(*DiagnosedError); ok {
+				writeDiagnosedError(writer, msg, diagnosedErr)
+			} else {
+				writer.WriteHeader(http.StatusInternalServerError)
+				writer.Write([]byte(msg))
+			}
+			logger.Errorw(msg, GameID, ctxConfig.Act.GameID)
+		case <-activationDone:
+			failure = s.abortError(ctxConfig)
+			msg := failure.Error()
+			if failure == errGameCancelled {
+				writer.WriteHeader(http.StatusConflict)
+			} else {
+				writer.WriteHeader(http.StatusInternalServerError)
+			}
+			writer.Write([]byte(msg))
+			logger.Errorw(msg, GameID, ctxConfig.Act.GameID, "FSM History", plIO.History())
+		}
+	}
+	if failure != nil && ctxConfig.GameSucceeded != nil && played(plIO.History()) {
+		s.discardOutputs(ctxConfig)
+	}
+	tracing.SpanFromContext(ctx).SetError(failure)
+	s.notifyGameFinished(ctxConfig, failure)
+	s.gameFinished(ctxConfig)
+	ctxConfig.Audit.Finish(failure)
+	logger.Debug("Activation finalized")
+}
+
+// setPreprocessingHeader marks the response as computed with fake preprocessing data if the players of the game agreed
+// on generating it.
+func setPreprocessingHeader(writer http.ResponseWriter, ctx *CtxConfig) {
+	if ctx.Preprocessing == PreprocessingInsecure {
+		writer.Header().Set(insecurePreprocessingHeader, "true")
+	}
+}
+
+// writeResult responds with the result of the MPC execution, encoded as requested by the client.
+func (s *Server) writeResult(writer http.ResponseWriter, req *http.Request, ctx *CtxConfig, stdout []byte, clock *activationTimer) {
+	contentType := s.responseContentType(req)
+	body, err := encodeResult(contentType, stdout)
+	if err != nil {
+		msg := fmt.Sprintf("error encoding the result: %s", err)
 		writer.WriteHeader(http.StatusInternalServerError)
 		writer.Write([]byte(msg))
-		s.logger.Errorw(msg, GameID, ctxConfig.Act.GameID, "FSM History", plIO.History())
+		s.activationLogger(ctx).Errorw(msg, GameID, ctx.Act.GameID)
+		return
+	}
+	s.cacheResult(ctx, stdout)
+	setPreprocessingHeader(writer, ctx)
+	writer.Header().Set("Content-Type", contentType)
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(body)
+	s.observeActivation(ctx, clock)
+}
+
+// played returns true if the player started playing the game, i.e., it may have written outputs.
+func played(history *fsm.History) bool {
+	if history == nil {
+		return false
+	}
+	for _, state := range history.GetStates() {
+		if state == Playing {
+			return true
+		}
+	}
+	return false
+}
+
+// discardOutputs deletes the secrets the outputs of the failed game are written to, so that no partial result lingers
+// in Amphora. As the discovery service reports the failure of a game to all players, and the ids of the secrets are
+// derived from the game id, all players discard their shares of the outputs consistently.
+func (s *Server) discardOutputs(ctx *CtxConfig) {
+	logger := s.activationLogger(ctx)
+	client := ctx.Spdz.AmphoraClient
+	if client == nil {
+		return
+	}
+	ids, err := OutputSecretIDs(ctx.Act)
+	if err != nil {
+		logger.Errorw(fmt.Sprintf("Failed to discard the outputs of the game: %s", err), GameID, ctx.Act.GameID)
+		return
+	}
+	discarded := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if err := client.DeleteSecretShare(id); err != nil {
+			logger.Errorw(fmt.Sprintf("Failed to discard the output secret %s: %s", id, err), GameID, ctx.Act.GameID)
+			continue
+		}
+		discarded = append(discarded, id)
+	}
+	ctx.Audit.Add(audit.OutputsDiscarded, map[string]interface{}{"secretIDs": discarded})
+	logger.Infow("Discarded the outputs of the failed game", GameID, ctx.Act.GameID, "SecretIDs", discarded)
+}
+
+// checkTimeBudget returns an error if the activation is expected to take longer than its time budget, based on the
+// durations of past activations of the program.
+func (s *Server) checkTimeBudget(ctx *CtxConfig, compile bool) error {
+	if ctx.Deadline.IsZero() {
+		return nil
+	}
+	remaining := time.Until(ctx.Deadline)
+	e := s.estimates.estimate(ctx.Act, compile)
+	if e.Total() <= remaining {
+		return nil
+	}
+	return fmt.Errorf("the remaining time budget of %s is insufficient, the activation is expected to take at least %s "+
+		"(compilation: %s, discovery: %s, execution: %s)", remaining.Round(time.Millisecond), e.Total(), e.Compilation,
+		e.Discovery, e.Execution)
+}
+
+// setExpectedDuration reports the expected duration of an activation starting at the given time in the headers.
+func setExpectedDuration(header http.Header, e expectedDuration, start time.Time) {
+	header.Set(expectedDurationP50Header, e.P50.Round(time.Millisecond).String())
+	header.Set(expectedDurationP90Header, e.P90.Round(time.Millisecond).String())
+	header.Set(expectedDurationP99Header, e.P99.Round(time.Millisecond).String())
+	header.Set(estimatedCompletionHeader, start.Add(e.P50).UTC().Format(time.RFC3339))
+}
+
+// observeActivation records the durations of the phases of a successful activation.
+func (s *Server) observeActivation(ctx *CtxConfig, clock *activationTimer) {
+	if discovery, execution, ok := clock.phases(); ok {
+		s.estimates.observeActivation(ctx.Act, discovery, execution)
+	}
+}
+
+// writeProxyEntriesError responds with the error along with the computed proxy entries and the skipped players.
+func writeProxyEntriesError(writer http.ResponseWriter, msg string, err *ProxyEntriesError) {
+	body, _ := json.Marshal(struct {
+		Error string `json:"error"`
+		*ProxyEntriesDiagnosis
+	}{msg, err.Diagnosis})
+	writer.Header().Set("Content-Type", ContentTypeJSON)
+	writer.WriteHeader(http.StatusInternalServerError)
+	writer.Write(body)
+}
+
+// writeDiagnosedError responds with the error along with the diagnostics of the execution.
+func writeDiagnosedError(writer http.ResponseWriter, msg string, err *DiagnosedError) {
+	body, _ := json.Marshal(struct {
+		Error       string       `json:"error"`
+		Diagnostics *Diagnostics `json:"diagnostics"`
+	}{msg, err.Diagnostics})
+	writer.Header().Set("Content-Type", ContentTypeJSON)
+	writer.WriteHeader(http.StatusInternalServerError)
+	writer.Write(body)
+}
+
+// streamResult writes the output values to the response while they are received from the MPC runtime, starting with
+// the given ones. As the status code has already been sent, a warning or error the activation finishes with is appended
+// to the response body. Returns the error the activation failed with, if any.
+func (s *Server) streamResult(writer http.ResponseWriter, ctx *CtxConfig, sess *session, values []string, plIO AbstractPlayerWithIO) error {
+	setPreprocessingHeader(writer, ctx)
+	writer.Header().Set("Content-Type", ContentTypeJSON)
+	writer.WriteHeader(http.StatusOK)
+	flusher, _ := writer.(http.Flusher)
+	logger := s.activationLogger(ctx)
+	writer.Write([]byte(`{"response":[`))
+	// The values are only kept for the result cache, and only as long as they do not exceed the size of the cached
+	// results, so that the memory of the streaming stays bounded.
+	cache := s.config.ResultCache != nil
+	streamed := []string{}
+	count, size := 0, int64(0)
+	var failure error
+	for open := true; open && failure == nil; {
+		for _, v := range values {
+			if count > 0 {
+				writer.Write([]byte(","))
+			}
+			value, _ := json.Marshal(v)
+			writer.Write(value)
+			count++
+			if !cache {
+				continue
+			}
+			size += int64(len(value))
+			if s.config.MaxResultSize > 0 && size > s.config.MaxResultSize {
+				logger.Warnw("Not caching the result of the game exceeding the maximum size", GameID, ctx.Act.GameID, "MaxResultSize", s.config.MaxResultSize)
+				cache, streamed = false, nil
+				continue
+			}
+			streamed = append(streamed, v)
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		select {
+		case values, open = <-ctx.Output:
+		case <-ctx.Context.Done():
+			failure = s.abortError(ctx)
+		}
+	}
+	var result Result
+	if failure == nil {
+		select {
+		case stdout := <-sess.respCh:
+			if err := json.Unmarshal(stdout, &result); err != nil {
+				failure = fmt.Errorf("error decoding the result: %s", err)
+			}
+		case err := <-sess.errCh:
+			failure = fmt.Errorf("error while talking to Discovery: %s", err)
+		case err := <-sess.execErrCh:
+			failure = fmt.Errorf("error during MPC execution: %s", err)
+		case <-ctx.Context.Done():
+			failure = s.abortError(ctx)
+		}
+	}
+	writer.Write([]byte("]"))
+	if result.Warning != nil {
+		warning, _ := json.Marshal(result.Warning)
+		writer.Write([]byte(`,"warning":`))
+		writer.Write(warning)
+	}
+	if result.Diagnostics != nil {
+		diagnostics, _ := json.Marshal(result.Diagnostics)
+		writer.Write([]byte(`,"diagnostics":`))
+		writer.Write(diagnostics)
+	}
+	if failure != nil {
+		msg, _ := json.Marshal(failure.Error())
+		writer.Write([]byte(`,"error":`))
+		writer.Write(msg)
+		logger.Errorw(failure.Error(), GameID, ctx.Act.GameID, "Streamed", count, "FSM History", plIO.History())
+	}
+	writer.Write([]byte("}"))
+	if failure == nil && cache {
+		cached, _ := json.Marshal(Result{Response: streamed, Warning: result.Warning, Diagnostics: result.Diagnostics})
+		s.cacheResult(ctx, cached)
+	}
+	return failure
+}
+
+// deferDelivery answers the activation with the execution whose result is still being delivered, so that the client
+// can retrieve the outcome later on. The delivery is awaited in the background.
+func (s *Server) deferDelivery(writer http.ResponseWriter, ctx *CtxConfig, sess *session, plIO AbstractPlayerWithIO) {
+	logger := s.activationLogger(ctx)
+	ex := Execution{
+		GameID: ctx.Act.GameID,
+		Status: ExecutionDeliveryPending,
+		owner:  ctx.AuthorizedUser,
+	}
+	s.executions.put(ex)
+	body, _ := json.Marshal(ex)
+	writer.Header().Set("Content-Type", ContentTypeJSON)
+	writer.Header().Set("Location", ExecutionsPath+ex.GameID)
+	writer.WriteHeader(http.StatusAccepted)
+	writer.Write(body)
+	logger.Warnw("Result delivery exceeded its timeout, continuing in the background", GameID, ctx.Act.GameID, "FSM History", plIO.History())
+	// The game is still in flight until the result has been delivered.
+	s.lifecycle.Retain()
+	s.gamesInFlight.retain(ctx.Act.GameID)
+	// The computation has finished, hence only the outcome of the activation itself is of interest.
+	go func() {
+		defer s.lifecycle.End()
+		defer s.gamesInFlight.end(ctx.Act.GameID)
+		var failure error
+		select {
+		case stdout := <-sess.respCh:
+			var result Result
+			if err := json.Unmarshal(stdout, &result); err != nil {
+				failure = fmt.Errorf("error decoding the result: %s", err)
+			}
+			ex.Response = result.Response
+		case err := <-sess.execErrCh:
+			failure = err
+		}
+		ex.Status = ExecutionSucceeded
+		if failure != nil {
+			ex.Status = ExecutionFailed
+			ex.Error = failure.Error()
+			ex.Response = nil
+			logger.Errorw("Result delivery failed", GameID, ctx.Act.GameID, "Error", failure)
+		} else {
+			logger.Infow("Result delivered", GameID, ctx.Act.GameID)
+		}
+		s.executions.put(ex)
+		s.notifyGameFinished(ctx, failure)
+		s.gameFinished(ctx)
+		ctx.Audit.Finish(failure)
+	}()
+}
+
+// newSession returns a new session of an activation.
+func newSession() *session {
+	return &session{
+		respCh:    make(chan []byte),
+		errCh:     make(chan error, parallelGames),
+		execErrCh: make(chan error, parallelGames),
+	}
+}
+
+// session holds the channels an activation reports its outcome on. It is allocated per request, so that the outcome of
+// an activation is never delivered to the handler of another one.
+type session struct {
+	// respCh receives the result of the MPC execution.
+	respCh chan []byte
+	// errCh receives the errors of the discovery client.
+	errCh chan error
+	// execErrCh receives the errors of the MPC execution.
+	execErrCh chan error
+}
+
+// activationLogger returns the logger of the server with the labels of the activation attached.
+func (s *Server) activationLogger(ctx *CtxConfig) *zap.SugaredLogger {
+	return s.logger.With(labelFields(ctx.Act.Labels)...)
+}
+
+// startAuditTrail starts the audit trail of the activation unless it has been started before or games are not audited.
+func (s *Server) startAuditTrail(ctx *CtxConfig) {
+	if ctx.Audit != nil {
+		return
+	}
+	protocol, _, _ := resolveProtocol(ctx.Act, s.config)
+	ctx.Audit = s.config.Auditor.NewTrail(audit.Record{
+		GameID:            ctx.Act.GameID,
+		ProgramIdentifier: s.config.ProgramIdentifier,
+		PlayerID:          s.config.PlayerID,
+		User:              ctx.AuthorizedUser,
+		SecretIDs:         ctx.Act.AmphoraParams,
+		OutputType:        ctx.Act.Output.Type,
+		Protocol:          protocol,
+		Labels:            ctx.Act.Labels,
+	})
+}
+
+// notifyGameFinished informs the configured notifiers about the outcome of the game.
+func (s *Server) notifyGameFinished(ctx *CtxConfig, failure error) {
+	if s.config == nil || s.config.Notifications == nil {
+		return
+	}
+	e := notify.Event{
+		Name:              notify.GameSucceeded,
+		GameID:            ctx.Act.GameID,
+		ProgramIdentifier: s.config.ProgramIdentifier,
+		PlayerID:          s.config.PlayerID,
+		Time:              time.Now(),
+		Labels:            ctx.Act.Labels,
+	}
+	if failure != nil {
+		e.Name = notify.GameFailed
+		e.Error = failure.Error()
+	}
+	s.config.Notifications.Dispatch(e)
+}
+
+// gameFinished calls the function registered to be called once a game has finished, if any.
+func (s *Server) gameFinished(ctx *CtxConfig) {
+	if s.onGameFinished != nil {
+		s.onGameFinished(ctx.Act.GameID)
+	}
+}
+
+// checkPodAffinity verifies that the activation was received by the pod the game is pinned to for this player.
+func checkPodAffinity(ctx *CtxConfig, pod string) error {
+	affinity := ctx.Act.PodAffinity
+	if len(affinity) == 0 {
+		return nil
+	}
+	id := int(ctx.Spdz.PlayerID)
+	if id >= len(affinity) {
+		return fmt.Errorf("pod affinity of game %s does not name a pod for player %d", ctx.Act.GameID, id)
 	}
-	s.logger.Debug("Activation finalized")
+	if affinity[id] != pod {
+		return fmt.Errorf("game %s is pinned to pod %s for player %d, but the activation was received by pod %s", ctx.Act.GameID, affinity[id], id, pod)
+	}
+	return nil
 }
 
 // getPlayer is main purpose to test activation handler using a custom PlayerWithIO
@@ -316,23 +1396,37 @@ func (s *Server) getPlayer(initializer func() AbstractPlayerWithIO) AbstractPlay
 type AbstractPlayerWithIO interface {
 	Start()
 	History() *fsm.History
+	DiscoveryEndpoint() string
+	Cancel()
 }
 
-// NewPlayerWithIO returns a new instance of PlayerWithIO.
-func NewPlayerWithIO(ctx *CtxConfig, dcConf *DiscoveryClientTypedConfig, pod string, spdz MPCEngine, stateTimeout time.Duration, computationTimeout time.Duration, errCh chan error, logger *zap.SugaredLogger) (*PlayerWithIO, error) {
-	bus := mb.New(defaultBusSize)
+// NewPlayerWithIO returns a new instance of PlayerWithIO. The observers are notified about the state transitions of the
+// player.
+func NewPlayerWithIO(ctx *CtxConfig, dcConf *DiscoveryClientTypedConfig, pod string, spdz MPCEngine, stateTimeout time.Duration, computationTimeout time.Duration, errCh chan error, logger *zap.SugaredLogger, observers ...fsm.Observer) (*PlayerWithIO, error) {
+	bus := PlayerBusMetrics.Instrument(mb.New(defaultBusSize), logger)
 
 	name := NewTopicFromPlayerID(ctx)
 	params := &PlayerParams{
 		// probuf3 will omit playerID=0.
-		PlayerID: ctx.Spdz.PlayerID + 100,
-		Players:  ctx.Spdz.PlayerCount,
-		Pod:      pod,
-		IP:       ctx.Spdz.FrontendURL,
-		GameID:   ctx.Act.GameID,
-		Name:     name,
+		PlayerID:          ctx.Spdz.PlayerID + 100,
+		Players:           ctx.Spdz.PlayerCount,
+		Pod:               pod,
+		IP:                ctx.Spdz.FrontendURL,
+		GameID:            ctx.Act.GameID,
+		Name:              name,
+		PodAffinity:       ctx.Act.PodAffinity,
+		ParamsFingerprint: ParamsFingerprint(ctx.Spdz),
+		Traceparent:       tracing.Traceparent(ctx.Context),
+		Namespace:         dcConf.Namespace,
+		StateTimeouts:     ctx.Spdz.StateTimeouts,
+		Succeeded:         ctx.GameSucceeded,
+		Features:          playerFeatures(ctx),
+	}
+	if c := ctx.Spdz.Colocation; c != nil {
+		params.Cluster, params.LocalIP = c.Cluster, c.PodIP
 	}
 	pl, _ := NewPlayer(ctx.Context, bus, stateTimeout, computationTimeout, spdz, params, errCh, logger)
+	pl.Observe(observers...)
 
 	wires := &Wires{
 		In:  make(chan *pb.Event, 1),
@@ -388,17 +1482,81 @@ func (p *PlayerWithIO) History() *fsm.History {
 	return p.Player.History()
 }
 
+// DiscoveryEndpoint returns the address of the discovery endpoint the player is connected to.
+func (p *PlayerWithIO) DiscoveryEndpoint() string {
+	return p.Client.Endpoint()
+}
+
+// Cancel reports the failure of the game to the discovery service on behalf of the player.
+func (p *PlayerWithIO) Cancel() {
+	p.Player.Cancel()
+}
+
+// podNameEnv is the environment variable the downward API exposes the name of the pod in, if configured.
+const podNameEnv = "POD_NAME"
+
+// getPodName returns the name of the pod the server runs in.
 func (s *Server) getPodName() (string, error) {
-	// TODO: this is brittle, read the pod name from more reliable place.
-	//       use something like os.Getenv("HOST_NAME")?
-	cmder := s.executor
-	name, _, err := cmder.CallCMD(context.TODO(), []string{"hostname"}, "/")
-	if err != nil {
-		return "", err
+	if s.pod != "" {
+		return s.pod, nil
+	}
+	// The pod name is provided by the downward API if exposed, Kubernetes sets the host name to it otherwise.
+	if name := os.Getenv(podNameEnv); name != "" {
+		return name, nil
 	}
-	hostname := strings.TrimSuffix(string(name), "\n")
-	hostname = strings.TrimSuffix(hostname, "\r")
-	return hostname, err
+	return os.Hostname()
+}
+
+// acceptedContentTypes returns the media types activations and results may be encoded with.
+func (s *Server) acceptedContentTypes() []string {
+	return acceptedContentTypes(s.config)
+}
+
+// acceptedContentTypes returns the media types accepted with the given configuration. Defaults to JSON.
+func acceptedContentTypes(conf *SPDZEngineTypedConfig) []string {
+	if conf == nil || len(conf.AcceptedContentTypes) == 0 {
+		return []string{ContentTypeJSON}
+	}
+	return conf.AcceptedContentTypes
+}
+
+// requestContentType returns the accepted media type the request body is encoded with.
+func (s *Server) requestContentType(r *http.Request) (string, bool) {
+	for _, t := range s.acceptedContentTypes() {
+		if s.hasContentType(r, t) {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// responseContentType returns the media type the result is encoded with. Protobuf is used if it is accepted and
+// requested by the `accept` header of the request, JSON otherwise.
+func (s *Server) responseContentType(r *http.Request) string {
+	protobufAccepted := false
+	for _, t := range s.acceptedContentTypes() {
+		if t == ContentTypeProtobuf {
+			protobufAccepted = true
+		}
+	}
+	if protobufAccepted && accepts(r, ContentTypeProtobuf) {
+		return ContentTypeProtobuf
+	}
+	return ContentTypeJSON
+}
+
+// accepts returns true if the given media type is requested by the `accept` header of the request.
+func accepts(r *http.Request, mimetype string) bool {
+	for _, v := range strings.Split(r.Header.Get("Accept"), ",") {
+		t, _, err := mime.ParseMediaType(v)
+		if err != nil {
+			continue
+		}
+		if t == mimetype {
+			return true
+		}
+	}
+	return false
 }
 
 // Determine whether the request `content-type` includes a
@@ -439,10 +1597,16 @@ func NewTransportClientFromDiverseConfigs(dcConf *DiscoveryClientTypedConfig, ct
 		ErrCh:          ch.Err,
 		Host:           dcConf.Host,
 		Port:           dcConf.Port,
+		Fallbacks:      dcConf.Fallbacks,
+		Health:         discoveryHealth,
 		Logger:         logger,
 		ConnID:         ctx.Act.GameID,
 		EventScope:     EventScopeSelf,
+		Namespace:      dcConf.EventNamespace,
 		ConnectTimeout: dcConf.ConnectTimeout,
+		TLS:            dcConf.TLS,
+		Token:          dcConf.Token,
+		Backoff:        c.NewBackoff(dcConf.ReconnectTimeout),
 		Context:        ctx.Context,
 	}
 	cl, err := c.NewClient(clientConf)
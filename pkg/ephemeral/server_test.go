@@ -11,9 +11,23 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/carbynestack/ephemeral/pkg/allowlist"
+	"github.com/carbynestack/ephemeral/pkg/amphora"
 	"github.com/carbynestack/ephemeral/pkg/discovery/fsm"
+	. "github.com/carbynestack/ephemeral/pkg/ephemeral/io"
+	apb "github.com/carbynestack/ephemeral/pkg/ephemeral/proto"
+	"github.com/carbynestack/ephemeral/pkg/notify"
+	"github.com/carbynestack/ephemeral/pkg/quota"
+	"github.com/carbynestack/ephemeral/pkg/results"
+	"io"
+	"math/big"
+	"mime/multipart"
+	"os/exec"
+	"sync"
 	"time"
 
+	"github.com/golang/protobuf/proto"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"go.uber.org/zap"
@@ -52,7 +66,56 @@ var _ = Describe("Server", func() {
 				StateTimeout:            10 * time.Second,
 				NetworkEstablishTimeout: 10 * time.Second,
 			}
-			s = NewServer("sub", func(*CtxConfig) error { return nil }, func(*CtxConfig) ([]byte, error) { return nil, nil }, l, config)
+			s = NewServer("sub", func(*CtxConfig) error { return nil }, func(*CtxConfig) (*CompilationReport, error) { return &CompilationReport{Success: true}, nil }, func(*CtxConfig) ([]byte, error) { return nil, nil }, l, config)
+		})
+
+		Context("when the pod is terminating", func() {
+			It("rejects new activations once draining", func() {
+				s.Drain()
+				req, _ := http.NewRequest("POST", "/", nil)
+				s.DrainFilter(handler200).ServeHTTP(rr, req)
+				Expect(rr.Code).To(Equal(http.StatusServiceUnavailable))
+				Expect(rr.Body.String()).To(Equal("the service is terminating and does not accept new games"))
+			})
+			It("postpones the shutdown until the games in flight have finished", func() {
+				config.PreStopTimeout = 5 * time.Second
+				started := make(chan struct{})
+				finish := make(chan struct{})
+				game := http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+					close(started)
+					<-finish
+					writer.WriteHeader(http.StatusOK)
+				})
+				go s.DrainFilter(game).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", nil))
+				<-started
+				stopped := make(chan struct{})
+				go func() {
+					s.PreStop()
+					close(stopped)
+				}()
+				Consistently(stopped, 100*time.Millisecond).ShouldNot(BeClosed())
+				close(finish)
+				Eventually(stopped).Should(BeClosed())
+			})
+			It("stops postponing the shutdown after the timeout", func() {
+				config.PreStopTimeout = 50 * time.Millisecond
+				started := make(chan struct{})
+				finish := make(chan struct{})
+				defer close(finish)
+				game := http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+					close(started)
+					<-finish
+				})
+				go s.DrainFilter(game).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", nil))
+				<-started
+				stopped := make(chan struct{})
+				go func() {
+					s.PreStop()
+					close(stopped)
+				}()
+				Eventually(stopped).Should(BeClosed())
+				Expect(s.lifecycle.Active()).To(Equal(1))
+			})
 		})
 
 		Context("when going through body filter", func() {
@@ -69,6 +132,48 @@ var _ = Describe("Server", func() {
 				req.Header.Add("Authorization", authHeader)
 				s.RequestFilter(handler200).ServeHTTP(rr, req)
 			})
+			Context("when the client disconnects", func() {
+				var (
+					activation chan context.Context
+					done       chan struct{}
+					disconnect context.CancelFunc
+				)
+				// activate runs an activation which lasts until its context is done in the given context of the
+				// request.
+				activate := func(client context.Context) {
+					activation = make(chan context.Context, 1)
+					done = make(chan struct{})
+					handler := http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+						activation <- req.Context()
+						<-req.Context().Done()
+					})
+					act.GameID = gameID
+					body, _ := json.Marshal(&act)
+					client, disconnect = context.WithCancel(client)
+					req, _ := http.NewRequestWithContext(client, "POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					go func() {
+						defer close(done)
+						s.RequestFilter(handler).ServeHTTP(rr, req)
+					}()
+				}
+				It("continues the activation until the games are aborted on termination", func() {
+					activate(context.Background())
+					var ctx context.Context
+					Eventually(activation).Should(Receive(&ctx))
+					disconnect()
+					Consistently(done, 100*time.Millisecond).ShouldNot(BeClosed())
+					s.AbortGames()
+					Eventually(done).Should(BeClosed())
+					Expect(ctx.Err()).To(Equal(context.Canceled))
+				})
+				It("cancels the activation if requested to along with the request", func() {
+					activate(WithCancelOnDisconnect(context.Background()))
+					Eventually(activation).Should(Receive())
+					disconnect()
+					Eventually(done).Should(BeClosed())
+				})
+			})
 			Context("when the game id is not a valid UUID", func() {
 				It("responds with 400 http code", func() {
 					act.GameID = "123"
@@ -93,6 +198,152 @@ var _ = Describe("Server", func() {
 					Expect(respCode).To(Equal(http.StatusOK))
 				})
 			})
+			Context("when the protocol is not supported", func() {
+				It("returns a 400 response code", func() {
+					act.GameID = gameID
+					act.Protocol = "semi"
+					body, _ := json.Marshal(&act)
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusBadRequest))
+					Expect(rr.Body.String()).To(Equal("unsupported protocol semi, supported are [mascot]"))
+				})
+			})
+			Context("when the time budget is invalid", func() {
+				It("returns a 400 response code", func() {
+					act.GameID = gameID
+					body, _ := json.Marshal(&act)
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					req.Header.Add(timeBudgetHeader, "0s")
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusBadRequest))
+					Expect(rr.Body.String()).To(Equal("invalid time budget: time budget must be positive, got 0s"))
+				})
+			})
+			Context("when a time budget is given", func() {
+				It("sets the deadline of the activation", func() {
+					act.GameID = gameID
+					act.TimeBudget = "1m"
+					body, _ := json.Marshal(&act)
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					var deadline time.Time
+					s.RequestFilter(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+						deadline = req.Context().Value(ctxConf).(*CtxConfig).Deadline
+					})).ServeHTTP(rr, req)
+					Expect(deadline).To(BeTemporally("~", time.Now().Add(time.Minute), time.Second))
+				})
+			})
+			Context("when a game of the same id is executing", func() {
+				It("returns a 409 response code with the state of the executing game", func() {
+					act.GameID = gameID
+					body, _ := json.Marshal(&act)
+					started := make(chan struct{})
+					finish := make(chan struct{})
+					finished := make(chan struct{})
+					go func() {
+						defer close(finished)
+						req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+						req.Header.Add("Authorization", authHeader)
+						s.RequestFilter(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+							s.gamesInFlight.get(gameID).OnTransition(Init, Register, Registering, gameID)
+							close(started)
+							<-finish
+						})).ServeHTTP(httptest.NewRecorder(), req)
+					}()
+					<-started
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusConflict))
+					var conflict struct {
+						Error  string `json:"error"`
+						GameID string `json:"gameId"`
+						State  string `json:"state"`
+					}
+					Expect(json.Unmarshal(rr.Body.Bytes(), &conflict)).To(Succeed())
+					Expect(conflict.GameID).To(Equal(gameID))
+					Expect(conflict.State).To(Equal(Registering))
+					Expect(conflict.Error).To(HavePrefix(fmt.Sprintf("game %s is already executing on this pod", gameID)))
+					close(finish)
+					<-finished
+					rr = httptest.NewRecorder()
+					req, _ = http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusOK))
+				})
+			})
+			Context("when the request body is too large", func() {
+				It("returns a 413 response code", func() {
+					act.GameID = gameID
+					body, _ := json.Marshal(&act)
+					config.MaxRequestSize = int64(len(body)) - 1
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusRequestEntityTooLarge))
+					Expect(rr.Body.String()).To(Equal(fmt.Sprintf("the request body exceeds the maximum size of %d bytes", len(body)-1)))
+				})
+				It("returns a 413 response code if the size is not announced", func() {
+					act.GameID = gameID
+					act.SecretParams, act.AmphoraParams = []string{"YQ==", "Yg==", "Yw=="}, nil
+					body, _ := json.Marshal(&act)
+					config.MaxRequestSize = int64(len(body)) - 10
+					req, _ := http.NewRequest("POST", "/", struct{ io.Reader }{bytes.NewReader(body)})
+					req.Header.Add("Authorization", authHeader)
+					Expect(req.ContentLength).To(BeZero())
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusRequestEntityTooLarge))
+				})
+				It("accepts a request of the maximum size", func() {
+					act.GameID = gameID
+					body, _ := json.Marshal(&act)
+					config.MaxRequestSize = int64(len(body))
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusOK))
+				})
+			})
+			Context("when the seed cannot be applied", func() {
+				It("returns a 400 response code", func() {
+					act.GameID = gameID
+					act.Seed = "benchmark"
+					body, _ := json.Marshal(&act)
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusBadRequest))
+					Expect(rr.Body.String()).To(Equal("invalid seed: a seed can only be pinned if insecure preprocessing is enabled"))
+				})
+			})
+			Context("when the backend is not enabled", func() {
+				It("returns a 400 response code", func() {
+					act.GameID = gameID
+					act.Backend = SimulatorBackend
+					body, _ := json.Marshal(&act)
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusBadRequest))
+					Expect(rr.Body.String()).To(Equal("invalid backend: the simulator backend is not enabled"))
+				})
+			})
+			Context("when the session is unknown", func() {
+				It("returns a 400 response code", func() {
+					act.GameID = gameID
+					act.SessionID = "a5d8a2b4-4c4e-4b8e-9a53-1f0b6a4c8f11"
+					body, _ := json.Marshal(&act)
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusBadRequest))
+					Expect(rr.Body.String()).To(Equal("invalid session: no session found with id a5d8a2b4-4c4e-4b8e-9a53-1f0b6a4c8f11"))
+				})
+			})
 			Context("when the body is empty", func() {
 				It("returns a 400 response code", func() {
 					req, _ := http.NewRequest("POST", "/", nil)
@@ -104,6 +355,193 @@ var _ = Describe("Server", func() {
 					Expect(respBody).To(Equal("request body is nil"))
 				})
 			})
+			Context("when the pod affinity does not name a pod for each player", func() {
+				It("returns a 400 response code", func() {
+					act.GameID = gameID
+					act.PodAffinity = []string{"pod-0"}
+					config.PlayerCount = 2
+					body, _ := json.Marshal(act)
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusBadRequest))
+					Expect(rr.Body.String()).To(Equal("pod affinity must name a pod for each of the 2 players"))
+				})
+			})
+			Context("when the client connections do not take all parameters", func() {
+				It("returns a 400 response code", func() {
+					act.GameID = gameID
+					act.Connections = []ClientConnection{{Params: 1}, {Port: 14000, Params: 1}}
+					body, _ := json.Marshal(act)
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusBadRequest))
+					Expect(rr.Body.String()).To(Equal("invalid connections: the connections take 2 parameters, but 1 are given"))
+				})
+			})
+			Context("when the port of a client connection is out of range", func() {
+				It("returns a 400 response code", func() {
+					act.GameID = gameID
+					act.Connections = []ClientConnection{{Port: 70000, Params: 1}}
+					body, _ := json.Marshal(act)
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusBadRequest))
+					Expect(rr.Body.String()).To(Equal("invalid connections: port 70000 of connection 0 is out of range"))
+				})
+			})
+			Context("when the result schema is invalid", func() {
+				It("returns a 400 response code", func() {
+					act.GameID = gameID
+					act.Output.Schema = []OutputField{{Name: "sum", Count: 1}, {Name: "sum", Count: 2}}
+					body, _ := json.Marshal(act)
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusBadRequest))
+					Expect(rr.Body.String()).To(Equal("invalid result schema: output sum is declared more than once"))
+				})
+				It("rejects outputs without values", func() {
+					Expect(validateOutputSchema(&OutputConfig{Schema: []OutputField{{Name: "sum"}}})).To(
+						MatchError("the number of values of output sum must be positive, got 0"))
+				})
+			})
+			Context("when the labels are invalid", func() {
+				It("returns a 400 response code", func() {
+					act.GameID = gameID
+					act.Labels = map[string]string{"Tenant": "acme"}
+					body, _ := json.Marshal(act)
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusBadRequest))
+					Expect(rr.Body.String()).To(Equal("invalid labels: invalid label key \"Tenant\""))
+				})
+			})
+			Context("when a protobuf encoded activation is provided", func() {
+				It("decodes it into the ctxConfig", func() {
+					config.AcceptedContentTypes = []string{ContentTypeJSON, ContentTypeProtobuf}
+					body, _ := proto.Marshal(&apb.Activation{
+						GameID:       gameID,
+						SecretParams: []string{"YQ=="},
+						Output:       &apb.OutputConfig{Type: PlainText},
+					})
+					handler200 = http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+						ctxConfig := req.Context().Value(ctxConf).(*CtxConfig)
+						Expect(ctxConfig.Act.GameID).To(Equal(gameID))
+						Expect(ctxConfig.Act.SecretParams).To(Equal([]string{"YQ=="}))
+						Expect(ctxConfig.Act.Output.Type).To(Equal(PlainText))
+						writer.WriteHeader(http.StatusOK)
+					})
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					req.Header.Add("Content-Type", ContentTypeProtobuf)
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusOK))
+				})
+			})
+			Context("when a multipart activation is provided", func() {
+				It("appends the binary parts to the secret parameters", func() {
+					config.AcceptedContentTypes = []string{ContentTypeJSON, ContentTypeMultipart}
+					var body bytes.Buffer
+					w := multipart.NewWriter(&body)
+					part, _ := w.CreateFormField("activation")
+					part.Write([]byte(`{"gameID":"` + gameID + `","output":{"type":"PLAINTEXT"}}`))
+					part, _ = w.CreateFormFile("secretParams", "a.bin")
+					part.Write([]byte("ab"))
+					part, _ = w.CreateFormFile("secretParams", "b.bin")
+					part.Write([]byte{0})
+					w.Close()
+					var params []string
+					handler200 = http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+						params = req.Context().Value(ctxConf).(*CtxConfig).Act.SecretParams
+						writer.WriteHeader(http.StatusOK)
+					})
+					req, _ := http.NewRequest("POST", "/", &body)
+					req.Header.Add("Authorization", authHeader)
+					req.Header.Add("Content-Type", w.FormDataContentType())
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusOK))
+					Expect(params).To(Equal([]string{"YWI=", "AA=="}))
+				})
+				It("returns a 400 response code if the activation part is missing", func() {
+					config.AcceptedContentTypes = []string{ContentTypeJSON, ContentTypeMultipart}
+					var body bytes.Buffer
+					w := multipart.NewWriter(&body)
+					part, _ := w.CreateFormFile("secretParams", "a.bin")
+					part.Write([]byte("ab"))
+					w.Close()
+					req, _ := http.NewRequest("POST", "/", &body)
+					req.Header.Add("Authorization", authHeader)
+					req.Header.Add("Content-Type", w.FormDataContentType())
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusBadRequest))
+				})
+			})
+			Context("when the secret parameters are encoded", func() {
+				var params []string
+				BeforeEach(func() {
+					params = nil
+					act.GameID = gameID
+					act.AmphoraParams = nil
+					handler200 = http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+						ctxConfig := req.Context().Value(ctxConf).(*CtxConfig)
+						Expect(ctxConfig.Act.Encoding).To(Equal(EncodingBase64))
+						params = ctxConfig.Act.SecretParams
+						writer.WriteHeader(http.StatusOK)
+					})
+				})
+				It("decodes hex encoded secret shares", func() {
+					act.Encoding = EncodingHex
+					act.SecretParams = []string{"6162"}
+					body, _ := json.Marshal(act)
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusOK))
+					Expect(params).To(Equal([]string{"YWI="}))
+				})
+				It("secret shares clear values given in JSON", func() {
+					config.Prime.SetString("198766463529478683931867765928436695041", 10)
+					config.RInv.SetString("133854242216446749056083838363708373830", 10)
+					config.GfpMacKey = *big.NewInt(1234)
+					act.Encoding = EncodingJSON
+					act.SecretParams = []string{"[1, -2]"}
+					body, _ := json.Marshal(act)
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusOK))
+					Expect(params).To(HaveLen(1))
+					share, err := base64.StdEncoding.DecodeString(params[0])
+					Expect(err).NotTo(HaveOccurred())
+					Expect(share).To(HaveLen(2 * BodySize))
+				})
+				It("returns a 400 response code if the values are not integers", func() {
+					config.Prime.SetString("198766463529478683931867765928436695041", 10)
+					config.RInv.SetString("133854242216446749056083838363708373830", 10)
+					act.Encoding = EncodingJSON
+					act.SecretParams = []string{"[1.5]"}
+					body, _ := json.Marshal(act)
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusBadRequest))
+					Expect(rr.Body.String()).To(HavePrefix("error decoding secret parameters: parameter 0 is not a JSON array of 64 bit integers"))
+				})
+				It("returns a 400 response code if the encoding is not supported", func() {
+					act.Encoding = "utf8"
+					act.SecretParams = []string{"a"}
+					body, _ := json.Marshal(act)
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+					req.Header.Add("Authorization", authHeader)
+					s.RequestFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusBadRequest))
+					Expect(rr.Body.String()).To(Equal("error decoding secret parameters: unsupported encoding utf8, must be one of base64, hex, json"))
+				})
+			})
 			Context("when a not-valid JSON is provided in the body", func() {
 				It("returns a 400 response code", func() {
 					body := []byte("a")
@@ -143,6 +581,32 @@ var _ = Describe("Server", func() {
 					Expect(respBody).To(Equal("application/json content type must be provided"))
 				})
 			})
+			Context("when protobuf is accepted in addition to JSON", func() {
+				BeforeEach(func() {
+					config.AcceptedContentTypes = []string{ContentTypeJSON, ContentTypeProtobuf}
+				})
+				It("passes protobuf requests", func() {
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader([]byte{}))
+					req.Header.Add("Content-Type", ContentTypeProtobuf)
+					s.MethodFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusOK))
+				})
+				It("names all accepted content types when rejecting a request", func() {
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader([]byte{}))
+					req.Header.Add("Content-Type", "text/plain")
+					s.MethodFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusUnsupportedMediaType))
+					Expect(rr.Body.String()).To(Equal("application/json or application/x-protobuf content type must be provided"))
+				})
+			})
+			Context("when protobuf is not accepted", func() {
+				It("returns a 415 response code for protobuf requests", func() {
+					req, _ := http.NewRequest("POST", "/", bytes.NewReader([]byte{}))
+					req.Header.Add("Content-Type", ContentTypeProtobuf)
+					s.MethodFilter(handler200).ServeHTTP(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusUnsupportedMediaType))
+				})
+			})
 			Context("when POST with application/json content type is provided", func() {
 				It("returns a 200", func() {
 					act.GameID = gameID
@@ -156,6 +620,25 @@ var _ = Describe("Server", func() {
 			})
 		})
 
+		Context("when going through quota filter", func() {
+			It("forwards the request if no quotas are configured", func() {
+				req := requestWithContext("/", act)
+				s.QuotaFilter(handler200).ServeHTTP(rr, req)
+				Expect(rr.Code).To(Equal(http.StatusOK))
+			})
+			It("reports the quota and rejects requests exceeding it", func() {
+				config.Quota, _ = quota.NewTracker(1, 0, "")
+				req := requestWithContext("/", act)
+				s.QuotaFilter(handler200).ServeHTTP(rr, req)
+				Expect(rr.Code).To(Equal(http.StatusOK))
+				Expect(rr.Header().Get(quotaExecutionsLimitHeader)).To(Equal("1"))
+				Expect(rr.Header().Get(quotaExecutionsRemainingHeader)).To(Equal("0"))
+				rr = httptest.NewRecorder()
+				s.QuotaFilter(handler200).ServeHTTP(rr, req)
+				Expect(rr.Code).To(Equal(http.StatusTooManyRequests))
+				Expect(rr.Header().Get("Retry-After")).To(Equal("3600"))
+			})
+		})
 		Context("when going through compilation handler", func() {
 			Context("when compile parameter is not set", func() {
 				It("forwards to the next handler without compilation", func() {
@@ -217,6 +700,22 @@ var _ = Describe("Server", func() {
 							Expect(compiled).To(BeTrue())
 						})
 					})
+					Context("when the program is not allow-listed", func() {
+						It("returns a 403 without compiling", func() {
+							var compiled bool
+							s.compile = func(*CtxConfig) error {
+								compiled = true
+								return nil
+							}
+							req := requestWithContext("/?compile=true", act)
+							conf := req.Context().Value(ctxConf).(*CtxConfig)
+							conf.Act.Code = "print_ln('b')"
+							conf.Spdz.ProgramAllowList, _ = allowlist.New([]string{allowlist.Hash("print_ln('a')")}, nil)
+							s.CompilationHandler(handler200).ServeHTTP(rr, req)
+							Expect(rr.Code).To(Equal(http.StatusForbidden))
+							Expect(compiled).To(BeFalse())
+						})
+					})
 					Context("when compilation fails", func() {
 						It("returns a 503 response code", func() {
 							s.compile = func(*CtxConfig) error {
@@ -227,6 +726,14 @@ var _ = Describe("Server", func() {
 							respCode := rr.Code
 							Expect(respCode).To(Equal(http.StatusServiceUnavailable))
 						})
+						It("returns a 422 response code if the program does not compile", func() {
+							s.compile = func(*CtxConfig) error {
+								return &exec.ExitError{}
+							}
+							req := requestWithContext("/?compile=true", act)
+							s.CompilationHandler(handler200).ServeHTTP(rr, req)
+							Expect(rr.Code).To(Equal(http.StatusUnprocessableEntity))
+						})
 					})
 					Context("when additional parameters are provided", func() {
 						It("still compiles the code", func() {
@@ -252,6 +759,52 @@ var _ = Describe("Server", func() {
 						})
 					})
 				})
+				Context("when the time budget is insufficient", func() {
+					It("returns a 400 response code without compiling", func() {
+						var compiled bool
+						s.compile = func(*CtxConfig) error {
+							compiled = true
+							return nil
+						}
+						req := requestWithContext("/?compile=true", act)
+						conf := req.Context().Value(ctxConf).(*CtxConfig)
+						conf.Deadline = time.Now().Add(5 * time.Second)
+						s.estimates.observeCompilation(conf.Act, 3*time.Second)
+						s.estimates.observeActivation(conf.Act, time.Second, 4*time.Second)
+						s.CompilationHandler(handler200).ServeHTTP(rr, req)
+						Expect(rr.Code).To(Equal(http.StatusBadRequest))
+						Expect(rr.Body.String()).To(HaveSuffix("the activation is expected to take at least 8s (compilation: 3s, discovery: 1s, execution: 4s)"))
+						Expect(compiled).To(BeFalse())
+					})
+					It("forwards the request if the program is not compiled", func() {
+						req := requestWithContext("/", act)
+						conf := req.Context().Value(ctxConf).(*CtxConfig)
+						conf.Deadline = time.Now().Add(6 * time.Second)
+						s.estimates.observeCompilation(conf.Act, 3*time.Second)
+						s.estimates.observeActivation(conf.Act, time.Second, 4*time.Second)
+						s.CompilationHandler(handler200).ServeHTTP(rr, req)
+						Expect(rr.Code).To(Equal(http.StatusOK))
+					})
+				})
+				Context("when invalid compiler options are provided", func() {
+					It("returns a 400 response code", func() {
+						req := requestWithContext("/?compile=true", act)
+						conf := req.Context().Value(ctxConf).(*CtxConfig)
+						conf.Act.CompilerOptions = &CompilerOptions{OptimizationLevel: 4}
+						s.CompilationHandler(handler200).ServeHTTP(rr, req)
+						Expect(rr.Code).To(Equal(http.StatusBadRequest))
+						Expect(rr.Body.String()).To(Equal("invalid compiler options: optimization level must be between 0 and 3, got 4"))
+					})
+					It("rejects a prime not matching the prime of the VCP", func() {
+						req := requestWithContext("/?compile=true", act)
+						conf := req.Context().Value(ctxConf).(*CtxConfig)
+						conf.Spdz.Prime.SetInt64(17)
+						conf.Act.CompilerOptions = &CompilerOptions{Prime: "13"}
+						s.CompilationHandler(handler200).ServeHTTP(rr, req)
+						Expect(rr.Code).To(Equal(http.StatusBadRequest))
+						Expect(rr.Body.String()).To(Equal("invalid compiler options: prime 13 does not match the prime of the VCP"))
+					})
+				})
 				Context("when no context config was specified", func() {
 					It("returns a 400", func() {
 						ctx := context.TODO()
@@ -265,6 +818,115 @@ var _ = Describe("Server", func() {
 				})
 			})
 		})
+		Context("when going through compile only handler", func() {
+			compileRequest := func(act *Activation) *http.Request {
+				body, _ := json.Marshal(act)
+				req, _ := http.NewRequest("POST", "/compile", bytes.NewReader(body))
+				req.Header.Add("Authorization", authHeader)
+				return req
+			}
+			It("responds with the compilation report", func() {
+				s.compileWithReport = func(ctx *CtxConfig) (*CompilationReport, error) {
+					Expect(ctx.Act.Code).To(Equal("print_ln('a')"))
+					return &CompilationReport{Success: true, Stdout: "compiled", Threads: 2, BytecodeSize: 42}, nil
+				}
+				s.CompileOnlyHandler(rr, compileRequest(&Activation{Code: "print_ln('a')"}))
+				Expect(rr.Code).To(Equal(http.StatusOK))
+				var report CompilationReport
+				Expect(json.Unmarshal(rr.Body.Bytes(), &report)).To(Succeed())
+				Expect(report).To(Equal(CompilationReport{Success: true, Stdout: "compiled", Threads: 2, BytecodeSize: 42}))
+			})
+			It("responds with 422 and the compiler output if compilation fails", func() {
+				s.compileWithReport = func(*CtxConfig) (*CompilationReport, error) {
+					return &CompilationReport{Stderr: "SyntaxError"}, nil
+				}
+				s.CompileOnlyHandler(rr, compileRequest(&Activation{Code: "print_ln("}))
+				Expect(rr.Code).To(Equal(http.StatusUnprocessableEntity))
+				Expect(rr.Body.String()).To(ContainSubstring("SyntaxError"))
+			})
+			It("streams the output of the compiler to clients accepting newline delimited JSON", func() {
+				s.compileWithReport = func(ctx *CtxConfig) (*CompilationReport, error) {
+					ctx.CompilerStdout.Write([]byte("Compiling program"))
+					ctx.CompilerStderr.Write([]byte("warning"))
+					return &CompilationReport{Success: true, Stdout: "Compiling program", Stderr: "warning", Threads: 1}, nil
+				}
+				req := compileRequest(&Activation{Code: "print_ln('a')"})
+				req.Header.Set("Accept", ContentTypeNDJSON)
+				s.CompileOnlyHandler(rr, req)
+				Expect(rr.Code).To(Equal(http.StatusOK))
+				Expect(rr.Header().Get("Content-Type")).To(Equal(ContentTypeNDJSON))
+				var lines []CompilationOutput
+				dec := json.NewDecoder(rr.Body)
+				for dec.More() {
+					var line CompilationOutput
+					Expect(dec.Decode(&line)).To(Succeed())
+					lines = append(lines, line)
+				}
+				Expect(lines).To(Equal([]CompilationOutput{
+					{Stdout: "Compiling program"},
+					{Stderr: "warning"},
+					{Report: &CompilationReport{Success: true, Threads: 1}},
+				}))
+			})
+			It("reports errors of the compiler at the end of the streamed output", func() {
+				s.compileWithReport = func(*CtxConfig) (*CompilationReport, error) {
+					return nil, errors.New("no space left on device")
+				}
+				req := compileRequest(&Activation{Code: "print_ln('a')"})
+				req.Header.Set("Accept", ContentTypeNDJSON)
+				s.CompileOnlyHandler(rr, req)
+				Expect(rr.Code).To(Equal(http.StatusOK))
+				Expect(rr.Body.String()).To(Equal(`{"error":"error compiling the code: no space left on device"}` + "\n"))
+			})
+			It("responds with 403 if the program is not allow-listed", func() {
+				config.ProgramAllowList, _ = allowlist.New([]string{allowlist.Hash("print_ln('a')")}, nil)
+				s.CompileOnlyHandler(rr, compileRequest(&Activation{Code: "print_ln('b')"}))
+				Expect(rr.Code).To(Equal(http.StatusForbidden))
+				Expect(rr.Body.String()).To(HavePrefix("program cannot be compiled: program is not allow-listed"))
+				rr = httptest.NewRecorder()
+				s.CompileOnlyHandler(rr, compileRequest(&Activation{Code: "print_ln('a')"}))
+				Expect(rr.Code).To(Equal(http.StatusOK))
+			})
+			It("responds with 400 if no code is provided", func() {
+				s.CompileOnlyHandler(rr, compileRequest(&Activation{}))
+				Expect(rr.Code).To(Equal(http.StatusBadRequest))
+				Expect(rr.Body.String()).To(Equal("code must be provided"))
+			})
+			It("responds with 401 if the request is not authorized", func() {
+				req := compileRequest(&Activation{Code: "print_ln('a')"})
+				req.Header.Del("Authorization")
+				s.CompileOnlyHandler(rr, req)
+				Expect(rr.Code).To(Equal(http.StatusUnauthorized))
+			})
+		})
+		Context("when requesting the capabilities", func() {
+			It("responds with the capabilities of the deployment", func() {
+				req, _ := http.NewRequest("GET", "/capabilities", nil)
+				s.CapabilitiesHandler(rr, req)
+				Expect(rr.Code).To(Equal(http.StatusOK))
+				Expect(rr.Header().Get("Content-Type")).To(Equal(ContentTypeJSON))
+				var c Capabilities
+				Expect(json.Unmarshal(rr.Body.Bytes(), &c)).To(Succeed())
+				Expect(c.ContentTypes).To(Equal([]string{ContentTypeJSON}))
+			})
+			It("responds with 405 for other methods than GET", func() {
+				req, _ := http.NewRequest("POST", "/capabilities", nil)
+				s.CapabilitiesHandler(rr, req)
+				Expect(rr.Code).To(Equal(http.StatusMethodNotAllowed))
+			})
+		})
+		Context("when requesting the metrics", func() {
+			It("responds with the statistics of the tuple streamers", func() {
+				TupleStreamerMetrics.Add("bit_gfp", StreamerStats{Streamers: 1, Stalls: 2, StallTime: time.Second})
+				req, _ := http.NewRequest("GET", MetricsPath, nil)
+				s.MetricsHandler(rr, req)
+				Expect(rr.Code).To(Equal(http.StatusOK))
+				var m Metrics
+				Expect(json.Unmarshal(rr.Body.Bytes(), &m)).To(Succeed())
+				Expect(m.TupleStreamers).To(HaveKey("bit_gfp"))
+				Expect(m.TupleStreamers["bit_gfp"].Stalls).To(BeNumerically(">=", 2))
+			})
+		})
 		Context("when going through activation handler", func() {
 			var (
 				req    *http.Request
@@ -296,12 +958,84 @@ var _ = Describe("Server", func() {
 					errCh:  errCh,
 				}
 				s.player = player
-				s.respCh = respCh
-				s.errCh = errCh
+				s.newSession = func() *session {
+					return &session{respCh: respCh, errCh: errCh}
+				}
 				s.activate = func(*CtxConfig) ([]byte, error) {
 					return []byte{}, nil
 				}
-				s.executor = &FakeExecutor{}
+			})
+			Context("when the output is streamed", func() {
+				var player *FakePlayerWithIO
+				BeforeEach(func() {
+					s.config.OutputStreamBufferSize = 32
+					player = s.player.(*FakePlayerWithIO)
+				})
+				It("writes the values to the response while they are received", func() {
+					player.start = func() {
+						go func() {
+							conf.Output <- []string{"a", "b"}
+							conf.Output <- []string{"c"}
+							close(conf.Output)
+							respCh <- []byte(`{"response":null}`)
+						}()
+					}
+					s.ActivationHandler(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusOK))
+					Expect(rr.Header().Get("Content-Type")).To(Equal(ContentTypeJSON))
+					Expect(rr.Body.String()).To(Equal(`{"response":["a","b","c"]}`))
+				})
+				It("appends the error the activation failed with", func() {
+					player.start = func() {
+						go func() {
+							conf.Output <- []string{"a"}
+							close(conf.Output)
+							errCh <- errors.New("connection lost")
+						}()
+					}
+					s.ActivationHandler(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusOK))
+					Expect(rr.Body.String()).To(Equal(`{"response":["a"],"error":"error while talking to Discovery: connection lost"}`))
+				})
+				Context("when the results are cached", func() {
+					var store *results.MemoryStore
+					BeforeEach(func() {
+						store = results.NewMemoryStore(results.DefaultMaxResults, results.DefaultTTL)
+						s.config.ResultCache = store
+						player.start = func() {
+							go func() {
+								conf.Output <- []string{"a", "b"}
+								conf.Output <- []string{"c"}
+								close(conf.Output)
+								respCh <- []byte(`{"response":null}`)
+							}()
+						}
+					})
+					It("caches the streamed values", func() {
+						s.ActivationHandler(rr, req)
+						Expect(rr.Code).To(Equal(http.StatusOK))
+						e, err := store.Get(gameID)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(e.Result).To(MatchJSON(`{"response":["a","b","c"]}`))
+					})
+					It("does not cache the streamed values exceeding the maximum size", func() {
+						s.config.MaxResultSize = 8
+						s.ActivationHandler(rr, req)
+						Expect(rr.Body.String()).To(Equal(`{"response":["a","b","c"]}`))
+						_, err := store.Get(gameID)
+						Expect(err).To(Equal(results.ErrNotFound))
+					})
+				})
+				It("responds as usual if nothing was streamed", func() {
+					player.start = func() {
+						go func() {
+							close(conf.Output)
+							errCh <- errors.New("connection lost")
+						}()
+					}
+					s.ActivationHandler(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusInternalServerError))
+				})
 			})
 			Context("when execution finishes with success", func() {
 				It("responds with 200", func() {
@@ -310,11 +1044,182 @@ var _ = Describe("Server", func() {
 					code := rr.Code
 					Expect(code).To(Equal(http.StatusOK))
 				})
+				It("encodes the result with protobuf if requested", func() {
+					s.config.AcceptedContentTypes = []string{ContentTypeJSON, ContentTypeProtobuf}
+					req.Header.Add("Accept", ContentTypeProtobuf)
+					respCh <- []byte(`{"response":["a","b"]}`)
+					s.ActivationHandler(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusOK))
+					Expect(rr.Header().Get("Content-Type")).To(Equal(ContentTypeProtobuf))
+					var res apb.Result
+					Expect(proto.Unmarshal(rr.Body.Bytes(), &res)).To(Succeed())
+					Expect(res.Response).To(Equal([]string{"a", "b"}))
+				})
+				It("notifies about the successful game", func() {
+					notifier := &fakeNotifier{events: make(chan notify.Event, 1)}
+					s.config.Notifications = notify.NewDispatcher(l, notifier)
+					respCh <- []byte{}
+					s.ActivationHandler(rr, req)
+					var e notify.Event
+					Eventually(notifier.events).Should(Receive(&e))
+					Expect(e.Name).To(Equal(notify.GameSucceeded))
+					Expect(e.GameID).To(Equal(gameID))
+				})
+				It("reports the discovery endpoint in the response header", func() {
+					respCh <- []byte{}
+					s.ActivationHandler(rr, req)
+					Expect(rr.Header().Get(discoveryEndpointHeader)).To(Equal("discovery:8080"))
+				})
+				It("reports the expected duration of programs activated before in the response header", func() {
+					s.estimates.observeActivation(conf.Act, time.Second, 2*time.Second)
+					respCh <- []byte{}
+					s.ActivationHandler(rr, req)
+					Expect(rr.Header().Get(expectedDurationP50Header)).To(Equal("3s"))
+					Expect(rr.Header().Get(expectedDurationP90Header)).To(Equal("3s"))
+					Expect(rr.Header().Get(expectedDurationP99Header)).To(Equal("3s"))
+					completion, err := time.Parse(time.RFC3339, rr.Header().Get(estimatedCompletionHeader))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(completion).To(BeTemporally("~", time.Now().Add(3*time.Second), 2*time.Second))
+				})
+				It("reports no expected duration for programs not activated before", func() {
+					respCh <- []byte{}
+					s.ActivationHandler(rr, req)
+					Expect(rr.Header().Get(expectedDurationP50Header)).To(BeEmpty())
+					Expect(rr.Header().Get(estimatedCompletionHeader)).To(BeEmpty())
+				})
+			})
+			Context("when a result delivery timeout is configured", func() {
+				var player *FakePlayerWithIO
+				BeforeEach(func() {
+					s.config.ResultDeliveryTimeout = 10 * time.Millisecond
+					conf.AuthorizedUser = "someID"
+					player = s.player.(*FakePlayerWithIO)
+				})
+				It("responds with the pending execution if the delivery exceeds the timeout", func() {
+					player.start = func() {
+						close(conf.Delivery)
+					}
+					s.ActivationHandler(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusAccepted))
+					Expect(rr.Header().Get("Location")).To(Equal(ExecutionsPath + gameID))
+					Expect(rr.Body.String()).To(Equal(fmt.Sprintf(`{"gameID":"%s","status":"SucceededDeliveryPending"}`, gameID)))
+
+					respCh <- []byte(fmt.Sprintf(`{"response":["%s"]}`, gameID))
+					Eventually(func() string {
+						ex, _ := s.executions.get(gameID)
+						return ex.Status
+					}).Should(Equal(ExecutionSucceeded))
+					ex, _ := s.executions.get(gameID)
+					Expect(ex.Response).To(Equal([]string{gameID}))
+				})
+				It("responds as usual if the result is delivered in time", func() {
+					player.start = func() {
+						close(conf.Delivery)
+						respCh <- []byte{}
+					}
+					s.ActivationHandler(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusOK))
+					_, ok := s.executions.get(gameID)
+					Expect(ok).To(BeFalse())
+				})
+			})
+			Context("when the result is written to amphora", func() {
+				var (
+					player        *FakePlayerWithIO
+					amphoraClient *fakeAmphoraClient
+				)
+				BeforeEach(func() {
+					conf.Act.Output.Type = AmphoraSecret
+					amphoraClient = &fakeAmphoraClient{}
+					conf.Spdz.AmphoraClient = amphoraClient
+					player = s.player.(*FakePlayerWithIO)
+					player.history = fsm.NewHistory()
+					player.history.AddState(Playing)
+				})
+				It("responds once all players finished the game successfully", func() {
+					player.start = func() {
+						go func() {
+							respCh <- []byte(fmt.Sprintf(`{"response":["%s"]}`, gameID))
+							close(conf.GameSucceeded)
+						}()
+					}
+					s.ActivationHandler(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusOK))
+					Expect(amphoraClient.deleted).To(BeEmpty())
+				})
+				It("discards the output if another player failed", func() {
+					player.start = func() {
+						go func() {
+							respCh <- []byte(fmt.Sprintf(`{"response":["%s"]}`, gameID))
+							errCh <- errors.New("game failed with error: GameError")
+						}()
+					}
+					s.ActivationHandler(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusInternalServerError))
+					Expect(amphoraClient.deleted).To(Equal([]string{gameID}))
+				})
+				It("discards nothing if the player did not start playing", func() {
+					player.history = fsm.NewHistory()
+					errCh <- errors.New("game failed with error: GameError")
+					s.ActivationHandler(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusInternalServerError))
+					Expect(amphoraClient.deleted).To(BeEmpty())
+				})
+			})
+			Context("when activations run concurrently", func() {
+				It("delivers the outcome of each activation to its own handler", func() {
+					var (
+						mux      sync.Mutex
+						sessions []*session
+					)
+					s.newSession = func() *session {
+						mux.Lock()
+						defer mux.Unlock()
+						sess := newSession()
+						sessions = append(sessions, sess)
+						return sess
+					}
+					sessionCount := func() int {
+						mux.Lock()
+						defer mux.Unlock()
+						return len(sessions)
+					}
+					activate := func(id string) *httptest.ResponseRecorder {
+						c := *conf
+						c.Act = &Activation{GameID: id}
+						r := req.WithContext(context.WithValue(context.Background(), ctxConf, &c))
+						rec := httptest.NewRecorder()
+						s.ActivationHandler(rec, r)
+						return rec
+					}
+					first, second := make(chan *httptest.ResponseRecorder), make(chan *httptest.ResponseRecorder)
+					go func() { first <- activate("first") }()
+					Eventually(sessionCount).Should(Equal(1))
+					go func() { second <- activate("second") }()
+					Eventually(sessionCount).Should(Equal(2))
+
+					// The activation started last finishes first.
+					sessions[1].respCh <- []byte(`{"response":["second"]}`)
+					Expect((<-second).Body.String()).To(Equal(`{"response":["second"]}`))
+					sessions[0].execErrCh <- errors.New("some error")
+					rec := <-first
+					Expect(rec.Code).To(Equal(http.StatusInternalServerError))
+					Expect(rec.Body.String()).To(Equal("error during MPC execution: some error"))
+				})
+			})
+			Context("when the game is pinned to another pod", func() {
+				It("responds with a 409", func() {
+					conf.Act.PodAffinity = []string{"other-pod"}
+					s.pod = "ephemeral-0"
+					s.ActivationHandler(rr, req)
+					Expect(rr.Code).To(Equal(http.StatusConflict))
+					Expect(rr.Body.String()).To(Equal(fmt.Sprintf("game %s is pinned to pod other-pod for player 0, but the activation was received by pod ephemeral-0", gameID)))
+				})
 			})
 			Context("when execution finishes with error", func() {
 				Context("when ephemeral error happens", func() {
 					It("responds with a 500", func() {
-						s.errCh <- errors.New("some error")
+						errCh <- errors.New("some error")
 						s.ActivationHandler(rr, req)
 						code := rr.Code
 						respBody := rr.Body.String()
@@ -322,6 +1227,51 @@ var _ = Describe("Server", func() {
 						Expect(respBody).To(Equal("error while talking to Discovery: some error"))
 					})
 				})
+				Context("when proxy entries cannot be created for all players", func() {
+					It("responds with the skipped players", func() {
+						execErrCh := make(chan error, 1)
+						s.newSession = func() *session {
+							return &session{respCh: respCh, errCh: errCh, execErrCh: execErrCh}
+						}
+						execErrCh <- &ProxyEntriesError{Diagnosis: &ProxyEntriesDiagnosis{
+							Entries: []*ProxyConfig{{Host: "10.0.0.2", Port: "30002", LocalPort: "5002"}},
+							Skipped: []SkippedPlayer{{ID: 0, Reason: SkippedSelf}, {ID: 1, Reason: SkippedMissingPort}},
+						}}
+						s.ActivationHandler(rr, req)
+						Expect(rr.Code).To(Equal(http.StatusInternalServerError))
+						Expect(rr.Header().Get("Content-Type")).To(Equal(ContentTypeJSON))
+						Expect(rr.Body.String()).To(MatchJSON(`{
+							"error": "error during MPC execution: could not get all ProxyEntries, skipped players: 0 (self), 1 (missing port)",
+							"proxyEntries": [{"host": "10.0.0.2", "port": "30002", "localPort": "5002"}],
+							"skipped": [{"id": 0, "reason": "self"}, {"id": 1, "reason": "missing port"}]
+						}`))
+					})
+				})
+				Context("when the diagnostics of the execution have been requested", func() {
+					It("responds with the diagnostics", func() {
+						execErrCh := make(chan error, 1)
+						s.newSession = func() *session {
+							return &session{respCh: respCh, errCh: errCh, execErrCh: execErrCh}
+						}
+						execErrCh <- &DiagnosedError{
+							Err: errors.New("amphora read error"),
+							Diagnostics: &Diagnostics{
+								Amphora: []AmphoraInteraction{{Operation: AmphoraGet, SecretID: "a", DurationMs: 5, Error: "amphora read error"}},
+							},
+						}
+						s.ActivationHandler(rr, req)
+						Expect(rr.Code).To(Equal(http.StatusInternalServerError))
+						Expect(rr.Header().Get("Content-Type")).To(Equal(ContentTypeJSON))
+						Expect(rr.Body.String()).To(MatchJSON(`{
+							"error": "error during MPC execution: amphora read error",
+							"diagnostics": {
+								"inputBytes": 0,
+								"outputBytes": 0,
+								"amphora": [{"operation": "get", "secretId": "a", "size": 0, "durationMs": 5, "status": 0, "error": "amphora read error"}]
+							}
+						}`))
+					})
+				})
 				Context("when the timeout is reached during the execution", func() {
 					It("responds with a 500", func() {
 						conf.Spdz = &SPDZEngineTypedConfig{
@@ -489,19 +1439,38 @@ var _ = Describe("PlayerWithIO", func() {
 			Expect(p.History()).To(Equal(history))
 		})
 	})
+	Context("when fetching the discovery endpoint", func() {
+		It("returns the endpoint of the transport client", func() {
+			p := &PlayerWithIO{Client: &FakeTransportClient{}}
+			Expect(p.DiscoveryEndpoint()).To(Equal("discovery:8080"))
+		})
+	})
 })
 
 type FakePlayerWithIO struct {
-	respCh chan []byte
-	errCh  chan error
+	respCh    chan []byte
+	errCh     chan error
+	start     func()
+	history   *fsm.History
+	cancelled bool
 }
 
 func (f *FakePlayerWithIO) Start() {
-	return
+	if f.start != nil {
+		f.start()
+	}
 }
 
 func (f *FakePlayerWithIO) History() *fsm.History {
-	return nil
+	return f.history
+}
+
+func (f *FakePlayerWithIO) DiscoveryEndpoint() string {
+	return "discovery:8080"
+}
+
+func (f *FakePlayerWithIO) Cancel() {
+	f.cancelled = true
 }
 
 func requestWithContext(path string, act *Activation) *http.Request {
@@ -518,3 +1487,31 @@ func requestWithContext(path string, act *Activation) *http.Request {
 	req = req.WithContext(ctx)
 	return req
 }
+
+type fakeAmphoraClient struct {
+	// deleted are the ids of the secret shares deleted.
+	deleted []string
+}
+
+func (f *fakeAmphoraClient) GetSecretShare(id string, _ string) (amphora.SecretShare, error) {
+	return amphora.SecretShare{SecretID: id}, nil
+}
+func (f *fakeAmphoraClient) CreateSecretShare(*amphora.SecretShare) error {
+	return nil
+}
+func (f *fakeAmphoraClient) CreateSecretShareFromReader(string, []amphora.Tag, io.Reader) error {
+	return nil
+}
+func (f *fakeAmphoraClient) DeleteSecretShare(secretID string) error {
+	f.deleted = append(f.deleted, secretID)
+	return nil
+}
+
+type fakeNotifier struct {
+	events chan notify.Event
+}
+
+func (f *fakeNotifier) Notify(e notify.Event) error {
+	f.events <- e
+	return nil
+}
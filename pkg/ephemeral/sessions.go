@@ -0,0 +1,306 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// SessionsPath is the path computation sessions are opened on, followed by the id of a session to retrieve or close it.
+const SessionsPath = "/sessions/"
+
+// ComputationSession groups games activated one after another by the same client. The pod affinity, compiler options
+// and labels are fixed when the session is opened and apply to all of its games. The network to the other players is
+// kept between the games of the session if proxy ports are assigned per game.
+type ComputationSession struct {
+	ID              string            `json:"id"`
+	PodAffinity     []string          `json:"podAffinity,omitempty"`
+	CompilerOptions *CompilerOptions  `json:"compilerOptions,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	// Games is the number of games activated in the session so far.
+	Games int `json:"games"`
+	// owner is the user that opened the session, only they may activate games in it.
+	owner string
+	// active is the number of games of the session in flight.
+	active int
+	timer  *time.Timer
+}
+
+// sameParameters returns true if both sessions fix the same parameters.
+func (c *ComputationSession) sameParameters(other *ComputationSession) bool {
+	return reflect.DeepEqual(c.PodAffinity, other.PodAffinity) &&
+		reflect.DeepEqual(c.CompilerOptions, other.CompilerOptions) &&
+		reflect.DeepEqual(c.Labels, other.Labels)
+}
+
+// disarm stops the idle timeout of the session, if any.
+func (c *ComputationSession) disarm() {
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+}
+
+// newComputationSessions returns an empty store of sessions. Sessions without games in flight are closed after the
+// given idle timeout, in which case onClose is called with the id of the session. Sessions are only closed on request
+// if the idle timeout is not positive.
+func newComputationSessions(idleTimeout time.Duration, onClose func(id string)) *computationSessions {
+	return &computationSessions{
+		byID:        map[string]*ComputationSession{},
+		idleTimeout: idleTimeout,
+		onClose:     onClose,
+	}
+}
+
+// computationSessions keeps the open computation sessions.
+type computationSessions struct {
+	mux         sync.Mutex
+	byID        map[string]*ComputationSession
+	idleTimeout time.Duration
+	onClose     func(id string)
+}
+
+// open opens the given session for the given user. Opening a session again with the same parameters has no effect, so
+// that clients can retry. Returns whether the session has been created.
+func (c *computationSessions) open(session ComputationSession, owner string) (ComputationSession, bool, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if existing, ok := c.byID[session.ID]; ok {
+		if existing.owner != owner || !existing.sameParameters(&session) {
+			return ComputationSession{}, false, fmt.Errorf("session %s is already open with different parameters", session.ID)
+		}
+		return *existing, false, nil
+	}
+	session.owner = owner
+	session.Games = 0
+	c.byID[session.ID] = &session
+	c.arm(&session)
+	return session, true, nil
+}
+
+// get returns the session with the given id if it is owned by the given user.
+func (c *computationSessions) get(id, owner string) (ComputationSession, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	session, ok := c.byID[id]
+	if !ok || session.owner != owner {
+		return ComputationSession{}, false
+	}
+	return *session, true
+}
+
+// close closes the session with the given id if it is owned by the given user. Returns false if there is no such
+// session and an error if games of the session are in flight.
+func (c *computationSessions) close(id, owner string) (bool, error) {
+	c.mux.Lock()
+	session, ok := c.byID[id]
+	if !ok || session.owner != owner {
+		c.mux.Unlock()
+		return false, nil
+	}
+	if session.active > 0 {
+		c.mux.Unlock()
+		return true, fmt.Errorf("session %s cannot be closed while %d games are in flight", id, session.active)
+	}
+	session.disarm()
+	delete(c.byID, id)
+	c.mux.Unlock()
+	c.onClose(id)
+	return true, nil
+}
+
+// begin applies the parameters of the session the activation belongs to and registers the game with the session.
+// Parameters of the activation contradicting the ones of the session are rejected. Each successful call must be
+// followed by a call of end once the game has finished.
+func (c *computationSessions) begin(act *Activation, owner string) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	session, ok := c.byID[act.SessionID]
+	if !ok || session.owner != owner {
+		return fmt.Errorf("no session found with id %s", act.SessionID)
+	}
+	if len(act.PodAffinity) > 0 && !reflect.DeepEqual(act.PodAffinity, session.PodAffinity) {
+		return fmt.Errorf("the pod affinity is fixed by session %s", act.SessionID)
+	}
+	if act.CompilerOptions != nil && !reflect.DeepEqual(act.CompilerOptions, session.CompilerOptions) {
+		return fmt.Errorf("the compiler options are fixed by session %s", act.SessionID)
+	}
+	for k, v := range act.Labels {
+		if fixed, ok := session.Labels[k]; ok && fixed != v {
+			return fmt.Errorf("the label %s is fixed by session %s", k, act.SessionID)
+		}
+	}
+	act.PodAffinity = session.PodAffinity
+	act.CompilerOptions = session.CompilerOptions
+	if len(session.Labels) > 0 {
+		labels := map[string]string{}
+		for k, v := range act.Labels {
+			labels[k] = v
+		}
+		for k, v := range session.Labels {
+			labels[k] = v
+		}
+		act.Labels = labels
+	}
+	session.Games++
+	session.active++
+	session.disarm()
+	return nil
+}
+
+// end unregisters a game registered by begin. The idle timeout of the session starts once no game is in flight.
+func (c *computationSessions) end(id string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	session, ok := c.byID[id]
+	if !ok {
+		return
+	}
+	session.active--
+	if session.active == 0 {
+		c.arm(session)
+	}
+}
+
+// arm starts the idle timeout of the session. It must be called with the lock held.
+func (c *computationSessions) arm(session *ComputationSession) {
+	session.disarm()
+	if c.idleTimeout <= 0 {
+		return
+	}
+	id := session.ID
+	session.timer = time.AfterFunc(c.idleTimeout, func() {
+		c.mux.Lock()
+		current, ok := c.byID[id]
+		if !ok || current != session || current.active > 0 {
+			c.mux.Unlock()
+			return
+		}
+		delete(c.byID, id)
+		c.mux.Unlock()
+		c.onClose(id)
+	})
+}
+
+// SessionsHandler opens a computation session on POST /sessions, serves it on GET /sessions/<id> and closes it on
+// DELETE /sessions/<id>. Sessions are only visible to the user that opened them.
+func (s *Server) SessionsHandler(writer http.ResponseWriter, req *http.Request) {
+	user, err := GetUserFromAuthHeader(req.Header.Get("Authorization"), s.authUserIdField)
+	if err != nil {
+		msg := "unauthorized request"
+		writer.WriteHeader(http.StatusUnauthorized)
+		writer.Write([]byte(msg))
+		s.logger.Errorw(msg, "Error", err)
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(req.URL.Path, strings.TrimSuffix(SessionsPath, "/")), "/")
+	switch {
+	case req.Method == http.MethodPost && id == "":
+		s.openSession(writer, req, user)
+	case req.Method == http.MethodGet && id != "":
+		session, ok := s.sessions.get(id, user)
+		if !ok {
+			s.writeSessionNotFound(writer, id)
+			return
+		}
+		writeSession(writer, http.StatusOK, session)
+	case req.Method == http.MethodDelete && id != "":
+		found, err := s.sessions.close(id, user)
+		if !found {
+			s.writeSessionNotFound(writer, id)
+			return
+		}
+		if err != nil {
+			msg := err.Error()
+			writer.WriteHeader(http.StatusConflict)
+			writer.Write([]byte(msg))
+			s.logger.Error(msg)
+			return
+		}
+		s.logger.Infow("Closed the session", "SessionID", id)
+		writer.WriteHeader(http.StatusNoContent)
+	default:
+		msg := "sessions are opened with POST and retrieved or closed with GET or DELETE on their path"
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+	}
+}
+
+// openSession opens the session described by the request body.
+func (s *Server) openSession(writer http.ResponseWriter, req *http.Request, user string) {
+	var session ComputationSession
+	body := newLimitedBody(req, s.config.MaxRequestSize)
+	err := decodeJSON(body, &session)
+	if writeRequestTooLarge(writer, s.logger, body) {
+		return
+	}
+	if err == nil {
+		err = s.validateSession(&session)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("invalid session: %s", err)
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return
+	}
+	opened, created, err := s.sessions.open(session, user)
+	if err != nil {
+		msg := err.Error()
+		writer.WriteHeader(http.StatusConflict)
+		writer.Write([]byte(msg))
+		s.logger.Error(msg)
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+		s.logger.Infow("Opened a session", "SessionID", session.ID)
+	}
+	writer.Header().Set("Location", SessionsPath+session.ID)
+	writeSession(writer, status, opened)
+}
+
+// validateSession verifies the parameters fixed by the session.
+func (s *Server) validateSession(session *ComputationSession) error {
+	if !isValidUUID(session.ID) {
+		return fmt.Errorf("id %s is not a valid UUID", session.ID)
+	}
+	if len(session.PodAffinity) > 0 && len(session.PodAffinity) != int(s.config.PlayerCount) {
+		return fmt.Errorf("pod affinity must name a pod for each of the %d players", s.config.PlayerCount)
+	}
+	if err := validateCompilerOptions(session.CompilerOptions, s.config); err != nil {
+		return fmt.Errorf("invalid compiler options: %s", err)
+	}
+	if err := validateLabels(session.Labels); err != nil {
+		return fmt.Errorf("invalid labels: %s", err)
+	}
+	return nil
+}
+
+// writeSessionNotFound responds that the session does not exist. Sessions of other users are reported as missing to
+// not disclose their existence.
+func (s *Server) writeSessionNotFound(writer http.ResponseWriter, id string) {
+	msg := fmt.Sprintf("no session found with id %s", id)
+	writer.WriteHeader(http.StatusNotFound)
+	writer.Write([]byte(msg))
+	s.logger.Errorw(msg, "SessionID", id)
+}
+
+// writeSession responds with the session as JSON.
+func writeSession(writer http.ResponseWriter, status int, session ComputationSession) {
+	body, _ := json.Marshal(session)
+	writer.Header().Set("Content-Type", ContentTypeJSON)
+	writer.WriteHeader(status)
+	writer.Write(body)
+}
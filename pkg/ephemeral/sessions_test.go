@@ -0,0 +1,144 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Sessions", func() {
+	const sessionID = "a5d8a2b4-4c4e-4b8e-9a53-1f0b6a4c8f11"
+	authHeader := func(user string) string {
+		claims := base64.StdEncoding.WithPadding(base64.NoPadding).EncodeToString([]byte(fmt.Sprintf(`{"sub":"%s"}`, user)))
+		return fmt.Sprintf("Bearer header.%s.signature", claims)
+	}
+	var (
+		s      *Server
+		rr     *httptest.ResponseRecorder
+		closed chan string
+	)
+	request := func(method, path, body, user string) *http.Request {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Add("Authorization", authHeader(user))
+		return req
+	}
+	BeforeEach(func() {
+		s = NewServer("sub", nil, nil, nil, zap.NewNop().Sugar(), &SPDZEngineTypedConfig{PlayerCount: 2})
+		closed = make(chan string, 1)
+		s.OnSessionClosed(func(id string) {
+			closed <- id
+		})
+		rr = httptest.NewRecorder()
+	})
+	open := func(body string) {
+		s.SessionsHandler(rr, request(http.MethodPost, "/sessions", body, "someID"))
+	}
+	Context("when opening a session", func() {
+		It("responds with the session", func() {
+			open(fmt.Sprintf(`{"id":"%s","podAffinity":["pod-0","pod-1"],"labels":{"loop":"fed"}}`, sessionID))
+			Expect(rr.Code).To(Equal(http.StatusCreated))
+			Expect(rr.Header().Get("Location")).To(Equal(SessionsPath + sessionID))
+			var session ComputationSession
+			Expect(json.Unmarshal(rr.Body.Bytes(), &session)).To(Succeed())
+			Expect(session.PodAffinity).To(Equal([]string{"pod-0", "pod-1"}))
+		})
+		It("accepts opening the same session again", func() {
+			open(fmt.Sprintf(`{"id":"%s"}`, sessionID))
+			rr = httptest.NewRecorder()
+			open(fmt.Sprintf(`{"id":"%s"}`, sessionID))
+			Expect(rr.Code).To(Equal(http.StatusOK))
+		})
+		It("rejects opening the session with different parameters", func() {
+			open(fmt.Sprintf(`{"id":"%s"}`, sessionID))
+			rr = httptest.NewRecorder()
+			open(fmt.Sprintf(`{"id":"%s","labels":{"loop":"fed"}}`, sessionID))
+			Expect(rr.Code).To(Equal(http.StatusConflict))
+		})
+		It("rejects invalid ids", func() {
+			open(`{"id":"session"}`)
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+			Expect(rr.Body.String()).To(Equal("invalid session: id session is not a valid UUID"))
+		})
+		It("rejects an incomplete pod affinity", func() {
+			open(fmt.Sprintf(`{"id":"%s","podAffinity":["pod-0"]}`, sessionID))
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+	Context("when a session is open", func() {
+		BeforeEach(func() {
+			open(fmt.Sprintf(`{"id":"%s","podAffinity":["pod-0","pod-1"],"labels":{"loop":"fed"}}`, sessionID))
+			rr = httptest.NewRecorder()
+		})
+		It("serves it to its owner only", func() {
+			s.SessionsHandler(rr, request(http.MethodGet, SessionsPath+sessionID, "", "someID"))
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			rr = httptest.NewRecorder()
+			s.SessionsHandler(rr, request(http.MethodGet, SessionsPath+sessionID, "", "otherID"))
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+		})
+		It("closes it on request", func() {
+			s.SessionsHandler(rr, request(http.MethodDelete, SessionsPath+sessionID, "", "someID"))
+			Expect(rr.Code).To(Equal(http.StatusNoContent))
+			Expect(closed).To(Receive(Equal(sessionID)))
+			_, ok := s.sessions.get(sessionID, "someID")
+			Expect(ok).To(BeFalse())
+		})
+		It("applies its parameters to the games", func() {
+			act := &Activation{SessionID: sessionID, Labels: map[string]string{"step": "1"}}
+			Expect(s.sessions.begin(act, "someID")).To(Succeed())
+			defer s.sessions.end(sessionID)
+			Expect(act.PodAffinity).To(Equal([]string{"pod-0", "pod-1"}))
+			Expect(act.Labels).To(Equal(map[string]string{"loop": "fed", "step": "1"}))
+			session, _ := s.sessions.get(sessionID, "someID")
+			Expect(session.Games).To(Equal(1))
+		})
+		It("rejects games contradicting its parameters", func() {
+			act := &Activation{SessionID: sessionID, PodAffinity: []string{"pod-1", "pod-0"}}
+			err := s.sessions.begin(act, "someID")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(fmt.Sprintf("the pod affinity is fixed by session %s", sessionID)))
+		})
+		It("rejects games of other users", func() {
+			err := s.sessions.begin(&Activation{SessionID: sessionID}, "otherID")
+			Expect(err).To(HaveOccurred())
+		})
+		It("is not closed while games are in flight", func() {
+			Expect(s.sessions.begin(&Activation{SessionID: sessionID}, "someID")).To(Succeed())
+			s.SessionsHandler(rr, request(http.MethodDelete, SessionsPath+sessionID, "", "someID"))
+			Expect(rr.Code).To(Equal(http.StatusConflict))
+			s.sessions.end(sessionID)
+		})
+	})
+	Context("when a session is idle", func() {
+		It("closes it after the idle timeout", func() {
+			sessions := newComputationSessions(10*time.Millisecond, func(id string) {
+				closed <- id
+			})
+			_, _, err := sessions.open(ComputationSession{ID: sessionID}, "someID")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sessions.begin(&Activation{SessionID: sessionID}, "someID")).To(Succeed())
+			Consistently(closed, 50*time.Millisecond).ShouldNot(Receive())
+			sessions.end(sessionID)
+			Eventually(closed).Should(Receive(Equal(sessionID)))
+		})
+	})
+	It("rejects unauthorized requests", func() {
+		req, _ := http.NewRequest(http.MethodGet, SessionsPath+sessionID, nil)
+		s.SessionsHandler(rr, req)
+		Expect(rr.Code).To(Equal(http.StatusUnauthorized))
+	})
+})
@@ -0,0 +1,73 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// DefaultSimulatorExecutable is the emulator of MP-SPDZ used by the plaintext simulator if not configured otherwise.
+const DefaultSimulatorExecutable = "./emulate.x"
+
+// NewPlaintextSimulator returns a simulator executing the programs compiled by the given engine with the emulator of
+// the given config.
+func NewPlaintextSimulator(engine *SPDZEngine, conf *SimulatorConfig) *PlaintextSimulator {
+	return &PlaintextSimulator{engine: engine, conf: conf}
+}
+
+// PlaintextSimulator is a backend executing the programs in cleartext with the emulator of MP-SPDZ instead of running
+// an MPC protocol with the other players. The game is still coordinated by the discovery service, but no network is
+// established between the players and no tuples are fetched. Each player emulates the program on its own, playing the
+// role of party 0. The input parameters are passed on to the program as they are, i.e., the clients provide the
+// cleartext values in place of their shares, and the outputs are returned in cleartext. The simulator is meant for the
+// functional testing of programs and of ephemeral itself, and must never be used with secret data.
+type PlaintextSimulator struct {
+	engine *SPDZEngine
+	conf   *SimulatorConfig
+}
+
+// Activate emulates the program of the given game, sends it the input parameters and waits for the response.
+func (p *PlaintextSimulator) Activate(ctx *CtxConfig) ([]byte, error) {
+	s := p.engine
+	logger := s.logger.With(labelFields(ctx.Act.Labels)...)
+	act := ctx.Act
+	if err := s.prepareWorkDir(ctx, !ctx.Compiled); err != nil {
+		msg := "error preparing the work directory"
+		logger.Errorw(msg, GameID, act.GameID)
+		return nil, fmt.Errorf("%s: %s", msg, err)
+	}
+	ctx.Preprocessing = PreprocessingNone
+	command := p.command(s.layout(ctx))
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		logger.Warnw("Emulating the program in cleartext, do not use with secret data", GameID, act.GameID, "command", command)
+		stdout, stderr, err := s.callRuntime(ctx, []string{command})
+		if err != nil {
+			logger.Errorw("Error while emulating the user code", GameID, act.GameID, "StdErr", string(stderr), "StdOut", string(stdout), "error", err)
+			ctx.ErrCh <- fmt.Errorf("error while emulating the user code: %v", err)
+			return
+		}
+		logger.Debugw("Emulation finished", GameID, act.GameID, "StdErr", string(stderr), "StdOut", string(stdout))
+	}()
+	// The emulator plays the role of party 0, hence it listens for the clients on the ports of party 0.
+	return s.feed(ctx, logger, s.feedPortsOf(act, 0), nil)
+}
+
+// command returns the command emulating the program of the given layout.
+func (p *PlaintextSimulator) command(l *gameLayout) string {
+	executable := p.conf.Executable
+	if executable == "" {
+		executable = DefaultSimulatorExecutable
+	}
+	command := fmt.Sprintf("%s %s", l.executable(executable), appName)
+	if len(p.conf.Args) > 0 {
+		command += " " + strings.Join(p.conf.Args, " ")
+	}
+	return command
+}
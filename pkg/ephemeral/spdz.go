@@ -6,22 +6,32 @@ package ephemeral
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"github.com/carbynestack/ephemeral/pkg/audit"
 	"github.com/carbynestack/ephemeral/pkg/castor"
+	"github.com/carbynestack/ephemeral/pkg/certs"
+	"github.com/carbynestack/ephemeral/pkg/depcheck"
 	d "github.com/carbynestack/ephemeral/pkg/discovery"
 	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
 	. "github.com/carbynestack/ephemeral/pkg/ephemeral/io"
 	"github.com/carbynestack/ephemeral/pkg/ephemeral/network"
+	"github.com/carbynestack/ephemeral/pkg/tracing"
 	. "github.com/carbynestack/ephemeral/pkg/types"
 	. "github.com/carbynestack/ephemeral/pkg/utils"
 	"github.com/google/uuid"
 	"io/ioutil"
 	"math/big"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -29,14 +39,14 @@ import (
 )
 
 const (
-	proxyAddress        = "localhost"
-	basePort            = int32(10000)
-	appName             = "mpc-program"
-	baseDir             = "/mp-spdz"
-	ipFile              = baseDir + "/ip-file"
-	tcpCheckerTimeout   = 50 * time.Millisecond
-	defaultPath         = baseDir + "/Programs/Source/" + appName + ".mpc"
-	defaultSchedulePath = baseDir + "/Programs/Schedules/" + appName + ".sch"
+	proxyAddress      = "localhost"
+	basePort          = int32(10000)
+	appName           = "mpc-program"
+	tcpCheckerTimeout = 50 * time.Millisecond
+	// baseDir and ipFile are the locations of the MP-SPDZ installation and the ip file in the ephemeral image, used
+	// if not configured otherwise.
+	baseDir = "/mp-spdz"
+	ipFile  = baseDir + "/ip-file"
 )
 
 // MPCEngine is an interface for an MPC runtime that performs the computation.
@@ -63,15 +73,41 @@ type SPDZWrapper struct {
 	respCh   chan []byte
 	errCh    chan error
 	logger   *zap.SugaredLogger
+
+	mux sync.Mutex
+	// players are the players of the PlayersReady event the computation was started for. Nil if not started yet.
+	players []*pb.Player
 }
 
-// Execute runs the MPC computation.
+// Execute runs the MPC computation. It is idempotent, i.e., a PlayersReady event resent by the discovery service
+// does not start the computation again.
 func (s *SPDZWrapper) Execute(event *pb.Event) error {
+	s.mux.Lock()
+	started := s.players != nil
+	if !started {
+		s.players = event.Players
+	}
+	s.mux.Unlock()
+	if started {
+		s.ignoreRepeated(event)
+		return nil
+	}
 	entries, err := s.getProxyEntries(event.Players)
 	if err != nil {
+		if _, ok := err.(*ProxyEntriesError); ok {
+			// Let the client know which players have been skipped.
+			s.errCh <- err
+		}
 		return err
 	}
 	s.ctx.ProxyEntries = entries
+	s.ctx.Compression = allSupport(event.Players, FeatureCompression)
+	insecure, err := agreeOnInsecurePreprocessing(event.Players, s.ctx.Spdz)
+	if err != nil {
+		s.errCh <- err
+		return err
+	}
+	s.ctx.InsecurePreprocessing = insecure
 	s.ctx.ErrCh = s.errCh
 	s.logger.Debug("Starting MPC execution")
 	res, err := s.activate(s.ctx)
@@ -84,33 +120,172 @@ func (s *SPDZWrapper) Execute(event *pb.Event) error {
 	return err
 }
 
-func (s *SPDZWrapper) getProxyEntries(pls []*pb.Player) ([]*ProxyConfig, error) {
-	if len(pls) == 1 {
-		return nil, errors.New("you must provide at least two players")
+// ignoreRepeated logs the changes of the players of a repeated PlayersReady event. The network of a running game is not
+// changed.
+func (s *SPDZWrapper) ignoreRepeated(event *pb.Event) {
+	s.mux.Lock()
+	added, removed, changed := diffPlayers(s.players, event.Players)
+	s.mux.Unlock()
+	if len(added)+len(removed)+len(changed) == 0 {
+		s.logger.Debugw("Ignoring repeated PlayersReady event", GameID, s.ctx.Act.GameID)
+		return
+	}
+	s.logger.Warnw("The players changed after the game has started, keeping the network of the game", GameID,
+		s.ctx.Act.GameID, "Added", added, "Removed", removed, "Changed", changed)
+}
+
+// diffPlayers returns the ids of the players added, removed and whose address changed between the given sets of
+// players, in order. Players are identified by the first occurrence of their id.
+func diffPlayers(before, after []*pb.Player) (added, removed, changed []int32) {
+	index := func(players []*pb.Player) map[int32]*pb.Player {
+		byID := make(map[int32]*pb.Player, len(players))
+		for _, pl := range players {
+			// Compensate the protobuf3 workaround, see DryRunProxyEntries.
+			if _, ok := byID[pl.Id-100]; !ok {
+				byID[pl.Id-100] = pl
+			}
+		}
+		return byID
+	}
+	old, current := index(before), index(after)
+	for id, pl := range current {
+		prev, ok := old[id]
+		switch {
+		case !ok:
+			added = append(added, id)
+		case prev.Ip != pl.Ip || prev.Port != pl.Port:
+			changed = append(changed, id)
+		}
+	}
+	for id := range old {
+		if _, ok := current[id]; !ok {
+			removed = append(removed, id)
+		}
 	}
+	for _, ids := range [][]int32{added, removed, changed} {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	}
+	return added, removed, changed
+}
+
+// playerFeatures returns the optional features this player advertises for the game to the other players.
+func playerFeatures(ctx *CtxConfig) []string {
+	var features []string
+	if c := ctx.Spdz.ProxyCompression; c != nil {
+		if requested := ctx.Act.Compression; requested != nil && *requested || requested == nil && c.ByDefault {
+			features = append(features, FeatureCompression)
+		}
+	}
+	if ctx.InsecurePreprocessing {
+		features = append(features, FeatureInsecurePreprocessing)
+	}
+	return features
+}
+
+// agreeOnInsecurePreprocessing returns whether the game generates fake preprocessing data, i.e., if any of the players
+// proposed it, e.g., as it cannot reach its Castor. As all players see the same PlayersReady event, they agree on the
+// outcome. An error is returned if fake preprocessing data is proposed while insecure preprocessing is disabled for
+// this player, as the players would compute on incompatible preprocessing data otherwise.
+func agreeOnInsecurePreprocessing(players []*pb.Player, conf *SPDZEngineTypedConfig) (bool, error) {
+	for _, pl := range players {
+		for _, f := range pl.Features {
+			if f != FeatureInsecurePreprocessing {
+				continue
+			}
+			if conf.InsecurePreprocessing == nil {
+				return false, fmt.Errorf("player %d proposed fake preprocessing data, but insecure preprocessing is disabled", d.PlayerIndex(pl))
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// allSupport returns whether all players advertised the given feature. As all players see the same PlayersReady
+// event, they agree on the outcome.
+func allSupport(players []*pb.Player, feature string) bool {
+	if len(players) == 0 {
+		return false
+	}
+	for _, pl := range players {
+		supported := false
+		for _, f := range pl.Features {
+			if f == feature {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return false
+		}
+	}
+	return true
+}
+
+// colocated returns whether the given player runs in the same cluster as this player and can be reached directly.
+func colocated(conf *ColocationConfig, player *pb.Player) bool {
+	return conf != nil && player.Cluster == conf.Cluster && player.LocalIp != ""
+}
+
+// DryRunProxyEntries computes the proxy entries for the players of the given PlayersReady event without applying them.
+// The players are processed in the order of their ids, if an id is used more than once the first player in the event
+// takes precedence.
+func (s *SPDZWrapper) DryRunProxyEntries(event *pb.Event) *ProxyEntriesDiagnosis {
 	// Copy to new Slice so that we don't modify the original Slice (just in case)
-	players := make([]*pb.Player, len(pls))
-	copy(players, pls)
-	sort.Slice(players, func(left, right int) bool {
+	players := make([]*pb.Player, len(event.Players))
+	copy(players, event.Players)
+	sort.SliceStable(players, func(left, right int) bool {
 		return players[left].Id < players[right].Id
 	})
-	var proxyEntries []*ProxyConfig
+	diagnosis := &ProxyEntriesDiagnosis{}
+	seen := map[int32]bool{}
 	for _, player := range players {
-		// TODO: remove this 100 hack, it is a temp workaround for protobuf3.
-		// Create proxy entries for all OTHER players
-		if (player.Id - 100) != s.ctx.Spdz.PlayerID {
-			proxyEntries = append(proxyEntries, &ProxyConfig{
+		id := d.PlayerIndex(player)
+		switch {
+		case seen[id]:
+			diagnosis.Skipped = append(diagnosis.Skipped, SkippedPlayer{ID: id, Reason: SkippedDuplicateID})
+			continue
+		case id == s.ctx.Spdz.PlayerID:
+			diagnosis.Skipped = append(diagnosis.Skipped, SkippedPlayer{ID: id, Reason: SkippedSelf})
+		case player.Port == 0:
+			diagnosis.Skipped = append(diagnosis.Skipped, SkippedPlayer{ID: id, Reason: SkippedMissingPort})
+		case colocated(s.ctx.Spdz.Colocation, player):
+			// Players in the same cluster are reached directly on the port MP-SPDZ listens on, bypassing the gateway.
+			diagnosis.Entries = append(diagnosis.Entries, &ProxyConfig{
+				Host:      player.LocalIp,
+				Port:      strconv.Itoa(int(d.BasePort + id)),
+				LocalPort: s.getLocalPortForPlayer(id),
+			})
+		default:
+			// Create proxy entries for all OTHER players
+			entry := &ProxyConfig{
 				Host:      player.Ip,
 				Port:      strconv.Itoa(int(player.Port)),
-				LocalPort: s.getLocalPortForPlayer(player.Id - 100),
-			})
+				LocalPort: s.getLocalPortForPlayer(id),
+			}
+			if s.ctx.Spdz.TlsEnabled {
+				// The gateway of the player presents the certificate of its party.
+				entry.ServerName = certs.PlayerName(s.ctx.Spdz.PartyNumber(id))
+			}
+			diagnosis.Entries = append(diagnosis.Entries, entry)
 		}
+		seen[id] = true
 	}
-	s.logger.Infow("Created ProxyEntries", "ProxyEntries", proxyEntries, "Players", players)
-	if len(proxyEntries) != len(players)-1 {
-		return nil, errors.New("could not get all ProxyEntries")
+	return diagnosis
+}
+
+func (s *SPDZWrapper) getProxyEntries(pls []*pb.Player) ([]*ProxyConfig, error) {
+	if len(pls) == 1 {
+		return nil, errors.New("you must provide at least two players")
+	}
+	diagnosis := s.DryRunProxyEntries(&pb.Event{Players: pls})
+	s.logger.Infow("Created ProxyEntries", "ProxyEntries", diagnosis.Entries, "Skipped", diagnosis.Skipped, "Players", pls)
+	if !diagnosis.complete() {
+		err := &ProxyEntriesError{Diagnosis: diagnosis}
+		s.logger.Errorw(err.Error(), GameID, s.ctx.Act.GameID)
+		return nil, err
 	}
-	return proxyEntries, nil
+	return diagnosis.Entries, nil
 }
 
 // getLocalPortForPlayer returns the port that is set by the proxy.
@@ -139,24 +314,63 @@ func NewSPDZEngine(logger *zap.SugaredLogger, cmder Executor, config *SPDZEngine
 	}
 	feeder := NewAmphoraFeeder(logger, config)
 	checker := network.NewTCPChecker(c)
-	proxy := network.NewProxy(logger, config, checker)
 	playerDataPaths, err := preparePlayerData(config)
 	if err != nil {
 		return nil, err
 	}
-	return &SPDZEngine{logger: logger,
+	var proxyPorts *network.PortSetAllocator
+	if config.ProxyPortRange != "" {
+		proxyPorts, err = network.NewPortSetAllocator(config.ProxyPortRange, config.PlayerCount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy port range: %v", err)
+		}
+	}
+	mpSpdz := mpSpdzConfig(config)
+	var compileCache *CompileCache
+	if config.CompileCacheSize > 0 {
+		compileCache = NewCompileCache(mpSpdz.BaseDir, filepath.Join(mpSpdz.BaseDir, "Programs/Cache"), []string{
+			"Programs/Schedules/" + appName + ".sch",
+			"Programs/Bytecode/" + appName + "-*.bc",
+		}, config.CompileCacheSize)
+	}
+	if config.InsecurePreprocessing != nil {
+		if config.InsecurePreprocessing.Fallback {
+			logger.Warn("INSECURE: Preprocessing data is generated locally if Castor is unreachable, do not use in production")
+		} else {
+			logger.Warn("INSECURE: Preprocessing data is generated locally instead of being fetched from Castor, do not use in production")
+		}
+	}
+	streamerFactory := DefaultCastorTupleStreamerFactory
+	var streamerPool *StreamerPool
+	if config.WarmPool != nil {
+		streamerPool = NewStreamerPool(config.WarmPool.MaxStreamers, config.WarmPool.MaxIdle)
+		streamerFactory = func(l *zap.SugaredLogger, tt castor.TupleType, conf *SPDZEngineTypedConfig, playerDataDir string, gameID uuid.UUID, threadNr int) (TupleStreamer, error) {
+			return streamerPool.Acquire(l, tt, conf, playerDataDir, gameID, threadNr)
+		}
+	}
+	engine := &SPDZEngine{logger: logger,
 		cmder:           cmder,
 		config:          config,
 		checker:         checker,
 		feeder:          feeder,
 		playerDataPaths: playerDataPaths,
-		sourceCodePath:  defaultPath,
-		schedulePath:    defaultSchedulePath,
-		proxy:           proxy,
-		baseDir:         baseDir,
-		ipFile:          ipFile,
-		streamerFactory: DefaultCastorTupleStreamerFactory,
-	}, nil
+		sourceCodePath:  filepath.Join(mpSpdz.SourceDir, appName+".mpc"),
+		schedulePath:    filepath.Join(mpSpdz.BaseDir, "Programs/Schedules", appName+".sch"),
+		baseDir:         mpSpdz.BaseDir,
+		ipFile:          mpSpdz.IPFile,
+		streamerFactory: streamerFactory,
+		streamerPool:    streamerPool,
+		proxyPorts:      proxyPorts,
+		compileCache:    compileCache,
+		newProxy: func() network.AbstractProxy {
+			return network.NewProxy(logger, config, checker)
+		},
+		sessionProxies: map[string]*sessionProxy{},
+	}
+	if err := engine.cleanWorkDirs(); err != nil {
+		return nil, err
+	}
+	return engine, nil
 }
 
 // SPDZEngine compiles, executes, provides IO operations for SPDZ based runtimes.
@@ -169,32 +383,115 @@ type SPDZEngine struct {
 	playerDataPaths map[castor.SPDZProtocol]string
 	sourceCodePath  string
 	schedulePath    string
-	proxy           network.AbstractProxy
 	baseDir         string
 	ipFile          string
 	streamerFactory TupleStreamerFactory
+	// streamerPool keeps the tuple streamers of a game for the next game of the same parameters. If nil, streamers are
+	// created for each game.
+	streamerPool *StreamerPool
+	// proxyPorts assigns each game its own set of local proxy ports. If nil, fixed local ports per player are used.
+	proxyPorts *network.PortSetAllocator
+	// compileCache keeps the artifacts of recently compiled programs. If nil, programs are always compiled.
+	compileCache *CompileCache
+	// running tracks the MPC executions which have not terminated yet, including their tuple streamers.
+	running sync.WaitGroup
+	// portOffset shifts the ports SPDZ listens on for the input parameters and the other players.
+	portOffset int32
+	// newProxy creates the proxy of a game, or the one kept between the games of a computation session.
+	newProxy func() network.AbstractProxy
+	// sessionProxies are the proxies kept for the next game of a computation session, indexed by the id of the session.
+	sessionProxies map[string]*sessionProxy
+	sessionsMux    sync.Mutex
+}
+
+// Wait blocks until all MPC executions and their tuple streamers have terminated.
+func (s *SPDZEngine) Wait() {
+	s.running.Wait()
 }
 
 // Activate starts a proxy, writes an IP file, start SPDZ execution, unpacks inputs parameters, sends them to the runtime and waits for the response.
 func (s *SPDZEngine) Activate(ctx *CtxConfig) ([]byte, error) {
-	proxyErrCh := make(chan error, 1)
+	logger := s.logger.With(labelFields(ctx.Act.Labels)...)
 	act := ctx.Act
-	err := s.proxy.Run(ctx, proxyErrCh)
-	defer s.proxy.Stop()
+	if s.proxyPorts != nil {
+		// The games of a session use the same ports, so that the proxy can be kept between them.
+		owner := act.GameID
+		if act.SessionID != "" {
+			owner = sessionPortsOwner(act.SessionID)
+		} else {
+			defer s.proxyPorts.Release(owner)
+		}
+		base, err := s.proxyPorts.Acquire(owner)
+		if err != nil {
+			msg := "error allocating proxy ports"
+			logger.Errorw(msg, GameID, act.GameID)
+			return nil, fmt.Errorf("%s: %s", msg, err)
+		}
+		s.assignLocalPorts(ctx, base)
+	}
+	if ctx.Compression {
+		// The proxy decompresses the traffic of the other players received on the regular port.
+		own := d.BasePort + s.portOffset + s.config.PlayerID
+		ctx.InboundProxy = &ProxyConfig{
+			Host:      proxyAddress,
+			Port:      strconv.Itoa(int(own + s.config.ProxyCompression.InternalPortOffset)),
+			LocalPort: strconv.Itoa(int(own)),
+		}
+		logger.Debugw("Compressing the traffic between the players", GameID, act.GameID)
+	}
+	_, networkSpan := tracing.StartSpan(ctx.Context, "network establishment")
+	proxyErrCh, stopProxy, err := s.runProxy(ctx)
+	networkSpan.SetError(err)
+	networkSpan.Finish()
+	defer stopProxy()
 	if err != nil {
 		msg := "error starting the tcp proxy"
-		s.logger.Errorw(msg, GameID, act.GameID)
+		logger.Errorw(msg, GameID, act.GameID)
 		return nil, fmt.Errorf("%s: %s", msg, err)
 	}
-	err = s.writeIPFile(s.ipFile, proxyAddress, ctx.Spdz.PlayerCount)
+	if err := s.prepareWorkDir(ctx, !ctx.Compiled); err != nil {
+		msg := "error preparing the work directory"
+		logger.Errorw(msg, GameID, act.GameID)
+		return nil, fmt.Errorf("%s: %s", msg, err)
+	}
+	ipFile := s.layout(ctx).ipFile
+	switch {
+	case s.proxyPorts != nil:
+		err = s.writeGameIPFile(ipFile, proxyAddress, s.partyPorts(ctx))
+		defer Fio.Delete(ipFile)
+	case s.config.PartyNumbers != nil || ctx.InboundProxy != nil:
+		// MP-SPDZ derives the ports from the party numbers if the ip file lists none, which do not match the ports
+		// of the players once they are remapped or MP-SPDZ listens on the internal port.
+		err = s.writeGameIPFile(ipFile, proxyAddress, s.partyPorts(ctx))
+	default:
+		err = s.writeIPFile(ipFile, proxyAddress, ctx.Spdz.PlayerCount)
+	}
 	if err != nil {
 		msg := "error due to writing to the ip file"
-		s.logger.Errorw(msg, GameID, act.GameID)
+		logger.Errorw(msg, GameID, act.GameID)
 		return nil, fmt.Errorf("%s: %s", msg, err)
 	}
-	go s.startMPC(ctx)
+	source, err := s.preprocessingSource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx.Preprocessing = source
+	if act.Seed != "" || act.Diagnostics {
+		ctx.Reproducibility = newReproducibility(ctx, s.config)
+	}
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		s.startMPC(ctx)
+	}()
+	return s.feed(ctx, logger, s.getFeedPorts(act), proxyErrCh)
+}
+
+// feed passes the input parameters of the activation to the runtime on the given ports and waits for the response.
+// The activation fails once an error is reported on the given channel.
+func (s *SPDZEngine) feed(ctx *CtxConfig, logger *zap.SugaredLogger, feedPorts []string, proxyErrCh chan error) ([]byte, error) {
+	act := ctx.Act
 	defer s.feeder.Close()
-	feedPort := s.getFeedPort()
 	doneCh := make(chan struct{})
 	var activationResult []byte = nil
 	var activationErr error = nil
@@ -202,78 +499,404 @@ func (s *SPDZEngine) Activate(ctx *CtxConfig) ([]byte, error) {
 		defer close(doneCh)
 		// Read the secret shares either from Amphora or from the http request.
 		if len(act.AmphoraParams) > 0 {
-			activationResult, activationErr = s.feeder.LoadFromSecretStoreAndFeed(act, feedPort, ctx)
+			activationResult, activationErr = s.feeder.LoadFromSecretStoreAndFeed(act, feedPorts, ctx)
 		} else if len(act.SecretParams) > 0 {
-			activationResult, activationErr = s.feeder.LoadFromRequestAndFeed(act, feedPort, ctx)
+			activationResult, activationErr = s.feeder.LoadFromRequestAndFeed(act, feedPorts, ctx)
 		} else {
 			activationErr = errors.New("no MPC parameters specified")
 		}
 	}()
-	select {
-	case <-doneCh:
-		if activationErr == nil {
-			s.logger.Debugw("Activation finished successful", GameID, act.GameID)
-		} else {
-			s.logger.Errorw("Activation finished with error", GameID, act.GameID, "Error", activationErr)
+	delivery, activationDone := ctx.Delivery, ctx.Context.Done()
+	for {
+		select {
+		case <-doneCh:
+			if activationErr == nil {
+				logger.Debugw("Activation finished successful", GameID, act.GameID)
+			} else {
+				logger.Errorw("Activation finished with error", GameID, act.GameID, "Error", activationErr)
+			}
+			return activationResult, activationErr
+		case err := <-proxyErrCh:
+			logger.Errorw("Activation finished with proxy error", GameID, act.GameID, "ProxyError", err)
+			return nil, err
+		case <-delivery:
+			// The computation has finished. The delivery of the result is bounded by the server, so that it is not
+			// aborted once the activation context is closed.
+			delivery, activationDone = nil, nil
+		case <-activationDone:
+			logger.Debug("Stopping SPDZ activation - context closed")
+			return nil, errors.New("SPDZ activation cancelled due to closed context")
 		}
-		return activationResult, activationErr
-	case err := <-proxyErrCh:
-		s.logger.Errorw("Activation finished with proxy error", GameID, act.GameID, "ProxyError", err)
-		return nil, err
-	case <-ctx.Context.Done():
-		s.logger.Debug("Stopping SPDZ activation - context closed")
-		return nil, errors.New("SPDZ activation cancelled due to closed context")
 	}
 }
 
-func (s *SPDZEngine) getNumberOfThreads() (int, error) {
-	file, err := Fio.OpenRead(s.schedulePath)
+// runProxy starts the proxy to the other players of the game and returns the channel its errors are reported on along
+// with the function stopping it once the game has finished. Each game gets a proxy of its own, so that overlapping
+// games do not stop each other's proxy. The proxy of a computation session is kept running between
+// its games as long as the other players are reached the same way, so that the network is not established again. This
+// requires proxy ports to be assigned per game, as the proxy would block the fixed local ports otherwise.
+func (s *SPDZEngine) runProxy(ctx *CtxConfig) (chan error, func(), error) {
+	sessionID := ctx.Act.SessionID
+	if sessionID == "" || s.proxyPorts == nil {
+		proxy := s.newProxy()
+		errCh := make(chan error, 1)
+		err := proxy.Run(ctx, errCh)
+		return errCh, proxy.Stop, err
+	}
+	s.sessionsMux.Lock()
+	kept, ok := s.sessionProxies[sessionID]
+	delete(s.sessionProxies, sessionID)
+	s.sessionsMux.Unlock()
+	if ok {
+		if kept.alive() && reflect.DeepEqual(kept.entries, ctx.ProxyEntries) && reflect.DeepEqual(kept.inbound, ctx.InboundProxy) {
+			s.logger.Debugw("Reusing the network of the session", GameID, ctx.Act.GameID, "SessionID", sessionID)
+			s.keepSessionProxy(sessionID, kept)
+			return kept.errCh, func() {}, nil
+		}
+		kept.proxy.Stop()
+	}
+	sp := &sessionProxy{
+		proxy:   s.newProxy(),
+		entries: ctx.ProxyEntries,
+		inbound: ctx.InboundProxy,
+		errCh:   make(chan error, 1),
+	}
+	if err := sp.proxy.Run(ctx, sp.errCh); err != nil {
+		return sp.errCh, sp.proxy.Stop, err
+	}
+	s.keepSessionProxy(sessionID, sp)
+	return sp.errCh, func() {}, nil
+}
+
+// keepSessionProxy keeps the proxy for the next game of the session.
+func (s *SPDZEngine) keepSessionProxy(sessionID string, sp *sessionProxy) {
+	s.sessionsMux.Lock()
+	defer s.sessionsMux.Unlock()
+	s.sessionProxies[sessionID] = sp
+}
+
+// CloseSession stops the proxy kept for the given computation session and releases its ports.
+func (s *SPDZEngine) CloseSession(sessionID string) {
+	s.sessionsMux.Lock()
+	sp, ok := s.sessionProxies[sessionID]
+	delete(s.sessionProxies, sessionID)
+	s.sessionsMux.Unlock()
+	if ok {
+		sp.proxy.Stop()
+	}
+	if s.proxyPorts != nil {
+		s.proxyPorts.Release(sessionPortsOwner(sessionID))
+	}
+}
+
+// sessionPortsOwner returns the key the proxy ports of a computation session are acquired for.
+func sessionPortsOwner(sessionID string) string {
+	return "session/" + sessionID
+}
+
+// sessionProxy is the proxy kept between the games of a computation session.
+type sessionProxy struct {
+	proxy   network.AbstractProxy
+	entries []*ProxyConfig
+	inbound *ProxyConfig
+	errCh   chan error
+}
+
+// alive returns false if the proxy has terminated.
+func (p *sessionProxy) alive() bool {
+	select {
+	case <-p.errCh:
+		return false
+	default:
+		return true
+	}
+}
+
+// getNumberOfThreads returns the number of threads declared in the given schedule of the compiled program. An error is
+// returned if the number is invalid or exceeds the configured limit.
+func (s *SPDZEngine) getNumberOfThreads(schedulePath string) (int, error) {
+	file, err := Fio.OpenRead(schedulePath)
 	if err != nil {
 		return 0, fmt.Errorf("error accessing the program's schedule: %s", err)
 	}
 	defer file.Close()
-	nThreads, err := Fio.ReadLine(file)
+	line, err := Fio.ReadLine(file)
 	if err != nil {
 		return 0, fmt.Errorf("error reading number of threads: %s", err)
 	}
-	return strconv.Atoi(nThreads)
+	nThreads, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || nThreads < 1 {
+		return 0, fmt.Errorf("invalid number of threads %q in the program's schedule", line)
+	}
+	if s.config.MaxThreads > 0 && nThreads > s.config.MaxThreads {
+		return 0, fmt.Errorf("the program uses %d threads, at most %d are allowed", nThreads, s.config.MaxThreads)
+	}
+	return nThreads, nil
 }
 
 // Compile compiles a SPDZ application and returns the number of threads declared by the program.
 func (s *SPDZEngine) Compile(ctx *CtxConfig) error {
 	act := ctx.Act
-	path := s.sourceCodePath
+	if err := s.prepareWorkDir(ctx, false); err != nil {
+		return err
+	}
+	l := s.layout(ctx)
+	path := l.sourceCodePath
 	data := []byte(act.Code)
 	err := ioutil.WriteFile(path, data, 0644)
 	if err != nil {
 		return err
 	}
-	var stdoutSlice []byte
-	var stderrSlice []byte
-	command := fmt.Sprintf("./compile.py -M %s", appName)
-	stdoutSlice, stderrSlice, err = s.cmder.CallCMD(context.TODO(), []string{command}, s.baseDir)
-	stdOut := string(stdoutSlice)
-	stdErr := string(stderrSlice)
-	s.logger.Debugw("Compiled Successfully", "Command", command, "StdOut", stdOut, "StdErr", stdErr)
+	command := l.compileCommand(act.CompilerOptions)
+	key := CompileCacheKey(act.Code, command)
+	if s.compileCache != nil && !ctx.ForceCompile {
+		cached, err := s.compileCache.RestoreIn(l.dir, key)
+		if err != nil {
+			s.logger.Warnw("Failed to restore the program from the compile cache", GameID, act.GameID, "Error", err)
+		} else if cached {
+			s.logger.Debugw("Using the program from the compile cache", GameID, act.GameID, "Key", key)
+			return nil
+		}
+	}
+	_, _, err = s.runCompiler(ctx, l, command)
+	return err
+}
+
+// CompileWithReport compiles a SPDZ application, bypassing the compile cache, and reports the output of the compiler
+// as well as the number of threads and the size of the bytecode of the program. A failing compilation is reported as
+// unsuccessful, the returned error indicates that the compiler could not be run. As the program is not compiled for a
+// game, it is compiled in a temporary directory which is removed afterwards, so that neither the program deployed in
+// the MP-SPDZ directory nor concurrent compilations are affected.
+func (s *SPDZEngine) CompileWithReport(ctx *CtxConfig) (*CompilationReport, error) {
+	act := ctx.Act
+	dir, err := ioutil.TempDir("", "ephemeral-compile-")
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("error creating the compilation directory: %v", err)
+	}
+	defer func() {
+		if err := Fio.Delete(dir); err != nil {
+			s.logger.Warnw("Failed to remove the compilation directory", "Dir", dir, "Error", err)
+		}
+	}()
+	l := s.dirLayout(dir)
+	for _, d := range []string{"Programs/Source", "Programs/Bytecode", "Programs/Schedules"} {
+		if err := Fio.CreatePath(filepath.Join(dir, d)); err != nil {
+			return nil, fmt.Errorf("error creating the compilation directory: %v", err)
+		}
+	}
+	if err := ioutil.WriteFile(l.sourceCodePath, []byte(act.Code), 0644); err != nil {
+		return nil, err
+	}
+	stdout, stderr, err := s.runCompiler(ctx, l, l.compileCommand(act.CompilerOptions))
+	report := &CompilationReport{
+		Success: err == nil,
+		Stdout:  string(stdout),
+		Stderr:  string(stderr),
+	}
+	if err == errGf2nDisabled {
+		reportFailure(ctx, report, err)
+	}
+	if err != nil {
+		return report, nil
+	}
+	report.Threads, err = s.getNumberOfThreads(l.schedulePath)
+	if err != nil {
+		// The program declares an invalid or too large number of threads.
+		reportFailure(ctx, report, err)
+		return report, nil
+	}
+	report.BytecodeSize, err = s.bytecodeSize(l.dir)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// reportFailure marks the compilation as unsuccessful and appends the reason to the reported and streamed output of the
+// compiler.
+func reportFailure(ctx *CtxConfig, report *CompilationReport, reason error) {
+	report.Success = false
+	report.Stderr += reason.Error()
+	if ctx.CompilerStderr != nil {
+		ctx.CompilerStderr.Write([]byte(reason.Error()))
 	}
-	return nil
 }
 
-// getFeedPort returns the port on which SPDZ accepts input parameters.
-func (s *SPDZEngine) getFeedPort() string {
-	return strconv.FormatInt(int64(basePort+s.config.PlayerID), 10)
+// IsCompilationError returns true if the error indicates that the program failed to compile, as opposed to the
+// compiler failing to run.
+func IsCompilationError(err error) bool {
+	if err == errGf2nDisabled {
+		return true
+	}
+	_, ok := err.(*exec.ExitError)
+	return ok
+}
+
+// runCompiler compiles the source code written before to the given layout with the given command and adds the program
+// to the compile cache on success.
+func (s *SPDZEngine) runCompiler(ctx *CtxConfig, l *gameLayout, command string) ([]byte, []byte, error) {
+	act := ctx.Act
+	stdout, stderr, err := s.callCompiler(ctx, command, l.dir)
+	s.logger.Debugw("Compiled Successfully", "Command", command, "StdOut", string(stdout), "StdErr", string(stderr))
+	if err != nil {
+		return stdout, stderr, err
+	}
+	if s.config.Gf2nDisabled && requiresGf2n(stdout) {
+		return stdout, stderr, errGf2nDisabled
+	}
+	if s.compileCache != nil {
+		if err := s.compileCache.StoreFrom(l.dir, CompileCacheKey(act.Code, command)); err != nil {
+			s.logger.Warnw("Failed to add the program to the compile cache", GameID, act.GameID, "Error", err)
+		}
+	}
+	return stdout, stderr, nil
+}
+
+// callCompiler runs the compiler in the given directory and passes its output on to the writers of the context, if any.
+// The output is passed on while the compiler runs if supported by the executor, otherwise once it has finished.
+func (s *SPDZEngine) callCompiler(ctx *CtxConfig, command string, dir string) ([]byte, []byte, error) {
+	c := ctx.Context
+	if c == nil {
+		c = context.TODO()
+	}
+	if ctx.CompilerStdout == nil && ctx.CompilerStderr == nil {
+		return s.cmder.CallCMD(c, []string{command}, dir)
+	}
+	if streaming, ok := s.cmder.(StreamingExecutor); ok {
+		return streaming.CallCMDWithOutput(c, []string{command}, dir, ctx.CompilerStdout, ctx.CompilerStderr)
+	}
+	stdout, stderr, err := s.cmder.CallCMD(c, []string{command}, dir)
+	if ctx.CompilerStdout != nil {
+		ctx.CompilerStdout.Write(stdout)
+	}
+	if ctx.CompilerStderr != nil {
+		ctx.CompilerStderr.Write(stderr)
+	}
+	return stdout, stderr, err
+}
+
+// errGf2nDisabled is returned when compiling a program that requires gf2n tuples while gf2n is disabled.
+var errGf2nDisabled = errors.New("the program requires gf2n tuples, but gf2n is not configured for this VCP")
+
+// gf2nRequirement matches the gf2n tuples listed by the compiler in the requirements of a program, e.g.,
+// "        100 gf2n triples".
+var gf2nRequirement = regexp.MustCompile(`(?m)^\s*\d+ gf2n `)
+
+// requiresGf2n returns true if the compiler output lists gf2n tuples in the requirements of the program.
+func requiresGf2n(compilerOutput []byte) bool {
+	return gf2nRequirement.Match(compilerOutput)
+}
+
+// bytecodeSize returns the total size of the bytecode files of the program compiled in the given directory.
+func (s *SPDZEngine) bytecodeSize(dir string) (int64, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "Programs/Bytecode", appName+"-*.bc"))
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return 0, fmt.Errorf("error accessing the program's bytecode: %s", err)
+		}
+		size += info.Size()
+	}
+	return size, nil
+}
+
+// compileCommand returns the command used to compile the program in the MP-SPDZ directory with the given options.
+func (s *SPDZEngine) compileCommand(opts *CompilerOptions) string {
+	return s.baseLayout(nil).compileCommand(opts)
+}
+
+// mpSpdzConfig returns the configured MP-SPDZ installation with unset paths replaced by their defaults.
+func mpSpdzConfig(conf *SPDZEngineTypedConfig) MPSPDZConfig {
+	c := conf.MPSPDZ
+	if c.BaseDir == "" {
+		c.BaseDir = baseDir
+	}
+	if c.IPFile == "" {
+		c.IPFile = filepath.Join(c.BaseDir, filepath.Base(ipFile))
+	}
+	if c.SourceDir == "" {
+		c.SourceDir = filepath.Join(c.BaseDir, "Programs/Source")
+	}
+	return c
+}
+
+// compilerFlags returns the MP-SPDZ compiler flags for the given options.
+func compilerFlags(opts *CompilerOptions) []string {
+	if opts == nil {
+		return nil
+	}
+	var flags []string
+	if opts.OptimizationLevel >= 1 {
+		flags = append(flags, "-l")
+	}
+	if opts.OptimizationLevel >= 2 {
+		flags = append(flags, "-D")
+	}
+	if opts.OptimizationLevel >= 3 {
+		flags = append(flags, "-O")
+	}
+	if opts.BitLength > 0 {
+		flags = append(flags, "-F", strconv.Itoa(opts.BitLength))
+	}
+	if opts.Budget > 0 {
+		flags = append(flags, "-b", strconv.Itoa(opts.Budget))
+	}
+	if opts.Prime != "" {
+		flags = append(flags, "--prime", opts.Prime)
+	}
+	return flags
+}
+
+// getFeedPorts returns the ports on which SPDZ accepts the input parameters, one for each client connection of the
+// activation.
+func (s *SPDZEngine) getFeedPorts(act *Activation) []string {
+	return s.feedPortsOf(act, s.config.PartyNumber(s.config.PlayerID))
+}
+
+// feedPortsOf returns the ports on which the given party of SPDZ accepts the input parameters, one for each client
+// connection of the activation.
+func (s *SPDZEngine) feedPortsOf(act *Activation, party int32) []string {
+	if len(act.Connections) == 0 {
+		return []string{s.feedPort(basePort, party)}
+	}
+	ports := make([]string, len(act.Connections))
+	for i, conn := range act.Connections {
+		port := conn.Port
+		if port == 0 {
+			port = basePort
+		}
+		ports[i] = s.feedPort(port, party)
+	}
+	return ports
+}
+
+// feedPort returns the port of the given party on which SPDZ listens for client connections at the given base port.
+func (s *SPDZEngine) feedPort(base int32, party int32) string {
+	return strconv.FormatInt(int64(base+s.portOffset+party), 10)
 }
 
 func (s *SPDZEngine) startMPC(ctx *CtxConfig) {
-	s.logger.Debugw("Starting MPC", GameID, ctx.Act.GameID)
-	nThreads, err := s.getNumberOfThreads()
+	logger := s.logger.With(labelFields(ctx.Act.Labels)...)
+	logger.Debugw("Starting MPC", GameID, ctx.Act.GameID)
+	l := s.layout(ctx)
+	nThreads, err := s.getNumberOfThreads(l.schedulePath)
 	if err != nil {
 		ctx.ErrCh <- fmt.Errorf("failed to determine the number of threads: %v", err)
 		return
 	}
+	protocolName, protocol, err := resolveProtocol(ctx.Act, s.config)
+	if err != nil {
+		ctx.ErrCh <- err
+		return
+	}
 	wg := new(sync.WaitGroup)
+	var tupleStreamers = []TupleStreamer{}
+	var tupleTypes = []castor.TupleType{}
+	_, streamSpan := tracing.StartSpan(ctx.Context, "tuple streaming")
 	defer func() {
 		gracefully := make(chan struct{})
 		go func() {
@@ -282,47 +905,84 @@ func (s *SPDZEngine) startMPC(ctx *CtxConfig) {
 		}()
 		select {
 		case <-gracefully:
+			s.releaseStreamers(tupleStreamers)
 		case <-time.After(time.Second * 30):
-			s.logger.Error("Tuple streamers have not terminated gracefully")
+			logger.Error("Tuple streamers have not terminated gracefully")
 		}
+		streamSpan.Finish()
+		s.recordTupleUsage(ctx, tupleStreamers)
+		auditTupleUsage(ctx, tupleStreamers, tupleTypes)
+		reportTupleStreaming(logger, tupleStreamers, tupleTypes)
 	}()
 
-	var tupleStreamers = []TupleStreamer{}
 	gameUUID, err := uuid.Parse(ctx.Act.GameID)
 	if err != nil {
 		ctx.ErrCh <- fmt.Errorf("error parsing gameID: %v", err)
 		return
 	}
-	for _, tt := range castor.SupportedTupleTypes {
+	requiredTupleTypes := tupleTypesOf(protocol.TupleFamilies, supportedTupleTypes(s.config))
+	prepPerThread := " --file-prep-per-thread"
+	if len(requiredTupleTypes) == 0 {
+		// The protocol generates its preprocessing data during the computation.
+		prepPerThread = ""
+	} else if ctx.Preprocessing == PreprocessingInsecure {
+		// The fake preprocessing data is shared by all threads, hence no tuples are streamed from Castor.
+		prepPerThread = ""
+		defer s.restoreMacKeys(l, logger)
+		if err := s.generateInsecurePreprocessing(ctx, l, logger); err != nil {
+			ctx.ErrCh <- err
+			return
+		}
+		nThreads = 0
+	}
+	// The tuple streamers keep the tuple stock they were created with if the configuration is reloaded meanwhile.
+	config := s.config.Snapshot()
+	for _, tt := range requiredTupleTypes {
 		for thread := 0; thread < nThreads; thread++ {
-			s.logger.Debugw("Creating new tuple streamer", TupleType, tt, "TupleStock", s.config.TupleStock, "Player-Data", s.playerDataPaths[tt.SpdzProtocol], GameID, gameUUID, "ThreadNr", thread)
-			streamer, err := s.streamerFactory(s.logger, tt, s.config, s.playerDataPaths[tt.SpdzProtocol], gameUUID, thread)
+			logger.Debugw("Creating new tuple streamer", TupleType, tt, "TupleStock", config.TupleStock, "Player-Data", l.playerDataPaths[tt.SpdzProtocol], GameID, gameUUID, "ThreadNr", thread)
+			streamer, err := s.streamerFactory(logger, tt, config, l.playerDataPaths[tt.SpdzProtocol], gameUUID, thread)
 			if err != nil {
-				s.logger.Errorw("Error when initializing tuple streamer", GameID, ctx.Act.GameID, TupleType, tt, "Error", err)
+				logger.Errorw("Error when initializing tuple streamer", GameID, ctx.Act.GameID, TupleType, tt, "Error", err)
 				ctx.ErrCh <- err
 				return
 			}
 			tupleStreamers = append(tupleStreamers, streamer)
+			tupleTypes = append(tupleTypes, tt)
 		}
 	}
 	computationFinished := make(chan struct{})
 	terminateStreams := make(chan struct{})
 	defer close(terminateStreams)
-	streamErrCh := make(chan error, len(castor.SupportedTupleTypes))
+	// Each streamer may report an error, so that none of them blocks if several threads fail at once.
+	streamErrCh := make(chan error, len(tupleStreamers))
 	for _, s := range tupleStreamers {
 		wg.Add(1)
 		s.StartStreamTuples(terminateStreams, streamErrCh, wg)
 	}
-	command := []string{fmt.Sprintf("./Player-Online.x %s %s -N %s --ip-file-name %s --file-prep-per-thread", fmt.Sprint(s.config.PlayerID), appName, fmt.Sprint(ctx.Spdz.PlayerCount), ipFile)}
-	s.logger.Infow("Starting Player-Online.x", GameID, ctx.Act.GameID, "command", command)
+	var flags string
+	if args := append(append([]string{}, protocol.Flags...), s.config.MPSPDZ.ExtraArgs...); len(args) > 0 {
+		flags = " " + strings.Join(args, " ")
+	}
+	runtime, removeTmpDir, err := s.limitRuntime(gameUUID.String(), fmt.Sprintf("%s %s %s -N %s --ip-file-name %s%s%s", l.executable(protocol.Executable), fmt.Sprint(s.config.PartyNumber(s.config.PlayerID)), appName, fmt.Sprint(ctx.Spdz.PlayerCount), l.ipFile, prepPerThread, flags))
+	if err != nil {
+		ctx.ErrCh <- err
+		return
+	}
+	command := []string{runtime}
+	logger.Infow("Starting the MPC runtime", GameID, ctx.Act.GameID, "Protocol", protocolName, "command", command)
 	go func() {
-		stdout, stderr, err := s.cmder.CallCMD(ctx.Context, command, s.baseDir)
+		_, runtimeSpan := tracing.StartSpan(ctx.Context, "mpc runtime")
+		stdout, stderr, err := s.callRuntime(ctx, command)
+		removeTmpDir()
+		runtimeSpan.SetError(err)
+		runtimeSpan.Finish()
+		ctx.Audit.Add(audit.MPCFinished, map[string]interface{}{"exitCode": exitCode(err)})
 		if err != nil {
-			s.logger.Errorw("Error while executing the user code", GameID, ctx.Act.GameID, "StdErr", string(stderr), "StdOut", string(stdout), "error", err)
+			logger.Errorw("Error while executing the user code", GameID, ctx.Act.GameID, "StdErr", string(stderr), "StdOut", string(stdout), "error", err)
 			err := fmt.Errorf("error while executing the user code: %v", err)
 			ctx.ErrCh <- err
 		} else {
-			s.logger.Debugw("Computation finished", GameID, ctx.Act.GameID, "StdErr", string(stderr), "StdOut", string(stdout))
+			logger.Debugw("Computation finished", GameID, ctx.Act.GameID, "StdErr", string(stderr), "StdOut", string(stdout))
 		}
 		close(computationFinished)
 	}()
@@ -330,11 +990,261 @@ func (s *SPDZEngine) startMPC(ctx *CtxConfig) {
 	case <-computationFinished:
 	case err := <-streamErrCh:
 		error := fmt.Errorf("error while streaming tuples: %v", err)
-		s.logger.Error(error)
+		streamSpan.SetError(error)
+		logger.Error(error)
 		ctx.ErrCh <- error
 	}
 }
 
+// limitRuntime prefixes the command starting the MPC runtime of the given game with the configured resource limits
+// and creates the temporary directory of the game. The returned function removes the directory again.
+func (s *SPDZEngine) limitRuntime(gameID string, command string) (string, func(), error) {
+	limits := s.config.ProcessLimits
+	if limits == nil {
+		return command, func() {}, nil
+	}
+	var steps []string
+	if limits.MaxOpenFiles > 0 {
+		steps = append(steps, fmt.Sprintf("ulimit -n %d", limits.MaxOpenFiles))
+	}
+	if !limits.CoreDumps {
+		steps = append(steps, "ulimit -c 0")
+	}
+	tmpDir := limits.TmpDir
+	if tmpDir == "" {
+		tmpDir = filepath.Join(s.baseDir, "tmp")
+	}
+	tmpDir = filepath.Join(tmpDir, gameID)
+	if err := Fio.CreatePath(tmpDir); err != nil {
+		return "", nil, fmt.Errorf("error creating the temporary directory of the game: %v", err)
+	}
+	steps = append(steps, "export TMPDIR="+tmpDir, "exec "+command)
+	return strings.Join(steps, " && "), func() {
+		if err := Fio.Delete(tmpDir); err != nil {
+			s.logger.Warnw("Failed to remove the temporary directory of the game", GameID, gameID, "Error", err)
+		}
+	}, nil
+}
+
+// callRuntime executes the MPC runtime in the directory of the game and passes its output on to the runtime log of the
+// activation, if any. The output is passed on while the runtime runs if supported by the executor, otherwise once it
+// has finished.
+func (s *SPDZEngine) callRuntime(ctx *CtxConfig, command []string) ([]byte, []byte, error) {
+	dir := s.layout(ctx).dir
+	if ctx.RuntimeStdout == nil && ctx.RuntimeStderr == nil {
+		return s.cmder.CallCMD(ctx.Context, command, dir)
+	}
+	if streaming, ok := s.cmder.(StreamingExecutor); ok {
+		return streaming.CallCMDWithOutput(ctx.Context, command, dir, ctx.RuntimeStdout, ctx.RuntimeStderr)
+	}
+	stdout, stderr, err := s.cmder.CallCMD(ctx.Context, command, dir)
+	if ctx.RuntimeStdout != nil {
+		ctx.RuntimeStdout.Write(stdout)
+	}
+	if ctx.RuntimeStderr != nil {
+		ctx.RuntimeStderr.Write(stderr)
+	}
+	return stdout, stderr, err
+}
+
+// castorProbeTimeout is the maximum duration of connecting to castor when deciding whether to fall back to fake
+// preprocessing data.
+const castorProbeTimeout = 2 * time.Second
+
+// castorAvailable returns a probe of the reachability of the castor service of the given configuration. Castor is
+// considered unavailable if no castor service is configured.
+func castorAvailable(conf *SPDZEngineTypedConfig) func() bool {
+	if conf.CastorClient == nil {
+		return func() bool { return false }
+	}
+	c, ok := conf.CastorClient.(*castor.Client)
+	if !ok {
+		return func() bool { return true }
+	}
+	probe := depcheck.ReachableURL("castor", c.URL, castorProbeTimeout)
+	return func() bool { return probe.Run() == nil }
+}
+
+// useInsecurePreprocessing returns whether the player proposes to generate fake preprocessing data for the given game
+// instead of fetching tuples from castor, i.e., if insecure preprocessing is enabled and, if configured as a fallback,
+// castor is unreachable as reported by the given probe. A nil probe considers castor reachable.
+func useInsecurePreprocessing(config *SPDZEngineTypedConfig, available func() bool, logger *zap.SugaredLogger, gameID string) bool {
+	conf := config.InsecurePreprocessing
+	if conf == nil {
+		return false
+	}
+	if !conf.Fallback {
+		return true
+	}
+	if available == nil || available() {
+		return false
+	}
+	logger.Warnw("INSECURE: Castor is unreachable, falling back to fake preprocessing data", GameID, gameID)
+	return true
+}
+
+// generateInsecurePreprocessing generates fake preprocessing data for all players with MP-SPDZ's Fake-Offline.x
+// instead of fetching tuples from Castor in the directory of the given layout. Each player generates the data on its
+// own, hence all players generate it and the fake MAC key shares from the same seed, so that their shares of the data
+// match. The data is not secure and must never be used in production.
+func (s *SPDZEngine) generateInsecurePreprocessing(ctx *CtxConfig, l *gameLayout, logger *zap.SugaredLogger) error {
+	// The seed is validated to be passed on as is. The id of the game, a UUID, is known to all players otherwise.
+	seed := ctx.Act.Seed
+	if seed == "" {
+		seed = ctx.Act.GameID
+	}
+	if err := s.writeInsecureMacKeys(ctx, l, seed); err != nil {
+		return err
+	}
+	var gf2n string
+	if !s.config.Gf2nDisabled {
+		gf2n = fmt.Sprintf(" -lg2 %d", s.config.Gf2nBitLength)
+	}
+	command := []string{fmt.Sprintf("%s %d -lgp %d%s -P %s --default %d --seed %s",
+		l.executable("./Fake-Offline.x"), ctx.Spdz.PlayerCount, s.config.Prime.BitLen(), gf2n, s.config.Prime.String(), s.config.InsecurePreprocessing.TupleCount, seed)}
+	logger.Warnw("INSECURE: Generating fake preprocessing data, do not use in production", GameID, ctx.Act.GameID, "command", command)
+	_, span := tracing.StartSpan(ctx.Context, "insecure preprocessing")
+	defer span.Finish()
+	stdout, stderr, err := s.cmder.CallCMD(ctx.Context, command, l.dir)
+	if err != nil {
+		span.SetError(err)
+		logger.Errorw("Error generating fake preprocessing data", GameID, ctx.Act.GameID, "StdErr", string(stderr), "StdOut", string(stdout), "error", err)
+		return fmt.Errorf("error generating fake preprocessing data: %v", err)
+	}
+	return nil
+}
+
+// tupleUsageReporter is implemented by tuple streamers that report the amount of tuple data they consumed.
+type tupleUsageReporter interface {
+	FetchedTupleBytes() int64
+}
+
+// writeInsecureMacKeys writes fake MAC key shares of all players, derived from the given seed of the game, to the
+// preprocessing data directories of the given layout. Fake-Offline.x derives the data from the shares found there, and
+// the MPC runtime computes with the share of the player. The MAC keys of the players are never used for fake data.
+func (s *SPDZEngine) writeInsecureMacKeys(ctx *CtxConfig, l *gameLayout, seed string) error {
+	protocols := []castor.SPDZProtocol{castor.SPDZGfp}
+	if !s.config.Gf2nDisabled {
+		protocols = append(protocols, castor.SPDZGf2n)
+	}
+	for _, p := range protocols {
+		for party := 0; party < int(ctx.Spdz.PlayerCount); party++ {
+			path := filepath.Join(l.playerDataPaths[p], fmt.Sprintf("Player-MAC-Keys-%s-P%d", p.Shorthand, party))
+			if err := writeMacKey(path, ctx.Spdz.PlayerCount, s.insecureMacKey(p, seed, party)); err != nil {
+				return fmt.Errorf("failed to write mac key to file: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// insecureMacKey derives the fake MAC key share of the given party for the given protocol from the seed of a game. The
+// shares are known to anyone knowing the seed, hence they are only fit for fake preprocessing data. gf2n shares are
+// formatted like the gf2nMacKey of the configuration.
+func (s *SPDZEngine) insecureMacKey(p castor.SPDZProtocol, seed string, party int) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d", seed, p.Shorthand, party)))
+	key := new(big.Int).SetBytes(h[:])
+	if p == castor.SPDZGf2n {
+		mask := new(big.Int).Lsh(big.NewInt(1), uint(s.config.Gf2nBitLength))
+		key.Mod(key, mask)
+		return fmt.Sprintf("%#x", key)
+	}
+	return key.Mod(key, &s.config.Prime).String()
+}
+
+// restoreMacKeys writes the MAC key shares of the player back to the preprocessing data directories of the given layout
+// once fake MAC keys have been written to them, as the directories are shared with the following games unless the
+// games have their own work directories.
+func (s *SPDZEngine) restoreMacKeys(l *gameLayout, logger *zap.SugaredLogger) {
+	keys := map[castor.SPDZProtocol]string{castor.SPDZGfp: s.config.GfpMacKey.String()}
+	if !s.config.Gf2nDisabled {
+		keys[castor.SPDZGf2n] = s.config.Gf2nMacKey
+	}
+	party := s.config.PartyNumber(s.config.PlayerID)
+	for p, key := range keys {
+		path := filepath.Join(l.playerDataPaths[p], fmt.Sprintf("Player-MAC-Keys-%s-P%d", p.Shorthand, party))
+		if err := writeMacKey(path, s.config.PlayerCount, key); err != nil {
+			logger.Errorw("Failed to restore the MAC key of the player", "Path", path, "Error", err)
+		}
+	}
+}
+
+// recordTupleUsage accounts the tuple data consumed by the given streamers to the quota of the requesting user.
+func (s *SPDZEngine) recordTupleUsage(ctx *CtxConfig, streamers []TupleStreamer) {
+	if s.config.Quota == nil {
+		return
+	}
+	var consumed int64
+	for _, ts := range streamers {
+		if r, ok := ts.(tupleUsageReporter); ok {
+			consumed += r.FetchedTupleBytes()
+		}
+	}
+	err := s.config.Quota.AddTupleBytes(ctx.AuthorizedUser, consumed)
+	if err != nil {
+		s.logger.Warnw("Failed to persist the quota usage", GameID, ctx.Act.GameID, "Error", err)
+	}
+}
+
+// auditTupleUsage records the tuple data streamed to the MPC runtime per tuple type in the audit trail.
+func auditTupleUsage(ctx *CtxConfig, streamers []TupleStreamer, types []castor.TupleType) {
+	if ctx.Audit == nil || len(streamers) == 0 {
+		return
+	}
+	bytesPerType := map[string]interface{}{}
+	for i, ts := range streamers {
+		if r, ok := ts.(tupleUsageReporter); ok {
+			consumed, _ := bytesPerType[types[i].Name].(int64)
+			bytesPerType[types[i].Name] = consumed + r.FetchedTupleBytes()
+		}
+	}
+	ctx.Audit.Add(audit.TuplesStreamed, bytesPerType)
+}
+
+// releaseStreamers returns the given terminated streamers to the warm pool, if any.
+func (s *SPDZEngine) releaseStreamers(streamers []TupleStreamer) {
+	if s.streamerPool == nil {
+		return
+	}
+	for _, ts := range streamers {
+		if c, ok := ts.(*CastorTupleStreamer); ok {
+			s.streamerPool.Release(c)
+		}
+	}
+}
+
+// streamerStatsReporter is implemented by tuple streamers that keep statistics.
+type streamerStatsReporter interface {
+	Stats() StreamerStats
+}
+
+// reportTupleStreaming logs a summary of the statistics of the given streamers per tuple type and adds them to the
+// TupleStreamerMetrics.
+func reportTupleStreaming(logger *zap.SugaredLogger, streamers []TupleStreamer, types []castor.TupleType) {
+	statsPerType := map[string]StreamerStats{}
+	for i, ts := range streamers {
+		if r, ok := ts.(streamerStatsReporter); ok {
+			statsPerType[types[i].Name] = statsPerType[types[i].Name].Add(r.Stats())
+		}
+	}
+	for _, tt := range TupleTypes(statsPerType) {
+		statsPerType[tt].Log(logger, tt)
+		TupleStreamerMetrics.Add(tt, statsPerType[tt])
+	}
+}
+
+// exitCode returns the exit code of a command that finished with the given error, 0 if it succeeded and -1 if it did
+// not exit regularly.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
 func (s *SPDZEngine) writeIPFile(path string, addr string, parties int32) error {
 	var addrs string
 	for i := int32(0); i < parties; i++ {
@@ -345,11 +1255,72 @@ func (s *SPDZEngine) writeIPFile(path string, addr string, parties int32) error
 	return ioutil.WriteFile(path, data, 0644)
 }
 
+// writeGameIPFile writes an ip file which lists the address and port of each party in order of the party numbers.
+func (s *SPDZEngine) writeGameIPFile(path string, addr string, ports []string) error {
+	var addrs string
+	for _, port := range ports {
+		addrs = addrs + fmt.Sprintf("%s:%s\n", addr, port)
+	}
+	s.logger.Infow("Writing to game IPFile: ", "path", path, "content", addrs)
+	return ioutil.WriteFile(path, []byte(addrs), 0644)
+}
+
+// ipFilePath returns the ip file used by the given game. Games get their own ip file if the proxy ports are assigned
+// per game.
+func (s *SPDZEngine) ipFilePath(ctx *CtxConfig) string {
+	if s.proxyPorts == nil {
+		return s.ipFile
+	}
+	return fmt.Sprintf("%s-%s", s.ipFile, ctx.Act.GameID)
+}
+
+// assignLocalPorts assigns the ports of the game's port set starting at base to the proxy entries. The proxy entries
+// are expected to be ordered by player ID and to not contain an entry for this player.
+func (s *SPDZEngine) assignLocalPorts(ctx *CtxConfig, base int32) {
+	for i, entry := range ctx.ProxyEntries {
+		id := int32(i)
+		if id >= s.config.PlayerID {
+			id++
+		}
+		entry.LocalPort = strconv.Itoa(int(base + id))
+	}
+}
+
+// localPorts returns the ports MP-SPDZ uses to reach each player. This player keeps listening on the port the
+// network is targeting, or the internal port if the traffic is compressed, while all other players are reached through
+// the game's proxy ports.
+func (s *SPDZEngine) localPorts(ctx *CtxConfig) []string {
+	ports := make([]string, 0, ctx.Spdz.PlayerCount)
+	for _, entry := range ctx.ProxyEntries {
+		ports = append(ports, entry.LocalPort)
+	}
+	own := strconv.Itoa(int(d.BasePort + s.portOffset + s.config.PlayerID))
+	if ctx.InboundProxy != nil {
+		own = ctx.InboundProxy.Port
+	}
+	ports = append(ports[:s.config.PlayerID], append([]string{own}, ports[s.config.PlayerID:]...)...)
+	return ports
+}
+
+// partyPorts returns the ports MP-SPDZ uses to reach each party, i.e., the local ports of the players ordered by their
+// party numbers.
+func (s *SPDZEngine) partyPorts(ctx *CtxConfig) []string {
+	ports := s.localPorts(ctx)
+	if s.config.PartyNumbers == nil || len(ports) != len(s.config.PartyNumbers) {
+		return ports
+	}
+	ordered := make([]string, len(ports))
+	for id, port := range ports {
+		ordered[s.config.PartyNumber(int32(id))] = port
+	}
+	return ordered
+}
+
 // preparePlayerData returns the directories for the supported protocol's preprocessing data. It therefore creates
 // the required directories and writes the mac keys and other required parameters to the files expected by SPDZ.
 func preparePlayerData(conf *SPDZEngineTypedConfig) (map[castor.SPDZProtocol]string, error) {
 	playerDataDirs := make(map[castor.SPDZProtocol]string)
-	for _, p := range castor.SupportedSPDZProtocols {
+	for _, p := range supportedSPDZProtocols(conf) {
 		path, err := createPlayerDataForProtocol(p, conf)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create preprocessing data directories: %v", err)
@@ -363,6 +1334,31 @@ func preparePlayerData(conf *SPDZEngineTypedConfig) (map[castor.SPDZProtocol]str
 	return playerDataDirs, nil
 }
 
+// supportedSPDZProtocols returns the fields tuples are provided in, i.e., gfp only if gf2n is disabled.
+func supportedSPDZProtocols(conf *SPDZEngineTypedConfig) []castor.SPDZProtocol {
+	if conf == nil || !conf.Gf2nDisabled {
+		return castor.SupportedSPDZProtocols
+	}
+	return []castor.SPDZProtocol{castor.SPDZGfp}
+}
+
+// supportedTupleTypes returns the enabled tuple types of the fields tuples are provided in.
+func supportedTupleTypes(conf *SPDZEngineTypedConfig) []castor.TupleType {
+	enabled := castor.DefaultTupleTypes()
+	if conf != nil && conf.TupleTypes != nil {
+		enabled = conf.TupleTypes
+	}
+	var types []castor.TupleType
+	for _, tt := range enabled {
+		for _, p := range supportedSPDZProtocols(conf) {
+			if tt.SpdzProtocol == p {
+				types = append(types, tt)
+			}
+		}
+	}
+	return types
+}
+
 func createPlayerDataForProtocol(p castor.SPDZProtocol, conf *SPDZEngineTypedConfig) (string, error) {
 	var playerDataDir, macKey string
 	switch p {
@@ -381,7 +1377,7 @@ func createPlayerDataForProtocol(p castor.SPDZProtocol, conf *SPDZEngineTypedCon
 	if err != nil && !os.IsExist(err) {
 		return "", fmt.Errorf("error creating directory path: %v", err)
 	}
-	macKeyFileName := fmt.Sprintf("Player-MAC-Keys-%s-P%d", p.Shorthand, conf.PlayerID)
+	macKeyFileName := fmt.Sprintf("Player-MAC-Keys-%s-P%d", p.Shorthand, conf.PartyNumber(conf.PlayerID))
 	err = writeMacKey(playerDataDir+macKeyFileName, conf.PlayerCount, macKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to write mac key to file: %v", err)
@@ -400,6 +1396,14 @@ func writeMacKey(macKeyFilePath string, playerCount int32, macKey string) error
 	return err
 }
 
+// ParamsFingerprint returns a hash of the public SPDZ parameters, i.e., the prime and the field sizes. Players with
+// different fingerprints cannot compute together. The MAC keys are secret and therefore not part of the fingerprint.
+func ParamsFingerprint(conf *SPDZEngineTypedConfig) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s|%d|%d", conf.Prime.String(), conf.RInv.String(), conf.Gf2nBitLength, conf.Gf2nStorageSize)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 func writeGfpParams(playerDataDir string, prime big.Int) error {
 	file, err := Fio.OpenWriteOrCreate(filepath.Join(playerDataDir, "Params-Data"))
 	if err != nil {
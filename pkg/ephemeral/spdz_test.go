@@ -5,18 +5,21 @@
 package ephemeral
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"github.com/carbynestack/ephemeral/pkg/castor"
 	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
 	"github.com/carbynestack/ephemeral/pkg/ephemeral/io"
+	"github.com/carbynestack/ephemeral/pkg/ephemeral/network"
 	. "github.com/carbynestack/ephemeral/pkg/types"
 	"github.com/carbynestack/ephemeral/pkg/utils"
 	"github.com/google/uuid"
 	"io/ioutil"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"strconv"
 	"sync"
 	"time"
@@ -90,6 +93,141 @@ var _ = Describe("Spdz", func() {
 				Expect(err.Error()).To(And(ContainSubstring(s.sourceCodePath), HaveSuffix("no such file or directory")))
 			})
 		})
+		Context("the compile cache is enabled", func() {
+			It("compiles identical programs only once unless forced", func() {
+				cmd := &CountingFakeExecutor{}
+				dir := filepath.Dir(fileName)
+				s := &SPDZEngine{
+					cmder:          cmd,
+					sourceCodePath: fileName,
+					logger:         zap.NewNop().Sugar(),
+					config:         &SPDZEngineTypedConfig{PrepFolder: prepFolder},
+					compileCache:   NewCompileCache(dir, filepath.Join(dir, "cache"), []string{"*.bc"}, 1),
+				}
+				conf := &CtxConfig{
+					Act: &Activation{
+						Code: "a",
+					},
+				}
+				Expect(s.Compile(conf)).To(Succeed())
+				Expect(s.Compile(conf)).To(Succeed())
+				Expect(cmd.Calls).To(Equal(1))
+				conf.ForceCompile = true
+				Expect(s.Compile(conf)).To(Succeed())
+				Expect(cmd.Calls).To(Equal(2))
+			})
+		})
+		Context("compiler options are provided", func() {
+			It("passes them as flags to the compiler", func() {
+				Expect(compilerFlags(nil)).To(BeEmpty())
+				Expect(compilerFlags(&CompilerOptions{
+					OptimizationLevel: 2,
+					BitLength:         64,
+					Budget:            1000,
+					Prime:             "17",
+				})).To(Equal([]string{"-l", "-D", "-F", "64", "-b", "1000", "--prime", "17"}))
+			})
+		})
+		Context("compiling with a report", func() {
+			It("reports the number of threads and the bytecode size", func() {
+				cmder := &CompilingFakeExecutor{threads: 2}
+				s := &SPDZEngine{
+					cmder:          cmder,
+					sourceCodePath: fileName,
+					logger:         zap.NewNop().Sugar(),
+					config:         &SPDZEngineTypedConfig{PrepFolder: prepFolder},
+				}
+				report, err := s.CompileWithReport(&CtxConfig{Act: &Activation{Code: "a"}})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(report.Success).To(BeTrue())
+				Expect(report.Threads).To(Equal(2))
+				Expect(report.BytecodeSize).To(Equal(int64(5)))
+			})
+			It("compiles in a temporary directory which is removed afterwards", func() {
+				cmder := &CompilingFakeExecutor{threads: 1}
+				s := &SPDZEngine{
+					cmder:          cmder,
+					sourceCodePath: fileName,
+					logger:         zap.NewNop().Sugar(),
+					config:         &SPDZEngineTypedConfig{PrepFolder: prepFolder},
+				}
+				_, err := s.CompileWithReport(&CtxConfig{Act: &Activation{Code: "a"}})
+				Expect(err).NotTo(HaveOccurred())
+				_, err = s.CompileWithReport(&CtxConfig{Act: &Activation{Code: "b"}})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cmder.dirs).To(HaveLen(2))
+				Expect(cmder.dirs[0]).NotTo(Equal(cmder.dirs[1]))
+				for _, dir := range cmder.dirs {
+					Expect(dir).NotTo(Equal(filepath.Dir(fileName)))
+					Expect(dir).NotTo(BeADirectory())
+				}
+				Expect(fileName).NotTo(BeAnExistingFile())
+			})
+			It("streams the output of the compiler", func() {
+				var stdout bytes.Buffer
+				s := &SPDZEngine{
+					cmder:          &CompilingFakeExecutor{threads: 1, stdout: "Compiling program"},
+					sourceCodePath: fileName,
+					logger:         zap.NewNop().Sugar(),
+					config:         &SPDZEngineTypedConfig{PrepFolder: prepFolder},
+				}
+				report, err := s.CompileWithReport(&CtxConfig{Act: &Activation{Code: "a"}, CompilerStdout: &stdout})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(report.Stdout).To(Equal("Compiling program"))
+				Expect(stdout.String()).To(Equal("Compiling program"))
+			})
+			It("reports programs declaring too many threads as unsuccessful", func() {
+				var stderr bytes.Buffer
+				s := &SPDZEngine{
+					cmder:          &CompilingFakeExecutor{threads: 4},
+					sourceCodePath: fileName,
+					logger:         zap.NewNop().Sugar(),
+					config:         &SPDZEngineTypedConfig{PrepFolder: prepFolder, MaxThreads: 2},
+				}
+				report, err := s.CompileWithReport(&CtxConfig{Act: &Activation{Code: "a"}, CompilerStderr: &stderr})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(report.Success).To(BeFalse())
+				Expect(report.Stderr).To(Equal("the program uses 4 threads, at most 2 are allowed"))
+				Expect(stderr.String()).To(Equal(report.Stderr))
+			})
+			It("reports a failing compilation as unsuccessful", func() {
+				s := &SPDZEngine{
+					cmder:          &BrokenFakeExecutor{},
+					sourceCodePath: fileName,
+					logger:         zap.NewNop().Sugar(),
+					config:         &SPDZEngineTypedConfig{PrepFolder: prepFolder},
+				}
+				report, err := s.CompileWithReport(&CtxConfig{Act: &Activation{Code: "a"}})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(report.Success).To(BeFalse())
+			})
+		})
+		Context("gf2n is disabled", func() {
+			var s *SPDZEngine
+			BeforeEach(func() {
+				s = &SPDZEngine{
+					sourceCodePath: fileName,
+					logger:         zap.NewNop().Sugar(),
+					config:         &SPDZEngineTypedConfig{PrepFolder: prepFolder, Gf2nDisabled: true},
+				}
+			})
+			It("rejects programs requiring gf2n tuples", func() {
+				s.cmder = &OutputFakeExecutor{stdout: "Program requires at most:\n        100 integer triples\n         10 gf2n triples\n"}
+				err := s.Compile(&CtxConfig{Act: &Activation{Code: "a"}})
+				Expect(err).To(Equal(errGf2nDisabled))
+			})
+			It("compiles programs requiring gfp tuples only", func() {
+				s.cmder = &OutputFakeExecutor{stdout: "Program requires at most:\n        100 integer triples\n          2 integer bits\n"}
+				Expect(s.Compile(&CtxConfig{Act: &Activation{Code: "a"}})).To(Succeed())
+			})
+			It("reports the missing gf2n configuration", func() {
+				s.cmder = &OutputFakeExecutor{stdout: "Program requires at most:\n          1 gf2n bits\n"}
+				report, err := s.CompileWithReport(&CtxConfig{Act: &Activation{Code: "a"}})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(report.Success).To(BeFalse())
+				Expect(report.Stderr).To(Equal(errGf2nDisabled.Error()))
+			})
+		})
 		Context("compilation fails", func() {
 			It("returns an error", func() {
 				s := &SPDZEngine{
@@ -123,12 +261,12 @@ var _ = Describe("Spdz", func() {
 			prepFolder, _ := ioutil.TempDir("", "ephemeral_")
 			fileName = fmt.Sprintf("%s/ip-file", prepFolder)
 			s = &SPDZEngine{
-				proxy:   &FakeProxy{},
-				logger:  zap.NewNop().Sugar(),
-				cmder:   &FakeExecutor{},
-				feeder:  &FakeFeeder{},
-				baseDir: "/tmp",
-				ipFile:  fileName,
+				newProxy: func() network.AbstractProxy { return &FakeProxy{} },
+				logger:   zap.NewNop().Sugar(),
+				cmder:    &FakeExecutor{},
+				feeder:   &FakeFeeder{},
+				baseDir:  "/tmp",
+				ipFile:   fileName,
 				config: &SPDZEngineTypedConfig{
 					PlayerID:   int32(0),
 					PrepFolder: prepFolder,
@@ -179,13 +317,154 @@ var _ = Describe("Spdz", func() {
 		})
 		Context("when proxy fails to start", func() {
 			It("returns an error", func() {
-				s.proxy = &BrokenFakeProxy{}
+				s.newProxy = func() network.AbstractProxy { return &BrokenFakeProxy{} }
 				res, err := s.Activate(ctx)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(Equal("error starting the tcp proxy: some error"))
 				Expect(res).To(BeNil())
 			})
 		})
+		Context("when games overlap", func() {
+			It("runs and stops a proxy of their own for each game", func() {
+				var proxies []*CountingFakeProxy
+				s.newProxy = func() network.AbstractProxy {
+					p := &CountingFakeProxy{}
+					proxies = append(proxies, p)
+					return p
+				}
+				ctx.Act.SecretParams = []string{"b"}
+				_, err := s.Activate(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				ctx.Act.GameID = "0e4a4a0a-6d0f-4c3b-8a8e-6f5c1b2d3e4f"
+				_, err = s.Activate(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(proxies).To(HaveLen(2))
+				for _, p := range proxies {
+					Expect(p.runs).To(Equal(1))
+					Expect(p.stops).To(Equal(1))
+				}
+			})
+		})
+		Context("when proxy ports are assigned per game", func() {
+			It("writes a game specific ip file and releases the ports afterwards", func() {
+				allocator, err := network.NewPortSetAllocator("20000:20001", 2)
+				Expect(err).NotTo(HaveOccurred())
+				s.proxyPorts = allocator
+				ctx.Act.SecretParams = []string{"b"}
+				ctx.ProxyEntries = []*ProxyConfig{{Host: "peer", Port: "30000", LocalPort: "5001"}}
+				_, err = s.Activate(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ctx.ProxyEntries[0].LocalPort).To(Equal("20001"))
+				Expect(s.localPorts(ctx)).To(Equal([]string{"5000", "20001"}))
+				_, err = os.Stat(fmt.Sprintf("%s-%s", fileName, ctx.Act.GameID))
+				Expect(os.IsNotExist(err)).To(BeTrue())
+				_, err = allocator.Acquire("other")
+				Expect(err).NotTo(HaveOccurred())
+			})
+			Context("when the players are mapped to other parties", func() {
+				It("lists the ports of the players in the order of their party numbers", func() {
+					s.config.PartyNumbers = []int32{1, 0}
+					ctx.Act.SecretParams = []string{"b"}
+					ctx.ProxyEntries = []*ProxyConfig{{Host: "peer", Port: "30000", LocalPort: "5001"}}
+					_, err := s.Activate(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					content, err := ioutil.ReadFile(fileName)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(content)).To(Equal("localhost:5001\nlocalhost:5000\n"))
+				})
+			})
+			Context("when the games belong to a session", func() {
+				var (
+					allocator *network.PortSetAllocator
+					proxies   []*CountingFakeProxy
+				)
+				BeforeEach(func() {
+					var err error
+					allocator, err = network.NewPortSetAllocator("20000:20001", 2)
+					Expect(err).NotTo(HaveOccurred())
+					s.proxyPorts = allocator
+					proxies = nil
+					s.newProxy = func() network.AbstractProxy {
+						p := &CountingFakeProxy{}
+						proxies = append(proxies, p)
+						return p
+					}
+					s.sessionProxies = map[string]*sessionProxy{}
+					ctx.Act.SessionID = "a5d8a2b4-4c4e-4b8e-9a53-1f0b6a4c8f11"
+					ctx.Act.SecretParams = []string{"b"}
+					ctx.ProxyEntries = []*ProxyConfig{{Host: "peer", Port: "30000", LocalPort: "5001"}}
+				})
+				It("keeps the proxy and the ports until the session is closed", func() {
+					_, err := s.Activate(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					ctx.Act.GameID = "0e4a4a0a-6d0f-4c3b-8a8e-6f5c1b2d3e4f"
+					_, err = s.Activate(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(proxies).To(HaveLen(1))
+					Expect(proxies[0].runs).To(Equal(1))
+					Expect(proxies[0].stops).To(Equal(0))
+					_, err = allocator.Acquire("other")
+					Expect(err).To(HaveOccurred())
+					s.CloseSession(ctx.Act.SessionID)
+					Expect(proxies[0].stops).To(Equal(1))
+					_, err = allocator.Acquire("other")
+					Expect(err).NotTo(HaveOccurred())
+				})
+				It("replaces the proxy if the other players are reached differently", func() {
+					_, err := s.Activate(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					ctx.ProxyEntries = []*ProxyConfig{{Host: "other-peer", Port: "30000", LocalPort: "5001"}}
+					_, err = s.Activate(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(proxies).To(HaveLen(2))
+					Expect(proxies[0].stops).To(Equal(1))
+					Expect(proxies[1].runs).To(Equal(1))
+				})
+			})
+		})
+		Context("when the traffic between the players is compressed", func() {
+			It("lets MP-SPDZ listen on the internal port behind the proxy", func() {
+				s.config.ProxyCompression = &ProxyCompressionConfig{Level: 1, InternalPortOffset: 20000}
+				ctx.Compression = true
+				ctx.Act.SecretParams = []string{"b"}
+				ctx.ProxyEntries = []*ProxyConfig{{Host: "peer", Port: "30000", LocalPort: "5001"}}
+				_, err := s.Activate(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ctx.InboundProxy).To(Equal(&ProxyConfig{Host: "localhost", Port: "25000", LocalPort: "5000"}))
+				content, err := ioutil.ReadFile(fileName)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(Equal("localhost:25000\nlocalhost:5001\n"))
+			})
+		})
+		Context("when the players negotiate the compression", func() {
+			var (
+				enabled = true
+				players []*pb.Player
+			)
+			BeforeEach(func() {
+				ctx.Spdz.ProxyCompression = &ProxyCompressionConfig{}
+				players = []*pb.Player{{Id: 0, Features: []string{FeatureCompression}}, {Id: 1, Features: []string{FeatureCompression}}}
+			})
+			It("advertises the compression if requested by the activation", func() {
+				ctx.Act.Compression = &enabled
+				Expect(playerFeatures(ctx)).To(ConsistOf(FeatureCompression))
+			})
+			It("advertises the compression by default if configured", func() {
+				Expect(playerFeatures(ctx)).To(BeEmpty())
+				ctx.Spdz.ProxyCompression.ByDefault = true
+				Expect(playerFeatures(ctx)).To(ConsistOf(FeatureCompression))
+			})
+			It("does not advertise the compression if not supported", func() {
+				ctx.Spdz.ProxyCompression = nil
+				ctx.Act.Compression = &enabled
+				Expect(playerFeatures(ctx)).To(BeEmpty())
+			})
+			It("compresses the traffic only if all players support it", func() {
+				Expect(allSupport(players, FeatureCompression)).To(BeTrue())
+				players[1].Features = nil
+				Expect(allSupport(players, FeatureCompression)).To(BeFalse())
+			})
+		})
 		Context("when writing the IP file fails", func() {
 			It("returns an error", func() {
 				s.ipFile = fmt.Sprintf("/non-existing-dir-%d/non-existing-file-%d", random, random)
@@ -213,6 +492,7 @@ var _ = Describe("Spdz", func() {
 			s = &SPDZEngine{
 				logger:  zap.NewNop().Sugar(),
 				baseDir: "/tmp",
+				ipFile:  ipFile,
 				config: &SPDZEngineTypedConfig{
 					PlayerID: int32(0),
 				},
@@ -259,6 +539,24 @@ var _ = Describe("Spdz", func() {
 				})
 			})
 		})
+		Context("when the schedule declares an invalid number of threads", func() {
+			BeforeEach(func() {
+				mockedFio.OpenReadResponse = utils.OpenReadResponse{File: &utils.SimpleFileMock{}, Error: nil}
+			})
+			It("returns an error if the number is not positive", func() {
+				mockedFio.ReadLineResponse = utils.ReadLineResponse{Line: "0", Error: nil}
+				s.startMPC(ctx)
+				err := <-errCh
+				Expect(err.Error()).To(Equal(`failed to determine the number of threads: invalid number of threads "0" in the program's schedule`))
+			})
+			It("returns an error if the number exceeds the limit", func() {
+				s.config.MaxThreads = 4
+				mockedFio.ReadLineResponse = utils.ReadLineResponse{Line: "8", Error: nil}
+				s.startMPC(ctx)
+				err := <-errCh
+				Expect(err.Error()).To(Equal("failed to determine the number of threads: the program uses 8 threads, at most 4 are allowed"))
+			})
+		})
 		Context("when multiple threads defined", func() {
 			var scheduleFile utils.File
 			numberOfThreads := 2
@@ -293,6 +591,139 @@ var _ = Describe("Spdz", func() {
 						Expect(err).To(Equal(fmt.Errorf("expected error")))
 					})
 				})
+				Context("when gf2n is disabled", func() {
+					It("streams gfp tuples only", func() {
+						s.config.Gf2nDisabled = true
+						s.cmder = &FakeExecutor{}
+						var streamed []castor.TupleType
+						s.streamerFactory = func(l *zap.SugaredLogger, tt castor.TupleType, conf *SPDZEngineTypedConfig, dir string, id uuid.UUID, thread int) (io.TupleStreamer, error) {
+							streamed = append(streamed, tt)
+							return FakeStreamerFactory(l, tt, conf, dir, id, thread)
+						}
+						s.startMPC(ctx)
+						Expect(errCh).To(BeEmpty())
+						Expect(streamed).To(HaveLen(numberOfThreads * len(castor.DefaultTupleTypes()) / 2))
+						for _, tt := range streamed {
+							Expect(tt.SpdzProtocol).To(Equal(castor.SPDZGfp))
+						}
+					})
+				})
+				Context("when the tuple streams of all threads fail at once", func() {
+					It("returns an error without blocking a streamer", func() {
+						release := make(chan struct{})
+						defer close(release)
+						s.cmder = &BlockingFakeExecutor{release: release}
+						streamers := 0
+						s.streamerFactory = func(*zap.SugaredLogger, castor.TupleType, *SPDZEngineTypedConfig, string, uuid.UUID, int) (io.TupleStreamer, error) {
+							streamers++
+							return &FailingFakeTupleStreamer{err: fmt.Errorf("expected error")}, nil
+						}
+						done := make(chan struct{})
+						go func() {
+							s.startMPC(ctx)
+							close(done)
+						}()
+						Eventually(done, 5*time.Second).Should(BeClosed())
+						Expect(streamers).To(Equal(numberOfThreads * len(castor.DefaultTupleTypes())))
+						err := <-errCh
+						Expect(err).To(Equal(fmt.Errorf("error while streaming tuples: expected error")))
+					})
+				})
+				Context("when the activation selects a protocol", func() {
+					var (
+						cmder    *RecordingFakeExecutor
+						streamed []castor.TupleType
+					)
+					BeforeEach(func() {
+						cmder = &RecordingFakeExecutor{}
+						streamed = nil
+						s.cmder = cmder
+						s.streamerFactory = func(l *zap.SugaredLogger, tt castor.TupleType, conf *SPDZEngineTypedConfig, dir string, id uuid.UUID, thread int) (io.TupleStreamer, error) {
+							streamed = append(streamed, tt)
+							return FakeStreamerFactory(l, tt, conf, dir, id, thread)
+						}
+						s.config.Protocols = map[string]ProtocolConfig{
+							MascotProtocol: DefaultProtocols()[MascotProtocol],
+							"semi":         {Executable: "./Semi-Party.x", Flags: []string{"--batch-size", "100"}},
+							"shamir":       {Executable: "./Shamir-Party.x", TupleFamilies: []string{"Triples"}},
+						}
+						s.config.DefaultProtocol = MascotProtocol
+					})
+					It("runs its executable with its flags", func() {
+						ctx.Act.Protocol = "semi"
+						s.startMPC(ctx)
+						Expect(errCh).To(BeEmpty())
+						Expect(cmder.Commands).To(Equal([][]string{{"./Semi-Party.x 0 mpc-program -N 2 --ip-file-name /mp-spdz/ip-file --batch-size 100"}}))
+						Expect(streamed).To(BeEmpty())
+					})
+					It("passes the extra arguments after the flags of the protocol", func() {
+						ctx.Act.Protocol = "semi"
+						s.config.MPSPDZ.ExtraArgs = []string{"--bucket-size", "4"}
+						s.startMPC(ctx)
+						Expect(errCh).To(BeEmpty())
+						Expect(cmder.Commands).To(Equal([][]string{{"./Semi-Party.x 0 mpc-program -N 2 --ip-file-name /mp-spdz/ip-file --batch-size 100 --bucket-size 4"}}))
+					})
+					It("runs the executable as the party the player is mapped to", func() {
+						ctx.Act.Protocol = "semi"
+						s.config.PartyNumbers = []int32{1, 0}
+						s.startMPC(ctx)
+						Expect(errCh).To(BeEmpty())
+						Expect(cmder.Commands).To(Equal([][]string{{"./Semi-Party.x 1 mpc-program -N 2 --ip-file-name /mp-spdz/ip-file --batch-size 100"}}))
+						Expect(s.getFeedPorts(ctx.Act)).To(Equal([]string{"10001"}))
+					})
+					It("accepts the client connections on the ports of the party the player is mapped to", func() {
+						s.config.PartyNumbers = []int32{1, 0}
+						ctx.Act.Connections = []ClientConnection{{Params: 1}, {Port: 14000, Params: 1}}
+						Expect(s.getFeedPorts(ctx.Act)).To(Equal([]string{"10001", "14001"}))
+					})
+					It("streams the tuple families it requires only", func() {
+						ctx.Act.Protocol = "shamir"
+						s.startMPC(ctx)
+						Expect(errCh).To(BeEmpty())
+						Expect(cmder.Commands).To(Equal([][]string{{"./Shamir-Party.x 0 mpc-program -N 2 --ip-file-name /mp-spdz/ip-file --file-prep-per-thread"}}))
+						Expect(streamed).To(ConsistOf(castor.MultiplicationTripleGfp, castor.MultiplicationTripleGfp, castor.MultiplicationTripleGf2n, castor.MultiplicationTripleGf2n))
+					})
+					It("runs the default protocol if none is selected", func() {
+						s.startMPC(ctx)
+						Expect(errCh).To(BeEmpty())
+						Expect(cmder.Commands[0][0]).To(HavePrefix("./Player-Online.x "))
+						Expect(streamed).To(HaveLen(2 * len(castor.DefaultTupleTypes())))
+					})
+					It("returns an error if the protocol is not declared", func() {
+						ctx.Act.Protocol = "hemi"
+						s.startMPC(ctx)
+						err := <-errCh
+						Expect(err.Error()).To(Equal("unsupported protocol hemi, supported are [mascot semi shamir]"))
+					})
+					Context("when process limits are configured", func() {
+						BeforeEach(func() {
+							ctx.Act.Protocol = "semi"
+						})
+						It("runs the executable with the limits and a temporary directory of the game", func() {
+							s.config.ProcessLimits = &ProcessLimitsConfig{MaxOpenFiles: 256, TmpDir: "/scratch"}
+							s.startMPC(ctx)
+							Expect(errCh).To(BeEmpty())
+							tmpDir := "/scratch/" + ctx.Act.GameID
+							Expect(cmder.Commands).To(Equal([][]string{{"ulimit -n 256 && ulimit -c 0 && export TMPDIR=" + tmpDir + " && exec ./Semi-Party.x 0 mpc-program -N 2 --ip-file-name /mp-spdz/ip-file --batch-size 100"}}))
+							Expect(mockedFio.CreatePathCalls).To(ContainElement(tmpDir))
+							Expect(mockedFio.DeleteCalls).To(ContainElement(tmpDir))
+						})
+						It("creates the temporary directory in the MP-SPDZ directory by default", func() {
+							s.config.ProcessLimits = &ProcessLimitsConfig{CoreDumps: true}
+							s.startMPC(ctx)
+							Expect(errCh).To(BeEmpty())
+							Expect(cmder.Commands[0][0]).To(HavePrefix("export TMPDIR=/tmp/tmp/" + ctx.Act.GameID + " && exec ./Semi-Party.x "))
+						})
+						It("returns an error if the temporary directory cannot be created", func() {
+							s.config.ProcessLimits = &ProcessLimitsConfig{}
+							mockedFio.CreatePathResponse = errors.New("read-only file system")
+							s.startMPC(ctx)
+							err := <-errCh
+							Expect(err.Error()).To(Equal("error creating the temporary directory of the game: read-only file system"))
+							Expect(cmder.Commands).To(BeEmpty())
+						})
+					})
+				})
 				Context("with tuple streamer started successfully", func() {
 					Context("when SPDZ process fails", func() {
 						BeforeEach(func() {
@@ -370,6 +801,103 @@ var _ = Describe("Spdz", func() {
 			Expect(gfpMacFile).To(BeAnExistingFile())
 			Expect(gfpParamsFile).To(BeAnExistingFile())
 		})
+		It("names the MAC key files after the party the player is mapped to", func() {
+			prepFolder, _ := ioutil.TempDir("", "ephemeral_")
+			defer os.RemoveAll(prepFolder)
+			config := &SPDZEngineTypedConfig{PrepFolder: prepFolder, PlayerID: 0, PlayerCount: 2, PartyNumbers: []int32{1, 0}}
+			_, err := NewSPDZEngine(zap.NewNop().Sugar(), &utils.Commander{}, config)
+			Expect(err).NotTo(HaveOccurred())
+			gfpMacFile := fmt.Sprintf("%s/%d-%s-%d/Player-MAC-Keys-%s-P1",
+				config.PrepFolder, config.PlayerCount, castor.SPDZGfp.Shorthand, config.Prime.BitLen(), castor.SPDZGfp.Shorthand)
+			Expect(gfpMacFile).To(BeAnExistingFile())
+		})
+		It("uses the configured MP-SPDZ installation", func() {
+			prepFolder, _ := ioutil.TempDir("", "ephemeral_")
+			defer os.RemoveAll(prepFolder)
+			config := &SPDZEngineTypedConfig{PrepFolder: prepFolder, MPSPDZ: MPSPDZConfig{BaseDir: "/opt/mp-spdz", SourceDir: "/var/programs"}}
+			s, err := NewSPDZEngine(zap.NewNop().Sugar(), &utils.Commander{}, config)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.baseDir).To(Equal("/opt/mp-spdz"))
+			Expect(s.ipFile).To(Equal("/opt/mp-spdz/ip-file"))
+			Expect(s.schedulePath).To(Equal("/opt/mp-spdz/Programs/Schedules/mpc-program.sch"))
+			Expect(s.sourceCodePath).To(Equal("/var/programs/mpc-program.mpc"))
+			Expect(s.compileCommand(nil)).To(Equal("./compile.py -M /var/programs/mpc-program.mpc"))
+		})
+		It("compiles the program by name if its source is located in the MP-SPDZ directory", func() {
+			prepFolder, _ := ioutil.TempDir("", "ephemeral_")
+			defer os.RemoveAll(prepFolder)
+			s, err := NewSPDZEngine(zap.NewNop().Sugar(), &utils.Commander{}, &SPDZEngineTypedConfig{PrepFolder: prepFolder})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.sourceCodePath).To(Equal("/mp-spdz/Programs/Source/mpc-program.mpc"))
+			Expect(s.compileCommand(nil)).To(Equal("./compile.py -M mpc-program"))
+		})
+		It("does not prepare player data for gf2n if it is disabled", func() {
+			prepFolder, _ := ioutil.TempDir("", "ephemeral_")
+			defer os.RemoveAll(prepFolder)
+			config := &SPDZEngineTypedConfig{PrepFolder: prepFolder, Gf2nDisabled: true}
+			s, err := NewSPDZEngine(zap.NewNop().Sugar(), &utils.Commander{}, config)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.playerDataPaths).To(HaveLen(1))
+			Expect(s.playerDataPaths).To(HaveKey(castor.SPDZGfp))
+			gf2nDir := fmt.Sprintf("%s/%d-%s-%d", config.PrepFolder, config.PlayerCount, castor.SPDZGf2n.Shorthand, config.Gf2nBitLength)
+			Expect(gf2nDir).NotTo(BeADirectory())
+		})
+	})
+	Context("when deciding on insecure preprocessing", func() {
+		var (
+			conf      *SPDZEngineTypedConfig
+			available bool
+			probe     func() bool
+		)
+		BeforeEach(func() {
+			conf = &SPDZEngineTypedConfig{}
+			available = true
+			probe = func() bool { return available }
+		})
+		It("fetches the tuples from castor if disabled", func() {
+			available = false
+			Expect(useInsecurePreprocessing(conf, probe, zap.NewNop().Sugar(), "game")).To(BeFalse())
+		})
+		It("always generates fake preprocessing data if enabled", func() {
+			conf.InsecurePreprocessing = &InsecurePreprocessingConfig{Enabled: true}
+			Expect(useInsecurePreprocessing(conf, probe, zap.NewNop().Sugar(), "game")).To(BeTrue())
+		})
+		It("generates fake preprocessing data as a fallback only if castor is unreachable", func() {
+			conf.InsecurePreprocessing = &InsecurePreprocessingConfig{Enabled: true, Fallback: true}
+			Expect(useInsecurePreprocessing(conf, probe, zap.NewNop().Sugar(), "game")).To(BeFalse())
+			available = false
+			Expect(useInsecurePreprocessing(conf, probe, zap.NewNop().Sugar(), "game")).To(BeTrue())
+		})
+		It("considers castor unavailable if not configured", func() {
+			Expect(castorAvailable(&SPDZEngineTypedConfig{})()).To(BeFalse())
+		})
+		It("advertises the proposal to the other players", func() {
+			ctx := &CtxConfig{Act: &Activation{}, Spdz: conf, InsecurePreprocessing: true}
+			Expect(playerFeatures(ctx)).To(ConsistOf(FeatureInsecurePreprocessing))
+		})
+		It("generates fake preprocessing data if any player proposed it", func() {
+			conf.InsecurePreprocessing = &InsecurePreprocessingConfig{Enabled: true, Fallback: true}
+			players := []*pb.Player{{Id: 100}, {Id: 101, Features: []string{FeatureInsecurePreprocessing}}}
+			Expect(agreeOnInsecurePreprocessing(players, conf)).To(BeTrue())
+			Expect(agreeOnInsecurePreprocessing([]*pb.Player{{Id: 100}, {Id: 101}}, conf)).To(BeFalse())
+		})
+		It("fails the game if fake preprocessing data is proposed while disabled", func() {
+			players := []*pb.Player{{Id: 100}, {Id: 101, Features: []string{FeatureInsecurePreprocessing}}}
+			_, err := agreeOnInsecurePreprocessing(players, conf)
+			Expect(err).To(MatchError("player 1 proposed fake preprocessing data, but insecure preprocessing is disabled"))
+		})
+	})
+	Context("when fingerprinting the parameters", func() {
+		It("depends on the prime but not on the MAC keys", func() {
+			conf := &SPDZEngineTypedConfig{Gf2nBitLength: 40}
+			conf.Prime.SetInt64(17)
+			fingerprint := ParamsFingerprint(conf)
+			conf.GfpMacKey.SetInt64(3)
+			conf.Gf2nMacKey = "0x1"
+			Expect(ParamsFingerprint(conf)).To(Equal(fingerprint))
+			conf.Prime.SetInt64(19)
+			Expect(ParamsFingerprint(conf)).NotTo(Equal(fingerprint))
+		})
 	})
 	Context("executing SPDZWrapper", func() {
 		var (
@@ -406,7 +934,8 @@ var _ = Describe("Spdz", func() {
 							Id: 100,
 						},
 						&pb.Player{
-							Id: 101,
+							Id:   101,
+							Port: 30001,
 						},
 					},
 				}
@@ -416,6 +945,33 @@ var _ = Describe("Spdz", func() {
 				Expect(res).To(Equal([]byte("a")))
 			})
 		})
+		Context("when the PlayersReady event is repeated", func() {
+			It("starts the computation only once", func() {
+				var activations int
+				w.activate = func(*CtxConfig) ([]byte, error) {
+					activations++
+					return []byte("a"), nil
+				}
+				event := &pb.Event{Players: []*pb.Player{{Id: 100}, {Id: 101, Ip: "10.0.0.1", Port: 30001}}}
+				Expect(w.Execute(event)).To(Succeed())
+				<-respCh
+				moved := &pb.Event{Players: []*pb.Player{{Id: 100}, {Id: 101, Ip: "10.0.0.2", Port: 30001}}}
+				Expect(w.Execute(moved)).To(Succeed())
+				Expect(activations).To(Equal(1))
+				Expect(respCh).To(BeEmpty())
+				Expect(w.ctx.ProxyEntries[0].Host).To(Equal("10.0.0.1"))
+			})
+			It("reports the changes of the players", func() {
+				before := []*pb.Player{{Id: 100}, {Id: 101, Ip: "10.0.0.1", Port: 30001}, {Id: 102, Ip: "10.0.0.2", Port: 30002}}
+				after := []*pb.Player{{Id: 103, Ip: "10.0.0.3", Port: 30003}, {Id: 101, Ip: "10.0.0.1", Port: 30005}, {Id: 100}}
+				added, removed, changed := diffPlayers(before, after)
+				Expect(added).To(Equal([]int32{3}))
+				Expect(removed).To(Equal([]int32{2}))
+				Expect(changed).To(Equal([]int32{1}))
+				added, removed, changed = diffPlayers(before, before)
+				Expect(append(append(added, removed...), changed...)).To(BeEmpty())
+			})
+		})
 		Context("when there is no second player in the list", func() {
 			It("returns an error", func() {
 				event := &pb.Event{
@@ -431,6 +987,72 @@ var _ = Describe("Spdz", func() {
 				Expect(err.Error()).To(Equal("you must provide at least two players"))
 			})
 		})
+		Context("when proxy entries cannot be created for all players", func() {
+			It("returns the skipped players to the channels", func() {
+				event := &pb.Event{
+					Players: []*pb.Player{
+						{Id: 100},
+						{Id: 101},
+						{Id: 102, Port: 30002},
+					},
+				}
+				err := w.Execute(event)
+				Expect(err).To(MatchError("could not get all ProxyEntries, skipped players: 0 (self), 1 (missing port)"))
+				Expect(<-errCh).To(Equal(err))
+			})
+		})
+		Context("when proxy entries are dry-run", func() {
+			It("reports the entries and the skipped players independent of the order of the players", func() {
+				players := []*pb.Player{
+					{Id: 103, Ip: "10.0.0.3", Port: 30003},
+					{Id: 101, Ip: "10.0.0.1", Port: 30001},
+					{Id: 100, Ip: "10.0.0.0", Port: 30000},
+					{Id: 102, Ip: "10.0.0.2"},
+					{Id: 101, Ip: "10.0.0.4", Port: 30004},
+				}
+				diagnosis := w.DryRunProxyEntries(&pb.Event{Players: players})
+				Expect(diagnosis.Entries).To(Equal([]*ProxyConfig{
+					{Host: "10.0.0.1", Port: "30001", LocalPort: "5001"},
+					{Host: "10.0.0.3", Port: "30003", LocalPort: "5003"},
+				}))
+				Expect(diagnosis.Skipped).To(Equal([]SkippedPlayer{
+					{ID: 0, Reason: SkippedSelf},
+					{ID: 1, Reason: SkippedDuplicateID},
+					{ID: 2, Reason: SkippedMissingPort},
+				}))
+				players[0], players[2] = players[2], players[0]
+				Expect(w.DryRunProxyEntries(&pb.Event{Players: players})).To(Equal(diagnosis))
+				Expect(w.ctx.ProxyEntries).To(Equal([]*ProxyConfig{{}}))
+			})
+			It("reaches the players in the same cluster directly", func() {
+				w.ctx.Spdz.Colocation = &ColocationConfig{Cluster: "dev", PodIP: "10.1.0.1"}
+				players := []*pb.Player{
+					{Id: 100, Cluster: "dev", LocalIp: "10.1.0.1"},
+					{Id: 101, Ip: "10.0.0.1", Port: 30001, Cluster: "dev", LocalIp: "10.1.0.2"},
+					{Id: 102, Ip: "10.0.0.2", Port: 30002, Cluster: "prod", LocalIp: "10.2.0.1"},
+				}
+				diagnosis := w.DryRunProxyEntries(&pb.Event{Players: players})
+				Expect(diagnosis.Entries).To(Equal([]*ProxyConfig{
+					{Host: "10.1.0.2", Port: "5001", LocalPort: "5001"},
+					{Host: "10.0.0.2", Port: "30002", LocalPort: "5002"},
+				}))
+			})
+			It("verifies the gateways of the players by the names of their parties if TLS is enabled", func() {
+				w.ctx.Spdz.TlsEnabled = true
+				w.ctx.Spdz.PartyNumbers = []int32{0, 2, 1}
+				w.ctx.Spdz.Colocation = &ColocationConfig{Cluster: "dev", PodIP: "10.1.0.1"}
+				players := []*pb.Player{
+					{Id: 100, Cluster: "dev", LocalIp: "10.1.0.1"},
+					{Id: 101, Ip: "10.0.0.1", Port: 30001, Cluster: "dev", LocalIp: "10.1.0.2"},
+					{Id: 102, Ip: "10.0.0.2", Port: 30002, Cluster: "prod", LocalIp: "10.2.0.1"},
+				}
+				diagnosis := w.DryRunProxyEntries(&pb.Event{Players: players})
+				Expect(diagnosis.Entries).To(Equal([]*ProxyConfig{
+					{Host: "10.1.0.2", Port: "5001", LocalPort: "5001"},
+					{Host: "10.0.0.2", Port: "30002", LocalPort: "5002", ServerName: "P1"},
+				}))
+			})
+		})
 		Context("when activation fails", func() {
 			It("returns err to the channels and responds with an error", func() {
 				w.activate = func(*CtxConfig) ([]byte, error) {
@@ -442,7 +1064,8 @@ var _ = Describe("Spdz", func() {
 							Id: 100,
 						},
 						&pb.Player{
-							Id: 101,
+							Id:   101,
+							Port: 30001,
 						},
 					},
 				}
@@ -454,12 +1077,46 @@ var _ = Describe("Spdz", func() {
 			})
 		})
 	})
+	Context("when passing the output of the MPC runtime on", func() {
+		var (
+			stdout, stderr bytes.Buffer
+			ctx            *CtxConfig
+		)
+		BeforeEach(func() {
+			stdout.Reset()
+			stderr.Reset()
+			ctx = &CtxConfig{Context: context.TODO(), RuntimeStdout: &stdout, RuntimeStderr: &stderr}
+		})
+		It("copies the output while the runtime runs", func() {
+			s := &SPDZEngine{cmder: &cmder, baseDir: "/tmp"}
+			out, _, err := s.callRuntime(ctx, []string{"echo 1; echo 2 >&2"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(out)).To(Equal("1\n"))
+			Expect(stdout.String()).To(Equal("1\n"))
+			Expect(stderr.String()).To(Equal("2\n"))
+		})
+		It("copies the output once the runtime finished if the executor does not stream", func() {
+			s := &SPDZEngine{cmder: &OutputFakeExecutor{stdout: "1\n"}}
+			_, _, err := s.callRuntime(ctx, []string{"./Player-Online.x"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout.String()).To(Equal("1\n"))
+		})
+	})
 })
 
 func FakeStreamerFactory(*zap.SugaredLogger, castor.TupleType, *SPDZEngineTypedConfig, string, uuid.UUID, int) (io.TupleStreamer, error) {
 	return &FakeTupleStreamer{}, nil
 }
 
+type FailingFakeTupleStreamer struct {
+	err error
+}
+
+func (fts *FailingFakeTupleStreamer) StartStreamTuples(terminateCh chan struct{}, errCh chan error, wg *sync.WaitGroup) {
+	errCh <- fts.err
+	wg.Done()
+}
+
 type FakeTupleStreamer struct {
 	terminateChan chan struct{}
 	errCh         chan error
@@ -0,0 +1,182 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/carbynestack/ephemeral/pkg/castor"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+	. "github.com/carbynestack/ephemeral/pkg/utils"
+)
+
+// gameLayout locates the files of a game, i.e., its program, schedule, ip file and preprocessing data, and the
+// directory MP-SPDZ is run in.
+type gameLayout struct {
+	// dir is the directory the compiler and the virtual machine are run in.
+	dir             string
+	sourceCodePath  string
+	schedulePath    string
+	ipFile          string
+	playerDataPaths map[castor.SPDZProtocol]string
+	// mpSpdzDir is the MP-SPDZ installation the executables are resolved against. Empty if the executables are
+	// located in dir.
+	mpSpdzDir string
+}
+
+// executable returns the path of the given MP-SPDZ executable, e.g., "./compile.py", relative to the directory of
+// the layout.
+func (l *gameLayout) executable(name string) string {
+	if l.mpSpdzDir == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(l.mpSpdzDir, name)
+}
+
+// compileTarget returns the program argument of the compiler, i.e., the name of the program if its source is located
+// in the Programs/Source directory of the layout and the path of the source otherwise.
+func (l *gameLayout) compileTarget() string {
+	if filepath.Dir(l.sourceCodePath) == filepath.Join(l.dir, "Programs/Source") {
+		return appName
+	}
+	return l.sourceCodePath
+}
+
+// compileCommand returns the command used to compile the program of the layout with the given options.
+func (l *gameLayout) compileCommand(opts *CompilerOptions) string {
+	return strings.Join(append(append([]string{l.executable("./compile.py"), "-M"}, compilerFlags(opts)...),
+		l.compileTarget()), " ")
+}
+
+// baseLayout returns the layout of the games sharing the MP-SPDZ directory, i.e., if no work directories are used.
+func (s *SPDZEngine) baseLayout(ctx *CtxConfig) *gameLayout {
+	ipFile := s.ipFile
+	if ctx != nil {
+		ipFile = s.ipFilePath(ctx)
+	}
+	return &gameLayout{
+		dir:             s.baseDir,
+		sourceCodePath:  s.sourceCodePath,
+		schedulePath:    s.schedulePath,
+		ipFile:          ipFile,
+		playerDataPaths: s.playerDataPaths,
+	}
+}
+
+// layout returns the layout of the given game, which is located in the work directory of the game if configured.
+func (s *SPDZEngine) layout(ctx *CtxConfig) *gameLayout {
+	if !s.workDirsEnabled() || ctx == nil || ctx.Act.GameID == "" {
+		return s.baseLayout(ctx)
+	}
+	return s.dirLayout(s.workDir(ctx.Act.GameID))
+}
+
+// dirLayout returns the layout of a program located in the given directory, e.g., the work directory of a game.
+func (s *SPDZEngine) dirLayout(dir string) *gameLayout {
+	playerDataPaths := map[castor.SPDZProtocol]string{}
+	for p, path := range s.playerDataPaths {
+		playerDataPaths[p] = filepath.Join(dir, "Player-Data", filepath.Base(path)) + "/"
+	}
+	return &gameLayout{
+		dir:             dir,
+		sourceCodePath:  filepath.Join(dir, "Programs/Source", appName+".mpc"),
+		schedulePath:    filepath.Join(dir, "Programs/Schedules", appName+".sch"),
+		ipFile:          filepath.Join(dir, filepath.Base(s.ipFile)),
+		playerDataPaths: playerDataPaths,
+		mpSpdzDir:       s.baseDir,
+	}
+}
+
+// workDirsEnabled returns whether the games get their own work directories.
+func (s *SPDZEngine) workDirsEnabled() bool {
+	return s.config != nil && s.config.GameWorkDirs != nil
+}
+
+// workDirsRoot returns the directory the work directories of the games are created in.
+func (s *SPDZEngine) workDirsRoot() string {
+	if root := s.config.GameWorkDirs.Root; root != "" {
+		return root
+	}
+	return filepath.Join(s.baseDir, "Games")
+}
+
+// workDir returns the work directory of the given game.
+func (s *SPDZEngine) workDir(gameID string) string {
+	return filepath.Join(s.workDirsRoot(), filepath.Base(gameID))
+}
+
+// prepareWorkDir creates the work directory of the given game, if configured and not done yet, with the directories
+// MP-SPDZ expects and the preprocessing data parameters. The program deployed in the MP-SPDZ directory is copied to
+// the work directory if requested and none has been compiled for the game.
+func (s *SPDZEngine) prepareWorkDir(ctx *CtxConfig, deployedProgram bool) error {
+	if !s.workDirsEnabled() {
+		return nil
+	}
+	l := s.layout(ctx)
+	for _, d := range []string{"Programs/Source", "Programs/Bytecode", "Programs/Schedules"} {
+		if err := Fio.CreatePath(filepath.Join(l.dir, d)); err != nil {
+			return fmt.Errorf("error creating the work directory of the game: %v", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(l.dir, "Player-Data")); os.IsNotExist(err) {
+		conf := s.config.Snapshot()
+		conf.PrepFolder = filepath.Join(l.dir, "Player-Data")
+		if _, err := preparePlayerData(conf); err != nil {
+			return err
+		}
+	}
+	if certs := s.config.PlayerCerts; certs != nil {
+		if err := certs.Install(filepath.Join(l.dir, "Player-Data")); err != nil {
+			return fmt.Errorf("error installing the player certificates: %v", err)
+		}
+	}
+	if !deployedProgram {
+		return nil
+	}
+	if _, err := os.Stat(l.schedulePath); err == nil {
+		return nil
+	}
+	base := s.baseLayout(nil)
+	programs, err := filepath.Glob(filepath.Join(base.dir, "Programs/Bytecode", appName+"-*.bc"))
+	if err != nil {
+		return err
+	}
+	programs = append(programs, base.schedulePath)
+	for _, src := range programs {
+		rel, err := filepath.Rel(base.dir, src)
+		if err != nil {
+			return err
+		}
+		if err := copyFile(src, filepath.Join(l.dir, rel)); err != nil {
+			return fmt.Errorf("error copying the program to the work directory of the game: %v", err)
+		}
+	}
+	return nil
+}
+
+// RemoveWorkDir removes the work directory of the given game, if any. It is called once the game has finished.
+func (s *SPDZEngine) RemoveWorkDir(gameID string) {
+	if !s.workDirsEnabled() || gameID == "" {
+		return
+	}
+	if err := Fio.Delete(s.workDir(gameID)); err != nil {
+		s.logger.Warnw("Failed to remove the work directory of the game", GameID, gameID, "Error", err)
+	}
+}
+
+// cleanWorkDirs removes the work directories left behind by a previous run of the service.
+func (s *SPDZEngine) cleanWorkDirs() error {
+	if !s.workDirsEnabled() {
+		return nil
+	}
+	root := s.workDirsRoot()
+	if err := Fio.Delete(root); err != nil {
+		return fmt.Errorf("error removing the work directories of previous games: %v", err)
+	}
+	return Fio.CreatePath(root)
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package ephemeral
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/carbynestack/ephemeral/pkg/castor"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+	"github.com/carbynestack/ephemeral/pkg/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Game work directories", func() {
+	const gameID = "71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4"
+	var (
+		baseDir string
+		config  *SPDZEngineTypedConfig
+		ctx     *CtxConfig
+	)
+	BeforeEach(func() {
+		var err error
+		baseDir, err = ioutil.TempDir("", "ephemeral_")
+		Expect(err).NotTo(HaveOccurred())
+		config = &SPDZEngineTypedConfig{
+			PrepFolder:   filepath.Join(baseDir, "Player-Data"),
+			Gf2nDisabled: true,
+			MPSPDZ:       MPSPDZConfig{BaseDir: baseDir},
+			GameWorkDirs: &GameWorkDirsConfig{},
+		}
+		ctx = &CtxConfig{Act: &Activation{GameID: gameID}}
+	})
+	AfterEach(func() {
+		os.RemoveAll(baseDir)
+	})
+	Context("when no work directories are configured", func() {
+		It("runs the games in the MP-SPDZ directory", func() {
+			config.GameWorkDirs = nil
+			s, err := NewSPDZEngine(zap.NewNop().Sugar(), &utils.Commander{}, config)
+			Expect(err).NotTo(HaveOccurred())
+			l := s.layout(ctx)
+			Expect(l.dir).To(Equal(baseDir))
+			Expect(l.schedulePath).To(Equal(s.schedulePath))
+			Expect(l.executable("./Fake-Offline.x")).To(Equal("./Fake-Offline.x"))
+			Expect(s.prepareWorkDir(ctx, true)).To(Succeed())
+			Expect(filepath.Join(baseDir, "Games")).NotTo(BeADirectory())
+		})
+	})
+	Context("when work directories are configured", func() {
+		var s *SPDZEngine
+		BeforeEach(func() {
+			Expect(os.MkdirAll(filepath.Join(baseDir, "Games", "left-behind"), 0755)).To(Succeed())
+			var err error
+			s, err = NewSPDZEngine(zap.NewNop().Sugar(), &utils.Commander{}, config)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("removes the work directories of previous runs", func() {
+			Expect(filepath.Join(baseDir, "Games")).To(BeADirectory())
+			Expect(filepath.Join(baseDir, "Games", "left-behind")).NotTo(BeADirectory())
+		})
+		It("locates the files of a game in its work directory", func() {
+			dir := filepath.Join(baseDir, "Games", gameID)
+			l := s.layout(ctx)
+			Expect(l.dir).To(Equal(dir))
+			Expect(l.sourceCodePath).To(Equal(filepath.Join(dir, "Programs/Source", appName+".mpc")))
+			Expect(l.schedulePath).To(Equal(filepath.Join(dir, "Programs/Schedules", appName+".sch")))
+			Expect(l.ipFile).To(Equal(filepath.Join(dir, "ip-file")))
+			Expect(l.playerDataPaths[castor.SPDZGfp]).To(HavePrefix(filepath.Join(dir, "Player-Data") + "/"))
+			Expect(l.executable("./Player-Online.x")).To(Equal(filepath.Join(baseDir, "Player-Online.x")))
+			Expect(l.compileCommand(nil)).To(Equal(filepath.Join(baseDir, "compile.py") + " -M " + appName))
+		})
+		It("does not escape the root with the game id", func() {
+			Expect(s.workDir("../../etc")).To(Equal(filepath.Join(baseDir, "Games", "etc")))
+		})
+		It("prepares the work directory with the preprocessing data parameters and the deployed program", func() {
+			Expect(os.MkdirAll(filepath.Join(baseDir, "Programs/Bytecode"), 0755)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(baseDir, "Programs/Schedules"), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(baseDir, "Programs/Bytecode", appName+"-0.bc"), []byte("bc"), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(s.schedulePath, []byte("1\n"), 0644)).To(Succeed())
+			Expect(s.prepareWorkDir(ctx, true)).To(Succeed())
+			l := s.layout(ctx)
+			Expect(filepath.Join(l.dir, "Programs/Source")).To(BeADirectory())
+			Expect(filepath.Join(l.dir, "Programs/Bytecode", appName+"-0.bc")).To(BeAnExistingFile())
+			Expect(l.schedulePath).To(BeAnExistingFile())
+			Expect(filepath.Join(l.playerDataPaths[castor.SPDZGfp], "Params-Data")).To(BeAnExistingFile())
+		})
+		It("keeps the program compiled for the game", func() {
+			Expect(s.prepareWorkDir(ctx, false)).To(Succeed())
+			l := s.layout(ctx)
+			Expect(ioutil.WriteFile(l.schedulePath, []byte("2\n"), 0644)).To(Succeed())
+			Expect(s.prepareWorkDir(ctx, true)).To(Succeed())
+			content, err := ioutil.ReadFile(l.schedulePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("2\n"))
+		})
+		It("removes the work directory once the game has finished", func() {
+			Expect(s.prepareWorkDir(ctx, false)).To(Succeed())
+			s.RemoveWorkDir(gameID)
+			Expect(s.layout(ctx).dir).NotTo(BeADirectory())
+		})
+	})
+})
@@ -53,7 +53,8 @@ func generateEphemeralIntegrationTestsWithPlayerCount(playerCount int) {
 				Port:   port,
 				Logger: logger,
 			}
-			tr := server.NewTransportServer(serverConf)
+			tr, err := server.NewTransportServer(serverConf)
+			Expect(err).NotTo(HaveOccurred())
 			pb := discovery.NewPublisher(bus)
 			stateTimeout := 10 * time.Second
 			computationTimeout := 20 * time.Second
@@ -61,13 +62,17 @@ func generateEphemeralIntegrationTestsWithPlayerCount(playerCount int) {
 				FreePorts: []int32{30000, 30001, 30002, 30003, 30004, 30005},
 			}
 			cl := &discovery.FakeDClient{}
-			s := discovery.NewServiceNG(bus, pb, stateTimeout, computationTimeout, tr, n, frontendAddress, logger, ModeMaster, cl, playerCount)
+			s := discovery.NewServiceNG(bus, pb, stateTimeout, computationTimeout, tr, n, frontendAddress, logger, ModeMaster, cl, playerCount, discovery.NewMemoryStateStore())
 			defer s.Stop()
 			go s.Start()
 			s.WaitUntilReady(5 * time.Second)
 			act := &Activation{
 				GameID: "71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4",
 			}
+			// The clients of the players reconnect and replay unacknowledged events until their context is done, so
+			// they must not outlive the test and leak into the games of the following ones.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
 			players := make([]*p.PlayerWithIO, playerCount)
 			for i := 0; i < playerCount; i++ {
 				ctxConf := &CtxConfig{
@@ -76,7 +81,7 @@ func generateEphemeralIntegrationTestsWithPlayerCount(playerCount int) {
 						FrontendURL: frontendAddress,
 						PlayerID:    int32(i),
 					},
-					Context: context.TODO(),
+					Context: ctx,
 				}
 				pod := fmt.Sprintf("abc%d", i)
 				player, err := p.NewPlayerWithIO(ctxConf, conf, pod, spdz, stateTimeout, computationTimeout, errCh, logger)
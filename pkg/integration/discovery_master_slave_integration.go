@@ -84,7 +84,8 @@ func getDiscovery(port string, logger *zap.SugaredLogger, bus mb.MessageBus, fro
 		Port:   port,
 		Logger: logger,
 	}
-	tr := server.NewTransportServer(serverConf)
+	tr, err := server.NewTransportServer(serverConf)
+	Expect(err).NotTo(HaveOccurred())
 	pb := d.NewPublisher(bus)
 	stateTimeout := 10 * time.Second
 	connectTimeout := 10 * time.Second
@@ -108,6 +109,6 @@ func getDiscovery(port string, logger *zap.SugaredLogger, bus mb.MessageBus, fro
 	}
 	cl, _ := c.NewClient(clientConf)
 	playerCount := 2
-	s := d.NewServiceNG(bus, pb, stateTimeout, connectTimeout, tr, n, frontend, logger, mode, cl, playerCount)
+	s := d.NewServiceNG(bus, pb, stateTimeout, connectTimeout, tr, n, frontend, logger, mode, cl, playerCount, d.NewMemoryStateStore())
 	return s
 }
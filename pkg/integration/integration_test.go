@@ -9,7 +9,9 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"github.com/carbynestack/ephemeral/pkg/conformance"
 	. "github.com/carbynestack/ephemeral/pkg/ephemeral/io"
 	"io/ioutil"
 	"net/http"
@@ -28,6 +30,9 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+// maxThreads is the number of threads used by the multi-threaded programs, e.g., go test -args --max-threads=4.
+var maxThreads = flag.Int("max-threads", 2, "the number of threads used by the multi-threaded programs")
+
 var _ = Describe("Ephemeral integration test", func() {
 	integration := os.Getenv("INTEGRATION")
 	if strings.ToLower(integration) == "true" {
@@ -76,29 +81,17 @@ cint.write_to_socket(client_socket_id, resp)`
 			}
 			RunMPCAndVerify(activation, players, verify)
 		})
-		It("returns a 400 when wrongly encoded params are provided", func() {
-			activation := getActivation(code)
-			notValidBase64Params := []string{
-				"AAAAAAAAAAAAAAAAAAAAAHV5WQAAAAAAAAAAAAAAAAA",
-				"Qv9nIfmyLlZ3iFnFX5pMBKI8JwAAAAAAAAAAAAAAAAA"}
-			activation.SecretParams = notValidBase64Params
-			RunFailingMPCVerify(activation, players, "error decoding secret parameters: illegal base64 data at input byte 40", http.StatusBadRequest)
-		})
-		It("returns a 400 when both secret params and amphora params are specified", func() {
-			activation := getActivation(code)
-			secretParams := []string{
-				"AAAAAAAAAAAAAAAAAAAAAHV5WQAAAAAAAAAAAAAAAAA",
-				"Qv9nIfmyLlZ3iFnFX5pMBKI8JwAAAAAAAAAAAAAAAAA"}
-			amphoraUUIDs := []string{
-				"1",
-				"2"}
-			activation.SecretParams = secretParams
-			activation.AmphoraParams = amphoraUUIDs
-			RunFailingMPCVerify(activation, players, "either secret params or amphora secret share UUIDs must be specified, not both of them", http.StatusBadRequest)
-		})
-		It("returns a 400 when no parameters are specified", func() {
-			activation := getActivation(code)
-			RunFailingMPCVerify(activation, players, "either secret params or amphora secret share UUIDs must be specified, none of them given", http.StatusBadRequest)
+		It("conforms to the API for invalid and failing activations", func() {
+			rootDomain := getRootDomain()
+			var targets []string
+			for _, p := range players {
+				targets = append(targets, fmt.Sprintf("http://hellovc%s.default.%s", p, rootDomain))
+			}
+			runner := &conformance.Runner{}
+			report := runner.Run(targets, conformance.Cases())
+			for _, r := range report.Results {
+				Expect(r.Passed).To(BeTrue(), "%s failed on %s: got %d %q (%s)", r.Case, r.Target, r.Status, r.Message, r.Error)
+			}
 		})
 		It("returns a 503 when no compiled code is given", func() {
 			// We neither supply code nor compile it and we also use generic container with no code inside. So the execution must fail.
@@ -168,6 +161,39 @@ sint.write_to_socket(client_socket_id, resp)`
 			}
 			RunMPCAndVerify(activation, players, verify)
 		})
+		It("runs programs using several threads", func() {
+			// Each thread squares the sum of the inputs, which consumes triples from the tuple streams of the thread.
+			code = fmt.Sprintf(`listen(10000)
+client_socket_id = regint()
+acceptclientconnection(client_socket_id, 10000)
+v = sint.read_from_socket(client_socket_id, 2)
+inputs = Array(2, sint)
+inputs[0] = v[0]
+inputs[1] = v[1]
+squares = Array(%[1]d, sint)
+@for_range_multithread(%[1]d, 1, %[1]d)
+def _(i):
+    sum = inputs[0] + inputs[1]
+    squares[i] = sum * sum
+resp = Array(%[1]d, cint)
+@for_range(%[1]d)
+def _(i):
+    resp[i] = squares[i].reveal()
+cint.write_to_socket(client_socket_id, resp)`, *maxThreads)
+			secretParams := []string{
+				"AAAAAAAAAAAAAAAAAAAAAHV5WQAAAAAAAAAAAAAAAAA=",
+				"Qv9nIfmyLlZ3iFnFX5pMBKI8JwAAAAAAAAAAAAAAAAA="}
+			activation := getActivation(code)
+			activation.SecretParams = secretParams
+			verify := func(result Result) {
+				Expect(result.Response).To(HaveLen(*maxThreads))
+				for _, r := range result.Response {
+					decoded, _ := base64.StdEncoding.DecodeString(r)
+					Expect(string(decoded)).To(Equal("49284"))
+				}
+			}
+			RunMPCAndVerify(activation, players, verify)
+		})
 		It("uses bulk params from the request and responds back with normal param", func() {
 			inputParams := []string{
 				"AAAAAAAAAAAAAAAAAAAAAHV5WQAAAAAAAAAAAAAAAABC/2ch+bIuVneIWcVfmkwEojwnAAAAAAAAAAAAAAAAAA=="}
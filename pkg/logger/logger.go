@@ -9,10 +9,28 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
+// ParseLevel parses the name of a log level, e.g., "info". The debug level is returned for an empty name.
+func ParseLevel(name string) (zapcore.Level, error) {
+	level := zapcore.DebugLevel
+	if name == "" {
+		return level, nil
+	}
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return level, err
+	}
+	return level, nil
+}
+
 // NewDevelopmentLogger returns a new development logger.
 func NewDevelopmentLogger() (*zap.SugaredLogger, error) {
+	return NewDevelopmentLoggerAt(zap.NewAtomicLevelAt(zapcore.DebugLevel))
+}
+
+// NewDevelopmentLoggerAt returns a new development logger logging at the given level, which can be changed while the
+// logger is in use.
+func NewDevelopmentLoggerAt(level zap.AtomicLevel) (*zap.SugaredLogger, error) {
 	cfg := zap.Config{
-		Level:       zap.NewAtomicLevelAt(zapcore.DebugLevel),
+		Level:       level,
 		Development: true,
 		Encoding:    "console",
 		OutputPaths: []string{"stdout"},
@@ -8,16 +8,18 @@
 package controller
 
 import (
+	"github.com/carbynestack/ephemeral/pkg/types"
+
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
 // AddToManagerFuncs is a list of functions to add all Controllers to the Manager
-var AddToManagerFuncs []func(manager.Manager) error
+var AddToManagerFuncs []func(manager.Manager, *types.NetworkControllerConfig) error
 
 // AddToManager adds all Controllers to the Manager
-func AddToManager(m manager.Manager) error {
+func AddToManager(m manager.Manager, conf *types.NetworkControllerConfig) error {
 	for _, f := range AddToManagerFuncs {
-		if err := f(m); err != nil {
+		if err := f(m, conf); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,48 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package network
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/carbynestack/ephemeral/pkg/types"
+)
+
+// DefaultSharedGatewayName is the name of the shared gateway if not configured.
+const DefaultSharedGatewayName = "mpc-shared-gateway"
+
+// defaultIngressSelector selects the default Istio ingress gateway.
+var defaultIngressSelector = map[string]string{"istio": "ingressgateway"}
+
+// ParseConfig reads the configuration of the network controller from the given file. The defaults are used if the
+// file does not exist.
+func ParseConfig(path string) (*types.NetworkControllerConfig, error) {
+	conf := &types.NetworkControllerConfig{}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		SetDefaults(conf)
+		return conf, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, conf); err != nil {
+		return nil, err
+	}
+	SetDefaults(conf)
+	return conf, nil
+}
+
+// SetDefaults sets the default values of the parameters not configured.
+func SetDefaults(conf *types.NetworkControllerConfig) {
+	if len(conf.IngressSelector) == 0 {
+		conf.IngressSelector = defaultIngressSelector
+	}
+	if conf.SharedGatewayName == "" {
+		conf.SharedGatewayName = DefaultSharedGatewayName
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package network
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseConfig", func() {
+	var dir string
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "network-controller")
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+	It("uses the defaults if the file does not exist", func() {
+		conf, err := ParseConfig(filepath.Join(dir, "config.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conf.IngressSelector).To(Equal(map[string]string{"istio": "ingressgateway"}))
+		Expect(conf.SharedGatewayName).To(Equal(DefaultSharedGatewayName))
+		Expect(conf.SharedGateway).To(BeFalse())
+	})
+	It("reads the configuration", func() {
+		path := filepath.Join(dir, "config.json")
+		data := []byte(`{"ingressSelector": {"app": "mpc-ingress"}, "gatewayNamespace": "istio-system", "sharedGateway": true}`)
+		Expect(ioutil.WriteFile(path, data, 0644)).To(Succeed())
+		conf, err := ParseConfig(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conf.IngressSelector).To(Equal(map[string]string{"app": "mpc-ingress"}))
+		Expect(conf.GatewayNamespace).To(Equal("istio-system"))
+		Expect(conf.SharedGateway).To(BeTrue())
+		Expect(conf.SharedGatewayName).To(Equal(DefaultSharedGatewayName))
+	})
+	It("fails for malformed files", func() {
+		path := filepath.Join(dir, "config.json")
+		Expect(ioutil.WriteFile(path, []byte(`{`), 0644)).To(Succeed())
+		_, err := ParseConfig(path)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -10,52 +10,70 @@ package network
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+
 	mpcv1alpha1 "github.com/carbynestack/ephemeral/pkg/network-controller/apis/mpc/v1alpha1"
-	clientset "github.com/knative/pkg/client/clientset/versioned"
+	ctypes "github.com/carbynestack/ephemeral/pkg/types"
+	"github.com/gogo/protobuf/proto"
+	networkingv1beta1 "istio.io/api/networking/v1beta1"
+	istiov1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	clientset "istio.io/client-go/pkg/clientset/versioned"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	"knative.dev/pkg/apis/istio/v1alpha3"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
-	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
-var log = logf.ZapLogger(true).WithName("controller_network")
-
-var istioGW = "test"
+var log = logf.Log.WithName("controller_network")
 
 var podLabel = "mpc.podName"
 
+// networkFinalizer marks the networks whose gateway and virtual service have to be deleted, and whose port has to be
+// released, before the network is removed.
+const networkFinalizer = "mpc.bosch.com/network-cleanup"
+
+// gatewayLabel marks the gateways created by the controller.
+const gatewayLabel = "mpc.gateway"
+
 // Add creates a new Network Controller and adds it to the PortsState. The PortsState will set fields on the Controller
 // and Start it when the PortsState is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+func Add(mgr manager.Manager, conf *ctypes.NetworkControllerConfig) error {
+	return add(mgr, newReconciler(mgr, conf))
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, conf *ctypes.NetworkControllerConfig) reconcile.Reconciler {
 
 	c := mgr.GetConfig()
 	cs := clientset.NewForConfigOrDie(c)
 
-	return &ReconcileNetwork{client: mgr.GetClient(), scheme: mgr.GetScheme(), sharedClientSet: cs}
+	var releasePort PortReleaser
+	if adminURL := os.Getenv(DiscoveryAdminURLEnv); adminURL != "" {
+		releasePort = NewHTTPPortReleaser(adminURL, &http.Client{Timeout: releaseTimeout})
+	}
+
+	return &ReconcileNetwork{client: mgr.GetClient(), scheme: mgr.GetScheme(), sharedClientSet: cs, releasePort: releasePort, config: conf}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 
 	// Add Istio resources to the scheme so we can watch them.
-	v1alpha3.AddToScheme(mgr.GetScheme())
+	if err := istiov1beta1.AddToScheme(mgr.GetScheme()); err != nil {
+		return err
+	}
 
 	// Create a new controller
 	c, err := controller.New("network-controller", mgr, controller.Options{Reconciler: r})
@@ -70,7 +88,7 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	}
 
 	// Watch for changes to secondary resource Pods and requeue the owner Network
-	err = watchChildResources(c, &corev1.Service{}, &v1alpha3.VirtualService{})
+	err = watchChildResources(c, &corev1.Service{}, &istiov1beta1.Gateway{}, &istiov1beta1.VirtualService{})
 	if err != nil {
 		return err
 	}
@@ -99,7 +117,10 @@ type ReconcileNetwork struct {
 	// that reads objects from the cache and writes to the apiserver
 	client          client.Client
 	scheme          *runtime.Scheme
-	sharedClientSet *clientset.Clientset
+	sharedClientSet clientset.Interface
+	// releasePort is called once a network is deleted, the ports are not released if nil.
+	releasePort PortReleaser
+	config      *ctypes.NetworkControllerConfig
 }
 
 // Reconcile reads that state of the cluster for a Network object and makes changes based on the state read
@@ -126,6 +147,21 @@ func (r *ReconcileNetwork) Reconcile(request reconcile.Request) (reconcile.Resul
 		return reconcile.Result{}, err
 	}
 
+	if instance.DeletionTimestamp != nil {
+		if err := r.finalize(instance); err != nil {
+			reqLogger.Error(err, "not able to finalize the network")
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, nil
+	}
+	if !containsString(instance.Finalizers, networkFinalizer) {
+		instance.Finalizers = append(instance.Finalizers, networkFinalizer)
+		if err := r.client.Update(context.TODO(), instance); err != nil {
+			reqLogger.Error(err, "not able to add the finalizer to the network")
+			return reconcile.Result{}, err
+		}
+	}
+
 	// TODO: this is a pretty hacky solution, if the pod is reconciled after an update, the label will be removed.
 	// Label the pod would like to assign a service to.
 
@@ -138,6 +174,9 @@ func (r *ReconcileNetwork) Reconcile(request reconcile.Request) (reconcile.Resul
 	}
 
 	if _, ok := pod.Labels[podLabel]; !ok {
+		if pod.Labels == nil {
+			pod.Labels = map[string]string{}
+		}
 		pod.Labels[podLabel] = podName
 		err = r.client.Update(context.TODO(), pod)
 		if err != nil {
@@ -167,45 +206,217 @@ func (r *ReconcileNetwork) Reconcile(request reconcile.Request) (reconcile.Resul
 		return reconcile.Result{}, err
 	}
 
-	// Check if the gateway already exist, create it otherwise.
-
-	gw := newGateway(instance, instance.Spec.Port)
+	if r.config.SharedGateway {
+		if err := r.reconcileSharedGateway(instance); err != nil {
+			reqLogger.Error(err, "not able to reconcile the shared gateway", "Gateway.Name", r.config.SharedGatewayName)
+			return reconcile.Result{}, err
+		}
+	} else {
+		gw := newGateway(instance, r.gatewayNamespace(instance), r.config.IngressSelector, r.config.TLSCredentialName)
+		// Owner references must not cross namespaces, gateways in other namespaces are deleted by the finalizer only.
+		if gw.Namespace == instance.Namespace {
+			if err := controllerutil.SetControllerReference(instance, gw, r.scheme); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		if err := r.reconcileGateway(instance, gw); err != nil {
+			reqLogger.Error(err, "not able to reconcile the gateway", "Gateway.Name", gw.Name)
+			return reconcile.Result{}, err
+		}
+	}
 
-	if err := controllerutil.SetControllerReference(instance, gw, r.scheme); err != nil {
+	vs := newVirtualService(instance, instance.Spec.Port, r.gatewayRef(instance))
+	if err := controllerutil.SetControllerReference(instance, vs, r.scheme); err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := r.reconcileVirtualService(instance, vs); err != nil {
+		reqLogger.Error(err, "not able to reconcile the virtual service", "VirtualService.Name", vs.Name)
 		return reconcile.Result{}, err
 	}
 
-	gatewayName := gatewayName(instance.Name)
-	_, err = r.sharedClientSet.NetworkingV1alpha3().Gateways(request.Namespace).Get(gatewayName, metav1.GetOptions{})
-	if err != nil && errors.IsNotFound(err) {
-		reqLogger.Info(fmt.Sprintf("Creating a new gateway \"%s\"", gatewayName))
-		_, err := r.sharedClientSet.NetworkingV1alpha3().Gateways(request.Namespace).Create(gw)
-		if err != nil {
-			reqLogger.Error(err, "not able to create the gateway")
-			return reconcile.Result{}, err
+	return reconcile.Result{}, nil
+}
+
+// reconcileGateway creates the given gateway if it does not exist. An existing gateway whose spec or controller
+// differs, e.g., as it has been created against the v1alpha3 API by a previous version of the controller, is converted
+// to the given one.
+func (r *ReconcileNetwork) reconcileGateway(instance *mpcv1alpha1.Network, gw *istiov1beta1.Gateway) error {
+	gateways := r.sharedClientSet.NetworkingV1beta1().Gateways(gw.Namespace)
+	found, err := gateways.Get(gw.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		log.Info(fmt.Sprintf("Creating a new gateway \"%s\"", gw.Name))
+		_, err = gateways.Create(gw)
+		return err
+	} else if err != nil {
+		return err
+	}
+	owned := len(gw.OwnerReferences) == 0 || metav1.IsControlledBy(found, instance)
+	if proto.Equal(&found.Spec, &gw.Spec) && owned {
+		return nil
+	}
+	log.Info(fmt.Sprintf("Converting the gateway \"%s\"", gw.Name))
+	found.Spec = gw.Spec
+	found.OwnerReferences = gw.OwnerReferences
+	_, err = gateways.Update(found)
+	return err
+}
+
+// reconcileSharedGateway adds the server of the given network to the shared gateway, which is created if it does not
+// exist. The server of the network is replaced if its port changed.
+func (r *ReconcileNetwork) reconcileSharedGateway(instance *mpcv1alpha1.Network) error {
+	gateways := r.sharedClientSet.NetworkingV1beta1().Gateways(r.gatewayNamespace(instance))
+	srv := newServer(instance.Name, instance.Spec.Port, r.config.TLSCredentialName)
+	found, err := gateways.Get(r.config.SharedGatewayName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		gw := newSharedGateway(r.config.SharedGatewayName, r.gatewayNamespace(instance), r.config.IngressSelector)
+		gw.Spec.Servers = []*networkingv1beta1.Server{srv}
+		log.Info(fmt.Sprintf("Creating the shared gateway \"%s\"", gw.Name))
+		_, err = gateways.Create(gw)
+		return err
+	} else if err != nil {
+		return err
+	}
+	i := serverIndex(found.Spec.Servers, instance.Name)
+	if i >= 0 && proto.Equal(found.Spec.Servers[i], srv) && reflect.DeepEqual(found.Spec.Selector, r.config.IngressSelector) {
+		return nil
+	}
+	if i >= 0 {
+		found.Spec.Servers[i] = srv
+	} else {
+		found.Spec.Servers = append(found.Spec.Servers, srv)
+	}
+	found.Spec.Selector = r.config.IngressSelector
+	log.Info(fmt.Sprintf("Adding network \"%s\" to the shared gateway \"%s\"", instance.Name, found.Name))
+	_, err = gateways.Update(found)
+	return err
+}
+
+// releaseSharedGateway removes the server of the given network from the shared gateway. The gateway is deleted once
+// no server is left.
+func (r *ReconcileNetwork) releaseSharedGateway(instance *mpcv1alpha1.Network) error {
+	gateways := r.sharedClientSet.NetworkingV1beta1().Gateways(r.gatewayNamespace(instance))
+	found, err := gateways.Get(r.config.SharedGatewayName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	i := serverIndex(found.Spec.Servers, instance.Name)
+	if i < 0 {
+		return nil
+	}
+	found.Spec.Servers = append(found.Spec.Servers[:i], found.Spec.Servers[i+1:]...)
+	if len(found.Spec.Servers) == 0 {
+		log.Info(fmt.Sprintf("Deleting the shared gateway \"%s\"", found.Name))
+		err = gateways.Delete(found.Name, &metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			return nil
 		}
+		return err
 	}
+	_, err = gateways.Update(found)
+	return err
+}
 
-	vs := newVirtualService(instance, instance.Spec.Port, gatewayName)
+// gatewayNamespace returns the namespace the gateway of the given network is created in.
+func (r *ReconcileNetwork) gatewayNamespace(instance *mpcv1alpha1.Network) string {
+	if r.config.GatewayNamespace != "" {
+		return r.config.GatewayNamespace
+	}
+	return instance.Namespace
+}
 
-	if err := controllerutil.SetControllerReference(instance, vs, r.scheme); err != nil {
-		return reconcile.Result{}, err
+// gatewayRef returns the reference of the virtual service of the given network to its gateway. Gateways in other
+// namespaces are referenced by "<namespace>/<name>".
+func (r *ReconcileNetwork) gatewayRef(instance *mpcv1alpha1.Network) string {
+	name := gatewayName(instance.Name)
+	if r.config.SharedGateway {
+		name = r.config.SharedGatewayName
+	}
+	if ns := r.gatewayNamespace(instance); ns != instance.Namespace {
+		return ns + "/" + name
 	}
+	return name
+}
 
-	_, err = r.sharedClientSet.NetworkingV1alpha3().VirtualServices(request.Namespace).Get(vsName(request.Name), metav1.GetOptions{})
-	if apierrs.IsNotFound(err) {
-		reqLogger.Info("Creating a new Virtual Service", "VirtualService.Namespace", service.Namespace, "VirtualService.Name", vs.Name)
-		_, err := r.sharedClientSet.NetworkingV1alpha3().VirtualServices(request.Namespace).Create(vs)
-		if err != nil {
-			reqLogger.Error(err, "can't create a virtual service")
-			return reconcile.Result{}, err
-		}
+// reconcileVirtualService creates the given virtual service if it does not exist, or converts an existing one, see
+// reconcileGateway.
+func (r *ReconcileNetwork) reconcileVirtualService(instance *mpcv1alpha1.Network, vs *istiov1beta1.VirtualService) error {
+	services := r.sharedClientSet.NetworkingV1beta1().VirtualServices(vs.Namespace)
+	found, err := services.Get(vs.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		log.Info("Creating a new Virtual Service", "VirtualService.Namespace", vs.Namespace, "VirtualService.Name", vs.Name)
+		_, err = services.Create(vs)
+		return err
 	} else if err != nil {
-		reqLogger.Error(err, "error retrieving a virtual service")
-		return reconcile.Result{}, err
+		return err
 	}
+	if proto.Equal(&found.Spec, &vs.Spec) && metav1.IsControlledBy(found, instance) {
+		return nil
+	}
+	log.Info("Converting the Virtual Service", "VirtualService.Namespace", vs.Namespace, "VirtualService.Name", vs.Name)
+	found.Spec = vs.Spec
+	found.OwnerReferences = vs.OwnerReferences
+	_, err = services.Update(found)
+	return err
+}
 
-	return reconcile.Result{}, nil
+// finalize deletes the gateway and the virtual service of the given network, which are not garbage collected along
+// with the network as they are created via the Istio clientset, and releases its port. The finalizer is removed
+// afterwards, so that the network is deleted.
+func (r *ReconcileNetwork) finalize(instance *mpcv1alpha1.Network) error {
+	if !containsString(instance.Finalizers, networkFinalizer) {
+		return nil
+	}
+	networking := r.sharedClientSet.NetworkingV1beta1()
+	err := networking.VirtualServices(instance.Namespace).Delete(vsName(instance.Name), &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if r.config.SharedGateway {
+		err = r.releaseSharedGateway(instance)
+	} else {
+		err = networking.Gateways(r.gatewayNamespace(instance)).Delete(gatewayName(instance.Name), &metav1.DeleteOptions{})
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if r.releasePort != nil {
+		if err := r.releasePort(instance); err != nil {
+			return err
+		}
+	}
+	instance.Finalizers = removeString(instance.Finalizers, networkFinalizer)
+	return r.client.Update(context.TODO(), instance)
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(values []string, s string) []string {
+	var result []string
+	for _, v := range values {
+		if v != s {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// serverIndex returns the index of the server of the given network, or -1 if there is none.
+func serverIndex(servers []*networkingv1beta1.Server, network string) int {
+	for i, s := range servers {
+		if s.Port != nil && s.Port.Name == network {
+			return i
+		}
+	}
+	return -1
 }
 
 func serviceName(base string) string {
@@ -220,15 +431,25 @@ func gatewayName(base string) string {
 	return base + "-mpc-gateway"
 }
 
-func newServer(name string, number int) v1alpha3.Server {
-	return v1alpha3.Server{
-		Port: v1alpha3.Port{
-			Number:   number,
+// newServer returns the server of a gateway exposing the given port. The server terminates mutual TLS with the
+// certificate of the given credential if set, and passes the TCP traffic through otherwise.
+func newServer(name string, number int32, credential string) *networkingv1beta1.Server {
+	srv := &networkingv1beta1.Server{
+		Port: &networkingv1beta1.Port{
+			Number:   uint32(number),
 			Protocol: "TCP",
 			Name:     name,
 		},
 		Hosts: []string{"*"},
 	}
+	if credential != "" {
+		srv.Port.Protocol = "TLS"
+		srv.Tls = &networkingv1beta1.Server_TLSOptions{
+			Mode:           networkingv1beta1.Server_TLSOptions_MUTUAL,
+			CredentialName: credential,
+		}
+	}
+	return srv
 }
 
 func newServiceForKnativePod(cr *mpcv1alpha1.Network) *corev1.Service {
@@ -258,27 +479,27 @@ func newServiceForKnativePod(cr *mpcv1alpha1.Network) *corev1.Service {
 	return service
 }
 
-func newVirtualService(cr *mpcv1alpha1.Network, port int32, gateway string) *v1alpha3.VirtualService {
-	return &v1alpha3.VirtualService{
+func newVirtualService(cr *mpcv1alpha1.Network, port int32, gateway string) *istiov1beta1.VirtualService {
+	return &istiov1beta1.VirtualService{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      vsName(cr.Name),
 			Namespace: cr.Namespace,
 			Labels:    cr.Labels,
 		},
-		Spec: v1alpha3.VirtualServiceSpec{
+		Spec: networkingv1beta1.VirtualService{
 			Hosts:    []string{"*"},
 			Gateways: []string{gateway},
-			TCP: []v1alpha3.TCPRoute{
-				v1alpha3.TCPRoute{
-					Match: []v1alpha3.L4MatchAttributes{
-						v1alpha3.L4MatchAttributes{
-							Port: int(port)},
+			Tcp: []*networkingv1beta1.TCPRoute{
+				{
+					Match: []*networkingv1beta1.L4MatchAttributes{
+						{
+							Port: uint32(port)},
 					},
-					Route: []v1alpha3.HTTPRouteDestination{
-						v1alpha3.HTTPRouteDestination{
-							Destination: v1alpha3.Destination{
+					Route: []*networkingv1beta1.RouteDestination{
+						{
+							Destination: &networkingv1beta1.Destination{
 								Host: serviceName(cr.Name) + "." + cr.Namespace + ".svc.cluster.local",
-								Port: v1alpha3.PortSelector{
+								Port: &networkingv1beta1.PortSelector{
 									Number: uint32(cr.Spec.TargetPort),
 								},
 							},
@@ -290,22 +511,36 @@ func newVirtualService(cr *mpcv1alpha1.Network, port int32, gateway string) *v1a
 		}}
 }
 
-func newGateway(cr *mpcv1alpha1.Network, port int32) *v1alpha3.Gateway {
-	gwlb := cr.Labels
-	gwlb["mpc.gateway"] = "true"
-	selectors := map[string]string{}
-	selectors["istio"] = "ingressgateway"
-	srv := newServer(cr.Name, int(port))
-	servers := []v1alpha3.Server{srv}
-	return &v1alpha3.Gateway{
+func newGateway(cr *mpcv1alpha1.Network, namespace string, selector map[string]string, credential string) *istiov1beta1.Gateway {
+	gwlb := map[string]string{}
+	for k, v := range cr.Labels {
+		gwlb[k] = v
+	}
+	gwlb[gatewayLabel] = "true"
+	srv := newServer(cr.Name, cr.Spec.Port, credential)
+	servers := []*networkingv1beta1.Server{srv}
+	return &istiov1beta1.Gateway{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      gatewayName(cr.Name),
-			Namespace: cr.Namespace,
+			Namespace: namespace,
 			Labels:    gwlb,
 		},
-		Spec: v1alpha3.GatewaySpec{
-			Selector: selectors,
+		Spec: networkingv1beta1.Gateway{
+			Selector: selector,
 			Servers:  servers,
 		},
 	}
 }
+
+func newSharedGateway(name string, namespace string, selector map[string]string) *istiov1beta1.Gateway {
+	return &istiov1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{gatewayLabel: "true"},
+		},
+		Spec: networkingv1beta1.Gateway{
+			Selector: selector,
+		},
+	}
+}
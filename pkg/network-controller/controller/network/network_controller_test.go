@@ -0,0 +1,319 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package network
+
+import (
+	"context"
+	"fmt"
+
+	mpcv1alpha1 "github.com/carbynestack/ephemeral/pkg/network-controller/apis/mpc/v1alpha1"
+	ctypes "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	networkingv1beta1 "istio.io/api/networking/v1beta1"
+	istiov1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("ReconcileNetwork", func() {
+	const (
+		namespace = "default"
+		name      = "network1"
+		pod       = "pod1"
+	)
+	var (
+		r        *ReconcileNetwork
+		istio    *istiofake.Clientset
+		released []string
+		conf     *ctypes.NetworkControllerConfig
+		request  = reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+	)
+	newReconcileWith := func(mutate func(network *mpcv1alpha1.Network), istioObjects ...runtime.Object) {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(mpcv1alpha1.SchemeBuilder.AddToScheme(scheme)).To(Succeed())
+		Expect(istiov1beta1.AddToScheme(scheme)).To(Succeed())
+		network := &mpcv1alpha1.Network{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				UID:       "4711",
+				Labels:    map[string]string{podLabel: pod},
+			},
+			Spec: mpcv1alpha1.NetworkSpec{Port: 30000, TargetPort: 5000},
+		}
+		mutate(network)
+		p := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: pod, Namespace: namespace}}
+		// The objects are created through the clientset, as the object tracker of client-go files the gateways passed
+		// to NewSimpleClientset under the guessed resource "gatewaies".
+		istio = istiofake.NewSimpleClientset()
+		for _, obj := range istioObjects {
+			switch o := obj.(type) {
+			case *istiov1beta1.Gateway:
+				_, err := istio.NetworkingV1beta1().Gateways(o.Namespace).Create(o)
+				Expect(err).NotTo(HaveOccurred())
+			case *istiov1beta1.VirtualService:
+				_, err := istio.NetworkingV1beta1().VirtualServices(o.Namespace).Create(o)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		}
+		released = nil
+		r = &ReconcileNetwork{
+			client:          fake.NewFakeClientWithScheme(scheme, network, p),
+			scheme:          scheme,
+			sharedClientSet: istio,
+			releasePort: func(network *mpcv1alpha1.Network) error {
+				released = append(released, network.Name)
+				return nil
+			},
+			config: conf,
+		}
+	}
+	newReconcile := func(istioObjects ...runtime.Object) {
+		newReconcileWith(func(*mpcv1alpha1.Network) {}, istioObjects...)
+	}
+	BeforeEach(func() {
+		conf = &ctypes.NetworkControllerConfig{}
+		SetDefaults(conf)
+	})
+	Context("when the network is new", func() {
+		BeforeEach(func() {
+			newReconcile()
+		})
+		It("labels the pod and creates the service, the gateway and the virtual service", func() {
+			_, err := r.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			p := &corev1.Pod{}
+			Expect(r.client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: pod}, p)).To(Succeed())
+			Expect(p.Labels).To(HaveKeyWithValue(podLabel, pod))
+
+			svc := &corev1.Service{}
+			Expect(r.client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: serviceName(name)}, svc)).To(Succeed())
+			Expect(svc.Spec.Ports[0].Port).To(Equal(int32(5000)))
+
+			gw, err := istio.NetworkingV1beta1().Gateways(namespace).Get(gatewayName(name), metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gw.Spec.Servers[0].Port.Number).To(Equal(uint32(30000)))
+			Expect(gw.Spec.Selector).To(HaveKeyWithValue("istio", "ingressgateway"))
+			Expect(gw.OwnerReferences[0].Name).To(Equal(name))
+
+			vs, err := istio.NetworkingV1beta1().VirtualServices(namespace).Get(vsName(name), metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vs.Spec.Gateways).To(Equal([]string{gatewayName(name)}))
+			Expect(vs.Spec.Tcp[0].Match[0].Port).To(Equal(uint32(30000)))
+			Expect(vs.Spec.Tcp[0].Route[0].Destination.Host).To(Equal(serviceName(name) + ".default.svc.cluster.local"))
+			Expect(vs.Spec.Tcp[0].Route[0].Destination.Port.Number).To(Equal(uint32(5000)))
+		})
+		It("adds the finalizer to the network", func() {
+			_, err := r.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+			network := &mpcv1alpha1.Network{}
+			Expect(r.client.Get(context.TODO(), request.NamespacedName, network)).To(Succeed())
+			Expect(network.Finalizers).To(ConsistOf(networkFinalizer))
+		})
+		It("does not change the resources when reconciled again", func() {
+			_, err := r.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = r.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+			updates := 0
+			for _, a := range istio.Actions() {
+				if a.GetVerb() == "update" {
+					updates++
+				}
+			}
+			Expect(updates).To(Equal(0))
+		})
+	})
+	Context("when the gateway and virtual service were created by a previous version", func() {
+		BeforeEach(func() {
+			newReconcile(
+				&istiov1beta1.Gateway{
+					ObjectMeta: metav1.ObjectMeta{Name: gatewayName(name), Namespace: namespace},
+					Spec: networkingv1beta1.Gateway{
+						Servers: []*networkingv1beta1.Server{{Port: &networkingv1beta1.Port{Number: 31000, Protocol: "TCP", Name: name}}},
+					},
+				},
+				&istiov1beta1.VirtualService{
+					ObjectMeta: metav1.ObjectMeta{Name: vsName(name), Namespace: namespace},
+					Spec:       networkingv1beta1.VirtualService{Hosts: []string{"*"}},
+				},
+			)
+		})
+		It("converts them", func() {
+			_, err := r.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			gw, err := istio.NetworkingV1beta1().Gateways(namespace).Get(gatewayName(name), metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gw.Spec.Servers[0].Port.Number).To(Equal(uint32(30000)))
+			Expect(metav1.IsControlledBy(gw, &mpcv1alpha1.Network{ObjectMeta: metav1.ObjectMeta{UID: "4711"}})).To(BeTrue())
+
+			vs, err := istio.NetworkingV1beta1().VirtualServices(namespace).Get(vsName(name), metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vs.Spec.Tcp).To(HaveLen(1))
+		})
+	})
+	Context("when the network is deleted", func() {
+		BeforeEach(func() {
+			now := metav1.Now()
+			newReconcileWith(func(network *mpcv1alpha1.Network) {
+				network.DeletionTimestamp = &now
+				network.Finalizers = []string{networkFinalizer}
+			},
+				&istiov1beta1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: gatewayName(name), Namespace: namespace}},
+				&istiov1beta1.VirtualService{ObjectMeta: metav1.ObjectMeta{Name: vsName(name), Namespace: namespace}},
+			)
+		})
+		It("deletes the gateway and the virtual service, releases the port and removes the finalizer", func() {
+			_, err := r.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = istio.NetworkingV1beta1().Gateways(namespace).Get(gatewayName(name), metav1.GetOptions{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+			_, err = istio.NetworkingV1beta1().VirtualServices(namespace).Get(vsName(name), metav1.GetOptions{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+			Expect(released).To(Equal([]string{name}))
+
+			network := &mpcv1alpha1.Network{}
+			Expect(r.client.Get(context.TODO(), request.NamespacedName, network)).To(Succeed())
+			Expect(network.Finalizers).To(BeEmpty())
+		})
+		It("keeps the finalizer if the port cannot be released", func() {
+			r.releasePort = func(*mpcv1alpha1.Network) error {
+				return fmt.Errorf("discovery unavailable")
+			}
+			_, err := r.Reconcile(request)
+			Expect(err).To(MatchError("discovery unavailable"))
+
+			network := &mpcv1alpha1.Network{}
+			Expect(r.client.Get(context.TODO(), request.NamespacedName, network)).To(Succeed())
+			Expect(network.Finalizers).To(ConsistOf(networkFinalizer))
+		})
+	})
+	Context("when the gateways are placed into another namespace", func() {
+		BeforeEach(func() {
+			conf.GatewayNamespace = "istio-system"
+			conf.IngressSelector = map[string]string{"app": "mpc-ingress"}
+			newReconcile()
+		})
+		It("creates the gateway there and references it from the virtual service", func() {
+			_, err := r.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			gw, err := istio.NetworkingV1beta1().Gateways("istio-system").Get(gatewayName(name), metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gw.Spec.Selector).To(Equal(map[string]string{"app": "mpc-ingress"}))
+			Expect(gw.OwnerReferences).To(BeEmpty())
+
+			vs, err := istio.NetworkingV1beta1().VirtualServices(namespace).Get(vsName(name), metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vs.Spec.Gateways).To(Equal([]string{"istio-system/" + gatewayName(name)}))
+		})
+	})
+	Context("when the gateways terminate mutual TLS", func() {
+		BeforeEach(func() {
+			conf.TLSCredentialName = "mpc-player-certs"
+			newReconcile()
+		})
+		It("creates the gateway with a mutual TLS server using the credential", func() {
+			_, err := r.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			gw, err := istio.NetworkingV1beta1().Gateways(namespace).Get(gatewayName(name), metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gw.Spec.Servers).To(HaveLen(1))
+			Expect(gw.Spec.Servers[0].Port.Protocol).To(Equal("TLS"))
+			Expect(gw.Spec.Servers[0].Tls.Mode).To(Equal(networkingv1beta1.Server_TLSOptions_MUTUAL))
+			Expect(gw.Spec.Servers[0].Tls.CredentialName).To(Equal("mpc-player-certs"))
+		})
+	})
+	Context("when the gateway is shared", func() {
+		BeforeEach(func() {
+			conf.SharedGateway = true
+		})
+		It("creates the shared gateway with the server of the network", func() {
+			newReconcile()
+			_, err := r.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			gw, err := istio.NetworkingV1beta1().Gateways(namespace).Get(DefaultSharedGatewayName, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gw.Spec.Servers).To(HaveLen(1))
+			Expect(gw.Spec.Servers[0].Port.Name).To(Equal(name))
+			Expect(gw.Spec.Servers[0].Port.Number).To(Equal(uint32(30000)))
+			_, err = istio.NetworkingV1beta1().Gateways(namespace).Get(gatewayName(name), metav1.GetOptions{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			vs, err := istio.NetworkingV1beta1().VirtualServices(namespace).Get(vsName(name), metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vs.Spec.Gateways).To(Equal([]string{DefaultSharedGatewayName}))
+		})
+		It("adds the server of the network to the existing shared gateway", func() {
+			newReconcile(newSharedGatewayWith(namespace, newServer("network0", 30001, "")))
+			_, err := r.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = r.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			gw, err := istio.NetworkingV1beta1().Gateways(namespace).Get(DefaultSharedGatewayName, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gw.Spec.Servers).To(HaveLen(2))
+			Expect(gw.Spec.Servers[1].Port.Name).To(Equal(name))
+		})
+		It("removes the server of a deleted network and keeps the gateway for the others", func() {
+			now := metav1.Now()
+			newReconcileWith(func(network *mpcv1alpha1.Network) {
+				network.DeletionTimestamp = &now
+				network.Finalizers = []string{networkFinalizer}
+			}, newSharedGatewayWith(namespace, newServer("network0", 30001, ""), newServer(name, 30000, "")))
+			_, err := r.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			gw, err := istio.NetworkingV1beta1().Gateways(namespace).Get(DefaultSharedGatewayName, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gw.Spec.Servers).To(HaveLen(1))
+			Expect(gw.Spec.Servers[0].Port.Name).To(Equal("network0"))
+		})
+		It("deletes the shared gateway once the last network is deleted", func() {
+			now := metav1.Now()
+			newReconcileWith(func(network *mpcv1alpha1.Network) {
+				network.DeletionTimestamp = &now
+				network.Finalizers = []string{networkFinalizer}
+			}, newSharedGatewayWith(namespace, newServer(name, 30000, "")))
+			_, err := r.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = istio.NetworkingV1beta1().Gateways(namespace).Get(DefaultSharedGatewayName, metav1.GetOptions{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+	Context("when the network does not exist", func() {
+		BeforeEach(func() {
+			newReconcile()
+		})
+		It("does nothing", func() {
+			_, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "unknown"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(istio.Actions()).To(BeEmpty())
+		})
+	})
+})
+
+// newSharedGatewayWith returns a shared gateway with the given servers.
+func newSharedGatewayWith(namespace string, servers ...*networkingv1beta1.Server) *istiov1beta1.Gateway {
+	gw := newSharedGateway(DefaultSharedGatewayName, namespace, defaultIngressSelector)
+	gw.Spec.Servers = servers
+	return gw
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package network
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	mpcv1alpha1 "github.com/carbynestack/ephemeral/pkg/network-controller/apis/mpc/v1alpha1"
+)
+
+// DiscoveryAdminURLEnv is the environment variable holding the URL of the admin API of the discovery service the ports
+// of deleted networks are released to, e.g., "http://discovery.default.svc:8081". The ports are not released if not
+// set.
+const DiscoveryAdminURLEnv = "DISCOVERY_ADMIN_URL"
+
+// releasePath is the path of the admin API of the discovery service the ports of deleted networks are released on.
+const releasePath = "/admin/networks/release"
+
+// releaseTimeout is the time the discovery service is given to release the port of a network.
+const releaseTimeout = 10 * time.Second
+
+// PortReleaser is called once a network is deleted, so that its port is returned to the pool it was assigned from.
+type PortReleaser func(network *mpcv1alpha1.Network) error
+
+// NewHTTPPortReleaser returns a PortReleaser posting the names of the deleted networks to the admin API of the
+// discovery service at the given URL.
+func NewHTTPPortReleaser(adminURL string, client *http.Client) PortReleaser {
+	endpoint := strings.TrimSuffix(adminURL, "/") + releasePath
+	return func(network *mpcv1alpha1.Network) error {
+		resp, err := client.Post(endpoint+"?name="+url.QueryEscape(network.Name), "text/plain", nil)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			return fmt.Errorf("releasing the port of network %s failed with status %s", network.Name, resp.Status)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	mpcv1alpha1 "github.com/carbynestack/ephemeral/pkg/network-controller/apis/mpc/v1alpha1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("HTTPPortReleaser", func() {
+	var (
+		server   *httptest.Server
+		status   int
+		requests []*http.Request
+		network  = &mpcv1alpha1.Network{ObjectMeta: metav1.ObjectMeta{Name: "pod1"}}
+	)
+	BeforeEach(func() {
+		status = http.StatusNoContent
+		requests = nil
+		server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			requests = append(requests, req)
+			writer.WriteHeader(status)
+		}))
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+	It("posts the name of the network to the discovery service", func() {
+		Expect(NewHTTPPortReleaser(server.URL+"/", server.Client())(network)).To(Succeed())
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Method).To(Equal(http.MethodPost))
+		Expect(requests[0].URL.Path).To(Equal(releasePath))
+		Expect(requests[0].URL.Query().Get("name")).To(Equal("pod1"))
+	})
+	It("fails if the discovery service does not release the port", func() {
+		status = http.StatusInternalServerError
+		Expect(NewHTTPPortReleaser(server.URL, server.Client())(network)).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,88 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package notify
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// GameSucceeded is emitted when a game finished successfully.
+	GameSucceeded = "GameSucceeded"
+	// GameFailed is emitted when a game finished with an error.
+	GameFailed = "GameFailed"
+)
+
+// Event describes the outcome of a game.
+type Event struct {
+	Name              string    `json:"name"`
+	GameID            string    `json:"gameID"`
+	ProgramIdentifier string    `json:"programIdentifier"`
+	PlayerID          int32     `json:"playerID"`
+	Time              time.Time `json:"time"`
+	// Error is the reason a game failed. Empty for successful games.
+	Error string `json:"error,omitempty"`
+	// Labels are the labels of the activation the game was started by.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Notifier informs external systems about events.
+type Notifier interface {
+	Notify(e Event) error
+}
+
+// NewFilter returns a notifier forwarding only the events with the given names to n. All events are forwarded if no
+// names are given.
+func NewFilter(n Notifier, names []string) Notifier {
+	if len(names) == 0 {
+		return n
+	}
+	f := &Filter{next: n, names: map[string]bool{}}
+	for _, name := range names {
+		f.names[name] = true
+	}
+	return f
+}
+
+// Filter forwards a subset of the events to a notifier.
+type Filter struct {
+	next  Notifier
+	names map[string]bool
+}
+
+// Notify forwards the event if it passes the filter.
+func (f *Filter) Notify(e Event) error {
+	if !f.names[e.Name] {
+		return nil
+	}
+	return f.next.Notify(e)
+}
+
+// NewDispatcher returns a dispatcher sending events to the given notifiers.
+func NewDispatcher(logger *zap.SugaredLogger, notifiers ...Notifier) *Dispatcher {
+	return &Dispatcher{
+		logger:    logger,
+		notifiers: notifiers,
+	}
+}
+
+// Dispatcher sends events to a set of notifiers without blocking the caller.
+type Dispatcher struct {
+	logger    *zap.SugaredLogger
+	notifiers []Notifier
+}
+
+// Dispatch sends the event to all notifiers in the background. Failures are logged only.
+func (d *Dispatcher) Dispatch(e Event) {
+	for _, n := range d.notifiers {
+		go func(n Notifier) {
+			if err := n.Notify(e); err != nil {
+				d.logger.Warnw("Failed to send notification", "Event", e.Name, "GameID", e.GameID, "Error", err)
+			}
+		}(n)
+	}
+}
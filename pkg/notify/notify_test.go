@@ -0,0 +1,84 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+type recordingNotifier struct {
+	events chan Event
+	err    error
+}
+
+func (r *recordingNotifier) Notify(e Event) error {
+	r.events <- e
+	return r.err
+}
+
+var _ = Describe("Notify", func() {
+	var recorder *recordingNotifier
+	BeforeEach(func() {
+		recorder = &recordingNotifier{events: make(chan Event, 10)}
+	})
+	Context("when filtering events", func() {
+		It("forwards only the selected events", func() {
+			f := NewFilter(recorder, []string{GameFailed})
+			Expect(f.Notify(Event{Name: GameSucceeded})).To(Succeed())
+			Expect(f.Notify(Event{Name: GameFailed})).To(Succeed())
+			Expect(recorder.events).To(HaveLen(1))
+			Expect((<-recorder.events).Name).To(Equal(GameFailed))
+		})
+		It("forwards all events if none are selected", func() {
+			Expect(NewFilter(recorder, nil)).To(BeIdenticalTo(recorder))
+		})
+	})
+	Context("when dispatching events", func() {
+		It("notifies all notifiers even if one fails", func() {
+			failing := &recordingNotifier{events: make(chan Event, 1), err: errors.New("unreachable")}
+			d := NewDispatcher(zap.NewNop().Sugar(), failing, recorder)
+			d.Dispatch(Event{Name: GameSucceeded, GameID: "g"})
+			Eventually(failing.events).Should(Receive())
+			Eventually(recorder.events).Should(Receive(Equal(Event{Name: GameSucceeded, GameID: "g"})))
+		})
+	})
+	Context("when posting to a webhook", func() {
+		It("sends the event as JSON", func() {
+			received := make(chan Event, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var e Event
+				Expect(json.NewDecoder(r.Body).Decode(&e)).To(Succeed())
+				Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+				received <- e
+			}))
+			defer server.Close()
+			w, err := NewWebhookNotifier(server.URL, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(w.Notify(Event{Name: GameFailed, GameID: "g", Error: "timeout"})).To(Succeed())
+			Expect(<-received).To(Equal(Event{Name: GameFailed, GameID: "g", Error: "timeout"}))
+		})
+		It("returns an error if the webhook rejects the event", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer server.Close()
+			w, _ := NewWebhookNotifier(server.URL, time.Second)
+			err := w.Notify(Event{Name: GameFailed})
+			Expect(err).To(MatchError("webhook responded with status code 500"))
+		})
+		It("rejects invalid URLs", func() {
+			_, err := NewWebhookNotifier("ftp://host", time.Second)
+			Expect(err).To(MatchError("invalid webhook URL ftp://host"))
+		})
+	})
+})
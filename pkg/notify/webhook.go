@@ -0,0 +1,49 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// NewWebhookNotifier returns a notifier posting events as JSON to the given URL.
+func NewWebhookNotifier(endpoint string, timeout time.Duration) (*WebhookNotifier, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %s", endpoint)
+	}
+	return &WebhookNotifier{
+		URL:        *u,
+		HttpClient: http.Client{Timeout: timeout},
+	}, nil
+}
+
+// WebhookNotifier posts events to a generic webhook.
+type WebhookNotifier struct {
+	URL        url.URL
+	HttpClient http.Client
+}
+
+// Notify posts the event to the webhook. An error is returned if the webhook does not respond with a 2xx status code.
+func (w *WebhookNotifier) Notify(e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	resp, err := w.HttpClient.Post(w.URL.String(), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error posting the notification: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status code %d", resp.StatusCode)
+	}
+	return nil
+}
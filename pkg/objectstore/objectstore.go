@@ -0,0 +1,272 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package objectstore uploads objects to S3 compatible object stores, e.g., AWS S3 or MinIO, and creates presigned URLs
+// to download them. Requests are signed with AWS Signature Version 4.
+package objectstore
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRegion is the region requests are signed for if none is configured.
+	DefaultRegion = "us-east-1"
+	// DefaultURLExpiry is the duration presigned URLs are valid for if none is configured.
+	DefaultURLExpiry = time.Hour
+	// MaxURLExpiry is the longest duration presigned URLs can be valid for.
+	MaxURLExpiry = 7 * 24 * time.Hour
+)
+
+const (
+	signingAlgorithm = "AWS4-HMAC-SHA256"
+	signingService   = "s3"
+	amzDateFormat    = "20060102T150405Z"
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+)
+
+// AbstractClient is an interface for object store clients.
+type AbstractClient interface {
+	// PutObject uploads the data as the object with the given key.
+	PutObject(key string, data []byte) error
+	// PresignGet returns a URL the object with the given key can be downloaded from without credentials.
+	PresignGet(key string) (string, error)
+}
+
+// Config specifies the bucket objects are stored in.
+type Config struct {
+	// Endpoint is the URL of the object store, e.g., "https://s3.eu-central-1.amazonaws.com" or "http://minio:9000".
+	Endpoint string
+	// Region is the region requests are signed for. Defaults to DefaultRegion.
+	Region string
+	// Bucket is the name of the bucket objects are stored in.
+	Bucket string
+	// Prefix is prepended to the keys of the objects.
+	Prefix string
+	// VirtualHostedStyle addresses the bucket as a subdomain of the endpoint instead of as the first segment of the
+	// path. Path style addressing is supported by MinIO and AWS S3 alike.
+	VirtualHostedStyle bool
+	AccessKeyID        string
+	SecretAccessKey    string
+	// URLExpiry is the duration presigned URLs are valid for. Defaults to DefaultURLExpiry.
+	URLExpiry time.Duration
+	// Timeout is the maximum duration of an upload. Not limited if zero.
+	Timeout time.Duration
+}
+
+// NewClient returns a client storing objects in the configured bucket.
+func NewClient(conf Config) (*Client, error) {
+	u, err := url.Parse(conf.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid object store endpoint %s", conf.Endpoint)
+	}
+	if conf.Bucket == "" {
+		return nil, fmt.Errorf("the bucket of the object store must be set")
+	}
+	if conf.AccessKeyID == "" || conf.SecretAccessKey == "" {
+		return nil, fmt.Errorf("the credentials of the object store must be set")
+	}
+	if conf.Region == "" {
+		conf.Region = DefaultRegion
+	}
+	if conf.URLExpiry == 0 {
+		conf.URLExpiry = DefaultURLExpiry
+	}
+	if conf.URLExpiry < time.Second || conf.URLExpiry > MaxURLExpiry {
+		return nil, fmt.Errorf("invalid URL expiry %s, must be between 1s and %s", conf.URLExpiry, MaxURLExpiry)
+	}
+	return &Client{
+		endpoint:   *u,
+		conf:       conf,
+		HttpClient: http.Client{Timeout: conf.Timeout},
+		now:        time.Now,
+	}, nil
+}
+
+// Client stores objects in a bucket of an S3 compatible object store.
+type Client struct {
+	endpoint   url.URL
+	conf       Config
+	HttpClient http.Client
+	now        func() time.Time
+}
+
+// PutObject uploads the data as the object with the given key. An error is returned if the object store does not
+// respond with a 2xx status code.
+func (c *Client) PutObject(key string, data []byte) error {
+	u := c.objectURL(key)
+	req, err := http.NewRequest(http.MethodPut, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	payloadHash := sha256.Sum256(data)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	c.sign(req, hex.EncodeToString(payloadHash[:]))
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error uploading object %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("uploading object %s failed with status code %d: %s", key, resp.StatusCode, body)
+	}
+	return nil
+}
+
+// PresignGet returns a URL the object with the given key can be downloaded from until the configured expiry.
+func (c *Client) PresignGet(key string) (string, error) {
+	now := c.now().UTC()
+	u := c.objectURL(key)
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", signingAlgorithm)
+	query.Set("X-Amz-Credential", c.conf.AccessKeyID+"/"+c.scope(now))
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(c.conf.URLExpiry/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+	canonicalQuery := canonicalQueryString(query)
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		canonicalURI(u.Path),
+		canonicalQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+	signature := c.signature(now, canonicalRequest)
+	u.RawQuery = canonicalQuery + "&X-Amz-Signature=" + signature
+	return u.String(), nil
+}
+
+// objectURL returns the URL of the object with the given key.
+func (c *Client) objectURL(key string) url.URL {
+	u := c.endpoint
+	path := strings.TrimSuffix(u.Path, "/")
+	if c.conf.VirtualHostedStyle {
+		u.Host = c.conf.Bucket + "." + u.Host
+	} else {
+		path += "/" + c.conf.Bucket
+	}
+	u.Path = path + "/" + c.conf.Prefix + key
+	u.RawPath = ""
+	u.RawQuery = ""
+	return u
+}
+
+// sign adds the authorization header to the request. The headers signed are the host and the x-amz-* headers.
+func (c *Client) sign(req *http.Request, payloadHash string) {
+	now := c.now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL.Path),
+		canonicalQueryString(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signingAlgorithm, c.conf.AccessKeyID, c.scope(now), signedHeaders, c.signature(now, canonicalRequest)))
+}
+
+// scope returns the credential scope of requests signed at the given time.
+func (c *Client) scope(t time.Time) string {
+	return strings.Join([]string{t.Format("20060102"), c.conf.Region, signingService, "aws4_request"}, "/")
+}
+
+// signature returns the signature of the canonical request signed at the given time.
+func (c *Client) signature(t time.Time, canonicalRequest string) string {
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		signingAlgorithm,
+		t.Format(amzDateFormat),
+		c.scope(t),
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+	key := hmacSHA256([]byte("AWS4"+c.conf.SecretAccessKey), t.Format("20060102"))
+	key = hmacSHA256(key, c.conf.Region)
+	key = hmacSHA256(key, signingService)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalURI encodes the segments of the path as required by Signature Version 4.
+func canonicalURI(path string) string {
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i := range segments {
+		segments[i] = uriEncode(segments[i])
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQueryString returns the parameters sorted by name and encoded as required by Signature Version 4.
+func canonicalQueryString(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var params []string
+	for _, name := range names {
+		values := append([]string{}, query[name]...)
+		sort.Strings(values)
+		for _, v := range values {
+			params = append(params, uriEncode(name)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// uriEncode percent-encodes all but the unreserved characters.
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || strings.IndexByte("-_.~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
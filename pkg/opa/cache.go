@@ -0,0 +1,85 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package opa
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// NewDecisionCache returns a cache keeping up to size decisions of the given decider for the given duration.
+func NewDecisionCache(decider Decider, ttl time.Duration, size int) *DecisionCache {
+	return &DecisionCache{
+		decider: decider,
+		ttl:     ttl,
+		size:    size,
+		entries: map[[sha256.Size]byte]cachedDecision{},
+		now:     time.Now,
+	}
+}
+
+// DecisionCache caches the decisions of a decider by their input, so that the policy is not evaluated again for
+// repeated activations. Failed evaluations are not cached. It is safe for concurrent use.
+type DecisionCache struct {
+	decider Decider
+	ttl     time.Duration
+	size    int
+	now     func() time.Time
+
+	mux     sync.Mutex
+	entries map[[sha256.Size]byte]cachedDecision
+}
+
+type cachedDecision struct {
+	decision Decision
+	expires  time.Time
+}
+
+// Decide returns the cached decision for the given input, or evaluates the policy if none is cached.
+func (c *DecisionCache) Decide(input interface{}) (Decision, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return Decision{}, err
+	}
+	key := sha256.Sum256(data)
+	c.mux.Lock()
+	entry, ok := c.entries[key]
+	c.mux.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.decision, nil
+	}
+	decision, err := c.decider.Decide(input)
+	if err != nil {
+		return Decision{}, err
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.evict()
+	c.entries[key] = cachedDecision{decision: decision, expires: c.now().Add(c.ttl)}
+	return decision, nil
+}
+
+// evict removes the expired decisions and, if the cache is still full, the decision expiring first.
+func (c *DecisionCache) evict() {
+	now := c.now()
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	for len(c.entries) >= c.size && len(c.entries) > 0 {
+		var oldest [sha256.Size]byte
+		var expires time.Time
+		for key, entry := range c.entries {
+			if expires.IsZero() || entry.expires.Before(expires) {
+				oldest, expires = key, entry.expires
+			}
+		}
+		delete(c.entries, oldest)
+	}
+}
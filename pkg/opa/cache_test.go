@@ -0,0 +1,66 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package opa_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/opa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type countingDecider struct {
+	calls int
+	err   error
+}
+
+func (d *countingDecider) Decide(input interface{}) (Decision, error) {
+	d.calls++
+	return Decision{Allowed: true}, d.err
+}
+
+var _ = Describe("DecisionCache", func() {
+	var decider *countingDecider
+	BeforeEach(func() {
+		decider = &countingDecider{}
+	})
+	It("evaluates the policy once for repeated inputs", func() {
+		cache := NewDecisionCache(decider, time.Hour, 10)
+		for i := 0; i < 3; i++ {
+			decision, err := cache.Decide(map[string]interface{}{"key": "value"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision.Allowed).To(BeTrue())
+		}
+		Expect(decider.calls).To(Equal(1))
+	})
+	It("evaluates the policy again once the decision has expired", func() {
+		cache := NewDecisionCache(decider, time.Nanosecond, 10)
+		_, _ = cache.Decide("input")
+		time.Sleep(time.Millisecond)
+		_, _ = cache.Decide("input")
+		Expect(decider.calls).To(Equal(2))
+	})
+	It("does not cache failed evaluations", func() {
+		decider.err = errors.New("opa is down")
+		cache := NewDecisionCache(decider, time.Hour, 10)
+		_, err := cache.Decide("input")
+		Expect(err).To(HaveOccurred())
+		decider.err = nil
+		_, err = cache.Decide("input")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decider.calls).To(Equal(2))
+	})
+	It("evicts decisions when full", func() {
+		cache := NewDecisionCache(decider, time.Hour, 1)
+		_, _ = cache.Decide("first")
+		_, _ = cache.Decide("second")
+		_, _ = cache.Decide("first")
+		Expect(decider.calls).To(Equal(3))
+	})
+})
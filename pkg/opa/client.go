@@ -41,6 +41,18 @@ type AbstractClient interface {
 	CanExecute(input interface{}) (bool, error)
 }
 
+// Decision is the outcome of the execute policy.
+type Decision struct {
+	Allowed bool `json:"allowed"`
+	// Reason explains the decision, if given by the policy.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Decider is implemented by clients reporting the reason of their execute decisions.
+type Decider interface {
+	Decide(input interface{}) (Decision, error)
+}
+
 // NewClient creates a new OPA client with the given endpoint and policy package. It returns an error if the endpoint is
 // invalid or the policy package is empty.
 func NewClient(logger *zap.SugaredLogger, endpoint string, policyPackage string) (*Client, error) {
@@ -78,12 +90,36 @@ func (c *Client) GenerateTags(data interface{}) ([]amphora.Tag, error) {
 // policy package. It returns true if the program can be executed, false otherwise. An error is returned if the request
 // fails.
 func (c *Client) CanExecute(data interface{}) (bool, error) {
-	result := ExecuteResponse{}
-	err := c.makeOpaRequest(ExecuteAction, data, &result)
+	decision, err := c.Decide(data)
 	if err != nil {
 		return false, err
 	}
-	return result.IsAllowed, nil
+	return decision.Allowed, nil
+}
+
+// Decide evaluates the execute policy for the given input. The policy either returns whether the program can be
+// executed, or an object with the decision as "allowed" and its "reason". The program cannot be executed if the policy
+// returns no result. An error is returned if the request fails.
+func (c *Client) Decide(data interface{}) (Decision, error) {
+	var result struct {
+		Result json.RawMessage `json:"result"`
+	}
+	err := c.makeOpaRequest(ExecuteAction, data, &result)
+	if err != nil {
+		return Decision{}, err
+	}
+	var decision Decision
+	switch {
+	case len(result.Result) == 0:
+	case bytes.HasPrefix(bytes.TrimSpace(result.Result), []byte("{")):
+		err = json.Unmarshal(result.Result, &decision)
+	default:
+		err = json.Unmarshal(result.Result, &decision.Allowed)
+	}
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to unmarshal opa decision: %w", err)
+	}
+	return decision, nil
 }
 
 func (c *Client) makeOpaRequest(action string, data interface{}, v interface{}) error {
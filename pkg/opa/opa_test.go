@@ -151,4 +151,36 @@ var _ = Describe("OpaClient", func() {
 			Expect(err).To(HaveOccurred())
 		})
 	})
+
+	Context("when deciding on an input", func() {
+		decide := func(body string) (Decision, error) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(body))
+			}))
+			defer server.Close()
+			u, _ := url.Parse(server.URL)
+			client := &Client{
+				URL:        *u,
+				HttpClient: http.Client{},
+				Logger:     logger,
+			}
+			return client.Decide(map[string]interface{}{"key": "value"})
+		}
+		It("accepts a boolean result", func() {
+			decision, err := decide(`{"result": true}`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision).To(Equal(Decision{Allowed: true}))
+		})
+		It("accepts a result with a reason", func() {
+			decision, err := decide(`{"result": {"allowed": false, "reason": "not the owner"}}`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision).To(Equal(Decision{Reason: "not the owner"}))
+		})
+		It("denies if the policy is undefined for the input", func() {
+			decision, err := decide(`{}`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision.Allowed).To(BeFalse())
+		})
+	})
 })
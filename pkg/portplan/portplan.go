@@ -0,0 +1,157 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package portplan
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Range is a range of consecutive ports, including its start and end.
+type Range struct {
+	Start, End int32
+}
+
+// String returns the range in the form start_port:end_port, or the port if the range consists of a single port.
+func (r Range) String() string {
+	if r.Start == r.End {
+		return strconv.Itoa(int(r.Start))
+	}
+	return fmt.Sprintf("%d:%d", r.Start, r.End)
+}
+
+// Size returns the number of ports of the range.
+func (r Range) Size() int {
+	return int(r.End-r.Start) + 1
+}
+
+func (r Range) overlaps(o Range) bool {
+	return r.Start <= o.End && o.Start <= r.End
+}
+
+// ParseRange parses a port range in the form start_port:end_port, e.g. 30000:30100, or a single port. Whitespace
+// around the ports is ignored. The ports must be valid TCP ports and the range must not end before it starts.
+func ParseRange(rng string) (Range, error) {
+	parts := strings.Split(rng, ":")
+	if len(parts) > 2 {
+		return Range{}, errors.New("the range must contain a port range in the form start_port:end_port, e.g. 30000:30100")
+	}
+	start, err := parsePort(parts[0])
+	if err != nil {
+		return Range{}, err
+	}
+	end := start
+	if len(parts) == 2 {
+		end, err = parsePort(parts[1])
+		if err != nil {
+			return Range{}, err
+		}
+	}
+	if end < start {
+		return Range{}, fmt.Errorf("the port range %s must not end before it starts", rng)
+	}
+	return Range{Start: start, End: end}, nil
+}
+
+// NormalizeRange returns the given port range in the form start_port:end_port, e.g. "30000:30100" for " 30000 : 30100"
+// and "8080:8080" for "8080".
+func NormalizeRange(rng string) (string, error) {
+	r, err := ParseRange(rng)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d:%d", r.Start, r.End), nil
+}
+
+func parsePort(port string) (int32, error) {
+	p, err := strconv.Atoi(strings.TrimSpace(port))
+	if err != nil {
+		return 0, fmt.Errorf("invalid port %q", port)
+	}
+	if p < 1 || p > 65535 {
+		return 0, fmt.Errorf("invalid port %d, must be between 1 and 65535", p)
+	}
+	return int32(p), nil
+}
+
+// Allocation is a range of ports used by a component of a service.
+type Allocation struct {
+	// Component describes what the ports are used for, e.g., "gRPC API".
+	Component string
+	Range     Range
+}
+
+// Plan collects the ports used by the components of a service to detect conflicting allocations before they result
+// in failures to bind the ports.
+type Plan struct {
+	allocations []Allocation
+}
+
+// Add adds the given range of ports used by the given component.
+func (p *Plan) Add(component string, r Range) {
+	p.allocations = append(p.allocations, Allocation{Component: component, Range: r})
+}
+
+// AddRange adds the ports of the given range, or single port, used by the given component. Nothing is added if the
+// range is empty, i.e., the component is disabled.
+func (p *Plan) AddRange(component, rng string) error {
+	if rng == "" {
+		return nil
+	}
+	r, err := ParseRange(rng)
+	if err != nil {
+		return fmt.Errorf("invalid ports of the %s: %v", component, err)
+	}
+	p.Add(component, r)
+	return nil
+}
+
+// Allocations returns the allocations of the plan ordered by their first port.
+func (p *Plan) Allocations() []Allocation {
+	sorted := make([]Allocation, len(p.allocations))
+	copy(sorted, p.allocations)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Range.Start < sorted[j].Range.Start
+	})
+	return sorted
+}
+
+// Validate returns an error naming the components and ports of the first conflicting allocations, if any.
+func (p *Plan) Validate() error {
+	allocations := p.Allocations()
+	for i := range allocations {
+		for j := i + 1; j < len(allocations) && allocations[j].Range.Start <= allocations[i].Range.End; j++ {
+			if allocations[i].Range.overlaps(allocations[j].Range) {
+				return &ConflictError{First: allocations[i], Second: allocations[j]}
+			}
+		}
+	}
+	return nil
+}
+
+// String returns the allocation map of the plan with one allocation per line, ordered by the ports.
+func (p *Plan) String() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	for _, a := range p.Allocations() {
+		fmt.Fprintf(w, "%s\t%s\n", a.Range, a.Component)
+	}
+	w.Flush()
+	return b.String()
+}
+
+// ConflictError is returned if two components use the same ports.
+type ConflictError struct {
+	First, Second Allocation
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("the ports %s of the %s overlap with the ports %s of the %s", e.First.Range, e.First.Component,
+		e.Second.Range, e.Second.Component)
+}
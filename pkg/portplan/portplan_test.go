@@ -0,0 +1,60 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package portplan
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Port plan", func() {
+	Context("when parsing a range", func() {
+		It("parses ranges and single ports", func() {
+			Expect(ParseRange("30000:30100")).To(Equal(Range{Start: 30000, End: 30100}))
+			Expect(ParseRange(" 30000 : 30100 ")).To(Equal(Range{Start: 30000, End: 30100}))
+			Expect(ParseRange("8080")).To(Equal(Range{Start: 8080, End: 8080}))
+		})
+		It("rejects invalid ranges", func() {
+			for _, rng := range []string{"", "a:b", "1:2:3", "0:10", "10:70000", "20:10"} {
+				_, err := ParseRange(rng)
+				Expect(err).To(HaveOccurred(), rng)
+			}
+		})
+		It("normalizes ranges", func() {
+			Expect(NormalizeRange(" 30000 : 30100")).To(Equal("30000:30100"))
+			Expect(NormalizeRange("8080")).To(Equal("8080:8080"))
+		})
+	})
+	Context("when validating a plan", func() {
+		var plan *Plan
+		BeforeEach(func() {
+			plan = &Plan{}
+			Expect(plan.AddRange("HTTP API", "8080")).To(Succeed())
+			Expect(plan.AddRange("player networks", "30000:30100")).To(Succeed())
+			Expect(plan.AddRange("disabled component", "")).To(Succeed())
+			plan.Add("MP-SPDZ players", Range{Start: 5000, End: 5001})
+		})
+		It("accepts disjoint allocations", func() {
+			Expect(plan.Validate()).To(Succeed())
+		})
+		It("names the conflicting components and their ports", func() {
+			Expect(plan.AddRange("gRPC API", "30050")).To(Succeed())
+			err := plan.Validate()
+			Expect(err).To(MatchError("the ports 30000:30100 of the player networks overlap with the ports 30050 of the gRPC API"))
+			Expect(err.(*ConflictError).Second.Component).To(Equal("gRPC API"))
+		})
+		It("detects conflicts with allocations contained in larger ones", func() {
+			plan.Add("client connections", Range{Start: 29000, End: 29999})
+			plan.Add("admin API", Range{Start: 30100, End: 30100})
+			Expect(plan.Validate()).To(MatchError("the ports 30000:30100 of the player networks overlap with the ports 30100 of the admin API"))
+		})
+		It("reports invalid ranges with the component", func() {
+			Expect(plan.AddRange("gRPC API", "grpc")).To(MatchError(`invalid ports of the gRPC API: invalid port "grpc"`))
+		})
+		It("prints the allocation map ordered by the ports", func() {
+			Expect(plan.String()).To(Equal("5000:5001    MP-SPDZ players\n8080         HTTP API\n30000:30100  player networks\n"))
+		})
+	})
+})
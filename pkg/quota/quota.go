@@ -0,0 +1,193 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package quota
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	executionWindow = time.Hour
+	tupleWindow     = 24 * time.Hour
+)
+
+// NewTracker returns a tracker enforcing the given limits per key. A limit of zero disables the respective quota. If a
+// store path is given, the usage is persisted to and restored from that file.
+func NewTracker(executionsPerHour int, tupleBytesPerDay int64, storePath string) (*Tracker, error) {
+	t := &Tracker{
+		executionsPerHour: executionsPerHour,
+		tupleBytesPerDay:  tupleBytesPerDay,
+		storePath:         storePath,
+		usage:             map[string]*Usage{},
+		now:               time.Now,
+	}
+	if storePath == "" {
+		return t, nil
+	}
+	data, err := ioutil.ReadFile(storePath)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &t.usage); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Tracker keeps track of the executions and the tuple consumption per key within sliding windows.
+type Tracker struct {
+	executionsPerHour int
+	tupleBytesPerDay  int64
+	storePath         string
+	usage             map[string]*Usage
+	mux               sync.Mutex
+	now               func() time.Time
+}
+
+// Usage is the recorded usage of a single key.
+type Usage struct {
+	Executions []time.Time   `json:"executions"`
+	TupleBytes []TupleRecord `json:"tupleBytes"`
+}
+
+// TupleRecord is the amount of tuple bytes consumed at a given time.
+type TupleRecord struct {
+	Time  time.Time `json:"time"`
+	Bytes int64     `json:"bytes"`
+}
+
+// Status describes the quotas of a key. Limits of zero indicate that the respective quota is disabled.
+type Status struct {
+	ExecutionsLimit     int
+	ExecutionsRemaining int
+	TupleBytesLimit     int64
+	TupleBytesRemaining int64
+	// RetryAfter is the time until the exceeded quotas allow further executions.
+	RetryAfter time.Duration
+	// Rejected is true if the execution was not recorded by Acquire, as a quota was already exhausted.
+	Rejected bool
+}
+
+// Exceeded returns true if any of the quotas is exhausted.
+func (s Status) Exceeded() bool {
+	return (s.ExecutionsLimit > 0 && s.ExecutionsRemaining <= 0) || (s.TupleBytesLimit > 0 && s.TupleBytesRemaining <= 0)
+}
+
+// Acquire checks the quotas of the given key and records an execution if they are not exceeded. The returned status
+// reflects the recorded execution, i.e., the last execution allowed leaves no execution remaining. The returned error
+// indicates that the usage could not be persisted.
+func (t *Tracker) Acquire(key string) (Status, error) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	u := t.prune(key)
+	status := t.status(u)
+	if status.Exceeded() {
+		status.Rejected = true
+		return status, nil
+	}
+	u.Executions = append(u.Executions, t.now())
+	if status.ExecutionsLimit > 0 {
+		status.ExecutionsRemaining--
+	}
+	return status, t.persist()
+}
+
+// AddTupleBytes records the consumption of tuple bytes for the given key.
+func (t *Tracker) AddTupleBytes(key string, bytes int64) error {
+	if bytes <= 0 {
+		return nil
+	}
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	u := t.prune(key)
+	u.TupleBytes = append(u.TupleBytes, TupleRecord{Time: t.now(), Bytes: bytes})
+	return t.persist()
+}
+
+// prune drops all records of the key that are outside of the windows and returns its usage.
+func (t *Tracker) prune(key string) *Usage {
+	u, ok := t.usage[key]
+	if !ok {
+		u = &Usage{}
+		t.usage[key] = u
+	}
+	now := t.now()
+	executions := u.Executions[:0]
+	for _, e := range u.Executions {
+		if now.Sub(e) < executionWindow {
+			executions = append(executions, e)
+		}
+	}
+	u.Executions = executions
+	tuples := u.TupleBytes[:0]
+	for _, r := range u.TupleBytes {
+		if now.Sub(r.Time) < tupleWindow {
+			tuples = append(tuples, r)
+		}
+	}
+	u.TupleBytes = tuples
+	return u
+}
+
+// status computes the quota status of the given, already pruned usage.
+func (t *Tracker) status(u *Usage) Status {
+	now := t.now()
+	s := Status{
+		ExecutionsLimit: t.executionsPerHour,
+		TupleBytesLimit: t.tupleBytesPerDay,
+	}
+	if t.executionsPerHour > 0 {
+		s.ExecutionsRemaining = t.executionsPerHour - len(u.Executions)
+		if s.ExecutionsRemaining <= 0 {
+			// Executions are recorded in chronological order, the oldest one leaving the window frees a slot.
+			oldest := u.Executions[len(u.Executions)-t.executionsPerHour]
+			s.RetryAfter = oldest.Add(executionWindow).Sub(now)
+		}
+	}
+	if t.tupleBytesPerDay > 0 {
+		var used int64
+		for _, r := range u.TupleBytes {
+			used += r.Bytes
+		}
+		s.TupleBytesRemaining = t.tupleBytesPerDay - used
+		if s.TupleBytesRemaining <= 0 {
+			s.TupleBytesRemaining = 0
+			// Wait until enough records left the window to drop below the limit again.
+			for _, r := range u.TupleBytes {
+				used -= r.Bytes
+				if used < t.tupleBytesPerDay {
+					if retry := r.Time.Add(tupleWindow).Sub(now); retry > s.RetryAfter {
+						s.RetryAfter = retry
+					}
+					break
+				}
+			}
+		}
+	}
+	return s
+}
+
+// persist writes the usage to the store, if any.
+func (t *Tracker) persist() error {
+	if t.storePath == "" {
+		return nil
+	}
+	data, err := json.Marshal(t.usage)
+	if err != nil {
+		return err
+	}
+	tmp := t.storePath + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.storePath)
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package quota
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tracker", func() {
+	var (
+		now time.Time
+		t   *Tracker
+	)
+	BeforeEach(func() {
+		now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		t, _ = NewTracker(2, 100, "")
+		t.now = func() time.Time { return now }
+	})
+	Context("when limiting executions", func() {
+		It("rejects executions exceeding the hourly limit", func() {
+			status, err := t.Acquire("alice")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.Exceeded()).To(BeFalse())
+			Expect(status.ExecutionsRemaining).To(Equal(1))
+			now = now.Add(10 * time.Minute)
+			status, _ = t.Acquire("alice")
+			Expect(status.ExecutionsRemaining).To(Equal(0))
+			Expect(status.Rejected).To(BeFalse())
+			status, _ = t.Acquire("alice")
+			Expect(status.Exceeded()).To(BeTrue())
+			Expect(status.Rejected).To(BeTrue())
+			Expect(status.RetryAfter).To(Equal(50 * time.Minute))
+		})
+		It("allows executions again once they left the window", func() {
+			t.Acquire("alice")
+			t.Acquire("alice")
+			now = now.Add(time.Hour)
+			status, _ := t.Acquire("alice")
+			Expect(status.Exceeded()).To(BeFalse())
+		})
+		It("tracks keys independently", func() {
+			t.Acquire("alice")
+			t.Acquire("alice")
+			status, _ := t.Acquire("bob")
+			Expect(status.Exceeded()).To(BeFalse())
+		})
+	})
+	Context("when limiting tuple consumption", func() {
+		It("rejects executions once the daily limit is consumed", func() {
+			t.AddTupleBytes("alice", 60)
+			now = now.Add(time.Hour)
+			t.AddTupleBytes("alice", 40)
+			status, _ := t.Acquire("alice")
+			Expect(status.Exceeded()).To(BeTrue())
+			Expect(status.TupleBytesRemaining).To(Equal(int64(0)))
+			Expect(status.RetryAfter).To(Equal(23 * time.Hour))
+		})
+	})
+	Context("when a store is configured", func() {
+		It("restores the usage from the store", func() {
+			dir, _ := ioutil.TempDir("", "quota_")
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "usage.json")
+			first, err := NewTracker(1, 0, path)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = first.Acquire("alice")
+			Expect(err).NotTo(HaveOccurred())
+			second, err := NewTracker(1, 0, path)
+			Expect(err).NotTo(HaveOccurred())
+			status, _ := second.Acquire("alice")
+			Expect(status.Exceeded()).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,85 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package results
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/carbynestack/ephemeral/pkg/amphora"
+	"github.com/google/uuid"
+)
+
+// resultTag is the tag marking the secrets holding the results of games. Its value is the id of the game.
+const resultTag = "ephemeral-result"
+
+// resultNamespace derives the ids of the secrets holding the results from the ids of the games.
+var resultNamespace = uuid.MustParse("5b0c3a56-0d7e-4c55-9d63-5f2a6c1e7b3d")
+
+// NewAmphoraStore returns a store keeping the results in the Amphora service of the given client.
+func NewAmphoraStore(client amphora.AbstractClient, programIdentifier string) *AmphoraStore {
+	return &AmphoraStore{client: client, programIdentifier: programIdentifier}
+}
+
+// AmphoraStore keeps each result as the data of a secret in Amphora, tagged with the id of its game. The id of the
+// secret is derived from the id of the game. The results are kept until they are deleted, they do not expire.
+//
+// **Note:** Each player keeps its own result in the Amphora service of its party, i.e., the stored results are the
+// same as returned to the client by the player.
+type AmphoraStore struct {
+	client            amphora.AbstractClient
+	programIdentifier string
+}
+
+// Put creates the secret holding the given result, replacing an existing one of the same game.
+func (a *AmphoraStore) Put(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := a.Delete(e.GameID); err != nil {
+		return err
+	}
+	return a.client.CreateSecretShare(&amphora.SecretShare{
+		SecretID: secretID(e.GameID),
+		Data:     base64.StdEncoding.EncodeToString(data),
+		Tags:     []amphora.Tag{{ValueType: "STRING", Key: resultTag, Value: e.GameID}},
+	})
+}
+
+// Get reads the secret holding the result of the given game.
+func (a *AmphoraStore) Get(gameID string) (Entry, error) {
+	share, err := a.client.GetSecretShare(secretID(gameID), a.programIdentifier)
+	if err != nil {
+		if amphora.StatusCode(err) == http.StatusNotFound {
+			return Entry{}, ErrNotFound
+		}
+		return Entry{}, err
+	}
+	data, err := base64.StdEncoding.DecodeString(share.Data)
+	if err != nil {
+		return Entry{}, fmt.Errorf("error reading the result of game %s: %v", gameID, err)
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Entry{}, fmt.Errorf("error reading the result of game %s: %v", gameID, err)
+	}
+	return e, nil
+}
+
+// Delete deletes the secret holding the result of the given game.
+func (a *AmphoraStore) Delete(gameID string) error {
+	if err := a.client.DeleteSecretShare(secretID(gameID)); err != nil && amphora.StatusCode(err) != http.StatusNotFound {
+		return err
+	}
+	return nil
+}
+
+// secretID returns the id of the secret holding the result of the given game.
+func secretID(gameID string) string {
+	return uuid.NewMD5(resultNamespace, []byte(gameID)).String()
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package results
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// resultSuffix is the file name suffix of the results kept on disk.
+const resultSuffix = ".result.json"
+
+// NewDiskStore returns a store keeping the results in the given directory for the given time. The directory is created
+// if it does not exist. Results left over from previous runs are kept until they expire.
+func NewDiskStore(dir string, ttl time.Duration) (*DiskStore, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("the time to live of the results must be positive")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("error creating the result directory: %v", err)
+	}
+	return &DiskStore{dir: dir, ttl: ttl}, nil
+}
+
+// DiskStore keeps the results as files in a directory, so that they survive restarts of the service if the directory
+// is located on a persistent volume.
+//
+// **Note:** The results may be secret shares or, for cleartext outputs, secret values. The directory must not be
+// shared with other parties.
+type DiskStore struct {
+	dir string
+	ttl time.Duration
+	mux sync.Mutex
+}
+
+// Put writes the given result to the directory and drops the expired results.
+func (d *DiskStore) Put(e Entry) error {
+	path, err := d.path(e.GameID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.evictExpired()
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("error writing the result of game %s: %v", e.GameID, err)
+	}
+	return nil
+}
+
+// Get reads the result of the given game unless it expired.
+func (d *DiskStore) Get(gameID string) (Entry, error) {
+	path, err := d.path(gameID)
+	if err != nil {
+		return Entry{}, ErrNotFound
+	}
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	info, err := os.Stat(path)
+	if err != nil {
+		return Entry{}, ErrNotFound
+	}
+	if d.expired(info) {
+		_ = os.Remove(path)
+		return Entry{}, ErrNotFound
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Entry{}, fmt.Errorf("error reading the result of game %s: %v", gameID, err)
+	}
+	return e, nil
+}
+
+// Delete removes the result of the given game from the directory.
+func (d *DiskStore) Delete(gameID string) error {
+	path, err := d.path(gameID)
+	if err != nil {
+		return nil
+	}
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// evictExpired removes all expired results. It must be called with the lock held.
+func (d *DiskStore) evictExpired() {
+	files, err := ioutil.ReadDir(d.dir)
+	if err != nil {
+		return
+	}
+	for _, info := range files {
+		if !info.IsDir() && strings.HasSuffix(info.Name(), resultSuffix) && d.expired(info) {
+			_ = os.Remove(filepath.Join(d.dir, info.Name()))
+		}
+	}
+}
+
+func (d *DiskStore) expired(info os.FileInfo) bool {
+	return time.Since(info.ModTime()) > d.ttl
+}
+
+// path returns the file the result of the given game is kept in. The game id must be a UUID, so that it cannot escape
+// the directory.
+func (d *DiskStore) path(gameID string) (string, error) {
+	id, err := uuid.Parse(gameID)
+	if err != nil {
+		return "", fmt.Errorf("invalid game id %s: %v", gameID, err)
+	}
+	return filepath.Join(d.dir, id.String()+resultSuffix), nil
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package results keeps the results of finished games, so that clients whose connection broke during a long
+// computation can retrieve the result without running the game again.
+package results
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Backends the results can be kept in.
+const (
+	BackendMemory  = "memory"
+	BackendDisk    = "disk"
+	BackendAmphora = "amphora"
+)
+
+// DefaultMaxResults is the number of results kept in memory if not configured.
+const DefaultMaxResults = 100
+
+// DefaultTTL is the time the results are kept for if not configured.
+const DefaultTTL = time.Hour
+
+// DefaultMaxResultSize is the maximum number of bytes of a kept result if not configured.
+const DefaultMaxResultSize = 16 << 20
+
+// ErrNotFound is returned if no result is kept for a game.
+var ErrNotFound = errors.New("no result found")
+
+// Entry is the result of a finished game.
+type Entry struct {
+	GameID string `json:"gameID"`
+	// Owner is the user that triggered the game, only they may retrieve its result.
+	Owner string `json:"owner"`
+	// Result is the JSON encoded result of the game as returned to the client.
+	Result json.RawMessage `json:"result"`
+	// Finished is the time the game finished at.
+	Finished time.Time `json:"finished"`
+}
+
+// Store keeps the results of finished games.
+type Store interface {
+	// Put keeps the given result, replacing a result of the same game.
+	Put(e Entry) error
+	// Get returns the result of the given game. Returns ErrNotFound if no result is kept for the game.
+	Get(gameID string) (Entry, error)
+	// Delete drops the result of the given game. Deleting a result which is not kept succeeds.
+	Delete(gameID string) error
+}
+
+// NewMemoryStore returns a store keeping at most maxResults results in memory for the given time. The least recently
+// used results are dropped first.
+func NewMemoryStore(maxResults int, ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		maxResults: maxResults,
+		ttl:        ttl,
+		byGameID:   map[string]*list.Element{},
+		lru:        list.New(),
+		now:        time.Now,
+	}
+}
+
+// MemoryStore keeps the results in memory. The results are lost once the service restarts.
+type MemoryStore struct {
+	maxResults int
+	ttl        time.Duration
+	now        func() time.Time
+	mux        sync.Mutex
+	byGameID   map[string]*list.Element
+	// lru holds the entries, the most recently used one first.
+	lru *list.List
+}
+
+// Put keeps the given result and drops the least recently used results exceeding the maximum number of results.
+func (m *MemoryStore) Put(e Entry) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if el, ok := m.byGameID[e.GameID]; ok {
+		m.lru.Remove(el)
+	}
+	m.byGameID[e.GameID] = m.lru.PushFront(e)
+	for m.lru.Len() > m.maxResults {
+		m.remove(m.lru.Back())
+	}
+	return nil
+}
+
+// Get returns the result of the given game unless it expired.
+func (m *MemoryStore) Get(gameID string) (Entry, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	el, ok := m.byGameID[gameID]
+	if !ok {
+		return Entry{}, ErrNotFound
+	}
+	e := el.Value.(Entry)
+	if m.ttl > 0 && m.now().Sub(e.Finished) > m.ttl {
+		m.remove(el)
+		return Entry{}, ErrNotFound
+	}
+	m.lru.MoveToFront(el)
+	return e, nil
+}
+
+// Delete drops the result of the given game.
+func (m *MemoryStore) Delete(gameID string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if el, ok := m.byGameID[gameID]; ok {
+		m.remove(el)
+	}
+	return nil
+}
+
+// remove drops the given entry. It must be called with the lock held.
+func (m *MemoryStore) remove(el *list.Element) {
+	m.lru.Remove(el)
+	delete(m.byGameID, el.Value.(Entry).GameID)
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package results
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/carbynestack/ephemeral/pkg/amphora"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	gameID      = "71b2a100-f3f6-11e9-81b4-2a2ae2dbcce4"
+	otherGameID = "a5d8a2b4-4c4e-4b8e-9a53-1f0b6a4c8f11"
+)
+
+// FakeAmphoraClient keeps the secret shares in memory.
+type FakeAmphoraClient struct {
+	shares map[string]amphora.SecretShare
+}
+
+func (f *FakeAmphoraClient) GetSecretShare(id string, _ string) (amphora.SecretShare, error) {
+	share, ok := f.shares[id]
+	if !ok {
+		return share, errors.New("no secret found")
+	}
+	return share, nil
+}
+
+func (f *FakeAmphoraClient) CreateSecretShare(share *amphora.SecretShare) error {
+	f.shares[share.SecretID] = *share
+	return nil
+}
+
+func (f *FakeAmphoraClient) CreateSecretShareFromReader(string, []amphora.Tag, io.Reader) error {
+	return nil
+}
+
+func (f *FakeAmphoraClient) DeleteSecretShare(id string) error {
+	delete(f.shares, id)
+	return nil
+}
+
+var _ = Describe("Results", func() {
+	entry := func(gameID string) Entry {
+		return Entry{GameID: gameID, Owner: "alice", Result: json.RawMessage(`{"response":["a"]}`), Finished: time.Now()}
+	}
+	behavesLikeAStore := func(store func() Store) {
+		It("returns the result kept for a game", func() {
+			s := store()
+			Expect(s.Put(entry(gameID))).To(Succeed())
+			e, err := s.Get(gameID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(e.Owner).To(Equal("alice"))
+			Expect(e.Result).To(MatchJSON(`{"response":["a"]}`))
+			_, err = s.Get(otherGameID)
+			Expect(err).To(Equal(ErrNotFound))
+		})
+		It("drops a deleted result", func() {
+			s := store()
+			Expect(s.Put(entry(gameID))).To(Succeed())
+			Expect(s.Delete(gameID)).To(Succeed())
+			_, err := s.Get(gameID)
+			Expect(err).To(Equal(ErrNotFound))
+			Expect(s.Delete(gameID)).To(Succeed())
+		})
+	}
+	Context("when kept in memory", func() {
+		behavesLikeAStore(func() Store { return NewMemoryStore(DefaultMaxResults, DefaultTTL) })
+		It("drops the least recently used results", func() {
+			s := NewMemoryStore(1, DefaultTTL)
+			Expect(s.Put(entry(gameID))).To(Succeed())
+			Expect(s.Put(entry(otherGameID))).To(Succeed())
+			_, err := s.Get(gameID)
+			Expect(err).To(Equal(ErrNotFound))
+			_, err = s.Get(otherGameID)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("drops the expired results", func() {
+			s := NewMemoryStore(DefaultMaxResults, time.Minute)
+			now := time.Now()
+			s.now = func() time.Time { return now.Add(2 * time.Minute) }
+			Expect(s.Put(entry(gameID))).To(Succeed())
+			_, err := s.Get(gameID)
+			Expect(err).To(Equal(ErrNotFound))
+		})
+	})
+	Context("when kept on disk", func() {
+		var dir string
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "results")
+			Expect(err).NotTo(HaveOccurred())
+		})
+		AfterEach(func() {
+			_ = os.RemoveAll(dir)
+		})
+		behavesLikeAStore(func() Store {
+			s, err := NewDiskStore(dir, DefaultTTL)
+			Expect(err).NotTo(HaveOccurred())
+			return s
+		})
+		It("rejects game ids which are not UUIDs", func() {
+			s, _ := NewDiskStore(dir, DefaultTTL)
+			Expect(s.Put(Entry{GameID: "../result"})).NotTo(Succeed())
+			_, err := s.Get("../result")
+			Expect(err).To(Equal(ErrNotFound))
+		})
+	})
+	Context("when kept in Amphora", func() {
+		It("keeps the result as the data of a secret tagged with the game id", func() {
+			client := &FakeAmphoraClient{shares: map[string]amphora.SecretShare{}}
+			s := NewAmphoraStore(client, "ephemeral-generic")
+			Expect(s.Put(entry(gameID))).To(Succeed())
+			Expect(client.shares).To(HaveKey(secretID(gameID)))
+			Expect(client.shares[secretID(gameID)].Tags).To(ConsistOf(amphora.Tag{ValueType: "STRING", Key: resultTag, Value: gameID}))
+			e, err := s.Get(gameID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(e.Result).To(MatchJSON(`{"response":["a"]}`))
+			Expect(s.Delete(gameID)).To(Succeed())
+			Expect(client.shares).To(BeEmpty())
+		})
+	})
+})
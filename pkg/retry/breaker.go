@@ -0,0 +1,79 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+// NewCircuitBreaker returns a circuit breaker opening after the given number of consecutive failures for the given
+// duration.
+func NewCircuitBreaker(failureThreshold int, openDuration time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+		now:              time.Now,
+	}
+}
+
+// CircuitBreaker stops calling a failing service for a while, so that the callers fail fast instead of waiting for
+// timeouts and the service is not flooded with requests while it recovers. It is closed initially and opens after a
+// number of consecutive failures. Once the open duration has elapsed, a single trial call is let through. The breaker
+// closes if it succeeds and opens again otherwise. It is safe for concurrent use.
+type CircuitBreaker struct {
+	failureThreshold int
+	openDuration     time.Duration
+	now              func() time.Time
+
+	mux      sync.Mutex
+	failures int
+	// openUntil is the time the breaker lets a trial call through. The breaker is closed if zero.
+	openUntil time.Time
+	// probing is true while a trial call is in flight.
+	probing bool
+}
+
+// Allow returns whether a call may be made. Otherwise, it returns the time until the next trial call is let through.
+// Each allowed call must be followed by a call to Record.
+func (b *CircuitBreaker) Allow() (bool, time.Duration) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if b.openUntil.IsZero() {
+		return true, 0
+	}
+	if wait := b.openUntil.Sub(b.now()); wait > 0 {
+		return false, wait
+	}
+	if b.probing {
+		return false, b.openDuration
+	}
+	b.probing = true
+	return true, 0
+}
+
+// Record records the outcome of an allowed call. Only failures caused by the service must be recorded as errors,
+// e.g., no invalid requests.
+func (b *CircuitBreaker) Record(err error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.probing = false
+	if err == nil {
+		b.failures = 0
+		b.openUntil = time.Time{}
+		return
+	}
+	b.failures++
+	if b.failures >= b.failureThreshold || !b.openUntil.IsZero() {
+		b.openUntil = b.now().Add(b.openDuration)
+	}
+}
+
+// Open returns whether the breaker is open, i.e., calls are rejected or only a trial call is let through.
+func (b *CircuitBreaker) Open() bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return !b.openUntil.IsZero()
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package retry_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/retry"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CircuitBreaker", func() {
+	failure := errors.New("failure")
+
+	It("opens after the configured number of consecutive failures", func() {
+		b := NewCircuitBreaker(2, time.Minute)
+		b.Record(failure)
+		b.Record(nil)
+		b.Record(failure)
+		Expect(b.Open()).To(BeFalse())
+		b.Record(failure)
+		Expect(b.Open()).To(BeTrue())
+		ok, wait := b.Allow()
+		Expect(ok).To(BeFalse())
+		Expect(wait).To(BeNumerically("~", time.Minute, time.Second))
+	})
+	It("lets a single trial call through once the open duration elapsed", func() {
+		b := NewCircuitBreaker(1, 10*time.Millisecond)
+		b.Record(failure)
+		Eventually(func() bool {
+			ok, _ := b.Allow()
+			return ok
+		}).Should(BeTrue())
+		ok, _ := b.Allow()
+		Expect(ok).To(BeFalse())
+		b.Record(nil)
+		Expect(b.Open()).To(BeFalse())
+	})
+	It("opens again if the trial call fails", func() {
+		b := NewCircuitBreaker(3, 10*time.Millisecond)
+		for i := 0; i < 3; i++ {
+			b.Record(failure)
+		}
+		Eventually(func() bool {
+			ok, _ := b.Allow()
+			return ok
+		}).Should(BeTrue())
+		b.Record(failure)
+		ok, _ := b.Allow()
+		Expect(ok).To(BeFalse())
+	})
+	Context("when created from the config", func() {
+		It("applies the defaults", func() {
+			b, err := NewBreaker(&CircuitBreakerConfig{})
+			Expect(err).NotTo(HaveOccurred())
+			for i := 0; i < 4; i++ {
+				b.Record(failure)
+			}
+			Expect(b.Open()).To(BeFalse())
+			b.Record(failure)
+			Expect(b.Open()).To(BeTrue())
+		})
+		It("rejects invalid settings", func() {
+			_, err := NewBreaker(&CircuitBreakerConfig{FailureThreshold: -1})
+			Expect(err).To(HaveOccurred())
+			_, err = NewBreaker(&CircuitBreakerConfig{OpenDuration: "a while"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,120 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// The names of the policies in the configuration.
+const (
+	ConstantPolicy    = "constant"
+	ExponentialPolicy = "exponential"
+)
+
+// Spec is the definition of a Config in the configuration file of a service.
+type Spec struct {
+	// Policy is either "constant" or "exponential".
+	Policy string `json:"policy"`
+	// Interval is the delay between the attempts of the constant policy and the initial delay of the exponential
+	// policy, e.g., "100ms".
+	Interval string `json:"interval"`
+	// MaxInterval bounds the delays of the exponential policy, e.g., "5s". The delays are not bounded if not set.
+	MaxInterval string `json:"maxInterval"`
+	// Jitter is the fraction of each delay that is randomized, between 0 and 1.
+	Jitter float64 `json:"jitter"`
+	// MaxAttempts limits the number of attempts. The number is not limited if not set.
+	MaxAttempts int `json:"maxAttempts"`
+	// Timeout limits the duration of the retries, e.g., "30s". The duration is not limited if not set.
+	Timeout string `json:"timeout"`
+}
+
+// BreakerSpec is the definition of a CircuitBreaker in the configuration file of a service.
+type BreakerSpec struct {
+	// FailureThreshold is the number of consecutive failed requests after which the breaker opens. Defaults to 5.
+	FailureThreshold int `json:"failureThreshold"`
+	// OpenDuration is the time requests are rejected for before a trial request is let through, e.g., "30s". Defaults
+	// to 30s.
+	OpenDuration string `json:"openDuration"`
+}
+
+// NewConfig returns the retry config defined by conf.
+func NewConfig(conf *Spec) (Config, error) {
+	interval, err := parseDuration(conf.Interval)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid retry interval: %v", err)
+	}
+	if interval <= 0 {
+		return Config{}, errors.New("the retry interval must be positive")
+	}
+	maxInterval, err := parseDuration(conf.MaxInterval)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid maximum retry interval: %v", err)
+	}
+	timeout, err := parseDuration(conf.Timeout)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid retry timeout: %v", err)
+	}
+	if conf.Jitter < 0 || conf.Jitter > 1 {
+		return Config{}, errors.New("the retry jitter must be between 0 and 1")
+	}
+	if conf.MaxAttempts < 0 {
+		return Config{}, errors.New("the maximum number of attempts must not be negative")
+	}
+	var policy Policy
+	switch conf.Policy {
+	case ConstantPolicy:
+		policy = Constant{Interval: interval}
+	case ExponentialPolicy:
+		policy = Exponential{Initial: interval, Max: maxInterval}
+	default:
+		return Config{}, fmt.Errorf("unknown retry policy %q", conf.Policy)
+	}
+	if conf.Jitter > 0 {
+		policy = Jittered{Policy: policy, Factor: conf.Jitter}
+	}
+	return Config{
+		Policy:      policy,
+		MaxAttempts: conf.MaxAttempts,
+		Timeout:     timeout,
+	}, nil
+}
+
+// Defaults of the circuit breaker config.
+const (
+	defaultFailureThreshold = 5
+	defaultOpenDuration     = 30 * time.Second
+)
+
+// NewBreaker returns the circuit breaker defined by conf.
+func NewBreaker(conf *BreakerSpec) (*CircuitBreaker, error) {
+	if conf.FailureThreshold < 0 {
+		return nil, errors.New("the failure threshold must not be negative")
+	}
+	threshold := conf.FailureThreshold
+	if threshold == 0 {
+		threshold = defaultFailureThreshold
+	}
+	openDuration, err := parseDuration(conf.OpenDuration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid open duration: %v", err)
+	}
+	if openDuration < 0 {
+		return nil, errors.New("the open duration must not be negative")
+	}
+	if openDuration == 0 {
+		openDuration = defaultOpenDuration
+	}
+	return NewCircuitBreaker(threshold, openDuration), nil
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package retry_test
+
+import (
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/retry"
+	. "github.com/carbynestack/ephemeral/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config", func() {
+	It("returns an exponential policy", func() {
+		conf, err := NewConfig(&RetryConfig{
+			Policy:      ExponentialPolicy,
+			Interval:    "100ms",
+			MaxInterval: "1s",
+			MaxAttempts: 5,
+			Timeout:     "10s",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conf.Policy).To(Equal(Exponential{Initial: 100 * time.Millisecond, Max: time.Second}))
+		Expect(conf.MaxAttempts).To(Equal(5))
+		Expect(conf.Timeout).To(Equal(10 * time.Second))
+	})
+	It("returns a jittered constant policy", func() {
+		conf, err := NewConfig(&RetryConfig{Policy: ConstantPolicy, Interval: "1s", Jitter: 0.2})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conf.Policy).To(Equal(Jittered{Policy: Constant{Interval: time.Second}, Factor: 0.2}))
+	})
+	It("rejects an unknown policy", func() {
+		_, err := NewConfig(&RetryConfig{Policy: "linear", Interval: "1s"})
+		Expect(err).To(MatchError(`unknown retry policy "linear"`))
+	})
+	It("rejects a missing interval", func() {
+		_, err := NewConfig(&RetryConfig{Policy: ConstantPolicy})
+		Expect(err).To(MatchError("the retry interval must be positive"))
+	})
+	It("rejects an invalid jitter", func() {
+		_, err := NewConfig(&RetryConfig{Policy: ConstantPolicy, Interval: "1s", Jitter: 1.5})
+		Expect(err).To(MatchError("the retry jitter must be between 0 and 1"))
+	})
+	It("rejects an invalid duration", func() {
+		_, err := NewConfig(&RetryConfig{Policy: ConstantPolicy, Interval: "1s", Timeout: "soon"})
+		Expect(err.Error()).To(HavePrefix("invalid retry timeout"))
+	})
+})
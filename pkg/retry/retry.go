@@ -0,0 +1,166 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package retry implements retrying operations with configurable delays between the attempts.
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// Policy defines the delays between the attempts of an operation.
+type Policy interface {
+	// Delay returns the delay before the given retry, starting at 1 for the first one.
+	Delay(retry int) time.Duration
+}
+
+// Constant waits the same interval before each retry.
+type Constant struct {
+	Interval time.Duration
+}
+
+// Delay returns the interval.
+func (c Constant) Delay(int) time.Duration {
+	return c.Interval
+}
+
+// Exponential doubles the delay with each retry, starting at Initial, up to Max. The delay is not bounded if Max is
+// zero.
+type Exponential struct {
+	Initial, Max time.Duration
+}
+
+// Delay returns Initial doubled retry-1 times, bounded by Max.
+func (e Exponential) Delay(retry int) time.Duration {
+	d := e.Initial
+	for i := 1; i < retry && (e.Max == 0 || d < e.Max); i++ {
+		d *= 2
+	}
+	if e.Max > 0 && d > e.Max {
+		d = e.Max
+	}
+	return d
+}
+
+// Jittered randomizes the delays of another policy, so that clients failing at the same time do not retry in
+// lockstep. Each delay d is chosen at random from [d*(1-Factor), d].
+type Jittered struct {
+	Policy Policy
+	// Factor is the fraction of the delay that is randomized, between 0 and 1.
+	Factor float64
+}
+
+// Delay returns the randomized delay of the underlying policy.
+func (j Jittered) Delay(retry int) time.Duration {
+	d := j.Policy.Delay(retry)
+	spread := int64(float64(d) * j.Factor)
+	if spread <= 0 {
+		return d
+	}
+	return d - time.Duration(rand.Int63n(spread+1))
+}
+
+// Hooks are notified about the attempts of an operation, e.g., to log them or to record metrics. All hooks are
+// optional.
+type Hooks struct {
+	// OnRetry is called after the given attempt failed with err and before waiting for the given delay.
+	OnRetry func(attempt int, delay time.Duration, err error)
+	// OnDone is called once the operation succeeded or has been given up after the given number of attempts.
+	OnDone func(attempts int, err error)
+}
+
+// Config defines how an operation is retried.
+type Config struct {
+	// Policy defines the delays between the attempts. The operation is attempted once if nil.
+	Policy Policy
+	// MaxAttempts limits the number of attempts. The number is not limited if zero.
+	MaxAttempts int
+	// Timeout limits the duration of the retries, i.e., no attempt is started after the timeout. The duration is not
+	// limited if zero.
+	Timeout time.Duration
+	Hooks   Hooks
+}
+
+// permanentError marks an error that is not retried.
+type permanentError struct {
+	err error
+}
+
+func (p *permanentError) Error() string {
+	return p.err.Error()
+}
+
+// Permanent wraps an error that must not be retried, e.g., as it is caused by an invalid request. Do returns the
+// wrapped error right away.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent returns whether the error is marked as permanent.
+func IsPermanent(err error) bool {
+	_, ok := err.(*permanentError)
+	return ok
+}
+
+// Do runs op until it succeeds, returns a permanent error, or the attempts are exhausted as defined by conf. The error
+// of the last attempt is returned in the latter case. The context is checked before each attempt, the error of the
+// context is returned once it is done.
+func Do(ctx context.Context, conf Config, op func() error) error {
+	var deadline time.Time
+	if conf.Timeout > 0 {
+		deadline = time.Now().Add(conf.Timeout)
+	}
+	attempt := 0
+	done := func(err error) error {
+		if conf.Hooks.OnDone != nil {
+			conf.Hooks.OnDone(attempt, err)
+		}
+		return err
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return done(err)
+		}
+		attempt++
+		err := op()
+		if err == nil {
+			return done(nil)
+		}
+		if permanent, ok := err.(*permanentError); ok {
+			return done(permanent.err)
+		}
+		if conf.Policy == nil || (conf.MaxAttempts > 0 && attempt >= conf.MaxAttempts) {
+			return done(err)
+		}
+		delay := conf.Policy.Delay(attempt)
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return done(err)
+			}
+			if delay > remaining {
+				delay = remaining
+			}
+		}
+		if conf.Hooks.OnRetry != nil {
+			conf.Hooks.OnRetry(attempt, delay, err)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return done(ctx.Err())
+		case <-timer.C:
+		}
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			return done(err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/carbynestack/ephemeral/pkg/retry"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Retry", func() {
+
+	Context("when computing delays", func() {
+		It("returns the interval of a constant policy", func() {
+			p := Constant{Interval: time.Second}
+			Expect(p.Delay(1)).To(Equal(time.Second))
+			Expect(p.Delay(5)).To(Equal(time.Second))
+		})
+		It("doubles the delay of an exponential policy up to the maximum", func() {
+			p := Exponential{Initial: 100 * time.Millisecond, Max: time.Second}
+			Expect(p.Delay(1)).To(Equal(100 * time.Millisecond))
+			Expect(p.Delay(2)).To(Equal(200 * time.Millisecond))
+			Expect(p.Delay(4)).To(Equal(800 * time.Millisecond))
+			Expect(p.Delay(5)).To(Equal(time.Second))
+			Expect(p.Delay(100)).To(Equal(time.Second))
+		})
+		It("randomizes the delay of a jittered policy", func() {
+			p := Jittered{Policy: Constant{Interval: time.Second}, Factor: 0.5}
+			for i := 1; i <= 100; i++ {
+				d := p.Delay(i)
+				Expect(d).To(BeNumerically(">=", 500*time.Millisecond))
+				Expect(d).To(BeNumerically("<=", time.Second))
+			}
+		})
+	})
+
+	Context("when running an operation", func() {
+		var (
+			attempts int
+			failures int
+			op       func() error
+		)
+		BeforeEach(func() {
+			attempts = 0
+			op = func() error {
+				attempts++
+				if attempts <= failures {
+					return errors.New("failed")
+				}
+				return nil
+			}
+		})
+
+		It("attempts it once without a policy", func() {
+			failures = 1
+			err := Do(context.Background(), Config{}, op)
+			Expect(err).To(HaveOccurred())
+			Expect(attempts).To(Equal(1))
+		})
+		It("retries it until it succeeds", func() {
+			failures = 2
+			var retries []int
+			var done int
+			conf := Config{
+				Policy: Constant{Interval: time.Millisecond},
+				Hooks: Hooks{
+					OnRetry: func(attempt int, delay time.Duration, err error) {
+						retries = append(retries, attempt)
+					},
+					OnDone: func(n int, err error) {
+						done = n
+					},
+				},
+			}
+			err := Do(context.Background(), conf, op)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(attempts).To(Equal(3))
+			Expect(retries).To(Equal([]int{1, 2}))
+			Expect(done).To(Equal(3))
+		})
+		It("gives up after the maximum number of attempts", func() {
+			failures = 10
+			conf := Config{Policy: Constant{Interval: time.Millisecond}, MaxAttempts: 3}
+			err := Do(context.Background(), conf, op)
+			Expect(err).To(MatchError("failed"))
+			Expect(attempts).To(Equal(3))
+		})
+		It("does not start an attempt after the timeout", func() {
+			failures = 100
+			conf := Config{Policy: Constant{Interval: 20 * time.Millisecond}, Timeout: 50 * time.Millisecond}
+			start := time.Now()
+			err := Do(context.Background(), conf, op)
+			Expect(err).To(MatchError("failed"))
+			Expect(attempts).To(BeNumerically("<=", 3))
+			Expect(time.Since(start)).To(BeNumerically("<", 200*time.Millisecond))
+		})
+		It("returns a permanent error right away", func() {
+			conf := Config{Policy: Constant{Interval: time.Millisecond}}
+			err := Do(context.Background(), conf, func() error {
+				attempts++
+				return Permanent(errors.New("invalid"))
+			})
+			Expect(err).To(MatchError("invalid"))
+			Expect(attempts).To(Equal(1))
+		})
+		It("stops once the context is done", func() {
+			failures = 100
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+			defer cancel()
+			conf := Config{Policy: Constant{Interval: 10 * time.Millisecond}}
+			err := Do(ctx, conf, op)
+			Expect(err).To(Equal(context.DeadlineExceeded))
+		})
+		It("does not attempt it if the context is already done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			err := Do(ctx, Config{}, op)
+			Expect(err).To(Equal(context.Canceled))
+			Expect(attempts).To(Equal(0))
+		})
+	})
+})
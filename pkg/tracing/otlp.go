@@ -0,0 +1,165 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Status codes and span kinds as defined by the OpenTelemetry protocol.
+const (
+	otlpStatusOk       = 1
+	otlpStatusError    = 2
+	otlpKindInternal   = 1
+	otlpScopeName      = "github.com/carbynestack/ephemeral"
+	otlpServiceNameKey = "service.name"
+)
+
+// NewOTLPExporter returns an exporter sending spans to the traces endpoint of an OpenTelemetry collector, e.g.,
+// http://otel-collector:4318/v1/traces, using the JSON encoding of the OTLP/HTTP protocol.
+func NewOTLPExporter(endpoint string, serviceName string, timeout time.Duration, logger *zap.SugaredLogger) (*OTLPExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %s", endpoint)
+	}
+	return &OTLPExporter{
+		URL:         *u,
+		ServiceName: serviceName,
+		HttpClient:  http.Client{Timeout: timeout},
+		logger:      logger,
+	}, nil
+}
+
+// OTLPExporter sends spans to an OpenTelemetry collector.
+type OTLPExporter struct {
+	URL         url.URL
+	ServiceName string
+	HttpClient  http.Client
+	logger      *zap.SugaredLogger
+}
+
+// Export sends the span asynchronously. Failures are logged, but do not affect the traced operation.
+func (e *OTLPExporter) Export(span *Span) {
+	go func() {
+		if err := e.Send(span); err != nil {
+			e.logger.Warnw("Failed to export span", "Span", span.Name, "Error", err)
+		}
+	}()
+}
+
+// Send posts the span to the collector. An error is returned if the collector does not respond with a 2xx status code.
+func (e *OTLPExporter) Send(span *Span) error {
+	body, err := json.Marshal(e.request(span))
+	if err != nil {
+		return err
+	}
+	resp, err := e.HttpClient.Post(e.URL.String(), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error posting the span: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector responded with status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// request converts the span to an OTLP export request.
+func (e *OTLPExporter) request(span *Span) *otlpRequest {
+	span.mux.Lock()
+	defer span.mux.Unlock()
+	s := otlpSpan{
+		TraceID:           hex.EncodeToString(span.Context.TraceID[:]),
+		SpanID:            hex.EncodeToString(span.Context.SpanID[:]),
+		Name:              span.Name,
+		Kind:              otlpKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+		Status:            otlpStatus{Code: otlpStatusOk},
+	}
+	if span.Parent.IsValid() {
+		s.ParentSpanID = hex.EncodeToString(span.Parent.SpanID[:])
+	}
+	keys := make([]string, 0, len(span.Attributes))
+	for k := range span.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s.Attributes = append(s.Attributes, otlpAttribute{Key: k, Value: otlpValue{StringValue: span.Attributes[k]}})
+	}
+	if span.Error != nil {
+		s.Status = otlpStatus{Code: otlpStatusError, Message: span.Error.Error()}
+	}
+	return &otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpAttribute{{Key: otlpServiceNameKey, Value: otlpValue{StringValue: e.ServiceName}}},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: otlpScopeName},
+				Spans: []otlpSpan{s},
+			}},
+		}},
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceparentHeader is the W3C trace context header, also used as gRPC metadata key, the trace context is propagated
+// with.
+const TraceparentHeader = "traceparent"
+
+type spanKey struct{}
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid returns true if both the trace and the span ID are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Traceparent returns the span context in the format of the W3C traceparent header.
+func (sc SpanContext) Traceparent() string {
+	flags := 0
+	if sc.Sampled {
+		flags = 1
+	}
+	return fmt.Sprintf("00-%s-%s-%02x", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]), flags)
+}
+
+// ParseTraceparent parses a span context given in the format of the W3C traceparent header.
+func ParseTraceparent(value string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, fmt.Errorf("malformed traceparent %q", value)
+	}
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, fmt.Errorf("malformed traceparent %q", value)
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, fmt.Errorf("malformed trace ID in traceparent %q", value)
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, fmt.Errorf("malformed span ID in traceparent %q", value)
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return sc, fmt.Errorf("malformed flags in traceparent %q", value)
+	}
+	if !sc.IsValid() {
+		return sc, fmt.Errorf("invalid trace or span ID in traceparent %q", value)
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, nil
+}
+
+// Exporter sends finished spans to a tracing backend.
+type Exporter interface {
+	Export(span *Span)
+}
+
+// NewTracer returns a tracer handing finished spans to the given exporter.
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// Tracer creates the root spans of traces. A nil tracer creates no spans.
+type Tracer struct {
+	exporter Exporter
+}
+
+// Start starts a span as child of the given, possibly remote, parent. A new trace is started if the parent is not
+// valid. The returned context carries the span, so that further spans can be started with StartSpan.
+func (t *Tracer) Start(ctx context.Context, name string, parent SpanContext) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		Name:       name,
+		Parent:     parent,
+		Start:      time.Now(),
+		Attributes: map[string]string{},
+		tracer:     t,
+	}
+	if parent.IsValid() {
+		span.Context.TraceID = parent.TraceID
+		span.Context.Sampled = parent.Sampled
+	} else {
+		span.Parent = SpanContext{}
+		randomBytes(span.Context.TraceID[:])
+		span.Context.Sampled = true
+	}
+	randomBytes(span.Context.SpanID[:])
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// StartSpan starts a child of the span carried by the given context. No span is created if the context does not carry
+// one.
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	return parent.tracer.Start(ctx, name, parent.Context)
+}
+
+// SpanFromContext returns the span carried by the given context, if any.
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Traceparent returns the W3C traceparent of the span carried by the given context, or an empty string if the context
+// does not carry one.
+func Traceparent(ctx context.Context) string {
+	span := SpanFromContext(ctx)
+	if span == nil {
+		return ""
+	}
+	return span.Context.Traceparent()
+}
+
+// Span is a timed operation within a trace. All methods may be called on a nil span.
+type Span struct {
+	Name       string
+	Context    SpanContext
+	Parent     SpanContext
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]string
+	// Error is the error the operation failed with, if any.
+	Error  error
+	tracer *Tracer
+	mux    sync.Mutex
+	ended  bool
+}
+
+// SetAttribute annotates the span with the given key and value.
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.Attributes[key] = value
+}
+
+// SetError marks the span as failed with the given error. Nil errors are ignored.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.Error = err
+}
+
+// Finish ends the span and hands it to the exporter. Subsequent calls have no effect.
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+	s.mux.Lock()
+	if s.ended {
+		s.mux.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = time.Now()
+	s.mux.Unlock()
+	if s.tracer.exporter != nil && s.Context.Sampled {
+		s.tracer.exporter.Export(s)
+	}
+}
+
+func randomBytes(b []byte) {
+	// crypto/rand only fails if the system's source of randomness is unavailable.
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+}
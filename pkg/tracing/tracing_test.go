@@ -0,0 +1,139 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+type recordingExporter struct {
+	spans []*Span
+}
+
+func (r *recordingExporter) Export(span *Span) {
+	r.spans = append(r.spans, span)
+}
+
+var _ = Describe("Tracing", func() {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	Context("when parsing a traceparent", func() {
+		It("round trips a valid value", func() {
+			sc, err := ParseTraceparent(traceparent)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sc.Sampled).To(BeTrue())
+			Expect(sc.Traceparent()).To(Equal(traceparent))
+		})
+		It("rejects malformed values", func() {
+			for _, v := range []string{"", "00-abc-def-01", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"} {
+				_, err := ParseTraceparent(v)
+				Expect(err).To(HaveOccurred(), v)
+			}
+		})
+	})
+	Context("when starting spans", func() {
+		var (
+			exporter *recordingExporter
+			tracer   *Tracer
+		)
+		BeforeEach(func() {
+			exporter = &recordingExporter{}
+			tracer = NewTracer(exporter)
+		})
+		It("continues the trace of a remote parent", func() {
+			parent, _ := ParseTraceparent(traceparent)
+			_, span := tracer.Start(context.Background(), "activation", parent)
+			Expect(span.Context.TraceID).To(Equal(parent.TraceID))
+			Expect(span.Context.SpanID).NotTo(Equal(parent.SpanID))
+			Expect(span.Parent).To(Equal(parent))
+		})
+		It("starts a new trace without a parent", func() {
+			_, span := tracer.Start(context.Background(), "activation", SpanContext{})
+			Expect(span.Context.IsValid()).To(BeTrue())
+			Expect(span.Parent.IsValid()).To(BeFalse())
+		})
+		It("starts children of the span carried by the context", func() {
+			ctx, root := tracer.Start(context.Background(), "activation", SpanContext{})
+			_, child := StartSpan(ctx, "compile")
+			Expect(child.Context.TraceID).To(Equal(root.Context.TraceID))
+			Expect(child.Parent).To(Equal(root.Context))
+			Expect(Traceparent(ctx)).To(Equal(root.Context.Traceparent()))
+		})
+		It("exports spans once when finished", func() {
+			_, span := tracer.Start(context.Background(), "activation", SpanContext{})
+			span.SetError(errors.New("failed"))
+			span.Finish()
+			span.Finish()
+			Expect(exporter.spans).To(HaveLen(1))
+			Expect(span.End).NotTo(BeZero())
+			Expect(span.Error).To(MatchError("failed"))
+		})
+		It("does not export spans of unsampled traces", func() {
+			parent, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+			_, span := tracer.Start(context.Background(), "activation", parent)
+			span.Finish()
+			Expect(exporter.spans).To(BeEmpty())
+		})
+		It("creates no spans without a tracer", func() {
+			var t *Tracer
+			ctx, span := t.Start(context.Background(), "activation", SpanContext{})
+			Expect(span).To(BeNil())
+			_, child := StartSpan(ctx, "compile")
+			Expect(child).To(BeNil())
+			child.SetAttribute("key", "value")
+			child.Finish()
+			Expect(Traceparent(ctx)).To(BeEmpty())
+		})
+	})
+	Context("when exporting spans to an OpenTelemetry collector", func() {
+		It("posts the span in OTLP/HTTP JSON encoding", func() {
+			var received map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				_ = json.Unmarshal(body, &received)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+			exporter, err := NewOTLPExporter(server.URL+"/v1/traces", "ephemeral", time.Second, zap.NewNop().Sugar())
+			Expect(err).NotTo(HaveOccurred())
+			parent, _ := ParseTraceparent(traceparent)
+			_, span := NewTracer(exporter).Start(context.Background(), "compile", parent)
+			span.SetAttribute("gameID", "g")
+			span.SetError(errors.New("failed"))
+			span.End = span.Start.Add(time.Second)
+			Expect(exporter.Send(span)).To(Succeed())
+			resourceSpans := received["resourceSpans"].([]interface{})[0].(map[string]interface{})
+			spans := resourceSpans["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})
+			s := spans[0].(map[string]interface{})
+			Expect(s["traceId"]).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+			Expect(s["parentSpanId"]).To(Equal("00f067aa0ba902b7"))
+			Expect(s["name"]).To(Equal("compile"))
+			Expect(s["status"]).To(HaveKeyWithValue("code", BeNumerically("==", otlpStatusError)))
+			Expect(s["attributes"]).To(HaveLen(1))
+		})
+		It("fails if the collector rejects the span", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+			}))
+			defer server.Close()
+			exporter, _ := NewOTLPExporter(server.URL, "ephemeral", time.Second, zap.NewNop().Sugar())
+			_, span := NewTracer(exporter).Start(context.Background(), "compile", SpanContext{})
+			Expect(exporter.Send(span)).To(MatchError("collector responded with status code 400"))
+		})
+		It("rejects invalid endpoints", func() {
+			_, err := NewOTLPExporter("collector:4318", "ephemeral", time.Second, zap.NewNop().Sugar())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
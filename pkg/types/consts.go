@@ -9,6 +9,17 @@ const (
 	DiscoveryServiceStarted = "DiscoveryServiceStarted"
 	// GameProtocolError indicates an error in the protocol as a response to the message that were not delivered to the Game state machine.
 	GameProtocolError = "GameProtocolError"
+	// PodAffinityMismatch indicates that the players of a game do not agree on the pods the game was pinned to.
+	PodAffinityMismatch = "PodAffinityMismatch"
+	// ParamsMismatch indicates that the players of a game are configured with different SPDZ parameters.
+	ParamsMismatch = "ParamsMismatch"
+	// PortsExhausted indicates that no free port is left for the network of a player of a game.
+	PortsExhausted = "PortsExhausted"
+	// PlayerWithdraw is sent by a player to leave a game before all players are ready, e.g., because the activation
+	// was cancelled.
+	PlayerWithdraw = "PlayerWithdraw"
+	// PlayerWithdrawn informs the players of a game that the player contained in the event has left the game.
+	PlayerWithdrawn = "PlayerWithdrawn"
 	// serviceEventsTopic represents the internal discovery service events.
 	ServiceEventsTopic        = "serviceEvents"
 	ClientIncomingEventsTopic = "clientIncomingEvents"
@@ -48,10 +59,38 @@ const (
 	SecretShare             = "SECRETSHARE"
 	PlainText               = "PLAINTEXT"
 	AmphoraSecret           = "AMPHORASECRET"
+	SignedInt               = "SIGNEDINT"
+	FixedPoint              = "FIXEDPOINT"
+	JSONArray               = "JSONARRAY"
+	ObjectStore             = "OBJECTSTORE"
 	ConnID                  = "ConnID"
 	EventScope              = "EventScope"
 	EventScopeAll           = "EventScopeAll"
 	EventScopeSelf          = "EventScropeSelf"
+	// EventNamespace is the stream metadata carrying the namespace of the client, e.g., the virtual cloud it belongs
+	// to. Clients only receive the events of the games of their namespace. Clients without namespace belong to the
+	// default namespace.
+	EventNamespace = "EventNamespace"
+	// LastSeq is the stream metadata carrying the highest sequence number a reconnecting client has received. The
+	// server replays the events it missed, clients that set it are expected to acknowledge the events they receive.
+	LastSeq = "LastSeq"
+	// ClientID is the stream metadata identifying the client instance. The server drops the events a client replays
+	// after reconnecting per client, as several clients may share a ConnID, e.g., the players of a game.
+	ClientID = "ClientID"
+	// Ack is the name of the events clients acknowledge the events received from the server with, and vice versa.
+	Ack = "Ack"
 
 	DefaultPolicy = "carbynestack.def"
+
+	// EncodingBase64 denotes secret parameters given as base64 encoded secret shares.
+	EncodingBase64 = "base64"
+	// EncodingHex denotes secret parameters given as hex encoded secret shares.
+	EncodingHex = "hex"
+	// EncodingJSON denotes secret parameters given as JSON arrays of clear 64 bit integers, which are secret shared by
+	// the players. All players must be given the same values.
+	EncodingJSON = "json"
+
+	// LabelPrefix is prepended to the keys of the activation labels when they are attached to logs, traces and output
+	// secrets.
+	LabelPrefix = "label."
 )
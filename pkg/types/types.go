@@ -6,11 +6,23 @@ package types
 
 import (
 	"context"
+	"github.com/carbynestack/ephemeral/pkg/allowlist"
 	"github.com/carbynestack/ephemeral/pkg/amphora"
+	"github.com/carbynestack/ephemeral/pkg/archive"
+	"github.com/carbynestack/ephemeral/pkg/audit"
 	"github.com/carbynestack/ephemeral/pkg/castor"
+	"github.com/carbynestack/ephemeral/pkg/certs"
 	pb "github.com/carbynestack/ephemeral/pkg/discovery/transport/proto"
+	"github.com/carbynestack/ephemeral/pkg/notify"
+	"github.com/carbynestack/ephemeral/pkg/objectstore"
 	"github.com/carbynestack/ephemeral/pkg/opa"
+	"github.com/carbynestack/ephemeral/pkg/quota"
+	"github.com/carbynestack/ephemeral/pkg/results"
+	"github.com/carbynestack/ephemeral/pkg/retry"
+	"github.com/carbynestack/ephemeral/pkg/tracing"
+	"io"
 	"math/big"
+	"sync"
 	"time"
 
 	mb "github.com/vardius/message-bus"
@@ -43,21 +55,157 @@ type DiscoveryConfig struct {
 	BusSize            int    `json:"busSize"`
 	PortRange          string `json:"portRange"`
 	PlayerCount        int    `json:"playerCount"`
+	// PortRanges are further ranges (start_port:end_port) the ports of the player networks are assigned from, along
+	// with PortRange.
+	PortRanges []string `json:"portRanges"`
+	// NamespacePortRanges are the ranges reserved for the players of a namespace. Players of other namespaces are
+	// assigned ports from PortRange and PortRanges.
+	NamespacePortRanges map[string][]string `json:"namespacePortRanges"`
+	// CheckpointPath is the file the state of the service is persisted to on termination and restored from on start,
+	// e.g., on a persistent volume. The state is not persisted if not set.
+	CheckpointPath string `json:"checkpointPath"`
+	// StateStore defines where the bookkeeping of players, pods and networks is kept. It is kept in memory if not set.
+	StateStore StateStoreConfig `json:"stateStore"`
+	// TLS secures the connections of clients. Connections are not encrypted if not set.
+	TLS *TLSConfig `json:"tls"`
+	// MasterTLS secures the connection of slaves to the master. Its AllowedSANs are the SANs of the master. The
+	// connection is not encrypted if not set.
+	MasterTLS *TLSConfig `json:"masterTLS"`
+	// Auth defines how clients are authenticated. Clients are not authenticated if not set.
+	Auth *AuthConfig `json:"auth"`
+	// MasterToken is the bearer token slaves present to the master.
+	MasterToken *TokenConfig `json:"masterToken"`
+	// BusStallThreshold is the duration after which a handler of or a publisher to the message bus is reported as
+	// stuck, e.g., "10s".
+	BusStallThreshold string `json:"busStallThreshold"`
+	// LogLevel is the minimum level of the log messages, e.g., "info". Defaults to "debug".
+	LogLevel string `json:"logLevel"`
+	// AdminPort is the port the readiness and the report of the dependency checks are served on via HTTP, e.g.,
+	// "8081". They are not served if not set.
+	AdminPort string `json:"adminPort"`
+	// GameRetention is the time finished games are kept for before they are evicted along with their players, e.g.,
+	// "5m".
+	GameRetention string `json:"gameRetention"`
+	// EventNamespace is the event namespace slaves connect to the master with, e.g., the name of the virtual cloud.
+	// The master only sends the events of the games of the namespace to the slave.
+	EventNamespace string `json:"eventNamespace"`
+	// EventNamespaces restricts the event namespaces clients may connect with, so that a single deployment serves
+	// several virtual clouds. Clients of any namespace are accepted if not set.
+	EventNamespaces []string `json:"eventNamespaces"`
+	// BackupMasters are further masters slaves fail over to, in order, if the master given by MasterHost and
+	// MasterPort becomes unreachable. The masters must share their state store, so that running games resume.
+	BackupMasters []DiscoveryEndpoint `json:"backupMasters"`
+	// PortAllocation is the policy the ports of the player networks are allocated by, i.e., "sequential" (default)
+	// or "random".
+	PortAllocation string `json:"portAllocation"`
+	// PortReuse is the policy the networks of the pods are reused across games by, i.e., "pod" (default) to keep the
+	// network of a pod until it is deleted, or "refcount" to release it once no running game references it anymore.
+	PortReuse string `json:"portReuse"`
+	// Networker is the implementation exposing the players to the other parties, i.e., "istio" (default) to create an
+	// Istio gateway per pod, or "service" to create a Kubernetes service of type NodePort per pod.
+	Networker string `json:"networker"`
+}
+
+// AuthConfig specifies the bearer tokens accepted from discovery clients. A token is accepted if it matches one of the
+// static tokens or is a valid JWT issued by the OIDC provider.
+type AuthConfig struct {
+	// Tokens are static secrets shared with the clients.
+	Tokens []string `json:"tokens"`
+	// OIDC validates tokens issued by an OpenID Connect provider, e.g., projected service account tokens.
+	OIDC *OIDCConfig `json:"oidc"`
+}
+
+// OIDCConfig specifies the OpenID Connect provider tokens are issued by.
+type OIDCConfig struct {
+	// Issuer must match the "iss" claim of the tokens, e.g., "https://kubernetes.default.svc".
+	Issuer string `json:"issuer"`
+	// Audience must be contained in the "aud" claim of the tokens.
+	Audience string `json:"audience"`
+	// JWKSURL is the URL of the keys the tokens are signed with. It is discovered from the configuration of the issuer
+	// if not set.
+	JWKSURL string `json:"jwksURL"`
+}
+
+// TokenConfig specifies the bearer token a client presents to the discovery service.
+type TokenConfig struct {
+	// Token is a static token.
+	Token string `json:"token"`
+	// File contains the token, e.g., a projected service account token. It is read on every connection, so that
+	// rotated tokens are picked up. Takes precedence over Token.
+	File string `json:"file"`
+	// Insecure allows to send the token over connections without TLS, e.g., if they are secured by a service mesh.
+	Insecure bool `json:"insecure"`
+}
+
+// StateStoreConfig specifies the store the discovery service keeps its bookkeeping in.
+type StateStoreConfig struct {
+	// Type is the kind of store, i.e., "memory", "etcd" or "redis".
+	Type string `json:"type"`
+	// Endpoint is the client endpoint of etcd, e.g., "etcd:2379", or the address of Redis, e.g., "redis:6379".
+	Endpoint string `json:"endpoint"`
+	// Prefix is prepended to all keys, so that several deployments can share a single store.
+	Prefix string `json:"prefix"`
+	// Timeout is the maximum duration of a single request to the store, e.g., "5s".
+	Timeout string `json:"timeout"`
+	// Username is the user the discovery service authenticates as with etcd. Redis is authenticated with the password
+	// only.
+	Username string `json:"username"`
+	// Password is the password the discovery service authenticates with.
+	Password string `json:"password"`
+	// PasswordFile contains the password, e.g., a key of a mounted secret. Takes precedence over Password.
+	PasswordFile string `json:"passwordFile"`
+	// TLS secures the connection to the store. The connection is not encrypted if not set.
+	TLS *TLSConfig `json:"tls"`
+}
+
+// NetworkControllerConfig is the configuration of the network controller exposing the players via Istio.
+type NetworkControllerConfig struct {
+	// IngressSelector selects the Istio ingress gateway the gateways of the networks are bound to. Defaults to
+	// {"istio": "ingressgateway"}.
+	IngressSelector map[string]string `json:"ingressSelector"`
+	// GatewayNamespace is the namespace the gateways are created in. Defaults to the namespace of the networks.
+	GatewayNamespace string `json:"gatewayNamespace"`
+	// SharedGateway exposes all networks via a single gateway with a server per network, instead of a gateway per
+	// network.
+	SharedGateway bool `json:"sharedGateway"`
+	// SharedGatewayName is the name of the shared gateway. Defaults to "mpc-shared-gateway".
+	SharedGatewayName string `json:"sharedGatewayName"`
+	// TLSCredentialName is the secret holding the certificate, key and CA the gateways terminate mutual TLS with,
+	// e.g., issued by cert-manager. The traffic is passed through to the players if not set.
+	TLSCredentialName string `json:"tlsCredentialName"`
 }
 
 // DiscoveryTypedConfig reflects DiscoveryConfig, but it contains the real property types
 type DiscoveryTypedConfig struct {
-	FrontendURL        string
-	MasterHost         string
-	MasterPort         string
-	Slave              bool
-	StateTimeout       time.Duration
-	ComputationTimeout time.Duration
-	ConnectTimeout     time.Duration
-	Port               string
-	BusSize            int
-	PortRange          string
-	PlayerCount        int
+	FrontendURL         string
+	MasterHost          string
+	MasterPort          string
+	Slave               bool
+	StateTimeout        time.Duration
+	ComputationTimeout  time.Duration
+	ConnectTimeout      time.Duration
+	Port                string
+	BusSize             int
+	PortRange           string
+	PlayerCount         int
+	PortRanges          []string
+	NamespacePortRanges map[string][]string
+	CheckpointPath      string
+	StateStore          StateStoreConfig
+	TLS                 *TLSConfig
+	MasterTLS           *TLSConfig
+	Auth                *AuthConfig
+	MasterToken         *TokenConfig
+	BusStallThreshold   time.Duration
+	LogLevel            string
+	AdminPort           string
+	GameRetention       time.Duration
+	EventNamespace      string
+	EventNamespaces     []string
+	BackupMasters       []DiscoveryEndpoint
+	PortAllocation      string
+	PortReuse           string
+	Networker           string
 }
 
 // Activation is an object that is received as an input from the Ephemeral client.
@@ -67,6 +215,165 @@ type Activation struct {
 	GameID        string       `json:"gameID"`
 	Code          string       `json:"code"`
 	Output        OutputConfig `json:"output"`
+	// PodAffinity optionally pins the game to specific pods. The entry at index i is the name of the pod player i is
+	// expected to run on.
+	PodAffinity []string `json:"podAffinity,omitempty"`
+	// CompilerOptions are passed to the MP-SPDZ compiler when the program is compiled.
+	CompilerOptions *CompilerOptions `json:"compilerOptions,omitempty"`
+	// Labels are free-form metadata, e.g., the use case or experiment the activation belongs to. They are attached to
+	// the logs, traces, notifications and output secrets of the activation.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Diagnostics requests the size of the input and output as well as a trace of the interactions with Amphora to be
+	// reported along with the result or error of the execution.
+	Diagnostics bool `json:"diagnostics,omitempty"`
+	// SessionID is the id of the computation session the game belongs to, if any. The session fixes the pod affinity,
+	// compiler options and labels of its games.
+	SessionID string `json:"sessionID,omitempty"`
+	// Protocol is the name of the MP-SPDZ protocol the game is executed with. The default protocol of the VCP is used
+	// if not set.
+	Protocol string `json:"protocol,omitempty"`
+	// TimeBudget is the time the client is willing to wait for the result, e.g., "90s". Activations which are expected
+	// to take longer are rejected right away. Takes precedence over the X-Time-Budget header.
+	TimeBudget string `json:"timeBudget,omitempty"`
+	// Connections splits the parameters among several client connections, for programs accepting the inputs of
+	// several data providers. The output is read from the first connection. All parameters are sent on a single
+	// connection to the default port if not set.
+	Connections []ClientConnection `json:"connections,omitempty"`
+	// Encoding is the encoding of the secret parameters, i.e., EncodingBase64, EncodingHex or EncodingJSON. Defaults to
+	// EncodingBase64.
+	Encoding string `json:"encoding,omitempty"`
+	// Compression requests or refuses the compression of the traffic between the players of the game. The traffic is
+	// compressed only if all players support it. The proxy compression config of the VCP applies if not set.
+	Compression *bool `json:"compression,omitempty"`
+	// Signature is the base64 encoded Ed25519 signature of the code, which allows to compile programs signed by a
+	// trusted key if the programs are allow-listed.
+	Signature string `json:"signature,omitempty"`
+	// Seed pins the randomness of the fake preprocessing data generated with insecure preprocessing, e.g., to compare
+	// the performance of runs on the same data. It consists of at most 16 letters, digits, dashes and underscores.
+	// MP-SPDZ's default seed is used if not set. Seeds are rejected if insecure preprocessing is disabled.
+	Seed string `json:"seed,omitempty"`
+	// Backend selects the backend executing the game, e.g., "simulator" for the plaintext simulator if enabled.
+	// Defaults to the MPC protocols of MP-SPDZ.
+	Backend string `json:"backend,omitempty"`
+}
+
+// Sources of the preprocessing data of a game.
+const (
+	// PreprocessingCastor denotes tuples streamed from Castor.
+	PreprocessingCastor = "castor"
+	// PreprocessingInsecure denotes fake preprocessing data generated locally.
+	PreprocessingInsecure = "insecure"
+	// PreprocessingNone denotes protocols generating their preprocessing data during the computation.
+	PreprocessingNone = "none"
+)
+
+// Reproducibility describes the parameters of a game affecting its outcome or performance, so that runs can be
+// reproduced and compared.
+type Reproducibility struct {
+	// Seed is the seed of the fake preprocessing data. Empty if MP-SPDZ's default seed is used or the preprocessing
+	// data is not generated locally.
+	Seed string `json:"seed,omitempty"`
+	// Preprocessing is the source of the preprocessing data, i.e., PreprocessingCastor, PreprocessingInsecure or
+	// PreprocessingNone.
+	Preprocessing string `json:"preprocessing"`
+	// InsecureTupleCount is the number of tuples generated per tuple type if the preprocessing data is fake.
+	InsecureTupleCount int `json:"insecureTupleCount,omitempty"`
+	// Protocol is the name of the MP-SPDZ protocol the game was executed with.
+	Protocol string `json:"protocol"`
+	// ProgramHash is the hex encoded SHA-256 hash of the source code of the program. Empty if the program deployed on
+	// the VCP was executed.
+	ProgramHash string `json:"programHash,omitempty"`
+	// CompilerOptions are the options the program was compiled with, if any.
+	CompilerOptions *CompilerOptions `json:"compilerOptions,omitempty"`
+	// RuntimeArgs are the arguments passed to the virtual machine in addition to the ones derived from the game.
+	RuntimeArgs []string `json:"runtimeArgs,omitempty"`
+	Prime       string   `json:"prime"`
+	// Gf2nBitLength is zero if gf2n is disabled.
+	Gf2nBitLength int32 `json:"gf2nBitLength,omitempty"`
+	PlayerCount   int32 `json:"playerCount"`
+	PlayerID      int32 `json:"playerID"`
+	// Versions are the versions of the components involved, i.e., "ephemeral", "go" and, if configured, "mpSpdz".
+	Versions map[string]string `json:"versions"`
+}
+
+// ClientConnection is one of the client connections a program accepts its inputs on.
+type ClientConnection struct {
+	// Port is the port the program listens on for the connection, i.e., the argument of listen(). The connection is
+	// established to this port plus the party number of the player. Defaults to 10000.
+	Port int32 `json:"port,omitempty"`
+	// Params is the number of parameters sent on the connection. The parameters of the activation are assigned to the
+	// connections in order.
+	Params int `json:"params"`
+}
+
+// CompilerOptions defines the options used when compiling the program with MP-SPDZ.
+type CompilerOptions struct {
+	// OptimizationLevel selects the optimizations applied by the compiler. Level 1 enables flow optimization, level 2
+	// additionally enables dead code elimination and level 3 additionally enables hard optimization.
+	OptimizationLevel int `json:"optimizationLevel"`
+	// BitLength is the bit length of secret integers.
+	BitLength int `json:"bitLength"`
+	// Budget limits the loop unrolling performed by the compiler.
+	Budget int `json:"budget"`
+	// Prime is the prime modulus the program is compiled for. It must match the prime of the VCP.
+	Prime string `json:"prime"`
+}
+
+// CompilationReport contains the diagnostics of compiling a program without executing it.
+type CompilationReport struct {
+	// Success is true if the program compiled without errors.
+	Success bool `json:"success"`
+	// Stdout and Stderr are the outputs of the compiler.
+	Stdout string `json:"stdout"`
+	Stderr string `json:"stderr"`
+	// Threads is the number of threads declared by the program.
+	Threads int `json:"threads"`
+	// BytecodeSize is the total size of the generated bytecode in bytes.
+	BytecodeSize int64 `json:"bytecodeSize"`
+}
+
+// CompilationOutput is a line of the compiler output streamed while a program is compiled without executing it.
+type CompilationOutput struct {
+	// Stdout and Stderr are a chunk of the respective output of the compiler.
+	Stdout string `json:"stdout,omitempty"`
+	Stderr string `json:"stderr,omitempty"`
+	// Report is sent on the last line, once the compiler has finished. It does not repeat the output of the compiler.
+	Report *CompilationReport `json:"report,omitempty"`
+	// Error is sent on the last line instead of the report if the compiler could not be run.
+	Error string `json:"error,omitempty"`
+}
+
+// Capabilities describes the features supported by a deployment of ephemeral.
+type Capabilities struct {
+	// Protocols are the descriptors of the supported SPDZ protocols.
+	Protocols []string `json:"protocols"`
+	// TupleTypes are the names of the supported tuple types.
+	TupleTypes []string `json:"tupleTypes"`
+	// OutputTypes are the supported types of the output of a computation.
+	OutputTypes []string `json:"outputTypes"`
+	// MaxPlayers is the number of players taking part in a game.
+	MaxPlayers int32 `json:"maxPlayers"`
+	// Field contains the public parameters of the fields computations are performed in.
+	Field FieldParameters `json:"field"`
+	// ContentTypes are the media types activations and results can be encoded with.
+	ContentTypes []string `json:"contentTypes"`
+	// Features are the optional features enabled in the deployment.
+	Features []string `json:"features"`
+	// MPCProtocols are the names of the MP-SPDZ protocols activations may select.
+	MPCProtocols []string `json:"mpcProtocols"`
+	// DefaultMPCProtocol is the protocol used by activations not selecting one.
+	DefaultMPCProtocol string `json:"defaultMPCProtocol"`
+	// MaxThreads is the maximum number of threads a program may use, 0 if not limited.
+	MaxThreads int `json:"maxThreads"`
+	// InputEncodings are the encodings the secret parameters of an activation can be given in.
+	InputEncodings []string `json:"inputEncodings"`
+}
+
+// FieldParameters are the public parameters of the fields computations are performed in.
+type FieldParameters struct {
+	Prime          string `json:"prime"`
+	PrimeBitLength int    `json:"primeBitLength"`
+	Gf2nBitLength  int32  `json:"gf2nBitLength"`
 }
 
 type ActivationInput struct {
@@ -80,6 +387,9 @@ type ProxyConfig struct {
 	Host      string `json:"host"`
 	Port      string `json:"port"`
 	LocalPort string `json:"localPort"`
+	// ServerName is the name the certificate of the gateway of the player is verified against if the connection is
+	// secured with TLS. Empty if the connection is plaintext, e.g., to colocated players.
+	ServerName string `json:"serverName,omitempty"`
 }
 
 // CtxConfig contains both execution and platform specific parameters.
@@ -90,6 +400,46 @@ type CtxConfig struct {
 	ProxyEntries   []*ProxyConfig
 	ErrCh          chan error
 	Context        context.Context
+	// ForceCompile defines whether the program is compiled even if it is found in the compile cache.
+	ForceCompile bool
+	// Compiled is true if the program has been compiled for the game. The program deployed in the MP-SPDZ directory is
+	// executed otherwise.
+	Compiled bool
+	// Output receives the output values in chunks while they are read from the MPC runtime if the output is streamed
+	// to the client. It is closed once all values have been sent. Nil if the output is not streamed.
+	Output chan []string
+	// Delivery is closed once the computation has finished and the result is delivered, e.g., uploaded to Amphora.
+	// Nil if the delivery phase is not observed.
+	Delivery chan struct{}
+	// Audit collects the lifecycle events of the game. Nil if games are not audited.
+	Audit *audit.Trail
+	// Deadline is the end of the time budget of the activation. Zero if the client did not set a time budget.
+	Deadline time.Time
+	// RuntimeStdout and RuntimeStderr receive the output of the MPC runtime while it runs. Nil if the output is not
+	// tailed.
+	RuntimeStdout io.Writer
+	RuntimeStderr io.Writer
+	// CompilerStdout and CompilerStderr receive the output of the compiler while it runs. Nil if the output is not
+	// streamed to the client.
+	CompilerStdout io.Writer
+	CompilerStderr io.Writer
+	// GameSucceeded is closed once all players finished the game successfully. Nil if the player does not await the
+	// outcome of the game.
+	GameSucceeded chan struct{}
+	// Compression is true if the traffic between the proxies of the players is compressed, i.e., all players support
+	// it.
+	Compression bool
+	// InboundProxy receives the traffic of the other players on LocalPort and forwards it to MP-SPDZ listening on Host
+	// and Port. Nil if the other players connect to MP-SPDZ directly.
+	InboundProxy *ProxyConfig
+	// InsecurePreprocessing is true if the player proposes to generate fake preprocessing data for the game. Once the
+	// players are ready, it is replaced by the decision of all players, i.e., true if any of them proposed it.
+	InsecurePreprocessing bool
+	// Preprocessing is the source of the preprocessing data of the game, i.e., PreprocessingCastor,
+	// PreprocessingInsecure or PreprocessingNone. It is decided before the MPC runtime is started.
+	Preprocessing string
+	// Reproducibility is attached to the result of the game. Nil if not requested.
+	Reproducibility *Reproducibility
 }
 
 // SPDZEngineConfig is the VPC specific configuration.
@@ -117,11 +467,341 @@ type SPDZEngineConfig struct {
 	DiscoveryConfig    DiscoveryClientConfig `json:"discoveryConfig"`
 	StateTimeout       string                `json:"stateTimeout"`
 	ComputationTimeout string                `json:"computationTimeout"`
+	// StateTimeouts are the timeouts of individual player states, i.e., Registering and Playing, overriding the state
+	// and computation timeouts.
+	StateTimeouts map[string]string `json:"stateTimeouts"`
+	// AllowPartialResults defines whether the successfully converted prefix of the output is returned along with a
+	// warning if converting the output of a computation fails.
+	AllowPartialResults bool `json:"allowPartialResults"`
+	// ProxyPortRange is the range of local ports (start_port:end_port) from which each game gets its own set of proxy
+	// ports. If not set, the sets are assigned from the ports following the ones MP-SPDZ listens on for the other
+	// players.
+	ProxyPortRange string `json:"proxyPortRange"`
+	// ProxyReusePort defines whether the proxy listeners are opened with SO_REUSEPORT.
+	ProxyReusePort bool `json:"proxyReusePort"`
+	// EgressLimit limits the rate the proxy sends data to each of the other players at. Not limited if not set.
+	EgressLimit *EgressLimitConfig `json:"egressLimit"`
+	// ProxyCompression enables the compression of the traffic between the proxies of the players. The traffic is not
+	// compressed if not set.
+	ProxyCompression *ProxyCompressionConfig `json:"proxyCompression"`
+	// TlsEnabled secures the connections of the proxy to the gateways of the other players with mutual TLS, using the
+	// certificates provisioned via PlayerCerts. It must match the TLS mode of the gateways, see the tlsCredentialName
+	// of the network controller. The connections from MP-SPDZ to the proxy remain plaintext on localhost. It cannot be
+	// combined with ProxyCompression, as compressed and encrypted traffic leaks information via its length.
+	TlsEnabled bool `json:"tlsEnabled"`
+	// Colocation lets the players running in the same cluster connect to each other directly instead of via the
+	// gateway. The other players are always reached via the gateway if not set.
+	Colocation *ColocationConfig `json:"colocation"`
+	// CompileCacheSize is the number of compiled programs kept to skip recompiling identical programs. The cache is
+	// disabled if not set.
+	CompileCacheSize int `json:"compileCacheSize"`
+	// AcceptedContentTypes are the media types activations and results may be encoded with. Only application/json is
+	// accepted if not set.
+	AcceptedContentTypes []string `json:"acceptedContentTypes"`
+	// Quota defines the limits enforced per authenticated user. No limits are enforced if not set.
+	Quota QuotaConfig `json:"quota"`
+	// Notifiers are informed about finished games.
+	Notifiers []NotifierConfig `json:"notifiers"`
+	// AuditSinks are the sinks the audit records of the games are written to. Games are not audited if not set.
+	AuditSinks []AuditSinkConfig `json:"auditSinks"`
+	// OutputStreamBufferSize is the maximum number of bytes of the output read from the MPC runtime at once. If set,
+	// the output is streamed to the client or Amphora while it is read instead of being held in memory as a whole.
+	OutputStreamBufferSize int `json:"outputStreamBufferSize"`
+	// Tracing defines where the spans of traced activations are exported to. Activations are not traced if not set.
+	Tracing TracingConfig `json:"tracing"`
+	// DrainTimeout is the maximum duration running games are given to finish once the service is terminated, e.g.,
+	// "20s". It should be shorter than the termination grace period of the pod.
+	DrainTimeout string `json:"drainTimeout"`
+	// PreStopTimeout is the maximum duration the shutdown of the service is postponed for on termination while games
+	// are in flight, e.g., "10m". Together with the drain timeout, it should be shorter than the termination grace
+	// period of the pod. The shutdown is not postponed if not set.
+	PreStopTimeout string `json:"preStopTimeout"`
+	// GRPCPort is the port the gRPC API is served on in addition to the HTTP API, e.g., "9090". The gRPC API is not
+	// served if not set.
+	GRPCPort string `json:"grpcPort"`
+	// LogLevel is the minimum level of the log messages, e.g., "info". Defaults to "debug".
+	LogLevel string `json:"logLevel"`
+	// InsecurePreprocessing replaces the tuples provided by Castor with fake preprocessing data generated locally. It
+	// must only be enabled in development clusters, e.g., for performance testing. Disabled if not set.
+	InsecurePreprocessing *InsecurePreprocessingConfig `json:"insecurePreprocessing"`
+	// ResultDeliveryTimeout is the maximum duration of delivering the result once the computation has finished, e.g.,
+	// "2m". It replaces the remaining activation deadline for the upload to Amphora. The activation deadline applies if
+	// not set.
+	ResultDeliveryTimeout string `json:"resultDeliveryTimeout"`
+	// SessionIdleTimeout is the duration after which a computation session without new games is closed, e.g., "10m".
+	// Defaults to 10 minutes.
+	SessionIdleTimeout string `json:"sessionIdleTimeout"`
+	// Protocols are the MP-SPDZ protocols activations may select, by name. Only "mascot" executed with
+	// Player-Online.x is supported if not set.
+	Protocols map[string]ProtocolConfig `json:"protocols"`
+	// DefaultProtocol is the name of the protocol used by activations not selecting one. Defaults to "mascot" if
+	// declared, or to the only declared protocol.
+	DefaultProtocol string `json:"defaultProtocol"`
+	// MaxThreads is the maximum number of threads a program may use. A tuple stream per tuple type is opened for each
+	// thread. The number is not limited if not set.
+	MaxThreads int `json:"maxThreads"`
+	// MPSPDZ defines the location of the MP-SPDZ installation and additional arguments of its virtual machines.
+	MPSPDZ MPSPDZConfig `json:"mpSpdz"`
+	// ObjectStore is the S3 compatible object store the output of activations with output type OBJECTSTORE is
+	// uploaded to. The output type is not supported if not set.
+	ObjectStore *ObjectStoreConfig `json:"objectStore"`
+	// PartyNumbers are the MP-SPDZ party numbers of the players, indexed by player ID, e.g., [1, 0] swaps the parties
+	// of a two-party deployment. It must be a permutation of the player IDs and identical for all players. The party
+	// number of a player equals its ID if not set.
+	PartyNumbers []int32 `json:"partyNumbers"`
+	// InputStreamBufferSize is the maximum number of bytes of the input written to the MPC runtime at once. If set, the
+	// input is streamed to the runtime instead of being packed in memory as a whole. Secrets read from Amphora are
+	// spooled to a temporary file in $TMPDIR before, as the runtime expects the size of the input upfront.
+	InputStreamBufferSize int `json:"inputStreamBufferSize"`
+	// ProcessLimits restricts the OS resources available to the MPC runtime of each game. The runtime is started
+	// without limits if not set.
+	ProcessLimits *ProcessLimitsConfig `json:"processLimits"`
+	// AuthScopes restricts the endpoints callers may use to the scopes granted by their tokens, e.g., to separate the
+	// compilation of programs from their execution. Scopes are not checked if not set.
+	AuthScopes *AuthScopesConfig `json:"authScopes"`
+	// Readiness defines when the service reports not to be ready for new activations, so that the serving layer routes
+	// them to other pods. The service is ready unless it is draining if not set.
+	Readiness *ReadinessConfig `json:"readiness"`
+	// ExecutionsArchive keeps the history of the executions for export on /admin/executions and, if configured,
+	// periodically exports it. The history is not kept if not set.
+	ExecutionsArchive *ExecutionsArchiveConfig `json:"executionsArchive"`
+	// ProgramAllowList restricts the programs that may be compiled to the registered and signed ones. Any program may
+	// be compiled if not set.
+	ProgramAllowList *ProgramAllowListConfig `json:"programAllowList"`
+	// GameWorkDirs gives each game its own work directory for its program, ip file and preprocessing data. The games
+	// share the MP-SPDZ directory if not set.
+	GameWorkDirs *GameWorkDirsConfig `json:"gameWorkDirs"`
+	// Simulator enables the plaintext simulator backend, which executes the programs without MPC for functional
+	// testing. Activations cannot select the simulator if not set.
+	Simulator *SimulatorConfig `json:"simulator"`
+	// MaxRequestSize is the maximum number of bytes of the body of the requests. Larger requests are rejected with
+	// 413. Defaults to 64 MiB, a negative value lifts the limit.
+	MaxRequestSize int64 `json:"maxRequestSize"`
+	// ResultCache keeps the results of the finished games for retrieval on /games/{id}/result. The results are not
+	// kept if not set.
+	ResultCache *ResultCacheConfig `json:"resultCache"`
+	// PlayerCerts provisions the certificates MP-SPDZ secures the channels between the players with into the
+	// preprocessing folder and rotates them. The certificates must be placed there manually if not set.
+	PlayerCerts *PlayerCertsConfig `json:"playerCerts"`
+}
+
+// PlayerCertsConfig specifies where the certificates of the player are obtained from.
+type PlayerCertsConfig struct {
+	// Source is either "secret" to read the certificate from CertFile and KeyFile, e.g., a secret issued by
+	// cert-manager, or "ca" to issue the certificates from the CA in CAFile and CAKeyFile.
+	Source string `json:"source"`
+	// CertFile and KeyFile are the certificate and key of the player for the "secret" source, e.g., the "tls.crt" and
+	// "tls.key" of a mounted secret. The common name of the certificate must be "P<party>".
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+	// CAFile is the CA the certificates of the other players are verified against, e.g., the "ca.crt" of a mounted
+	// secret. For the "ca" source, the certificates are issued from it.
+	CAFile string `json:"caFile"`
+	// CAKeyFile is the key of the CA the certificates are issued from for the "ca" source.
+	CAKeyFile string `json:"caKeyFile"`
+	// Validity is the lifetime of the certificates issued for the "ca" source, e.g., "24h". Defaults to 24 hours.
+	Validity string `json:"validity"`
+	// RenewBefore is the time before their expiry the certificates issued for the "ca" source are renewed. Defaults to
+	// a third of the validity.
+	RenewBefore string `json:"renewBefore"`
+	// RotationInterval is the interval the certificates are checked for changes in, e.g., "1m". Defaults to a minute.
+	RotationInterval string `json:"rotationInterval"`
+}
+
+// ResultCacheConfig specifies where the results of the finished games are kept.
+type ResultCacheConfig struct {
+	// Backend is either "memory", "disk" or "amphora". Defaults to "memory".
+	Backend string `json:"backend"`
+	// MaxResults is the number of results kept in memory, the least recently used ones are dropped first. Defaults to
+	// 100.
+	MaxResults int `json:"maxResults"`
+	// Dir is the directory the disk backend keeps the results in.
+	Dir string `json:"dir"`
+	// TTL is the time the memory and disk backends keep the results for, e.g., "1h". Defaults to one hour. The amphora
+	// backend keeps the results until they are deleted.
+	TTL string `json:"ttl"`
+	// MaxResultSize is the maximum number of bytes of a kept result. Larger results are not kept. Defaults to 16 MiB,
+	// a negative value lifts the limit.
+	MaxResultSize int64 `json:"maxResultSize"`
+}
+
+// SimulatorConfig specifies the plaintext simulator backend.
+type SimulatorConfig struct {
+	// Executable is the emulator of MP-SPDZ executing the programs in cleartext. Defaults to "./emulate.x".
+	Executable string `json:"executable"`
+	// Args are passed to the emulator after the name of the program.
+	Args []string `json:"args"`
+}
+
+// GameWorkDirsConfig specifies where the work directories of the games are created.
+type GameWorkDirsConfig struct {
+	// Root is the directory the work directories are created in. Defaults to "Games" in the MP-SPDZ directory.
+	Root string `json:"root"`
+}
+
+// ProgramAllowListConfig specifies the programs that may be compiled.
+type ProgramAllowListConfig struct {
+	// Hashes are the hex encoded SHA-256 hashes of the source code of the registered programs.
+	Hashes []string `json:"hashes"`
+	// PublicKeys are the base64 encoded Ed25519 public keys trusted to sign programs.
+	PublicKeys []string `json:"publicKeys"`
+}
+
+// ExecutionsArchiveConfig specifies how many executions are kept and where they are exported to.
+type ExecutionsArchiveConfig struct {
+	// MaxEntries is the number of executions kept, the oldest ones are dropped first. Defaults to 10000.
+	MaxEntries int `json:"maxEntries"`
+	// ExportInterval is the interval the executions archived since the last export are exported in, e.g., "1h". The
+	// executions are not exported periodically if not set.
+	ExportInterval string `json:"exportInterval"`
+	// ExportFormat is the format of the periodic exports, either "csv" or "json". Defaults to "csv".
+	ExportFormat string `json:"exportFormat"`
+	// ExportDir is the directory the periodic exports are written to.
+	ExportDir string `json:"exportDir"`
+	// ExportToObjectStore uploads the periodic exports to the object store configured by objectStore instead of
+	// writing them to ExportDir.
+	ExportToObjectStore bool `json:"exportToObjectStore"`
+}
+
+// ReadinessConfig defines the work in progress at which the service reports not to be ready on /ready.
+type ReadinessConfig struct {
+	// NotReadyWhileCompiling reports the service as not ready while a program is compiled.
+	NotReadyWhileCompiling bool `json:"notReadyWhileCompiling"`
+	// MaxGames is the number of games in flight at which the service reports as not ready and rejects further
+	// activations. The number of games is not limited if not set.
+	MaxGames int `json:"maxGames"`
+	// RetryAfter is the duration clients are asked to wait before retrying requests rejected with 503, e.g., "5s".
+	// Defaults to 5 seconds.
+	RetryAfter string `json:"retryAfter"`
+}
+
+// AuthScopesConfig specifies where the scopes granted to a caller are found in its token. The compile scope is required
+// to compile programs, the execute scope to activate the deployed program and the admin scope grants all scopes.
+type AuthScopesConfig struct {
+	// Claim is the path of the claim holding the scopes, e.g., "scope" or "realm_access.roles". The claim may be a
+	// space-separated string or a list of strings. Defaults to "scope".
+	Claim string `json:"claim"`
+}
+
+// ProcessLimitsConfig specifies the resource limits of the MPC runtime of a game, so that a single runaway program
+// cannot exhaust the file descriptors or the disk space the games of a pod share.
+type ProcessLimitsConfig struct {
+	// MaxOpenFiles is the maximum number of files the runtime may open. Not limited if not set.
+	MaxOpenFiles int `json:"maxOpenFiles"`
+	// CoreDumps allows the runtime to write core dumps, which are disabled otherwise.
+	CoreDumps bool `json:"coreDumps"`
+	// TmpDir is the directory a temporary directory is created in for each game. The runtime is started with TMPDIR
+	// set to it and the directory is removed once the game has finished. Defaults to "tmp" in the MP-SPDZ base
+	// directory.
+	TmpDir string `json:"tmpDir"`
+}
+
+// ObjectStoreConfig specifies the bucket outputs are uploaded to. The credentials can also be provided by the
+// EPHEMERAL_OBJECT_STORE_ACCESS_KEY_ID and EPHEMERAL_OBJECT_STORE_SECRET_ACCESS_KEY environment variables, which take
+// precedence.
+type ObjectStoreConfig struct {
+	// Endpoint is the URL of the object store, e.g., "https://s3.eu-central-1.amazonaws.com" or "http://minio:9000".
+	Endpoint string `json:"endpoint"`
+	// Region is the region requests are signed for. Defaults to "us-east-1".
+	Region string `json:"region"`
+	Bucket string `json:"bucket"`
+	// Prefix is prepended to the game ids the outputs are stored under, e.g., "results/".
+	Prefix string `json:"prefix"`
+	// VirtualHostedStyle addresses the bucket as a subdomain of the endpoint instead of in the path.
+	VirtualHostedStyle bool   `json:"virtualHostedStyle"`
+	AccessKeyID        string `json:"accessKeyID"`
+	SecretAccessKey    string `json:"secretAccessKey"`
+	// URLExpiry is the duration the presigned URLs returned to the clients are valid for, e.g., "1h". Defaults to one
+	// hour, must not exceed a week.
+	URLExpiry string `json:"urlExpiry"`
+	// Timeout is the maximum duration of uploading an output, e.g., "1m". Not limited if not set.
+	Timeout string `json:"timeout"`
+}
+
+// MPSPDZConfig defines where MP-SPDZ is installed and how its virtual machines are run.
+type MPSPDZConfig struct {
+	// BaseDir is the directory the compiler and the virtual machines are run in. Defaults to "/mp-spdz".
+	BaseDir string `json:"baseDir"`
+	// IPFile is the file the addresses of the other players are written to. Defaults to "ip-file" in the base
+	// directory.
+	IPFile string `json:"ipFile"`
+	// SourceDir is the directory the programs are written to before being compiled. Defaults to "Programs/Source" in
+	// the base directory.
+	SourceDir string `json:"sourceDir"`
+	// ExtraArgs are passed to the virtual machines of all protocols after the flags of the protocol, e.g.,
+	// ["--batch-size", "1000"].
+	ExtraArgs []string `json:"extraArgs"`
+	// Version is the version of the MP-SPDZ installation, e.g., "0.3.8". It is reported along with the results to make
+	// runs reproducible. Not reported if not set.
+	Version string `json:"version"`
+}
+
+// ProtocolConfig declares an MP-SPDZ protocol games can be executed with.
+type ProtocolConfig struct {
+	// Executable is the virtual machine of the protocol relative to the MP-SPDZ directory, e.g., "./Semi-Party.x".
+	Executable string `json:"executable"`
+	// TupleFamilies are the families of tuples streamed from Castor for the protocol, i.e., Bits, Inputs, Inverses,
+	// Squares and Triples. Protocols generating their preprocessing data during the computation require none.
+	TupleFamilies []string `json:"tupleFamilies"`
+	// Flags are additional command line flags passed to the executable.
+	Flags []string `json:"flags"`
+}
+
+// TracingConfig specifies the OpenTelemetry collector spans are exported to.
+type TracingConfig struct {
+	// Endpoint is the OTLP/HTTP traces endpoint of the collector, e.g., "http://otel-collector:4318/v1/traces".
+	Endpoint string `json:"endpoint"`
+	// ServiceName is the name the spans are reported with. Defaults to "ephemeral".
+	ServiceName string `json:"serviceName"`
+	// Timeout is the maximum duration of exporting a single span, e.g., "5s".
+	Timeout string `json:"timeout"`
+}
+
+// NotifierConfig specifies a notifier informed about finished games.
+type NotifierConfig struct {
+	// Type is the kind of notifier. Only "webhook" is supported.
+	Type string `json:"type"`
+	// URL is the endpoint the events are sent to.
+	URL string `json:"url"`
+	// Timeout is the maximum duration of sending a single event, e.g., "5s".
+	Timeout string `json:"timeout"`
+	// Events are the names of the events to notify about, i.e., GameSucceeded and GameFailed. All events are sent if
+	// not set.
+	Events []string `json:"events"`
+}
+
+// AuditSinkConfig specifies a sink the audit records of the games are written to.
+type AuditSinkConfig struct {
+	// Type is the kind of sink, either "file" or "webhook".
+	Type string `json:"type"`
+	// Path is the file the records are appended to, one JSON object per line. Used by the file sink only.
+	Path string `json:"path"`
+	// URL is the endpoint the records are posted to. Used by the webhook sink only.
+	URL string `json:"url"`
+	// Timeout is the maximum duration of posting a single record, e.g., "5s". Used by the webhook sink only.
+	Timeout string `json:"timeout"`
+}
+
+// QuotaConfig specifies the usage limits per authenticated user.
+type QuotaConfig struct {
+	// ExecutionsPerHour is the number of activations allowed within a sliding window of one hour.
+	ExecutionsPerHour int `json:"executionsPerHour"`
+	// TupleBytesPerDay is the amount of tuple data that may be consumed within a sliding window of one day.
+	TupleBytesPerDay int64 `json:"tupleBytesPerDay"`
+	// StorePath is the file the usage is persisted to. The usage is kept in memory only if not set.
+	StorePath string `json:"storePath"`
 }
 
 type OpaConfig struct {
 	Endpoint      string `json:"endpoint"`
 	PolicyPackage string `json:"policyPackage"`
+	// Admission evaluates the execute policy before an activation is accepted and rejects it if denied. The policy is
+	// only evaluated once the inputs have been read from Amphora if not set.
+	Admission bool `json:"admission"`
+	// DecisionCacheTTL is the time admission decisions are cached for, e.g., "1m". Decisions are not cached if not set.
+	DecisionCacheTTL string `json:"decisionCacheTTL"`
+	// DecisionCacheSize is the number of admission decisions cached. Defaults to 1000.
+	DecisionCacheSize int `json:"decisionCacheSize"`
 }
 
 // AmphoraConfig specifies the amphora host parameters.
@@ -129,6 +809,15 @@ type AmphoraConfig struct {
 	Host   string `json:"host"`
 	Scheme string `json:"scheme"`
 	Path   string `json:"path"`
+	// Retry defines how failed reads of secret shares are retried. They are not retried if not set.
+	Retry *RetryConfig `json:"retry"`
+	// RequestTimeout limits the duration of a single request, e.g., "10s". Requests are not limited if not set.
+	RequestTimeout string `json:"requestTimeout"`
+	// MaxIdleConns is the number of idle connections kept to Amphora for reuse. Defaults to 2 if not set.
+	MaxIdleConns int `json:"maxIdleConns"`
+	// Bulk reads the input secrets and creates the output secrets of a game with a single request each, which
+	// requires an Amphora providing the bulk endpoints.
+	Bulk bool `json:"bulk"`
 }
 
 // CastorConfig specifies the castor host and tuple stock parameters.
@@ -137,13 +826,147 @@ type CastorConfig struct {
 	Scheme     string `json:"scheme"`
 	Path       string `json:"path"`
 	TupleStock int32  `json:"tupleStock"`
+	// Retry defines how failed tuple requests are retried. They are not retried if not set.
+	Retry *RetryConfig `json:"retry"`
+	// RequestTimeout limits the duration of a single tuple request, e.g., "10s". Requests are not limited if not set.
+	RequestTimeout string `json:"requestTimeout"`
+	// CircuitBreaker stops requesting tuples for a while once castor failed repeatedly, so that games fail fast with a
+	// distinct error. Requests are always sent if not set.
+	CircuitBreaker *CircuitBreakerConfig `json:"circuitBreaker"`
+	// AdaptiveStock scales the number of tuples fetched at a time with the rate MP-SPDZ consumes them. TupleStock
+	// tuples are fetched at a time if not set.
+	AdaptiveStock *AdaptiveStockConfig `json:"adaptiveStock"`
+	// TupleCache keeps the tuples fetched, but not consumed by a game, on disk for later games requesting the same
+	// reservations. Unconsumed tuples are discarded if not set.
+	TupleCache *TupleCacheConfig `json:"tupleCache"`
+	// TupleTypes enables or disables the tuple types of the given castor names, e.g., {"DABIT_GFP": true}. All but the
+	// mixed-circuit tuple types are enabled by default.
+	TupleTypes map[string]bool `json:"tupleTypes"`
+	// EdaBitLength is the bit length of the edaBits requested from castor. Defaults to 64.
+	EdaBitLength int32 `json:"edaBitLength"`
+	// WarmPool keeps the tuple streamers of a game for the next game of the same parameters. Streamers are created for
+	// each game if not set.
+	WarmPool *WarmPoolConfig `json:"warmPool"`
+}
+
+// WarmPoolConfig bounds the tuple streamers kept between games.
+type WarmPoolConfig struct {
+	// MaxStreamers is the maximum number of streamers kept. Defaults to 64.
+	MaxStreamers int `json:"maxStreamers"`
+	// MaxIdle is the maximum time a streamer is kept for. Defaults to 1m.
+	MaxIdle string `json:"maxIdle"`
+}
+
+// TupleCacheConfig defines where and for how long tuples fetched from castor, but not consumed, are kept.
+type TupleCacheConfig struct {
+	// Directory is the directory the tuples are kept in. It is created if it does not exist.
+	Directory string `json:"directory"`
+	// TTL is the time the tuples are kept for. Defaults to 1h.
+	TTL string `json:"ttl"`
+}
+
+// AdaptiveStockConfig defines how the tuple streamers adapt the number of tuples fetched at a time. The tuples are
+// requested from castor in chunks of a fixed size, only the number of chunks fetched at a time is adapted. This way
+// all players request the same tuples, regardless of how fast their computation progresses.
+type AdaptiveStockConfig struct {
+	// ChunkSize is the number of tuples requested from castor at once. Defaults to the tuple stock.
+	ChunkSize int32 `json:"chunkSize"`
+	// MaxChunks is the maximum number of chunks fetched at a time. Defaults to 16.
+	MaxChunks int32 `json:"maxChunks"`
+	// MaxConcurrentFetches is the maximum number of chunks requested from castor concurrently. Defaults to 4.
+	MaxConcurrentFetches int `json:"maxConcurrentFetches"`
+	// DoubleBuffering keeps two batches of tuples ready instead of one.
+	DoubleBuffering bool `json:"doubleBuffering"`
+}
+
+// EgressLimitConfig defines the token bucket limiting the rate data is sent to another player at.
+type EgressLimitConfig struct {
+	// BytesPerSecond is the sustained rate.
+	BytesPerSecond int64 `json:"bytesPerSecond"`
+	// Burst is the amount of data that may be sent at once after a pause. Defaults to BytesPerSecond if not set.
+	Burst int64 `json:"burst"`
+}
+
+// ProxyCompressionConfig defines the compression of the traffic between the proxies of the players with DEFLATE.
+type ProxyCompressionConfig struct {
+	// Level is the compression level between 1 (fastest) and 9 (best compression). Defaults to 1.
+	Level int `json:"level"`
+	// ByDefault compresses the traffic of the games which do not request or refuse compression explicitly.
+	ByDefault bool `json:"byDefault"`
+	// InternalPortOffset is added to the port MP-SPDZ listens on for the other players when the traffic is compressed,
+	// as the proxy receives the compressed traffic on the regular port. Defaults to 20000.
+	InternalPortOffset int32 `json:"internalPortOffset"`
+}
+
+// ColocationConfig identifies the cluster a player runs in and the address it is reached at by the players in the same
+// cluster.
+type ColocationConfig struct {
+	// Cluster is the name of the cluster, which must be the same for all VCPs deployed to the cluster.
+	Cluster string `json:"cluster"`
+	// PodIP is the address of the pod, e.g., exposed by the downward API via EPHEMERAL_COLOCATION_POD_IP.
+	PodIP string `json:"podIP"`
+}
+
+// InsecurePreprocessingConfig defines the generation of fake preprocessing data with MP-SPDZ's Fake-Offline.x. The
+// generated data is not secure, hence the mode is meant for development clusters only.
+type InsecurePreprocessingConfig struct {
+	Enabled bool `json:"enabled"`
+	// TupleCount is the number of tuples generated per type and game. Defaults to 10000 if not set.
+	TupleCount int `json:"tupleCount"`
+	// Fallback proposes fake preprocessing data only for the games started while Castor is unreachable, and fetches
+	// the tuples from Castor otherwise. The game generates fake preprocessing data if any of its players proposed it.
+	// Castor is not used at all if no Castor host is configured.
+	Fallback bool `json:"fallback"`
 }
 
+// RetryConfig defines how failed requests are retried, see retry.Spec.
+type RetryConfig = retry.Spec
+
+// CircuitBreakerConfig defines when requests to a failing service are stopped for a while, see retry.BreakerSpec.
+type CircuitBreakerConfig = retry.BreakerSpec
+
 // Config contains TCP connection properties of Carrier.
 type DiscoveryClientConfig struct {
 	Port           string `json:"port"`
 	Host           string `json:"host"`
 	ConnectTimeout string `json:"connectTimeout"`
+	// Fallbacks is a prioritized list of endpoints that are tried in order if the primary endpoint given by Host and
+	// Port cannot be reached.
+	Fallbacks []DiscoveryEndpoint `json:"fallbacks"`
+	// TLS secures the connection to the discovery service. The connection is not encrypted if not set.
+	TLS *TLSConfig `json:"tls"`
+	// Namespace is the Kubernetes namespace the players run in. The discovery service assigns the ports of their
+	// networks from the port ranges of the namespace, if any.
+	Namespace string `json:"namespace"`
+	// Token is presented to the discovery service to authenticate the players.
+	Token *TokenConfig `json:"token"`
+	// ReconnectTimeout is the maximum duration of reconnecting to the discovery service once the connection of a
+	// player terminated, e.g., "30s". Defaults to 30 seconds.
+	ReconnectTimeout string `json:"reconnectTimeout"`
+	// EventNamespace is the event namespace the players connect to the discovery service with, e.g., the name of the
+	// virtual cloud. It is required if the discovery service serves several virtual clouds.
+	EventNamespace string `json:"eventNamespace"`
+}
+
+// TLSConfig specifies the certificates used to secure the connections of the discovery transport.
+type TLSConfig struct {
+	// CertFile and KeyFile are the PEM encoded certificate and private key presented to the peer. They are required
+	// by servers and presented by clients for mutual TLS.
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+	// CAFile is the PEM encoded bundle of CAs the certificate of the peer is verified against. Servers require clients
+	// to present a certificate if set, clients fall back to the system roots if not set.
+	CAFile string `json:"caFile"`
+	// AllowedSANs restricts the peers to those whose certificate contains one of the given URI or DNS SANs, e.g.,
+	// SPIFFE IDs like "spiffe://vcp-2.carbynestack.io/discovery". Clients verify the SANs instead of the host name of
+	// the server if set. Servers require CAFile to be set along with it.
+	AllowedSANs []string `json:"allowedSANs"`
+}
+
+// DiscoveryEndpoint is a single discovery service endpoint a client can connect to.
+type DiscoveryEndpoint struct {
+	Host string `json:"host"`
+	Port string `json:"port"`
 }
 
 // DiscoveryClientTypedConfig reflects DiscoveryClientConfig, but it contains the real property types.
@@ -151,11 +974,30 @@ type DiscoveryClientTypedConfig struct {
 	Port           string
 	Host           string
 	ConnectTimeout time.Duration
+	Fallbacks      []DiscoveryEndpoint
+	TLS            *TLSConfig
+	Namespace      string
+	Token          *TokenConfig
+	// ReconnectTimeout is zero if the default timeout applies.
+	ReconnectTimeout time.Duration
+	EventNamespace   string
 }
 
 // OutputConfig defines how the output of the app execution is treated.
 type OutputConfig struct {
 	Type string `json:"type"`
+	// Schema declares the named outputs of the program in the order they are written to the client connection. The
+	// output is split accordingly and, for AmphoraSecret outputs, stored in one secret per named output. The output is
+	// returned as a whole if not set.
+	Schema []OutputField `json:"schema,omitempty"`
+}
+
+// OutputField is a named output of a program.
+type OutputField struct {
+	// Name identifies the output in the result. It must be unique within the schema.
+	Name string `json:"name"`
+	// Count is the number of values the output consists of.
+	Count int `json:"count"`
 }
 
 // SPDZEngineTypedConfig reflects SPDZEngineConfig, but it contains the real property types.
@@ -183,4 +1025,151 @@ type SPDZEngineTypedConfig struct {
 	DiscoveryConfig         DiscoveryClientTypedConfig
 	StateTimeout            time.Duration
 	ComputationTimeout      time.Duration
+	StateTimeouts           map[string]time.Duration
+	AllowPartialResults     bool
+	ProxyPortRange          string
+	ProxyReusePort          bool
+	EgressLimit             *EgressLimitConfig
+	ProxyCompression        *ProxyCompressionConfig
+	TlsEnabled              bool
+	Colocation              *ColocationConfig
+	CompileCacheSize        int
+	AcceptedContentTypes    []string
+	// Quota enforces the usage limits per authenticated user. Nil if no limits are configured.
+	Quota *quota.Tracker
+	// Notifications informs external systems about finished games. Nil if no notifiers are configured.
+	Notifications *notify.Dispatcher
+	// OutputStreamBufferSize is the maximum number of bytes of the output read from the MPC runtime at once. Zero if
+	// the output is held in memory as a whole.
+	OutputStreamBufferSize int
+	// Tracer creates the spans of traced activations. Nil if tracing is disabled.
+	Tracer *tracing.Tracer
+	// DrainTimeout is the maximum duration running games are given to finish once the service is terminated.
+	DrainTimeout time.Duration
+	// PreStopTimeout is the maximum duration the shutdown of the service is postponed for on termination while games
+	// are in flight. Zero if the shutdown is not postponed.
+	PreStopTimeout time.Duration
+	// ResultDeliveryTimeout is the maximum duration of delivering the result once the computation has finished.
+	ResultDeliveryTimeout time.Duration
+	// InsecurePreprocessing generates fake preprocessing data instead of fetching tuples from Castor. Nil if disabled.
+	InsecurePreprocessing *InsecurePreprocessingConfig
+	// Auditor writes the audit records of the games. Nil if no audit sinks are configured.
+	Auditor            *audit.Auditor
+	SessionIdleTimeout time.Duration
+	// Protocols are the MP-SPDZ protocols activations may select, by name.
+	Protocols       map[string]ProtocolConfig
+	DefaultProtocol string
+	MaxThreads      int
+	// Gf2nDisabled is set if the gf2n MAC key or bit length is not configured. Neither player data nor tuple streams
+	// are prepared for gf2n then, and programs requiring gf2n tuples are rejected.
+	Gf2nDisabled bool
+	// MPSPDZ defines the MP-SPDZ installation. Unset paths default to the locations in the ephemeral image.
+	MPSPDZ MPSPDZConfig
+	// ObjectStoreClient uploads outputs to the object store. Nil if no object store is configured.
+	ObjectStoreClient objectstore.AbstractClient
+	// PartyNumbers are the MP-SPDZ party numbers of the players, indexed by player ID. Nil if they equal the player IDs.
+	PartyNumbers          []int32
+	InputStreamBufferSize int
+	// ProcessLimits restricts the OS resources of the MPC runtime of each game. Nil if the runtime is not restricted.
+	ProcessLimits *ProcessLimitsConfig
+	// AuthScopes defines the claim holding the scopes of the callers. Nil if scopes are not checked.
+	AuthScopes *AuthScopesConfig
+	// AdaptiveStock defines how the tuple streamers adapt the number of tuples fetched at a time, with the defaults
+	// applied. Nil if TupleStock tuples are fetched at a time.
+	AdaptiveStock *AdaptiveStockConfig
+	// TupleCache keeps the tuples fetched, but not consumed, for later games. Nil if unconsumed tuples are discarded.
+	TupleCache *castor.TupleCache
+	// TupleTypes are the tuple types streamed from castor, with the gf2n ones still subject to Gf2nDisabled. Nil if
+	// the default tuple types are streamed.
+	TupleTypes []castor.TupleType
+	// EdaBitLength is the bit length of the edaBits streamed from castor.
+	EdaBitLength int32
+	// WarmPool bounds the tuple streamers kept between games, with the defaults applied. Nil if streamers are created
+	// for each game.
+	WarmPool *WarmPoolTypedConfig
+	// Readiness defines when the service reports not to be ready, with the defaults applied.
+	Readiness ReadinessTypedConfig
+	// AdmissionPolicy decides whether an activation is accepted, possibly caching the decisions. Nil if activations
+	// are not checked on admission.
+	AdmissionPolicy opa.Decider
+	// ExecutionsArchive keeps the history of the audited executions. Nil if the history is not kept.
+	ExecutionsArchive *archive.Archive
+	// ArchiveExporter exports the history periodically. Nil if it is not exported periodically.
+	ArchiveExporter *archive.Exporter
+	// ProgramAllowList decides which programs may be compiled. Nil if any program may be compiled.
+	ProgramAllowList *allowlist.AllowList
+	// GameWorkDirs specifies the work directories of the games. Nil if the games share the MP-SPDZ directory.
+	GameWorkDirs *GameWorkDirsConfig
+	// Simulator specifies the plaintext simulator backend, with the defaults applied. Nil if it is disabled.
+	Simulator *SimulatorConfig
+	// MaxRequestSize is the maximum number of bytes of the body of the requests, with the default applied. Zero if
+	// unlimited.
+	MaxRequestSize int64
+	// ResultCache keeps the results of the finished games. Nil if the results are not kept.
+	ResultCache results.Store
+	// MaxResultSize is the maximum number of bytes of a kept result, with the default applied. Zero if unlimited.
+	MaxResultSize int64
+	// PlayerCerts keeps the certificates of the player in the preprocessing folder. Nil if they are placed there
+	// manually.
+	PlayerCerts *certs.Provisioner
+}
+
+// WarmPoolTypedConfig reflects WarmPoolConfig, but it contains the real property types.
+type WarmPoolTypedConfig struct {
+	MaxStreamers int
+	MaxIdle      time.Duration
+}
+
+// ReadinessTypedConfig reflects ReadinessConfig, but it contains the real property types.
+type ReadinessTypedConfig struct {
+	NotReadyWhileCompiling bool
+	MaxGames               int
+	RetryAfter             time.Duration
+}
+
+// reloadLock guards the settings of the typed configurations of the running services against concurrent reloads.
+var reloadLock sync.RWMutex
+
+// ReloadableSettings are the settings of SPDZEngineTypedConfig which are changed while the service is running, once the
+// configuration file is updated. Games started before keep the settings they were started with.
+type ReloadableSettings struct {
+	StateTimeout          time.Duration
+	ComputationTimeout    time.Duration
+	StateTimeouts         map[string]time.Duration
+	TupleStock            int32
+	PreStopTimeout        time.Duration
+	ResultDeliveryTimeout time.Duration
+}
+
+// Reload applies the given settings to the configuration. Readers take a snapshot of the configuration to see either
+// the settings before or after the reload, but never a mix of both.
+func (c *SPDZEngineTypedConfig) Reload(settings *ReloadableSettings) {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+	c.StateTimeout = settings.StateTimeout
+	c.ComputationTimeout = settings.ComputationTimeout
+	c.StateTimeouts = settings.StateTimeouts
+	c.TupleStock = settings.TupleStock
+	c.PreStopTimeout = settings.PreStopTimeout
+	c.ResultDeliveryTimeout = settings.ResultDeliveryTimeout
+}
+
+// Snapshot returns a copy of the configuration which is not affected by later reloads.
+func (c *SPDZEngineTypedConfig) Snapshot() *SPDZEngineTypedConfig {
+	if c == nil {
+		return nil
+	}
+	reloadLock.RLock()
+	defer reloadLock.RUnlock()
+	snapshot := *c
+	return &snapshot
+}
+
+// PartyNumber returns the MP-SPDZ party number of the player with the given ID. The party number determines the
+// position of the player in the ip file, the names of its MAC key and tuple files and the port inputs are fed on.
+func (c *SPDZEngineTypedConfig) PartyNumber(playerID int32) int32 {
+	if playerID >= 0 && int(playerID) < len(c.PartyNumbers) {
+		return c.PartyNumbers[playerID]
+	}
+	return playerID
 }
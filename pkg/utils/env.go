@@ -0,0 +1,135 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ApplyEnvOverrides sets the fields of the struct conf points to from the environment variables named after the prefix
+// and the JSON names of the fields, e.g., EPHEMERAL_CASTOR_CONFIG_TUPLE_STOCK for castorConfig.tupleStock. Strings,
+// booleans and numbers are given as is, values of other types, e.g., lists, as JSON. Nested structs can be overridden
+// as a whole, as JSON, or field by field. The variables are looked up using lookup, e.g., os.LookupEnv.
+func ApplyEnvOverrides(conf interface{}, prefix string, lookup func(string) (string, bool)) error {
+	v := reflect.ValueOf(conf)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expected a pointer to a struct, got %T", conf)
+	}
+	_, err := applyStructOverrides(v.Elem(), prefix, lookup)
+	return err
+}
+
+// applyStructOverrides applies the overrides to the fields of the given struct and returns whether any were applied.
+func applyStructOverrides(v reflect.Value, prefix string, lookup func(string) (string, bool)) (bool, error) {
+	applied := false
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		ok, err := applyOverride(v.Field(i), prefix+"_"+EnvName(name), lookup)
+		if err != nil {
+			return false, err
+		}
+		applied = applied || ok
+	}
+	return applied, nil
+}
+
+// applyOverride sets the given value from the variable with the given name, or, for structs, from the variables of
+// their fields. Returns whether an override was applied.
+func applyOverride(v reflect.Value, name string, lookup func(string) (string, bool)) (bool, error) {
+	if value, ok := lookup(name); ok {
+		if err := setValue(v, value); err != nil {
+			return false, fmt.Errorf("invalid value of %s: %v", name, err)
+		}
+		return true, nil
+	}
+	switch {
+	case v.Kind() == reflect.Struct:
+		return applyStructOverrides(v, name, lookup)
+	case v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct:
+		// Unset structs are only created if any of their fields is overridden.
+		target := v
+		if v.IsNil() {
+			target = reflect.New(v.Type().Elem())
+		}
+		ok, err := applyStructOverrides(target.Elem(), name, lookup)
+		if ok && v.IsNil() {
+			v.Set(target)
+		}
+		return ok, err
+	}
+	return false, nil
+}
+
+// setValue parses the given value according to the kind of v and assigns it.
+func setValue(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		target := reflect.New(v.Type())
+		if err := json.Unmarshal([]byte(value), target.Interface()); err != nil {
+			return err
+		}
+		v.Set(target.Elem())
+	}
+	return nil
+}
+
+// EnvName converts the JSON name of a field to the upper snake case used in the names of environment variables, e.g.,
+// "frontendURL" to "FRONTEND_URL".
+func EnvName(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextIsLower {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package utils_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/carbynestack/ephemeral/pkg/utils"
+)
+
+var _ = Describe("Env", func() {
+	type limits struct {
+		MaxOpenFiles int  `json:"maxOpenFiles"`
+		CoreDumps    bool `json:"coreDumps"`
+	}
+	type nested struct {
+		Host       string `json:"host"`
+		TupleStock int32  `json:"tupleStock"`
+	}
+	type config struct {
+		FrontendURL  string            `json:"frontendURL"`
+		PlayerID     int32             `json:"playerID"`
+		CastorConfig nested            `json:"castorConfig"`
+		Limits       *limits           `json:"processLimits"`
+		PartyNumbers []int32           `json:"partyNumbers"`
+		Timeouts     map[string]string `json:"stateTimeouts"`
+		Ignored      string            `json:"-"`
+	}
+	var env map[string]string
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	BeforeEach(func() {
+		env = map[string]string{}
+	})
+
+	It("converts JSON names to environment variable names", func() {
+		Expect(EnvName("frontendURL")).To(Equal("FRONTEND_URL"))
+		Expect(EnvName("gf2nMacKey")).To(Equal("GF2N_MAC_KEY"))
+		Expect(EnvName("playerID")).To(Equal("PLAYER_ID"))
+		Expect(EnvName("rInv")).To(Equal("R_INV"))
+	})
+	It("overrides fields of all kinds", func() {
+		env["EPHEMERAL_FRONTEND_URL"] = "apollo.example.com"
+		env["EPHEMERAL_PLAYER_ID"] = "1"
+		env["EPHEMERAL_CASTOR_CONFIG_TUPLE_STOCK"] = "500"
+		env["EPHEMERAL_PARTY_NUMBERS"] = "[1, 0]"
+		env["EPHEMERAL_STATE_TIMEOUTS"] = `{"Playing": "1m"}`
+		conf := &config{FrontendURL: "starbuck.example.com", CastorConfig: nested{Host: "castor"}}
+		Expect(ApplyEnvOverrides(conf, "EPHEMERAL", lookup)).To(Succeed())
+		Expect(conf).To(Equal(&config{
+			FrontendURL:  "apollo.example.com",
+			PlayerID:     1,
+			CastorConfig: nested{Host: "castor", TupleStock: 500},
+			PartyNumbers: []int32{1, 0},
+			Timeouts:     map[string]string{"Playing": "1m"},
+		}))
+	})
+	It("creates unset structs only if one of their fields is overridden", func() {
+		conf := &config{}
+		Expect(ApplyEnvOverrides(conf, "EPHEMERAL", lookup)).To(Succeed())
+		Expect(conf.Limits).To(BeNil())
+		env["EPHEMERAL_PROCESS_LIMITS_CORE_DUMPS"] = "true"
+		Expect(ApplyEnvOverrides(conf, "EPHEMERAL", lookup)).To(Succeed())
+		Expect(conf.Limits).To(Equal(&limits{CoreDumps: true}))
+	})
+	It("overrides structs as a whole", func() {
+		env["EPHEMERAL_PROCESS_LIMITS"] = `{"maxOpenFiles": 64}`
+		conf := &config{}
+		Expect(ApplyEnvOverrides(conf, "EPHEMERAL", lookup)).To(Succeed())
+		Expect(conf.Limits).To(Equal(&limits{MaxOpenFiles: 64}))
+	})
+	It("fails for invalid values", func() {
+		env["EPHEMERAL_PLAYER_ID"] = "first"
+		err := ApplyEnvOverrides(&config{}, "EPHEMERAL", lookup)
+		Expect(err).To(MatchError(ContainSubstring("invalid value of EPHEMERAL_PLAYER_ID")))
+	})
+})
@@ -8,10 +8,12 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"syscall"
 )
 
 // Executor is an interface for calling a command and process its output.
@@ -20,6 +22,14 @@ type Executor interface {
 	CallCMD(ctx context.Context, cmd []string, dir string) ([]byte, []byte, error)
 }
 
+// StreamingExecutor is an Executor which is able to pass the output of a command on while the command runs.
+type StreamingExecutor interface {
+	Executor
+	// CallCMDWithOutput executes the command like CallCMD and additionally copies its STDOUT and STDERR to the given
+	// writers while it runs. Nil writers are ignored.
+	CallCMDWithOutput(ctx context.Context, cmd []string, dir string, stdout io.Writer, stderr io.Writer) ([]byte, []byte, error)
+}
+
 var (
 	defaultCommand = "script"
 	defaultOptions = []string{"-e", "-q", "-c"}
@@ -53,18 +63,39 @@ func (c *Commander) Run(cmd string) ([]byte, []byte, error) {
 // If the command fails to run or doesn't complete successfully, the error is of type *ExitError. Other error types may be returned for I/O problems.
 // ```
 func (c *Commander) CallCMD(ctx context.Context, cmd []string, dir string) ([]byte, []byte, error) {
+	return c.CallCMDWithOutput(ctx, cmd, dir, nil, nil)
+}
+
+// CallCMDWithOutput calls a specified command like CallCMD and copies its stdout and stderr to the given writers while
+// it runs. The command runs in its own process group, which is killed once the context is done, so that the processes
+// started by the command, e.g., the MPC runtime, are terminated along with it.
+func (c *Commander) CallCMDWithOutput(ctx context.Context, cmd []string, dir string, stdout io.Writer, stderr io.Writer) ([]byte, []byte, error) {
 	baseCmd := c.Options
 	baseCmd = append(baseCmd, cmd...)
-	command := exec.CommandContext(ctx, c.Command, baseCmd...)
+	command := exec.Command(c.Command, baseCmd...)
 	stderrBuffer := bytes.NewBuffer([]byte{})
 	stdoutBuffer := bytes.NewBuffer([]byte{})
-	command.Stderr = stderrBuffer
-	command.Stdout = stdoutBuffer
+	command.Stderr = teeWriter(stderrBuffer, stderr)
+	command.Stdout = teeWriter(stdoutBuffer, stdout)
 	command.Dir = dir
+	command.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
 	err := command.Start()
 	if err != nil {
 		return nil, nil, err
 	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// The negative pid addresses the process group led by the command.
+			_ = syscall.Kill(-command.Process.Pid, syscall.SIGKILL)
+		case <-done:
+		}
+	}()
 	err = command.Wait()
 	if err != nil {
 		switch err.(type) {
@@ -77,6 +108,14 @@ func (c *Commander) CallCMD(ctx context.Context, cmd []string, dir string) ([]by
 	return stdoutBuffer.Bytes(), stderrBuffer.Bytes(), nil
 }
 
+// teeWriter returns a writer writing to the buffer and to w, if set.
+func teeWriter(buffer *bytes.Buffer, w io.Writer) io.Writer {
+	if w == nil {
+		return buffer
+	}
+	return io.MultiWriter(buffer, w)
+}
+
 // ReadFile reads file content for a given file location.
 func ReadFile(path string) ([]byte, error) {
 	str, err := filepath.EvalSymlinks(path)
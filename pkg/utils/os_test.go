@@ -5,6 +5,7 @@
 package utils_test
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io/ioutil"
@@ -36,6 +37,32 @@ var _ = Describe("OS utils", func() {
 			Expect(err).To(BeNil())
 			Expect(string(resp)).To(Equal("1\n"))
 		})
+		It("copies its output to the given writers while it runs", func() {
+			cmder := Commander{
+				Command: "bash",
+				Options: []string{"-c"},
+			}
+			var stdout, stderr bytes.Buffer
+			resp, errResp, err := cmder.CallCMDWithOutput(context.TODO(), []string{"echo 1; echo 2 >&2"}, "./", &stdout, &stderr)
+			Expect(err).To(BeNil())
+			Expect(string(resp)).To(Equal("1\n"))
+			Expect(string(errResp)).To(Equal("2\n"))
+			Expect(stdout.String()).To(Equal("1\n"))
+			Expect(stderr.String()).To(Equal("2\n"))
+		})
+		It("kills the processes started by it once the context is done", func() {
+			cmder := Commander{
+				Command: "bash",
+				Options: []string{"-c"},
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			// The background process keeps the output open, hence the call returns only once it has been killed, too.
+			_, _, err := cmder.CallCMD(ctx, []string{"sleep 10 & sleep 10"}, "./")
+			Expect(err).To(HaveOccurred())
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		})
 	})
 	Context("when an error occurs executing a command", func() {
 		Context("when the command returns an error to stderr", func() {
@@ -0,0 +1,69 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package utils
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// FileWatcher polls a file and reports changes of its content. In contrast to watching the file system for events,
+// polling also picks up mounted ConfigMaps, which Kubernetes updates by swapping symlinks.
+type FileWatcher struct {
+	Path     string
+	Interval time.Duration
+	// OnChange is called with the new content of the file whenever it changes.
+	OnChange func([]byte)
+	// OnError is called if the file cannot be read. Errors are ignored if not set.
+	OnError func(error)
+}
+
+// Run polls the file until stop is closed. Changes are reported relative to the content of the file when Run is called.
+func (w *FileWatcher) Run(stop <-chan struct{}) {
+	last, _ := ReadFile(w.Path)
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		content, err := ReadFile(w.Path)
+		if err != nil {
+			if w.OnError != nil {
+				w.OnError(err)
+			}
+			continue
+		}
+		if bytes.Equal(content, last) {
+			continue
+		}
+		last = content
+		w.OnChange(content)
+	}
+}
+
+// ChangedFields returns the names of the fields whose values differ in the given structs of the same type, or pointers
+// to them. The names are taken from the JSON tags of the fields, if any.
+func ChangedFields(old interface{}, new interface{}) []string {
+	o := reflect.Indirect(reflect.ValueOf(old))
+	n := reflect.Indirect(reflect.ValueOf(new))
+	var changed []string
+	for i := 0; i < o.NumField(); i++ {
+		if reflect.DeepEqual(o.Field(i).Interface(), n.Field(i).Interface()) {
+			continue
+		}
+		field := o.Type().Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			name = field.Name
+		}
+		changed = append(changed, name)
+	}
+	return changed
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2026 - for information on the respective copyright owner
+// see the NOTICE file and/or the repository https://github.com/carbynestack/ephemeral.
+//
+// SPDX-License-Identifier: Apache-2.0
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/carbynestack/ephemeral/pkg/utils"
+)
+
+var _ = Describe("Watch", func() {
+	Context("when watching a file", func() {
+		var (
+			dir     string
+			path    string
+			changes chan string
+			errs    chan error
+			stop    chan struct{}
+		)
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "watch")
+			Expect(err).NotTo(HaveOccurred())
+			path = filepath.Join(dir, "config.json")
+			Expect(ioutil.WriteFile(path, []byte("a"), 0644)).To(Succeed())
+			changes = make(chan string, 10)
+			errs = make(chan error, 10)
+			stop = make(chan struct{})
+			w := &FileWatcher{
+				Path:     path,
+				Interval: 10 * time.Millisecond,
+				OnChange: func(content []byte) { changes <- string(content) },
+				OnError:  func(err error) { errs <- err },
+			}
+			go w.Run(stop)
+		})
+		AfterEach(func() {
+			close(stop)
+			os.RemoveAll(dir)
+		})
+		It("reports changes of the content", func() {
+			Consistently(changes, 50*time.Millisecond).ShouldNot(Receive())
+			Expect(ioutil.WriteFile(path, []byte("b"), 0644)).To(Succeed())
+			Eventually(changes).Should(Receive(Equal("b")))
+			Consistently(changes, 50*time.Millisecond).ShouldNot(Receive())
+		})
+		It("follows replaced symlinks", func() {
+			Consistently(changes, 50*time.Millisecond).ShouldNot(Receive())
+			target := filepath.Join(dir, "target.json")
+			Expect(ioutil.WriteFile(target, []byte("c"), 0644)).To(Succeed())
+			link := filepath.Join(dir, "link")
+			Expect(os.Symlink(target, link)).To(Succeed())
+			Expect(os.Rename(link, path)).To(Succeed())
+			Eventually(changes).Should(Receive(Equal("c")))
+		})
+		It("reports files that cannot be read", func() {
+			Expect(os.Remove(path)).To(Succeed())
+			Eventually(errs).Should(Receive())
+		})
+	})
+	Context("when comparing structs", func() {
+		type config struct {
+			Timeout string   `json:"timeout"`
+			Ranges  []string `json:"ranges,omitempty"`
+			Level   string
+		}
+		It("returns the names of the changed fields", func() {
+			old := config{Timeout: "1s", Ranges: []string{"1:2"}, Level: "info"}
+			new := config{Timeout: "2s", Ranges: []string{"1:2"}, Level: "debug"}
+			Expect(ChangedFields(&old, &new)).To(Equal([]string{"timeout", "Level"}))
+		})
+		It("returns nothing for equal structs", func() {
+			Expect(ChangedFields(config{Ranges: []string{"1:2"}}, config{Ranges: []string{"1:2"}})).To(BeEmpty())
+		})
+	})
+})